@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"github.com/bitlum/connector/crpc"
 	"github.com/go-errors/errors"
 	"github.com/golang/protobuf/jsonpb"
@@ -26,6 +28,27 @@ func printRespJSON(resp proto.Message) {
 	fmt.Println(jsonStr)
 }
 
+var getInfoCommand = cli.Command{
+	Name:     "getinfo",
+	Category: "Info",
+	Usage:    "Return the state of connectors and their daemons.",
+	Action:   getInfo,
+}
+
+func getInfo(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	ctxb := context.Background()
+	resp, err := client.GetInfo(ctxb, &crpc.GetInfoRequest{})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
 var createReceiptCommand = cli.Command{
 	Name:     "createreceipt",
 	Category: "Receipt",
@@ -328,6 +351,11 @@ var estimateFeeCommand = cli.Command{
 				" or lightning network invoice. If receipt is specified the " +
 				"number are more accurate for lightning network media",
 		},
+		cli.StringFlag{
+			Name: "priority",
+			Usage: "(optional) Priority determines how fast the transaction" +
+				" should be confirmed: 'fast', 'normal' or 'economy'.",
+		},
 	},
 	Action: estimateFee,
 }
@@ -389,12 +417,84 @@ func estimateFee(ctx *cli.Context) error {
 		receipt = ctx.String("receipt")
 	}
 
+	priority, err := parsePriority(ctx.String("priority"))
+	if err != nil {
+		return err
+	}
+
 	ctxb := context.Background()
 	resp, err := client.EstimateFee(ctxb, &crpc.EstimateFeeRequest{
-		Asset:   asset,
-		Media:   media,
-		Amount:  amount,
-		Receipt: receipt,
+		Asset:    asset,
+		Media:    media,
+		Amount:   amount,
+		Receipt:  receipt,
+		Priority: priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+// parsePriority converts priority name to the proto enum, empty name means
+// that priority isn't specified.
+func parsePriority(name string) (crpc.FeePriority, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return crpc.FeePriority_PRIORITY_NONE, nil
+	case "fast":
+		return crpc.FeePriority_FAST, nil
+	case "normal":
+		return crpc.FeePriority_NORMAL, nil
+	case "economy":
+		return crpc.FeePriority_ECONOMY, nil
+	default:
+		return 0, errors.Errorf("invalid priority %v, supported priorities "+
+			"are: 'fast', 'normal', 'economy'", name)
+	}
+}
+
+var getFeeRatesCommand = cli.Command{
+	Name:     "getfeerates",
+	Category: "Fee",
+	Usage:    "Returns current fee rates of the priorities.",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "asset",
+			Usage: "(optional) Asset is an acronym of the crypto currency, " +
+				"if not specified fee rates of all assets are returned",
+		},
+	},
+	Action: getFeeRates,
+}
+
+func getFeeRates(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var asset crpc.Asset
+	if ctx.IsSet("asset") {
+		stringAsset := strings.ToLower(ctx.String("asset"))
+		switch stringAsset {
+		case "btc", "bitcoin":
+			asset = crpc.Asset_BTC
+		case "bch", "bitcoincash":
+			asset = crpc.Asset_BCH
+		case "ltc", "litecoin":
+			asset = crpc.Asset_LTC
+		case "dash":
+			asset = crpc.Asset_DASH
+		default:
+			return errors.Errorf("invalid asset %v, supported assets"+
+				"are: 'btc', 'bch', 'dash', 'ltc'", stringAsset)
+		}
+	}
+
+	ctxb := context.Background()
+	resp, err := client.GetFeeRates(ctxb, &crpc.GetFeeRatesRequest{
+		Asset: asset,
 	})
 	if err != nil {
 		return err
@@ -428,6 +528,16 @@ var sendPaymentCommand = cli.Command{
 			Usage: "Receipt is either blockchain address or lightning network" +
 				" invoice which identifies the receiver of the payment.",
 		},
+		cli.StringFlag{
+			Name: "idempotency_key",
+			Usage: "(optional) Idempotency key is the unique key which is " +
+				"used to safely retry the request without sending payment twice.",
+		},
+		cli.StringFlag{
+			Name: "priority",
+			Usage: "(optional) Priority determines how fast the transaction" +
+				" should be confirmed: 'fast', 'normal' or 'economy'.",
+		},
 	},
 	Action: sendPayment,
 }
@@ -496,12 +606,19 @@ func sendPayment(ctx *cli.Context) error {
 		return errors.Errorf("receipt argument is missing")
 	}
 
+	priority, err := parsePriority(ctx.String("priority"))
+	if err != nil {
+		return err
+	}
+
 	ctxb := context.Background()
 	resp, err := client.SendPayment(ctxb, &crpc.SendPaymentRequest{
-		Asset:   asset,
-		Media:   media,
-		Amount:  amount,
-		Receipt: receipt,
+		Asset:          asset,
+		Media:          media,
+		Amount:         amount,
+		Receipt:        receipt,
+		IdempotencyKey: ctx.String("idempotency_key"),
+		Priority:       priority,
 	})
 	if err != nil {
 		return err
@@ -511,74 +628,80 @@ func sendPayment(ctx *cli.Context) error {
 	return nil
 }
 
-var paymentByIDCommand = cli.Command{
-	Name:     "paymentbyid",
+var sendPaymentsCommand = cli.Command{
+	Name:     "sendpayments",
 	Category: "Payment",
-	Usage:    "Return payment by the given id",
+	Usage:    "Sends blockchain payments to the several recipients at once",
 	Flags: []cli.Flag{
 		cli.StringFlag{
-			Name: "id",
-			Usage: "ID it is unique identificator of the payment. " +
-				"In case of blockchain media payment id is the transaction" +
-				" id, in case of lightning media it is the payment hash.",
+			Name:  "asset",
+			Usage: "Asset is an acronym of the crypto currency",
+		},
+		cli.StringSliceFlag{
+			Name: "recipient",
+			Usage: "Recipient is the receiver address and the amount in the " +
+				"'address=amount' form, flag could be repeated.",
+		},
+		cli.StringFlag{
+			Name: "idempotency_key",
+			Usage: "(optional) Idempotency key is the unique key which is " +
+				"used to safely retry the request without sending payments twice.",
 		},
 	},
-	Action: paymentByID,
+	Action: sendPayments,
 }
 
-func paymentByID(ctx *cli.Context) error {
+func sendPayments(ctx *cli.Context) error {
 	client, cleanUp := getClient(ctx)
 	defer cleanUp()
 
-	var id string
-
-	if ctx.IsSet("id") {
-		id = ctx.String("id")
-	} else {
-		return errors.Errorf("id argument is missing")
-	}
+	var asset crpc.Asset
 
-	ctxb := context.Background()
-	resp, err := client.PaymentByID(ctxb, &crpc.PaymentByIDRequest{
-		PaymentId: id,
-	})
-	if err != nil {
-		return err
+	switch {
+	case ctx.IsSet("asset"):
+		stringAsset := strings.ToLower(ctx.String("asset"))
+		switch stringAsset {
+		case "btc", "bitcoin":
+			asset = crpc.Asset_BTC
+		case "bch", "bitcoincash":
+			asset = crpc.Asset_BCH
+		case "ltc", "litecoin":
+			asset = crpc.Asset_LTC
+		case "eth", "ethereum":
+			asset = crpc.Asset_ETH
+		case "dash":
+			asset = crpc.Asset_DASH
+		default:
+			return errors.Errorf("invalid asset %v, supported assets"+
+				"are: 'btc', 'bch', 'dash', 'eth', 'ltc'", stringAsset)
+		}
+	default:
+		return errors.Errorf("asset argument missing")
 	}
 
-	printRespJSON(resp)
-	return nil
-}
-
-var paymentByReceiptCommand = cli.Command{
-	Name:     "paymentbyreceipt",
-	Category: "Payment",
-	Usage:    "Return payment by the given receipt",
-	Flags: []cli.Flag{
-		cli.StringFlag{
-			Name: "receipt",
-			Usage: "Receipt is either blockchain address or lightning network" +
-				" invoice which identifies the receiver of the payment.",
-		},
-	},
-	Action: paymentByReceipt,
-}
-
-func paymentByReceipt(ctx *cli.Context) error {
-	client, cleanUp := getClient(ctx)
-	defer cleanUp()
+	var recipients []*crpc.Recipient
+	for _, recipient := range ctx.StringSlice("recipient") {
+		parts := strings.SplitN(recipient, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return errors.Errorf("invalid recipient %v, should be in the "+
+				"'address=amount' form", recipient)
+		}
 
-	var receipt string
+		recipients = append(recipients, &crpc.Recipient{
+			Receipt: parts[0],
+			Amount:  parts[1],
+		})
+	}
 
-	if ctx.IsSet("receipt") {
-		receipt = ctx.String("receipt")
-	} else {
-		return errors.Errorf("receipt argument is missing")
+	if len(recipients) == 0 {
+		return errors.Errorf("recipient argument is missing")
 	}
 
 	ctxb := context.Background()
-	resp, err := client.PaymentsByReceipt(ctxb, &crpc.PaymentsByReceiptRequest{
-		Receipt: receipt,
+	resp, err := client.SendPayments(ctxb, &crpc.SendPaymentsRequest{
+		Asset:          asset,
+		Recipients:     recipients,
+		IdempotencyKey: ctx.String("idempotency_key"),
 	})
 	if err != nil {
 		return err
@@ -588,10 +711,10 @@ func paymentByReceipt(ctx *cli.Context) error {
 	return nil
 }
 
-var listPaymentsCommand = cli.Command{
-	Name:     "listpayments",
+var createPaymentCommand = cli.Command{
+	Name:     "createpayment",
 	Category: "Payment",
-	Usage:    "Return list payments by the given filter parameters",
+	Usage:    "Creates payment, which has to be confirmed or canceled later",
 	Flags: []cli.Flag{
 		cli.StringFlag{
 			Name:  "asset",
@@ -603,38 +726,37 @@ var listPaymentsCommand = cli.Command{
 				" value of underlying asset",
 		},
 		cli.StringFlag{
-			Name: "direction",
-			Usage: "Direction identifies the direction of the payment, " +
-				"(incoming, outgoing).",
+			Name: "amount",
+			Usage: "(optional) Amount is the amount which will be sent by" +
+				" service.",
 		},
 		cli.StringFlag{
-			Name: "status",
-			Usage: "Status is the state of the payment, " +
-				"(waiting, pending, completed, failed).",
+			Name: "receipt",
+			Usage: "Receipt is either blockchain address or lightning network" +
+				" invoice which identifies the receiver of the payment.",
 		},
 		cli.StringFlag{
-			Name: "system",
-			Usage: "System denotes is that payment belongs to business logic" +
-				" of payment server or it was originated by " +
-				"user / third-party service (internal, external).",
+			Name: "idempotency_key",
+			Usage: "(optional) Idempotency key is the unique key which is " +
+				"used to safely retry the request without creating payment twice.",
 		},
 	},
-	Action: listPayments,
+	Action: createPayment,
 }
 
-func listPayments(ctx *cli.Context) error {
+func createPayment(ctx *cli.Context) error {
 	client, cleanUp := getClient(ctx)
 	defer cleanUp()
 
 	var (
-		media     crpc.Media
-		asset     crpc.Asset
-		status    crpc.PaymentStatus
-		direction crpc.PaymentDirection
-		system    crpc.PaymentSystem
+		media   crpc.Media
+		asset   crpc.Asset
+		amount  string
+		receipt string
 	)
 
-	if ctx.IsSet("media") {
+	switch {
+	case ctx.IsSet("media"):
 		stringMedia := ctx.String("media")
 		switch stringMedia {
 		case "bl", "blockchain":
@@ -645,9 +767,12 @@ func listPayments(ctx *cli.Context) error {
 			return errors.Errorf("invalid media type %v, support media type "+
 				"are: 'blockchain' and 'lightning'", stringMedia)
 		}
+	default:
+		return errors.New("media argument missing")
 	}
 
-	if ctx.IsSet("asset") {
+	switch {
+	case ctx.IsSet("asset"):
 		stringAsset := strings.ToLower(ctx.String("asset"))
 		switch stringAsset {
 		case "btc", "bitcoin":
@@ -664,48 +789,641 @@ func listPayments(ctx *cli.Context) error {
 			return errors.Errorf("invalid asset %v, supported assets"+
 				"are: 'btc', 'bch', 'dash', 'eth', 'ltc'", stringAsset)
 		}
+	default:
+		return errors.Errorf("asset argument missing")
 	}
 
-	if ctx.IsSet("status") {
-		stringStatus := strings.ToLower(ctx.String("status"))
-		switch stringStatus {
-		case strings.ToLower(crpc.PaymentStatus_WAITING.String()):
-			status = crpc.PaymentStatus_WAITING
-
-		case strings.ToLower(crpc.PaymentStatus_PENDING.String()):
-			status = crpc.PaymentStatus_PENDING
+	if ctx.IsSet("amount") {
+		amount = ctx.String("amount")
+	} else if media == crpc.Media_BLOCKCHAIN {
+		// In case of blockchain we always should specify amount.
+		// In case of lighnting we might not do that if it specified in the
+		// invoice.
+		return errors.Errorf("amount argument is missing")
+	}
 
-		case strings.ToLower(crpc.PaymentStatus_COMPLETED.String()):
-			status = crpc.PaymentStatus_COMPLETED
+	if ctx.IsSet("receipt") {
+		receipt = ctx.String("receipt")
+	} else {
+		return errors.Errorf("receipt argument is missing")
+	}
 
-		case strings.ToLower(crpc.PaymentStatus_FAILED.String()):
-			status = crpc.PaymentStatus_FAILED
-		default:
-			return errors.Errorf("invalid status %v, supported statuses"+
-				"are: 'waiting', 'pending', 'completed', 'failed'",
-				stringStatus)
-		}
+	ctxb := context.Background()
+	resp, err := client.CreatePayment(ctxb, &crpc.CreatePaymentRequest{
+		Asset:          asset,
+		Media:          media,
+		Amount:         amount,
+		Receipt:        receipt,
+		IdempotencyKey: ctx.String("idempotency_key"),
+	})
+	if err != nil {
+		return err
 	}
 
-	if ctx.IsSet("direction") {
-		stringDirection := strings.ToLower(ctx.String("direction"))
-		switch stringDirection {
+	printRespJSON(resp)
+	return nil
+}
 
-		case strings.ToLower(crpc.PaymentDirection_OUTGOING.String()):
-			direction = crpc.PaymentDirection_OUTGOING
+var confirmPaymentCommand = cli.Command{
+	Name:     "confirmpayment",
+	Category: "Payment",
+	Usage:    "Sends previously created payment",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "id",
+			Usage: "ID is the id of the payment which was returned on creation.",
+		},
+	},
+	Action: confirmPayment,
+}
 
-		case strings.ToLower(crpc.PaymentDirection_INCOMING.String()):
-			direction = crpc.PaymentDirection_INCOMING
+func confirmPayment(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
 
-		default:
-			return errors.Errorf("invalid direction %v, supported direction"+
-				"are: 'incoming', 'outgoing'",
-				stringDirection)
-		}
+	var id string
+
+	if ctx.IsSet("id") {
+		id = ctx.String("id")
+	} else {
+		return errors.Errorf("id argument is missing")
 	}
 
-	if ctx.IsSet("system") {
-		stringSystem := strings.ToLower(ctx.String("system"))
+	ctxb := context.Background()
+	resp, err := client.ConfirmPayment(ctxb, &crpc.ConfirmPaymentRequest{
+		PaymentId: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var cancelPaymentCommand = cli.Command{
+	Name:     "cancelpayment",
+	Category: "Payment",
+	Usage:    "Cancels previously created payment",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "id",
+			Usage: "ID is the id of the payment which was returned on creation.",
+		},
+	},
+	Action: cancelPayment,
+}
+
+func cancelPayment(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var id string
+
+	if ctx.IsSet("id") {
+		id = ctx.String("id")
+	} else {
+		return errors.Errorf("id argument is missing")
+	}
+
+	ctxb := context.Background()
+	resp, err := client.CancelPayment(ctxb, &crpc.CancelPaymentRequest{
+		PaymentId: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var bumpFeeCommand = cli.Command{
+	Name:     "bumpfee",
+	Category: "Payment",
+	Usage: "Replaces transaction of the pending outgoing payment with " +
+		"the one paying bigger fee",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "id",
+			Usage: "ID is the id of the payment which was returned on creation.",
+		},
+		cli.StringFlag{
+			Name: "fee_rate",
+			Usage: "(optional) Fee rate of the replacement transaction in " +
+				"the smallest units per byte, if not specified fee rate of " +
+				"the configured bump priority is used.",
+		},
+	},
+	Action: bumpFee,
+}
+
+func bumpFee(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var id string
+
+	if ctx.IsSet("id") {
+		id = ctx.String("id")
+	} else {
+		return errors.Errorf("id argument is missing")
+	}
+
+	ctxb := context.Background()
+	resp, err := client.BumpFee(ctxb, &crpc.BumpFeeRequest{
+		PaymentId: id,
+		FeeRate:   ctx.String("fee_rate"),
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var createPSBTPaymentCommand = cli.Command{
+	Name:     "createpsbtpayment",
+	Category: "Payment",
+	Usage: "Creates blockchain payment from the cold storage, which " +
+		"transaction has to be signed offline",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "asset",
+			Usage: "Asset is an acronym of the crypto currency",
+		},
+		cli.StringFlag{
+			Name:  "amount",
+			Usage: "Amount is the amount which will be sent by service.",
+		},
+		cli.StringFlag{
+			Name:  "receipt",
+			Usage: "Receipt is the blockchain address of the receiver.",
+		},
+		cli.StringFlag{
+			Name: "idempotency_key",
+			Usage: "(optional) Idempotency key is the unique key which is " +
+				"used to safely retry the request without creating payment twice.",
+		},
+	},
+	Action: createPSBTPayment,
+}
+
+func createPSBTPayment(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var (
+		asset   crpc.Asset
+		amount  string
+		receipt string
+	)
+
+	switch {
+	case ctx.IsSet("asset"):
+		stringAsset := strings.ToLower(ctx.String("asset"))
+		switch stringAsset {
+		case "btc", "bitcoin":
+			asset = crpc.Asset_BTC
+		case "ltc", "litecoin":
+			asset = crpc.Asset_LTC
+		default:
+			return errors.Errorf("invalid asset %v, supported assets"+
+				"are: 'btc', 'ltc'", stringAsset)
+		}
+	default:
+		return errors.Errorf("asset argument missing")
+	}
+
+	if ctx.IsSet("amount") {
+		amount = ctx.String("amount")
+	} else {
+		return errors.Errorf("amount argument is missing")
+	}
+
+	if ctx.IsSet("receipt") {
+		receipt = ctx.String("receipt")
+	} else {
+		return errors.Errorf("receipt argument is missing")
+	}
+
+	ctxb := context.Background()
+	resp, err := client.CreatePSBTPayment(ctxb, &crpc.CreatePSBTPaymentRequest{
+		Asset:          asset,
+		Amount:         amount,
+		Receipt:        receipt,
+		IdempotencyKey: ctx.String("idempotency_key"),
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var exportPSBTCommand = cli.Command{
+	Name:     "exportpsbt",
+	Category: "Payment",
+	Usage: "Returns partially signed transaction of the cold storage " +
+		"payment, which should be signed offline",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "id",
+			Usage: "ID is the id of the payment which was returned on creation.",
+		},
+	},
+	Action: exportPSBT,
+}
+
+func exportPSBT(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var id string
+
+	if ctx.IsSet("id") {
+		id = ctx.String("id")
+	} else {
+		return errors.Errorf("id argument is missing")
+	}
+
+	ctxb := context.Background()
+	resp, err := client.ExportPSBT(ctxb, &crpc.ExportPSBTRequest{
+		PaymentId: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var importPSBTCommand = cli.Command{
+	Name:     "importpsbt",
+	Category: "Payment",
+	Usage: "Imports signatures of the partially signed transaction, " +
+		"which has been signed offline, into the cold storage payment",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "id",
+			Usage: "ID is the id of the payment which was returned on creation.",
+		},
+		cli.StringFlag{
+			Name:  "psbt",
+			Usage: "PSBT is the base64 encoded signed transaction.",
+		},
+	},
+	Action: importPSBT,
+}
+
+func importPSBT(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var id, psbt string
+
+	if ctx.IsSet("id") {
+		id = ctx.String("id")
+	} else {
+		return errors.Errorf("id argument is missing")
+	}
+
+	if ctx.IsSet("psbt") {
+		psbt = ctx.String("psbt")
+	} else {
+		return errors.Errorf("psbt argument is missing")
+	}
+
+	ctxb := context.Background()
+	resp, err := client.ImportPSBT(ctxb, &crpc.ImportPSBTRequest{
+		PaymentId: id,
+		Psbt:      psbt,
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var finalizePSBTCommand = cli.Command{
+	Name:     "finalizepsbt",
+	Category: "Payment",
+	Usage: "Finalizes signed transaction of the cold storage payment " +
+		"and sends it",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "id",
+			Usage: "ID is the id of the payment which was returned on creation.",
+		},
+	},
+	Action: finalizePSBT,
+}
+
+func finalizePSBT(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var id string
+
+	if ctx.IsSet("id") {
+		id = ctx.String("id")
+	} else {
+		return errors.Errorf("id argument is missing")
+	}
+
+	ctxb := context.Background()
+	resp, err := client.FinalizePSBT(ctxb, &crpc.FinalizePSBTRequest{
+		PaymentId: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var reorganiseInputsCommand = cli.Command{
+	Name:     "reorganiseinputs",
+	Category: "Payment",
+	Usage: "Splits large unspent outputs of the wallet into the outputs " +
+		"of the optimal value",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "asset",
+			Usage: "Asset is an acronym of the crypto currency",
+		},
+		cli.BoolFlag{
+			Name: "preview",
+			Usage: "Preview estimates the reorganisation transaction " +
+				"without sending it.",
+		},
+	},
+	Action: reorganiseInputs,
+}
+
+func reorganiseInputs(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var asset crpc.Asset
+
+	switch {
+	case ctx.IsSet("asset"):
+		stringAsset := strings.ToLower(ctx.String("asset"))
+		switch stringAsset {
+		case "btc", "bitcoin":
+			asset = crpc.Asset_BTC
+		case "bch", "bitcoincash":
+			asset = crpc.Asset_BCH
+		case "ltc", "litecoin":
+			asset = crpc.Asset_LTC
+		case "dash":
+			asset = crpc.Asset_DASH
+		default:
+			return errors.Errorf("invalid asset %v, supported assets"+
+				"are: 'btc', 'bch', 'dash', 'ltc'", stringAsset)
+		}
+	default:
+		return errors.Errorf("asset argument missing")
+	}
+
+	ctxb := context.Background()
+	resp, err := client.ReorganiseInputs(ctxb, &crpc.ReorganiseInputsRequest{
+		Asset:   asset,
+		Preview: ctx.Bool("preview"),
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var paymentByIDCommand = cli.Command{
+	Name:     "paymentbyid",
+	Category: "Payment",
+	Usage:    "Return payment by the given id",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "id",
+			Usage: "ID it is unique identificator of the payment. " +
+				"In case of blockchain media payment id is the transaction" +
+				" id, in case of lightning media it is the payment hash.",
+		},
+	},
+	Action: paymentByID,
+}
+
+func paymentByID(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var id string
+
+	if ctx.IsSet("id") {
+		id = ctx.String("id")
+	} else {
+		return errors.Errorf("id argument is missing")
+	}
+
+	ctxb := context.Background()
+	resp, err := client.PaymentByID(ctxb, &crpc.PaymentByIDRequest{
+		PaymentId: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var paymentByReceiptCommand = cli.Command{
+	Name:     "paymentbyreceipt",
+	Category: "Payment",
+	Usage:    "Return payment by the given receipt",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "receipt",
+			Usage: "Receipt is either blockchain address or lightning network" +
+				" invoice which identifies the receiver of the payment.",
+		},
+	},
+	Action: paymentByReceipt,
+}
+
+func paymentByReceipt(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var receipt string
+
+	if ctx.IsSet("receipt") {
+		receipt = ctx.String("receipt")
+	} else {
+		return errors.Errorf("receipt argument is missing")
+	}
+
+	ctxb := context.Background()
+	resp, err := client.PaymentsByReceipt(ctxb, &crpc.PaymentsByReceiptRequest{
+		Receipt: receipt,
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var listPaymentsCommand = cli.Command{
+	Name:     "listpayments",
+	Category: "Payment",
+	Usage:    "Return list payments by the given filter parameters",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "asset",
+			Usage: "Asset is an acronym of the crypto currency",
+		},
+		cli.StringFlag{
+			Name: "media",
+			Usage: "Media is a type of technology which is used to transport" +
+				" value of underlying asset",
+		},
+		cli.StringFlag{
+			Name: "direction",
+			Usage: "Direction identifies the direction of the payment, " +
+				"(incoming, outgoing).",
+		},
+		cli.StringFlag{
+			Name: "status",
+			Usage: "Status is the state of the payment, " +
+				"(waiting, pending, completed, failed, reverted).",
+		},
+		cli.StringFlag{
+			Name: "system",
+			Usage: "System denotes is that payment belongs to business logic" +
+				" of payment server or it was originated by " +
+				"user / third-party service (internal, external).",
+		},
+		cli.Uint64Flag{
+			Name: "limit",
+			Usage: "Limit is the maximum number of payments in the " +
+				"response, if not specified all payments are returned.",
+		},
+		cli.StringFlag{
+			Name: "cursor",
+			Usage: "Cursor is the 'next_cursor' value from the previous " +
+				"response, it is used to fetch the next page of payments.",
+		},
+		cli.Int64Flag{
+			Name: "updated_after",
+			Usage: "Return only payments which were updated after the " +
+				"given time in milliseconds.",
+		},
+		cli.Int64Flag{
+			Name: "updated_before",
+			Usage: "Return only payments which were updated before the " +
+				"given time in milliseconds.",
+		},
+	},
+	Action: listPayments,
+}
+
+func listPayments(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var (
+		media     crpc.Media
+		asset     crpc.Asset
+		status    crpc.PaymentStatus
+		direction crpc.PaymentDirection
+		system    crpc.PaymentSystem
+	)
+
+	if ctx.IsSet("media") {
+		stringMedia := ctx.String("media")
+		switch stringMedia {
+		case "bl", "blockchain":
+			media = crpc.Media_BLOCKCHAIN
+		case "li", "lightning":
+			media = crpc.Media_LIGHTNING
+		default:
+			return errors.Errorf("invalid media type %v, support media type "+
+				"are: 'blockchain' and 'lightning'", stringMedia)
+		}
+	}
+
+	if ctx.IsSet("asset") {
+		stringAsset := strings.ToLower(ctx.String("asset"))
+		switch stringAsset {
+		case "btc", "bitcoin":
+			asset = crpc.Asset_BTC
+		case "bch", "bitcoincash":
+			asset = crpc.Asset_BCH
+		case "ltc", "litecoin":
+			asset = crpc.Asset_LTC
+		case "eth", "ethereum":
+			asset = crpc.Asset_ETH
+		case "dash":
+			asset = crpc.Asset_DASH
+		default:
+			return errors.Errorf("invalid asset %v, supported assets"+
+				"are: 'btc', 'bch', 'dash', 'eth', 'ltc'", stringAsset)
+		}
+	}
+
+	if ctx.IsSet("status") {
+		stringStatus := strings.ToLower(ctx.String("status"))
+		switch stringStatus {
+		case strings.ToLower(crpc.PaymentStatus_WAITING.String()):
+			status = crpc.PaymentStatus_WAITING
+
+		case strings.ToLower(crpc.PaymentStatus_PENDING.String()):
+			status = crpc.PaymentStatus_PENDING
+
+		case strings.ToLower(crpc.PaymentStatus_COMPLETED.String()):
+			status = crpc.PaymentStatus_COMPLETED
+
+		case strings.ToLower(crpc.PaymentStatus_FAILED.String()):
+			status = crpc.PaymentStatus_FAILED
+
+		case strings.ToLower(crpc.PaymentStatus_REVERTED.String()):
+			status = crpc.PaymentStatus_REVERTED
+		default:
+			return errors.Errorf("invalid status %v, supported statuses"+
+				"are: 'waiting', 'pending', 'completed', 'failed', "+
+				"'reverted'", stringStatus)
+		}
+	}
+
+	if ctx.IsSet("direction") {
+		stringDirection := strings.ToLower(ctx.String("direction"))
+		switch stringDirection {
+
+		case strings.ToLower(crpc.PaymentDirection_OUTGOING.String()):
+			direction = crpc.PaymentDirection_OUTGOING
+
+		case strings.ToLower(crpc.PaymentDirection_INCOMING.String()):
+			direction = crpc.PaymentDirection_INCOMING
+
+		default:
+			return errors.Errorf("invalid direction %v, supported direction"+
+				"are: 'incoming', 'outgoing'",
+				stringDirection)
+		}
+	}
+
+	if ctx.IsSet("system") {
+		stringSystem := strings.ToLower(ctx.String("system"))
 		switch stringSystem {
 		case strings.ToLower(crpc.PaymentSystem_INTERNAL.String()):
 			system = crpc.PaymentSystem_INTERNAL
@@ -722,16 +1440,254 @@ func listPayments(ctx *cli.Context) error {
 
 	ctxb := context.Background()
 	resp, err := client.ListPayments(ctxb, &crpc.ListPaymentsRequest{
-		Status:    status,
-		Direction: direction,
+		Status:        status,
+		Direction:     direction,
+		Asset:         asset,
+		Media:         media,
+		System:        system,
+		Limit:         uint32(ctx.Uint64("limit")),
+		Cursor:        ctx.String("cursor"),
+		UpdatedAfter:  ctx.Int64("updated_after"),
+		UpdatedBefore: ctx.Int64("updated_before"),
+	})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var subscribePaymentsCommand = cli.Command{
+	Name:     "subscribepayments",
+	Category: "Payment",
+	Usage:    "Print payments updates as they occur",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "asset",
+			Usage: "Asset is an acronym of the crypto currency",
+		},
+		cli.StringFlag{
+			Name: "media",
+			Usage: "Media is a type of technology which is used to transport" +
+				" value of underlying asset",
+		},
+		cli.StringFlag{
+			Name: "direction",
+			Usage: "Direction identifies the direction of the payment, " +
+				"(incoming, outgoing).",
+		},
+		cli.StringFlag{
+			Name: "system",
+			Usage: "System denotes is that payment belongs to business logic" +
+				" of payment server or it was originated by " +
+				"user / third-party service (internal, external).",
+		},
+	},
+	Action: subscribePayments,
+}
+
+func subscribePayments(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var (
+		media     crpc.Media
+		asset     crpc.Asset
+		direction crpc.PaymentDirection
+		system    crpc.PaymentSystem
+	)
+
+	if ctx.IsSet("media") {
+		stringMedia := ctx.String("media")
+		switch stringMedia {
+		case "bl", "blockchain":
+			media = crpc.Media_BLOCKCHAIN
+		case "li", "lightning":
+			media = crpc.Media_LIGHTNING
+		default:
+			return errors.Errorf("invalid media type %v, support media type "+
+				"are: 'blockchain' and 'lightning'", stringMedia)
+		}
+	}
+
+	if ctx.IsSet("asset") {
+		stringAsset := strings.ToLower(ctx.String("asset"))
+		switch stringAsset {
+		case "btc", "bitcoin":
+			asset = crpc.Asset_BTC
+		case "bch", "bitcoincash":
+			asset = crpc.Asset_BCH
+		case "ltc", "litecoin":
+			asset = crpc.Asset_LTC
+		case "eth", "ethereum":
+			asset = crpc.Asset_ETH
+		case "dash":
+			asset = crpc.Asset_DASH
+		default:
+			return errors.Errorf("invalid asset %v, supported assets"+
+				"are: 'btc', 'bch', 'dash', 'eth', 'ltc'", stringAsset)
+		}
+	}
+
+	if ctx.IsSet("direction") {
+		stringDirection := strings.ToLower(ctx.String("direction"))
+		switch stringDirection {
+
+		case strings.ToLower(crpc.PaymentDirection_OUTGOING.String()):
+			direction = crpc.PaymentDirection_OUTGOING
+
+		case strings.ToLower(crpc.PaymentDirection_INCOMING.String()):
+			direction = crpc.PaymentDirection_INCOMING
+
+		default:
+			return errors.Errorf("invalid direction %v, supported direction"+
+				"are: 'incoming', 'outgoing'",
+				stringDirection)
+		}
+	}
+
+	if ctx.IsSet("system") {
+		stringSystem := strings.ToLower(ctx.String("system"))
+		switch stringSystem {
+		case strings.ToLower(crpc.PaymentSystem_INTERNAL.String()):
+			system = crpc.PaymentSystem_INTERNAL
+
+		case strings.ToLower(crpc.PaymentSystem_EXTERNAL.String()):
+			system = crpc.PaymentSystem_EXTERNAL
+
+		default:
+			return errors.Errorf("invalid system %v, supported system"+
+				"are: 'internal', 'external'",
+				stringSystem)
+		}
+	}
+
+	ctxb := context.Background()
+	stream, err := client.SubscribePayments(ctxb, &crpc.SubscribePaymentsRequest{
 		Asset:     asset,
 		Media:     media,
+		Direction: direction,
 		System:    system,
 	})
 	if err != nil {
 		return err
 	}
 
+	for {
+		payment, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		printRespJSON(payment)
+	}
+}
+
+var bakeMacaroonCommand = cli.Command{
+	Name:     "bakemacaroon",
+	Category: "Macaroons",
+	Usage:    "Bakes new macaroon with the given permissions",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "permissions",
+			Usage: "Comma separated list of permissions granted by the " +
+				"macaroon (read, invoice, send, bake).",
+		},
+		cli.StringFlag{
+			Name: "save_to",
+			Usage: "If specified, macaroon is written in the file, " +
+				"otherwise hex encoded macaroon is printed.",
+		},
+	},
+	Action: bakeMacaroon,
+}
+
+func bakeMacaroon(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	var permissions []string
+
+	if ctx.IsSet("permissions") {
+		permissions = strings.Split(ctx.String("permissions"), ",")
+	} else {
+		return errors.Errorf("permissions argument is missing")
+	}
+
+	ctxb := context.Background()
+	resp, err := client.BakeMacaroon(ctxb, &crpc.BakeMacaroonRequest{
+		Permissions: permissions,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !ctx.IsSet("save_to") {
+		printRespJSON(resp)
+		return nil
+	}
+
+	data, err := hex.DecodeString(resp.Macaroon)
+	if err != nil {
+		return errors.Errorf("unable to decode macaroon: %v", err)
+	}
+
+	path := cleanAndExpandPath(ctx.String("save_to"))
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return errors.Errorf("unable to save macaroon: %v", err)
+	}
+
+	fmt.Printf("Macaroon saved to %v\n", path)
+	return nil
+}
+
+var listFailedNotificationsCommand = cli.Command{
+	Name:     "listfailednotifications",
+	Category: "Notifications",
+	Usage: "Return webhook notifications which haven't been delivered " +
+		"after all attempts.",
+	Action: listFailedNotifications,
+}
+
+func listFailedNotifications(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	ctxb := context.Background()
+	resp, err := client.ListFailedNotifications(ctxb,
+		&crpc.ListFailedNotificationsRequest{})
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var replayNotificationsCommand = cli.Command{
+	Name:      "replaynotifications",
+	Category:  "Notifications",
+	Usage:     "Schedule failed webhook notifications for the delivery.",
+	ArgsUsage: "[id...]",
+	Description: "Replays failed notifications with the given ids, if no " +
+		"ids are given all failed notifications are replayed.",
+	Action: replayNotifications,
+}
+
+func replayNotifications(ctx *cli.Context) error {
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	ctxb := context.Background()
+	resp, err := client.ReplayNotifications(ctxb,
+		&crpc.ReplayNotificationsRequest{
+			Ids: ctx.Args(),
+		})
+	if err != nil {
+		return err
+	}
+
 	printRespJSON(resp)
 	return nil
 }
@@ -2,15 +2,30 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/bitlum/connector/crpc"
+	"github.com/bitlum/connector/macaroons"
+	"github.com/btcsuite/btcutil"
 	"github.com/urfave/cli"
 	"google.golang.org/grpc"
+	"gopkg.in/macaroon.v2"
 )
 
 const (
 	defaultRPCPort     = "9002"
 	defaultRPCHostPort = "localhost:" + defaultRPCPort
+
+	defaultMacaroonFilename = "admin.macaroon"
+)
+
+var (
+	defaultConnectorDir = btcutil.AppDataDir("connector", false)
+	defaultMacaroonPath = filepath.Join(defaultConnectorDir,
+		defaultMacaroonFilename)
 )
 
 func fatal(err error) {
@@ -34,6 +49,26 @@ func getClientConn(ctx *cli.Context, skipMacaroons bool) *grpc.ClientConn {
 		grpc.WithInsecure(),
 	}
 
+	// Macaroon is attached to every request, so that server could check
+	// that client is authorised to call the method.
+	if !skipMacaroons && !ctx.GlobalBool("no-macaroons") {
+		macPath := cleanAndExpandPath(ctx.GlobalString("macaroonpath"))
+
+		macBytes, err := ioutil.ReadFile(macPath)
+		if err != nil {
+			fatal(fmt.Errorf("unable to read macaroon path (check "+
+				"the network setting!): %v", err))
+		}
+
+		mac := &macaroon.Macaroon{}
+		if err := mac.UnmarshalBinary(macBytes); err != nil {
+			fatal(fmt.Errorf("unable to decode macaroon: %v", err))
+		}
+
+		cred := macaroons.NewCredential(mac)
+		opts = append(opts, grpc.WithPerRPCCredentials(cred))
+	}
+
 	conn, err := grpc.Dial(ctx.GlobalString("rpcserver"), opts...)
 	if err != nil {
 		fatal(err)
@@ -53,19 +88,59 @@ func main() {
 			Value: defaultRPCHostPort,
 			Usage: "host:port of payserver",
 		},
+		cli.StringFlag{
+			Name:  "macaroonpath",
+			Value: defaultMacaroonPath,
+			Usage: "path to macaroon file",
+		},
+		cli.BoolFlag{
+			Name:  "no-macaroons",
+			Usage: "disable macaroon authentication",
+		},
 	}
 	app.Commands = []cli.Command{
+		getInfoCommand,
 		createReceiptCommand,
 		validateReceiptCommand,
 		balanceCommand,
 		estimateFeeCommand,
+		getFeeRatesCommand,
 		sendPaymentCommand,
+		sendPaymentsCommand,
+		createPaymentCommand,
+		confirmPaymentCommand,
+		cancelPaymentCommand,
+		bumpFeeCommand,
+		createPSBTPaymentCommand,
+		exportPSBTCommand,
+		importPSBTCommand,
+		finalizePSBTCommand,
+		reorganiseInputsCommand,
 		paymentByIDCommand,
 		paymentByReceiptCommand,
 		listPaymentsCommand,
+		subscribePaymentsCommand,
+		bakeMacaroonCommand,
+		listFailedNotificationsCommand,
+		replayNotificationsCommand,
 	}
 
 	if err := app.Run(os.Args); err != nil {
 		fatal(err)
 	}
 }
+
+// cleanAndExpandPath expands environment variables and leading ~ in the
+// passed path, cleans the result, and returns it.
+// This function is taken from https://github.com/btcsuite/btcd
+func cleanAndExpandPath(path string) string {
+	// Expand initial ~ to OS specific home directory.
+	if strings.HasPrefix(path, "~") {
+		homeDir := filepath.Dir(defaultConnectorDir)
+		path = strings.Replace(path, "~", homeDir, 1)
+	}
+
+	// NOTE: The os.ExpandEnv doesn't work with Windows-style %VARIABLE%,
+	// but the variables can still be expanded via POSIX-style $VARIABLE.
+	return filepath.Clean(os.ExpandEnv(path))
+}
@@ -6,23 +6,36 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"log"
 
+	"github.com/bitlum/connector/connectors"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/go-flags"
+	"github.com/shopspring/decimal"
 )
 
 const (
 	defaultRPCHost = "0.0.0.0"
 	defaultRPCPort = "9002"
 
+	defaultRESTHost = "0.0.0.0"
+
+	defaultWebhookMaxAttempts    = 10
+	defaultWebhookInitialBackoff = 5 * time.Second
+	defaultWebhookMaxBackoff     = time.Hour
+
 	defaultPrometheusEndpointHost = "0.0.0.0"
 	defaultPrometheusEndpointPort = "9999"
 
 	defaultTLSCertFilename = "server.cert"
 	defaultTLSKeyFilename  = "server.key"
 
+	defaultAdminMacaroonFilename    = "admin.macaroon"
+	defaultReadOnlyMacaroonFilename = "readonly.macaroon"
+	defaultInvoiceMacaroonFilename  = "invoice.macaroon"
+
 	defaultLogDirname  = "logs"
 	defaultLogFilename = "connector.log"
 	defaultLogLevel    = "info"
@@ -38,8 +51,20 @@ var (
 	defaultTLSCertPath = filepath.Join(homeDir, defaultTLSCertFilename)
 	defaultTLSKeyPath  = filepath.Join(homeDir, defaultTLSKeyFilename)
 	defaultLogDir      = filepath.Join(homeDir, defaultLogDirname)
+
+	defaultAdminMacaroonPath    = filepath.Join(homeDir, defaultAdminMacaroonFilename)
+	defaultReadOnlyMacaroonPath = filepath.Join(homeDir, defaultReadOnlyMacaroonFilename)
+	defaultInvoiceMacaroonPath  = filepath.Join(homeDir, defaultInvoiceMacaroonFilename)
 )
 
+type webhooksConfig struct {
+	URLs           []string      `long:"url" description:"Webhook url on which payment status changes are posted, might be specified multiple times"`
+	Secret         string        `long:"secret" description:"Secret with which body of the webhook request is signed, signature is passed in the X-Payserver-Signature header"`
+	MaxAttempts    int           `long:"maxattempts" description:"Number of delivery attempts after which notification is considered as failed"`
+	InitialBackoff time.Duration `long:"initialbackoff" description:"Delay before the first retry of the delivery, every next delay is twice bigger"`
+	MaxBackoff     time.Duration `long:"maxbackoff" description:"Maximum delay between the delivery retries"`
+}
+
 type prometheusConfig struct {
 	Host string `long:"host" description:"The host of the prometheus metrics endpoint, from which metric server is trying to fetch metrics"`
 	Port string `long:"port" description:"The port of the prometheus metrics endpoint, from which metric server is trying to fetch metrics"`
@@ -55,9 +80,17 @@ type config struct {
 	TLSCertPath string `long:"tlscertpath" description:"Path to TLS certificate which is used to encrypt RPC endpoint"`
 	TLSKeyPath  string `long:"tlskeypath" description:"Path to TLS private key which is used to encrypt RPC endpoint"`
 
+	NoMacaroons          bool   `long:"nomacaroons" description:"Disable macaroon authentication of the RPC endpoint"`
+	AdminMacaroonPath    string `long:"adminmacaroonpath" description:"Path to write the admin macaroon, which grants access to all RPC methods"`
+	ReadOnlyMacaroonPath string `long:"readonlymacaroonpath" description:"Path to write the read-only macaroon, which grants access to the methods which don't change the state"`
+	InvoiceMacaroonPath  string `long:"invoicemacaroonpath" description:"Path to write the invoice macaroon, which grants read-only access and creation of receipts"`
+
 	RPCHost string `long:"rpchost" description:"The host of the RPC endpoint"`
 	RPCPort string `long:"rpcport" description:"The port of the RPC endpoint"`
 
+	RESTHost string `long:"resthost" description:"The host of the REST/JSON endpoint"`
+	RESTPort string `long:"restport" description:"The port of the REST/JSON endpoint, if not specified endpoint is disabled"`
+
 	Network string `long:"network" description:"The network of the daemon to which connector is connecting" choice:"simnet" choice:"testnet" choice:"mainnet"`
 
 	ConfigFile string `long:"config" description:"Path to configuration file"`
@@ -67,6 +100,8 @@ type config struct {
 
 	Prometheus *prometheusConfig `group:"Prometheus" namespace:"prometheus"`
 
+	Webhooks *webhooksConfig `group:"Webhooks" namespace:"webhooks"`
+
 	Bitcoin          *BitcoindConfig `group:"bitcoin" namespace:"bitcoin"`
 	BitcoinLightning *LndConfig      `group:"bitcoinlightning" namespace:"bitcoinlightning"`
 	BitcoinCash      *BitcoindConfig `group:"bitcoincash" namespace:"bitcoincash"`
@@ -90,6 +125,10 @@ type LndConfig struct {
 
 	// TODO(andrew.shvv) Remove when lnd would return this info
 	PeerHost string `long:"peerhost" description:"Public host of the lnd via which other lightning network nodes could connect"`
+
+	MaxSendAttempts     int           `long:"maxsendattempts" description:"Number of attempts to send outgoing payment after which it is marked as failed, 1 disables retries"`
+	SendRetryBackoff    time.Duration `long:"sendretrybackoff" description:"Delay before the first retry of outgoing payment, every next delay is twice bigger"`
+	SendRetryMaxBackoff time.Duration `long:"sendretrymaxbackoff" description:"Maximum delay between the retries of outgoing payment"`
 }
 
 type GethConfig struct {
@@ -101,6 +140,10 @@ type GethConfig struct {
 	Port             int    `long:"port" description:"The port of the lnd daemon"`
 	User             string `long:"user" description:"Part of the credential information needed to connect to the daemon RPC endpoint"`
 	Password         string `long:"password" description:"Part of the credential information needed to connect to the daemon RPC endpoint"`
+
+	MaxSendAttempts     int           `long:"maxsendattempts" description:"Number of attempts to send outgoing payment after which it is marked as failed, 1 disables retries"`
+	SendRetryBackoff    time.Duration `long:"sendretrybackoff" description:"Delay before the first retry of outgoing payment, every next delay is twice bigger"`
+	SendRetryMaxBackoff time.Duration `long:"sendretrymaxbackoff" description:"Maximum delay between the retries of outgoing payment"`
 }
 
 type BitcoindConfig struct {
@@ -113,6 +156,190 @@ type BitcoindConfig struct {
 	Port             int    `long:"port" description:"The port of the lnd daemon"`
 	User             string `long:"user" description:"Part of the credential information needed to connect to the daemon RPC endpoint"`
 	Password         string `long:"password" description:"Part of the credential information needed to connect to the daemon RPC endpoint"`
+	Backend          string `long:"backend" description:"Connector backend {simple, full} -- simple sends payments with sendtoaddress of the daemon, full crafts transactions with in-house coin selection" choice:"simple" choice:"full"`
+	CoinSelection    string `long:"coinselection" description:"Coin selection strategy of the full backend {largest, smallest, bnb, oldest} -- largest spends the largest outputs first, smallest consolidates the smallest outputs first, bnb searches for the transaction without change falling back to largest, oldest spends the most confirmed outputs first" choice:"largest" choice:"smallest" choice:"bnb" choice:"oldest"`
+
+	MaxSendAttempts     int           `long:"maxsendattempts" description:"Number of attempts to send outgoing payment after which it is marked as failed, 1 disables retries"`
+	SendRetryBackoff    time.Duration `long:"sendretrybackoff" description:"Delay before the first retry of outgoing payment, every next delay is twice bigger"`
+	SendRetryMaxBackoff time.Duration `long:"sendretrymaxbackoff" description:"Maximum delay between the retries of outgoing payment"`
+
+	FastTarget      uint32        `long:"fasttarget" description:"Confirmation target in blocks of the fast fee priority"`
+	NormalTarget    uint32        `long:"normaltarget" description:"Confirmation target in blocks of the normal fee priority, which is used if priority isn't specified"`
+	EconomyTarget   uint32        `long:"economytarget" description:"Confirmation target in blocks of the economy fee priority"`
+	MinFeeRate      float64       `long:"minfeerate" description:"Floor of the estimated fee rate in the smallest units per byte"`
+	MaxFeeRate      float64       `long:"maxfeerate" description:"Ceiling of the estimated fee rate in the smallest units per byte, 0 means unlimited"`
+	FeeSmoothing    float64       `long:"feesmoothing" description:"Weight from 0 to 1 of the new fee estimate in the moving average of the fee rate, 1 disables smoothing"`
+	FeePollInterval time.Duration `long:"feepollinterval" description:"Interval with which fee rates are estimated"`
+
+	AutoBumpBlocks   uint32        `long:"autobumpblocks" description:"Number of blocks after which fee of the pending outgoing payment is bumped with replace-by-fee, 0 disables automatic bumping"`
+	BumpPriority     string        `long:"bumppriority" description:"Fee priority which fee rate is used to bump the fee, if fee rate isn't specified {fast, normal, economy}" choice:"fast" choice:"normal" choice:"economy"`
+	BumpPollInterval time.Duration `long:"bumppollinterval" description:"Interval with which pending outgoing payments are checked for being stuck"`
+
+	BatchInterval   time.Duration `long:"batchinterval" description:"Interval with which outgoing payments without the fee priority are sent in one transaction by the simple backend, 0 disables batching"`
+	BatchMaxOutputs int           `long:"batchmaxoutputs" description:"Number of queued payments on reaching which they are sent without waiting for the batch interval, and the maximum number of payments in one transaction"`
+
+	XPub       string `long:"xpub" description:"Extended public key from which deposit addresses are derived and imported into the daemon wallet as watch-only, instead of being created by the daemon wallet"`
+	Derivation string `long:"derivation" description:"Derivation scheme of the extended public key {bip32, bip44, bip84} -- bip32 derives legacy addresses from its children, bip44 derives legacy addresses from the external chain of the account key, bip84 derives native segwit addresses from the external chain of the account key" choice:"bip32" choice:"bip44" choice:"bip84"`
+	XPubOrigin string `long:"xpuborigin" description:"Fingerprint of the master key and derivation path of the extended public key, e.g. d34db33f/84'/0'/0', which are added to the partially signed transactions, so that the signer could find the keys of their inputs"`
+
+	MaxHotBalance    float64       `long:"maxhotbalance" description:"Confirmed balance of the daemon wallet exceeding which the excess is swept to the cold storage, 0 disables sweeping"`
+	TargetHotBalance float64       `long:"targethotbalance" description:"Balance which is left in the daemon wallet after the sweep, shouldn't exceed the max hot balance"`
+	ColdAddress      string        `long:"coldaddress" description:"Address of the cold storage to which excess of the daemon wallet is swept"`
+	ColdXPub         string        `long:"coldxpub" description:"Extended public key of the cold storage from which new address is derived for every sweep, instead of the cold address"`
+	ColdDerivation   string        `long:"coldderivation" description:"Derivation scheme of the cold storage extended public key {bip32, bip44, bip84}" choice:"bip32" choice:"bip44" choice:"bip84"`
+	MinSweepAmount   float64       `long:"minsweepamount" description:"Amount below which excess of the daemon wallet isn't swept"`
+	MaxSweepFee      float64       `long:"maxsweepfee" description:"Maximum fee of the sweep, 0 means unlimited"`
+	MaxSweepFeeRate  float64       `long:"maxsweepfeerate" description:"Maximum fee rate of the sweep in the smallest units per byte, 0 means unlimited"`
+	SweepPriority    string        `long:"sweeppriority" description:"Fee priority of the sweep {fast, normal, economy}" choice:"fast" choice:"normal" choice:"economy"`
+	SweepInterval    time.Duration `long:"sweepinterval" description:"Interval with which balance of the daemon wallet is checked for the excess"`
+
+	ConsolidateThreshold  float64       `long:"consolidatethreshold" description:"Amount below which unspent outputs of the daemon wallet are merged into one output, 0 disables consolidation"`
+	ConsolidateMaxFeeRate float64       `long:"consolidatemaxfeerate" description:"Fee rate in the smallest units per byte, above which unspent outputs aren't consolidated"`
+	ConsolidateMinInputs  int           `long:"consolidatemininputs" description:"Number of the small unspent outputs below which they aren't consolidated"`
+	ConsolidateMaxInputs  int           `long:"consolidatemaxinputs" description:"Maximum number of the unspent outputs which are merged in one transaction"`
+	ConsolidatePriority   string        `long:"consolidatepriority" description:"Fee priority of the consolidation transaction {fast, normal, economy}" choice:"fast" choice:"normal" choice:"economy"`
+	ConsolidateInterval   time.Duration `long:"consolidateinterval" description:"Interval with which fee rate is checked for being low enough to consolidate unspent outputs"`
+
+	OptimalUTXOValue float64       `long:"optimalutxovalue" description:"Value of the outputs into which unspent outputs larger than twice of it are split by the full backend, 0 means the default one"`
+	ReorgPriority    string        `long:"reorgpriority" description:"Fee priority of the transaction which splits large unspent outputs {fast, normal, economy}" choice:"fast" choice:"normal" choice:"economy"`
+	ReorgInterval    time.Duration `long:"reorginterval" description:"Interval with which large unspent outputs are split by the full backend, 0 disables automatic reorganisation"`
+}
+
+// toDaemonConfig converts config group to the config of the connector
+// factory.
+func (c *LndConfig) toDaemonConfig() *connectors.DaemonConfig {
+	return &connectors.DaemonConfig{
+		Host:         c.Host,
+		Port:         c.Port,
+		TLSCertPath:  c.TlsCertPath,
+		MacaroonPath: c.MacaroonPath,
+		PeerHost:     c.PeerHost,
+		PeerPort:     c.PeerPort,
+		SendRetry: connectors.RetryPolicy{
+			MaxAttempts: c.MaxSendAttempts,
+			Backoff:     c.SendRetryBackoff,
+			MaxBackoff:  c.SendRetryMaxBackoff,
+		},
+	}
+}
+
+// toDaemonConfig converts config group to the config of the connector
+// factory.
+func (c *GethConfig) toDaemonConfig() *connectors.DaemonConfig {
+	return &connectors.DaemonConfig{
+		Host:             c.Host,
+		Port:             c.Port,
+		User:             c.User,
+		Password:         c.Password,
+		MinConfirmations: c.MinConfirmations,
+		SyncDelay:        c.SyncDelay,
+		ForceLastHash:    c.ForceLastHash,
+		SendRetry: connectors.RetryPolicy{
+			MaxAttempts: c.MaxSendAttempts,
+			Backoff:     c.SendRetryBackoff,
+			MaxBackoff:  c.SendRetryMaxBackoff,
+		},
+	}
+}
+
+// toDaemonConfig converts config group to the config of the connector
+// factory.
+func (c *BitcoindConfig) toDaemonConfig() *connectors.DaemonConfig {
+	return &connectors.DaemonConfig{
+		Host:             c.Host,
+		Port:             c.Port,
+		User:             c.User,
+		Password:         c.Password,
+		MinConfirmations: c.MinConfirmations,
+		SyncDelay:        c.SyncDelay,
+		FeePerUnit:       c.FeePerUnit,
+		ForceLastHash:    c.ForceLastHash,
+		Backend:          c.Backend,
+		CoinSelection:    c.CoinSelection,
+		SendRetry: connectors.RetryPolicy{
+			MaxAttempts: c.MaxSendAttempts,
+			Backoff:     c.SendRetryBackoff,
+			MaxBackoff:  c.SendRetryMaxBackoff,
+		},
+		FeePolicy: connectors.FeePolicy{
+			FastTarget:    c.FastTarget,
+			NormalTarget:  c.NormalTarget,
+			EconomyTarget: c.EconomyTarget,
+			MinFeeRate:    c.MinFeeRate,
+			MaxFeeRate:    c.MaxFeeRate,
+			Smoothing:     c.FeeSmoothing,
+			PollInterval:  c.FeePollInterval,
+		},
+		FeeBump: connectors.FeeBumpPolicy{
+			AutoBumpBlocks: c.AutoBumpBlocks,
+			Priority:       connectors.FeePriority(c.BumpPriority),
+			PollInterval:   c.BumpPollInterval,
+		},
+		Batch: connectors.BatchPolicy{
+			Interval:   c.BatchInterval,
+			MaxOutputs: c.BatchMaxOutputs,
+		},
+		Derivation: connectors.DerivationPolicy{
+			XPub:   c.XPub,
+			Scheme: connectors.DerivationScheme(c.Derivation),
+			Origin: c.XPubOrigin,
+		},
+		Treasury: connectors.TreasuryPolicy{
+			MaxHotBalance:    decimal.NewFromFloat(c.MaxHotBalance),
+			TargetHotBalance: decimal.NewFromFloat(c.TargetHotBalance),
+			ColdAddress:      c.ColdAddress,
+			ColdDerivation: connectors.DerivationPolicy{
+				XPub:   c.ColdXPub,
+				Scheme: connectors.DerivationScheme(c.ColdDerivation),
+			},
+			MinSweepAmount:  decimal.NewFromFloat(c.MinSweepAmount),
+			MaxSweepFee:     decimal.NewFromFloat(c.MaxSweepFee),
+			MaxSweepFeeRate: c.MaxSweepFeeRate,
+			Priority:        connectors.FeePriority(c.SweepPriority),
+			PollInterval:    c.SweepInterval,
+		},
+		Consolidation: connectors.ConsolidationPolicy{
+			Threshold:    decimal.NewFromFloat(c.ConsolidateThreshold),
+			MaxFeeRate:   c.ConsolidateMaxFeeRate,
+			MinInputs:    c.ConsolidateMinInputs,
+			MaxInputs:    c.ConsolidateMaxInputs,
+			Priority:     connectors.FeePriority(c.ConsolidatePriority),
+			PollInterval: c.ConsolidateInterval,
+		},
+		Reorganisation: connectors.ReorganisationPolicy{
+			OptimalUTXOValue: decimal.NewFromFloat(c.OptimalUTXOValue),
+			Priority:         connectors.FeePriority(c.ReorgPriority),
+			Interval:         c.ReorgInterval,
+		},
+	}
+}
+
+// daemonConfigs returns configs of the enabled daemons, keyed by asset and
+// media of the connector which is working with the daemon.
+func (c *config) daemonConfigs() map[connectors.FactoryKey]*connectors.DaemonConfig {
+	cfgs := make(map[connectors.FactoryKey]*connectors.DaemonConfig)
+
+	add := func(asset connectors.Asset, media connectors.PaymentMedia,
+		disabled bool, cfg *connectors.DaemonConfig) {
+		if !disabled {
+			cfgs[connectors.FactoryKey{Asset: asset, Media: media}] = cfg
+		}
+	}
+
+	add(connectors.BTC, connectors.Blockchain, c.Bitcoin.Disabled,
+		c.Bitcoin.toDaemonConfig())
+	add(connectors.BCH, connectors.Blockchain, c.BitcoinCash.Disabled,
+		c.BitcoinCash.toDaemonConfig())
+	add(connectors.LTC, connectors.Blockchain, c.Litecoin.Disabled,
+		c.Litecoin.toDaemonConfig())
+	add(connectors.DASH, connectors.Blockchain, c.Dash.Disabled,
+		c.Dash.toDaemonConfig())
+	add(connectors.ETH, connectors.Blockchain, c.Ethereum.Disabled,
+		c.Ethereum.toDaemonConfig())
+	add(connectors.BTC, connectors.Lightning, c.BitcoinLightning.Disabled,
+		c.BitcoinLightning.toDaemonConfig())
+
+	return cfgs
 }
 
 // getDefaultConfig return default version of service config.
@@ -121,9 +348,15 @@ func getDefaultConfig() config {
 		TLSCertPath: defaultTLSCertPath,
 		TLSKeyPath:  defaultTLSKeyPath,
 
+		AdminMacaroonPath:    defaultAdminMacaroonPath,
+		ReadOnlyMacaroonPath: defaultReadOnlyMacaroonPath,
+		InvoiceMacaroonPath:  defaultInvoiceMacaroonPath,
+
 		RPCHost: defaultRPCHost,
 		RPCPort: defaultRPCPort,
 
+		RESTHost: defaultRESTHost,
+
 		ConfigFile: defaultConfigFile,
 		LogDir:     defaultLogDir,
 		DebugLevel: defaultLogLevel,
@@ -134,6 +367,12 @@ func getDefaultConfig() config {
 			Host: defaultPrometheusEndpointHost,
 			Port: defaultPrometheusEndpointPort,
 		},
+
+		Webhooks: &webhooksConfig{
+			MaxAttempts:    defaultWebhookMaxAttempts,
+			InitialBackoff: defaultWebhookInitialBackoff,
+			MaxBackoff:     defaultWebhookMaxBackoff,
+		},
 	}
 }
 
@@ -196,6 +435,9 @@ func (c *config) loadConfig() error {
 	// Ensure that the paths are expanded and cleaned.
 	c.TLSCertPath = cleanAndExpandPath(c.TLSCertPath)
 	c.TLSKeyPath = cleanAndExpandPath(c.TLSKeyPath)
+	c.AdminMacaroonPath = cleanAndExpandPath(c.AdminMacaroonPath)
+	c.ReadOnlyMacaroonPath = cleanAndExpandPath(c.ReadOnlyMacaroonPath)
+	c.InvoiceMacaroonPath = cleanAndExpandPath(c.InvoiceMacaroonPath)
 	c.LogDir = cleanAndExpandPath(c.LogDir)
 
 	// Parse, validate, and set debug log level(s).
@@ -0,0 +1,89 @@
+package connectors
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// defaultBatchMaxOutputs is the maximum number of payments which are
+	// sent in one transaction, if it isn't specified in the policy.
+	defaultBatchMaxOutputs = 100
+)
+
+// BatchPolicy determines how outgoing payments are batched into the
+// transactions with multiple outputs.
+type BatchPolicy struct {
+	// Interval is the interval with which queued payments are sent in one
+	// transaction. Zero disables batching.
+	Interval time.Duration
+
+	// MaxOutputs is the number of queued payments on reaching which they
+	// are sent without waiting for the interval, it is also the maximum
+	// number of payments in one transaction. Default is used if it is zero.
+	MaxOutputs int
+}
+
+// Enabled returns true if outgoing payments should be batched.
+func (p BatchPolicy) Enabled() bool {
+	return p.Interval != 0
+}
+
+// BatchSize returns the maximum number of payments in one transaction.
+func (p BatchPolicy) BatchSize() int {
+	if p.MaxOutputs == 0 {
+		return defaultBatchMaxOutputs
+	}
+
+	return p.MaxOutputs
+}
+
+// BatchesStorage is used to keep the index of the batch transactions
+// outputs. Payment id is derived from the transaction id, which is unknown
+// when payment is queued, that is why outputs of the batch transaction
+// should be mapped to the payments explicitly.
+//
+// NOTE: This storage should be persistent.
+type BatchesStorage interface {
+	// PutBatch saves the payments which have been sent by the batch
+	// transaction, keyed by their receipts.
+	PutBatch(asset Asset, txID string, payments map[string]string) error
+
+	// BatchedPaymentID returns id of the payment which has been sent to
+	// the receipt by the batch transaction, empty string is returned if
+	// there is no such payment.
+	BatchedPaymentID(asset Asset, txID, receipt string) (string, error)
+}
+
+// SplitFee splits the fee of the batch transaction between its payments
+// pro-rata to their amounts. Shares are rounded down to the given number of
+// decimal places, and the rounding remainder is paid by the last payment,
+// so that shares sum up to the fee exactly.
+func SplitFee(fee decimal.Decimal, amounts []decimal.Decimal,
+	places int32) []decimal.Decimal {
+
+	total := decimal.Zero
+	for _, amount := range amounts {
+		total = total.Add(amount)
+	}
+
+	shares := make([]decimal.Decimal, len(amounts))
+	if len(amounts) == 0 {
+		return shares
+	}
+
+	left := fee
+	for i, amount := range amounts[:len(amounts)-1] {
+		if total.IsZero() {
+			shares[i] = decimal.Zero
+			continue
+		}
+
+		shares[i] = fee.Mul(amount).Div(total).Truncate(places)
+		left = left.Sub(shares[i])
+	}
+	shares[len(amounts)-1] = left
+
+	return shares
+}
@@ -0,0 +1,56 @@
+package connectors
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSplitFee(t *testing.T) {
+	tests := []struct {
+		name    string
+		fee     string
+		amounts []string
+		shares  []string
+	}{
+		{
+			name:    "single payment",
+			fee:     "0.0001",
+			amounts: []string{"1"},
+			shares:  []string{"0.0001"},
+		},
+		{
+			name:    "pro-rata",
+			fee:     "0.0004",
+			amounts: []string{"1", "3"},
+			shares:  []string{"0.0001", "0.0003"},
+		},
+		{
+			name:    "remainder is paid by the last payment",
+			fee:     "0.00000010",
+			amounts: []string{"1", "1", "1"},
+			shares:  []string{"0.00000003", "0.00000003", "0.00000004"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			amounts := make([]decimal.Decimal, len(test.amounts))
+			for i, amount := range test.amounts {
+				amounts[i] = decimal.RequireFromString(amount)
+			}
+
+			shares := SplitFee(decimal.RequireFromString(test.fee), amounts, 8)
+			if len(shares) != len(test.shares) {
+				t.Fatalf("wrong number of shares: %v", len(shares))
+			}
+
+			for i, share := range shares {
+				if !share.Equal(decimal.RequireFromString(test.shares[i])) {
+					t.Fatalf("wrong share(%v), expected: %v, actual: %v",
+						i, test.shares[i], share)
+				}
+			}
+		})
+	}
+}
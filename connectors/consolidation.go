@@ -0,0 +1,119 @@
+package connectors
+
+import (
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// defaultConsolidationInterval is the interval with which fee rate is
+	// checked for being low enough to consolidate the unspent outputs.
+	defaultConsolidationInterval = time.Hour
+
+	// defaultConsolidationMinInputs is the default number of the small
+	// unspent outputs, below which they aren't consolidated.
+	defaultConsolidationMinInputs = 10
+
+	// defaultConsolidationMaxInputs is the default maximum number of the
+	// inputs of the consolidation transaction.
+	defaultConsolidationMaxInputs = 100
+)
+
+// ConsolidationPolicy determines when small unspent outputs of the wallet
+// are merged into one output, so that they wouldn't make withdrawals
+// expensive when fee rates are high.
+type ConsolidationPolicy struct {
+	// Threshold is the amount below which unspent output is consolidated.
+	// Zero disables consolidation.
+	Threshold decimal.Decimal
+
+	// MaxFeeRate is the ceiling of the fee rate in the smallest units of
+	// the asset per byte, outputs are consolidated only if current fee rate
+	// of the priority doesn't exceed it.
+	MaxFeeRate float64
+
+	// MinInputs is the number of the small unspent outputs below which
+	// they aren't consolidated, default is used if it is zero.
+	MinInputs int
+
+	// MaxInputs is the maximum number of the inputs of the consolidation
+	// transaction, default is used if it is zero.
+	MaxInputs int
+
+	// Priority is the fee priority of the consolidation transaction,
+	// economy priority is used if it is default one.
+	Priority FeePriority
+
+	// PollInterval is the interval with which fee rate is checked, default
+	// is used if it is zero.
+	PollInterval time.Duration
+}
+
+// Enabled returns true if small unspent outputs should be consolidated.
+func (p ConsolidationPolicy) Enabled() bool {
+	return p.Threshold.Sign() > 0
+}
+
+// ConsolidationPriority returns the fee priority of the consolidation
+// transaction.
+func (p ConsolidationPolicy) ConsolidationPriority() FeePriority {
+	if p.Priority == DefaultPriority {
+		return EconomyPriority
+	}
+
+	return p.Priority
+}
+
+// InputsRange returns the minimum and the maximum number of the inputs of
+// the consolidation transaction.
+func (p ConsolidationPolicy) InputsRange() (int, int) {
+	minInputs, maxInputs := p.MinInputs, p.MaxInputs
+	if minInputs == 0 {
+		minInputs = defaultConsolidationMinInputs
+	}
+
+	if maxInputs == 0 {
+		maxInputs = defaultConsolidationMaxInputs
+	}
+
+	return minInputs, maxInputs
+}
+
+// Interval returns the interval with which fee rate is checked for being
+// low enough to consolidate the unspent outputs.
+func (p ConsolidationPolicy) Interval() time.Duration {
+	if p.PollInterval == 0 {
+		return defaultConsolidationInterval
+	}
+
+	return p.PollInterval
+}
+
+// Validate checks that policy is consistent.
+func (p ConsolidationPolicy) Validate() error {
+	if !p.Enabled() {
+		return nil
+	}
+
+	if p.MaxFeeRate <= 0 {
+		return errors.New("max consolidation fee rate should be specified")
+	}
+
+	if p.MinInputs < 0 || p.MaxInputs < 0 {
+		return errors.New("number of inputs shouldn't be negative")
+	}
+
+	minInputs, maxInputs := p.InputsRange()
+	if minInputs < 2 {
+		return errors.New("at least two outputs should be consolidated")
+	}
+
+	if minInputs > maxInputs {
+		return errors.New("min number of inputs shouldn't exceed the max " +
+			"number of inputs")
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package connectors
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// TestConsolidationPolicyValidate checks that inconsistent policies are
+// rejected.
+func TestConsolidationPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy ConsolidationPolicy
+		valid  bool
+	}{{
+		name:  "disabled",
+		valid: true,
+	}, {
+		name: "defaults",
+		policy: ConsolidationPolicy{
+			Threshold:  decimal.NewFromFloat(0.001),
+			MaxFeeRate: 5,
+		},
+		valid: true,
+	}, {
+		name: "no fee rate ceiling",
+		policy: ConsolidationPolicy{
+			Threshold: decimal.NewFromFloat(0.001),
+		},
+	}, {
+		name: "single input",
+		policy: ConsolidationPolicy{
+			Threshold:  decimal.NewFromFloat(0.001),
+			MaxFeeRate: 5,
+			MinInputs:  1,
+		},
+	}, {
+		name: "min inputs exceed max inputs",
+		policy: ConsolidationPolicy{
+			Threshold:  decimal.NewFromFloat(0.001),
+			MaxFeeRate: 5,
+			MaxInputs:  5,
+		},
+	}}
+
+	for _, test := range tests {
+		err := test.policy.Validate()
+		if test.valid && err != nil {
+			t.Fatalf("%v: policy should be valid: %v", test.name, err)
+		} else if !test.valid && err == nil {
+			t.Fatalf("%v: policy should be invalid", test.name)
+		}
+	}
+}
@@ -2,11 +2,12 @@ package bitcoind
 
 import (
 	"bytes"
+	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"encoding/hex"
 	"github.com/bitlum/connector/common"
 	"github.com/bitlum/connector/connectors"
 	"github.com/bitlum/connector/connectors/rpc"
@@ -14,6 +15,7 @@ import (
 	"github.com/bitlum/connector/metrics/crypto"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btclog"
 	"github.com/btcsuite/btcutil"
@@ -27,17 +29,21 @@ var (
 	// accounts available.
 	allAccounts = "*"
 
-	// defaultAccount denotes default account of wallet.
+	// defaultAccount denotes default account of wallet, change and
+	// loopback outputs are sent on it.
 	defaultAccount = ""
 
-	// minimumFeeRate is the minimal satoshis which we should pay for one byte
-	//  of information in blockchain.
-	minimumFeeRate = decimal.NewFromFloat(1.0)
+	// depositAccount is the account of the deposit addresses, it is used to
+	// distinguish incoming payments from the change outputs.
+	depositAccount = "zigzag"
 
-	// This value is calculated as being optimal by running emulation of
+
+	// defaultOptimalUTXOValue is the value of the outputs into which large
+	// unspent outputs are split, if reorganisation policy doesn't specify
+	// it. This value is calculated as being optimal by running emulation of
 	// activity on payserver on 18 Nov 2018. This value is optimised to have
 	// spent less fee, at the same time having high success payment  rate.
-	optimalUTXOValue, _ = btcutil.NewAmount(0.00806451)
+	defaultOptimalUTXOValue, _ = btcutil.NewAmount(0.00806451)
 )
 
 // Config is a bitcoind config.
@@ -53,10 +59,6 @@ type Config struct {
 	// loop (in seconds).
 	SyncLoopDelay int
 
-	// InputReorgLoopDelay is used to tweak delay of large inputa
-	// reorganisations proceeding loop (in seconds).
-	InputReorgLoopDelay int
-
 	// SyncUnspentLoopDelay is used to tweak delay of sync unspent proceesing
 	// loop (in seconds).
 	SyncUnspentLoopDelay int
@@ -91,6 +93,51 @@ type Config struct {
 	// StateStorage is used to keep data which is needed for connector to
 	// properly synchronise and track transactions.
 	StateStorage connectors.StateStorage
+
+	// RetryPolicy determines how outgoing payments which couldn't be sent
+	// are retried.
+	RetryPolicy connectors.RetryPolicy
+
+	// AttemptsStorage is used to persist attempts of the outgoing
+	// payments which are being retried.
+	AttemptsStorage connectors.AttemptsStorage
+
+	// FeePolicy determines how fee rates of the payments are estimated.
+	FeePolicy connectors.FeePolicy
+
+	// CoinSelector is the strategy of choosing the unspent outputs which
+	// are spent by the transactions. If it isn't specified largest first
+	// strategy is used.
+	CoinSelector CoinSelector
+
+	// FeeBumpPolicy determines how fee of the stuck outgoing payments is
+	// bumped.
+	FeeBumpPolicy connectors.FeeBumpPolicy
+
+	// ReplacementsStorage is used to map the transactions which have
+	// replaced the original ones to the payments.
+	ReplacementsStorage connectors.ReplacementsStorage
+
+	// DerivationPolicy determines the extended public key from which
+	// deposit addresses are derived, instead of being created by the
+	// daemon wallet.
+	DerivationPolicy connectors.DerivationPolicy
+
+	// DerivationStorage is used to keep the index of the next derived
+	// deposit address, and of the next derived cold storage address.
+	DerivationStorage connectors.DerivationStorage
+
+	// TreasuryPolicy determines how much funds are kept in the wallet of
+	// the daemon, and the cold storage to which the excess is swept.
+	TreasuryPolicy connectors.TreasuryPolicy
+
+	// ConsolidationPolicy determines when small unspent outputs of the
+	// wallet are merged into one output.
+	ConsolidationPolicy connectors.ConsolidationPolicy
+
+	// ReorganisationPolicy determines how large unspent outputs of the
+	// wallet are split into the outputs of the optimal value.
+	ReorganisationPolicy connectors.ReorganisationPolicy
 }
 
 func (c *Config) validate() error {
@@ -115,10 +162,6 @@ func (c *Config) validate() error {
 		c.SyncLoopDelay = 5
 	}
 
-	if c.InputReorgLoopDelay == 0 {
-		c.InputReorgLoopDelay = 5
-	}
-
 	if c.SyncUnspentLoopDelay == 0 {
 		c.SyncUnspentLoopDelay = 5
 	}
@@ -139,11 +182,44 @@ func (c *Config) validate() error {
 		return errors.New("payment store should be specified")
 	}
 
+	if c.StateStorage == nil {
+		return errors.New("state storage should be specified")
+	}
+
+	if c.AttemptsStorage == nil {
+		return errors.New("attempts storage should be specified")
+	}
+
+	if c.ReplacementsStorage == nil {
+		return errors.New("replacements storage should be specified")
+	}
+
+	if c.DerivationPolicy.Enabled() && c.DerivationStorage == nil {
+		return errors.New("derivation storage should be specified")
+	}
+
+	if err := c.TreasuryPolicy.Validate(); err != nil {
+		return errors.Errorf("invalid treasury policy: %v", err)
+	}
+
+	if err := c.ConsolidationPolicy.Validate(); err != nil {
+		return errors.Errorf("invalid consolidation policy: %v", err)
+	}
+
+	if err := c.ReorganisationPolicy.Validate(); err != nil {
+		return errors.Errorf("invalid reorganisation policy: %v", err)
+	}
+
+	if c.CoinSelector == nil {
+		c.CoinSelector, _ = NewCoinSelector(LargestFirst)
+	}
+
 	return nil
 }
 
 // Connector implements connectors.BlockchainConnector interface for bitcoind
-// client.
+// client. Unlike the simple connector, it crafts and signs transactions
+// itself, selecting inputs from the locally cached unspent outputs.
 type Connector struct {
 	started  int32
 	shutdown int32
@@ -153,13 +229,12 @@ type Connector struct {
 	cfg    *Config
 	client rpc.Client
 
-	// pending is a map of blockhain pending payments,
-	// which hasn't been confirmed from connector point of view.
-	// TODO(andrew.shvv) Remove because now we could use storage directly
-	pending map[string][]*connectors.Payment
-
 	lastSyncedBlock *rpc.BlockVerboseResp
 
+	// syncedHeight is the height of the last synced block, it is updated
+	// atomically.
+	syncedHeight int64
+
 	netParams *chaincfg.Params
 	log       *common.NamedLogger
 
@@ -170,18 +245,60 @@ type Connector struct {
 	// unspentSyncMtx is used to lock the utxo local map during is
 	// usage/population.
 	unspentSyncMtx sync.Mutex
+
+	// sendMtx is used to prevent the same payment to be sent twice, in case
+	// of the concurrent requests with the same idempotency key.
+	sendMtx sync.Mutex
+
+	// sender sends outgoing payments and retries the ones which couldn't
+	// be sent.
+	sender *connectors.PaymentSender
+
+	// oracle estimates fee rates of the payment priorities.
+	oracle *connectors.FeeOracle
+
+	// replacer bumps fee of the pending payments by replacing their
+	// transactions.
+	replacer *Replacer
+
+	// deriver derives deposit addresses from the extended public key, it
+	// is nil if addresses are created by the daemon wallet.
+	deriver *AddressDeriver
+
+	// treasurer sweeps excess of the wallet to the cold storage, it is nil
+	// if sweeping is disabled.
+	treasurer *connectors.Treasurer
+	cold      *ColdStorage
+
+	// consolidator merges small unspent outputs of the wallet, it is nil
+	// if consolidation is disabled.
+	consolidator *Consolidator
+
+	lifecycle connectors.Lifecycle
 }
 
 // A compile time check to ensure Connector implements the BlockchainConnector
 // interface.
-//var _ connectors.BlockchainConnector = (*Connector)(nil)
+var _ connectors.BlockchainConnector = (*Connector)(nil)
+
+// A compile time check to ensure Connector implements the FeeBumper
+// interface.
+var _ connectors.FeeBumper = (*Connector)(nil)
+
+// A compile time check to ensure Connector implements the InputsReorganiser
+// interface.
+var _ connectors.InputsReorganiser = (*Connector)(nil)
+
+// A compile time check to ensure Connector implements the MultiSender
+// interface.
+var _ connectors.MultiSender = (*Connector)(nil)
 
 func NewConnector(cfg *Config) (*Connector, error) {
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
 
-	return &Connector{
+	c := &Connector{
 		cfg:    cfg,
 		quit:   make(chan struct{}),
 		client: cfg.RPCClient,
@@ -189,7 +306,125 @@ func NewConnector(cfg *Config) (*Connector, error) {
 			Name:   string(cfg.Asset),
 			Logger: cfg.Logger,
 		},
-	}, nil
+	}
+
+	var err error
+	c.sender, err = connectors.NewPaymentSender(&connectors.PaymentSenderConfig{
+		Asset:        cfg.Asset,
+		Media:        connectors.Blockchain,
+		Policy:       cfg.RetryPolicy,
+		Storage:      cfg.AttemptsStorage,
+		PaymentStore: cfg.PaymentStore,
+		Send:         c.sendPayment,
+		Lookup:       c.lookupPayment,
+		Abandon:      c.abandonPayment,
+		Locker:       &c.sendMtx,
+		Logger:       cfg.Logger,
+	})
+	if err != nil {
+		return nil, errors.Errorf("unable to create payment sender: %v", err)
+	}
+
+	c.oracle, err = connectors.NewFeeOracle(&connectors.FeeOracleConfig{
+		Asset:    cfg.Asset,
+		Policy:   cfg.FeePolicy,
+		Estimate: c.estimateFeeRate,
+		Fallback: decimal.New(int64(cfg.FeePerByte), 0),
+		Logger:   cfg.Logger,
+	})
+	if err != nil {
+		return nil, errors.Errorf("unable to create fee oracle: %v", err)
+	}
+
+	c.replacer, err = NewReplacer(&ReplacerConfig{
+		Asset:            cfg.Asset,
+		Client:           cfg.RPCClient,
+		PaymentStore:     cfg.PaymentStore,
+		Storage:          cfg.ReplacementsStorage,
+		Policy:           cfg.FeeBumpPolicy,
+		MaxFeeRate:       cfg.FeePolicy.MaxFeeRate,
+		MinConfirmations: cfg.MinConfirmations,
+		FeeRate:          c.getFeeRate,
+		SyncedHeight: func() int64 {
+			return atomic.LoadInt64(&c.syncedHeight)
+		},
+		DecodeAddress: func(address string) (btcutil.Address, error) {
+			return decodeAddress(cfg.Asset, address, c.netParams.Name)
+		},
+		Replaced: c.replacedPayment,
+		Locker:   &c.sendMtx,
+		Logger:   cfg.Logger,
+	})
+	if err != nil {
+		return nil, errors.Errorf("unable to create replacer: %v", err)
+	}
+
+	if cfg.DerivationPolicy.Enabled() {
+		c.deriver, err = NewAddressDeriver(&AddressDeriverConfig{
+			Asset:       cfg.Asset,
+			Policy:      cfg.DerivationPolicy,
+			Client:      cfg.RPCClient,
+			Storage:     cfg.DerivationStorage,
+			Label:       depositAccount,
+			ChangeLabel: defaultAccount,
+			Logger:      cfg.Logger,
+		})
+		if err != nil {
+			return nil, errors.Errorf("unable to create address deriver: %v",
+				err)
+		}
+	}
+
+	if cfg.TreasuryPolicy.Enabled() {
+		c.cold, c.treasurer, err = NewTreasury(&TreasuryConfig{
+			Asset:   cfg.Asset,
+			Daemon:  cfg.RPCClient.DaemonName(),
+			Policy:  cfg.TreasuryPolicy,
+			Client:  cfg.RPCClient,
+			Storage: cfg.DerivationStorage,
+			NetParams: func() *chaincfg.Params {
+				return c.netParams
+			},
+			Balance:     c.ConfirmedBalance,
+			FeeRate:     c.getFeeRate,
+			EstimateFee: c.EstimateTxFee,
+			Create: func(address, amount string,
+				priority connectors.FeePriority) (*connectors.Payment, error) {
+
+				payment, _, err := c.createPayment(address, amount, "",
+					priority, connectors.Internal)
+				return payment, err
+			},
+			Send:    c.sender.Send,
+			Locker:  &c.sendMtx,
+			Metrics: cfg.Metrics,
+			Logger:  cfg.Logger,
+		})
+		if err != nil {
+			return nil, errors.Errorf("unable to create treasurer: %v", err)
+		}
+	}
+
+	if cfg.ConsolidationPolicy.Enabled() {
+		c.consolidator, err = NewConsolidator(&ConsolidatorConfig{
+			Asset:            cfg.Asset,
+			Policy:           cfg.ConsolidationPolicy,
+			Client:           cfg.RPCClient,
+			PaymentStore:     cfg.PaymentStore,
+			MinConfirmations: cfg.MinConfirmations,
+			FeeRate:          c.getFeeRate,
+			Send:             c.sender.Send,
+			Spent:            c.removeUnspent,
+			Locker:           &c.sendMtx,
+			Logger:           cfg.Logger,
+		})
+		if err != nil {
+			return nil, errors.Errorf("unable to create consolidator: %v",
+				err)
+		}
+	}
+
+	return c, nil
 }
 
 func (c *Connector) Start() (err error) {
@@ -204,6 +439,8 @@ func (c *Connector) Start() (err error) {
 		if err != nil {
 			atomic.SwapInt32(&c.started, 0)
 		}
+
+		c.lifecycle.Started(err)
 	}()
 
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
@@ -229,6 +466,18 @@ func (c *Connector) Start() (err error) {
 		return errors.Errorf("failed to get net params: %v", err)
 	}
 
+	if c.deriver != nil {
+		if err := c.deriver.ValidateNet(c.netParams); err != nil {
+			return errors.Errorf("unable to derive addresses: %v", err)
+		}
+	}
+
+	if c.cold != nil {
+		if err := c.cold.ValidateNet(c.netParams); err != nil {
+			return errors.Errorf("unable to sweep to cold storage: %v", err)
+		}
+	}
+
 	// Initialize cache with the last synced block hash.
 	c.log.Info("Getting last synced block hash...")
 	var lastSyncedBlockHash *chainhash.Hash
@@ -255,17 +504,26 @@ func (c *Connector) Start() (err error) {
 		return errors.Errorf("unable to fetch last synced block with hash("+
 			"%v): %v", lastSyncedBlockHash, err)
 	}
+	atomic.StoreInt64(&c.syncedHeight, c.lastSyncedBlock.Height)
 
 	c.log.Infof("Last synced block, hash(%v), heigh(%v)",
 		lastSyncedBlockHash, c.lastSyncedBlock.Height)
 
-	// First of all unlock all unspent outputs, to exclude the situation where
-	// we accidentally locked inputs and server crashed.
+	// Inputs of the waiting payments are locked, so that they wouldn't be
+	// used by other transactions. Daemon keeps locks only in memory, that
+	// is why they have to be restored, and all other inputs are unlocked
+	// to exclude the situation where we accidentally locked inputs and
+	// server crashed.
 	c.log.Debugf("Unlocking unspent inputs...")
 	if err := c.client.UnlockUnspent(); err != nil {
 		return errors.Errorf("unable to unlock unspent outputs")
 	}
 
+	if err := c.lockWaitingInputs(); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return err
+	}
+
 	c.wg.Add(1)
 	go func() {
 		defer func() {
@@ -326,53 +584,118 @@ func (c *Connector) Start() (err error) {
 		}
 	}()
 
+	if c.cfg.ReorganisationPolicy.Enabled() {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			c.log.Info("Starting large inputs reorganisation goroutine...")
+			c.runReorganisation()
+			c.log.Info("Quit large inputs reorganisation goroutine")
+		}()
+	}
+
 	c.wg.Add(1)
 	go func() {
-		defer func() {
-			c.log.Info("Quit reorganisation goroutine")
-			c.wg.Done()
-		}()
+		defer c.wg.Done()
 
-		reorgInputsTicker := time.NewTicker(time.Second *
-			time.Duration(c.cfg.InputReorgLoopDelay))
-		defer reorgInputsTicker.Stop()
+		c.log.Info("Starting payments retry goroutine...")
+		c.sender.Run(c.quit)
+		c.log.Info("Quit payments retry goroutine")
+	}()
 
-		for {
-			select {
-			case <-reorgInputsTicker.C:
-				if err := c.reorgLargeInputs(); err != nil {
-					m.AddError(metrics.MiddleSeverity)
-					c.log.Errorf("unable to reorganise utxo: %v", err)
-					continue
-				}
-			case <-c.quit:
-				return
-			}
-		}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		c.log.Info("Starting fee rates estimation goroutine...")
+		c.oracle.Run(c.quit)
+		c.log.Info("Quit fee rates estimation goroutine")
 	}()
 
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		c.log.Info("Starting fee bumping goroutine...")
+		c.replacer.Run(c.quit)
+		c.log.Info("Quit fee bumping goroutine")
+	}()
+
+	if c.treasurer != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			c.log.Info("Starting cold storage sweeping goroutine...")
+			c.treasurer.Run(c.quit)
+			c.log.Info("Quit cold storage sweeping goroutine")
+		}()
+	}
+
+	if c.consolidator != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			c.log.Info("Starting unspent outputs consolidation goroutine...")
+			c.consolidator.Run(c.quit)
+			c.log.Info("Quit unspent outputs consolidation goroutine")
+		}()
+	}
+
 	return err
 }
 
-func (c *Connector) Stop(reason string) {
+func (c *Connector) Stop(reason string) error {
 	if !atomic.CompareAndSwapInt32(&c.shutdown, 0, 1) {
 		c.log.Warn("client already shutdown")
-		return
+		return nil
 	}
 
 	c.log.Infof("client shutting down (reason: %v)...", reason)
 	close(c.quit)
 
 	c.wg.Wait()
+	c.lifecycle.Stopped()
 
 	c.log.Info("client shutdown")
+	return nil
 }
 
-func (c *Connector) WaitShutDown() <-chan struct{} {
-	return c.quit
+// Status returns the lifecycle state of the connector, and the sync state
+// of the connector and its daemon.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) Status() *connectors.ConnectorStatus {
+	status := c.lifecycle.Status()
+	status.Net = c.cfg.Net
+	status.MinConfirmations = c.cfg.MinConfirmations
+
+	status.SyncedHeight = atomic.LoadInt64(&c.syncedHeight)
+
+	chainInfo, err := c.client.GetBlockChainInfo()
+	if err != nil {
+		status.DaemonError = connectors.WrapDaemonError(
+			c.client.DaemonName(), err).Error()
+		return status
+	}
+	status.BestHeight = chainInfo.Blocks
+
+	networkInfo, err := c.client.GetNetworkInfo()
+	if err != nil {
+		status.DaemonError = connectors.WrapDaemonError(
+			c.client.DaemonName(), err).Error()
+		return status
+	}
+	status.DaemonVersion = networkInfo.SubVersion
+
+	return status
 }
 
-// CreateAddress is used to create deposit address.
+// CreateAddress is used to create deposit address. If extended public key
+// is specified, address is derived from it, otherwise it is created by the
+// daemon wallet.
 //
 // NOTE: Part of the connectors.BlockchainConnector interface.
 func (c *Connector) CreateAddress() (string, error) {
@@ -380,614 +703,1002 @@ func (c *Connector) CreateAddress() (string, error) {
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	address, err := c.client.GetNewAddress("zigzag")
+	if c.deriver != nil {
+		address, err := c.deriver.NewAddress(c.netParams)
+		if err != nil {
+			m.AddError(metrics.HighSeverity)
+			return "", err
+		}
+
+		return address.String(), nil
+	}
+
+	address, err := c.client.GetNewAddress(depositAccount)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return "", err
+		return "", connectors.WrapDaemonError(c.client.DaemonName(), err)
 	}
 
 	return address.String(), nil
 }
 
-// CreatePayment generates the payment, but not sends it,
-// instead returns the payment id and waits for it to be approved.
+// SendPayment creates transaction which sends given amount to the given
+// address with the fee rate of the priority, and sends it to the blockchain
+// network. If transaction couldn't be sent, it is retried. Default priority
+// is the normal one.
 //
 // NOTE: Part of the connectors.BlockchainConnector interface.
-func (c *Connector) CreatePayment(address string, amount string) (*connectors.Payment, error) {
+func (c *Connector) SendPayment(address, amount, idempotencyKey string,
+	priority connectors.FeePriority) (*connectors.Payment, error) {
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	decodedAddress, err := decodeAddress(c.cfg.Asset, address, c.netParams.Name)
-	if err != nil {
-		m.AddError(metrics.LowSeverity)
-		return nil, errors.Errorf("invalid address: %v", err)
-	}
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
 
-	amtInBtc, err := decimal.NewFromString(amount)
+	payment, created, err := c.createPayment(address, amount, idempotencyKey,
+		priority, connectors.External)
 	if err != nil {
-		m.AddError(metrics.LowSeverity)
-		return nil, errors.Errorf("unable to decode amount: %v", err)
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	} else if !created {
+		c.log.Infof("Payment(%v) with idempotency key(%v) has been already "+
+			"sent", payment.PaymentID, idempotencyKey)
+		return payment, nil
 	}
 
-	feeSatoshiPerByte := uint64(c.getFeeRate().IntPart())
-	amtInSat := decAmount2Sat(amtInBtc)
-	tx, fee, changeAmt, changAddr, err := c.craftTransaction(feeSatoshiPerByte,
-		amtInSat, decodedAddress)
+	payment, err = c.sender.Send(payment)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable to generate new transaction: %v", err)
+		return nil, err
 	}
 
-	signedTx, err := c.client.SignRawTransaction(tx)
+	return payment, nil
+}
+
+// SendPayments creates single transaction which pays to all recipients with
+// the fee rate of the default priority, and sends it to the blockchain
+// network. Every recipient gets its own payment, and fee of the transaction
+// is split between them pro-rata to their amounts. If transaction couldn't
+// be sent, it is retried along with all of its payments.
+//
+// NOTE: Part of the connectors.MultiSender interface.
+func (c *Connector) SendPayments(recipients []*connectors.Recipient,
+	idempotencyKey string) ([]*connectors.Payment, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	amounts, err := connectors.RecipientAmounts(recipients)
 	if err != nil {
-		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable to sign generated transaction: %v", err)
+		m.AddError(metrics.LowSeverity)
+		return nil, err
 	}
 
-	var rawTx bytes.Buffer
-	if err := signedTx.Serialize(&rawTx); err != nil {
-		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable serialize signed tx: %v", err)
+	outputs := make(map[btcutil.Address]btcutil.Amount, len(recipients))
+	receipts := make(map[string]struct{}, len(recipients))
+	for _, recipient := range recipients {
+		address, amount, err := c.parseRecipient(recipient.Address,
+			recipient.Amount)
+		if err != nil {
+			m.AddError(metrics.LowSeverity)
+			return nil, err
+		}
+
+		if _, ok := receipts[recipient.Address]; ok {
+			m.AddError(metrics.LowSeverity)
+			return nil, &connectors.ErrInvalidReceipt{
+				Reason: errors.Errorf("address(%v) is specified several "+
+					"times", recipient.Address),
+			}
+		}
+
+		receipts[recipient.Address] = struct{}{}
+		outputs[address] = decAmount2Sat(amount)
 	}
 
-	txID := signedTx.TxHash().String()
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
 
-	payment := &connectors.Payment{
-		UpdatedAt: connectors.NowInMilliSeconds(),
-		Status:    connectors.Waiting,
-		Direction: connectors.Outgoing,
-		System:    connectors.External,
-		Receipt:   address,
-		Asset:     connectors.Asset(c.cfg.Asset),
-		Media:     connectors.Blockchain,
-		Amount:    amtInBtc.Round(8),
-		MediaFee:  sat2DecAmount(fee),
-		MediaID:   txID,
-		Detail: &connectors.GeneratedTxDetails{
-			RawTx: rawTx.Bytes(),
-			TxID:  txID,
-		},
+	payments, err := connectors.PaymentsByIdempotencyKey(c.cfg.PaymentStore,
+		idempotencyKey, c.cfg.Asset, connectors.Blockchain, recipients,
+		amounts)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	} else if payments != nil {
+		c.log.Infof("Payments with idempotency key(%v) have been already "+
+			"sent", idempotencyKey)
+		return payments, nil
 	}
 
-	payment.PaymentID, err = payment.GenPaymentID()
+	payments, err = c.createPayments(recipients, amounts, outputs,
+		idempotencyKey)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable generate payment id: %v", err)
+		return nil, err
 	}
 
-	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+	// Transaction is sent and retried along with the first payment, the
+	// other payments are updated after it. Payment which is going to be
+	// retried is marked as pending by the sender itself.
+	payment, err := c.sender.Send(payments[0])
+	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable add payment in store: %v", err)
+		return nil, err
 	}
+	payments[0] = payment
+	c.updateSiblingPayments(payment)
 
-	c.log.Infof("Create payment %v", spew.Sdump(payment))
-
-	// Also save internal change to ourselves for the record.
-	// For every payment to ourselves we have to create one outgoing and one
-	// incoming payment. Incoming payment will be created when unspent
-	// outputs will be synced.
-	if changeAmt != 0 {
-		changePayment := &connectors.Payment{
-			UpdatedAt: connectors.NowInMilliSeconds(),
-			Status:    connectors.Waiting,
-			Direction: connectors.Outgoing,
-			System:    connectors.Internal,
-			Receipt:   changAddr.String(),
-			Asset:     connectors.Asset(c.cfg.Asset),
-			Media:     connectors.Blockchain,
-			Amount:    sat2DecAmount(changeAmt).Round(8),
-			MediaFee:  sat2DecAmount(fee),
-			MediaID:   txID,
-			Detail:    nil,
-		}
-
-		changePayment.PaymentID, err = changePayment.GenPaymentID()
-		if err != nil {
-			m.AddError(metrics.HighSeverity)
-			return nil, errors.Errorf("unable generate payment id: %v", err)
-		}
-
-		if err := c.cfg.PaymentStore.SavePayment(changePayment); err != nil {
-			m.AddError(metrics.HighSeverity)
-			return nil, errors.Errorf("unable add payment in store: %v", err)
-		}
+	if err := c.reloadPayments(payments[1:]); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
 	}
 
-	return payment, nil
+	c.log.Infof("Send %v payments in transaction(%v)", len(payments),
+		payment.MediaID)
+
+	return payments, nil
 }
 
-// SendPayment sends created previously payment to the
-// blockchain network.
+// CreatePayment creates and signs transaction which sends given amount to
+// the given address, and stores it as waiting payment with the exact fee.
+// If transaction has change, the change output is stored as the internal
+// payment. Inputs of the transaction are locked until payment is confirmed
+// or canceled.
 //
 // NOTE: Part of the connectors.BlockchainConnector interface.
-func (c *Connector) SendPayment(paymentID string) (*connectors.Payment, error) {
+func (c *Connector) CreatePayment(address, amount,
+	idempotencyKey string) (*connectors.Payment, error) {
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	payment, err := c.cfg.PaymentStore.PaymentByID(paymentID)
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, created, err := c.createPayment(address, amount, idempotencyKey,
+		connectors.DefaultPriority, connectors.External)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable find payment(%v): %v", paymentID,
-			err)
+		return nil, err
+	} else if !created {
+		c.log.Infof("Payment(%v) with idempotency key(%v) has been already "+
+			"created", payment.PaymentID, idempotencyKey)
 	}
 
-	details, ok := payment.Detail.(*connectors.GeneratedTxDetails)
-	if !ok {
-		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable get details for payment(%v)",
-			paymentID)
-	}
+	return payment, nil
+}
 
-	wireTx := new(wire.MsgTx)
-	r := bytes.NewBuffer(details.RawTx)
+// createPayment creates and signs transaction with the fee rate of the
+// priority, and saves it as the waiting payment of the given system. If
+// payment with the given idempotency key already exists, it is returned
+// instead, and false is returned as the second value.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) createPayment(address, amount, idempotencyKey string,
+	priority connectors.FeePriority, system connectors.PaymentSystem) (
+	*connectors.Payment, bool, error) {
 
-	if err := wireTx.Deserialize(r); err != nil {
-		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable to deserialize raw tx: %v", err)
+	decodedAddress, amtInBtc, err := c.parseRecipient(address, amount)
+	if err != nil {
+		return nil, false, err
 	}
 
-	// We were given only payment id of external payment, and in order to
-	// update status of change output, we have to generate payment of it.
-	changePayment := &connectors.Payment{
-		MediaID:   payment.MediaID,
-		Receipt:   payment.Receipt,
-		Direction: payment.Direction,
-		System:    connectors.Internal,
+	payment, err := connectors.PaymentByIdempotencyKey(c.cfg.PaymentStore,
+		idempotencyKey, c.cfg.Asset, connectors.Blockchain, address, amtInBtc)
+	if err != nil {
+		return nil, false, err
+	} else if payment != nil {
+		return payment, false, nil
 	}
 
-	changePayment.PaymentID, err = changePayment.GenPaymentID()
+	amtInSat := decAmount2Sat(amtInBtc)
+	feeSatoshiPerByte := uint64(c.getFeeRate(priority).Ceil().IntPart())
+	tx, fee, changeAmt, changeAddr, err := c.craftTransaction(feeSatoshiPerByte,
+		map[btcutil.Address]btcutil.Amount{decodedAddress: amtInSat})
 	if err != nil {
-		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable generate payment id: %v", err)
+		return nil, false, err
 	}
 
-	spew.Dump(changePayment)
-	changePayment, err = c.cfg.PaymentStore.PaymentByID(changePayment.PaymentID)
-	if err != nil {
-		// If change output / payment hasn't been found that means that it
-		// doesn't exist, and that is normal
-		changePayment = nil
-		spew.Dump(err)
+	if SupportsRBF(c.cfg.Asset) {
+		SignalRBF(tx)
 	}
 
-	if err = c.client.SendRawTransaction(wireTx); err != nil {
-		payment.Status = connectors.Failed
-		payment.UpdatedAt = connectors.NowInMilliSeconds()
+	txID, rawTx, err := c.signTransaction(tx)
+	if err != nil {
+		return nil, false, err
+	}
 
-		if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-			m.AddError(metrics.HighSeverity)
-			c.log.Errorf("unable update payment(%v) status to fail: %v",
-				payment.PaymentID, err)
-		}
+	details := &connectors.GeneratedTxDetails{
+		RawTx: rawTx,
+		TxID:  txID,
+	}
+	if changeAddr != nil {
+		details.ChangeAddress = changeAddr.String()
+	}
 
-		if changePayment != nil {
-			changePayment.Status = connectors.Failed
-			changePayment.UpdatedAt = connectors.NowInMilliSeconds()
-			if err := c.cfg.PaymentStore.SavePayment(changePayment); err != nil {
-				m.AddError(metrics.HighSeverity)
-				c.log.Errorf("unable update payment(%v) status to fail: %v",
-					changePayment.PaymentID, err)
-			}
-		}
+	payment = &connectors.Payment{
+		UpdatedAt:      connectors.NowInMilliSeconds(),
+		Status:         connectors.Waiting,
+		Direction:      connectors.Outgoing,
+		System:         system,
+		Receipt:        address,
+		Asset:          c.cfg.Asset,
+		Media:          connectors.Blockchain,
+		Amount:         amtInBtc.Round(8),
+		MediaFee:       sat2DecAmount(fee),
+		MediaID:        txID,
+		Detail:         details,
+		IdempotencyKey: idempotencyKey,
+	}
 
-		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable to send payment(%v): %v",
-			paymentID, err)
+	payment.PaymentID, err = payment.GenPaymentID()
+	if err != nil {
+		UnlockInputs(c.client, txInputs(tx), c.log)
+		return nil, false, errors.Errorf("unable generate payment id: %v", err)
 	}
 
-	payment.Status = connectors.Pending
-	payment.UpdatedAt = connectors.NowInMilliSeconds()
 	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-		m.AddError(metrics.HighSeverity)
-		c.log.Errorf("unable update payment(%v) status to pending: %v",
-			payment.PaymentID, err)
+		UnlockInputs(c.client, txInputs(tx), c.log)
+		return nil, false, errors.Errorf("unable add payment in store: %v",
+			err)
 	}
 
-	if changePayment != nil {
-		// We have to update circular payment aka change output status as well.
-		changePayment.Status = connectors.Pending
-		changePayment.UpdatedAt = connectors.NowInMilliSeconds()
-		if err := c.cfg.PaymentStore.SavePayment(changePayment); err != nil {
-			m.AddError(metrics.HighSeverity)
-			c.log.Errorf("unable update payment(%v) status to pending: %v",
-				changePayment.PaymentID, err)
-		}
-	}
+	c.log.Infof("Create payment %v", spew.Sdump(payment))
 
-	c.log.Infof("Send payment %v", spew.Sdump(payment))
+	if err := c.createChangePayment(txID, changeAddr, changeAmt); err != nil {
+		c.failCreatedPayments([]*connectors.Payment{payment}, txInputs(tx),
+			err)
+		return nil, false, err
+	}
 
-	return payment, nil
+	return payment, true, nil
 }
 
-// ConfirmedBalance returns number of funds which could be used for sending.
+// createPayments creates and signs single transaction with the given
+// outputs and the fee rate of the default priority, and saves the waiting
+// external payment for every recipient. Fee of the transaction is split
+// between payments pro-rata to their amounts. Transaction doesn't signal
+// replace-by-fee, because its replacement would have to update all of its
+// payments.
 //
-// NOTE: Part of the connectors.Connector interface.
-func (c *Connector) ConfirmedBalance() (decimal.Decimal, error) {
-	balance, err := c.client.GetBalanceByLabel(allAccounts, c.cfg.MinConfirmations)
+// NOTE: Should be called under the send mutex.
+func (c *Connector) createPayments(recipients []*connectors.Recipient,
+	amounts []decimal.Decimal, outputs map[btcutil.Address]btcutil.Amount,
+	idempotencyKey string) ([]*connectors.Payment, error) {
+
+	feeRatePerByte := uint64(c.getFeeRate(connectors.DefaultPriority).
+		Ceil().IntPart())
+	tx, fee, changeAmt, changeAddr, err := c.craftTransaction(feeRatePerByte,
+		outputs)
 	if err != nil {
-		return decimal.Zero, err
+		return nil, err
 	}
 
-	return decimal.NewFromFloat(balance.ToBTC()).Round(8), nil
-}
+	txID, rawTx, err := c.signTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
 
-// PendingBalance return the amount of funds waiting ro be confirmed.
-//
-// NOTE: Part of the connectors.BlockchainConnector interface.
-func (c *Connector) PendingBalance() (decimal.Decimal, error) {
-	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
-		common.GetFunctionName(), c.cfg.Metrics)
-	defer m.Finish()
+	shares := connectors.SplitFee(sat2DecAmount(fee), amounts, 8)
+	payments := make([]*connectors.Payment, len(recipients))
+	for i, recipient := range recipients {
+		details := &connectors.GeneratedTxDetails{
+			RawTx: rawTx,
+			TxID:  txID,
+		}
+		if changeAddr != nil {
+			details.ChangeAddress = changeAddr.String()
+		}
 
-	// TODO(andrew.shvv) Use storage for getting pending transaction and
-	// calculation of pending balance.
+		payment := &connectors.Payment{
+			UpdatedAt: connectors.NowInMilliSeconds(),
+			Status:    connectors.Waiting,
+			Direction: connectors.Outgoing,
+			System:    connectors.External,
+			Receipt:   recipient.Address,
+			Asset:     c.cfg.Asset,
+			Media:     connectors.Blockchain,
+			Amount:    amounts[i].Round(8),
+			MediaFee:  shares[i],
+			MediaID:   txID,
+			Detail:    details,
+			IdempotencyKey: connectors.RecipientIdempotencyKey(
+				idempotencyKey, i),
+		}
 
-	var amount decimal.Decimal
+		payment.PaymentID, err = payment.GenPaymentID()
+		if err != nil {
+			UnlockInputs(c.client, txInputs(tx), c.log)
+			return nil, errors.Errorf("unable generate payment id: %v", err)
+		}
 
-	// Return pending balance from all accounts
-	for _, pendingPayments := range c.pending {
-		for _, payment := range pendingPayments {
-			amount = amount.Add(payment.Amount)
+		if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+			UnlockInputs(c.client, txInputs(tx), c.log)
+			return nil, errors.Errorf("unable add payment in store: %v", err)
 		}
+
+		payments[i] = payment
 	}
 
-	return amount.Round(8), nil
+	c.log.Infof("Create %v payments in transaction(%v), fee(%v)",
+		len(payments), txID, printAmount(fee))
+
+	if err := c.createChangePayment(txID, changeAddr, changeAmt); err != nil {
+		c.failCreatedPayments(payments, txInputs(tx), err)
+		return nil, err
+	}
+
+	return payments, nil
 }
 
-// syncUnconfirmed updates status of incoming payments, from waiting to
-// pending, as well as blockchain detail and number of transaction needed for
-// it to be confirmed.
-func (c *Connector) syncUnconfirmed() error {
-	// Return set of non-confirmed from our point of view incoming
-	// transactions.
-	txs, err := c.client.ListUnspentMinMax(0, int(c.cfg.MinConfirmations-1))
+// signTransaction signs the generated transaction, and returns its id and
+// serialized signed transaction. If transaction couldn't be signed, its
+// inputs are unlocked.
+func (c *Connector) signTransaction(tx *wire.MsgTx) (string, []byte, error) {
+	signedTx, err := c.client.SignRawTransaction(tx)
 	if err != nil {
-		return err
+		UnlockInputs(c.client, txInputs(tx), c.log)
+		return "", nil, errors.Errorf("unable to sign generated "+
+			"transaction: %v", err)
 	}
 
-	c.pending = make(map[string][]*connectors.Payment)
-	for _, tx := range txs {
-		payment := &connectors.Payment{
-			UpdatedAt: connectors.NowInMilliSeconds(),
-			Status:    connectors.Pending,
-			Receipt:   tx.Address,
-			Asset:     c.cfg.Asset,
-			Account:   tx.Account,
-			Media:     connectors.Blockchain,
-			Amount:    decimal.NewFromFloat(tx.Amount),
-			MediaFee:  decimal.Zero,
-			MediaID:   tx.TxID,
-			Detail: &connectors.BlockchainPendingDetails{
-				Confirmations:     tx.Confirmations,
-				ConfirmationsLeft: int64(c.cfg.MinConfirmations) - tx.Confirmations,
-			},
-		}
+	var rawTx bytes.Buffer
+	if err := signedTx.Serialize(&rawTx); err != nil {
+		UnlockInputs(c.client, txInputs(tx), c.log)
+		return "", nil, errors.Errorf("unable serialize signed tx: %v", err)
+	}
 
-		if tx.Account == defaultAccount {
-			payment.Direction = connectors.Incoming
-			payment.System = connectors.Internal
-			payment.MediaFee = decimal.Zero
-			payment.PaymentID, err = payment.GenPaymentID()
-			if err != nil {
-				return errors.Errorf("unable generate payment id: %v", err)
-			}
-		} else {
-			payment.Direction = connectors.Incoming
-			payment.System = connectors.External
-			payment.MediaFee = decimal.Zero
-			payment.PaymentID, err = payment.GenPaymentID()
-			if err != nil {
-				return errors.Errorf("unable generate payment id: %v", err)
-			}
-		}
+	return signedTx.TxHash().String(), rawTx.Bytes(), nil
+}
+
+// createChangePayment saves internal change to ourselves for the record, if
+// transaction has the change. Fee is accounted only in the external
+// payments. For every payment to ourselves we have to create one outgoing
+// and one incoming payment. Incoming payment will be created when unspent
+// outputs will be synced.
+func (c *Connector) createChangePayment(txID string,
+	changeAddr btcutil.Address, changeAmt btcutil.Amount) error {
 
-		// TODO(andrew.shvv) Remove because now we could use storage directly
-		// for pending balance and pending transaction.
-		c.pending[tx.Account] = append(c.pending[tx.Account], payment)
+	if changeAddr == nil {
+		return nil
+	}
+
+	changePayment := &connectors.Payment{
+		UpdatedAt: connectors.NowInMilliSeconds(),
+		Status:    connectors.Waiting,
+		Direction: connectors.Outgoing,
+		System:    connectors.Internal,
+		Receipt:   changeAddr.String(),
+		Asset:     c.cfg.Asset,
+		Media:     connectors.Blockchain,
+		Amount:    sat2DecAmount(changeAmt).Round(8),
+		MediaFee:  decimal.Zero,
+		MediaID:   txID,
+	}
+
+	var err error
+	changePayment.PaymentID, err = changePayment.GenPaymentID()
+	if err != nil {
+		return errors.Errorf("unable generate payment id: %v", err)
+	}
+
+	if err := c.cfg.PaymentStore.SavePayment(changePayment); err != nil {
+		return errors.Errorf("unable add change payment in store: %v", err)
+	}
 
+	return nil
+}
+
+// failCreatedPayments marks saved payments of the transaction, which
+// couldn't be fully created, as failed and unlocks inputs of the
+// transaction, so that payments aren't left waiting with the locked inputs.
+// Errors are only logged, because creation has already failed.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) failCreatedPayments(payments []*connectors.Payment,
+	inputs []rpc.UnspentInput, reason error) {
+
+	for _, payment := range payments {
+		payment.Status = connectors.Failed
+		payment.FailureReason = reason.Error()
+		payment.UpdatedAt = connectors.NowInMilliSeconds()
 		if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-			return errors.Errorf("unable to save payment(%v): %v",
+			c.log.Errorf("unable update payment(%v) status to fail: %v",
 				payment.PaymentID, err)
 		}
+	}
+
+	UnlockInputs(c.client, inputs, c.log)
+}
+
+// parseRecipient decodes the address and the amount of the outgoing
+// payment, and checks that amount is positive.
+func (c *Connector) parseRecipient(address, amount string) (btcutil.Address,
+	decimal.Decimal, error) {
 
-		c.log.Infof("Pending transaction(%v),"+
-			"confirmations left(%v), account(%v), amount(%v)", tx.TxID,
-			int64(c.cfg.MinConfirmations)-tx.Confirmations,
-			accountToAlias(tx.Account), tx.Amount)
+	decodedAddress, err := decodeAddress(c.cfg.Asset, address, c.netParams.Name)
+	if err != nil {
+		return nil, decimal.Zero, &connectors.ErrInvalidReceipt{Reason: err}
 	}
 
-	return nil
+	amtInBtc, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, decimal.Zero, &connectors.ErrInvalidAmount{Amount: amount,
+			Reason: err}
+	}
+
+	if decAmount2Sat(amtInBtc) <= 0 {
+		return nil, decimal.Zero, &connectors.ErrInvalidAmount{
+			Amount: amount,
+			Reason: errors.New("amount should be positive"),
+		}
+	}
+
+	return decodedAddress, amtInBtc, nil
 }
 
-// findForkBlock is used to find block on which fork has happened,
-// at return it, so that syncing could continue.
-func (c *Connector) findForkBlock(orphanBlock *rpc.BlockVerboseResp) (
-	*rpc.BlockVerboseResp, error) {
-	for orphanBlock.Confirmations == -1 {
-		prevHash, err := chainhash.NewHashFromStr(orphanBlock.PreviousHash)
-		if err != nil {
-			return nil, errors.Errorf("unable to decode hash of prev "+
-				"orphan block: %v", err)
+// ConfirmPayment sends previously created waiting payment to the
+// blockchain network. If transaction couldn't be sent, it is retried, and
+// after all attempts payment is marked as failed and its inputs are
+// unlocked.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) ConfirmPayment(paymentID string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, _, err := c.waitingPayment(paymentID)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	// Transaction of the cold storage payment is sent only after it is
+	// signed elsewhere and finalized.
+	if _, ok := payment.Detail.(*connectors.PSBTDetails); ok {
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInvalidPSBT{
+			PaymentID: paymentID,
+			Reason:    errors.New("payment should be finalized with psbt"),
 		}
+	}
 
-		orphanBlock, err = c.client.GetBlockVerboseByHash(prevHash)
-		if err != nil {
-			return nil, errors.Errorf("unable to prev last sync block "+
-				"from daemon: %v", err)
+	payment, err = c.sender.Send(payment)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
 
+	return payment, nil
+}
+
+// sendPayment sends transaction of the payment to the blockchain network,
+// and marks payment and its change as pending. If it fails, inputs stay
+// locked until the payment sender abandons the payment.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) sendPayment(payment *connectors.Payment) (
+	*connectors.Payment, error) {
+
+	tx, err := paymentTx(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.client.SendRawTransaction(tx); err != nil {
+		return nil, convertRPCError(c.client.DaemonName(), err,
+			fmt.Sprintf("unable to send payment(%v)", payment.PaymentID))
+	}
+
+	SetSentHeight(payment, atomic.LoadInt64(&c.syncedHeight))
+	payment.Status = connectors.Pending
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return nil, errors.Errorf("unable update payment(%v) status to "+
+			"pending: %v", payment.PaymentID, err)
+	}
+
+	c.updateChangePayments(payment)
+	c.updateSiblingPayments(payment)
+
+	c.log.Infof("Send payment %v", spew.Sdump(payment))
+
+	return payment, nil
+}
+
+// lookupPayment checks whether transaction of the payment is already known
+// by the wallet, so that it wouldn't be sent twice. If so, payment is marked
+// as pending.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) lookupPayment(payment *connectors.Payment) (
+	*connectors.Payment, error) {
+
+	tx, err := paymentTx(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash := tx.TxHash()
+	if _, err := c.client.GetTransaction(&txHash); err != nil {
+		// Error is returned by the wallet if transaction is unknown.
+		err = connectors.WrapDaemonError(c.client.DaemonName(), err)
+		if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+			return nil, err
 		}
+
+		return nil, nil
 	}
 
-	return orphanBlock, nil
+	SetSentHeight(payment, atomic.LoadInt64(&c.syncedHeight))
+	payment.Status = connectors.Pending
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return nil, errors.Errorf("unable update payment(%v) status to "+
+			"pending: %v", payment.PaymentID, err)
+	}
+
+	c.updateChangePayments(payment)
+	c.updateSiblingPayments(payment)
+
+	return payment, nil
 }
 
-// proceedNextBlock process new blocks and updates payment status that
-// transaction reached the minimum confirmation threshold.
-func (c *Connector) proceedNextBlock() error {
-	var err error
+// abandonPayment unlocks inputs of the payment which has been marked as
+// failed by the payment sender, and marks its change as failed.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) abandonPayment(payment *connectors.Payment) {
+	c.updateChangePayments(payment)
+	c.updateSiblingPayments(payment)
 
-	hash, err := chainhash.NewHashFromStr(c.lastSyncedBlock.Hash)
+	tx, err := paymentTx(payment)
 	if err != nil {
-		return err
+		c.log.Errorf("unable to unlock inputs of payment(%v): %v",
+			payment.PaymentID, err)
+		return
 	}
 
-	// Update state of the block, such info as number of confirmations.
-	lastSyncedBlock, err := c.client.GetBlockVerboseByHash(hash)
+	UnlockInputs(c.client, txInputs(tx), c.log)
+}
+
+// CancelPayment cancels previously created waiting payment and its change,
+// and unlocks inputs of its transaction.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) CancelPayment(paymentID string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, tx, err := c.waitingPayment(paymentID)
 	if err != nil {
-		return err
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	UnlockInputs(c.client, txInputs(tx), c.log)
+
+	payment.Status = connectors.Failed
+	payment.FailureReason = connectors.CanceledReason
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("unable update payment(%v) status to "+
+			"fail: %v", payment.PaymentID, err)
+	}
+
+	c.updateChangePayments(payment)
+	c.updateSiblingPayments(payment)
+
+	c.log.Infof("Cancel payment %v", spew.Sdump(payment))
+
+	return payment, nil
+}
+
+// updateChangePayments sets status of the internal payments of the change
+// output to the status of the payment, transaction of which they belong to.
+// Errors are only logged, because payment itself has been already updated.
+func (c *Connector) updateChangePayments(payment *connectors.Payment) {
+	changeAddress := paymentChangeAddress(payment)
+	if changeAddress == "" {
+		return
 	}
-	c.lastSyncedBlock = lastSyncedBlock
 
-	// If bitcoind returns negative confirmation number it means that
-	// blockchain re-organization happened and we should handle it properly by
-	// moving backwards.
-	if c.lastSyncedBlock.Confirmations < 0 {
-		c.log.Info("Chain re-organisation has been found, handle it...")
+	for _, direction := range []connectors.PaymentDirection{
+		connectors.Outgoing, connectors.Incoming} {
+
+		id := connectors.GeneratePaymentID(payment.MediaID,
+			changeAddress, string(direction),
+			string(connectors.Internal))
 
-		forkBlock, err := c.findForkBlock(c.lastSyncedBlock)
+		// Incoming change payment exists only after the change output has
+		// been seen by the wallet.
+		changePayment, err := c.cfg.PaymentStore.PaymentByID(id)
 		if err != nil {
-			return errors.Errorf("unable to handle "+
-				"re-organizations: %v", err)
+			continue
 		}
 
-		c.log.Infof("Fork have been detected on block("+
-			"%v) using it as last synced block", forkBlock.Hash)
-		c.lastSyncedBlock = forkBlock
-	}
+		if changePayment.Status == payment.Status {
+			continue
+		}
 
-	for {
-		select {
-		case <-c.quit:
-			return nil
-		default:
+		changePayment.Status = payment.Status
+		changePayment.FailureReason = payment.FailureReason
+		changePayment.UpdatedAt = connectors.NowInMilliSeconds()
+		if err := c.cfg.PaymentStore.SavePayment(changePayment); err != nil {
+			c.log.Errorf("unable update change payment(%v) status to "+
+				"%v: %v", changePayment.PaymentID, payment.Status, err)
 		}
+	}
+}
+
+// updateSiblingPayments sets status of the other payments, which are paid
+// by the same transaction, to the status of the payment. Errors are only
+// logged, because payment itself has been already updated.
+func (c *Connector) updateSiblingPayments(payment *connectors.Payment) {
+	siblings, err := c.siblingPayments(payment)
+	if err != nil {
+		c.log.Errorf("unable to update payments of transaction(%v): %v",
+			payment.MediaID, err)
+		return
+	} else if len(siblings) == 0 {
+		return
+	}
 
-		// We should check next block only if there is minimum amount of
-		// confirmation above it.
-		if c.lastSyncedBlock.Confirmations < int64(c.cfg.MinConfirmations)+1 {
-			return nil
+	details := payment.Detail.(*connectors.GeneratedTxDetails)
+	for _, sibling := range siblings {
+		if sibling.Status == payment.Status {
+			continue
 		}
 
-		// This check is a bit redundant, but we should be ensured that
-		// next hash exists, otherwise the last synced hash will be overwritten
-		// with zero hash.
-		if c.lastSyncedBlock.NextHash == "" {
-			c.log.Errorf("unable to continue processing block(%v):"+
-				"next hash empty", c.lastSyncedBlock.Hash)
-			return nil
+		SetSentHeight(sibling, details.SentHeight)
+		sibling.Status = payment.Status
+		sibling.FailureReason = payment.FailureReason
+		sibling.UpdatedAt = connectors.NowInMilliSeconds()
+		if err := c.cfg.PaymentStore.SavePayment(sibling); err != nil {
+			c.log.Errorf("unable update payment(%v) status to %v: %v",
+				sibling.PaymentID, payment.Status, err)
 		}
+	}
+}
 
-		nextHash, err := chainhash.NewHashFromStr(c.lastSyncedBlock.NextHash)
+// reloadPayments replaces given payments with the stored ones, so that
+// updates made along with the other payments of their transaction are
+// returned.
+func (c *Connector) reloadPayments(payments []*connectors.Payment) error {
+	for i, payment := range payments {
+		stored, err := c.cfg.PaymentStore.PaymentByID(payment.PaymentID)
 		if err != nil {
-			return err
+			return errors.Errorf("unable to get payment(%v): %v",
+				payment.PaymentID, err)
 		}
 
-		proceededBlock, err := c.client.GetBlockVerboseByHash(nextHash)
-		if err != nil {
-			return err
+		payments[i] = stored
+	}
+
+	return nil
+}
+
+// siblingPayments returns the other payments of the same system, which are
+// paid by the transaction of the payment, i.e. payments which have been
+// sent to several recipients at once, or outputs of the reorganisation.
+// Payments are listed only if transaction has several outputs besides the
+// change one.
+func (c *Connector) siblingPayments(payment *connectors.Payment) (
+	[]*connectors.Payment, error) {
+
+	details, ok := payment.Detail.(*connectors.GeneratedTxDetails)
+	if !ok {
+		return nil, nil
+	}
+
+	tx, err := paymentTx(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := len(tx.TxOut)
+	if details.ChangeAddress != "" {
+		outputs--
+	}
+	if outputs <= 1 {
+		return nil, nil
+	}
+
+	payments, err := c.cfg.PaymentStore.ListPayments(c.cfg.Asset, "",
+		connectors.Outgoing, connectors.Blockchain, payment.System)
+	if err != nil {
+		return nil, errors.Errorf("unable to list payments: %v", err)
+	}
+
+	var siblings []*connectors.Payment
+	for _, p := range payments {
+		if p.MediaID != payment.MediaID || p.PaymentID == payment.PaymentID {
+			continue
+		}
+
+		// Change payments don't have the details of the transaction.
+		if _, ok := p.Detail.(*connectors.GeneratedTxDetails); !ok {
+			continue
 		}
 
-		for _, txHashStr := range proceededBlock.Tx {
-			txHash, err := chainhash.NewHashFromStr(txHashStr)
+		siblings = append(siblings, p)
+	}
+
+	return siblings, nil
+}
+
+// BumpFee replaces transaction of the pending payment by the transaction
+// paying the given fee rate in sat/byte, or the fee rate of the fee bump
+// policy priority if it is zero. Change of the replaced transaction is
+// marked as failed, and change of the replacement is tracked instead.
+//
+// NOTE: Part of the connectors.FeeBumper interface.
+func (c *Connector) BumpFee(paymentID string,
+	feeRate decimal.Decimal) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, err := c.replacer.BumpFee(paymentID, feeRate)
+	if err != nil {
+		m.AddError(metrics.MiddleSeverity)
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// replacedPayment marks change payments of the replaced transaction as
+// failed, and creates change payment of the current transaction of the
+// payment. Inputs of the current transaction are removed from the local
+// unspent cache, so that they wouldn't be used until the next cache sync.
+// Errors are only logged, because payment itself has been already updated.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) replacedPayment(payment *connectors.Payment,
+	replaced *connectors.ReplacedTx) {
+
+	if replaced.ChangeAddress != "" {
+		for _, direction := range []connectors.PaymentDirection{
+			connectors.Outgoing, connectors.Incoming} {
+
+			id := connectors.GeneratePaymentID(replaced.TxID,
+				replaced.ChangeAddress, string(direction),
+				string(connectors.Internal))
+
+			changePayment, err := c.cfg.PaymentStore.PaymentByID(id)
 			if err != nil {
-				c.log.Errorf("unable to decode tx hash(%v)", txHashStr)
 				continue
 			}
 
-			// Get transaction and if this transaction not correspond to non
-			// of our account the error will be returned, in the case skip
-			// this transaction.
-			tx, err := c.client.GetTransaction(txHash)
+			changePayment.Status = connectors.Failed
+			changePayment.FailureReason = connectors.ReplacedReason
+			changePayment.UpdatedAt = connectors.NowInMilliSeconds()
+			err = c.cfg.PaymentStore.SavePayment(changePayment)
 			if err != nil {
-				continue
+				c.log.Errorf("unable to fail change payment(%v) of "+
+					"replaced transaction: %v", changePayment.PaymentID, err)
 			}
+		}
+	}
 
-			if len(tx.Details) == 0 {
-				c.log.Errorf("unable to sync tx(%v), there is "+
-					"no details", tx.TxID)
-				continue
-			}
+	tx, err := paymentTx(payment)
+	if err != nil {
+		c.log.Errorf("unable to update change of payment(%v): %v",
+			payment.PaymentID, err)
+		return
+	}
 
-			for _, detail := range tx.Details {
-				payment := &connectors.Payment{
-					UpdatedAt: connectors.NowInMilliSeconds(),
-					Status:    connectors.Completed,
-					Receipt:   detail.Address,
-					Asset:     c.cfg.Asset,
-					Account:   detail.Account,
-					Amount:    decimal.NewFromFloat(detail.Amount).Abs(),
-					Media:     connectors.Blockchain,
-					MediaID:   tx.TxID,
-					MediaFee:  decimal.NewFromFloat(tx.Fee).Abs(),
-				}
+	c.removeUnspent(txInputs(tx))
 
-				if detail.Category == "receive" &&
-					detail.Account == defaultAccount {
-					payment.Direction = connectors.Incoming
-					payment.System = connectors.Internal
-					payment.PaymentID, err = payment.GenPaymentID()
-					if err != nil {
-						return errors.Errorf("unable generate payment id: %v", err)
-					}
-
-					if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-						return errors.Errorf("unable to save payment(%v): %v",
-							payment.PaymentID, err)
-					}
-
-					c.log.Infof("Receive is confirmed payment(%v)",
-						spew.Sdump(payment))
-
-				} else if detail.Category == "receive" {
-					payment.Direction = connectors.Incoming
-					payment.System = connectors.External
-					payment.PaymentID, err = payment.GenPaymentID()
-					if err != nil {
-						return errors.Errorf("unable generate payment id: %v", err)
-					}
-
-					if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-						return errors.Errorf("unable to save payment(%v): %v",
-							payment.PaymentID, err)
-					}
-
-					c.log.Infof("Receive is confirmed payment(%v)",
-						spew.Sdump(payment))
-
-				} else if detail.Category == "send" {
-					payment.Direction = connectors.Outgoing
-
-					// For every send payment we have to have database entry,
-					// such payment might be both external and internal.
-					// But we unable to identify by tx details was that
-					// payment internal or external, i.e. unable know id in
-					// advance.
-
-					payment.System = connectors.External
-					payment.PaymentID, err = payment.GenPaymentID()
-					if err != nil {
-						return errors.Errorf("unable generate payment id: %v", err)
-					}
-
-					_, err := c.cfg.PaymentStore.PaymentByID(payment.PaymentID)
-					if err == nil {
-						if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-							return errors.Errorf("unable to save payment(%v): %v",
-								payment.PaymentID, err)
-						}
-
-						c.log.Infof("Send is confirmed payment(%v)",
-							spew.Sdump(payment))
-					}
-
-					payment.System = connectors.Internal
-					payment.PaymentID, err = payment.GenPaymentID()
-					if err != nil {
-						return errors.Errorf("unable generate payment id: %v", err)
-					}
-
-					_, err = c.cfg.PaymentStore.PaymentByID(payment.PaymentID)
-					if err == nil {
-						if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-							return errors.Errorf("unable to save payment(%v): %v",
-								payment.PaymentID, err)
-						}
-
-						c.log.Infof("Send is confirmed payment(%v)",
-							spew.Sdump(payment))
-					}
-				}
-			}
-		}
+	details := payment.Detail.(*connectors.GeneratedTxDetails)
+	if details.ChangeAddress == "" {
+		return
+	}
 
-		encodedBlockHash := hex.EncodeToString(nextHash.CloneBytes())
-		err = c.cfg.StateStorage.PutLastSyncedHash([]byte(encodedBlockHash))
-		if err != nil {
-			return errors.Errorf("unable to put block hash in db: %v", err)
+	// Change payment of the restored transaction still exists, and its
+	// status is updated along with the other change payments.
+	c.saveChangePayment(payment, tx, details.ChangeAddress)
+	c.updateChangePayments(payment)
+}
+
+// saveChangePayment saves the outgoing internal payment of the change
+// output of the payment transaction, with the status of the payment, if it
+// doesn't exist yet. Errors are only logged, because payment itself has
+// been already updated.
+func (c *Connector) saveChangePayment(payment *connectors.Payment,
+	tx *wire.MsgTx, changeAddress string) {
+
+	id := connectors.GeneratePaymentID(payment.MediaID, changeAddress,
+		string(connectors.Outgoing), string(connectors.Internal))
+
+	if _, err := c.cfg.PaymentStore.PaymentByID(id); err == nil {
+		return
+	}
+
+	changeAddr, err := decodeAddress(c.cfg.Asset, changeAddress,
+		c.netParams.Name)
+	if err != nil {
+		c.log.Errorf("unable to decode change address: %v", err)
+		return
+	}
+
+	changeScript, err := txscript.PayToAddrScript(changeAddr)
+	if err != nil {
+		c.log.Errorf("unable to create change script: %v", err)
+		return
+	}
+
+	var changeAmt btcutil.Amount
+	for _, txOut := range tx.TxOut {
+		if bytes.Equal(txOut.PkScript, changeScript) {
+			changeAmt = btcutil.Amount(txOut.Value)
 		}
+	}
 
-		c.lastSyncedBlock = proceededBlock
+	changePayment := &connectors.Payment{
+		PaymentID: id,
+		UpdatedAt: connectors.NowInMilliSeconds(),
+		Status:    payment.Status,
+		Direction: connectors.Outgoing,
+		System:    connectors.Internal,
+		Receipt:   changeAddress,
+		Asset:     c.cfg.Asset,
+		Media:     connectors.Blockchain,
+		Amount:    sat2DecAmount(changeAmt).Round(8),
+		MediaFee:  decimal.Zero,
+		MediaID:   payment.MediaID,
+	}
 
-		// After transaction has been consumed by other subsystem
-		// overwrite cache.
-		c.log.Infof("Process block hash(%v), number(%v)",
-			proceededBlock.Hash, proceededBlock.Height)
+	if err := c.cfg.PaymentStore.SavePayment(changePayment); err != nil {
+		c.log.Errorf("unable add change payment in store: %v", err)
 	}
 }
 
-// fetchLastSyncedBlockHash returns hash of block which were handled in previous
-// cycle of processing.
-func (c *Connector) fetchLastSyncedBlockHash() (*chainhash.Hash, error) {
-	c.log.Info("Restore hash from database...")
-	data, _ := c.cfg.StateStorage.LastSyncedHash()
-	if data != nil {
-		decodedBlockHash, err := hex.DecodeString(string(data))
-		if err != nil {
-			return nil, errors.Errorf("unable decode block hash: %v", err)
-		}
+// lockWaitingInputs locks inputs of the waiting payments and of the ones
+// which are being retried, so that they wouldn't be used by other
+// transactions.
+func (c *Connector) lockWaitingInputs() error {
+	payments, err := c.cfg.PaymentStore.ListPayments(c.cfg.Asset,
+		connectors.Waiting, connectors.Outgoing, connectors.Blockchain,
+		connectors.External)
+	if err != nil {
+		return errors.Errorf("unable to list waiting payments: %v", err)
+	}
 
-		lastHash, err := chainhash.NewHash(decodedBlockHash)
+	for _, payment := range payments {
+		tx, err := paymentTx(payment)
 		if err != nil {
-			return nil, errors.Errorf("unable initialize hash: %v", err)
+			c.log.Errorf("unable to lock inputs of payment(%v): %v",
+				payment.PaymentID, err)
+			continue
 		}
 
-		return lastHash, nil
+		for _, input := range txInputs(tx) {
+			if err := c.client.LockUnspent(input); err != nil {
+				// Input might have been already spent by the previous
+				// attempt to send the payment.
+				c.log.Warnf("unable to lock input(%v:%v) of payment(%v): %v",
+					input.TxID, input.Vout, payment.PaymentID, err)
+			}
+		}
 	}
 
-	c.log.Info("Unable to find block in db, fetching best block...")
-	lastHash, err := c.client.GetBestBlockHash()
+	return nil
+}
+
+// waitingPayment returns waiting payment of this connector and its
+// transaction.
+func (c *Connector) waitingPayment(paymentID string) (*connectors.Payment,
+	*wire.MsgTx, error) {
+
+	payment, err := connectors.WaitingPayment(c.cfg.PaymentStore, paymentID,
+		c.cfg.Asset, connectors.Blockchain)
 	if err != nil {
-		return nil, errors.Errorf("unable to request last best block "+
-			"hash: %v", err)
+		return nil, nil, err
 	}
 
-	encodedBlockHash := hex.EncodeToString(lastHash.CloneBytes())
-	err = c.cfg.StateStorage.PutLastSyncedHash([]byte(encodedBlockHash))
+	tx, err := paymentTx(payment)
 	if err != nil {
-		return nil, errors.Errorf("unable to put block hash in db: %v", err)
+		return nil, nil, err
 	}
 
-	return lastHash, nil
+	return payment, tx, nil
 }
 
-func (c *Connector) sync() error {
-	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
-		common.GetFunctionName(), c.cfg.Metrics)
-	defer m.Finish()
+// paymentTx returns transaction which has been generated for the payment.
+// Transaction of the cold storage payment is unsigned until it is
+// finalized.
+func paymentTx(payment *connectors.Payment) (*wire.MsgTx, error) {
+	var rawTx []byte
+	switch details := payment.Detail.(type) {
+	case *connectors.GeneratedTxDetails:
+		rawTx = details.RawTx
+	case *connectors.PSBTDetails:
+		rawTx = details.RawTx
+		if len(rawTx) == 0 {
+			rawTx = details.UnsignedTx
+		}
+	default:
+		return nil, errors.Errorf("unable get details for payment(%v)",
+			payment.PaymentID)
+	}
 
-	if err := c.proceedNextBlock(); err != nil {
-		m.AddError(metrics.MiddleSeverity)
-		return errors.Errorf("unable to process blocks: %v", err)
+	tx := new(wire.MsgTx)
+	if err := tx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+		return nil, errors.Errorf("unable to deserialize raw tx: %v", err)
 	}
 
-	// As far as pending transaction may occur at any time,
-	// run it every cycle.
-	if err := c.syncUnconfirmed(); err != nil {
-		m.AddError(metrics.MiddleSeverity)
-		return errors.Errorf("unable to sync unconfirmed txs: %v", err)
+	return tx, nil
+}
+
+// paymentChangeAddress returns the address of the change output of the
+// transaction of the payment, or empty string if it has no change.
+func paymentChangeAddress(payment *connectors.Payment) string {
+	switch details := payment.Detail.(type) {
+	case *connectors.GeneratedTxDetails:
+		return details.ChangeAddress
+	case *connectors.PSBTDetails:
+		return details.ChangeAddress
+	default:
+		return ""
 	}
+}
 
-	balance, err := c.ConfirmedBalance()
+// ConfirmedBalance returns number of funds which could be used for sending.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) ConfirmedBalance() (decimal.Decimal, error) {
+	balance, err := c.client.GetBalanceByLabel(allAccounts, c.cfg.MinConfirmations)
 	if err != nil {
-		m.AddError(metrics.MiddleSeverity)
-		return errors.Errorf("unable to get available funds: %v", err)
+		return decimal.Zero, connectors.WrapDaemonError(c.client.DaemonName(), err)
 	}
 
-	c.log.Infof("Asset(%v), media(blockchain), available funds(%v)",
-		c.cfg.Asset, balance.Round(8).String())
+	return sat2DecAmount(balance).Round(8), nil
+}
 
-	f, _ := balance.Float64()
-	m.CurrentFunds(f)
+// PendingBalance return the amount of funds waiting ro be confirmed.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) PendingBalance() (decimal.Decimal, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
 
-	// Report last synchronised block number from daemon point of view.
-	m.BlockNumber(c.lastSyncedBlock.Height)
+	payments, err := c.cfg.PaymentStore.ListPayments(c.cfg.Asset,
+		connectors.Pending, connectors.Incoming, connectors.Blockchain, "")
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return decimal.Zero, errors.Errorf("unable to list pending "+
+			"payments: %v", err)
+	}
 
-	return nil
+	var amount decimal.Decimal
+	for _, payment := range payments {
+		amount = amount.Add(payment.Amount)
+	}
+
+	return amount.Round(8), nil
 }
 
-// DecodeAddress takes the blockchain address and ensure its validity.
+// ValidateAddress takes the blockchain address and ensure its validity.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
 func (c *Connector) ValidateAddress(address string) error {
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
@@ -996,7 +1707,7 @@ func (c *Connector) ValidateAddress(address string) error {
 	_, err := decodeAddress(c.cfg.Asset, address, c.netParams.Name)
 	if err != nil {
 		m.AddError(metrics.LowSeverity)
-		return errors.Errorf("invalid address: %v", err)
+		return &connectors.ErrInvalidReceipt{Reason: err}
 	}
 
 	return nil
@@ -1007,52 +1718,111 @@ func (c *Connector) ValidateAddress(address string) error {
 //
 // NOTE: Fee depends on amount because of the number amount of inputs
 // which has to be used to construct the transaction.
-func (c *Connector) EstimateFee(amount string) (decimal.Decimal, error) {
+func (c *Connector) EstimateFee(amount string,
+	priority connectors.FeePriority) (decimal.Decimal, error) {
+	estimate, err := c.EstimateTxFee("", amount, priority)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return estimate.Fee, nil
+}
+
+// EstimateTxFee estimates fee of the transaction which sends the given
+// amount to the address, by simulating the coin selection over the current
+// unspent outputs of the wallet.
+//
+// NOTE: Part of the connectors.TxFeeEstimator interface.
+func (c *Connector) EstimateTxFee(address, amount string,
+	priority connectors.FeePriority) (*connectors.FeeEstimate, error) {
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	// Estimate fee for the median transaction size of 225 bytes.
-	// TODO(andrew.shvv) Use amount to construct actual transaction and
-	// calculate its size.
-	size := decimal.New(225, 0)
+	var receiver btcutil.Address
+	if address != "" {
+		var err error
+		receiver, err = decodeAddress(c.cfg.Asset, address,
+			c.netParams.Name)
+		if err != nil {
+			m.AddError(metrics.LowSeverity)
+			return nil, &connectors.ErrInvalidReceipt{Reason: err}
+		}
+	}
+
+	amt, err := decimal.NewFromString(amount)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInvalidAmount{Amount: amount, Reason: err}
+	}
+
+	if amt.IsNegative() {
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInvalidAmount{
+			Amount: amount,
+			Reason: errors.New("amount shouldn't be negative"),
+		}
+	}
 
-	feeRateSatoshiPerByte := c.getFeeRate()
-	feeInSatoshis := feeRateSatoshiPerByte.Mul(size)
-	feeInBitcoin := feeInSatoshis.Div(satoshiPerBitcoin)
+	unspent, err := c.client.ListUnspentMinMax(c.cfg.MinConfirmations,
+		math.MaxInt32)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, convertRPCError(c.client.DaemonName(), err,
+			"unable to list unspent")
+	}
 
-	return feeInBitcoin.Round(8), nil
+	feeRatePerByte := uint64(c.getFeeRate(priority).Ceil().IntPart())
+	selection, err := SimulateSelection(c.cfg.CoinSelector, feeRatePerByte,
+		decAmount2Sat(amt), []btcutil.Address{receiver},
+		SpendableInputs(unspent))
+	if err != nil {
+		return nil, err
+	}
+
+	return &connectors.FeeEstimate{
+		Fee:    sat2DecAmount(selection.Fee),
+		Inputs: len(selection.Inputs),
+		VSize:  selection.VSize,
+	}, nil
 }
 
-// getFeeRate estimates the approximate rate in sat/byte needed for a
-// transaction to begin confirmation within 2 blocks if possible.
+// getFeeRate returns current rate in sat/byte of the given priority, which
+// is estimated by the fee oracle.
+func (c *Connector) getFeeRate(priority connectors.FeePriority) decimal.Decimal {
+	return c.oracle.FeeRate(priority)
+}
+
+// FeeRates returns current fee rates of all priorities.
+//
+// NOTE: Part of the connectors.FeeRatesProvider interface.
+func (c *Connector) FeeRates() []*connectors.FeeRate {
+	return c.oracle.FeeRates()
+}
+
+// estimateFeeRate estimates the approximate rate in sat/byte needed for a
+// transaction to begin confirmation within the given number of blocks.
 //
 // NOTE: Uses virtual transaction size as defined in BIP 141
 // (witness data is discounted).
-func (c *Connector) getFeeRate() decimal.Decimal {
+func (c *Connector) estimateFeeRate(confTarget uint32) (decimal.Decimal,
+	error) {
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	feeRate, err := c.client.EstimateFee()
+	feeRate, err := c.client.EstimateFee(confTarget)
 	if err != nil {
 		if c.cfg.Net == "mainnet" {
 			// In case of mainnet such situation happens rarely for that
 			// reason we should notify about that. But in testnet and simnet
 			// usually not enough data to make fee proper fee estimation.
-			c.log.Errorf("unable get fee rate: %v", err)
+			c.log.Errorf("unable get fee rate for %v blocks: %v",
+				confTarget, err)
 			m.AddError(metrics.HighSeverity)
 		}
 
-		// Take fee rate from config, which was initialised on the start of
-		// payserver.
-		feeRateSatoshiPerByte := decimal.New(int64(c.cfg.FeePerByte), 0).Round(8)
-		if feeRateSatoshiPerByte.LessThan(minimumFeeRate) {
-			feeRateSatoshiPerByte = minimumFeeRate
-		}
-
-		c.log.Debugf("Get fee rate(%v sat/byte) from config", feeRateSatoshiPerByte)
-		return feeRateSatoshiPerByte
+		return decimal.Zero, err
 	}
 
 	// Initially rate is returned as BTC/Kb, for convience we convert it
@@ -1062,14 +1832,10 @@ func (c *Connector) getFeeRate() decimal.Decimal {
 	feeRateSatoshiPerKiloByte := feeRateBtcPerKiloByte.Mul(satoshiPerBitcoin)
 	feeRateSatoshiPerByte := feeRateSatoshiPerKiloByte.Div(bytesInKiloByte).Round(8)
 
-	if feeRateSatoshiPerByte.LessThan(minimumFeeRate) {
-		feeRateSatoshiPerByte = minimumFeeRate
-	}
-
-	c.log.Debugf("Get fee rate(%v sat/byte) from daemon",
-		feeRateSatoshiPerByte)
+	c.log.Debugf("Get fee rate(%v sat/byte) for %v blocks from daemon",
+		feeRateSatoshiPerByte, confTarget)
 
-	return feeRateSatoshiPerByte
+	return feeRateSatoshiPerByte, nil
 }
 
 // reportMetrics is used to report necessary health metrics about internal
@@ -1119,126 +1885,3 @@ func (c *Connector) reportMetrics() error {
 
 	return nil
 }
-
-// reorgLargeInputs takes large inputs and split them smaller one,
-// by making loopback transaction. Value of optimal utxo was calculated based
-// on optimisation of reducing fees and increasing payment success rate.
-func (c *Connector) reorgLargeInputs() error {
-	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
-		common.GetFunctionName(), c.cfg.Metrics)
-	defer m.Finish()
-
-	c.unspentSyncMtx.Lock()
-	defer c.unspentSyncMtx.Unlock()
-
-	// Try to get unspent outputs from local cache, if it is not initialized
-	// than sync it.
-	if c.unspent == nil {
-		if err := c.syncUnspent(); err != nil {
-			return errors.Errorf("unable to sync unspent: %v", err)
-		}
-	}
-
-	largeUTXO := make([]rpc.UnspentInput, 0)
-	overallAmount := btcutil.Amount(0)
-	for _, utxo := range c.unspent {
-		utxoAmount, err := btcutil.NewAmount(utxo.Amount)
-		if err != nil {
-			return errors.Errorf("unable convert utxo amount: %v", err)
-		}
-
-		// Avoid split if will be created less than two outputs. That is done
-		// in order to reduce a lot of reorganisation i.e. paying more fees.
-		if utxoAmount > (2 * optimalUTXOValue) {
-			largeUTXO = append(largeUTXO, utxo)
-			overallAmount += utxoAmount
-		}
-	}
-
-	if len(largeUTXO) == 0 {
-		c.log.Debug("Wasn't able found large enough utxo, skip re-balancing")
-		return nil
-	}
-
-	// Create list of output amounts based on large inputs.
-	feeSatoshiPerByte := uint64(c.getFeeRate().IntPart())
-	outputAmounts, _, err := createReorganisationOutputs(feeSatoshiPerByte,
-		largeUTXO, optimalUTXOValue)
-	if err != nil {
-		return errors.Errorf("unable calculate output amounts: %v", err)
-	}
-
-	outputs := make(map[btcutil.Address]btcutil.Amount)
-	for _, outputAmount := range outputAmounts {
-		// Create loopback address which point out to the default account
-		// of the wallet.
-		loopBackAddr, err := c.client.GetNewRawChangeAddress(defaultAccount)
-		if err != nil {
-			return errors.Errorf("unable create loopback address: %v", err)
-		}
-
-		outputs[loopBackAddr] = outputAmount
-	}
-
-	tx, err := c.client.CreateRawTransaction(largeUTXO, outputs)
-	if err != nil {
-		return errors.Errorf("unable to create raw reorganisation tx: %v", err)
-	}
-
-	c.log.Infof("Take %v inputs, with overall amount %v and create "+
-		"%v outputs with optimal value %v", len(tx.TxIn), overallAmount,
-		len(tx.TxOut), optimalUTXOValue)
-
-	// Signed reorganisation transaction.
-	signedTx, err := c.client.SignRawTransaction(tx)
-	if err != nil {
-		return errors.Errorf("unable to sign generated transaction: %v", err)
-	}
-
-	//txID := tx.TxHash().String()
-	//for loopBackAddress, amountSat := range outputs {
-	//	payment := &connectors.Payment{
-	//		PaymentID: generatePaymentID(txID, loopBackAddress.String(),
-	//			connectors.Outgoing),
-	//		UpdatedAt: connectors.NowInMilliSeconds(),
-	//		Status:    connectors.Waiting,
-	//		Direction: connectors.Outgoing,
-	//		Receipt:   loopBackAddress.String(),
-	//		Asset:     connectors.Asset(c.cfg.Asset),
-	//		Media:     connectors.Blockchain,
-	//		Amount:    sat2DecAmount(amountSat),
-	//		MediaFee:  sat2DecAmount(txFee / btcutil.Amount(len(outputs))),
-	//		MediaID:   txID,
-	//	}
-	//
-	//}
-
-	// TODO(andrew.shvv) for overall create internal payment
-	// TODO(andrew.shvv) for every output create internal payment
-	//
-
-	if err := c.client.SendRawTransaction(signedTx); err != nil {
-		//payment.Status = connectors.Failed
-		//payment.UpdatedAt = connectors.NowInMilliSeconds()
-		//
-		//if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-		//	m.AddError(metrics.HighSeverity)
-		//	c.log.Errorf("unable update payment(%v) status to fail: %v",
-		//		paymentID, err)
-		//}
-		//
-		//m.AddError(metrics.HighSeverity)
-		//return nil, errors.Errorf("unable to send payment(%v): %v",
-		//	paymentID, err)
-		return err
-	}
-
-	// Remove unspent utxo from local cache, otherwise inputs might be re-used
-	// which cause tx error on send. If transaction will fail, than inputs
-	// will be returned on next cache sync.
-	for _, input := range largeUTXO {
-		delete(c.unspent, input.TxID)
-	}
-
-	return nil
-}
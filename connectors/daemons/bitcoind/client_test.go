@@ -1,646 +1,662 @@
 package bitcoind
 
 import (
-	"github.com/bitlum/connector/common"
+	"testing"
+
 	"github.com/bitlum/connector/connectors"
 	"github.com/bitlum/connector/connectors/rpc"
 	"github.com/bitlum/connector/db/sqlite"
 	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/bitlum/go-bitcoind-rpc/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btclog"
 	"github.com/btcsuite/btcutil"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/go-errors/errors"
-	"math"
-	"testing"
-	"time"
+	"github.com/shopspring/decimal"
 )
 
-type response struct {
-	data interface{}
-	err  error
+const (
+	// receiverAddress is the address of someone else, on which payments are
+	// sent.
+	receiverAddress = "2N6tEa9BDvgue53LkS8B6BhGBnZEpbJZwhY"
+
+	// changeAddress is the address of our wallet, on which change is
+	// returned.
+	changeAddress = "2N6yeKrmeMFNkMWohbQCThx3djEfNhj3WYM"
+)
+
+// stubChain is the rpc client which implements only methods which are used
+// by the creation, sending and sync of the payments.
+type stubChain struct {
+	rpc.Client
+
+	t *testing.T
+
+	unspent []rpc.UnspentInput
+	blocks  map[string]*rpc.BlockVerboseResp
+	txs     map[string]*rpc.Transaction
+
+	sent     []*wire.MsgTx
+	sendErr  error
+	locked   int
+	unlocked int
+
+	// addresses is the number of the new addresses which have been
+	// created.
+	addresses byte
+}
+
+func (c *stubChain) DaemonName() string {
+	return "bitcoind"
+}
+
+func (c *stubChain) EstimateFee(confTarget uint32) (float64, error) {
+	return 0, errors.New("insufficient data")
 }
 
-// ReplayRPCClient...
-type ReplayRPCClient struct {
-	responses chan response
-	invoked   chan struct{}
-	t         *testing.T
-	delay     time.Duration
+func (c *stubChain) ListUnspentMinMax(minConf, maxConf int) ([]rpc.UnspentInput,
+	error) {
+	return c.unspent, nil
 }
 
-var _ rpc.Client = (*ReplayRPCClient)(nil)
+func (c *stubChain) LockUnspent(input rpc.UnspentInput) error {
+	c.locked++
+	return nil
+}
+
+func (c *stubChain) UnlockUnspentInput(input rpc.UnspentInput) error {
+	c.unlocked++
+	return nil
+}
+
+func (c *stubChain) GetNewRawChangeAddress(label string) (btcutil.Address,
+	error) {
+	return decodeAddress(connectors.BTC, changeAddress, "regtest")
+}
+
+func (c *stubChain) GetNewAddress(label string) (btcutil.Address, error) {
+	c.addresses++
+
+	var pkHash [20]byte
+	pkHash[0] = c.addresses
+	return btcutil.NewAddressPubKeyHash(pkHash[:], &chaincfg.RegressionNetParams)
+}
 
-func NewReplayRPCClient(t *testing.T) (*ReplayRPCClient) {
-	return &ReplayRPCClient{
-		responses: make(chan response, 100),
-		invoked:   make(chan struct{}, 100),
-		t:         t,
-		delay:     time.Second * 1,
+func (c *stubChain) CreateRawTransaction(inputs []rpc.UnspentInput,
+	outputs map[btcutil.Address]btcutil.Amount) (*wire.MsgTx, error) {
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for _, input := range inputs {
+		hash, err := chainhash.NewHashFromStr(input.TxID)
+		if err != nil {
+			return nil, err
+		}
+
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, input.Vout), nil, nil))
 	}
+
+	for address, amount := range outputs {
+		script, err := txscript.PayToAddrScript(address)
+		if err != nil {
+			return nil, err
+		}
+
+		tx.AddTxOut(wire.NewTxOut(int64(amount), script))
+	}
+
+	return tx, nil
 }
 
-func (c *ReplayRPCClient) respond(response response) error {
-	select {
-	case c.responses <- response:
-		return nil
-	case <-time.After(time.Second * 2):
-		return errors.Errorf("waited more than 2 seconds for rpc call")
+func (c *stubChain) SignRawTransaction(tx *wire.MsgTx) (*wire.MsgTx, error) {
+	return tx, nil
+}
+
+func (c *stubChain) SendRawTransaction(tx *wire.MsgTx) error {
+	if c.sendErr != nil {
+		return c.sendErr
 	}
+
+	c.sent = append(c.sent, tx)
+	return nil
 }
 
-func (c *ReplayRPCClient) GetBlockChainInfo() (*rpc.BlockChainInfoResp, error) {
-	c.t.Log(common.GetFunctionName())
+func (c *stubChain) GetTransaction(hash *chainhash.Hash) (*rpc.Transaction,
+	error) {
 
-	select {
-	case resp := <-c.responses:
-		return resp.data.(*rpc.BlockChainInfoResp), resp.err
-	case <-time.After(c.delay):
-		return nil, errors.Errorf("response delay")
+	tx, ok := c.txs[hash.String()]
+	if !ok {
+		return nil, errors.Errorf("Invalid or non-wallet transaction id")
 	}
+
+	return tx, nil
 }
 
-func (c *ReplayRPCClient) GetBlockVerboseByHash(blockHash *chainhash.Hash) (
+func (c *stubChain) GetBlockVerboseByHash(hash *chainhash.Hash) (
 	*rpc.BlockVerboseResp, error) {
-	c.t.Log(common.GetFunctionName())
 
-	select {
-	case resp := <-c.responses:
-		return resp.data.(*rpc.BlockVerboseResp), resp.err
-	case <-time.After(c.delay):
-		return nil, errors.Errorf("response delay")
+	block, ok := c.blocks[hash.String()]
+	if !ok {
+		return nil, errors.Errorf("block not found")
 	}
+
+	return block, nil
 }
-func (c *ReplayRPCClient) GetBestBlockHash() (*chainhash.Hash, error) {
-	c.t.Log(common.GetFunctionName())
 
-	select {
-	case resp := <-c.responses:
-		return resp.data.(*chainhash.Hash), resp.err
-	case <-time.After(c.delay):
-		return nil, errors.Errorf("response delay")
-	}
+// reorgMetrics is the metrics backend which keeps statuses of the
+// reorganised payments.
+type reorgMetrics struct {
+	crypto.MockBackend
+	statuses []string
 }
 
-func (c *ReplayRPCClient) UnlockUnspent() error {
-	c.t.Log(common.GetFunctionName())
-	return nil
+func (m *reorgMetrics) AddReorganisedPayment(daemon, asset, status string) {
+	m.statuses = append(m.statuses, status)
 }
 
-func (c *ReplayRPCClient) LockUnspent(input rpc.UnspentInput) error {
-	c.t.Log(common.GetFunctionName())
-	return nil
+// blockHash returns hash of the test block or transaction with the given
+// number.
+func blockHash(n byte) string {
+	var hash chainhash.Hash
+	hash[0] = n
+	return hash.String()
 }
 
-func (c *ReplayRPCClient) ListUnspentMinMax(minConf, maxConf int) ([]rpc.UnspentInput, error) {
-	c.t.Log(common.GetFunctionName())
+func newTestConnector(t *testing.T) (*Connector, *stubChain,
+	connectors.PaymentsStore, func()) {
+
+	db, clear, err := sqlite.MakeTestDB()
+	if err != nil {
+		t.Fatalf("unable create test db: %v", err)
+	}
 
-	select {
-	case resp := <-c.responses:
-		return resp.data.([]rpc.UnspentInput), resp.err
-	case <-time.After(c.delay):
-		return nil, errors.Errorf("response delay")
+	chain := &stubChain{
+		t: t,
+		unspent: []rpc.UnspentInput{{
+			Address:       changeAddress,
+			Amount:        3,
+			Confirmations: 10,
+			TxID:          blockHash(200),
+			Vout:          0,
+		}},
+		blocks: make(map[string]*rpc.BlockVerboseResp),
+		txs:    make(map[string]*rpc.Transaction),
 	}
-}
 
-func (c *ReplayRPCClient) GetAddressesByLabel(label string) ([]btcutil.Address, error) {
-	c.t.Log(common.GetFunctionName())
+	store := sqlite.NewPaymentStore(db)
+	c, err := NewConnector(&Config{
+		Net:                 "regtest",
+		MinConfirmations:    1,
+		RPCClient:           chain,
+		Asset:               connectors.BTC,
+		FeePerByte:          1,
+		Logger:              btclog.Disabled,
+		Metrics:             &reorgMetrics{},
+		PaymentStore:        store,
+		StateStorage:        sqlite.NewConnectorStateStorage(connectors.BTC, db),
+		AttemptsStorage:     db.AttemptsStorage(),
+		ReplacementsStorage: db.ReplacementsStorage(),
+	})
+	if err != nil {
+		clear()
+		t.Fatalf("unable to create connector: %v", err)
+	}
 
-	select {
-	case resp := <-c.responses:
-		return resp.data.([]btcutil.Address), resp.err
-	case <-time.After(c.delay):
-		return nil, errors.Errorf("response delay")
+	c.netParams, err = getParams(connectors.BTC, "regtest")
+	if err != nil {
+		clear()
+		t.Fatalf("unable to get params: %v", err)
 	}
+
+	return c, chain, store, clear
 }
 
-func (c *ReplayRPCClient) GetNewAddress(label string) (btcutil.Address, error) {
-	c.t.Log(common.GetFunctionName())
+// changePayment returns internal payment of the change of the given
+// payment.
+func changePayment(t *testing.T, store connectors.PaymentsStore,
+	payment *connectors.Payment,
+	direction connectors.PaymentDirection) *connectors.Payment {
 
-	select {
-	case resp := <-c.responses:
-		return resp.data.(btcutil.Address), resp.err
-	case <-time.After(c.delay):
-		return nil, errors.Errorf("response delay")
+	id := connectors.GeneratePaymentID(payment.MediaID, changeAddress,
+		string(direction), string(connectors.Internal))
+
+	change, err := store.PaymentByID(id)
+	if err != nil {
+		t.Fatalf("unable to get change payment: %v", err)
 	}
+
+	return change
 }
 
-func (c *ReplayRPCClient) SignRawTransaction(tx *wire.MsgTx) (*wire.MsgTx,
-	error) {
-	c.t.Log(common.GetFunctionName())
+// TestCreateConfirmPayment checks that created payment and its change are
+// saved as waiting, and that they become pending after confirmation.
+func TestCreateConfirmPayment(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
+	defer clear()
 
-	return tx, nil
-}
+	payment, err := c.CreatePayment(receiverAddress, "1", "key")
+	if err != nil {
+		t.Fatalf("unable create payment: %v", err)
+	}
 
-func (c *ReplayRPCClient) CreateRawTransaction([]rpc.UnspentInput,
-	map[btcutil.Address]btcutil.Amount) (*wire.MsgTx, error) {
-	c.t.Log(common.GetFunctionName())
+	if payment.Status != connectors.Waiting || chain.locked != 1 {
+		t.Fatalf("payment should be waiting with locked inputs")
+	}
 
-	select {
-	case resp := <-c.responses:
-		return resp.data.(*wire.MsgTx), resp.err
-	case <-time.After(c.delay):
-		return nil, errors.Errorf("response delay")
+	change := changePayment(t, store, payment, connectors.Outgoing)
+	if change.Status != connectors.Waiting {
+		t.Fatalf("change should be waiting, got %v", change.Status)
 	}
-}
 
-func (c *ReplayRPCClient) SendRawTransaction(tx *wire.MsgTx) error {
-	c.t.Log(common.GetFunctionName())
+	// Change is the funds of the input which are left after paying the
+	// amount and the fee.
+	if !change.Amount.Add(payment.Amount).Add(payment.MediaFee).Equal(
+		sat2DecAmount(3 * btcutil.SatoshiPerBitcoin)) {
+		t.Fatalf("wrong change amount: %v", change.Amount)
+	}
 
-	select {
-	case resp := <-c.responses:
-		return resp.err
-	case <-time.After(c.delay):
-		return errors.Errorf("response delay")
+	same, err := c.CreatePayment(receiverAddress, "1", "key")
+	if err != nil {
+		t.Fatalf("unable create payment: %v", err)
 	}
-}
 
-func (c *ReplayRPCClient) GetBalanceByLabel(label string,
-	minConfirms int) (btcutil.Amount, error) {
-	c.t.Log(common.GetFunctionName())
+	if same.PaymentID != payment.PaymentID || chain.locked != 1 {
+		t.Fatalf("payment with the same key shouldn't be created twice")
+	}
 
-	select {
-	case resp := <-c.responses:
-		return resp.data.(btcutil.Amount), resp.err
-	case <-time.After(c.delay):
-		return 0, errors.Errorf("response delay")
+	payment, err = c.ConfirmPayment(payment.PaymentID)
+	if err != nil {
+		t.Fatalf("unable confirm payment: %v", err)
 	}
 
-}
+	if payment.Status != connectors.Pending || len(chain.sent) != 1 {
+		t.Fatalf("payment should be sent")
+	}
 
-func (c *ReplayRPCClient) GetTransaction(txHash *chainhash.Hash) (*rpc.Transaction, error) {
-	c.t.Log(common.GetFunctionName())
+	if chain.sent[0].TxHash().String() != payment.MediaID {
+		t.Fatalf("wrong transaction has been sent")
+	}
 
-	select {
-	case resp := <-c.responses:
-		return resp.data.(*rpc.Transaction), resp.err
-	case <-time.After(c.delay):
-		return nil, errors.Errorf("response delay")
+	change = changePayment(t, store, payment, connectors.Outgoing)
+	if change.Status != connectors.Pending {
+		t.Fatalf("change should be pending, got %v", change.Status)
 	}
 }
 
-func (c *ReplayRPCClient) EstimateFee() (float64, error) {
-	c.t.Log(common.GetFunctionName())
+// changeFailureStore is the payments store which is unable to save the
+// change payments.
+type changeFailureStore struct {
+	connectors.PaymentsStore
+}
 
-	select {
-	case resp := <-c.responses:
-		return resp.data.(float64), resp.err
-	case <-time.After(c.delay):
-		return 0, errors.Errorf("response delay")
+func (s *changeFailureStore) SavePayment(payment *connectors.Payment) error {
+	if payment.System == connectors.Internal &&
+		payment.Status == connectors.Waiting {
+		return errors.New("database is locked")
 	}
-}
 
-func (c *ReplayRPCClient) DaemonName() string {
-	c.t.Log(common.GetFunctionName())
-	return "mock"
+	return s.PaymentsStore.SavePayment(payment)
 }
 
-func initConnector(
-	client *ReplayRPCClient,
-	paymentsStore connectors.PaymentsStore,
-	stateStore connectors.StateStorage,
-) (*Connector, error) {
-	c, err := NewConnector(&Config{
-		Net:                  "regtest",
-		MinConfirmations:     1,
-		SyncLoopDelay:        math.MaxInt16,
-		InputReorgLoopDelay:  math.MaxInt16,
-		SyncUnspentLoopDelay: math.MaxInt16,
-		Asset:                connectors.BTC,
-		Logger:               btclog.Disabled,
-		Metrics:              crypto.DisabledBackend,
-		LastSyncedBlockHash:  "",
-		PaymentStore:         paymentsStore,
-		StateStorage:         stateStore,
-		FeePerByte:           1,
-		RPCClient:            client,
-	})
+// TestCreatePaymentChangeFailure checks that payment isn't left waiting with
+// the locked inputs, if its change couldn't be saved.
+func TestCreatePaymentChangeFailure(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
+	defer clear()
+
+	c.cfg.PaymentStore = &changeFailureStore{PaymentsStore: store}
+
+	if _, err := c.CreatePayment(receiverAddress, "1", "key"); err == nil {
+		t.Fatalf("payment without change shouldn't be created")
+	}
+
+	payments, err := store.ListPayments("", "", "", "", "")
 	if err != nil {
-		return nil, errors.Errorf("unable create connector: %v", err)
+		t.Fatalf("unable to list payments: %v", err)
 	}
 
-	// Return chain on getting information on start of connector
-	{
-		if err := client.respond(response{
-			data: &rpc.BlockChainInfoResp{
-				Chain: "regtest",
-			},
-			err: nil,
-		}); err != nil {
-			return nil, errors.Errorf("unable to respond: %v", err)
-		}
+	if len(payments) != 1 || payments[0].Status != connectors.Failed {
+		t.Fatalf("payment should be failed")
 	}
 
-	// Return last synced block hash and last synced block on start of connector
-	lastBlockHashStr := "00000000000000000023486b9d95a729418b2be569f35372b698bd8b308d1178"
-	{
-		lastBlockHash, err := chainhash.NewHashFromStr(lastBlockHashStr)
-		if err != nil {
-			return nil, errors.Errorf("non-correct hash: %v", err)
-		}
+	if chain.unlocked != chain.locked {
+		t.Fatalf("inputs of failed payment should be unlocked")
+	}
+}
 
-		if err := client.respond(response{
-			data: lastBlockHash,
-			err:  nil,
-		}); err != nil {
-			return nil, errors.Errorf("unable to respond: %v", err)
-		}
+// TestCancelPayment checks that canceled payment and its change are marked
+// as failed, and that inputs of the payment are unlocked.
+func TestCancelPayment(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
+	defer clear()
 
-		if err := client.respond(response{
-			data: &rpc.BlockVerboseResp{
-				Hash:          lastBlockHashStr,
-				Height:        1,
-				NextHash:      "",
-				Confirmations: 0,
-				PreviousHash:  "",
-				Tx:            []string{},
-			},
-			err: nil,
-		}); err != nil {
-			return nil, errors.Errorf("unable to respond: %v", err)
-		}
+	payment, err := c.CreatePayment(receiverAddress, "1", "")
+	if err != nil {
+		t.Fatalf("unable create payment: %v", err)
 	}
 
-	// Return default account address on start of connector
-	{
-		address, err := decodeAddress(connectors.BTC,
-			"2N6yeKrmeMFNkMWohbQCThx3djEfNhj3WYM", "regtest")
-		if err != nil {
-			return nil, errors.Errorf("unable to decode address: %v", err)
-		}
+	payment, err = c.CancelPayment(payment.PaymentID)
+	if err != nil {
+		t.Fatalf("unable cancel payment: %v", err)
+	}
 
-		if err := client.respond(response{
-			data: []btcutil.Address{address},
-			err:  nil,
-		}); err != nil {
-			return nil, errors.Errorf("unable to respond: %v", err)
-		}
+	if payment.Status != connectors.Failed ||
+		payment.FailureReason != connectors.CanceledReason {
+		t.Fatalf("payment should be canceled")
 	}
 
-	if err := c.Start(); err != nil {
-		return nil, errors.Errorf("unable to start connector: %v", err)
+	if chain.unlocked != 1 {
+		t.Fatalf("inputs of canceled payment should be unlocked")
 	}
 
-	return c, nil
+	change := changePayment(t, store, payment, connectors.Outgoing)
+	if change.Status != connectors.Failed {
+		t.Fatalf("change should be failed, got %v", change.Status)
+	}
+
+	if _, err := c.ConfirmPayment(payment.PaymentID); err !=
+		connectors.ErrPaymentNotWaiting {
+		t.Fatalf("canceled payment shouldn't be sent, got %v", err)
+	}
 }
 
-// какие юзкейсы мы хотим проверить?
-// - платёж самому себе
-// - реорганизационный платёж
-// - отправка платежа
-// - получение платежа
-// - фии когда нет данных
-// - фии когда есть данные
-// - вероятно в будущем баланс, который не зависит от использованных аутпутов
-// - как быть с балансом?
-//	- надо хранить баланс в базе
-//	- надо синкать его с настоящим и давать ворнинг если они не совпадают
-// 	- при каждом исходящем платеже мы должны отнимать его
-// 	- при каждом входящем платеже мы должны пополнять его
-//	-
-// 	- текущий баланс это количество отправленных
-func TestProcessBlockCircularTransaction(t *testing.T) {
-	db, clear, err := sqlite.MakeTestDB()
-	if err != nil {
-		t.Fatalf("unable create test db: %v", err)
+// TestInsufficientFunds checks that typed error is returned if unspent
+// outputs are not enough to send the payment.
+func TestInsufficientFunds(t *testing.T) {
+	c, _, _, clear := newTestConnector(t)
+	defer clear()
+
+	_, err := c.SendPayment(receiverAddress, "3", "",
+		connectors.DefaultPriority)
+	if _, ok := err.(*connectors.ErrInsufficientFunds); !ok {
+		t.Fatalf("insufficient funds error should be returned, got %v", err)
 	}
+}
+
+// TestSendPayments checks that payments to several recipients are sent in
+// one transaction, that they share its status, and that fee is split
+// between them.
+func TestSendPayments(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
 	defer clear()
 
-	paymentsStore := &sqlite.PaymentsStore{db: db}
-	stateStore := sqlite.NewConnectorStateStorage(connectors.BTC, db)
-	client := NewReplayRPCClient(t)
+	var pkHash [20]byte
+	pkHash[0] = 0xff
+	address, err := btcutil.NewAddressPubKeyHash(pkHash[:],
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
 
-	c, err := initConnector(client, paymentsStore, stateStore)
+	recipients := []*connectors.Recipient{
+		{Address: receiverAddress, Amount: "1"},
+		{Address: address.String(), Amount: "0.5"},
+	}
+
+	chain.sendErr = &btcjson.RPCError{
+		Code: btcjson.ErrRPCWalletInsufficientFunds,
+	}
+	if _, err := c.SendPayments(recipients, "failed"); err == nil {
+		t.Fatalf("payments shouldn't be sent")
+	}
+
+	failed, err := store.ListPayments("", connectors.Failed,
+		connectors.Outgoing, "", connectors.External)
 	if err != nil {
-		t.Fatalf("unabelt init connector: %v", err)
-	}
-	defer c.Stop("test end")
-
-	// Return last synced block, in order to check confirmation number
-	lastBlockHashStr := "00000000000000000023486b9d95a729418b2be569f35372b698bd8b308d1178"
-	if err := client.respond(response{
-		data: &rpc.BlockVerboseResp{
-			Hash:          lastBlockHashStr,
-			Height:        1,
-			NextHash:      "00000000000000000016645564d1719f59c967975cec21d8c7d2941834aed36a",
-			Confirmations: 3,
-			Tx:            []string{},
-		},
-		err: nil,
-	}); err != nil {
-		t.Fatalf("unable to respond: %v", err)
-	}
-
-	// Return next block, because confirmation of previous block is higher
-	// than minimal required.
-	if err := client.respond(response{
-		data: &rpc.BlockVerboseResp{
-			Hash:          "00000000000000000016645564d1719f59c967975cec21d8c7d2941834aed36a",
-			Height:        2,
-			NextHash:      "",
-			Confirmations: 2,
-			Tx: []string{
-				"1aef1e640bf5dcca0d922f49afe2f171e1c5bd1517906cdc7507dca466c8e65e",
-			},
-		},
-		err: nil,
-	}); err != nil {
-		t.Fatalf("unable to respond: %v", err)
-	}
-
-	// Emulate circular transaction - from our address, to our another address.
-	fee := -2.497e-05
-	if err := client.respond(response{
-		data: &rpc.Transaction{
-			Amount:        0,
-			Fee:           -2.497e-05,
-			Confirmations: 1,
-			TxID:          "1aef1e640bf5dcca0d922f49afe2f171e1c5bd1517906cdc7507dca466c8e65e",
-			Details: []rpc.TransactionDetails{
-				{
-					Account:           "",
-					Address:           "2N6tEa9BDvgue53LkS8B6BhGBnZEpbJZwhY",
-					Amount:            -1,
-					Category:          "send",
-					InvolvesWatchOnly: false,
-					Fee:               &fee,
-					Vout:              0,
-				},
-				{
-					Account:           "",
-					Address:           "2NEEKkxXPRBGaY55NmxiCG6ksJ7LAxn4TfM",
-					Amount:            -8.99997503,
-					Category:          "send",
-					InvolvesWatchOnly: false,
-					Fee:               &fee,
-					Vout:              1,
-				},
-				{
-					Account:           "zigzag",
-					Address:           "2N6tEa9BDvgue53LkS8B6BhGBnZEpbJZwhY",
-					Amount:            1,
-					Category:          "receive",
-					InvolvesWatchOnly: false,
-					Fee:               nil,
-					Vout:              0,
-				},
-				{
-					Account:           "",
-					Address:           "2NEEKkxXPRBGaY55NmxiCG6ksJ7LAxn4TfM",
-					Amount:            8.99997503,
-					Category:          "receive",
-					InvolvesWatchOnly: false,
-					Fee:               nil,
-					Vout:              1,
-				},
-			},
-		},
-		err: nil,
-	}); err != nil {
-		t.Fatalf("unable to respond: %v", err)
+		t.Fatalf("unable to list payments: %v", err)
 	}
 
-	if err := c.proceedNextBlock(); err != nil {
-		t.Fatalf("unable to process blocks: %v", err)
+	if len(failed) != 2 || chain.unlocked != 1 {
+		t.Fatalf("all payments of failed transaction should be failed")
 	}
 
-	payments, err := paymentsStore.ListPayments("", "", "", "", "")
+	chain.sendErr = nil
+	c.unspent = nil
+	payments, err := c.SendPayments(recipients, "key")
 	if err != nil {
-		t.Fatalf("unable fetch payment: %v", err)
+		t.Fatalf("unable to send payments: %v", err)
 	}
 
-	spew.Dump(payments)
-}
+	if len(chain.sent) != 1 || len(chain.sent[0].TxOut) != 3 {
+		t.Fatalf("payments should be sent in one transaction with change")
+	}
 
-func TestProcessBlockOutgoingTransaction(t *testing.T) {
-	db, clear, err := sqlite.MakeTestDB()
+	fee := decimal.Zero
+	for _, payment := range payments {
+		if payment.Status != connectors.Pending ||
+			payment.MediaID != chain.sent[0].TxHash().String() {
+			t.Fatalf("payment should be pending: %v", spew.Sdump(payment))
+		}
+
+		fee = fee.Add(payment.MediaFee)
+	}
+
+	change := changePayment(t, store, payments[0], connectors.Outgoing)
+	if change.Status != connectors.Pending {
+		t.Fatalf("change should be pending, got %v", change.Status)
+	}
+
+	if !fee.Add(change.Amount).Equal(decimal.New(15, -1)) {
+		t.Fatalf("fee should be split between payments, got %v", fee)
+	}
+
+	same, err := c.SendPayments(recipients, "key")
 	if err != nil {
-		t.Fatalf("unable create test db: %v", err)
+		t.Fatalf("unable to send payments: %v", err)
 	}
-	defer clear()
 
-	paymentsStore := &sqlite.PaymentsStore{db: db}
-	stateStore := sqlite.NewConnectorStateStorage(connectors.BTC, db)
-	client := NewReplayRPCClient(t)
+	if len(chain.sent) != 1 || same[0].PaymentID != payments[0].PaymentID ||
+		same[1].PaymentID != payments[1].PaymentID {
+		t.Fatalf("payments with the same key shouldn't be sent twice")
+	}
+}
+
+// TestProcessBlockOutgoingTransaction checks that sent payment and its
+// change are completed when transaction is confirmed, and that they are
+// moved back to pending if block is disconnected by reorganisation.
+func TestProcessBlockOutgoingTransaction(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
+	defer clear()
 
-	c, err := initConnector(client, paymentsStore, stateStore)
+	payment, err := c.SendPayment(receiverAddress, "1", "",
+		connectors.DefaultPriority)
 	if err != nil {
-		t.Fatalf("unabelt init connector: %v", err)
+		t.Fatalf("unable send payment: %v", err)
+	}
+
+	fee, _ := payment.MediaFee.Float64()
+	tx := &rpc.Transaction{
+		Amount:        -1,
+		Fee:           -fee,
+		Confirmations: 1,
+		TxID:          payment.MediaID,
+		Details: []rpc.TransactionDetails{{
+			Address:  receiverAddress,
+			Amount:   -1,
+			Category: "send",
+		}},
+	}
+	chain.txs[tx.TxID] = tx
+
+	chain.blocks[blockHash(1)] = &rpc.BlockVerboseResp{
+		Hash:          blockHash(1),
+		Height:        1,
+		NextHash:      blockHash(2),
+		Confirmations: 2,
+	}
+	chain.blocks[blockHash(2)] = &rpc.BlockVerboseResp{
+		Hash:          blockHash(2),
+		Height:        2,
+		Confirmations: 1,
+		PreviousHash:  blockHash(1),
+		Tx:            []string{tx.TxID},
+	}
+	c.lastSyncedBlock = chain.blocks[blockHash(1)]
+
+	if err := c.proceedNextBlock(); err != nil {
+		t.Fatalf("unable to process blocks: %v", err)
 	}
-	defer c.Stop("test end")
 
-	// In order to check behaviour of syncing the outgoing payment,
-	// we have to emulate payment send, otherwise necessary database entries
-	// will not be created and during block processing this transaction will
-	// be skipped.
+	if c.syncedHeight != 2 {
+		t.Fatalf("wrong synced height: %v", c.syncedHeight)
+	}
 
-	// Fee rate client response
-	if err := client.respond(response{
-		data: 0.0001,
-		err:  nil,
-	}); err != nil {
-		t.Fatalf("unable to respond: %v", err)
+	payment, _ = store.PaymentByID(payment.PaymentID)
+	if payment.Status != connectors.Completed {
+		t.Fatalf("payment should be completed, got %v", payment.Status)
 	}
 
-	if err := client.respond(response{
-		data: []rpc.UnspentInput{
-			{
-				Address:       "2NEb8LSh3BLVuCaFSi3iM5PVi4ZhxDoizPV",
-				Account:       "zigzag",
-				Amount:        3,
-				Confirmations: 10,
-				TxID:          "7a99cafa3c53cdfaff44bbca4503e510a7c9dfac968d4b05760070881dec8ff8",
-				Vout:          0,
-			},
-		},
-		err: nil,
-	}); err != nil {
-		t.Fatalf("unable to respond: %v", err)
+	change := changePayment(t, store, payment, connectors.Outgoing)
+	if change.Status != connectors.Completed {
+		t.Fatalf("change should be completed, got %v", change.Status)
 	}
 
-	address, err := decodeAddress(connectors.BTC,
-		"2N6yeKrmeMFNkMWohbQCThx3djEfNhj3WYM", "regtest")
-	if err != nil {
-		t.Fatalf("unable to decode address: %v", err)
+	// Block has been disconnected, and transaction returned in the
+	// mempool.
+	chain.blocks[blockHash(2)].Confirmations = -1
+	chain.blocks[blockHash(1)].Confirmations = 1
+	tx.Confirmations = 0
+
+	if err := c.proceedNextBlock(); err != nil {
+		t.Fatalf("unable to process blocks: %v", err)
 	}
 
-	if err := client.respond(response{
-		data: address,
-		err:  nil,
-	}); err != nil {
-		t.Fatalf("unable to respond: %v", err)
+	if c.lastSyncedBlock.Hash != blockHash(1) {
+		t.Fatalf("sync should continue from the fork block")
 	}
 
-	if err := client.respond(response{
-		data: &wire.MsgTx{
-			Version:  1,
-			TxIn:     nil,
-			TxOut:    nil,
-			LockTime: 0,
-		},
-		err: nil,
-	}); err != nil {
-		t.Fatalf("unable to respond: %v", err)
+	payment, _ = store.PaymentByID(payment.PaymentID)
+	if payment.Status != connectors.Pending {
+		t.Fatalf("reorganised payment should be pending, got %v",
+			payment.Status)
 	}
 
-	payment, err := c.CreatePayment("2N6tEa9BDvgue53LkS8B6BhGBnZEpbJZwhY", "1")
-	if err != nil {
-		t.Fatalf("unable create payment: %v", err)
+	change = changePayment(t, store, payment, connectors.Outgoing)
+	if change.Status != connectors.Pending {
+		t.Fatalf("change should be pending, got %v", change.Status)
 	}
 
-	if _, err := c.SendPayment(payment.PaymentID); err != nil {
-		t.Fatalf("unable create payment: %v", err)
+	statuses := c.cfg.Metrics.(*reorgMetrics).statuses
+	if len(statuses) != 1 || statuses[0] != string(connectors.Pending) {
+		t.Fatalf("wrong reorganisation metrics: %v", statuses)
 	}
+}
 
-	// Return last synced block, in order to check confirmation number
-	lastBlockHashStr := "00000000000000000023486b9d95a729418b2be569f35372b698bd8b308d1178"
-	if err := client.respond(response{
-		data: &rpc.BlockVerboseResp{
-			Hash:          lastBlockHashStr,
-			Height:        1,
-			NextHash:      "00000000000000000016645564d1719f59c967975cec21d8c7d2941834aed36a",
-			Confirmations: 3,
-			Tx:            []string{},
-		},
-		err: nil,
-	}); err != nil {
-		t.Fatalf("unable to respond: %v", err)
-	}
-
-	// Return next block, because confirmation of previous block is higher
-	// than minimal required.
-	if err := client.respond(response{
-		data: &rpc.BlockVerboseResp{
-			Hash:          "00000000000000000016645564d1719f59c967975cec21d8c7d2941834aed36a",
-			Height:        2,
-			NextHash:      "",
-			Confirmations: 2,
-			Tx: []string{
-				"1aef1e640bf5dcca0d922f49afe2f171e1c5bd1517906cdc7507dca466c8e65e",
+// TestProcessBlockCircularTransaction checks that transaction from our
+// address to our another address is saved as external incoming payment on
+// the deposit address, and as internal one on the change address.
+func TestProcessBlockCircularTransaction(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
+	defer clear()
+
+	tx := &rpc.Transaction{
+		Fee:           -2.497e-05,
+		Confirmations: 1,
+		TxID:          blockHash(100),
+		Details: []rpc.TransactionDetails{
+			{
+				Address:  receiverAddress,
+				Amount:   -1,
+				Category: "send",
 			},
-		},
-		err: nil,
-	}); err != nil {
-		t.Fatalf("unable to respond: %v", err)
-	}
-
-	// Emulate circular transaction - from our address, to our another address.
-	fee := -2.497e-05
-	if err := client.respond(response{
-		data: &rpc.Transaction{
-			Amount:        -1,
-			Fee:           -2.497e-05,
-			Confirmations: 1,
-			TxID:          "3ef7aee9fecb3a7f546fe2f6ad05899fe67a5017437cec4051c64cea51a717fa",
-			Details: []rpc.TransactionDetails{
-				{
-					Account:           "",
-					Address:           "2N28ActFCgieyTdmMeui5GzgQhXapj55Fhe",
-					Amount:            -1,
-					Category:          "send",
-					InvolvesWatchOnly: false,
-					Fee:               &fee,
-					Vout:              0,
-				},
-				{
-					Account:           "",
-					Address:           "2N6yeKrmeMFNkMWohbQCThx3djEfNhj3WYM",
-					Amount:            -7.99995006,
-					Category:          "send",
-					InvolvesWatchOnly: false,
-					Fee:               &fee,
-					Vout:              1,
-				},
-				{
-					Account:           "",
-					Address:           "2N6yeKrmeMFNkMWohbQCThx3djEfNhj3WYM",
-					Amount:            7.99995006,
-					Category:          "receive",
-					InvolvesWatchOnly: false,
-					Fee:               nil,
-					Vout:              1,
-				},
+			{
+				Account:  depositAccount,
+				Address:  receiverAddress,
+				Amount:   1,
+				Category: "receive",
+			},
+			{
+				Account:  defaultAccount,
+				Address:  changeAddress,
+				Amount:   8.99997503,
+				Category: "receive",
+				Vout:     1,
 			},
 		},
-		err: nil,
-	}); err != nil {
-		t.Fatalf("unable to respond: %v", err)
 	}
+	chain.txs[tx.TxID] = tx
+
+	chain.blocks[blockHash(1)] = &rpc.BlockVerboseResp{
+		Hash:          blockHash(1),
+		Height:        1,
+		NextHash:      blockHash(2),
+		Confirmations: 2,
+	}
+	chain.blocks[blockHash(2)] = &rpc.BlockVerboseResp{
+		Hash:          blockHash(2),
+		Height:        2,
+		Confirmations: 1,
+		Tx:            []string{tx.TxID},
+	}
+	c.lastSyncedBlock = chain.blocks[blockHash(1)]
 
 	if err := c.proceedNextBlock(); err != nil {
 		t.Fatalf("unable to process blocks: %v", err)
 	}
 
-	payments, err := paymentsStore.ListPayments("", "", "", "", "")
+	payments, err := store.ListPayments("", "", "", "", "")
 	if err != nil {
 		t.Fatalf("unable fetch payment: %v", err)
 	}
 
-	// Should be one 2 internal payments, 1 external
-	spew.Dump(payments)
+	// Outgoing payment which hasn't been sent by connector is not saved.
+	if len(payments) != 2 {
+		t.Fatalf("wrong number of payments: %v", len(payments))
+	}
+
+	for _, payment := range payments {
+		if payment.Status != connectors.Completed ||
+			payment.Direction != connectors.Incoming {
+			t.Fatalf("wrong payment: %v", payment)
+		}
+
+		if (payment.Receipt == changeAddress) !=
+			(payment.System == connectors.Internal) {
+			t.Fatalf("wrong payment system: %v", payment)
+		}
+	}
 }
 
-// (rpc.UnspentInput) {
-//  Address: (string) (len=35) "2N6tEa9BDvgue53LkS8B6BhGBnZEpbJZwhY",
-//  Account: (string) (len=6) "zigzag",
-//  Amount: (float64) 1,
-//  Confirmations: (int64) 0,
-//  TxID: (string) (len=64) "7a99cafa3c53cdfaff44bbca4503e510a7c9dfac968d4b05760070881dec8ff8",
-//  Vout: (uint32) 0
-// },
-// (rpc.UnspentInput) {
-//  Address: (string) (len=35) "2NEEKkxXPRBGaY55NmxiCG6ksJ7LAxn4TfM",
-//  Account: (string) "",
-//  Amount: (float64) 8.99997503,
-//  Confirmations: (int64) 0,
-//  TxID: (string) (len=64) "7a99cafa3c53cdfaff44bbca4503e510a7c9dfac968d4b05760070881dec8ff8",
-//  Vout: (uint32) 1
-// }
-//}
-
-// Transaction to someone else (to 2N28ActFCgieyTdmMeui5GzgQhXapj55Fhe)
-//
-// (rpc.UnspentInput) {
-//  Address: (string) (len=35) "2N6yeKrmeMFNkMWohbQCThx3djEfNhj3WYM",
-//  Account: (string) "",
-//  Amount: (float64) 7.99995006,
-//  Confirmations: (int64) 0,
-//  TxID: (string) (len=64) "3ef7aee9fecb3a7f546fe2f6ad05899fe67a5017437cec4051c64cea51a717fa",
-//  Vout: (uint32) 1
-// }
-//}
-
-// Transaction to us from someone else:
-//
-//(*rpc.Transaction)(0xc42073e780)({
-// Amount: (float64) 3,
-// Fee: (float64) 0,
-// Confirmations: (int64) 1,
-// TxID: (string) (len=64) "49c3287d6c503934e93529723d5f6cc4459ae1c7ed078d9a095d7a22267bf097",
-// Details: ([]rpc.TransactionDetails) (len=1 cap=1) {
-//  (rpc.TransactionDetails) {
-//   Account: (string) (len=6) "zigzag",
-//   Address: (string) (len=35) "2NEb8LSh3BLVuCaFSi3iM5PVi4ZhxDoizPV",
-//   Amount: (float64) 3,
-//   Category: (string) (len=7) "receive",
-//   InvolvesWatchOnly: (bool) false,
-//   Fee: (*float64)(<nil>),
-//   Vout: (uint32) 1
-//  }
-// }
-//})
-//
-//
-//([]rpc.UnspentInput) (len=1 cap=1) {
-// (rpc.UnspentInput) {
-//  Address: (string) (len=35) "2NEb8LSh3BLVuCaFSi3iM5PVi4ZhxDoizPV",
-//  Account: (string) (len=6) "zigzag",
-//  Amount: (float64) 1,
-//  Confirmations: (int64) 0,
-//  TxID: (string) (len=64) "5d00a0a2d75ddfc36901d0afd95fd16b1f508b060e92ff61fd383e8cde585282",
-//  Vout: (uint32) 0
-// }
-//}
-
-// Transaction
+// TestEstimateTxFee checks that fee is estimated with the size of the
+// transaction which would be created for the payment.
+func TestEstimateTxFee(t *testing.T) {
+	c, _, _, clear := newTestConnector(t)
+	defer clear()
+
+	estimate, err := c.EstimateTxFee(receiverAddress, "1",
+		connectors.DefaultPriority)
+	if err != nil {
+		t.Fatalf("unable to estimate fee: %v", err)
+	}
+
+	// P2PKH input, P2SH receiver output and P2PKH change output with
+	// the fee rate of 1 sat/byte.
+	if estimate.Inputs != 1 || estimate.VSize != 10+149+32+34 ||
+		!estimate.Fee.Equal(sat2DecAmount(10+149+32+34)) {
+		t.Fatalf("wrong estimate: %v", spew.Sdump(estimate))
+	}
+
+	fee, err := c.EstimateFee("0", connectors.DefaultPriority)
+	if err != nil {
+		t.Fatalf("unable to estimate fee: %v", err)
+	}
+
+	if !fee.Equal(sat2DecAmount(10 + 149 + 2*34)) {
+		t.Fatalf("wrong fee of the payment without amount: %v", fee)
+	}
+
+	_, err = c.EstimateTxFee(receiverAddress, "3",
+		connectors.DefaultPriority)
+	if _, ok := err.(*connectors.ErrInsufficientFunds); !ok {
+		t.Fatalf("insufficient funds error should be returned, got %v", err)
+	}
+
+	_, err = c.EstimateTxFee("invalid", "1",
+		connectors.DefaultPriority)
+	if _, ok := err.(*connectors.ErrInvalidReceipt); !ok {
+		t.Fatalf("invalid receipt error should be returned, got %v", err)
+	}
+}
@@ -0,0 +1,460 @@
+package bitcoind
+
+import (
+	"encoding/hex"
+	"sort"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/go-errors/errors"
+)
+
+const (
+	// LargestFirst is the name of the coin selection strategy which spends
+	// the largest outputs first, it results in the transactions with the
+	// least number of inputs and the lowest fee.
+	LargestFirst = "largest"
+
+	// SmallestFirst is the name of the coin selection strategy which
+	// spends the smallest outputs first, it consolidates small outputs at
+	// the price of the bigger fee.
+	SmallestFirst = "smallest"
+
+	// BranchAndBound is the name of the coin selection strategy which
+	// searches for the set of outputs which pays the amount and fee
+	// without the change, and falls back to the largest first strategy
+	// if there is no such set.
+	BranchAndBound = "bnb"
+
+	// OldestFirst is the name of the coin selection strategy which spends
+	// outputs with the biggest number of confirmations first.
+	OldestFirst = "oldest"
+)
+
+// bnbMaxTries is the maximum number of the branch and bound search steps,
+// after which the best found solution is used.
+const bnbMaxTries = 100000
+
+// CoinSelection is the result of the coin selection.
+type CoinSelection struct {
+	// Inputs are the unspent outputs which should be spent by the
+	// transaction.
+	Inputs []rpc.UnspentInput
+
+	// Change is the amount of the change output, it is zero if
+	// transaction has no change.
+	Change btcutil.Amount
+
+	// Fee is the fee of the transaction. It includes change which is
+	// less than dust limit, and is left to the miners.
+	Fee btcutil.Amount
+
+	// VSize is the estimated virtual size of the transaction in bytes.
+	VSize int
+}
+
+// CoinSelector is an interface of the coin selection strategy, which
+// chooses the unspent outputs spent by the transaction.
+type CoinSelector interface {
+	// Select selects unspent outputs which are enough to pay the overall
+	// amount to the receivers along with the fee of the transaction, with
+	// the given fee rate in sat/byte. If outputs are not enough
+	// connectors.ErrInsufficientFunds is returned.
+	Select(feeRatePerByte uint64, amt btcutil.Amount,
+		receivers []btcutil.Address, unspent []rpc.UnspentInput) (
+		*CoinSelection, error)
+}
+
+// NewCoinSelector returns coin selection strategy by its name, empty name
+// denotes the largest first strategy.
+func NewCoinSelector(name string) (CoinSelector, error) {
+	switch name {
+	case "", LargestFirst:
+		return &orderedSelector{less: largerInput}, nil
+	case SmallestFirst:
+		return &orderedSelector{less: smallerInput}, nil
+	case BranchAndBound:
+		return &bnbSelector{
+			fallback: &orderedSelector{less: largerInput},
+		}, nil
+	case OldestFirst:
+		return &orderedSelector{less: olderInput}, nil
+	default:
+		return nil, errors.Errorf("unknown coin selection strategy(%v)",
+			name)
+	}
+}
+
+// orderedSelector is the coin selection strategy which spends outputs in
+// the given order, until they are enough to pay the amount and the fee.
+type orderedSelector struct {
+	// less reports whether first output should be spent before the
+	// second one.
+	less func(a, b rpc.UnspentInput) bool
+}
+
+// Select selects unspent outputs which are enough to pay the overall amount
+// to the receivers along with the fee of the transaction.
+//
+// NOTE: Part of the CoinSelector interface.
+func (s *orderedSelector) Select(feeRatePerByte uint64, amt btcutil.Amount,
+	receivers []btcutil.Address, unspent []rpc.UnspentInput) (*CoinSelection,
+	error) {
+
+	inputs, err := sortInputs(unspent, s.less)
+	if err != nil {
+		return nil, err
+	}
+
+	var total btcutil.Amount
+	for i, input := range inputs {
+		total += input.amount
+
+		selection := newSelection(feeRatePerByte, amt, receivers, inputs[:i+1],
+			total)
+		if selection != nil {
+			return selection, nil
+		}
+	}
+
+	return nil, insufficientFunds(feeRatePerByte, amt, receivers, inputs, total)
+}
+
+// bnbSelector is the branch and bound coin selection strategy, it searches
+// for the set of outputs which pays the amount and the fee with the excess
+// which is less than the cost of the change output, so that excess could
+// be left to the miners and the change output isn't needed.
+type bnbSelector struct {
+	// fallback is used if there is no set of outputs which doesn't need
+	// the change.
+	fallback CoinSelector
+}
+
+// Select selects unspent outputs which are enough to pay the overall amount
+// to the receivers along with the fee of the transaction.
+//
+// NOTE: Part of the CoinSelector interface.
+func (s *bnbSelector) Select(feeRatePerByte uint64, amt btcutil.Amount,
+	receivers []btcutil.Address, unspent []rpc.UnspentInput) (*CoinSelection,
+	error) {
+
+	inputs, err := sortInputs(unspent, largerInput)
+	if err != nil {
+		return nil, err
+	}
+
+	// Search is done over the effective values of the inputs, i.e. the
+	// amount which is left after paying the fee for the input itself.
+	// Inputs which cost more than they bring are not worth spending.
+	var (
+		pool      []selectorInput
+		effective []btcutil.Amount
+		available btcutil.Amount
+	)
+	for _, input := range inputs {
+		value := input.amount - inputFee(feeRatePerByte, input.UnspentInput)
+		if value <= 0 {
+			continue
+		}
+
+		pool = append(pool, input)
+		effective = append(effective, value)
+		available += value
+	}
+
+	// Change output costs the fee for the output itself, and the fee for
+	// spending it in the future.
+	costOfChange := btcutil.Amount(uint64(P2PKHOutputSize)*feeRatePerByte) +
+		inputFee(feeRatePerByte, rpc.UnspentInput{})
+	target := amt + txFee(feeRatePerByte, receivers, nil, false)
+
+	var (
+		value     btcutil.Amount
+		total     btcutil.Amount
+		included  []bool
+		best      *CoinSelection
+		bestWaste btcutil.Amount
+	)
+
+	for tries := 0; tries < bnbMaxTries; tries++ {
+		backtrack := false
+
+		switch {
+		case value+available < target || value > target+costOfChange:
+			backtrack = true
+
+		case value >= target:
+			// Effective values are only an estimation, so fee of the
+			// found solution is checked with the whole transaction.
+			var selected []selectorInput
+			for i, ok := range included {
+				if ok {
+					selected = append(selected, pool[i])
+				}
+			}
+
+			fee := txFee(feeRatePerByte, receivers, selected, false)
+			waste := total - amt - fee
+			if waste >= 0 && waste <= costOfChange &&
+				(best == nil || waste < bestWaste) {
+				best = &CoinSelection{
+					Inputs: unwrapInputs(selected),
+					Fee:    total - amt,
+					VSize:  txVSize(receivers, selected, false),
+				}
+				bestWaste = waste
+			}
+
+			backtrack = true
+		}
+
+		if backtrack {
+			// Walk back to the last included input, returning excluded
+			// ones to the available ones, and try the branch without it.
+			for len(included) > 0 && !included[len(included)-1] {
+				included = included[:len(included)-1]
+				available += effective[len(included)]
+			}
+
+			if len(included) == 0 {
+				break
+			}
+
+			last := len(included) - 1
+			included[last] = false
+			value -= effective[last]
+			total -= pool[last].amount
+			continue
+		}
+
+		// Try the branch with the next input.
+		next := len(included)
+		available -= effective[next]
+		value += effective[next]
+		total += pool[next].amount
+		included = append(included, true)
+	}
+
+	if best != nil {
+		return best, nil
+	}
+
+	return s.fallback.Select(feeRatePerByte, amt, receivers, unspent)
+}
+
+// selectorInput is the unspent output with the parsed amount.
+type selectorInput struct {
+	rpc.UnspentInput
+	amount btcutil.Amount
+}
+
+// sortInputs parses amounts of the unspent outputs, and returns them in
+// the given order. Outputs which are equal from the order POV are sorted by
+// their outpoint, so that selection is deterministic.
+func sortInputs(unspent []rpc.UnspentInput,
+	less func(a, b rpc.UnspentInput) bool) ([]selectorInput, error) {
+
+	inputs := make([]selectorInput, len(unspent))
+	for i, input := range unspent {
+		amount, err := btcutil.NewAmount(input.Amount)
+		if err != nil {
+			return nil, errors.Errorf("unable to parse amount of "+
+				"input(%v:%v): %v", input.TxID, input.Vout, err)
+		}
+
+		inputs[i] = selectorInput{UnspentInput: input, amount: amount}
+	}
+
+	sort.Slice(inputs, func(i, j int) bool {
+		a, b := inputs[i].UnspentInput, inputs[j].UnspentInput
+		if less(a, b) {
+			return true
+		}
+
+		if less(b, a) {
+			return false
+		}
+
+		if a.TxID != b.TxID {
+			return a.TxID < b.TxID
+		}
+
+		return a.Vout < b.Vout
+	})
+
+	return inputs, nil
+}
+
+func largerInput(a, b rpc.UnspentInput) bool {
+	return a.Amount > b.Amount
+}
+
+func smallerInput(a, b rpc.UnspentInput) bool {
+	return a.Amount < b.Amount
+}
+
+func olderInput(a, b rpc.UnspentInput) bool {
+	return a.Confirmations > b.Confirmations
+}
+
+// newSelection returns selection of the given inputs if they are enough to
+// pay the amount and the fee, otherwise nil is returned. Change which is
+// less than dust limit is left to the miners, otherwise transaction would
+// be rejected by the network.
+func newSelection(feeRatePerByte uint64, amt btcutil.Amount,
+	receivers []btcutil.Address, inputs []selectorInput,
+	total btcutil.Amount) *CoinSelection {
+
+	fee := txFee(feeRatePerByte, receivers, inputs, true)
+	if total >= amt+fee {
+		change := total - amt - fee
+		if change >= DefaultDustLimit() {
+			return &CoinSelection{
+				Inputs: unwrapInputs(inputs),
+				Change: change,
+				Fee:    fee,
+				VSize:  txVSize(receivers, inputs, true),
+			}
+		}
+	}
+
+	// Inputs might be not enough to pay for the change output, but enough
+	// to pay for the transaction without it.
+	fee = txFee(feeRatePerByte, receivers, inputs, false)
+	if total >= amt+fee {
+		return &CoinSelection{
+			Inputs: unwrapInputs(inputs),
+			Fee:    total - amt,
+			VSize:  txVSize(receivers, inputs, false),
+		}
+	}
+
+	return nil
+}
+
+// insufficientFunds returns error with the amount which is needed to pay
+// for the transaction spending all inputs.
+func insufficientFunds(feeRatePerByte uint64, amt btcutil.Amount,
+	receivers []btcutil.Address, inputs []selectorInput,
+	total btcutil.Amount) error {
+
+	needed := amt + txFee(feeRatePerByte, receivers, inputs, false)
+	return &connectors.ErrInsufficientFunds{
+		Needed:    sat2DecAmount(needed),
+		Available: sat2DecAmount(total),
+	}
+}
+
+func unwrapInputs(inputs []selectorInput) []rpc.UnspentInput {
+	unspent := make([]rpc.UnspentInput, len(inputs))
+	for i, input := range inputs {
+		unspent[i] = input.UnspentInput
+	}
+
+	return unspent
+}
+
+// txVSize returns virtual size of the transaction which spends the given
+// inputs, pays to the receivers, and optionally has the change output.
+func txVSize(receivers []btcutil.Address, inputs []selectorInput,
+	withChange bool) int {
+
+	var weightEstimate TxWeightEstimator
+	for _, input := range inputs {
+		addInput(&weightEstimate, input.UnspentInput)
+	}
+
+	for _, receiver := range receivers {
+		addOutput(&weightEstimate, receiver)
+	}
+
+	// Change address is created after the coin selection, assume that
+	// it is P2PKH, as the largest of the standard outputs.
+	if withChange {
+		weightEstimate.AddP2PKHOutput()
+	}
+
+	return weightEstimate.VSize()
+}
+
+// txFee returns fee of the transaction with the given fee rate.
+func txFee(feeRatePerByte uint64, receivers []btcutil.Address,
+	inputs []selectorInput, withChange bool) btcutil.Amount {
+	return btcutil.Amount(uint64(txVSize(receivers, inputs, withChange)) *
+		feeRatePerByte)
+}
+
+// inputFee returns the fee which is paid for spending the given output.
+func inputFee(feeRatePerByte uint64, input rpc.UnspentInput) btcutil.Amount {
+	var weightEstimate TxWeightEstimator
+	addInput(&weightEstimate, input)
+
+	weight := weightEstimate.inputSize * witnessScaleFactor
+	if weightEstimate.hasWitness {
+		weight += weightEstimate.inputWitnessSize
+	}
+	size := (weight + witnessScaleFactor - 1) / witnessScaleFactor
+
+	return btcutil.Amount(uint64(size) * feeRatePerByte)
+}
+
+// addInput updates weight estimate with the input which spends the given
+// output, the type of the input is determined by the script of the output.
+// Output with unknown script is assumed to be P2PKH, as the largest of the
+// standard ones.
+//
+// NOTE: Wallet creates P2SH addresses only with nested P2WKH script.
+func addInput(weightEstimate *TxWeightEstimator, input rpc.UnspentInput) {
+	script, err := hex.DecodeString(input.ScriptPubKey)
+	if err != nil {
+		weightEstimate.AddP2PKHInput()
+		return
+	}
+
+	switch txscript.GetScriptClass(script) {
+	case txscript.WitnessV0PubKeyHashTy:
+		weightEstimate.AddP2WKHInput()
+	case txscript.ScriptHashTy:
+		weightEstimate.AddNestedP2WKHInput()
+	default:
+		weightEstimate.AddP2PKHInput()
+	}
+}
+
+// addOutput updates weight estimate with the output which pays to the
+// given address.
+func addOutput(weightEstimate *TxWeightEstimator, address btcutil.Address) {
+	switch address.(type) {
+	case *btcutil.AddressWitnessPubKeyHash:
+		weightEstimate.AddP2WKHOutput()
+	case *btcutil.AddressWitnessScriptHash:
+		weightEstimate.AddP2WSHOutput()
+	case *btcutil.AddressScriptHash:
+		weightEstimate.AddP2SHOutput()
+	default:
+		weightEstimate.AddP2PKHOutput()
+	}
+}
+
+// SimulateSelection returns the coin selection of the transaction which
+// sends the amount to the receivers, it is used to estimate the fee of the
+// transaction. If amount is zero, transaction spending the single output
+// and having the change is simulated, so that fee could be estimated even
+// if there are no unspent outputs.
+func SimulateSelection(selector CoinSelector, feeRatePerByte uint64,
+	amt btcutil.Amount, receivers []btcutil.Address,
+	unspent []rpc.UnspentInput) (*CoinSelection, error) {
+
+	if amt > 0 {
+		return selector.Select(feeRatePerByte, amt, receivers, unspent)
+	}
+
+	inputs := []selectorInput{{}}
+	return &CoinSelection{
+		Inputs: unwrapInputs(inputs),
+		Fee:    txFee(feeRatePerByte, receivers, inputs, true),
+		VSize:  txVSize(receivers, inputs, true),
+	}, nil
+}
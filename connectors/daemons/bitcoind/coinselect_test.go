@@ -0,0 +1,301 @@
+package bitcoind
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+var (
+	// p2pkhScript is the script of the P2PKH output.
+	p2pkhScript = "76a914" + hex.EncodeToString(bytes.Repeat([]byte{1}, 20)) +
+		"88ac"
+
+	// p2wkhScript is the script of the native P2WKH output.
+	p2wkhScript = "0014" + hex.EncodeToString(bytes.Repeat([]byte{1}, 20))
+)
+
+// testReceivers returns the single P2PKH address on which payments are
+// sent.
+func testReceivers(t *testing.T) []btcutil.Address {
+	address, err := btcutil.NewAddressPubKeyHash(make([]byte, 20),
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+
+	return []btcutil.Address{address}
+}
+
+// testUnspent returns P2PKH outputs with the given amounts in BTC, every
+// next output has less confirmations than previous one.
+func testUnspent(amounts ...float64) []rpc.UnspentInput {
+	unspent := make([]rpc.UnspentInput, len(amounts))
+	for i, amount := range amounts {
+		unspent[i] = rpc.UnspentInput{
+			Amount:        amount,
+			Confirmations: int64(100 - i),
+			TxID:          blockHash(byte(i + 1)),
+			ScriptPubKey:  p2pkhScript,
+		}
+	}
+
+	return unspent
+}
+
+func selectorInputs(t *testing.T, unspent []rpc.UnspentInput) []selectorInput {
+	inputs, err := sortInputs(unspent, largerInput)
+	if err != nil {
+		t.Fatalf("unable to parse inputs: %v", err)
+	}
+
+	return inputs
+}
+
+func amounts(inputs []rpc.UnspentInput) []float64 {
+	result := make([]float64, len(inputs))
+	for i, input := range inputs {
+		result[i] = input.Amount
+	}
+
+	return result
+}
+
+func equalAmounts(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestNewCoinSelector(t *testing.T) {
+	for _, name := range []string{"", LargestFirst, SmallestFirst,
+		BranchAndBound, OldestFirst} {
+		if _, err := NewCoinSelector(name); err != nil {
+			t.Fatalf("unable to create selector(%v): %v", name, err)
+		}
+	}
+
+	if _, err := NewCoinSelector("random"); err == nil {
+		t.Fatalf("unknown selector shouldn't be created")
+	}
+}
+
+// TestCoinSelectorStrategies checks that strategies select inputs in their
+// order, and that change and fee are calculated properly.
+func TestCoinSelectorStrategies(t *testing.T) {
+	receivers := testReceivers(t)
+	amt := btcutil.Amount(0.35 * btcutil.SatoshiPerBitcoin)
+	feeRate := uint64(10)
+
+	tests := []struct {
+		name     string
+		unspent  []rpc.UnspentInput
+		selected []float64
+	}{
+		{
+			name:     LargestFirst,
+			unspent:  testUnspent(0.1, 0.5, 0.3, 0.2),
+			selected: []float64{0.5},
+		},
+		{
+			name:     SmallestFirst,
+			unspent:  testUnspent(0.1, 0.5, 0.3, 0.2),
+			selected: []float64{0.1, 0.2, 0.3},
+		},
+		{
+			name:     OldestFirst,
+			unspent:  testUnspent(0.3, 0.1, 0.5, 0.2),
+			selected: []float64{0.3, 0.1},
+		},
+		{
+			// There is no changeless solution, so largest first is
+			// used.
+			name:     BranchAndBound,
+			unspent:  testUnspent(0.1, 0.5, 0.3, 0.2),
+			selected: []float64{0.5},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector, _ := NewCoinSelector(test.name)
+
+			selection, err := selector.Select(feeRate, amt, receivers,
+				test.unspent)
+			if err != nil {
+				t.Fatalf("unable to select inputs: %v", err)
+			}
+
+			if !equalAmounts(amounts(selection.Inputs), test.selected) {
+				t.Fatalf("wrong inputs: %v", amounts(selection.Inputs))
+			}
+
+			// P2PKH inputs with P2PKH receiver and change outputs,
+			// signatures are assumed to be of the maximum size.
+			size := 10 + 149*len(selection.Inputs) + 2*34
+			if selection.VSize != size {
+				t.Fatalf("wrong size: %v, expected: %v", selection.VSize,
+					size)
+			}
+
+			if selection.Fee != btcutil.Amount(uint64(size)*feeRate) {
+				t.Fatalf("wrong fee: %v", selection.Fee)
+			}
+
+			var total btcutil.Amount
+			for _, amount := range selection.Inputs {
+				a, _ := btcutil.NewAmount(amount.Amount)
+				total += a
+			}
+
+			if total != amt+selection.Fee+selection.Change {
+				t.Fatalf("wrong change: %v", selection.Change)
+			}
+		})
+	}
+}
+
+// TestBranchAndBound checks that branch and bound finds the inputs which
+// pay the amount and the fee without the change.
+func TestBranchAndBound(t *testing.T) {
+	receivers := testReceivers(t)
+	feeRate := uint64(10)
+	unspent := testUnspent(1, 2, 5, 3)
+
+	// Only inputs of 1 and 3 BTC are able to pay the amount without the
+	// change.
+	expected := selectorInputs(t, []rpc.UnspentInput{unspent[0], unspent[3]})
+	amt := btcutil.Amount(4*btcutil.SatoshiPerBitcoin) -
+		txFee(feeRate, receivers, expected, false)
+
+	selector, _ := NewCoinSelector(BranchAndBound)
+	selection, err := selector.Select(feeRate, amt, receivers, unspent)
+	if err != nil {
+		t.Fatalf("unable to select inputs: %v", err)
+	}
+
+	if !equalAmounts(amounts(selection.Inputs), []float64{3, 1}) {
+		t.Fatalf("wrong inputs: %v", amounts(selection.Inputs))
+	}
+
+	if selection.Change != 0 {
+		t.Fatalf("transaction shouldn't have change")
+	}
+
+	if selection.Fee != txFee(feeRate, receivers, expected, false) {
+		t.Fatalf("wrong fee: %v", selection.Fee)
+	}
+
+	// Largest first spends the largest input and creates the change.
+	selector, _ = NewCoinSelector(LargestFirst)
+	selection, err = selector.Select(feeRate, amt, receivers, unspent)
+	if err != nil {
+		t.Fatalf("unable to select inputs: %v", err)
+	}
+
+	if !equalAmounts(amounts(selection.Inputs), []float64{5}) ||
+		selection.Change == 0 {
+		t.Fatalf("wrong inputs: %v", amounts(selection.Inputs))
+	}
+}
+
+// TestDustChange checks that change which is less than dust limit is left
+// to the miners.
+func TestDustChange(t *testing.T) {
+	receivers := testReceivers(t)
+	feeRate := uint64(10)
+	unspent := testUnspent(1)
+
+	fee := txFee(feeRate, receivers, selectorInputs(t, unspent), false)
+	amt := btcutil.Amount(btcutil.SatoshiPerBitcoin) - fee - 100
+
+	selector, _ := NewCoinSelector(LargestFirst)
+	selection, err := selector.Select(feeRate, amt, receivers, unspent)
+	if err != nil {
+		t.Fatalf("unable to select inputs: %v", err)
+	}
+
+	if selection.Change != 0 || selection.Fee != fee+100 {
+		t.Fatalf("dust change should be left to miners, change: %v, "+
+			"fee: %v", selection.Change, selection.Fee)
+	}
+
+	if selection.VSize != 10+149+34 {
+		t.Fatalf("wrong size: %v", selection.VSize)
+	}
+}
+
+// TestInsufficientFundsSelection checks that typed error is returned if
+// outputs are not enough to pay the amount and the fee.
+func TestInsufficientFundsSelection(t *testing.T) {
+	receivers := testReceivers(t)
+	unspent := testUnspent(0.1, 0.2)
+
+	for _, name := range []string{LargestFirst, SmallestFirst,
+		BranchAndBound, OldestFirst} {
+		selector, _ := NewCoinSelector(name)
+
+		_, err := selector.Select(10, btcutil.Amount(0.3*
+			btcutil.SatoshiPerBitcoin), receivers, unspent)
+		if _, ok := err.(*connectors.ErrInsufficientFunds); !ok {
+			t.Fatalf("%v: insufficient funds error should be returned, "+
+				"got %v", name, err)
+		}
+	}
+}
+
+// TestInputTypes checks that size of the transaction depends on the
+// scripts of the spent outputs.
+func TestInputTypes(t *testing.T) {
+	receivers := testReceivers(t)
+
+	tests := []struct {
+		script string
+		size   int
+	}{
+		{
+			// Unknown scripts are treated as P2PKH.
+			script: "",
+			size:   10 + 149 + 2*34,
+		},
+		{
+			script: p2pkhScript,
+			size:   10 + 149 + 2*34,
+		},
+		{
+			// 4 * (10 + 41 + 2*34) + 2 + 109 = 587 weight.
+			script: p2wkhScript,
+			size:   147,
+		},
+	}
+
+	for _, test := range tests {
+		unspent := testUnspent(1)
+		unspent[0].ScriptPubKey = test.script
+
+		selector, _ := NewCoinSelector(LargestFirst)
+		selection, err := selector.Select(1, btcutil.Amount(
+			0.5*btcutil.SatoshiPerBitcoin), receivers, unspent)
+		if err != nil {
+			t.Fatalf("unable to select inputs: %v", err)
+		}
+
+		if selection.VSize != test.size {
+			t.Fatalf("wrong size of transaction with script(%v): %v, "+
+				"expected: %v", test.script, selection.VSize, test.size)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package bitcoind
+
+import (
+	"bytes"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bitlum/connector/common"
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/btcsuite/btclog"
+	"github.com/btcsuite/btcutil"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// ConsolidatorConfig is a config of the unspent outputs consolidator.
+type ConsolidatorConfig struct {
+	// Asset is an asset of the connector which outputs are consolidated.
+	Asset connectors.Asset
+
+	// Policy determines which outputs are consolidated and when.
+	Policy connectors.ConsolidationPolicy
+
+	// Client is the rpc client of the daemon which signs the consolidation
+	// transactions.
+	Client rpc.Client
+
+	// PaymentStore is used to save the consolidation payments.
+	PaymentStore connectors.PaymentsStore
+
+	// MinConfirmations is the number of confirmations of the unspent
+	// outputs, which might be consolidated.
+	MinConfirmations int
+
+	// FeeRate returns the fee rate of the priority in sat/byte.
+	FeeRate func(priority connectors.FeePriority) decimal.Decimal
+
+	// Send sends the waiting consolidation payment.
+	Send func(payment *connectors.Payment) (*connectors.Payment, error)
+
+	// Spent is called after consolidation payment has been saved, so that
+	// connector could remove its inputs from the local cache. It might be
+	// nil.
+	Spent func(inputs []rpc.UnspentInput)
+
+	// Locker is the send mutex of the connector, it is held while outputs
+	// are selected and consolidation payment is sent, so that they
+	// wouldn't be spent by the concurrent payments.
+	Locker sync.Locker
+
+	Logger btclog.Logger
+}
+
+func (c *ConsolidatorConfig) validate() error {
+	if c.Asset == "" {
+		return errors.New("asset should be specified")
+	}
+
+	if err := c.Policy.Validate(); err != nil {
+		return err
+	}
+
+	if c.Client == nil {
+		return errors.New("rpc client should be specified")
+	}
+
+	if c.PaymentStore == nil {
+		return errors.New("payment store should be specified")
+	}
+
+	if c.FeeRate == nil {
+		return errors.New("fee rate function should be specified")
+	}
+
+	if c.Send == nil {
+		return errors.New("send function should be specified")
+	}
+
+	if c.Locker == nil {
+		return errors.New("locker should be specified")
+	}
+
+	if c.Logger == nil {
+		return errors.New("logger should be specified")
+	}
+
+	return nil
+}
+
+// Consolidator merges small unspent outputs of the wallet into one output
+// on the new address of the wallet, when fee rate is low enough. Merged
+// outputs are spent with the cost of one input when fee rates are high.
+// Consolidation transaction is saved as the outgoing internal payment.
+type Consolidator struct {
+	cfg *ConsolidatorConfig
+	log *common.NamedLogger
+}
+
+// NewConsolidator creates new unspent outputs consolidator.
+func NewConsolidator(cfg *ConsolidatorConfig) (*Consolidator, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Consolidator{
+		cfg: cfg,
+		log: &common.NamedLogger{
+			Name:   string(cfg.Asset),
+			Logger: cfg.Logger,
+		},
+	}, nil
+}
+
+// Run consolidates unspent outputs with the interval of the policy, until
+// quit channel is closed.
+func (c *Consolidator) Run(quit <-chan struct{}) {
+	ticker := time.NewTicker(c.cfg.Policy.Interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := c.Consolidate(); err != nil {
+				c.cfg.Logger.Errorf("unable to consolidate %v unspent "+
+					"outputs: %v", c.cfg.Asset, err)
+			}
+		case <-quit:
+			return
+		}
+	}
+}
+
+// Consolidate sends the transaction which merges unspent outputs below the
+// threshold of the policy, and returns its payment. Nil is returned if fee
+// rate exceeds the ceiling of the policy, or if there are not enough small
+// outputs to consolidate.
+func (c *Consolidator) Consolidate() (*connectors.Payment, error) {
+	policy := c.cfg.Policy
+
+	feeRate := c.cfg.FeeRate(policy.ConsolidationPriority())
+	if feeRate.GreaterThan(decimal.NewFromFloat(policy.MaxFeeRate)) {
+		c.cfg.Logger.Debugf("Skip consolidation of %v unspent outputs, fee "+
+			"rate(%v) exceeds the max consolidation fee rate(%v)",
+			c.cfg.Asset, feeRate, policy.MaxFeeRate)
+		return nil, nil
+	}
+	feeRatePerByte := uint64(feeRate.Ceil().IntPart())
+
+	c.cfg.Locker.Lock()
+	defer c.cfg.Locker.Unlock()
+
+	unspent, err := c.cfg.Client.ListUnspentMinMax(c.cfg.MinConfirmations,
+		math.MaxInt32)
+	if err != nil {
+		return nil, errors.Errorf("unable to list unspent: %v", err)
+	}
+
+	minInputs, maxInputs := policy.InputsRange()
+	inputs, err := consolidationInputs(SpendableInputs(unspent),
+		decAmount2Sat(policy.Threshold), feeRatePerByte, maxInputs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(inputs) < minInputs {
+		c.cfg.Logger.Debugf("Skip consolidation of %v unspent outputs, "+
+			"only %v outputs are below the threshold(%v)", c.cfg.Asset,
+			len(inputs), policy.Threshold)
+		return nil, nil
+	}
+
+	// Output is sent on the new address rather than on the change one,
+	// so that consolidation transaction would be listed by the wallet,
+	// and its payment would be completed by the sync.
+	address, err := c.cfg.Client.GetNewAddress(defaultAccount)
+	if err != nil {
+		return nil, errors.Errorf("unable to get new address: %v", err)
+	}
+
+	var total btcutil.Amount
+	for _, input := range inputs {
+		total += input.amount
+	}
+
+	fee := txFee(feeRatePerByte, []btcutil.Address{address}, inputs,
+		false)
+	if total-fee < DefaultDustLimit() {
+		return nil, nil
+	}
+
+	payment, err := c.createPayment(unwrapInputs(inputs), address,
+		total-fee, fee)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cfg.Spent != nil {
+		c.cfg.Spent(unwrapInputs(inputs))
+	}
+
+	c.cfg.Logger.Infof("Consolidate %v unspent %v outputs with overall "+
+		"amount %v, fee(%v), payment(%v)", len(inputs), c.cfg.Asset,
+		printAmount(total), printAmount(fee), payment.PaymentID)
+
+	return c.cfg.Send(payment)
+}
+
+// createPayment creates and signs the transaction which sends the amount
+// to the address spending the given inputs, and saves it as the waiting
+// internal payment. Inputs are locked, and they are unlocked if payment
+// couldn't be created.
+func (c *Consolidator) createPayment(inputs []rpc.UnspentInput,
+	address btcutil.Address, amount,
+	fee btcutil.Amount) (*connectors.Payment, error) {
+
+	var err error
+	var locked []rpc.UnspentInput
+	defer func() {
+		if err != nil {
+			UnlockInputs(c.cfg.Client, locked, c.log)
+		}
+	}()
+
+	for _, input := range inputs {
+		if err = c.cfg.Client.LockUnspent(input); err != nil {
+			return nil, errors.Errorf("unable to lock input: %v", err)
+		}
+		locked = append(locked, input)
+	}
+
+	tx, err := c.cfg.Client.CreateRawTransaction(inputs,
+		map[btcutil.Address]btcutil.Amount{address: amount})
+	if err != nil {
+		return nil, errors.Errorf("unable to create transaction: %v", err)
+	}
+
+	if SupportsRBF(c.cfg.Asset) {
+		SignalRBF(tx)
+	}
+
+	signedTx, err := c.cfg.Client.SignRawTransaction(tx)
+	if err != nil {
+		return nil, errors.Errorf("unable to sign transaction: %v", err)
+	}
+
+	var rawTx bytes.Buffer
+	if err = signedTx.Serialize(&rawTx); err != nil {
+		return nil, errors.Errorf("unable serialize signed tx: %v", err)
+	}
+
+	txID := signedTx.TxHash().String()
+	payment := &connectors.Payment{
+		UpdatedAt: connectors.NowInMilliSeconds(),
+		Status:    connectors.Waiting,
+		Direction: connectors.Outgoing,
+		System:    connectors.Internal,
+		Receipt:   address.String(),
+		Asset:     c.cfg.Asset,
+		Media:     connectors.Blockchain,
+		Amount:    sat2DecAmount(amount).Round(8),
+		MediaFee:  sat2DecAmount(fee),
+		MediaID:   txID,
+		Detail: &connectors.GeneratedTxDetails{
+			RawTx: rawTx.Bytes(),
+			TxID:  txID,
+		},
+	}
+
+	payment.PaymentID, err = payment.GenPaymentID()
+	if err != nil {
+		return nil, errors.Errorf("unable generate payment id: %v", err)
+	}
+
+	if err = c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return nil, errors.Errorf("unable add payment in store: %v", err)
+	}
+
+	return payment, nil
+}
+
+// consolidationInputs returns the smallest outputs which are below the
+// threshold, but which are worth more than the fee of their spending with
+// the given fee rate, otherwise consolidation would only burn them.
+func consolidationInputs(unspent []rpc.UnspentInput,
+	threshold btcutil.Amount, feeRatePerByte uint64,
+	maxInputs int) ([]selectorInput, error) {
+
+	sorted, err := sortInputs(unspent, smallerInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []selectorInput
+	for _, input := range sorted {
+		if len(inputs) == maxInputs || input.amount >= threshold {
+			break
+		}
+
+		if input.amount <= inputFee(feeRatePerByte, input.UnspentInput) {
+			continue
+		}
+
+		inputs = append(inputs, input)
+	}
+
+	return inputs, nil
+}
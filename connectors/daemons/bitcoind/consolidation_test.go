@@ -0,0 +1,126 @@
+package bitcoind
+
+import (
+	"testing"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/btcsuite/btclog"
+	"github.com/shopspring/decimal"
+)
+
+// TestConsolidate checks that only the small outputs, which are worth
+// spending, are consolidated when fee rate is below the ceiling, and that
+// consolidation payment is completed by the sync.
+func TestConsolidate(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
+	defer clear()
+
+	// Output which is worth less than the fee of its spending is left
+	// untouched, as well as the large one.
+	chain.unspent = append(chain.unspent, rpc.UnspentInput{
+		Amount:        0.000001,
+		Confirmations: 10,
+		TxID:          blockHash(201),
+	})
+	for i := byte(0); i < 3; i++ {
+		chain.unspent = append(chain.unspent, rpc.UnspentInput{
+			Amount:        0.001,
+			Confirmations: 10,
+			TxID:          blockHash(202 + i),
+		})
+	}
+
+	feeRate := decimal.New(10, 0)
+	consolidator, err := NewConsolidator(&ConsolidatorConfig{
+		Asset: connectors.BTC,
+		Policy: connectors.ConsolidationPolicy{
+			Threshold:  decimal.NewFromFloat(0.01),
+			MaxFeeRate: 5,
+			MinInputs:  2,
+			MaxInputs:  2,
+		},
+		Client:       chain,
+		PaymentStore: store,
+		FeeRate: func(connectors.FeePriority) decimal.Decimal {
+			return feeRate
+		},
+		Send:   c.sender.Send,
+		Spent:  c.removeUnspent,
+		Locker: &c.sendMtx,
+		Logger: btclog.Disabled,
+	})
+	if err != nil {
+		t.Fatalf("unable to create consolidator: %v", err)
+	}
+
+	payment, err := consolidator.Consolidate()
+	if err != nil || payment != nil || len(chain.sent) != 0 {
+		t.Fatalf("outputs shouldn't be consolidated with high fee rate: %v",
+			err)
+	}
+
+	feeRate = decimal.New(2, 0)
+	payment, err = consolidator.Consolidate()
+	if err != nil {
+		t.Fatalf("unable to consolidate: %v", err)
+	}
+
+	if payment == nil || payment.Status != connectors.Pending ||
+		payment.System != connectors.Internal ||
+		payment.Direction != connectors.Outgoing {
+		t.Fatalf("consolidation should be pending internal payment: %v",
+			payment)
+	}
+
+	if len(chain.sent) != 1 || len(chain.sent[0].TxIn) != 2 ||
+		len(chain.sent[0].TxOut) != 1 || chain.locked != 2 {
+		t.Fatalf("two small outputs should be consolidated in one")
+	}
+
+	for _, txIn := range chain.sent[0].TxIn {
+		hash := txIn.PreviousOutPoint.Hash.String()
+		if hash != blockHash(202) && hash != blockHash(203) {
+			t.Fatalf("wrong consolidated output: %v", hash)
+		}
+	}
+
+	if !payment.Amount.Add(payment.MediaFee).Equal(
+		decimal.NewFromFloat(0.002)) {
+		t.Fatalf("fee should be paid from the consolidated amount")
+	}
+
+	amount, _ := payment.Amount.Float64()
+	fee, _ := payment.MediaFee.Float64()
+	tx := &rpc.Transaction{
+		Fee:           -fee,
+		Confirmations: 1,
+		TxID:          payment.MediaID,
+		Details: []rpc.TransactionDetails{{
+			Address:  payment.Receipt,
+			Amount:   -amount,
+			Category: "send",
+		}, {
+			Account:  defaultAccount,
+			Address:  payment.Receipt,
+			Amount:   amount,
+			Category: "receive",
+		}},
+	}
+	for _, detail := range tx.Details {
+		if err := c.completeDetail(tx, detail); err != nil {
+			t.Fatalf("unable to complete detail: %v", err)
+		}
+	}
+
+	payments, err := store.ListPayments("", connectors.Completed, "", "",
+		connectors.Internal)
+	if err != nil {
+		t.Fatalf("unable to list payments: %v", err)
+	}
+
+	if len(payments) != 2 {
+		t.Fatalf("both sides of consolidation should be completed, got %v",
+			len(payments))
+	}
+}
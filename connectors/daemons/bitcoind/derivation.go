@@ -0,0 +1,500 @@
+package bitcoind
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bitlum/connector/common"
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btclog"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/go-errors/errors"
+)
+
+const (
+	// externalChain is the index of the chain of the account extended key,
+	// from which receiving addresses are derived, as defined in BIP44.
+	externalChain = 0
+
+	// internalChain is the index of the chain of the account extended key,
+	// from which change addresses are derived, as defined in BIP44.
+	internalChain = 1
+
+	// accountDepth is the depth of the account extended key in the BIP44
+	// and BIP84 hierarchy.
+	accountDepth = 3
+)
+
+var (
+	// bip84Versions maps the versions of the BIP32 extended public keys to
+	// the versions of the same keys in BIP84 hierarchy, which are used by
+	// the wallets as defined in SLIP132.
+	bip84Versions = map[[4]byte][4]byte{
+		// xpub => zpub
+		{0x04, 0x88, 0xb2, 0x1e}: {0x04, 0xb2, 0x47, 0x46},
+
+		// tpub => vpub
+		{0x04, 0x35, 0x87, 0xcf}: {0x04, 0x5f, 0x1c, 0xf6},
+	}
+)
+
+// AddressDeriverConfig is a config of the address deriver.
+type AddressDeriverConfig struct {
+	// Asset is an asset of the connector which addresses are derived.
+	Asset connectors.Asset
+
+	// Policy determines the extended public key from which addresses are
+	// derived and the derivation scheme.
+	Policy connectors.DerivationPolicy
+
+	// Client is the rpc client of the daemon, into which wallet derived
+	// addresses are imported.
+	Client rpc.Client
+
+	// Storage is used to keep the index of the next derived address.
+	Storage connectors.DerivationStorage
+
+	// Label is the label with which derived addresses are imported into
+	// the daemon wallet.
+	Label string
+
+	// ChangeLabel is the label with which derived change addresses of the
+	// transactions spending the outputs of the derived addresses are
+	// imported into the daemon wallet.
+	ChangeLabel string
+
+	Logger btclog.Logger
+}
+
+func (c *AddressDeriverConfig) validate() error {
+	if c.Asset == "" {
+		return errors.New("asset should be specified")
+	}
+
+	if !c.Policy.Enabled() {
+		return errors.New("extended public key should be specified")
+	}
+
+	switch c.Policy.DerivationScheme() {
+	case connectors.BIP32, connectors.BIP44, connectors.BIP84:
+	default:
+		return errors.Errorf("unknown derivation scheme(%v)",
+			c.Policy.Scheme)
+	}
+
+	if c.Client == nil {
+		return errors.New("rpc client should be specified")
+	}
+
+	if c.Storage == nil {
+		return errors.New("derivation storage should be specified")
+	}
+
+	if c.Logger == nil {
+		return errors.New("logger should be specified")
+	}
+
+	return nil
+}
+
+// derivationChain is the extended key which children are the public keys
+// of the derived addresses.
+type derivationChain struct {
+	key *hdkeychain.ExtendedKey
+
+	// path is the path of the chain relative to the extended public key of
+	// the config.
+	path []uint32
+
+	// storageKey is the key under which index of the next address of the
+	// chain is kept in the derivation storage.
+	storageKey string
+
+	// scanned is the number of addresses of the chain, key origins of
+	// which are cached.
+	scanned uint32
+}
+
+// keyOrigin is the public key of the derived address along with the
+// fingerprint of the master key and the derivation path of the public key,
+// which are needed by the signer of the partially signed transaction to
+// find its private key, as defined in BIP174.
+type keyOrigin struct {
+	pubKey      []byte
+	fingerprint [4]byte
+	path        []uint32
+}
+
+// AddressDeriver derives deposit addresses from the extended public key,
+// and imports them into the daemon wallet as watch-only, so that deposits
+// are tracked by the daemon, while their private keys are kept elsewhere.
+type AddressDeriver struct {
+	cfg *AddressDeriverConfig
+	log *common.NamedLogger
+
+	// version is the version of the serialized extended public key.
+	version []byte
+
+	// chain is the chain of the derived deposit addresses.
+	chain *derivationChain
+
+	// changeChain is the chain of the derived change addresses. BIP32
+	// scheme doesn't define the internal chain, so for it change addresses
+	// are derived from the same chain as the other ones.
+	changeChain *derivationChain
+
+	// fingerprint and path are the key origin of the extended public key
+	// of the config.
+	fingerprint [4]byte
+	path        []uint32
+
+	// origins maps the derived addresses to their key origins.
+	origins    map[string]*keyOrigin
+	originsMtx sync.Mutex
+}
+
+// NewAddressDeriver parses the extended public key of the config, and
+// creates the deriver of the addresses from it.
+func NewAddressDeriver(cfg *AddressDeriverConfig) (*AddressDeriver, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	key, err := hdkeychain.NewKeyFromString(cfg.Policy.XPub)
+	if err != nil {
+		return nil, errors.Errorf("unable to parse extended public key: %v",
+			err)
+	}
+
+	if key.IsPrivate() {
+		return nil, errors.New("extended private key is given, only " +
+			"extended public key should be specified")
+	}
+
+	d := &AddressDeriver{
+		cfg: cfg,
+		log: &common.NamedLogger{
+			Name:   string(cfg.Asset),
+			Logger: cfg.Logger,
+		},
+		chain: &derivationChain{
+			key:        key,
+			storageKey: cfg.Policy.XPub,
+		},
+		origins: make(map[string]*keyOrigin),
+	}
+	d.changeChain = d.chain
+
+	// Version isn't exposed by the extended key, so it is taken from its
+	// string form, which starts with the version base58 encoded.
+	d.version = keyVersion(cfg.Policy.XPub)
+
+	d.fingerprint, d.path, err = parseKeyOrigin(key, cfg.Policy.Origin)
+	if err != nil {
+		return nil, errors.Errorf("invalid key origin(%v): %v",
+			cfg.Policy.Origin, err)
+	}
+
+	scheme := cfg.Policy.DerivationScheme()
+	if scheme == connectors.BIP44 || scheme == connectors.BIP84 {
+		if key.Depth() != accountDepth {
+			return nil, errors.Errorf("extended public key of %v scheme "+
+				"should be the account key with depth %v, actual depth: %v",
+				scheme, accountDepth, key.Depth())
+		}
+
+		external, err := key.Child(externalChain)
+		if err != nil {
+			return nil, errors.Errorf("unable to derive external chain: %v",
+				err)
+		}
+
+		internal, err := key.Child(internalChain)
+		if err != nil {
+			return nil, errors.Errorf("unable to derive internal chain: %v",
+				err)
+		}
+
+		// Index of the external chain is kept under the extended public
+		// key of the config, and index of the internal chain under its
+		// own extended public key.
+		d.chain = &derivationChain{
+			key:        external,
+			path:       []uint32{externalChain},
+			storageKey: cfg.Policy.XPub,
+		}
+		d.changeChain = &derivationChain{
+			key:        internal,
+			path:       []uint32{internalChain},
+			storageKey: internal.String(),
+		}
+	}
+
+	return d, nil
+}
+
+// ValidateNet checks that addresses of the extended public key could be
+// derived for the given network.
+func (d *AddressDeriver) ValidateNet(net *chaincfg.Params) error {
+	scheme := d.cfg.Policy.DerivationScheme()
+	if scheme == connectors.BIP84 && net.Bech32HRPSegwit == "" {
+		return errors.Errorf("%v network doesn't support segwit addresses "+
+			"of %v scheme", d.cfg.Asset, scheme)
+	}
+
+	if bytes.Equal(d.version, net.HDPublicKeyID[:]) {
+		return nil
+	}
+
+	if version, ok := bip84Versions[net.HDPublicKeyID]; ok &&
+		scheme == connectors.BIP84 && bytes.Equal(d.version, version[:]) {
+		return nil
+	}
+
+	return errors.Errorf("extended public key is not for %v network",
+		net.Name)
+}
+
+// DeriveAddress returns the address of the given index. Address isn't
+// imported into the daemon wallet.
+func (d *AddressDeriver) DeriveAddress(index uint32,
+	net *chaincfg.Params) (btcutil.Address, error) {
+	return d.deriveAddress(d.chain, index, net)
+}
+
+// deriveAddress returns the address of the given index of the chain.
+func (d *AddressDeriver) deriveAddress(chain *derivationChain,
+	index uint32, net *chaincfg.Params) (btcutil.Address, error) {
+
+	key, err := chain.key.Child(index)
+	if err != nil {
+		return nil, err
+	}
+
+	address, _, err := d.keyAddress(key, net)
+	return address, err
+}
+
+// keyAddress returns the address of the extended public key, along with
+// the serialized public key.
+func (d *AddressDeriver) keyAddress(key *hdkeychain.ExtendedKey,
+	net *chaincfg.Params) (btcutil.Address, []byte, error) {
+
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return nil, nil, errors.Errorf("unable to get public key: %v", err)
+	}
+	serializedKey := pubKey.SerializeCompressed()
+	pubKeyHash := btcutil.Hash160(serializedKey)
+
+	var address btcutil.Address
+	if d.cfg.Policy.DerivationScheme() == connectors.BIP84 {
+		address, err = btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, net)
+	} else {
+		address, err = btcutil.NewAddressPubKeyHash(pubKeyHash, net)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return address, serializedKey, nil
+}
+
+// NewAddress derives the next address, and imports it into the daemon
+// wallet as watch-only. Blockchain isn't rescanned on import, because
+// address hasn't been given to anyone yet.
+func (d *AddressDeriver) NewAddress(net *chaincfg.Params) (btcutil.Address,
+	error) {
+	return d.newAddress(d.chain, net, d.cfg.Label)
+}
+
+// NewChangeAddress derives the next address of the internal chain, and
+// imports it into the daemon wallet as watch-only with the change label, so
+// that funds which are returned on it aren't treated as deposits.
+func (d *AddressDeriver) NewChangeAddress(net *chaincfg.Params) (
+	btcutil.Address, error) {
+	return d.newAddress(d.changeChain, net, d.cfg.ChangeLabel)
+}
+
+// newAddress derives the next address of the chain, and imports it into the
+// daemon wallet with the given label.
+func (d *AddressDeriver) newAddress(chain *derivationChain,
+	net *chaincfg.Params, label string) (btcutil.Address, error) {
+
+	address, err := d.nextAddress(chain, net)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.cfg.Client.ImportAddress(address, label); err != nil {
+		return nil, connectors.WrapDaemonError(d.cfg.Client.DaemonName(),
+			err)
+	}
+
+	return address, nil
+}
+
+// NextAddress reserves the index of the next address and derives it.
+// Address isn't imported into the daemon wallet.
+func (d *AddressDeriver) NextAddress(net *chaincfg.Params) (btcutil.Address,
+	error) {
+	return d.nextAddress(d.chain, net)
+}
+
+// nextAddress reserves the index of the next address of the chain and
+// derives it.
+func (d *AddressDeriver) nextAddress(chain *derivationChain,
+	net *chaincfg.Params) (btcutil.Address, error) {
+
+	for {
+		index, err := d.cfg.Storage.NextDerivationIndex(d.cfg.Asset,
+			chain.storageKey)
+		if err != nil {
+			return nil, errors.Errorf("unable to get derivation index: %v",
+				err)
+		}
+
+		address, err := d.deriveAddress(chain, index, net)
+		if err == hdkeychain.ErrInvalidChild {
+			// As defined in BIP32, index is skipped if key of the index
+			// is invalid, which is extremely unlikely.
+			d.log.Warnf("Skip invalid child key of index(%v)", index)
+			continue
+		} else if err != nil {
+			return nil, errors.Errorf("unable to derive address of "+
+				"index(%v): %v", index, err)
+		}
+
+		d.log.Infof("Derive address(%v) of index(%v)", address, index)
+
+		return address, nil
+	}
+}
+
+// keyOrigin returns the key origin of the address, false is returned if
+// address hasn't been derived by the deriver. Addresses which have been
+// derived since the previous call are cached on demand, so that the whole
+// chain is derived only once.
+func (d *AddressDeriver) keyOrigin(address string,
+	net *chaincfg.Params) (*keyOrigin, bool, error) {
+
+	d.originsMtx.Lock()
+	defer d.originsMtx.Unlock()
+
+	if origin, ok := d.origins[address]; ok {
+		return origin, true, nil
+	}
+
+	chains := []*derivationChain{d.chain}
+	if d.changeChain != d.chain {
+		chains = append(chains, d.changeChain)
+	}
+
+	for _, chain := range chains {
+		next, err := d.cfg.Storage.DerivationIndex(d.cfg.Asset,
+			chain.storageKey)
+		if err != nil {
+			return nil, false, errors.Errorf("unable to get derivation "+
+				"index: %v", err)
+		}
+
+		for ; chain.scanned < next; chain.scanned++ {
+			key, err := chain.key.Child(chain.scanned)
+			if err == hdkeychain.ErrInvalidChild {
+				continue
+			} else if err != nil {
+				return nil, false, err
+			}
+
+			derived, pubKey, err := d.keyAddress(key, net)
+			if err != nil {
+				return nil, false, err
+			}
+
+			path := make([]uint32, 0, len(d.path)+len(chain.path)+1)
+			path = append(path, d.path...)
+			path = append(path, chain.path...)
+			path = append(path, chain.scanned)
+
+			d.origins[derived.String()] = &keyOrigin{
+				pubKey:      pubKey,
+				fingerprint: d.fingerprint,
+				path:        path,
+			}
+		}
+	}
+
+	origin, ok := d.origins[address]
+	return origin, ok, nil
+}
+
+// parseKeyOrigin parses the key origin of the extended key, which is given
+// in the form of the key origin of the output script descriptor, e.g.
+// "d34db33f/84'/0'/0'". If origin isn't given, extended key is treated as
+// the master key, so that its own fingerprint is used with the empty path.
+func parseKeyOrigin(key *hdkeychain.ExtendedKey, origin string) ([4]byte,
+	[]uint32, error) {
+
+	var fingerprint [4]byte
+	if origin == "" {
+		pubKey, err := key.ECPubKey()
+		if err != nil {
+			return fingerprint, nil, err
+		}
+
+		copy(fingerprint[:], btcutil.Hash160(pubKey.SerializeCompressed()))
+		return fingerprint, nil, nil
+	}
+
+	parts := strings.Split(origin, "/")
+	decoded, err := hex.DecodeString(parts[0])
+	if err != nil || len(decoded) != len(fingerprint) {
+		return fingerprint, nil, errors.New("fingerprint should be 4 " +
+			"bytes hex encoded")
+	}
+	copy(fingerprint[:], decoded)
+
+	path := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		var hardened bool
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") {
+			hardened = true
+			part = part[:len(part)-1]
+		}
+
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return fingerprint, nil, errors.Errorf("invalid path "+
+				"index(%v)", part)
+		}
+
+		if hardened {
+			index += hdkeychain.HardenedKeyStart
+		}
+		path = append(path, uint32(index))
+	}
+
+	if len(path) != int(key.Depth()) {
+		return fingerprint, nil, errors.Errorf("path should have the "+
+			"depth of the extended key %v", key.Depth())
+	}
+
+	return fingerprint, path, nil
+}
+
+// keyVersion returns version of the base58 encoded extended key.
+func keyVersion(key string) []byte {
+	decoded := base58.Decode(key)
+	if len(decoded) < 4 {
+		return nil
+	}
+
+	return decoded[:4]
+}
@@ -0,0 +1,244 @@
+package bitcoind
+
+import (
+	"testing"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/bitlum/connector/connectors/rpc/bitcoincash"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btclog"
+	"github.com/btcsuite/btcutil"
+)
+
+// Account keys of the "abandon abandon ... about" mnemonic, test vectors
+// of BIP44 and BIP84.
+const (
+	bip44XPub = "xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5W" +
+		"SWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj"
+
+	bip84ZPub = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE" +
+		"3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+
+	bip84TPub = "tpubDC8msFGeGuwnKG9Upg7DM2b4DaRqg3CUZa5g8v2SRQ6K4NSkxUgd7" +
+		"HsL2XVWbVm39yBA4LAxysQAm397zwQSQoQgewGiYZqrA9DsP4zbQ1M"
+
+	// bip84TPubOrigin is the key origin of the test network account key.
+	bip84TPubOrigin = "73c5da0a/84'/1'/0'"
+)
+
+// importChain is the rpc client which records imported addresses.
+type importChain struct {
+	rpc.Client
+
+	imported map[string]string
+}
+
+func (c *importChain) DaemonName() string {
+	return "bitcoind"
+}
+
+func (c *importChain) ImportAddress(address btcutil.Address,
+	label string) error {
+	c.imported[address.String()] = label
+	return nil
+}
+
+// memoryDerivationStorage is a derivation storage which keeps indexes in
+// memory.
+type memoryDerivationStorage struct {
+	indexes map[string]uint32
+}
+
+func (s *memoryDerivationStorage) NextDerivationIndex(asset connectors.Asset,
+	xpub string) (uint32, error) {
+	index := s.indexes[xpub]
+	s.indexes[xpub]++
+	return index, nil
+}
+
+func (s *memoryDerivationStorage) DerivationIndex(asset connectors.Asset,
+	xpub string) (uint32, error) {
+	return s.indexes[xpub], nil
+}
+
+func newTestDeriver(t *testing.T, xpub string,
+	scheme connectors.DerivationScheme) (*AddressDeriver, *importChain) {
+
+	chain := &importChain{imported: make(map[string]string)}
+	d, err := NewAddressDeriver(&AddressDeriverConfig{
+		Asset: connectors.BTC,
+		Policy: connectors.DerivationPolicy{
+			XPub:   xpub,
+			Scheme: scheme,
+		},
+		Client: chain,
+		Storage: &memoryDerivationStorage{
+			indexes: make(map[string]uint32),
+		},
+		Label:  depositAccount,
+		Logger: btclog.Disabled,
+	})
+	if err != nil {
+		t.Fatalf("unable to create deriver: %v", err)
+	}
+
+	return d, chain
+}
+
+// TestDeriveAddress checks that addresses of the schemes are derived as
+// defined by the test vectors.
+func TestDeriveAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		xpub      string
+		scheme    connectors.DerivationScheme
+		net       *chaincfg.Params
+		addresses []string
+	}{
+		{
+			name:   "bip44",
+			xpub:   bip44XPub,
+			scheme: connectors.BIP44,
+			net:    &chaincfg.MainNetParams,
+			addresses: []string{
+				"1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA",
+				"1Ak8PffB2meyfYnbXZR9EGfLfFZVpzJvQP",
+			},
+		},
+		{
+			name:   "bip84 zpub",
+			xpub:   bip84ZPub,
+			scheme: connectors.BIP84,
+			net:    &chaincfg.MainNetParams,
+			addresses: []string{
+				"bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu",
+				"bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g",
+			},
+		},
+		{
+			name:   "bip84 tpub",
+			xpub:   bip84TPub,
+			scheme: connectors.BIP84,
+			net:    &chaincfg.TestNet3Params,
+			addresses: []string{
+				"tb1q6rz28mcfaxtmd6v789l9rrlrusdprr9pqcpvkl",
+				"tb1qd7spv5q28348xl4myc8zmh983w5jx32cjhkn97",
+			},
+		},
+		{
+			name:   "bip32",
+			xpub:   bip44XPub,
+			scheme: connectors.BIP32,
+			net:    &chaincfg.MainNetParams,
+			addresses: []string{
+				"13KE6TffArLh4fVM6uoQzvsYq5vwetJcVM",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, chain := newTestDeriver(t, test.xpub, test.scheme)
+
+			if err := d.ValidateNet(test.net); err != nil {
+				t.Fatalf("key should be valid for network: %v", err)
+			}
+
+			for i, expected := range test.addresses {
+				address, err := d.NewAddress(test.net)
+				if err != nil {
+					t.Fatalf("unable to derive address: %v", err)
+				}
+
+				if address.String() != expected {
+					t.Fatalf("wrong address(%v), expected: %v, actual: %v",
+						i, expected, address)
+				}
+
+				label, ok := chain.imported[expected]
+				if !ok || label != depositAccount {
+					t.Fatalf("address(%v) should be imported", i)
+				}
+			}
+		})
+	}
+}
+
+// TestDeriveChangeAddress checks that change addresses are derived from the
+// internal chain of the account key, independently from the deposit ones.
+func TestDeriveChangeAddress(t *testing.T) {
+	d, chain := newTestDeriver(t, bip84ZPub, connectors.BIP84)
+	d.cfg.ChangeLabel = "change"
+
+	// Address of m/84'/0'/0'/1/0 path from the test vectors of BIP84.
+	const expected = "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"
+
+	address, err := d.NewChangeAddress(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to derive change address: %v", err)
+	}
+
+	if address.String() != expected {
+		t.Fatalf("wrong change address, expected: %v, actual: %v",
+			expected, address)
+	}
+
+	if chain.imported[expected] != "change" {
+		t.Fatalf("change address should be imported with change label")
+	}
+
+	// Index of the external chain shouldn't be used by change address.
+	address, err = d.NewAddress(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to derive address: %v", err)
+	}
+
+	if address.String() != "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu" {
+		t.Fatalf("wrong address of m/84'/0'/0'/0/0 path: %v", address)
+	}
+}
+
+// TestAddressDeriverInvalidKey checks that keys which couldn't be used for
+// derivation are rejected.
+func TestAddressDeriverInvalidKey(t *testing.T) {
+	policies := []connectors.DerivationPolicy{
+		// Private key shouldn't be given to the connector.
+		{XPub: "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqj" +
+			"iChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"},
+
+		// Master key isn't the account key of BIP44 hierarchy.
+		{XPub: "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY" +
+			"2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"},
+
+		{XPub: bip44XPub, Scheme: "bip49"},
+
+		// Key origin should have the depth of the account key.
+		{XPub: bip44XPub, Origin: "73c5da0a/44'/0'"},
+		{XPub: bip44XPub, Origin: "73c5da/44'/0'/0'"},
+	}
+
+	for i, policy := range policies {
+		_, err := NewAddressDeriver(&AddressDeriverConfig{
+			Asset:   connectors.BTC,
+			Policy:  policy,
+			Client:  &importChain{},
+			Storage: &memoryDerivationStorage{},
+			Logger:  btclog.Disabled,
+		})
+		if err == nil {
+			t.Fatalf("policy(%v) should be rejected", i)
+		}
+	}
+
+	// Segwit addresses couldn't be derived for bitcoin cash, and key of
+	// the main network couldn't be used in test network.
+	d, _ := newTestDeriver(t, bip84ZPub, connectors.BIP84)
+	if err := d.ValidateNet(&bitcoincash.MainNetParams); err == nil {
+		t.Fatalf("segwit scheme should be rejected for bitcoin cash")
+	}
+
+	if err := d.ValidateNet(&chaincfg.TestNet3Params); err == nil {
+		t.Fatalf("main network key should be rejected for test network")
+	}
+}
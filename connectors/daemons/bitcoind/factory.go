@@ -0,0 +1,96 @@
+package bitcoind
+
+import (
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/bitlum/connector/connectors/rpc/bitcoin"
+	"github.com/bitlum/connector/connectors/rpc/bitcoincash"
+	"github.com/bitlum/connector/connectors/rpc/dash"
+	"github.com/bitlum/connector/connectors/rpc/litecoin"
+	"github.com/go-errors/errors"
+)
+
+// backendName is the name of the connector backend, which crafts and signs
+// transactions itself with the in-house coin selection.
+const backendName = "full"
+
+func init() {
+	connectors.RegisterBackendFactory(connectors.BTC, connectors.Blockchain,
+		backendName, newFactory(func(cfg bitcoin.ClientConfig) (rpc.Client,
+			error) {
+			cfg.Name = "bitcoind"
+			return bitcoin.NewClient(cfg)
+		}))
+
+	connectors.RegisterBackendFactory(connectors.BCH, connectors.Blockchain,
+		backendName, newFactory(func(cfg bitcoin.ClientConfig) (rpc.Client,
+			error) {
+			cfg.Name = "bitcoinabc"
+			return bitcoincash.NewClient(bitcoincash.ClientConfig(cfg))
+		}))
+
+	connectors.RegisterBackendFactory(connectors.DASH, connectors.Blockchain,
+		backendName, newFactory(func(cfg bitcoin.ClientConfig) (rpc.Client,
+			error) {
+			cfg.Name = "dashd"
+			return dash.NewClient(dash.ClientConfig(cfg))
+		}))
+
+	connectors.RegisterBackendFactory(connectors.LTC, connectors.Blockchain,
+		backendName, newFactory(func(cfg bitcoin.ClientConfig) (rpc.Client,
+			error) {
+			cfg.Name = "litecoind"
+			return litecoin.NewClient(litecoin.ClientConfig(cfg))
+		}))
+}
+
+// newFactory returns connector factory which creates rpc client of the
+// daemon with the given function.
+func newFactory(newClient func(cfg bitcoin.ClientConfig) (rpc.Client,
+	error)) connectors.Factory {
+
+	return func(cfg *connectors.FactoryConfig) (connectors.Connector, error) {
+		client, err := newClient(bitcoin.ClientConfig{
+			Logger:   cfg.RPCLogger,
+			Asset:    cfg.Asset,
+			RPCHost:  cfg.Daemon.Host,
+			RPCPort:  cfg.Daemon.Port,
+			User:     cfg.Daemon.User,
+			Password: cfg.Daemon.Password,
+		})
+		if err != nil {
+			return nil, errors.Errorf("unable to create %v rpc client: %v",
+				cfg.Asset, err)
+		}
+
+		selector, err := NewCoinSelector(cfg.Daemon.CoinSelection)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewConnector(&Config{
+			Net:                  cfg.Net,
+			MinConfirmations:     cfg.Daemon.MinConfirmations,
+			SyncLoopDelay:        cfg.Daemon.SyncDelay,
+			LastSyncedBlockHash:  cfg.Daemon.ForceLastHash,
+			RPCClient:            client,
+			Asset:                cfg.Asset,
+			FeePerByte:           cfg.Daemon.FeePerUnit,
+			Logger:               cfg.Logger,
+			Metrics:              cfg.Metrics,
+			PaymentStore:         cfg.PaymentStore,
+			StateStorage:         cfg.Storage.ConnectorStateStorage(cfg.Asset),
+			RetryPolicy:          cfg.Daemon.SendRetry,
+			AttemptsStorage:      cfg.Storage.AttemptsStorage(),
+			CoinSelector:         selector,
+			FeePolicy:            cfg.Daemon.FeePolicy,
+			FeeBumpPolicy:        cfg.Daemon.FeeBump,
+			ReplacementsStorage:  cfg.Storage.ReplacementsStorage(),
+			DerivationPolicy:     cfg.Daemon.Derivation,
+			DerivationStorage:    cfg.Storage.DerivationStorage(),
+			TreasuryPolicy:       cfg.Daemon.Treasury,
+			ConsolidationPolicy:  cfg.Daemon.Consolidation,
+			ReorganisationPolicy: cfg.Daemon.Reorganisation,
+		})
+	}
+}
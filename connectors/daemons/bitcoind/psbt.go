@@ -0,0 +1,490 @@
+package bitcoind
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/bitlum/connector/common"
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/bitlum/connector/metrics"
+	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// A compile time check to ensure Connector implements the ColdSigner
+// interface.
+var _ connectors.ColdSigner = (*Connector)(nil)
+
+// SupportsPSBT returns whether daemon of the asset supports partially
+// signed transactions, as defined in BIP174.
+func SupportsPSBT(asset connectors.Asset) bool {
+	return asset == connectors.BTC || asset == connectors.LTC
+}
+
+// CreatePSBTPayment creates unsigned transaction which sends given amount
+// to the given address from the outputs of the watch-only addresses, which
+// are derived from the extended public key, and stores it as the waiting
+// payment with the exact fee. Change is returned on the newly derived
+// address. Inputs of the transaction are locked until payment is finalized
+// or canceled.
+//
+// NOTE: Part of the connectors.ColdSigner interface.
+func (c *Connector) CreatePSBTPayment(address, amount,
+	idempotencyKey string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	// Cold storage consists of the outputs of the derived addresses, keys
+	// of which aren't known by the daemon.
+	if !SupportsPSBT(c.cfg.Asset) || c.deriver == nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, connectors.ErrPSBTNotSupported
+	}
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	decodedAddress, amtInBtc, err := c.parseRecipient(address, amount)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	payment, err := connectors.PaymentByIdempotencyKey(c.cfg.PaymentStore,
+		idempotencyKey, c.cfg.Asset, connectors.Blockchain, address, amtInBtc)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	} else if payment != nil {
+		c.log.Infof("Payment(%v) with idempotency key(%v) has been already "+
+			"created", payment.PaymentID, idempotencyKey)
+		return payment, nil
+	}
+
+	feeSatoshiPerByte := uint64(c.getFeeRate(connectors.DefaultPriority).
+		Ceil().IntPart())
+	tx, selection, changeAddr, err := c.craftColdTransaction(
+		feeSatoshiPerByte, decAmount2Sat(amtInBtc), decodedAddress)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	payment, err = c.savePSBTPayment(tx, selection, changeAddr, address,
+		amtInBtc, idempotencyKey)
+	if err != nil {
+		UnlockInputs(c.client, selection.Inputs, c.log)
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	c.log.Infof("Create cold storage payment %v", spew.Sdump(payment))
+
+	return payment, nil
+}
+
+// craftColdTransaction performs coin selection over the confirmed outputs
+// of the watch-only addresses, and creates unsigned transaction which pays
+// the amount to the address. If necessary, change address is derived.
+func (c *Connector) craftColdTransaction(feeRatePerByte uint64,
+	amtSat btcutil.Amount, address btcutil.Address) (*wire.MsgTx,
+	*CoinSelection, btcutil.Address, error) {
+
+	c.log.Debugf("Performing cold storage coin selection fee rate(%v "+
+		"sat/byte), amount(%v)", feeRatePerByte, amtSat)
+
+	// Locked outputs, which are spent by the other waiting payments, are
+	// not returned by the daemon.
+	unspent, err := c.client.ListUnspentMinMax(c.cfg.MinConfirmations,
+		math.MaxInt32)
+	if err != nil {
+		return nil, nil, nil, errors.Errorf("unable to list unspent: %v",
+			err)
+	}
+
+	cold := make(map[string]rpc.UnspentInput)
+	for _, u := range unspent {
+		if u.WatchOnly {
+			cold[fmt.Sprintf("%v:%v", u.TxID, u.Vout)] = u
+		}
+	}
+
+	outputs := map[btcutil.Address]btcutil.Amount{address: amtSat}
+	selection, err := c.coinSelect(feeRatePerByte, outputs, cold)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	c.log.Debugf("Selected %v cold storage inputs, amount(%v), change(%v), "+
+		"fee(%v)", len(selection.Inputs), printAmount(amtSat),
+		printAmount(selection.Change), printAmount(selection.Fee))
+
+	tx, changeAddr, err := c.fundTransaction(selection, outputs,
+		func() (btcutil.Address, error) {
+			return c.deriver.NewChangeAddress(c.netParams)
+		})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return tx, selection, changeAddr, nil
+}
+
+// savePSBTPayment converts unsigned transaction to the partially signed
+// one, and saves it as the waiting payment. Change payment is saved only
+// after transaction is finalized, because identifier of the transaction
+// might be changed by the signatures.
+func (c *Connector) savePSBTPayment(tx *wire.MsgTx, selection *CoinSelection,
+	changeAddr btcutil.Address, address string, amount decimal.Decimal,
+	idempotencyKey string) (*connectors.Payment, error) {
+
+	psbt, err := c.client.CreatePSBT(tx)
+	if err != nil {
+		return nil, convertRPCError(c.client.DaemonName(), err,
+			"unable to create psbt")
+	}
+
+	psbt, err = c.addPSBTDerivations(psbt, selection.Inputs, changeAddr)
+	if err != nil {
+		return nil, errors.Errorf("unable to add derivation paths to "+
+			"psbt: %v", err)
+	}
+
+	var unsignedTx bytes.Buffer
+	if err := tx.Serialize(&unsignedTx); err != nil {
+		return nil, errors.Errorf("unable serialize unsigned tx: %v", err)
+	}
+
+	details := &connectors.PSBTDetails{
+		PSBT:       psbt,
+		UnsignedTx: unsignedTx.Bytes(),
+	}
+	if changeAddr != nil {
+		details.ChangeAddress = changeAddr.String()
+	}
+
+	payment := &connectors.Payment{
+		UpdatedAt:      connectors.NowInMilliSeconds(),
+		Status:         connectors.Waiting,
+		Direction:      connectors.Outgoing,
+		System:         connectors.External,
+		Receipt:        address,
+		Asset:          c.cfg.Asset,
+		Media:          connectors.Blockchain,
+		Amount:         amount.Round(8),
+		MediaFee:       sat2DecAmount(selection.Fee),
+		MediaID:        tx.TxHash().String(),
+		Detail:         details,
+		IdempotencyKey: idempotencyKey,
+	}
+
+	payment.PaymentID, err = payment.GenPaymentID()
+	if err != nil {
+		return nil, errors.Errorf("unable generate payment id: %v", err)
+	}
+
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return nil, errors.Errorf("unable add payment in store: %v", err)
+	}
+
+	return payment, nil
+}
+
+// addPSBTDerivations adds derivation paths of the public keys of the spent
+// outputs and of the change output to the partially signed transaction.
+// Daemon isn't able to add them by itself, because watch-only addresses are
+// imported without their keys, and without the paths signer couldn't find
+// which private keys should be used.
+func (c *Connector) addPSBTDerivations(psbt string,
+	inputs []rpc.UnspentInput, changeAddr btcutil.Address) (string, error) {
+
+	p, err := decodePSBT(psbt)
+	if err != nil {
+		return "", err
+	}
+
+	addresses := make(map[wire.OutPoint]string, len(inputs))
+	for _, input := range inputs {
+		hash, err := chainhash.NewHashFromStr(input.TxID)
+		if err != nil {
+			return "", err
+		}
+		addresses[*wire.NewOutPoint(hash, input.Vout)] = input.Address
+	}
+
+	for i, txIn := range p.tx.TxIn {
+		address := addresses[txIn.PreviousOutPoint]
+		origin, ok, err := c.deriver.keyOrigin(address, c.netParams)
+		if err != nil {
+			return "", err
+		} else if !ok {
+			c.log.Warnf("Input(%v) of address(%v) hasn't been derived "+
+				"from the extended public key, its derivation path is "+
+				"unknown", txIn.PreviousOutPoint, address)
+			continue
+		}
+
+		p.inputs[i].addDerivation(psbtInBip32Derivation, origin)
+	}
+
+	if changeAddr == nil {
+		return p.encode()
+	}
+
+	origin, ok, err := c.deriver.keyOrigin(changeAddr.String(), c.netParams)
+	if err != nil {
+		return "", err
+	} else if !ok {
+		return "", errors.Errorf("change address(%v) hasn't been derived",
+			changeAddr)
+	}
+
+	changeScript, err := txscript.PayToAddrScript(changeAddr)
+	if err != nil {
+		return "", err
+	}
+
+	for i, txOut := range p.tx.TxOut {
+		if bytes.Equal(txOut.PkScript, changeScript) {
+			p.outputs[i].addDerivation(psbtOutBip32Derivation, origin)
+		}
+	}
+
+	return p.encode()
+}
+
+// ExportPSBT returns base64 encoded partially signed transaction of the
+// waiting cold storage payment, along with the signatures which have been
+// imported so far.
+//
+// NOTE: Part of the connectors.ColdSigner interface.
+func (c *Connector) ExportPSBT(paymentID string) (string, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	_, details, err := c.psbtPayment(paymentID)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return "", err
+	}
+
+	return details.PSBT, nil
+}
+
+// ImportPSBT combines signatures of the given partially signed
+// transaction, which has been signed elsewhere, with the ones of the
+// waiting cold storage payment. Transaction might be signed by several
+// signers, each of which signatures are imported separately.
+//
+// NOTE: Part of the connectors.ColdSigner interface.
+func (c *Connector) ImportPSBT(paymentID,
+	psbt string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, details, err := c.psbtPayment(paymentID)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	// Daemon refuses to combine transactions which are different, so
+	// that signatures of the other transaction couldn't be imported.
+	combined, err := c.client.CombinePSBT(details.PSBT, psbt)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, c.psbtError(paymentID, err)
+	}
+
+	_, complete, err := c.client.FinalizePSBT(combined)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, c.psbtError(paymentID, err)
+	}
+
+	details.PSBT = combined
+	details.Complete = complete
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("unable update psbt of payment(%v): %v",
+			paymentID, err)
+	}
+
+	c.log.Infof("Import psbt of payment(%v), complete(%v)", paymentID,
+		complete)
+
+	return payment, nil
+}
+
+// FinalizePSBT finalizes fully signed transaction of the waiting cold
+// storage payment, and sends it to the blockchain network, after which
+// payment is tracked as any other outgoing payment. If transaction
+// couldn't be sent, it is retried, and after all attempts payment is marked
+// as failed and its inputs are unlocked.
+//
+// NOTE: Part of the connectors.ColdSigner interface.
+func (c *Connector) FinalizePSBT(paymentID string) (*connectors.Payment,
+	error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, details, err := c.psbtPayment(paymentID)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	tx, complete, err := c.client.FinalizePSBT(details.PSBT)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, c.psbtError(paymentID, err)
+	} else if !complete {
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInvalidPSBT{
+			PaymentID: paymentID,
+			Reason:    errors.New("not all inputs are signed"),
+		}
+	}
+
+	unsignedTx, err := paymentTx(payment)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	if !sameTx(tx, unsignedTx) {
+		m.AddError(metrics.HighSeverity)
+		return nil, &connectors.ErrInvalidPSBT{
+			PaymentID: paymentID,
+			Reason: errors.New("finalized transaction differs from the " +
+				"transaction of the payment"),
+		}
+	}
+
+	var rawTx bytes.Buffer
+	if err := tx.Serialize(&rawTx); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("unable serialize signed tx: %v", err)
+	}
+
+	// Identifier of the non-segwit transaction is changed by the
+	// signatures, so transaction is mapped to the payment, the same way
+	// as the replacement transactions are.
+	txID := tx.TxHash().String()
+	if txID != payment.MediaID {
+		err := c.cfg.ReplacementsStorage.PutReplacement(c.cfg.Asset, txID,
+			paymentID)
+		if err != nil {
+			m.AddError(metrics.HighSeverity)
+			return nil, errors.Errorf("unable to save transaction(%v) of "+
+				"payment(%v): %v", txID, paymentID, err)
+		}
+	}
+
+	details.RawTx = rawTx.Bytes()
+	details.TxID = txID
+	details.Complete = true
+	payment.MediaID = txID
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("unable update payment(%v): %v",
+			paymentID, err)
+	}
+
+	if details.ChangeAddress != "" {
+		c.saveChangePayment(payment, tx, details.ChangeAddress)
+	}
+
+	c.log.Infof("Finalize psbt of payment(%v), transaction(%v)", paymentID,
+		txID)
+
+	payment, err = c.sender.Send(payment)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// psbtPayment returns waiting cold storage payment of this connector and
+// its details.
+func (c *Connector) psbtPayment(paymentID string) (*connectors.Payment,
+	*connectors.PSBTDetails, error) {
+
+	payment, err := connectors.WaitingPayment(c.cfg.PaymentStore, paymentID,
+		c.cfg.Asset, connectors.Blockchain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	details, ok := payment.Detail.(*connectors.PSBTDetails)
+	if !ok {
+		return nil, nil, &connectors.ErrInvalidPSBT{
+			PaymentID: paymentID,
+			Reason:    errors.New("payment isn't sent from cold storage"),
+		}
+	}
+
+	return payment, details, nil
+}
+
+// psbtError converts error returned by the daemon on processing of the
+// partially signed transaction to the typed error.
+func (c *Connector) psbtError(paymentID string, err error) error {
+	err = connectors.WrapDaemonError(c.client.DaemonName(), err)
+	if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+		return err
+	}
+
+	return &connectors.ErrInvalidPSBT{
+		PaymentID: paymentID,
+		Reason:    err,
+	}
+}
+
+// sameTx checks that transactions spend the same outputs and have the same
+// outputs, version, lock time and input sequences, regardless of the
+// signatures.
+func sameTx(a, b *wire.MsgTx) bool {
+	if a.Version != b.Version || a.LockTime != b.LockTime ||
+		len(a.TxIn) != len(b.TxIn) || len(a.TxOut) != len(b.TxOut) {
+		return false
+	}
+
+	for i := range a.TxIn {
+		if a.TxIn[i].PreviousOutPoint != b.TxIn[i].PreviousOutPoint ||
+			a.TxIn[i].Sequence != b.TxIn[i].Sequence {
+			return false
+		}
+	}
+
+	for i := range a.TxOut {
+		if a.TxOut[i].Value != b.TxOut[i].Value ||
+			!bytes.Equal(a.TxOut[i].PkScript, b.TxOut[i].PkScript) {
+			return false
+		}
+	}
+
+	return true
+}
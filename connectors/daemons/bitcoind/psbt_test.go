@@ -0,0 +1,456 @@
+package bitcoind
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btclog"
+	"github.com/btcsuite/btcutil"
+	"github.com/go-errors/errors"
+)
+
+// psbtChain is the rpc client which imitates the processing of partially
+// signed transactions by the daemon. Inputs are signed by adding the
+// partial signatures to them, as it is done by the signer.
+type psbtChain struct {
+	*stubChain
+
+	imported map[string]string
+	unsigned map[string]*wire.MsgTx
+}
+
+func (c *psbtChain) ImportAddress(address btcutil.Address,
+	label string) error {
+	c.imported[address.String()] = label
+	return nil
+}
+
+func (c *psbtChain) CreatePSBT(tx *wire.MsgTx) (string, error) {
+	c.unsigned[tx.TxHash().String()] = tx
+
+	p, err := newPartialTx(tx.Copy())
+	if err != nil {
+		return "", err
+	}
+
+	return p.encode()
+}
+
+func (c *psbtChain) CombinePSBT(psbts ...string) (string, error) {
+	combined, err := decodePSBT(psbts[0])
+	if err != nil {
+		return "", errors.New("TX decode failed")
+	}
+
+	for _, psbt := range psbts[1:] {
+		p, err := decodePSBT(psbt)
+		if err != nil {
+			return "", errors.New("TX decode failed")
+		}
+
+		if p.tx.TxHash() != combined.tx.TxHash() {
+			return "", errors.New("PSBTs not compatible (different " +
+				"transactions)")
+		}
+
+		for i, input := range p.inputs {
+			for _, pair := range input {
+				if _, ok := combined.inputs[i].pair(pair.key); !ok {
+					combined.inputs[i] = append(combined.inputs[i], pair)
+				}
+			}
+		}
+	}
+
+	return combined.encode()
+}
+
+func (c *psbtChain) FinalizePSBT(psbt string) (*wire.MsgTx, bool, error) {
+	p, err := decodePSBT(psbt)
+	if err != nil {
+		return nil, false, errors.New("TX decode failed")
+	}
+
+	for _, input := range p.inputs {
+		if !isSigned(input) {
+			return nil, false, nil
+		}
+	}
+
+	// Signatures of the non-segwit inputs change the transaction id.
+	tx := c.unsigned[p.tx.TxHash().String()].Copy()
+	for _, txIn := range tx.TxIn {
+		txIn.SignatureScript = []byte{0x01}
+	}
+
+	return tx, true, nil
+}
+
+// newPartialTx returns partially signed transaction of the unsigned
+// transaction, without any information about its inputs and outputs.
+func newPartialTx(tx *wire.MsgTx) (*partialTx, error) {
+	var unsignedTx bytes.Buffer
+	if err := tx.SerializeNoWitness(&unsignedTx); err != nil {
+		return nil, err
+	}
+
+	return &partialTx{
+		tx: tx,
+		global: psbtMap{{
+			key:   []byte{psbtGlobalUnsignedTx},
+			value: unsignedTx.Bytes(),
+		}},
+		inputs:  make([]psbtMap, len(tx.TxIn)),
+		outputs: make([]psbtMap, len(tx.TxOut)),
+	}, nil
+}
+
+// psbtInPartialSig is the type of the input key of the signature.
+const psbtInPartialSig = 0x02
+
+// isSigned returns true if input of the partially signed transaction
+// contains the signature.
+func isSigned(input psbtMap) bool {
+	for _, pair := range input {
+		if pair.key[0] == psbtInPartialSig {
+			return true
+		}
+	}
+
+	return false
+}
+
+// signPSBT adds the signatures to the inputs of the partially signed
+// transaction.
+func signPSBT(t *testing.T, psbt string) string {
+	p, err := decodePSBT(psbt)
+	if err != nil {
+		t.Fatalf("unable to decode psbt: %v", err)
+	}
+
+	for i := range p.inputs {
+		p.inputs[i] = append(p.inputs[i], psbtPair{
+			key:   []byte{psbtInPartialSig, 0x02},
+			value: []byte{0x30},
+		})
+	}
+
+	signed, err := p.encode()
+	if err != nil {
+		t.Fatalf("unable to encode psbt: %v", err)
+	}
+
+	return signed
+}
+
+// newTestColdConnector returns the connector which cold storage consists
+// of the watch-only output of the given amount.
+func newTestColdConnector(t *testing.T, amount float64) (*Connector,
+	*psbtChain, connectors.PaymentsStore, func()) {
+
+	c, stub, store, clear := newTestConnector(t)
+
+	chain := &psbtChain{
+		stubChain: stub,
+		imported:  make(map[string]string),
+		unsigned:  make(map[string]*wire.MsgTx),
+	}
+	c.client = chain
+
+	var err error
+	c.deriver, err = NewAddressDeriver(&AddressDeriverConfig{
+		Asset: connectors.BTC,
+		Policy: connectors.DerivationPolicy{
+			XPub:   bip84TPub,
+			Scheme: connectors.BIP84,
+			Origin: bip84TPubOrigin,
+		},
+		Client: chain,
+		Storage: &memoryDerivationStorage{
+			indexes: make(map[string]uint32),
+		},
+		Label:       depositAccount,
+		ChangeLabel: defaultAccount,
+		Logger:      btclog.Disabled,
+	})
+	if err != nil {
+		clear()
+		t.Fatalf("unable to create deriver: %v", err)
+	}
+
+	// Cold storage output is received on the derived deposit address.
+	address, err := c.deriver.NewAddress(c.netParams)
+	if err != nil {
+		clear()
+		t.Fatalf("unable to derive address: %v", err)
+	}
+
+	chain.unspent = append(chain.unspent, rpc.UnspentInput{
+		Address:       address.String(),
+		Amount:        amount,
+		Confirmations: 10,
+		TxID:          blockHash(201),
+		Vout:          0,
+		WatchOnly:     true,
+	})
+
+	return c, chain, store, clear
+}
+
+// checkPSBTDerivation checks that the pair of the partially signed
+// transaction contains the derivation path of the public key of the given
+// index of the chain.
+func checkPSBTDerivation(t *testing.T, m psbtMap, keyType byte,
+	chain *derivationChain, index uint32) {
+
+	key, err := chain.key.Child(index)
+	if err != nil {
+		t.Fatalf("unable to derive key: %v", err)
+	}
+
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		t.Fatalf("unable to get public key: %v", err)
+	}
+
+	pair, ok := m.pair(append([]byte{keyType},
+		pubKey.SerializeCompressed()...))
+	if !ok {
+		t.Fatalf("psbt should contain derivation path of the public key")
+	}
+
+	// Master key fingerprint followed by m/84'/1'/0'/<chain>/<index>.
+	expected := "73c5da0a" + "54000080" + "01000080" + "00000080" +
+		hex.EncodeToString([]byte{byte(chain.path[0]), 0, 0, 0,
+			byte(index), 0, 0, 0})
+	if hex.EncodeToString(pair.value) != expected {
+		t.Fatalf("wrong derivation path, expected: %v, actual: %x",
+			expected, pair.value)
+	}
+}
+
+// TestPSBTPayment checks that cold storage payment is created from the
+// watch-only outputs, and that it is sent only after its transaction is
+// signed and finalized, and then completed by the sync.
+func TestPSBTPayment(t *testing.T) {
+	c, chain, store, clear := newTestColdConnector(t, 2)
+	defer clear()
+
+	// Outputs of the hot wallet shouldn't be spent by the cold storage
+	// payment.
+	_, err := c.CreatePSBTPayment(receiverAddress, "2.5", "")
+	if _, ok := err.(*connectors.ErrInsufficientFunds); !ok {
+		t.Fatalf("insufficient funds error should be returned, got %v", err)
+	}
+
+	payment, err := c.CreatePSBTPayment(receiverAddress, "1", "key")
+	if err != nil {
+		t.Fatalf("unable create payment: %v", err)
+	}
+
+	details, ok := payment.Detail.(*connectors.PSBTDetails)
+	if !ok || payment.Status != connectors.Waiting || chain.locked != 1 {
+		t.Fatalf("payment should be waiting with locked inputs")
+	}
+
+	label, ok := chain.imported[details.ChangeAddress]
+	if !ok || label != defaultAccount {
+		t.Fatalf("change should be returned on the derived address")
+	}
+
+	same, err := c.CreatePSBTPayment(receiverAddress, "1", "key")
+	if err != nil || same.PaymentID != payment.PaymentID {
+		t.Fatalf("payment with the same key shouldn't be created twice")
+	}
+
+	psbt, err := c.ExportPSBT(payment.PaymentID)
+	if err != nil || psbt != details.PSBT {
+		t.Fatalf("unable to export psbt: %v", err)
+	}
+
+	// Signer should be given the derivation paths of the keys of the
+	// inputs and of the change, which aren't known by the daemon.
+	exported, err := decodePSBT(psbt)
+	if err != nil {
+		t.Fatalf("unable to decode psbt: %v", err)
+	}
+
+	changeAddr, err := btcutil.DecodeAddress(details.ChangeAddress,
+		c.netParams)
+	if err != nil {
+		t.Fatalf("unable to decode change address: %v", err)
+	}
+
+	changeScript, err := txscript.PayToAddrScript(changeAddr)
+	if err != nil {
+		t.Fatalf("unable to create change script: %v", err)
+	}
+
+	checkPSBTDerivation(t, exported.inputs[0], psbtInBip32Derivation,
+		c.deriver.chain, 0)
+	for i, txOut := range exported.tx.TxOut {
+		if bytes.Equal(txOut.PkScript, changeScript) {
+			checkPSBTDerivation(t, exported.outputs[i],
+				psbtOutBip32Derivation, c.deriver.changeChain, 0)
+		} else if len(exported.outputs[i]) != 0 {
+			t.Fatalf("derivation path shouldn't be added to the " +
+				"recipient output")
+		}
+	}
+
+	// Unsigned transaction couldn't be sent.
+	_, err = c.ConfirmPayment(payment.PaymentID)
+	if _, ok := err.(*connectors.ErrInvalidPSBT); !ok {
+		t.Fatalf("unsigned payment shouldn't be confirmed, got %v", err)
+	}
+
+	_, err = c.FinalizePSBT(payment.PaymentID)
+	if _, ok := err.(*connectors.ErrInvalidPSBT); !ok {
+		t.Fatalf("unsigned payment shouldn't be finalized, got %v", err)
+	}
+
+	other := exported.tx.Copy()
+	other.LockTime++
+	otherPSBT, err := newPartialTx(other)
+	if err != nil {
+		t.Fatalf("unable to create psbt: %v", err)
+	}
+
+	encoded, err := otherPSBT.encode()
+	if err != nil {
+		t.Fatalf("unable to encode psbt: %v", err)
+	}
+
+	_, err = c.ImportPSBT(payment.PaymentID, signPSBT(t, encoded))
+	if _, ok := err.(*connectors.ErrInvalidPSBT); !ok {
+		t.Fatalf("psbt of other transaction shouldn't be imported, got %v",
+			err)
+	}
+
+	signed := signPSBT(t, psbt)
+	payment, err = c.ImportPSBT(payment.PaymentID, signed)
+	if err != nil {
+		t.Fatalf("unable to import psbt: %v", err)
+	}
+
+	details = payment.Detail.(*connectors.PSBTDetails)
+	if !details.Complete || details.PSBT != signed {
+		t.Fatalf("signed psbt should be imported")
+	}
+
+	// Signer shouldn't be able to change the transaction, e.g. to delay it
+	// with the lock time or to change replaceability of it.
+	unsignedHash := payment.MediaID
+	unsignedTx := chain.unsigned[unsignedHash]
+	for _, tamper := range []func(tx *wire.MsgTx){
+		func(tx *wire.MsgTx) { tx.LockTime = 500 },
+		func(tx *wire.MsgTx) { tx.Version++ },
+		func(tx *wire.MsgTx) { tx.TxIn[0].Sequence = 0 },
+	} {
+		tampered := unsignedTx.Copy()
+		tamper(tampered)
+		chain.unsigned[unsignedHash] = tampered
+
+		_, err = c.FinalizePSBT(payment.PaymentID)
+		if _, ok := err.(*connectors.ErrInvalidPSBT); !ok {
+			t.Fatalf("changed transaction shouldn't be finalized, got %v",
+				err)
+		}
+	}
+	chain.unsigned[unsignedHash] = unsignedTx
+
+	unsignedTxID := payment.MediaID
+	payment, err = c.FinalizePSBT(payment.PaymentID)
+	if err != nil {
+		t.Fatalf("unable to finalize psbt: %v", err)
+	}
+
+	if payment.Status != connectors.Pending || len(chain.sent) != 1 ||
+		chain.sent[0].TxHash().String() != payment.MediaID {
+		t.Fatalf("finalized transaction should be sent")
+	}
+
+	if payment.MediaID == unsignedTxID {
+		t.Fatalf("payment should be tracked by the signed transaction")
+	}
+
+	id := connectors.GeneratePaymentID(payment.MediaID, details.ChangeAddress,
+		string(connectors.Outgoing), string(connectors.Internal))
+	change, err := store.PaymentByID(id)
+	if err != nil || change.Status != connectors.Pending {
+		t.Fatalf("change should be pending: %v", err)
+	}
+
+	// Sent payment is completed by the sync as any other payment, although
+	// its id is derived from the unsigned transaction.
+	tx := &rpc.Transaction{
+		Confirmations: 1,
+		TxID:          payment.MediaID,
+		Details: []rpc.TransactionDetails{{
+			Address:  receiverAddress,
+			Amount:   -1,
+			Category: "send",
+		}},
+	}
+	chain.txs[tx.TxID] = tx
+
+	chain.blocks[blockHash(1)] = &rpc.BlockVerboseResp{
+		Hash:          blockHash(1),
+		Height:        1,
+		NextHash:      blockHash(2),
+		Confirmations: 2,
+	}
+	chain.blocks[blockHash(2)] = &rpc.BlockVerboseResp{
+		Hash:          blockHash(2),
+		Height:        2,
+		Confirmations: 1,
+		Tx:            []string{tx.TxID},
+	}
+	c.lastSyncedBlock = chain.blocks[blockHash(1)]
+
+	if err := c.proceedNextBlock(); err != nil {
+		t.Fatalf("unable to process blocks: %v", err)
+	}
+
+	payment, _ = store.PaymentByID(payment.PaymentID)
+	if payment.Status != connectors.Completed {
+		t.Fatalf("payment should be completed, got %v", payment.Status)
+	}
+
+	change, _ = store.PaymentByID(id)
+	if change.Status != connectors.Completed {
+		t.Fatalf("change should be completed, got %v", change.Status)
+	}
+}
+
+// TestCancelPSBTPayment checks that inputs of the canceled cold storage
+// payment are unlocked.
+func TestCancelPSBTPayment(t *testing.T) {
+	c, chain, _, clear := newTestColdConnector(t, 2)
+	defer clear()
+
+	payment, err := c.CreatePSBTPayment(receiverAddress, "1", "")
+	if err != nil {
+		t.Fatalf("unable create payment: %v", err)
+	}
+
+	payment, err = c.CancelPayment(payment.PaymentID)
+	if err != nil {
+		t.Fatalf("unable cancel payment: %v", err)
+	}
+
+	if payment.Status != connectors.Failed || chain.unlocked != 1 {
+		t.Fatalf("payment should be canceled with unlocked inputs")
+	}
+
+	if _, err := c.ImportPSBT(payment.PaymentID, ""); err !=
+		connectors.ErrPaymentNotWaiting {
+		t.Fatalf("psbt of canceled payment shouldn't be imported, got %v",
+			err)
+	}
+}
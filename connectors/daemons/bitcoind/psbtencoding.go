@@ -0,0 +1,183 @@
+package bitcoind
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/go-errors/errors"
+)
+
+const (
+	// psbtMagic is the magic bytes with which every partially signed
+	// transaction starts, as defined in BIP174.
+	psbtMagic = "psbt\xff"
+
+	// psbtGlobalUnsignedTx is the type of the global key of the unsigned
+	// transaction.
+	psbtGlobalUnsignedTx = 0x00
+
+	// psbtInBip32Derivation is the type of the input key of the public key
+	// derivation path.
+	psbtInBip32Derivation = 0x06
+
+	// psbtOutBip32Derivation is the type of the output key of the public
+	// key derivation path.
+	psbtOutBip32Derivation = 0x02
+
+	// maxPSBTFieldSize is the maximum size of the key or value of the
+	// partially signed transaction, it prevents the allocation of the huge
+	// buffers on the malformed input.
+	maxPSBTFieldSize = wire.MaxMessagePayload
+)
+
+// psbtPair is the key-value pair of the partially signed transaction, key
+// of which starts with its type.
+type psbtPair struct {
+	key   []byte
+	value []byte
+}
+
+// psbtMap is the ordered set of the key-value pairs of the partially signed
+// transaction, which describe the transaction, one of its inputs or one of
+// its outputs.
+type psbtMap []psbtPair
+
+// pair returns the pair with the given key.
+func (m psbtMap) pair(key []byte) (psbtPair, bool) {
+	for _, pair := range m {
+		if bytes.Equal(pair.key, key) {
+			return pair, true
+		}
+	}
+
+	return psbtPair{}, false
+}
+
+// addDerivation adds the key origin of the public key with the key of the
+// given type, if it hasn't been added yet.
+func (m *psbtMap) addDerivation(keyType byte, origin *keyOrigin) {
+	key := append([]byte{keyType}, origin.pubKey...)
+	if _, ok := m.pair(key); ok {
+		return
+	}
+
+	value := make([]byte, len(origin.fingerprint)+4*len(origin.path))
+	copy(value, origin.fingerprint[:])
+	for i, index := range origin.path {
+		binary.LittleEndian.PutUint32(value[4+4*i:], index)
+	}
+
+	*m = append(*m, psbtPair{key: key, value: value})
+}
+
+// partialTx is the decoded partially signed transaction, unknown pairs of
+// which are kept as is, so that it is encoded back without losses.
+type partialTx struct {
+	tx      *wire.MsgTx
+	global  psbtMap
+	inputs  []psbtMap
+	outputs []psbtMap
+}
+
+// decodePSBT decodes base64 encoded partially signed transaction.
+func decodePSBT(encoded string) (*partialTx, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(data, []byte(psbtMagic)) {
+		return nil, errors.New("invalid psbt magic bytes")
+	}
+	r := bytes.NewReader(data[len(psbtMagic):])
+
+	p := &partialTx{}
+	if p.global, err = readPSBTMap(r); err != nil {
+		return nil, err
+	}
+
+	unsignedTx, ok := p.global.pair([]byte{psbtGlobalUnsignedTx})
+	if !ok {
+		return nil, errors.New("psbt doesn't contain unsigned transaction")
+	}
+
+	p.tx = wire.NewMsgTx(wire.TxVersion)
+	err = p.tx.DeserializeNoWitness(bytes.NewReader(unsignedTx.value))
+	if err != nil {
+		return nil, errors.Errorf("unable to decode unsigned tx: %v", err)
+	}
+
+	p.inputs = make([]psbtMap, len(p.tx.TxIn))
+	for i := range p.inputs {
+		if p.inputs[i], err = readPSBTMap(r); err != nil {
+			return nil, err
+		}
+	}
+
+	p.outputs = make([]psbtMap, len(p.tx.TxOut))
+	for i := range p.outputs {
+		if p.outputs[i], err = readPSBTMap(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// encode returns base64 encoded partially signed transaction.
+func (p *partialTx) encode() (string, error) {
+	var b bytes.Buffer
+	b.WriteString(psbtMagic)
+
+	maps := append([]psbtMap{p.global}, p.inputs...)
+	maps = append(maps, p.outputs...)
+	for _, m := range maps {
+		if err := writePSBTMap(&b, m); err != nil {
+			return "", err
+		}
+	}
+
+	return base64.StdEncoding.EncodeToString(b.Bytes()), nil
+}
+
+// readPSBTMap reads key-value pairs until the separator.
+func readPSBTMap(r io.Reader) (psbtMap, error) {
+	var m psbtMap
+	for {
+		key, err := wire.ReadVarBytes(r, 0, maxPSBTFieldSize, "psbt key")
+		if err != nil {
+			return nil, err
+		}
+
+		// Map is terminated by the key of zero length.
+		if len(key) == 0 {
+			return m, nil
+		}
+
+		value, err := wire.ReadVarBytes(r, 0, maxPSBTFieldSize,
+			"psbt value")
+		if err != nil {
+			return nil, err
+		}
+
+		m = append(m, psbtPair{key: key, value: value})
+	}
+}
+
+// writePSBTMap writes key-value pairs followed by the separator.
+func writePSBTMap(w io.Writer, m psbtMap) error {
+	for _, pair := range m {
+		if err := wire.WriteVarBytes(w, 0, pair.key); err != nil {
+			return err
+		}
+
+		if err := wire.WriteVarBytes(w, 0, pair.value); err != nil {
+			return err
+		}
+	}
+
+	return wire.WriteVarInt(w, 0, 0)
+}
@@ -0,0 +1,750 @@
+package bitcoind
+
+import (
+	"bytes"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bitlum/connector/common"
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btclog"
+	"github.com/btcsuite/btcutil"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// rbfSequence is the sequence number of the inputs, which signals that
+	// transaction could be replaced by the transaction paying bigger fee,
+	// as defined in BIP125.
+	rbfSequence = wire.MaxTxInSequenceNum - 2
+
+	// incrementalFeeRate is the minimum fee rate in sat/byte by which
+	// replacement transaction should increase the fee, in order to be
+	// relayed by the network.
+	incrementalFeeRate = 1
+)
+
+// SupportsRBF returns whether network of the asset supports replace-by-fee.
+func SupportsRBF(asset connectors.Asset) bool {
+	return asset == connectors.BTC || asset == connectors.LTC
+}
+
+// SignalRBF marks inputs of the unsigned transaction, so that transaction
+// could be replaced later by the transaction paying bigger fee.
+func SignalRBF(tx *wire.MsgTx) {
+	for _, txIn := range tx.TxIn {
+		txIn.Sequence = rbfSequence
+	}
+}
+
+// signalsRBF returns whether transaction could be replaced.
+func signalsRBF(tx *wire.MsgTx) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetSentHeight records the height of the best block at the moment when
+// transaction of the payment has been sent, so that it could be determined
+// for how long it is stuck.
+func SetSentHeight(payment *connectors.Payment, height int64) {
+	switch details := payment.Detail.(type) {
+	case *connectors.GeneratedTxDetails:
+		details.SentHeight = height
+	case *connectors.BatchedTxDetails:
+		details.SentHeight = height
+	}
+}
+
+// sentHeight returns the height at which transaction of the payment has
+// been sent, or zero if it is unknown.
+func sentHeight(payment *connectors.Payment) int64 {
+	switch details := payment.Detail.(type) {
+	case *connectors.GeneratedTxDetails:
+		return details.SentHeight
+	case *connectors.BatchedTxDetails:
+		return details.SentHeight
+	}
+
+	return 0
+}
+
+// txWeight returns weight of the signed transaction.
+func txWeight(tx *wire.MsgTx) int {
+	return tx.SerializeSizeStripped()*(witnessScaleFactor-1) +
+		tx.SerializeSize()
+}
+
+// weightToVSize converts weight of the transaction to its virtual size.
+func weightToVSize(weight int) int {
+	return (weight + witnessScaleFactor - 1) / witnessScaleFactor
+}
+
+// ReplacerConfig is a config of the transaction replacer.
+type ReplacerConfig struct {
+	// Asset is an asset of the connector which payments are replaced.
+	Asset connectors.Asset
+
+	// Client is the rpc client of the daemon which signs and sends the
+	// replacement transactions.
+	Client rpc.Client
+
+	// PaymentStore is used to update the replaced payments.
+	PaymentStore connectors.PaymentsStore
+
+	// Storage is used to map the replacement transactions to the payments.
+	Storage connectors.ReplacementsStorage
+
+	// Policy determines how fee of the stuck payments is bumped.
+	Policy connectors.FeeBumpPolicy
+
+	// MaxFeeRate is the ceiling of the fee rate in sat/byte, zero means
+	// that fee rate isn't limited.
+	MaxFeeRate float64
+
+	// MinConfirmations is the number of confirmations of the unspent
+	// outputs, which might be added to the replacement transaction if
+	// change isn't enough to pay the bigger fee.
+	MinConfirmations int
+
+	// FeeRate returns the fee rate of the priority in sat/byte.
+	FeeRate func(priority connectors.FeePriority) decimal.Decimal
+
+	// SyncedHeight returns the height of the last synced block.
+	SyncedHeight func() int64
+
+	// DecodeAddress decodes the address of the network of the connector.
+	DecodeAddress func(address string) (btcutil.Address, error)
+
+	// BumpWalletTx replaces the transaction of the payment, which has been
+	// sent by the wallet of the daemon, by the transaction paying the given
+	// fee rate in sat/byte. It might be nil, if connector doesn't send
+	// payments by the wallet.
+	BumpWalletTx func(payment *connectors.Payment,
+		feeRate float64) (*connectors.Payment, error)
+
+	// Replaced is called after transaction of the payment has been
+	// replaced, and payment has been saved, so that connector could update
+	// the payments of the change outputs. It might be nil.
+	Replaced func(payment *connectors.Payment, replaced *connectors.ReplacedTx)
+
+	// Locker is the send mutex of the connector, it is held while fee of
+	// the stuck payment is bumped automatically.
+	Locker sync.Locker
+
+	Logger btclog.Logger
+}
+
+func (c *ReplacerConfig) validate() error {
+	if c.Asset == "" {
+		return errors.New("asset should be specified")
+	}
+
+	if c.Client == nil {
+		return errors.New("rpc client should be specified")
+	}
+
+	if c.PaymentStore == nil {
+		return errors.New("payment store should be specified")
+	}
+
+	if c.Storage == nil {
+		return errors.New("replacements storage should be specified")
+	}
+
+	if c.FeeRate == nil {
+		return errors.New("fee rate function should be specified")
+	}
+
+	if c.SyncedHeight == nil {
+		return errors.New("synced height function should be specified")
+	}
+
+	if c.DecodeAddress == nil {
+		return errors.New("decode address function should be specified")
+	}
+
+	if c.Locker == nil {
+		return errors.New("locker should be specified")
+	}
+
+	if c.Logger == nil {
+		return errors.New("logger should be specified")
+	}
+
+	return nil
+}
+
+// Replacer replaces transactions of the pending outgoing payments by the
+// transactions paying bigger fee, and keeps track of which of them is
+// eventually mined.
+type Replacer struct {
+	cfg *ReplacerConfig
+	log *common.NamedLogger
+}
+
+// NewReplacer creates new transaction replacer.
+func NewReplacer(cfg *ReplacerConfig) (*Replacer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Replacer{
+		cfg: cfg,
+		log: &common.NamedLogger{
+			Name:   string(cfg.Asset),
+			Logger: cfg.Logger,
+		},
+	}, nil
+}
+
+// BumpFee replaces transaction of the pending payment by the transaction
+// paying the given fee rate in sat/byte, or the fee rate of the policy
+// priority if it is zero. Amount of the payment is kept, and bigger fee is
+// paid from the change, and if change isn't enough from the additional
+// inputs. Transaction which has been sent by the wallet of the daemon is
+// replaced by the wallet itself.
+//
+// NOTE: Should be called under the send mutex.
+func (r *Replacer) BumpFee(paymentID string,
+	feeRate decimal.Decimal) (*connectors.Payment, error) {
+
+	if !SupportsRBF(r.cfg.Asset) {
+		return nil, &connectors.ErrNotReplaceable{
+			PaymentID: paymentID,
+			Reason: errors.Errorf("%v doesn't support replace-by-fee",
+				r.cfg.Asset),
+		}
+	}
+
+	payment, err := connectors.PendingPayment(r.cfg.PaymentStore, paymentID,
+		r.cfg.Asset, connectors.Blockchain)
+	if err != nil {
+		return nil, err
+	}
+
+	if feeRate.IsZero() {
+		feeRate = r.cfg.FeeRate(r.cfg.Policy.BumpPriority())
+	}
+
+	rate, _ := feeRate.Float64()
+	switch {
+	case rate <= 0:
+		return nil, &connectors.ErrInvalidFeeRate{
+			FeeRate: feeRate.String(),
+			Reason:  errors.New("fee rate should be positive"),
+		}
+
+	case r.cfg.MaxFeeRate > 0 && rate > r.cfg.MaxFeeRate:
+		return nil, &connectors.ErrInvalidFeeRate{
+			FeeRate: feeRate.String(),
+			Reason: errors.Errorf("fee rate is greater than the maximum "+
+				"one(%v sat/byte)", r.cfg.MaxFeeRate),
+		}
+	}
+
+	details, ok := payment.Detail.(*connectors.GeneratedTxDetails)
+	if !ok {
+		if _, ok := payment.Detail.(*connectors.BatchedTxDetails); ok &&
+			r.cfg.BumpWalletTx != nil {
+			return r.cfg.BumpWalletTx(payment, rate)
+		}
+
+		return nil, &connectors.ErrNotReplaceable{
+			PaymentID: paymentID,
+			Reason: errors.New("transaction hasn't been created by " +
+				"connector"),
+		}
+	}
+
+	tx := new(wire.MsgTx)
+	if err := tx.Deserialize(bytes.NewReader(details.RawTx)); err != nil {
+		return nil, errors.Errorf("unable to deserialize raw tx: %v", err)
+	}
+
+	if !signalsRBF(tx) {
+		return nil, &connectors.ErrNotReplaceable{
+			PaymentID: paymentID,
+			Reason:    errors.New("transaction doesn't signal replace-by-fee"),
+		}
+	}
+
+	txHash := tx.TxHash()
+	walletTx, err := r.cfg.Client.GetTransaction(&txHash)
+	if err != nil {
+		return nil, convertRPCError(r.cfg.Client.DaemonName(), err,
+			"unable to get transaction")
+	}
+
+	if walletTx.Confirmations > 0 {
+		return nil, &connectors.ErrNotReplaceable{
+			PaymentID: paymentID,
+			Reason:    errors.New("transaction has been already confirmed"),
+		}
+	} else if walletTx.Confirmations < 0 {
+		return nil, &connectors.ErrNotReplaceable{
+			PaymentID: paymentID,
+			Reason:    errors.New("transaction has been double spent"),
+		}
+	}
+
+	oldFee := decAmount2Sat(payment.MediaFee)
+	oldFeeRate := float64(oldFee) / float64(weightToVSize(txWeight(tx)))
+	if err := CheckBumpedFeeRate(rate, oldFeeRate); err != nil {
+		return nil, err
+	}
+
+	replacement, err := r.craftReplacement(payment, tx, oldFee, rate)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []rpc.UnspentInput
+	for _, input := range txInputs(replacement.tx)[len(tx.TxIn):] {
+		if err := r.cfg.Client.LockUnspent(input); err != nil {
+			UnlockInputs(r.cfg.Client, added, r.log)
+			return nil, errors.Errorf("unable to lock input: %v", err)
+		}
+		added = append(added, input)
+	}
+
+	signedTx, err := r.cfg.Client.SignRawTransaction(replacement.tx)
+	if err != nil {
+		UnlockInputs(r.cfg.Client, added, r.log)
+		return nil, errors.Errorf("unable to sign replacement "+
+			"transaction: %v", err)
+	}
+
+	var rawTx bytes.Buffer
+	if err := signedTx.Serialize(&rawTx); err != nil {
+		UnlockInputs(r.cfg.Client, added, r.log)
+		return nil, errors.Errorf("unable serialize signed tx: %v", err)
+	}
+
+	// Replacement is indexed before it is sent, so that sync would never
+	// see the transaction which it couldn't map to the payment.
+	txID := signedTx.TxHash().String()
+	err = r.cfg.Storage.PutReplacement(r.cfg.Asset, txID, payment.PaymentID)
+	if err != nil {
+		UnlockInputs(r.cfg.Client, added, r.log)
+		return nil, errors.Errorf("unable to save replacement of "+
+			"payment(%v): %v", payment.PaymentID, err)
+	}
+
+	if err := r.cfg.Client.SendRawTransaction(signedTx); err != nil {
+		UnlockInputs(r.cfg.Client, added, r.log)
+		return nil, convertRPCError(r.cfg.Client.DaemonName(), err,
+			"unable to send replacement transaction")
+	}
+
+	replaced := &connectors.ReplacedTx{
+		RawTx:         details.RawTx,
+		TxID:          payment.MediaID,
+		Fee:           payment.MediaFee,
+		ChangeAddress: details.ChangeAddress,
+	}
+
+	details.Replaced = append(details.Replaced, replaced)
+	details.RawTx = rawTx.Bytes()
+	details.TxID = txID
+	details.ChangeAddress = replacement.changeAddress
+	details.SentHeight = r.cfg.SyncedHeight()
+
+	payment.MediaID = txID
+	payment.MediaFee = sat2DecAmount(replacement.fee)
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := r.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return nil, errors.Errorf("unable to save payment(%v) with "+
+			"replacement transaction(%v): %v", payment.PaymentID, txID, err)
+	}
+
+	if r.cfg.Replaced != nil {
+		r.cfg.Replaced(payment, replaced)
+	}
+
+	r.cfg.Logger.Infof("Bump fee of payment %v", spew.Sdump(payment))
+
+	return payment, nil
+}
+
+// CheckBumpedFeeRate checks that the fee rate of the replacement
+// transaction is bigger than the fee rate of the replaced one by at least
+// the incremental fee rate, so that replacement would be relayed.
+func CheckBumpedFeeRate(feeRate, oldFeeRate float64) error {
+	if feeRate < oldFeeRate+incrementalFeeRate {
+		return &connectors.ErrInvalidFeeRate{
+			FeeRate: decimal.NewFromFloat(feeRate).String(),
+			Reason: errors.Errorf("fee rate should be greater than the "+
+				"current one(%.2f sat/byte) at least by %v sat/byte",
+				oldFeeRate, incrementalFeeRate),
+		}
+	}
+
+	return nil
+}
+
+// replacement is the unsigned transaction which replaces the transaction
+// of the payment.
+type replacement struct {
+	tx            *wire.MsgTx
+	fee           btcutil.Amount
+	changeAddress string
+}
+
+// craftReplacement creates the unsigned copy of the payment transaction,
+// which pays the fee with the given rate. Fee is paid from the change
+// output, if it isn't enough additional inputs are spent, and new change
+// output is created if needed.
+func (r *Replacer) craftReplacement(payment *connectors.Payment,
+	tx *wire.MsgTx, oldFee btcutil.Amount, feeRate float64) (*replacement,
+	error) {
+
+	details := payment.Detail.(*connectors.GeneratedTxDetails)
+	changeAddress := details.ChangeAddress
+
+	var changeScript []byte
+	if changeAddress != "" {
+		address, err := r.cfg.DecodeAddress(changeAddress)
+		if err != nil {
+			return nil, errors.Errorf("unable to decode change address: %v",
+				err)
+		}
+
+		changeScript, err = txscript.PayToAddrScript(address)
+		if err != nil {
+			return nil, errors.Errorf("unable to create change script: %v",
+				err)
+		}
+	}
+
+	weight := txWeight(tx)
+	hasWitness := tx.HasWitness()
+
+	newTx := tx.Copy()
+	newTx.TxOut = nil
+	for _, txIn := range newTx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+
+	// Everything except the change is kept, and change is recreated with
+	// the amount which is left after paying the new fee.
+	var (
+		outputsAmount btcutil.Amount
+		available     = oldFee
+		withChange    = weight
+		withoutChange = weight
+	)
+	for _, txOut := range tx.TxOut {
+		if changeScript != nil && bytes.Equal(txOut.PkScript, changeScript) {
+			available += btcutil.Amount(txOut.Value)
+			withoutChange -= txOut.SerializeSize() * witnessScaleFactor
+			continue
+		}
+
+		outputsAmount += btcutil.Amount(txOut.Value)
+		newTx.AddTxOut(txOut)
+	}
+
+	// Change address is created if it is needed, assume that it is
+	// P2PKH, as the largest of the standard outputs.
+	if changeScript == nil {
+		withChange += P2PKHOutputSize * witnessScaleFactor
+	}
+
+	var (
+		unspent []selectorInput
+		next    int
+	)
+	for {
+		fee := replacementFee(withChange, feeRate, oldFee)
+		if available-fee >= DefaultDustLimit() {
+			if changeScript == nil {
+				address, err := r.cfg.Client.GetNewRawChangeAddress(
+					defaultAccount)
+				if err != nil {
+					return nil, errors.Errorf("unable to get change "+
+						"address: %v", err)
+				}
+
+				changeScript, err = txscript.PayToAddrScript(address)
+				if err != nil {
+					return nil, errors.Errorf("unable to create change "+
+						"script: %v", err)
+				}
+				changeAddress = address.String()
+			}
+
+			newTx.AddTxOut(wire.NewTxOut(int64(available-fee), changeScript))
+
+			return &replacement{
+				tx:            newTx,
+				fee:           fee,
+				changeAddress: changeAddress,
+			}, nil
+		}
+
+		// Change which is left after paying the fee is less than dust,
+		// so it is left to the miners.
+		if available >= replacementFee(withoutChange, feeRate, oldFee) {
+			return &replacement{
+				tx:  newTx,
+				fee: available,
+			}, nil
+		}
+
+		if unspent == nil {
+			list, err := r.cfg.Client.ListUnspentMinMax(
+				r.cfg.MinConfirmations, math.MaxInt32)
+			if err != nil {
+				return nil, convertRPCError(r.cfg.Client.DaemonName(), err,
+					"unable to list unspent")
+			}
+
+			unspent, err = sortInputs(SpendableInputs(list), largerInput)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if next >= len(unspent) {
+			return nil, &connectors.ErrInsufficientFunds{
+				Needed: sat2DecAmount(outputsAmount +
+					replacementFee(withoutChange, feeRate, oldFee)),
+				Available: sat2DecAmount(outputsAmount + available),
+			}
+		}
+
+		input := unspent[next]
+		next++
+
+		hash, err := chainhash.NewHashFromStr(input.TxID)
+		if err != nil {
+			return nil, errors.Errorf("unable to decode hash of input: %v",
+				err)
+		}
+
+		var inputWeight int
+		inputWeight, hasWitness = addedInputWeight(input.UnspentInput,
+			len(newTx.TxIn), hasWitness)
+
+		txIn := wire.NewTxIn(wire.NewOutPoint(hash, input.Vout), nil, nil)
+		txIn.Sequence = rbfSequence
+		newTx.AddTxIn(txIn)
+
+		withChange += inputWeight
+		withoutChange += inputWeight
+		available += input.amount
+	}
+}
+
+// replacementFee returns the fee of the replacement transaction with the
+// given weight. Replacement should pay at least the fee of the original
+// transaction and the relay fee of its own size, as required by BIP125.
+func replacementFee(weight int, feeRate float64,
+	oldFee btcutil.Amount) btcutil.Amount {
+
+	vsize := weightToVSize(weight)
+	fee := btcutil.Amount(math.Ceil(feeRate * float64(vsize)))
+
+	minFee := oldFee + btcutil.Amount(vsize*incrementalFeeRate)
+	if fee < minFee {
+		return minFee
+	}
+
+	return fee
+}
+
+// addedInputWeight returns the weight which is added by the input to the
+// transaction with the given number of inputs, and whether transaction has
+// witness after the input is added. If input is the first witness input of
+// the transaction, weight includes the witness header and empty witnesses
+// of the other inputs.
+func addedInputWeight(input rpc.UnspentInput, numInputs int,
+	txHasWitness bool) (int, bool) {
+
+	var weightEstimate TxWeightEstimator
+	addInput(&weightEstimate, input)
+
+	weight := weightEstimate.inputSize * witnessScaleFactor
+	if weightEstimate.hasWitness {
+		weight += weightEstimate.inputWitnessSize
+		if !txHasWitness {
+			weight += WitnessHeaderSize + numInputs
+		}
+	} else if txHasWitness {
+		weight += weightEstimate.inputWitnessSize
+	}
+
+	return weight, txHasWitness || weightEstimate.hasWitness
+}
+
+// ResolvePaymentID returns id of the payment to which replacement
+// transaction belongs, or the given payment id if transaction isn't a
+// replacement one.
+func (r *Replacer) ResolvePaymentID(txID, paymentID string) (string, error) {
+	replacedID, err := r.cfg.Storage.ReplacedPaymentID(r.cfg.Asset, txID)
+	if err != nil {
+		return "", errors.Errorf("unable to get payment of replacement "+
+			"transaction(%v): %v", txID, err)
+	}
+
+	if replacedID != "" {
+		return replacedID, nil
+	}
+
+	return paymentID, nil
+}
+
+// SyncTx checks whether transaction is the current transaction of the
+// payment. If transaction is the replaced one, and it has been mined
+// instead of its replacement, payment is switched back to it. Returns false
+// if transaction should be ignored by sync.
+//
+// NOTE: Should be called under the send mutex.
+func (r *Replacer) SyncTx(payment *connectors.Payment, txID string,
+	confirmed bool) (bool, error) {
+
+	if payment.MediaID == txID {
+		return true, nil
+	}
+
+	// Replaced transactions are conflicted or dropped from the mempool,
+	// after their replacement is accepted.
+	if !confirmed {
+		return false, nil
+	}
+
+	details, ok := payment.Detail.(*connectors.GeneratedTxDetails)
+	if !ok {
+		return false, nil
+	}
+
+	for i, replaced := range details.Replaced {
+		if replaced.TxID != txID {
+			continue
+		}
+
+		current := &connectors.ReplacedTx{
+			RawTx:         details.RawTx,
+			TxID:          payment.MediaID,
+			Fee:           payment.MediaFee,
+			ChangeAddress: details.ChangeAddress,
+		}
+
+		details.Replaced = append(details.Replaced[:i:i],
+			details.Replaced[i+1:]...)
+		details.Replaced = append(details.Replaced, current)
+		details.RawTx = replaced.RawTx
+		details.TxID = replaced.TxID
+		details.ChangeAddress = replaced.ChangeAddress
+
+		payment.MediaID = replaced.TxID
+		payment.MediaFee = replaced.Fee
+		payment.UpdatedAt = connectors.NowInMilliSeconds()
+		if err := r.cfg.PaymentStore.SavePayment(payment); err != nil {
+			return false, errors.Errorf("unable to restore replaced "+
+				"transaction(%v) of payment(%v): %v", txID,
+				payment.PaymentID, err)
+		}
+
+		if r.cfg.Replaced != nil {
+			r.cfg.Replaced(payment, current)
+		}
+
+		r.cfg.Logger.Warnf("Replaced transaction(%v) of payment(%v) has "+
+			"been mined instead of replacement(%v)", txID,
+			payment.PaymentID, current.TxID)
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// Run bumps fee of the payments which are stuck in the mempool for more
+// than the number of blocks of the policy, until quit channel is closed.
+// If automatic bumping is disabled it returns immediately.
+func (r *Replacer) Run(quit <-chan struct{}) {
+	if r.cfg.Policy.AutoBumpBlocks == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.cfg.Policy.Interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.bumpStuck(quit)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// bumpStuck bumps fee of the pending payments which are stuck.
+func (r *Replacer) bumpStuck(quit <-chan struct{}) {
+	payments, err := r.cfg.PaymentStore.ListPayments(r.cfg.Asset,
+		connectors.Pending, connectors.Outgoing, connectors.Blockchain,
+		connectors.External)
+	if err != nil {
+		r.cfg.Logger.Errorf("unable to list pending payments: %v", err)
+		return
+	}
+
+	// Several payments are sent in one wallet transaction, so it is
+	// bumped only once.
+	bumped := make(map[string]struct{})
+
+	height := r.cfg.SyncedHeight()
+	for _, payment := range payments {
+		select {
+		case <-quit:
+			return
+		default:
+		}
+
+		// Payments which has been sent before the height has been
+		// recorded are skipped, because it is unknown for how long they
+		// are stuck.
+		sent := sentHeight(payment)
+		if sent == 0 || height-sent < int64(r.cfg.Policy.AutoBumpBlocks) {
+			continue
+		}
+
+		if _, ok := bumped[payment.MediaID]; ok {
+			continue
+		}
+		bumped[payment.MediaID] = struct{}{}
+
+		r.cfg.Locker.Lock()
+		_, err := r.BumpFee(payment.PaymentID, decimal.Zero)
+		r.cfg.Locker.Unlock()
+
+		switch err.(type) {
+		case nil:
+		case *connectors.ErrInvalidFeeRate, *connectors.ErrNotReplaceable:
+			// Fee rate of the priority isn't greater than the current one,
+			// or transaction is shared by several payments and doesn't
+			// signal replace-by-fee, so there is no point in the
+			// replacement.
+			r.cfg.Logger.Debugf("Payment(%v) is stuck, but its fee "+
+				"isn't bumped: %v", payment.PaymentID, err)
+		default:
+			r.cfg.Logger.Errorf("unable to bump fee of stuck payment(%v): %v",
+				payment.PaymentID, err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package bitcoind
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/shopspring/decimal"
+)
+
+// sendStuckPayment sends the payment, which transaction is in the mempool.
+func sendStuckPayment(t *testing.T, c *Connector,
+	chain *stubChain) *connectors.Payment {
+
+	payment, err := c.SendPayment(receiverAddress, "1", "",
+		connectors.DefaultPriority)
+	if err != nil {
+		t.Fatalf("unable send payment: %v", err)
+	}
+
+	chain.txs[payment.MediaID] = &rpc.Transaction{TxID: payment.MediaID}
+
+	return payment
+}
+
+// serializeTx returns raw transaction.
+func serializeTx(t *testing.T, tx *wire.MsgTx) []byte {
+	var rawTx bytes.Buffer
+	if err := tx.Serialize(&rawTx); err != nil {
+		t.Fatalf("unable to serialize tx: %v", err)
+	}
+
+	return rawTx.Bytes()
+}
+
+// mineTx connects block with the given outgoing transaction.
+func mineTx(t *testing.T, c *Connector, chain *stubChain,
+	payment *connectors.Payment, txID string) {
+
+	fee, _ := payment.MediaFee.Float64()
+	chain.txs[txID] = &rpc.Transaction{
+		Amount:        -1,
+		Fee:           -fee,
+		Confirmations: 1,
+		TxID:          txID,
+		Details: []rpc.TransactionDetails{{
+			Address:  receiverAddress,
+			Amount:   -1,
+			Category: "send",
+		}},
+	}
+
+	chain.blocks[blockHash(1)] = &rpc.BlockVerboseResp{
+		Hash:          blockHash(1),
+		Height:        1,
+		NextHash:      blockHash(2),
+		Confirmations: 2,
+	}
+	chain.blocks[blockHash(2)] = &rpc.BlockVerboseResp{
+		Hash:          blockHash(2),
+		Height:        2,
+		Confirmations: 1,
+		PreviousHash:  blockHash(1),
+		Tx:            []string{txID},
+	}
+	c.lastSyncedBlock = chain.blocks[blockHash(1)]
+
+	if err := c.proceedNextBlock(); err != nil {
+		t.Fatalf("unable to process blocks: %v", err)
+	}
+}
+
+// TestBumpFee checks that transaction of the pending payment is replaced
+// by the transaction paying bigger fee from the change, and that payment
+// is completed when replacement is mined.
+func TestBumpFee(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
+	defer clear()
+
+	payment := sendStuckPayment(t, c, chain)
+	if !signalsRBF(chain.sent[0]) {
+		t.Fatalf("transaction should signal replace-by-fee")
+	}
+
+	// Fee rate should be greater than the current one at least by the
+	// incremental relay fee.
+	_, err := c.BumpFee(payment.PaymentID, decimal.New(1, 0))
+	if _, ok := err.(*connectors.ErrInvalidFeeRate); !ok {
+		t.Fatalf("invalid fee rate error should be returned, got %v", err)
+	}
+
+	oldChange := changePayment(t, store, payment, connectors.Outgoing)
+
+	bumped, err := c.BumpFee(payment.PaymentID, decimal.New(10, 0))
+	if err != nil {
+		t.Fatalf("unable to bump fee: %v", err)
+	}
+
+	if bumped.PaymentID != payment.PaymentID ||
+		bumped.MediaID == payment.MediaID ||
+		!bumped.MediaFee.GreaterThan(payment.MediaFee) {
+		t.Fatalf("payment should be updated with replacement")
+	}
+
+	if len(chain.sent) != 2 ||
+		chain.sent[1].TxHash().String() != bumped.MediaID ||
+		chain.sent[1].TxIn[0].PreviousOutPoint !=
+			chain.sent[0].TxIn[0].PreviousOutPoint {
+		t.Fatalf("replacement should spend the same inputs")
+	}
+
+	details := bumped.Detail.(*connectors.GeneratedTxDetails)
+	if len(details.Replaced) != 1 ||
+		details.Replaced[0].TxID != payment.MediaID ||
+		!details.Replaced[0].Fee.Equal(payment.MediaFee) {
+		t.Fatalf("replaced transaction should be kept: %v", details.Replaced)
+	}
+
+	oldChange, _ = store.PaymentByID(oldChange.PaymentID)
+	if oldChange.Status != connectors.Failed ||
+		oldChange.FailureReason != connectors.ReplacedReason {
+		t.Fatalf("change of replaced transaction should be failed")
+	}
+
+	// Bigger fee is paid from the change.
+	change := changePayment(t, store, bumped, connectors.Outgoing)
+	if change.Status != connectors.Pending || !change.Amount.Equal(
+		oldChange.Amount.Sub(bumped.MediaFee.Sub(payment.MediaFee))) {
+		t.Fatalf("wrong change of replacement: %v", change)
+	}
+
+	mineTx(t, c, chain, bumped, bumped.MediaID)
+
+	payment, _ = store.PaymentByID(payment.PaymentID)
+	if payment.Status != connectors.Completed ||
+		payment.MediaID != bumped.MediaID {
+		t.Fatalf("payment should be completed with replacement")
+	}
+
+	_, err = c.BumpFee(payment.PaymentID, decimal.New(20, 0))
+	if err != connectors.ErrPaymentNotPending {
+		t.Fatalf("fee of completed payment shouldn't be bumped, got %v", err)
+	}
+}
+
+// TestReplacedTxMined checks that payment is switched back to the replaced
+// transaction, if it has been mined instead of the replacement.
+func TestReplacedTxMined(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
+	defer clear()
+
+	payment := sendStuckPayment(t, c, chain)
+
+	bumped, err := c.BumpFee(payment.PaymentID, decimal.New(10, 0))
+	if err != nil {
+		t.Fatalf("unable to bump fee: %v", err)
+	}
+
+	mineTx(t, c, chain, payment, payment.MediaID)
+
+	restored, _ := store.PaymentByID(payment.PaymentID)
+	if restored.Status != connectors.Completed ||
+		restored.MediaID != payment.MediaID ||
+		!restored.MediaFee.Equal(payment.MediaFee) {
+		t.Fatalf("payment should be completed with replaced transaction")
+	}
+
+	change := changePayment(t, store, payment, connectors.Outgoing)
+	if change.Status != connectors.Completed {
+		t.Fatalf("change of mined transaction should be completed, got %v",
+			change.Status)
+	}
+
+	change = changePayment(t, store, bumped, connectors.Outgoing)
+	if change.Status != connectors.Failed {
+		t.Fatalf("change of replacement should be failed, got %v",
+			change.Status)
+	}
+}
+
+// TestBumpFeeAdditionalInputs checks that additional inputs are spent, if
+// change of the transaction isn't enough to pay the bigger fee.
+func TestBumpFeeAdditionalInputs(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
+	defer clear()
+
+	payment := sendStuckPayment(t, c, chain)
+
+	// Leave only small change in the transaction, so that it couldn't pay
+	// the bigger fee.
+	tx := chain.sent[0]
+	for _, txOut := range tx.TxOut {
+		if txOut.Value != btcutil.SatoshiPerBitcoin {
+			txOut.Value = 500
+		}
+	}
+
+	details := payment.Detail.(*connectors.GeneratedTxDetails)
+	details.RawTx = serializeTx(t, tx)
+	payment.MediaID = tx.TxHash().String()
+	if err := store.SavePayment(payment); err != nil {
+		t.Fatalf("unable to save payment: %v", err)
+	}
+	chain.txs[payment.MediaID] = &rpc.Transaction{TxID: payment.MediaID}
+
+	chain.unspent = []rpc.UnspentInput{{
+		Amount:        1,
+		Confirmations: 10,
+		TxID:          blockHash(201),
+	}}
+
+	bumped, err := c.BumpFee(payment.PaymentID, decimal.New(10, 0))
+	if err != nil {
+		t.Fatalf("unable to bump fee: %v", err)
+	}
+
+	replacement := chain.sent[len(chain.sent)-1]
+	if len(replacement.TxIn) != 2 || len(replacement.TxOut) != 2 {
+		t.Fatalf("replacement should have additional input and change")
+	}
+
+	if replacement.TxIn[1].Sequence != rbfSequence || chain.locked != 2 {
+		t.Fatalf("additional input should be locked and signal " +
+			"replace-by-fee")
+	}
+
+	// Change is the funds which are left from the old change and the
+	// additional input after paying the bigger fee.
+	change := changePayment(t, store, bumped, connectors.Outgoing)
+	if !change.Amount.Add(bumped.MediaFee).Equal(
+		sat2DecAmount(btcutil.SatoshiPerBitcoin + 500).Add(
+			payment.MediaFee)) {
+		t.Fatalf("wrong change of replacement: %v", change.Amount)
+	}
+}
@@ -0,0 +1,297 @@
+package bitcoind
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/bitlum/connector/common"
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/bitlum/connector/metrics"
+	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// maxReorganisationOutputs is the maximum number of outputs of the
+	// reorganisation transaction, 500 P2PKH outputs take about 17 kB, which
+	// leaves enough space for the inputs within the standard transaction
+	// size.
+	maxReorganisationOutputs = 500
+)
+
+// runReorganisation reorganises large inputs with the interval of the
+// reorganisation policy, until connector is stopped.
+func (c *Connector) runReorganisation() {
+	ticker := time.NewTicker(c.cfg.ReorganisationPolicy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := c.ReorganiseInputs(false); err != nil {
+				c.log.Errorf("unable to reorganise large inputs: %v", err)
+			}
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// ReorganiseInputs takes unspent outputs, which are larger than twice the
+// optimal value, and splits them into the outputs of the optimal value on
+// the new addresses of the wallet. Otherwise every payment would lock the
+// most of the balance in the unconfirmed change. Outputs are saved as the
+// internal payments, which are completed by the sync.
+//
+// NOTE: Part of the connectors.InputsReorganiser interface.
+func (c *Connector) ReorganiseInputs(preview bool) (*connectors.Reorganisation,
+	error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	// Inputs are selected and spent under the send mutex, so that they
+	// wouldn't be selected by the concurrent payments.
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	// Cache is synced before the inputs are selected, because sync takes
+	// the cache mutex by itself.
+	if c.unspent == nil {
+		if err := c.syncUnspent(); err != nil {
+			m.AddError(metrics.MiddleSeverity)
+			return nil, errors.Errorf("unable to sync unspent: %v", err)
+		}
+	}
+
+	optimalValue := c.optimalUTXOValue()
+	inputs, inputsAmount, err := c.largeInputs(2 * optimalValue)
+	if err != nil {
+		m.AddError(metrics.MiddleSeverity)
+		return nil, err
+	}
+
+	if len(inputs) == 0 {
+		c.log.Debug("Wasn't able found large enough utxo, skip re-balancing")
+		return nil, nil
+	}
+
+	// Reorganisation isn't urgent, so the cheap fee rate is used.
+	priority := c.cfg.ReorganisationPolicy.ReorganisationPriority()
+	feeRatePerByte := uint64(c.getFeeRate(priority).Ceil().IntPart())
+	outputAmounts, fee, err := createReorganisationOutputs(feeRatePerByte,
+		inputs, optimalValue)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, errors.Errorf("unable calculate output amounts: %v", err)
+	}
+
+	reorg := &connectors.Reorganisation{
+		Inputs:       len(inputs),
+		InputsAmount: sat2DecAmount(inputsAmount),
+		Outputs:      make([]decimal.Decimal, len(outputAmounts)),
+		Fee:          sat2DecAmount(fee),
+	}
+	for i, amount := range outputAmounts {
+		reorg.Outputs[i] = sat2DecAmount(amount)
+	}
+
+	if preview {
+		return reorg, nil
+	}
+
+	tx, payments, err := c.createReorganisation(inputs, reorg)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	// Transaction is sent and retried along with the first payment, and
+	// the other payments are updated after it. Transaction might reach the
+	// network even if error is returned, so its inputs are released only
+	// when payments are failed and abandoned by the sender, otherwise they
+	// are retried or found by the lookup.
+	payment, err := c.sender.Send(payments[0])
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+	payments[0] = payment
+	c.updateSiblingPayments(payment)
+
+	if err := c.reloadPayments(payments[1:]); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	reorg.TxID = tx.TxHash().String()
+	reorg.Payments = payments
+
+	c.log.Infof("Take %v inputs, with overall amount %v and create "+
+		"%v outputs with optimal value %v, tx(%v), fee(%v)", reorg.Inputs,
+		inputsAmount, len(outputAmounts), optimalValue, reorg.TxID,
+		printAmount(fee))
+
+	return reorg, nil
+}
+
+// optimalUTXOValue returns the value of the outputs into which large
+// unspent outputs are split.
+func (c *Connector) optimalUTXOValue() btcutil.Amount {
+	value := c.cfg.ReorganisationPolicy.OptimalUTXOValue
+	if value.Sign() == 0 {
+		return defaultOptimalUTXOValue
+	}
+
+	return decAmount2Sat(value)
+}
+
+// largeInputs returns unspent outputs of the local cache which are greater
+// than the given amount, along with their overall amount. Outputs are
+// sorted, so that transaction would be deterministic.
+func (c *Connector) largeInputs(minAmount btcutil.Amount) ([]rpc.UnspentInput,
+	btcutil.Amount, error) {
+
+	c.unspentSyncMtx.Lock()
+	unspent := make([]rpc.UnspentInput, 0, len(c.unspent))
+	for _, input := range c.unspent {
+		unspent = append(unspent, input)
+	}
+	c.unspentSyncMtx.Unlock()
+
+	sorted, err := sortInputs(unspent, largerInput)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var inputs []selectorInput
+	var amount btcutil.Amount
+	for _, input := range sorted {
+		// Avoid split if will be created less than two outputs. That is
+		// done in order to reduce a lot of reorganisation i.e. paying more
+		// fees.
+		if input.amount <= minAmount {
+			break
+		}
+
+		inputs = append(inputs, input)
+		amount += input.amount
+	}
+
+	return unwrapInputs(inputs), amount, nil
+}
+
+// createReorganisation locks the inputs, creates and signs the transaction
+// which spends them to the outputs of the reorganisation, and saves every
+// output as the waiting internal payment. Fee of the transaction is split
+// between payments pro-rata to their amounts.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) createReorganisation(inputs []rpc.UnspentInput,
+	reorg *connectors.Reorganisation) (*wire.MsgTx, []*connectors.Payment,
+	error) {
+
+	var err error
+	var locked []rpc.UnspentInput
+	var payments []*connectors.Payment
+	defer func() {
+		if err != nil {
+			c.abandonReorganisation(payments, locked, err)
+		}
+	}()
+
+	for _, input := range inputs {
+		if err = c.client.LockUnspent(input); err != nil {
+			return nil, nil, errors.Errorf("unable to lock input: %v", err)
+		}
+		locked = append(locked, input)
+	}
+	c.removeUnspent(locked)
+
+	// Outputs are sent on the new addresses rather than on the change
+	// ones, so that transaction would be listed by the wallet, and its
+	// payments would be completed by the sync.
+	addresses := make([]btcutil.Address, len(reorg.Outputs))
+	outputs := make(map[btcutil.Address]btcutil.Amount, len(reorg.Outputs))
+	for i, amount := range reorg.Outputs {
+		addresses[i], err = c.client.GetNewAddress(defaultAccount)
+		if err != nil {
+			return nil, nil, errors.Errorf("unable create loopback "+
+				"address: %v", err)
+		}
+
+		outputs[addresses[i]] = decAmount2Sat(amount)
+	}
+
+	// Transaction doesn't signal replace-by-fee, because its replacement
+	// would have to update all of its payments.
+	tx, err := c.client.CreateRawTransaction(inputs, outputs)
+	if err != nil {
+		return nil, nil, errors.Errorf("unable to create raw "+
+			"reorganisation tx: %v", err)
+	}
+
+	signedTx, err := c.client.SignRawTransaction(tx)
+	if err != nil {
+		return nil, nil, errors.Errorf("unable to sign generated "+
+			"transaction: %v", err)
+	}
+
+	var rawTx bytes.Buffer
+	if err = signedTx.Serialize(&rawTx); err != nil {
+		return nil, nil, errors.Errorf("unable serialize signed tx: %v", err)
+	}
+
+	txID := signedTx.TxHash().String()
+	shares := connectors.SplitFee(reorg.Fee, reorg.Outputs, 8)
+	for i, address := range addresses {
+		payment := &connectors.Payment{
+			UpdatedAt: connectors.NowInMilliSeconds(),
+			Status:    connectors.Waiting,
+			Direction: connectors.Outgoing,
+			System:    connectors.Internal,
+			Receipt:   address.String(),
+			Asset:     c.cfg.Asset,
+			Media:     connectors.Blockchain,
+			Amount:    reorg.Outputs[i].Round(8),
+			MediaFee:  shares[i],
+			MediaID:   txID,
+			Detail: &connectors.GeneratedTxDetails{
+				RawTx: rawTx.Bytes(),
+				TxID:  txID,
+			},
+		}
+
+		payment.PaymentID, err = payment.GenPaymentID()
+		if err != nil {
+			return nil, nil, errors.Errorf("unable generate payment id: %v",
+				err)
+		}
+
+		if err = c.cfg.PaymentStore.SavePayment(payment); err != nil {
+			return nil, nil, errors.Errorf("unable add payment in store: %v",
+				err)
+		}
+
+		payments = append(payments, payment)
+	}
+
+	return signedTx, payments, nil
+}
+
+// abandonReorganisation marks payments of the reorganisation, which
+// couldn't be created, as failed, unlocks its inputs and returns them back
+// to the local cache, so that they could be used by other transactions.
+// Errors are only logged, because reorganisation has already failed.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) abandonReorganisation(payments []*connectors.Payment,
+	inputs []rpc.UnspentInput, reason error) {
+
+	c.failCreatedPayments(payments, inputs, reason)
+	c.restoreUnspent(inputs)
+}
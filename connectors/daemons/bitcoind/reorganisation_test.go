@@ -0,0 +1,157 @@
+package bitcoind
+
+import (
+	"testing"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/bitlum/go-bitcoind-rpc/btcjson"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// TestReorganiseInputs checks that large output is split into the outputs
+// of the optimal value, that outputs are tracked as internal payments which
+// are completed by the sync, that failed reorganisation releases the
+// output, and that the retried one keeps it.
+func TestReorganiseInputs(t *testing.T) {
+	c, chain, store, clear := newTestConnector(t)
+	defer clear()
+
+	c.cfg.ReorganisationPolicy.OptimalUTXOValue = decimal.New(1, 0)
+
+	preview, err := c.ReorganiseInputs(true)
+	if err != nil {
+		t.Fatalf("unable to preview reorganisation: %v", err)
+	}
+
+	if preview == nil || preview.TxID != "" || len(preview.Payments) != 0 ||
+		len(chain.sent) != 0 || chain.locked != 0 {
+		t.Fatalf("previewed reorganisation shouldn't be sent")
+	}
+
+	if preview.Inputs != 1 || len(preview.Outputs) != 3 {
+		t.Fatalf("output should be split in three, got %v",
+			len(preview.Outputs))
+	}
+
+	overall := preview.Fee
+	for _, amount := range preview.Outputs {
+		overall = overall.Add(amount)
+	}
+	if !overall.Equal(preview.InputsAmount) {
+		t.Fatalf("fee should be paid from the split amount")
+	}
+
+	// Transaction might have reached the network before the error, so
+	// its output is kept locked while it is retried.
+	chain.sendErr = errors.New("request timeout")
+	retried, err := c.ReorganiseInputs(false)
+	if err != nil {
+		t.Fatalf("unable to reorganise inputs: %v", err)
+	}
+
+	for _, payment := range retried.Payments {
+		if payment.Status != connectors.Pending {
+			t.Fatalf("retried reorganisation should be pending, got %v",
+				payment.Status)
+		}
+	}
+
+	if chain.unlocked != 0 || len(c.unspent) != 0 {
+		t.Fatalf("retried reorganisation shouldn't release its output")
+	}
+
+	c.unspent = nil
+	chain.sendErr = &btcjson.RPCError{
+		Code: btcjson.ErrRPCWalletInsufficientFunds,
+	}
+	if _, err := c.ReorganiseInputs(false); err == nil {
+		t.Fatalf("reorganisation shouldn't be sent")
+	}
+
+	failed, err := store.ListPayments("", connectors.Failed, "", "",
+		connectors.Internal)
+	if err != nil {
+		t.Fatalf("unable to list payments: %v", err)
+	}
+
+	if len(failed) != 3 || chain.unlocked != 1 {
+		t.Fatalf("failed reorganisation should release its output")
+	}
+
+	// Released output is returned to the local cache with the periodic
+	// sync of unspent outputs.
+	if err := c.syncUnspent(); err != nil {
+		t.Fatalf("unable to sync unspent: %v", err)
+	}
+
+	chain.sendErr = nil
+	reorg, err := c.ReorganiseInputs(false)
+	if err != nil {
+		t.Fatalf("unable to reorganise inputs: %v", err)
+	}
+
+	if len(chain.sent) != 1 || reorg.TxID != chain.sent[0].TxHash().String() ||
+		len(chain.sent[0].TxOut) != 3 || len(c.unspent) != 0 {
+		t.Fatalf("reorganisation should be sent")
+	}
+
+	if len(reorg.Payments) != 3 {
+		t.Fatalf("every output should be tracked as payment, got %v",
+			len(reorg.Payments))
+	}
+
+	fee := decimal.Zero
+	for _, payment := range reorg.Payments {
+		if payment.Status != connectors.Pending ||
+			payment.System != connectors.Internal ||
+			payment.Direction != connectors.Outgoing {
+			t.Fatalf("reorganisation should be pending internal payment: %v",
+				payment)
+		}
+
+		fee = fee.Add(payment.MediaFee)
+	}
+
+	if !fee.Equal(reorg.Fee) {
+		t.Fatalf("fee should be split between payments, got %v, want %v",
+			fee, reorg.Fee)
+	}
+
+	txFee, _ := reorg.Fee.Float64()
+	tx := &rpc.Transaction{
+		Fee:           -txFee,
+		Confirmations: 1,
+		TxID:          reorg.TxID,
+	}
+	for _, payment := range reorg.Payments {
+		amount, _ := payment.Amount.Float64()
+		tx.Details = append(tx.Details, rpc.TransactionDetails{
+			Address:  payment.Receipt,
+			Amount:   -amount,
+			Category: "send",
+		}, rpc.TransactionDetails{
+			Account:  defaultAccount,
+			Address:  payment.Receipt,
+			Amount:   amount,
+			Category: "receive",
+		})
+	}
+	for _, detail := range tx.Details {
+		if err := c.completeDetail(tx, detail); err != nil {
+			t.Fatalf("unable to complete detail: %v", err)
+		}
+	}
+
+	completed, err := store.ListPayments("", connectors.Completed, "", "",
+		connectors.Internal)
+	if err != nil {
+		t.Fatalf("unable to list payments: %v", err)
+	}
+
+	if len(completed) != 6 {
+		t.Fatalf("both sides of every output should be completed, got %v",
+			len(completed))
+	}
+}
@@ -143,6 +143,7 @@ func (twe *TxWeightEstimator) AddNestedP2WKHInput() {
 	twe.inputSize += InputSize + P2WPKHSize
 	twe.inputWitnessSize += P2WKHWitnessSize
 	twe.inputSize++
+	twe.inputCount++
 	twe.hasWitness = true
 }
 
@@ -152,6 +153,7 @@ func (twe *TxWeightEstimator) AddNestedP2WSHInput(witnessSize int) {
 	twe.inputSize += InputSize + P2WSHSize
 	twe.inputWitnessSize += witnessSize
 	twe.inputSize++
+	twe.inputCount++
 	twe.hasWitness = true
 }
 
@@ -194,3 +196,9 @@ func (twe *TxWeightEstimator) Weight() int {
 	}
 	return weight
 }
+
+// VSize gets the estimated virtual size of the transaction, which is used
+// to calculate the fee of the transaction.
+func (twe *TxWeightEstimator) VSize() int {
+	return (twe.Weight() + witnessScaleFactor - 1) / witnessScaleFactor
+}
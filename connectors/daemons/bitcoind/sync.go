@@ -0,0 +1,495 @@
+package bitcoind
+
+import (
+	"sync/atomic"
+
+	"github.com/bitlum/connector/common"
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/bitlum/connector/metrics"
+	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// syncUnconfirmed updates status of incoming payments, from waiting to
+// pending, as well as blockchain detail and number of transaction needed for
+// it to be confirmed.
+func (c *Connector) syncUnconfirmed() error {
+	// Return set of non-confirmed from our point of view incoming
+	// transactions.
+	txs, err := c.client.ListUnspentMinMax(0, int(c.cfg.MinConfirmations-1))
+	if err != nil {
+		return err
+	}
+
+	for _, tx := range txs {
+		payment := &connectors.Payment{
+			UpdatedAt: connectors.NowInMilliSeconds(),
+			Status:    connectors.Pending,
+			Direction: connectors.Incoming,
+			System:    connectors.External,
+			Receipt:   tx.Address,
+			Asset:     c.cfg.Asset,
+			Account:   tx.Account,
+			Media:     connectors.Blockchain,
+			Amount:    decimal.NewFromFloat(tx.Amount),
+			MediaFee:  decimal.Zero,
+			MediaID:   tx.TxID,
+			Detail: &connectors.BlockchainPendingDetails{
+				Confirmations:     tx.Confirmations,
+				ConfirmationsLeft: int64(c.cfg.MinConfirmations) - tx.Confirmations,
+			},
+		}
+
+		// Change and loopback outputs are sent on the default account.
+		if tx.Account == defaultAccount {
+			payment.System = connectors.Internal
+		}
+
+		payment.PaymentID, err = payment.GenPaymentID()
+		if err != nil {
+			return errors.Errorf("unable generate payment id: %v", err)
+		}
+
+		if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+			return errors.Errorf("unable to save payment(%v): %v",
+				payment.PaymentID, err)
+		}
+
+		c.log.Infof("Pending transaction(%v),"+
+			"confirmations left(%v), account(%v), amount(%v)", tx.TxID,
+			int64(c.cfg.MinConfirmations)-tx.Confirmations,
+			tx.Account, tx.Amount)
+	}
+
+	return nil
+}
+
+// findForkBlock is used to find block on which fork has happened,
+// at return it, so that syncing could continue. Blocks which have been
+// disconnected by reorganisation are returned as well.
+func (c *Connector) findForkBlock(orphanBlock *rpc.BlockVerboseResp) (
+	*rpc.BlockVerboseResp, []*rpc.BlockVerboseResp, error) {
+
+	var disconnected []*rpc.BlockVerboseResp
+	for orphanBlock.Confirmations < 0 {
+		disconnected = append(disconnected, orphanBlock)
+
+		prevHash, err := chainhash.NewHashFromStr(orphanBlock.PreviousHash)
+		if err != nil {
+			return nil, nil, errors.Errorf("unable to decode hash of prev "+
+				"orphan block: %v", err)
+		}
+
+		orphanBlock, err = c.client.GetBlockVerboseByHash(prevHash)
+		if err != nil {
+			return nil, nil, errors.Errorf("unable to prev last sync block "+
+				"from daemon: %v", err)
+
+		}
+	}
+
+	return orphanBlock, disconnected, nil
+}
+
+// proceedNextBlock process new blocks and updates payment status that
+// transaction reached the minimum confirmation threshold.
+func (c *Connector) proceedNextBlock() error {
+	var err error
+
+	hash, err := chainhash.NewHashFromStr(c.lastSyncedBlock.Hash)
+	if err != nil {
+		return err
+	}
+
+	// Update state of the block, such info as number of confirmations.
+	lastSyncedBlock, err := c.client.GetBlockVerboseByHash(hash)
+	if err != nil {
+		return err
+	}
+	c.lastSyncedBlock = lastSyncedBlock
+
+	// If bitcoind returns negative confirmation number it means that
+	// blockchain re-organization happened and we should handle it properly by
+	// moving backwards.
+	if c.lastSyncedBlock.Confirmations < 0 {
+		c.log.Info("Chain re-organisation has been found, handle it...")
+
+		forkBlock, disconnected, err := c.findForkBlock(c.lastSyncedBlock)
+		if err != nil {
+			return errors.Errorf("unable to handle "+
+				"re-organizations: %v", err)
+		}
+
+		c.log.Infof("Fork have been detected on block("+
+			"%v) using it as last synced block", forkBlock.Hash)
+
+		for _, block := range disconnected {
+			if err := c.revertBlock(block); err != nil {
+				return errors.Errorf("unable to revert payments of "+
+					"block(%v): %v", block.Hash, err)
+			}
+		}
+
+		err = c.cfg.StateStorage.PutLastSyncedHash([]byte(forkBlock.Hash))
+		if err != nil {
+			return errors.Errorf("unable to put block hash in db: %v", err)
+		}
+
+		c.lastSyncedBlock = forkBlock
+		atomic.StoreInt64(&c.syncedHeight, forkBlock.Height)
+	}
+
+	for {
+		select {
+		case <-c.quit:
+			return nil
+		default:
+		}
+
+		// We should check next block only if there is minimum amount of
+		// confirmation above it.
+		if c.lastSyncedBlock.Confirmations < int64(c.cfg.MinConfirmations)+1 {
+			return nil
+		}
+
+		// This check is a bit redundant, but we should be ensured that
+		// next hash exists, otherwise the last synced hash will be overwritten
+		// with zero hash.
+		if c.lastSyncedBlock.NextHash == "" {
+			c.log.Errorf("unable to continue processing block(%v):"+
+				"next hash empty", c.lastSyncedBlock.Hash)
+			return nil
+		}
+
+		nextHash, err := chainhash.NewHashFromStr(c.lastSyncedBlock.NextHash)
+		if err != nil {
+			return err
+		}
+
+		proceededBlock, err := c.client.GetBlockVerboseByHash(nextHash)
+		if err != nil {
+			return err
+		}
+
+		for _, txHashStr := range proceededBlock.Tx {
+			tx, err := c.walletTransaction(txHashStr)
+			if err != nil {
+				return err
+			} else if tx == nil {
+				continue
+			}
+
+			for _, detail := range tx.Details {
+				if err := c.completeDetail(tx, detail); err != nil {
+					return err
+				}
+			}
+		}
+
+		err = c.cfg.StateStorage.PutLastSyncedHash([]byte(nextHash.String()))
+		if err != nil {
+			return errors.Errorf("unable to put block hash in db: %v", err)
+		}
+
+		c.lastSyncedBlock = proceededBlock
+		atomic.StoreInt64(&c.syncedHeight, proceededBlock.Height)
+
+		// After transaction has been consumed by other subsystem
+		// overwrite cache.
+		c.log.Infof("Process block hash(%v), number(%v)",
+			proceededBlock.Hash, proceededBlock.Height)
+	}
+}
+
+// walletTransaction returns transaction of the block if it corresponds to
+// our wallet, or nil otherwise.
+func (c *Connector) walletTransaction(txHashStr string) (*rpc.Transaction,
+	error) {
+
+	txHash, err := chainhash.NewHashFromStr(txHashStr)
+	if err != nil {
+		c.log.Errorf("unable to decode tx hash(%v)", txHashStr)
+		return nil, nil
+	}
+
+	// Get transaction and if this transaction not correspond to non
+	// of our account the error will be returned, in the case skip
+	// this transaction.
+	tx, err := c.client.GetTransaction(txHash)
+	if err != nil {
+		err = connectors.WrapDaemonError(c.client.DaemonName(), err)
+		if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	if len(tx.Details) == 0 {
+		c.log.Errorf("unable to sync tx(%v), there is "+
+			"no details", tx.TxID)
+		return nil, nil
+	}
+
+	return tx, nil
+}
+
+// detailPayment returns payment of the wallet transaction detail, or nil if
+// detail is not tracked.
+//
+// NOTE: Outgoing payments are generated as external, because we are unable
+// to identify by tx details was that payment internal or external.
+func (c *Connector) detailPayment(tx *rpc.Transaction,
+	detail rpc.TransactionDetails) (*connectors.Payment, error) {
+
+	payment := &connectors.Payment{
+		UpdatedAt: connectors.NowInMilliSeconds(),
+		Receipt:   detail.Address,
+		Asset:     c.cfg.Asset,
+		Account:   detail.Account,
+		Amount:    decimal.NewFromFloat(detail.Amount).Abs(),
+		Media:     connectors.Blockchain,
+		MediaID:   tx.TxID,
+		MediaFee:  decimal.NewFromFloat(tx.Fee).Abs(),
+	}
+
+	switch detail.Category {
+	case "receive":
+		payment.Direction = connectors.Incoming
+		payment.System = connectors.External
+		if detail.Account == defaultAccount {
+			payment.System = connectors.Internal
+		}
+
+		// Fee is paid by the sender of the transaction.
+		payment.MediaFee = decimal.Zero
+
+	case "send":
+		payment.Direction = connectors.Outgoing
+		payment.System = connectors.External
+
+	default:
+		return nil, nil
+	}
+
+	var err error
+	payment.PaymentID, err = payment.GenPaymentID()
+	if err != nil {
+		return nil, errors.Errorf("unable generate payment id: %v", err)
+	}
+
+	// Id of the outgoing payment is derived from its original transaction,
+	// which might have been replaced by the one paying bigger fee.
+	if payment.Direction == connectors.Outgoing {
+		externalID := payment.PaymentID
+		payment.PaymentID, err = c.replacer.ResolvePaymentID(tx.TxID,
+			externalID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Internal payments which have been created by the connector,
+		// e.g. sweeps to the cold storage, have their own ids.
+		if payment.PaymentID == externalID {
+			internalID := InternalPaymentID(c.cfg.PaymentStore, tx.TxID,
+				detail.Address)
+			if internalID != "" {
+				payment.PaymentID = internalID
+				payment.System = connectors.Internal
+			}
+		}
+	}
+
+	return payment, nil
+}
+
+// completeDetail marks payment of the confirmed wallet transaction detail
+// as completed. Information which has been saved on payment creation is
+// kept, and change of the outgoing payment is completed as well. For every
+// send payment we have to have database entry, that is why outgoing
+// payments which are unknown are skipped.
+func (c *Connector) completeDetail(tx *rpc.Transaction,
+	detail rpc.TransactionDetails) error {
+
+	payment, err := c.detailPayment(tx, detail)
+	if err != nil || payment == nil {
+		return err
+	}
+
+	// Payment is updated under the send mutex, so that sync wouldn't
+	// overwrite changes which are made by the sending of the payment.
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	stored, err := c.cfg.PaymentStore.PaymentByID(payment.PaymentID)
+	if err == nil {
+		current, err := c.replacer.SyncTx(stored, tx.TxID, true)
+		if err != nil {
+			return err
+		} else if !current {
+			return nil
+		}
+
+		if stored.Status == connectors.Completed {
+			return nil
+		}
+
+		stored.Status = connectors.Completed
+		stored.UpdatedAt = connectors.NowInMilliSeconds()
+		payment = stored
+	} else if payment.Direction == connectors.Outgoing {
+		return nil
+	} else {
+		payment.Status = connectors.Completed
+	}
+
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return errors.Errorf("unable to save payment(%v): %v",
+			payment.PaymentID, err)
+	}
+
+	c.updateChangePayments(payment)
+
+	c.log.Infof("Payment is confirmed (%v)", spew.Sdump(payment))
+
+	return nil
+}
+
+// revertBlock moves completed payments of the block, which has been
+// disconnected by the reorganisation, back to pending, if transaction has
+// been mined again or it is in the mempool, or to reverted if it has been
+// double spent. Payments which are mined again are completed by the sync
+// after they receive enough confirmations.
+func (c *Connector) revertBlock(block *rpc.BlockVerboseResp) error {
+	c.log.Warnf("Block(%v) with height(%v) has been disconnected by "+
+		"reorganisation", block.Hash, block.Height)
+
+	for _, txHashStr := range block.Tx {
+		tx, err := c.walletTransaction(txHashStr)
+		if err != nil {
+			return err
+		} else if tx == nil {
+			continue
+		}
+
+		status := connectors.Pending
+		if tx.Confirmations < 0 {
+			status = connectors.Reverted
+		}
+
+		for _, detail := range tx.Details {
+			if err := c.revertDetail(tx, detail, status); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// revertDetail sets the given status to the payment of the wallet
+// transaction detail, if it has been completed.
+func (c *Connector) revertDetail(tx *rpc.Transaction,
+	detail rpc.TransactionDetails, status connectors.PaymentStatus) error {
+
+	payment, err := c.detailPayment(tx, detail)
+	if err != nil || payment == nil {
+		return err
+	}
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, err = c.cfg.PaymentStore.PaymentByID(payment.PaymentID)
+	if err != nil || payment.Status != connectors.Completed ||
+		payment.MediaID != tx.TxID {
+		return nil
+	}
+
+	payment.Status = status
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return errors.Errorf("unable to save payment(%v): %v",
+			payment.PaymentID, err)
+	}
+
+	c.updateChangePayments(payment)
+
+	c.log.Errorf("Completed payment(%v) has been moved to %v by chain "+
+		"reorganisation: %v", payment.PaymentID, status,
+		spew.Sdump(payment))
+	c.cfg.Metrics.AddReorganisedPayment(c.client.DaemonName(),
+		string(c.cfg.Asset), string(status))
+
+	return nil
+}
+
+// fetchLastSyncedBlockHash returns hash of block which were handled in previous
+// cycle of processing.
+func (c *Connector) fetchLastSyncedBlockHash() (*chainhash.Hash, error) {
+	c.log.Info("Restore hash from database...")
+
+	// Error is returned by the storage if hash hasn't been saved yet.
+	data, _ := c.cfg.StateStorage.LastSyncedHash()
+	if len(data) != 0 {
+		lastHash, err := chainhash.NewHashFromStr(string(data))
+		if err != nil {
+			return nil, errors.Errorf("unable decode block hash: %v", err)
+		}
+
+		return lastHash, nil
+	}
+
+	c.log.Info("Unable to find block in db, fetching best block...")
+	lastHash, err := c.client.GetBestBlockHash()
+	if err != nil {
+		return nil, errors.Errorf("unable to request last best block "+
+			"hash: %v", err)
+	}
+
+	err = c.cfg.StateStorage.PutLastSyncedHash([]byte(lastHash.String()))
+	if err != nil {
+		return nil, errors.Errorf("unable to put block hash in db: %v", err)
+	}
+
+	return lastHash, nil
+}
+
+func (c *Connector) sync() error {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	if err := c.proceedNextBlock(); err != nil {
+		m.AddError(metrics.MiddleSeverity)
+		return errors.Errorf("unable to process blocks: %v", err)
+	}
+
+	// As far as pending transaction may occur at any time,
+	// run it every cycle.
+	if err := c.syncUnconfirmed(); err != nil {
+		m.AddError(metrics.MiddleSeverity)
+		return errors.Errorf("unable to sync unconfirmed txs: %v", err)
+	}
+
+	balance, err := c.ConfirmedBalance()
+	if err != nil {
+		m.AddError(metrics.MiddleSeverity)
+		return errors.Errorf("unable to get available funds: %v", err)
+	}
+
+	c.log.Infof("Asset(%v), media(blockchain), available funds(%v)",
+		c.cfg.Asset, balance.Round(8).String())
+
+	f, _ := balance.Float64()
+	m.CurrentFunds(f)
+
+	// Report last synchronised block number from daemon point of view.
+	m.BlockNumber(c.lastSyncedBlock.Height)
+
+	return nil
+}
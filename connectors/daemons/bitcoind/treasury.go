@@ -0,0 +1,246 @@
+package bitcoind
+
+import (
+	"sync"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btclog"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// ColdStorageConfig is a config of the cold storage.
+type ColdStorageConfig struct {
+	// Asset is an asset of the connector which hot wallet is swept.
+	Asset connectors.Asset
+
+	// Policy determines the address or the extended public key of the
+	// cold storage.
+	Policy connectors.TreasuryPolicy
+
+	// Client is the rpc client of the daemon.
+	Client rpc.Client
+
+	// Storage is used to keep the index of the next address derived from
+	// the extended public key of the cold storage.
+	Storage connectors.DerivationStorage
+
+	Logger btclog.Logger
+}
+
+func (c *ColdStorageConfig) validate() error {
+	if c.Asset == "" {
+		return errors.New("asset should be specified")
+	}
+
+	if c.Policy.ColdAddress == "" && !c.Policy.ColdDerivation.Enabled() {
+		return errors.New("either cold address or cold extended public " +
+			"key should be specified")
+	}
+
+	if c.Policy.ColdDerivation.Enabled() && c.Storage == nil {
+		return errors.New("derivation storage should be specified")
+	}
+
+	return nil
+}
+
+// ColdStorage returns the addresses of the cold storage, to which excess of
+// the hot wallet is swept. If extended public key of the cold storage is
+// specified, new address is derived for every sweep. Derived addresses are
+// not imported into the daemon wallet, so that swept funds aren't tracked
+// as deposits.
+type ColdStorage struct {
+	cfg *ColdStorageConfig
+
+	// deriver derives addresses from the extended public key of the cold
+	// storage, it is nil if cold address is specified.
+	deriver *AddressDeriver
+}
+
+// NewColdStorage creates new cold storage.
+func NewColdStorage(cfg *ColdStorageConfig) (*ColdStorage, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	s := &ColdStorage{cfg: cfg}
+
+	if cfg.Policy.ColdDerivation.Enabled() {
+		var err error
+		s.deriver, err = NewAddressDeriver(&AddressDeriverConfig{
+			Asset:   cfg.Asset,
+			Policy:  cfg.Policy.ColdDerivation,
+			Client:  cfg.Client,
+			Storage: cfg.Storage,
+			Logger:  cfg.Logger,
+		})
+		if err != nil {
+			return nil, errors.Errorf("unable to create cold address "+
+				"deriver: %v", err)
+		}
+	}
+
+	return s, nil
+}
+
+// ValidateNet checks that cold storage address belongs to the given
+// network.
+func (s *ColdStorage) ValidateNet(net *chaincfg.Params) error {
+	if s.deriver != nil {
+		return s.deriver.ValidateNet(net)
+	}
+
+	_, err := decodeAddress(s.cfg.Asset, s.cfg.Policy.ColdAddress, net.Name)
+	if err != nil {
+		return errors.Errorf("invalid cold address(%v): %v",
+			s.cfg.Policy.ColdAddress, err)
+	}
+
+	return nil
+}
+
+// Address returns the address to which the next sweep should be sent.
+func (s *ColdStorage) Address(net *chaincfg.Params) (string, error) {
+	if s.deriver == nil {
+		return s.cfg.Policy.ColdAddress, nil
+	}
+
+	address, err := s.deriver.NextAddress(net)
+	if err != nil {
+		return "", err
+	}
+
+	return address.String(), nil
+}
+
+// TreasuryConfig is a config of the treasury of the connector, which
+// consists of the cold storage and the treasurer sweeping excess of the hot
+// wallet to it.
+type TreasuryConfig struct {
+	// Asset is an asset of the connector which hot wallet is swept.
+	Asset connectors.Asset
+
+	// Daemon is the name of the daemon, which is used as the metrics
+	// label.
+	Daemon string
+
+	// Policy determines when and how much funds are swept, and the cold
+	// storage to which they are swept.
+	Policy connectors.TreasuryPolicy
+
+	// Client is the rpc client of the daemon.
+	Client rpc.Client
+
+	// Storage is used to keep the index of the next address derived from
+	// the extended public key of the cold storage.
+	Storage connectors.DerivationStorage
+
+	// NetParams returns the network parameters of the daemon, which are
+	// known only after connector has been started.
+	NetParams func() *chaincfg.Params
+
+	// Balance returns the confirmed balance of the hot wallet.
+	Balance func() (decimal.Decimal, error)
+
+	// FeeRate returns current fee rate of the priority in sat/byte.
+	FeeRate func(priority connectors.FeePriority) decimal.Decimal
+
+	// EstimateFee estimates the fee of the transaction which sends given
+	// amount to the address with the given priority.
+	EstimateFee func(address, amount string,
+		priority connectors.FeePriority) (*connectors.FeeEstimate, error)
+
+	// Create creates and saves the waiting internal payment, which sends
+	// given amount to the address with the fee rate of the priority.
+	Create func(address, amount string, priority connectors.FeePriority) (
+		*connectors.Payment, error)
+
+	// Send sends the waiting sweep payment.
+	Send func(payment *connectors.Payment) (*connectors.Payment, error)
+
+	// Locker is the send mutex of the connector, it is held while sweep
+	// payment is created and sent.
+	Locker sync.Locker
+
+	Metrics crypto.MetricsBackend
+
+	Logger btclog.Logger
+}
+
+func (c *TreasuryConfig) validate() error {
+	if c.NetParams == nil {
+		return errors.New("net params function should be specified")
+	}
+
+	if c.Create == nil {
+		return errors.New("create function should be specified")
+	}
+
+	if c.Send == nil {
+		return errors.New("send function should be specified")
+	}
+
+	if c.Locker == nil {
+		return errors.New("locker should be specified")
+	}
+
+	return nil
+}
+
+// NewTreasury creates the cold storage of the policy and the treasurer
+// which sweeps excess of the hot wallet to it. Sweeps are saved as internal
+// payments before they are sent.
+func NewTreasury(cfg *TreasuryConfig) (*ColdStorage, *connectors.Treasurer,
+	error) {
+
+	if err := cfg.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	cold, err := NewColdStorage(&ColdStorageConfig{
+		Asset:   cfg.Asset,
+		Policy:  cfg.Policy,
+		Client:  cfg.Client,
+		Storage: cfg.Storage,
+		Logger:  cfg.Logger,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	treasurer, err := connectors.NewTreasurer(&connectors.TreasurerConfig{
+		Asset:       cfg.Asset,
+		Daemon:      cfg.Daemon,
+		Policy:      cfg.Policy,
+		Balance:     cfg.Balance,
+		FeeRate:     cfg.FeeRate,
+		EstimateFee: cfg.EstimateFee,
+		ColdAddress: func() (string, error) {
+			return cold.Address(cfg.NetParams())
+		},
+		Send: func(address, amount string,
+			priority connectors.FeePriority) (*connectors.Payment, error) {
+
+			cfg.Locker.Lock()
+			defer cfg.Locker.Unlock()
+
+			payment, err := cfg.Create(address, amount, priority)
+			if err != nil {
+				return nil, err
+			}
+
+			return cfg.Send(payment)
+		},
+		Metrics: cfg.Metrics,
+		Logger:  cfg.Logger,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cold, treasurer, nil
+}
@@ -9,53 +9,11 @@ import (
 	"github.com/go-errors/errors"
 	"github.com/shopspring/decimal"
 
-	txsize "github.com/bitlum/btcd/blockchain"
+	"github.com/bitlum/connector/common"
 	"github.com/bitlum/connector/connectors/rpc"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
 )
 
-// ErrInsufficientFunds is a type matching the error interface which is
-// returned when coin selection for a new funding transaction fails to due
-// having an insufficient amount of confirmed funds.
-type ErrInsufficientFunds struct {
-	amountNeeded    btcutil.Amount
-	amountAvailable btcutil.Amount
-}
-
-func (e *ErrInsufficientFunds) Error() string {
-	return fmt.Sprintf("not enough outputs to create transaction,"+
-		" need %v only have %v  available", printAmount(e.amountNeeded),
-		printAmount(e.amountAvailable))
-}
-
-// selectInputs selects a slice of inputs necessary to meet the specified
-// selection amount. If input selection is unable to succeed to to insufficient
-// funds, a non-nil error is returned.
-// TODO(andrew.shvv) Probably sort inputs, before selecting them.
-// TODO(andrew.shvv) Develop hierstic algorithm of choosing the outputs
-// efficiently.
-func selectInputs(amt btcutil.Amount,
-	inputsMap map[string]rpc.UnspentInput) (btcutil.Amount,
-	[]rpc.UnspentInput, error) {
-
-	var inputs []rpc.UnspentInput
-	satSelected := btcutil.Amount(0)
-	for _, input := range inputsMap {
-		amount, err := btcutil.NewAmount(input.Amount)
-		if err != nil {
-			return 0, nil, err
-		}
-
-		inputs = append(inputs, input)
-		satSelected += amount
-		if satSelected >= amt {
-			return satSelected, inputs, nil
-		}
-	}
-
-	return 0, nil, &ErrInsufficientFunds{amt, satSelected}
-}
-
 // syncUnspent populates local map of confirmed from our POV unspent outputs
 // so that later we could construct transaction in a fast manner.
 // Otherwise construction of transaction might take couple of seconds.
@@ -70,6 +28,8 @@ func (c *Connector) syncUnspent() error {
 		return errors.Errorf("unable to list unspent: %v", err)
 	}
 
+	unspent = SpendableInputs(unspent)
+
 	var amount decimal.Decimal
 	localUnspent := make(map[string]rpc.UnspentInput, len(unspent))
 	for _, u := range unspent {
@@ -89,15 +49,41 @@ func (c *Connector) syncUnspent() error {
 	return nil
 }
 
+// removeUnspent removes given outputs from the local cache, so that they
+// wouldn't be used until the next cache sync.
+func (c *Connector) removeUnspent(inputs []rpc.UnspentInput) {
+	c.unspentSyncMtx.Lock()
+	defer c.unspentSyncMtx.Unlock()
+
+	for _, input := range inputs {
+		delete(c.unspent, fmt.Sprintf("%v:%v", input.TxID, input.Vout))
+	}
+}
+
+// restoreUnspent returns given outputs back to the local cache, after
+// transaction which has been spending them has failed.
+func (c *Connector) restoreUnspent(inputs []rpc.UnspentInput) {
+	c.unspentSyncMtx.Lock()
+	defer c.unspentSyncMtx.Unlock()
+
+	if c.unspent == nil {
+		return
+	}
+
+	for _, input := range inputs {
+		c.unspent[fmt.Sprintf("%v:%v", input.TxID, input.Vout)] = input
+	}
+}
+
 // craftTransaction performs coin selection in order to obtain outputs which sum
-// to at least 'numCoins' amount of satoshis. If necessary, a change address will
-// also be generated.
+// to at least the overall amount of the given outputs, along with the fee.
+// If necessary, a change address will also be generated.
 func (c *Connector) craftTransaction(feeRatePerByte uint64,
-	amtSat btcutil.Amount, address btcutil.Address) (*wire.MsgTx,
+	outputs map[btcutil.Address]btcutil.Amount) (*wire.MsgTx,
 	btcutil.Amount, btcutil.Amount, btcutil.Address, error) {
 
 	c.log.Debugf("Performing coin selection fee rate(%v sat/byte), "+
-		"amount(%v)", feeRatePerByte, amtSat)
+		"amount(%v)", feeRatePerByte, outputsAmount(outputs))
 
 	// Try to get unspent outputs from local cache,
 	// if it is not initialized than sync it.
@@ -116,41 +102,21 @@ func (c *Connector) craftTransaction(feeRatePerByte uint64,
 	// Perform coin selection over our available, unlocked unspent outputs
 	// in order to find enough coins to meet the funding amount
 	// requirements.
-	selectedInputs, changeAmt, requiredFee, err := coinSelect(feeRatePerByte,
-		amtSat, c.unspent)
+	selection, err := c.coinSelect(feeRatePerByte, outputs, c.unspent)
 	if err != nil {
-		return nil, 0, 0, nil, errors.Errorf("unable to select inputs: %v", err)
+		return nil, 0, 0, nil, err
 	}
 
 	c.log.Debugf("Selected %v unspent inputs, amount(%v), change(%v), fee(%v)",
-		len(selectedInputs), printAmount(amtSat), printAmount(changeAmt),
-		printAmount(requiredFee))
-
-	// Lock the selected coins. These coins are now "reserved", this
-	// prevents concurrent funding requests from referring to and this
-	// double-spending the same set of coins.
-	for _, input := range selectedInputs {
-		if err = c.client.LockUnspent(input); err != nil {
-			return nil, 0, 0, nil, err
-		}
-	}
-
-	// Record any change output(s) generated as a result of the coin
-	// selection.
-	outputs := make(map[btcutil.Address]btcutil.Amount)
-	outputs[address] = amtSat
-	var changeAddr btcutil.Address
-	if changeAmt != 0 {
-		// Create loopback output with remaining amount which point out to the
-		// default account of the wallet.
-		changeAddr, err = c.client.GetNewRawChangeAddress(defaultAccount)
-		if err != nil {
-			return nil, 0, 0, nil, err
-		}
-		outputs[changeAddr] = changeAmt
-	}
-
-	tx, err := c.client.CreateRawTransaction(selectedInputs, outputs)
+		len(selection.Inputs), printAmount(outputsAmount(outputs)),
+		printAmount(selection.Change), printAmount(selection.Fee))
+
+	tx, changeAddr, err := c.fundTransaction(selection, outputs,
+		func() (btcutil.Address, error) {
+			// Create loopback output with remaining amount which point
+			// out to the default account of the wallet.
+			return c.client.GetNewRawChangeAddress(defaultAccount)
+		})
 	if err != nil {
 		return nil, 0, 0, nil, err
 	}
@@ -158,73 +124,105 @@ func (c *Connector) craftTransaction(feeRatePerByte uint64,
 	// Remove unspent utxo from local cache. Otherwise it will be updated only
 	// on next cache sync, which might cause inputs re-usage. If transaction
 	// will fail, than inputs will be returned on next cache sync.
-	for _, input := range selectedInputs {
+	for _, input := range selection.Inputs {
 		key := fmt.Sprintf("%v:%v", input.TxID, input.Vout)
 		delete(c.unspent, key)
 	}
 
-	return tx, requiredFee, changeAmt, changeAddr, nil
+	return tx, selection.Fee, selection.Change, changeAddr, nil
 }
 
-// coinSelect attempts to select a sufficient amount of coins, including a
-// change output to fund amt satoshis, adhering to the specified fee rate. The
-// specified fee rate should be expressed in sat/byte for coin selection to
-// function properly.
-func coinSelect(feeRatePerByte uint64, amtSat btcutil.Amount,
-	unspent map[string]rpc.UnspentInput) ([]rpc.UnspentInput,
-	btcutil.Amount, btcutil.Amount, error) {
+// fundTransaction locks the selected inputs, and creates transaction which
+// spends them in order to pay the given outputs. Change is sent on the
+// address which is returned by the given function. If transaction couldn't
+// be created, inputs are unlocked.
+func (c *Connector) fundTransaction(selection *CoinSelection,
+	outputs map[btcutil.Address]btcutil.Amount,
+	newChangeAddress func() (btcutil.Address, error)) (*wire.MsgTx,
+	btcutil.Address, error) {
 
-	amtNeeded := amtSat
-	for {
-		// First perform an initial round of coin selection to estimate
-		// the required fee.
-		totalSat, selectedUtxos, err := selectInputs(amtNeeded, unspent)
+	var err error
+	var locked []rpc.UnspentInput
+	defer func() {
+		// If transaction creation has failed, inputs should be returned
+		// back, so that they could be used by other transactions.
 		if err != nil {
-			return nil, 0, 0, err
+			UnlockInputs(c.client, locked, c.log)
 		}
+	}()
 
-		var weightEstimate TxWeightEstimator
+	// Lock the selected coins. These coins are now "reserved", this
+	// prevents concurrent funding requests from referring to and this
+	// double-spending the same set of coins.
+	for _, input := range selection.Inputs {
+		if err = c.client.LockUnspent(input); err != nil {
+			return nil, nil, errors.Errorf("unable to lock input: %v", err)
+		}
+		locked = append(locked, input)
+	}
 
-		// For every input add weight
-		for i := 0; i < len(selectedUtxos); i++ {
-			weightEstimate.AddP2PKHInput()
+	// Record any change output(s) generated as a result of the coin
+	// selection. Outputs are copied, so that change wouldn't be added to
+	// the outputs of the caller.
+	txOutputs := make(map[btcutil.Address]btcutil.Amount, len(outputs)+1)
+	for address, amount := range outputs {
+		txOutputs[address] = amount
+	}
+	var changeAddr btcutil.Address
+	if selection.Change != 0 {
+		changeAddr, err = newChangeAddress()
+		if err != nil {
+			return nil, nil, errors.Errorf("unable to get change "+
+				"address: %v", err)
 		}
+		txOutputs[changeAddr] = selection.Change
+	}
 
-		// This is usual transaction and it will contain one P2PKH output to
-		// pay to someone else, add weight for it.
-		weightEstimate.AddP2PKHOutput()
+	tx, err := c.client.CreateRawTransaction(selection.Inputs, txOutputs)
+	if err != nil {
+		return nil, nil, errors.Errorf("unable to create "+
+			"transaction: %v", err)
+	}
 
-		// Assume that change output is a P2PKH output.
-		weightEstimate.AddP2PKHOutput()
+	return tx, changeAddr, nil
+}
 
-		// The difference between the selected amount and the amount
-		// requested will be used to pay fees, and generate a change
-		// output with the remaining.
-		overShootAmt := totalSat - amtSat
+// coinSelect selects unspent outputs with the coin selection strategy of
+// the connector, in order to pay the given outputs along with the fee of
+// the transaction with given fee rate in sat/byte.
+func (c *Connector) coinSelect(feeRatePerByte uint64,
+	outputs map[btcutil.Address]btcutil.Amount,
+	unspent map[string]rpc.UnspentInput) (*CoinSelection, error) {
 
-		// Based on the estimated size and fee rate, if the excess
-		// amount isn't enough to pay fees, then increase the requested
-		// coin amount by the estimate required fee, performing another
-		// round of coin selection.
-		size := uint64(weightEstimate.Weight() / txsize.WitnessScaleFactor)
-		requiredFee := btcutil.Amount(size * feeRatePerByte)
+	inputs := make([]rpc.UnspentInput, 0, len(unspent))
+	for _, input := range unspent {
+		inputs = append(inputs, input)
+	}
 
-		if overShootAmt < requiredFee {
-			amtNeeded = amtSat + requiredFee
-			continue
-		}
+	receivers := make([]btcutil.Address, 0, len(outputs))
+	for address := range outputs {
+		receivers = append(receivers, address)
+	}
 
-		// If the fee is sufficient, then calculate the amount of the
-		// change output.
-		changeAmt := overShootAmt - requiredFee
+	return c.cfg.CoinSelector.Select(feeRatePerByte, outputsAmount(outputs),
+		receivers, inputs)
+}
 
-		return selectedUtxos, changeAmt, requiredFee, nil
+// outputsAmount returns the overall amount of the given outputs.
+func outputsAmount(outputs map[btcutil.Address]btcutil.Amount) btcutil.Amount {
+	var amount btcutil.Amount
+	for _, amt := range outputs {
+		amount += amt
 	}
+
+	return amount
 }
 
 // createReorganisationOutputs creates list of optimal outputs by diving
 // large inputs and taking into consideration transaction fee as well as dust
-// limits in order to avoid tx failure.
+// limits in order to avoid tx failure. Number of outputs is limited, so that
+// transaction wouldn't exceed the standard size, remaining amount is left in
+// the last output, and it is split by the next reorganisation.
 func createReorganisationOutputs(feeRatePerByte uint64,
 	inputs []rpc.UnspentInput, optimalUTXOValue btcutil.Amount) ([]btcutil.Amount,
 	btcutil.Amount, error) {
@@ -246,6 +244,9 @@ func createReorganisationOutputs(feeRatePerByte uint64,
 
 	var outputsAmounts []btcutil.Amount
 	numOptimalOutputs := int(overallAmount / optimalUTXOValue)
+	if numOptimalOutputs >= maxReorganisationOutputs {
+		numOptimalOutputs = maxReorganisationOutputs - 1
+	}
 	for i := 0; i < numOptimalOutputs; i++ {
 		outputsAmounts = append(outputsAmounts, optimalUTXOValue)
 	}
@@ -268,8 +269,8 @@ func createReorganisationOutputs(feeRatePerByte uint64,
 		var weightEstimate TxWeightEstimator
 
 		// Increase size of transaction on weight of inputs.
-		for i := 0; i < len(inputs); i++ {
-			weightEstimate.AddP2PKHInput()
+		for _, input := range inputs {
+			addInput(&weightEstimate, input)
 		}
 
 		// Increase size of transaction on weight of outputs.
@@ -277,7 +278,7 @@ func createReorganisationOutputs(feeRatePerByte uint64,
 			weightEstimate.AddP2PKHOutput()
 		}
 
-		size := uint64(weightEstimate.Weight() / txsize.WitnessScaleFactor)
+		size := uint64(weightEstimate.VSize())
 		requiredFee = btcutil.Amount(size * feeRatePerByte)
 
 		// We are paying paying tx fee by decreasing value of last output,
@@ -321,6 +322,33 @@ func createReorganisationOutputs(feeRatePerByte uint64,
 	}
 }
 
+// UnlockInputs unlocks given unspent outputs of the daemon wallet, errors
+// are only logged because outputs will be unlocked anyway on the daemon
+// restart.
+func UnlockInputs(client rpc.Client, inputs []rpc.UnspentInput,
+	log *common.NamedLogger) {
+
+	for _, input := range inputs {
+		if err := client.UnlockUnspentInput(input); err != nil {
+			log.Errorf("unable to unlock input(%v:%v): %v", input.TxID,
+				input.Vout, err)
+		}
+	}
+}
+
+// txInputs returns outputs which are spent by the transaction.
+func txInputs(tx *wire.MsgTx) []rpc.UnspentInput {
+	inputs := make([]rpc.UnspentInput, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		inputs[i] = rpc.UnspentInput{
+			TxID: txIn.PreviousOutPoint.Hash.String(),
+			Vout: txIn.PreviousOutPoint.Index,
+		}
+	}
+
+	return inputs
+}
+
 // DefaultDustLimit is used to calculate the dust HTLC amount which will be
 // send to other node during funding process.
 func DefaultDustLimit() btcutil.Amount {
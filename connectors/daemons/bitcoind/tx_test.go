@@ -70,7 +70,7 @@ func TestCreateReorganisationOutputsWithFee(t *testing.T) {
 	}
 
 	outputAmounts, fee, err := createReorganisationOutputs(feeRatePerByte,
-		inputs, optimalUTXOValue)
+		inputs, defaultOptimalUTXOValue)
 	if err != nil {
 		t.Fatalf("unable craft reoganisation outputs: %v", err)
 	}
@@ -91,7 +91,7 @@ func TestCreateReorganisationOutputsWithFee(t *testing.T) {
 	// Check all outputs except the last one which pays the fees,
 	// than they are equal to the optimal value.
 	for i := 0; i < len(outputAmounts)-1; i++ {
-		if outputAmounts[i] != optimalUTXOValue {
+		if outputAmounts[i] != defaultOptimalUTXOValue {
 			t.Fatalf("wrong output amount")
 		}
 	}
@@ -4,10 +4,12 @@ import (
 	"math/big"
 
 	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
 	"github.com/bitlum/connector/connectors/rpc/bitcoin"
 	"github.com/bitlum/connector/connectors/rpc/bitcoincash"
 	"github.com/bitlum/connector/connectors/rpc/dash"
 	"github.com/bitlum/connector/connectors/rpc/litecoin"
+	"github.com/bitlum/go-bitcoind-rpc/btcjson"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil"
 	"github.com/go-errors/errors"
@@ -32,6 +34,37 @@ func printAmount(a btcutil.Amount) string {
 	return decimal.NewFromFloat(a.ToBTC()).Round(8).String()
 }
 
+// SpendableInputs returns the unspent outputs which could be spent by the
+// wallet, skipping the outputs of the watch-only addresses.
+func SpendableInputs(unspent []rpc.UnspentInput) []rpc.UnspentInput {
+	spendable := make([]rpc.UnspentInput, 0, len(unspent))
+	for _, u := range unspent {
+		if !u.WatchOnly {
+			spendable = append(spendable, u)
+		}
+	}
+
+	return spendable
+}
+
+// InternalPaymentID returns id of the internal outgoing payment which has
+// been created by the connector for the output of the transaction, e.g. the
+// sweep to the cold storage. Sync generates outgoing payments as external,
+// because it is unable to identify internal ones by the transaction
+// details. Empty string is returned if there is no such payment.
+func InternalPaymentID(store connectors.PaymentsStore, txID,
+	receipt string) string {
+
+	id := connectors.GeneratePaymentID(txID, receipt,
+		string(connectors.Outgoing), string(connectors.Internal))
+
+	if _, err := store.PaymentByID(id); err != nil {
+		return ""
+	}
+
+	return id
+}
+
 func isProperNet(desiredNet, actualNet string) bool {
 	// Handle the case of different simulation networks names
 	if desiredNet == "simnet" && actualNet == "regtest" {
@@ -82,33 +115,20 @@ func getParams(asset connectors.Asset, network string) (*chaincfg.Params, error)
 	}
 }
 
-func accountToAlias(account string) connectors.AccountAlias {
-	switch account {
-	case defaultAccount:
-		return connectors.DefaultAccount
-
-	case allAccounts:
-		return connectors.AllAccounts
-
-	default:
-		return connectors.AccountAlias(account)
+// convertRPCError converts error returned by the daemon to the typed
+// connector error. If error couldn't be classified, it is annotated with
+// the given description.
+func convertRPCError(daemon string, err error, desc string) error {
+	if e, ok := err.(*btcjson.RPCError); ok &&
+		e.Code == btcjson.ErrRPCWalletInsufficientFunds {
+		return &connectors.ErrInsufficientFunds{}
 	}
-}
-
-func aliasToAccount(alias connectors.AccountAlias) string {
-	switch alias {
-	case connectors.SentAccount:
-		// In bitcoin-like asset you daemons is working in a way that you
-		// could use use all the money from all accounts.
-		return allAccounts
 
-	case connectors.DefaultAccount:
-		return defaultAccount
-
-	case connectors.AllAccounts:
-		return allAccounts
-
-	default:
-		return string(alias)
+	if err := connectors.WrapDaemonError(daemon, err); err != nil {
+		if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+			return err
+		}
 	}
+
+	return errors.Errorf("%v: %v", desc, err)
 }
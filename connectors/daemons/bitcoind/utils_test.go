@@ -1,6 +1,7 @@
 package bitcoind
 
 import (
+	"github.com/bitlum/connector/connectors/rpc"
 	"github.com/shopspring/decimal"
 	"testing"
 )
@@ -15,3 +16,17 @@ func TestDec2Amount(t *testing.T) {
 		t.Fatalf("wrong amount")
 	}
 }
+
+func TestSpendableInputs(t *testing.T) {
+	spendable := SpendableInputs([]rpc.UnspentInput{
+		{TxID: "1"},
+		{TxID: "2", WatchOnly: true},
+		{TxID: "3"},
+	})
+
+	if len(spendable) != 2 || spendable[0].TxID != "1" ||
+		spendable[1].TxID != "3" {
+		t.Fatalf("outputs of watch-only addresses should be skipped: %v",
+			spendable)
+	}
+}
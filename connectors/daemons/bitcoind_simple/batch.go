@@ -0,0 +1,693 @@
+package bitcoind_simple
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/bitlum/connector/common"
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/daemons/bitcoind"
+	"github.com/bitlum/connector/metrics"
+	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// queuePayment saves the payment as waiting to be sent in the next batch
+// transaction, and triggers sending of the batch if the queue is full.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) queuePayment(address string, amt decimal.Decimal,
+	idempotencyKey string) (*connectors.Payment, error) {
+
+	payment, err := c.newBatchedPayment(address, amt, idempotencyKey,
+		&connectors.BatchedTxDetails{QueuedAt: time.Now().UnixNano()})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return nil, errors.Errorf("unable save payment: %v", err)
+	}
+
+	c.log.Infof("Queue payment %v", spew.Sdump(payment))
+
+	queue, err := c.queuedPayments()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(queue) >= c.cfg.BatchPolicy.BatchSize() {
+		select {
+		case c.flushBatch <- struct{}{}:
+		default:
+		}
+	}
+
+	return payment, nil
+}
+
+// newBatchedPayment returns the waiting outgoing payment, which is going to
+// be sent by the wallet of the daemon. Transaction of the payment is
+// unknown until it is sent, that is why payment id is derived from the
+// random queue id, and payment is found by the sync with the index of the
+// batch transactions.
+func (c *Connector) newBatchedPayment(address string, amt decimal.Decimal,
+	idempotencyKey string, details *connectors.BatchedTxDetails) (
+	*connectors.Payment, error) {
+
+	if !amt.IsPositive() {
+		return nil, &connectors.ErrInvalidAmount{
+			Amount: amt.String(),
+			Reason: errors.New("amount should be positive"),
+		}
+	}
+
+	queueID, err := genQueueID()
+	if err != nil {
+		return nil, errors.Errorf("unable to generate queue id: %v", err)
+	}
+
+	payment := &connectors.Payment{
+		UpdatedAt:      connectors.NowInMilliSeconds(),
+		Status:         connectors.Waiting,
+		Direction:      connectors.Outgoing,
+		System:         connectors.External,
+		Receipt:        address,
+		Asset:          c.cfg.Asset,
+		Media:          connectors.Blockchain,
+		Amount:         amt.Round(8),
+		Detail:         details,
+		IdempotencyKey: idempotencyKey,
+	}
+
+	payment.PaymentID = connectors.GeneratePaymentID(queueID, address,
+		string(connectors.Outgoing), string(connectors.External))
+
+	return payment, nil
+}
+
+// queuedPayments returns payments which are waiting to be sent in the batch
+// transaction, in the order in which they have been queued.
+func (c *Connector) queuedPayments() ([]*connectors.Payment, error) {
+	payments, err := c.cfg.PaymentStore.ListPayments(c.cfg.Asset,
+		connectors.Waiting, connectors.Outgoing, connectors.Blockchain,
+		connectors.External)
+	if err != nil {
+		return nil, errors.Errorf("unable to list waiting payments: %v", err)
+	}
+
+	var queue []*connectors.Payment
+	for _, payment := range payments {
+		if _, ok := payment.Detail.(*connectors.BatchedTxDetails); ok {
+			queue = append(queue, payment)
+		}
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].Detail.(*connectors.BatchedTxDetails).QueuedAt <
+			queue[j].Detail.(*connectors.BatchedTxDetails).QueuedAt
+	})
+
+	return queue, nil
+}
+
+// runBatcher sends queued payments every interval of the batch policy, or
+// when the queue is full, until connector is stopped.
+func (c *Connector) runBatcher() {
+	ticker := time.NewTicker(c.cfg.BatchPolicy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.flushBatch:
+		case <-c.quit:
+			return
+		}
+
+		if err := c.sendBatches(); err != nil {
+			c.log.Errorf("unable to send batch: %v", err)
+		}
+	}
+}
+
+// sendBatches sends queued payments in the batch transaction, and keeps
+// sending them until there are not enough payments left for the full
+// batch.
+func (c *Connector) sendBatches() error {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	for {
+		queue, err := c.queuedPayments()
+		if err != nil {
+			m.AddError(metrics.HighSeverity)
+			return err
+		}
+
+		if len(queue) == 0 {
+			return nil
+		}
+
+		sent, err := c.sendBatch(queue)
+		if err != nil {
+			m.AddError(metrics.HighSeverity)
+			return err
+		}
+
+		if len(queue)-sent < c.cfg.BatchPolicy.BatchSize() {
+			return nil
+		}
+	}
+}
+
+// sendBatch sends the queued payments in one transaction, and returns the
+// number of payments which have left the queue. Transaction couldn't have
+// several outputs with the same address, that is why payments to the
+// address which is already in the batch are left for the next one. Batch
+// which couldn't be sent because of the temporary error is retried by the
+// payment sender, rather than returned to the queue, because transaction
+// might have been sent anyway.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) sendBatch(queue []*connectors.Payment) (int, error) {
+	var batch []*connectors.Payment
+	amount := decimal.Zero
+	receipts := make(map[string]struct{})
+	for _, payment := range queue {
+		if len(batch) == c.cfg.BatchPolicy.BatchSize() {
+			break
+		}
+
+		if _, ok := receipts[payment.Receipt]; ok {
+			continue
+		}
+
+		receipts[payment.Receipt] = struct{}{}
+		amount = amount.Add(payment.Amount)
+		batch = append(batch, payment)
+	}
+
+	// Lack of funds is the permanent error for the payment sender, so
+	// balance is checked beforehand, in order to keep payments in the
+	// queue until wallet is refilled. Wallet spends outputs with at least
+	// one confirmation.
+	balance, err := c.client.GetBalanceByLabel(allAccounts, 1)
+	if err != nil {
+		return 0, connectors.WrapDaemonError(c.client.DaemonName(), err)
+	}
+
+	if available := sat2DecAmount(balance); available.LessThan(amount) {
+		return 0, &connectors.ErrInsufficientFunds{
+			Needed:    amount,
+			Available: available,
+		}
+	}
+
+	if _, err := c.sendWalletPayments(batch); err != nil {
+		return 0, err
+	}
+
+	return len(batch), nil
+}
+
+// recoverBatches schedules the retry of the batches, sending of which has
+// been interrupted by the stop of the connector before either transaction
+// or the failed attempt has been saved. Payments of such batches are left
+// pending without transaction, and the payment sender looks up their
+// transaction before sending them again.
+func (c *Connector) recoverBatches() error {
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	list, err := c.cfg.AttemptsStorage.ListAttempts(c.cfg.Asset,
+		connectors.Blockchain)
+	if err != nil {
+		return errors.Errorf("unable to list payment attempts: %v", err)
+	}
+
+	retried := make(map[string]struct{}, len(list))
+	for _, attempts := range list {
+		retried[attempts.PaymentID] = struct{}{}
+	}
+
+	// Batch is sent on behalf of its first payment, id of which is the id
+	// of the batch.
+	unsent, err := c.walletPayments(func(payment *connectors.Payment,
+		details *connectors.BatchedTxDetails) bool {
+		return payment.MediaID == "" && payment.PaymentID == details.BatchID
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, payment := range unsent {
+		if _, ok := retried[payment.PaymentID]; ok {
+			continue
+		}
+
+		c.log.Warnf("Sending of batch(%v) has been interrupted, schedule "+
+			"its retry", payment.PaymentID)
+
+		err := c.cfg.AttemptsStorage.PutAttempts(&connectors.PaymentAttempts{
+			PaymentID:   payment.PaymentID,
+			Asset:       c.cfg.Asset,
+			Media:       connectors.Blockchain,
+			Attempts:    1,
+			NextAttempt: time.Now(),
+			LastError:   "sending has been interrupted",
+		})
+		if err != nil {
+			return errors.Errorf("unable to save attempts of batch(%v): %v",
+				payment.PaymentID, err)
+		}
+	}
+
+	return nil
+}
+
+// sendWalletPayments sends payments by the wallet of the daemon in one
+// transaction. Payments are saved as pending with the id of the batch
+// before transaction is sent, so that transaction could be found by this
+// id if response of the daemon has been lost. Transaction is sent by the
+// payment sender on behalf of the first payment, which is retried on the
+// temporary error. Returned payments are in the same order as the given
+// ones.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) sendWalletPayments(batch []*connectors.Payment) (
+	[]*connectors.Payment, error) {
+
+	batchID := batch[0].PaymentID
+	for _, payment := range batch {
+		details := payment.Detail.(*connectors.BatchedTxDetails)
+		details.BatchSize = len(batch)
+		details.BatchID = batchID
+
+		payment.Status = connectors.Pending
+		payment.UpdatedAt = connectors.NowInMilliSeconds()
+		if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+			return nil, errors.Errorf("unable save payment(%v): %v",
+				payment.PaymentID, err)
+		}
+	}
+
+	if _, err := c.sender.Send(batch[0]); err != nil {
+		return nil, err
+	}
+
+	// Payments of the batch are updated by the sender callbacks, so they
+	// are fetched from the store again.
+	payments := make([]*connectors.Payment, len(batch))
+	for i, payment := range batch {
+		var err error
+		payments[i], err = c.cfg.PaymentStore.PaymentByID(payment.PaymentID)
+		if err != nil {
+			return nil, errors.Errorf("unable get payment(%v): %v",
+				payment.PaymentID, err)
+		}
+	}
+
+	return payments, nil
+}
+
+// sendBatchTx sends the batch, which is led by the given payment, by the
+// wallet of the daemon. Id of the batch is set as the comment of the
+// transaction.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) sendBatchTx(payment *connectors.Payment) (
+	*connectors.Payment, error) {
+
+	batchID := payment.Detail.(*connectors.BatchedTxDetails).BatchID
+	batch, err := c.batchPayments(batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make(map[btcutil.Address]btcutil.Amount, len(batch))
+	for _, member := range batch {
+		address, err := decodeAddress(c.cfg.Asset, member.Receipt,
+			c.netParams.Name)
+		if err != nil {
+			return nil, &connectors.ErrInvalidReceipt{Reason: err}
+		}
+
+		amounts[address] = decAmount2Sat(member.Amount)
+	}
+
+	txHash, err := c.client.SendMany(amounts, batchID)
+	if err != nil {
+		return nil, convertRPCError(c.client.DaemonName(), err,
+			fmt.Sprintf("unable send batch(%v)", batchID))
+	}
+
+	return c.attachBatchTx(payment, batch, txHash)
+}
+
+// lookupBatchTx checks whether transaction of the batch, which is led by
+// the given payment, is already known by the wallet, so that batch
+// wouldn't be sent twice. If so, payments of the batch are updated with
+// the transaction.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) lookupBatchTx(payment *connectors.Payment) (
+	*connectors.Payment, error) {
+
+	// Transaction might have been already attached by the sync.
+	if payment.MediaID != "" {
+		return payment, nil
+	}
+
+	batchID := payment.Detail.(*connectors.BatchedTxDetails).BatchID
+	txHash, err := c.findBatchTx(batchID)
+	if err != nil || txHash == nil {
+		return nil, err
+	}
+
+	batch, err := c.batchPayments(batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.attachBatchTx(payment, batch, txHash)
+}
+
+// abandonBatch marks the rest of the batch, leading payment of which has
+// been marked as failed by the payment sender, as failed with the same
+// reason.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) abandonBatch(payment *connectors.Payment) {
+	batchID := payment.Detail.(*connectors.BatchedTxDetails).BatchID
+	batch, err := c.batchPayments(batchID)
+	if err != nil {
+		c.log.Errorf("unable to fail batch(%v): %v", batchID, err)
+		return
+	}
+
+	for _, sibling := range batch {
+		sibling.Status = connectors.Failed
+		sibling.FailureReason = payment.FailureReason
+		sibling.UpdatedAt = connectors.NowInMilliSeconds()
+		if err := c.cfg.PaymentStore.SavePayment(sibling); err != nil {
+			c.log.Errorf("unable update payment(%v) status to fail: %v",
+				sibling.PaymentID, err)
+		}
+	}
+}
+
+// attachBatchTx attaches transaction to the payments of the batch, and
+// returns the leading payment of the batch updated with it.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) attachBatchTx(payment *connectors.Payment,
+	batch []*connectors.Payment, txHash *chainhash.Hash) (
+	*connectors.Payment, error) {
+
+	if err := c.attachBatch(batch, txHash); err != nil {
+		return nil, err
+	}
+
+	for _, sent := range batch {
+		if sent.PaymentID == payment.PaymentID {
+			return sent, nil
+		}
+	}
+
+	return nil, errors.Errorf("payment(%v) isn't found in batch",
+		payment.PaymentID)
+}
+
+// batchPayments returns pending payments of the batch with the given id,
+// which are sent by the wallet of the daemon in one transaction.
+func (c *Connector) batchPayments(batchID string) ([]*connectors.Payment,
+	error) {
+
+	return c.walletPayments(func(payment *connectors.Payment,
+		details *connectors.BatchedTxDetails) bool {
+		return details.BatchID == batchID
+	})
+}
+
+// txPayments returns pending payments which have been sent by the wallet
+// of the daemon in the transaction with the given id.
+func (c *Connector) txPayments(txID string) ([]*connectors.Payment, error) {
+	return c.walletPayments(func(payment *connectors.Payment,
+		details *connectors.BatchedTxDetails) bool {
+		return payment.MediaID == txID
+	})
+}
+
+// walletPayments returns pending payments, which are sent by the wallet of
+// the daemon, and which are matched by the given function.
+func (c *Connector) walletPayments(match func(*connectors.Payment,
+	*connectors.BatchedTxDetails) bool) ([]*connectors.Payment, error) {
+
+	payments, err := c.cfg.PaymentStore.ListPayments(c.cfg.Asset,
+		connectors.Pending, connectors.Outgoing, connectors.Blockchain,
+		connectors.External)
+	if err != nil {
+		return nil, errors.Errorf("unable to list pending payments: %v", err)
+	}
+
+	var matched []*connectors.Payment
+	for _, payment := range payments {
+		details, ok := payment.Detail.(*connectors.BatchedTxDetails)
+		if ok && match(payment, details) {
+			matched = append(matched, payment)
+		}
+	}
+
+	return matched, nil
+}
+
+// bumpBatchFee replaces the wallet transaction of the payment by the one
+// paying the given fee rate in sat/byte, with the help of the wallet of the
+// daemon. Bigger fee is paid from the change of the transaction, and it is
+// split between all payments of the transaction, which are updated with
+// the replacement.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) bumpBatchFee(payment *connectors.Payment,
+	feeRate float64) (*connectors.Payment, error) {
+
+	if payment.MediaID == "" {
+		return nil, &connectors.ErrNotReplaceable{
+			PaymentID: payment.PaymentID,
+			Reason:    errors.New("transaction hasn't been sent yet"),
+		}
+	}
+
+	txHash, err := chainhash.NewHashFromStr(payment.MediaID)
+	if err != nil {
+		return nil, errors.Errorf("unable decode tx id of payment(%v): %v",
+			payment.PaymentID, err)
+	}
+
+	tx, err := c.client.GetTransaction(txHash)
+	if err != nil {
+		return nil, convertRPCError(c.client.DaemonName(), err,
+			"unable to get transaction")
+	}
+
+	if tx.Confirmations > 0 {
+		return nil, &connectors.ErrNotReplaceable{
+			PaymentID: payment.PaymentID,
+			Reason:    errors.New("transaction has been already confirmed"),
+		}
+	} else if tx.Confirmations < 0 {
+		return nil, &connectors.ErrNotReplaceable{
+			PaymentID: payment.PaymentID,
+			Reason:    errors.New("transaction has been double spent"),
+		}
+	}
+
+	if tx.VSize != 0 {
+		oldFee := decAmount2Sat(decimal.NewFromFloat(tx.Fee).Abs())
+		oldFeeRate := float64(oldFee) / float64(tx.VSize)
+		if err := bitcoind.CheckBumpedFeeRate(feeRate, oldFeeRate); err != nil {
+			return nil, err
+		}
+	}
+
+	batch, err := c.txPayments(payment.MediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	replacementHash, err := c.client.BumpFee(txHash, feeRate)
+	if err != nil {
+		err = connectors.WrapDaemonError(c.client.DaemonName(), err)
+		if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+			return nil, err
+		}
+
+		// Wallet refuses to replace transactions which don't signal
+		// replace-by-fee, or which outputs have been already spent.
+		return nil, &connectors.ErrNotReplaceable{
+			PaymentID: payment.PaymentID,
+			Reason:    err,
+		}
+	}
+
+	c.log.Infof("Bump fee of wallet transaction(%v), replacement(%v)",
+		txHash, replacementHash)
+
+	return c.attachBatchTx(payment, batch, replacementHash)
+}
+
+// restoreBatchTx switches payments of the wallet transaction, fee of which
+// has been bumped, back to the given replaced transaction, because it has
+// been mined instead of the replacement.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) restoreBatchTx(payment *connectors.Payment,
+	txID string) (*connectors.Payment, error) {
+
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, errors.Errorf("unable decode tx id(%v): %v", txID, err)
+	}
+
+	batch, err := c.txPayments(payment.MediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Warnf("Replaced wallet transaction(%v) of payment(%v) has been "+
+		"mined instead of replacement(%v)", txID, payment.PaymentID,
+		payment.MediaID)
+
+	// Payment which isn't pending anymore is not the part of the batch, it
+	// is only switched to the mined transaction.
+	for _, sent := range batch {
+		if sent.PaymentID == payment.PaymentID {
+			return c.attachBatchTx(payment, batch, txHash)
+		}
+	}
+
+	if err := c.attachBatch(batch, txHash); err != nil {
+		return nil, err
+	}
+
+	payment.MediaID = txID
+	return payment, nil
+}
+
+// findBatchTx returns hash of the wallet transaction of the batch with the
+// given id, or nil if wallet doesn't know it. Transaction is searched
+// among the ones which are not yet synced, because synced transactions of
+// the batches are attached to the payments by the sync.
+func (c *Connector) findBatchTx(batchID string) (*chainhash.Hash, error) {
+	var lastHash *chainhash.Hash
+
+	// Error is returned by the storage if hash hasn't been saved yet.
+	if data, _ := c.cfg.StateStore.LastSyncedHash(); len(data) != 0 {
+		var err error
+		lastHash, err = chainhash.NewHashFromStr(string(data))
+		if err != nil {
+			return nil, errors.Errorf("unable decode block hash: %v", err)
+		}
+	}
+
+	resp, err := c.client.ListSinceBlock(lastHash, c.cfg.MinConfirmations)
+	if err != nil {
+		return nil, connectors.WrapDaemonError(c.client.DaemonName(), err)
+	}
+
+	for _, tx := range resp.Transactions {
+		if tx.Category == "send" && tx.Comment == batchID {
+			return chainhash.NewHashFromStr(tx.TxID)
+		}
+	}
+
+	return nil, nil
+}
+
+// attachBatch updates payments, which have been sent by the wallet of the
+// daemon in one transaction, with this transaction. Transaction is saved in
+// the index of the batch transactions, so that its outputs would be
+// resolved to the payments by the sync. Fee of the transaction is split
+// between payments pro-rata to their amounts.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) attachBatch(batch []*connectors.Payment,
+	txHash *chainhash.Hash) error {
+
+	txID := txHash.String()
+
+	payments := make(map[string]string, len(batch))
+	for _, payment := range batch {
+		payments[payment.Receipt] = payment.PaymentID
+	}
+
+	if err := c.cfg.BatchesStore.PutBatch(c.cfg.Asset, txID,
+		payments); err != nil {
+		return errors.Errorf("unable to save batch transaction(%v): %v",
+			txID, err)
+	}
+
+	fee := c.sentTxFee(txHash)
+	batchAmounts := make([]decimal.Decimal, len(batch))
+	for i, payment := range batch {
+		batchAmounts[i] = payment.Amount
+	}
+	shares := connectors.SplitFee(fee, batchAmounts, 8)
+
+	height := atomic.LoadInt64(&c.syncedHeight)
+	for i, payment := range batch {
+		details := payment.Detail.(*connectors.BatchedTxDetails)
+		details.TxFee = fee
+		details.SentHeight = height
+
+		payment.MediaID = txID
+		payment.MediaFee = shares[i]
+		payment.UpdatedAt = connectors.NowInMilliSeconds()
+		if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+			return errors.Errorf("unable update payment(%v) with batch "+
+				"transaction(%v): %v", payment.PaymentID, txID, err)
+		}
+	}
+
+	c.log.Infof("Send batch transaction(%v) with %v payments, fee(%v)",
+		txID, len(batch), fee)
+
+	return nil
+}
+
+// sentTxFee returns fee of the transaction which has been sent by the
+// wallet of the daemon. Fee is only informational, so if it couldn't be
+// fetched, error is logged and zero fee is returned, in order to save the
+// payments of the transaction anyway.
+func (c *Connector) sentTxFee(txHash *chainhash.Hash) decimal.Decimal {
+	tx, err := c.client.GetTransaction(txHash)
+	if err != nil {
+		c.log.Errorf("unable get fee of transaction(%v): %v", txHash, err)
+		return decimal.Zero
+	}
+
+	return decimal.NewFromFloat(tx.Fee).Abs().Round(8)
+}
+
+// genQueueID generates random id of the queued payment.
+func genQueueID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
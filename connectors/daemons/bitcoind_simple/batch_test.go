@@ -0,0 +1,558 @@
+package bitcoind_simple
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/bitlum/go-bitcoind-rpc/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+var batchAddresses = []string{
+	"mycY7kfzccdaaSvH3gHwoqPkxhQPXVzSwz",
+	"mrQ96nXLKJG4v6XrQRDySwPtBi4QTtRpVU",
+	"mwHfttSrSFWz7MztJNCtubUPGAFqRPHUKi",
+}
+
+// batchChain is the rpc client which sends batch transactions in memory.
+type batchChain struct {
+	*stubChain
+
+	// sent are the outputs of the sent batch transactions.
+	sent []map[btcutil.Address]btcutil.Amount
+
+	// fee is the fee of every sent transaction.
+	fee float64
+
+	// err is returned on sending if it is set.
+	err error
+
+	// lost makes the transaction to be sent, but error to be returned, as
+	// if response of the daemon has been lost.
+	lost bool
+
+	// feeErr is returned on the request of the sent transaction if it is
+	// set.
+	feeErr error
+
+	// bumped are the transactions which fee has been bumped.
+	bumped []string
+
+	// balance is the confirmed balance of the wallet.
+	balance btcutil.Amount
+}
+
+func (c *batchChain) GetBalanceByLabel(label string, minConfirms int) (
+	btcutil.Amount, error) {
+	return c.balance, nil
+}
+
+func (c *batchChain) SendMany(amounts map[btcutil.Address]btcutil.Amount,
+	comment string) (*chainhash.Hash, error) {
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	c.sent = append(c.sent, amounts)
+	txID := blockHash(byte(100 + len(c.sent)))
+	if !c.lost {
+		return chainhash.NewHashFromStr(txID)
+	}
+
+	for address, amount := range amounts {
+		c.txs = append(c.txs, btcjson.ListTransactionsResult{
+			Category: "send",
+			Address:  address.String(),
+			Amount:   -amount.ToBTC(),
+			TxID:     txID,
+			Comment:  comment,
+		})
+	}
+
+	return nil, errors.New("request timeout")
+}
+
+func (c *batchChain) GetTransaction(hash *chainhash.Hash) (*rpc.Transaction,
+	error) {
+
+	if c.feeErr != nil {
+		return nil, c.feeErr
+	}
+
+	return &rpc.Transaction{TxID: hash.String(), Fee: -c.fee, VSize: 200},
+		nil
+}
+
+func (c *batchChain) BumpFee(hash *chainhash.Hash, feeRate float64) (
+	*chainhash.Hash, error) {
+
+	c.bumped = append(c.bumped, hash.String())
+	c.fee = feeRate * 200 / 1e8
+	return chainhash.NewHashFromStr(blockHash(byte(200 + len(c.bumped))))
+}
+
+func newTestBatcher(t *testing.T) (*Connector, *batchChain,
+	*memoryPaymentsStore) {
+
+	chain := &batchChain{
+		stubChain: &stubChain{},
+		fee:       0.0003,
+		balance:   btcutil.SatoshiPerBitcoin,
+	}
+
+	c, store, _ := newTestConnector(chain.stubChain)
+	c.client = chain
+	c.netParams = &chaincfg.TestNet3Params
+	c.flushBatch = make(chan struct{}, 1)
+	c.cfg.BatchPolicy = connectors.BatchPolicy{
+		Interval:   time.Hour,
+		MaxOutputs: 2,
+	}
+
+	return c, chain, store
+}
+
+// TestSendBatch checks that queued payments are sent in one transaction,
+// and that fee of the transaction is split between them.
+func TestSendBatch(t *testing.T) {
+	c, chain, store := newTestBatcher(t)
+
+	var queued []*connectors.Payment
+	for i, amount := range []string{"0.1", "0.2", "0.3"} {
+		payment, err := c.SendPayment(batchAddresses[i], amount, "",
+			connectors.DefaultPriority)
+		if err != nil {
+			t.Fatalf("unable to send payment: %v", err)
+		}
+
+		if payment.Status != connectors.Waiting || payment.MediaID != "" {
+			t.Fatalf("payment should be queued")
+		}
+		queued = append(queued, payment)
+	}
+
+	select {
+	case <-c.flushBatch:
+	default:
+		t.Fatalf("batch should be sent when queue is full")
+	}
+
+	if err := c.sendBatches(); err != nil {
+		t.Fatalf("unable to send batches: %v", err)
+	}
+
+	// Only the full batch is sent, the rest is left for the next interval.
+	if len(chain.sent) != 1 || len(chain.sent[0]) != 2 {
+		t.Fatalf("wrong sent batches: %v", chain.sent)
+	}
+
+	txID := blockHash(101)
+	fee := decimal.Zero
+	for i, payment := range queued[:2] {
+		sent := store.payments[payment.PaymentID]
+		if sent.Status != connectors.Pending || sent.MediaID != txID {
+			t.Fatalf("payment(%v) should be sent in batch", i)
+		}
+
+		details := sent.Detail.(*connectors.BatchedTxDetails)
+		if details.BatchSize != 2 || !details.TxFee.Equal(
+			decimal.RequireFromString("0.0003")) {
+			t.Fatalf("wrong batch details: %v", details)
+		}
+		fee = fee.Add(sent.MediaFee)
+	}
+
+	if !store.payments[queued[0].PaymentID].MediaFee.Equal(
+		decimal.RequireFromString("0.0001")) ||
+		!fee.Equal(decimal.RequireFromString("0.0003")) {
+		t.Fatalf("fee should be split pro-rata to the amounts")
+	}
+
+	if store.payments[queued[2].PaymentID].Status != connectors.Waiting {
+		t.Fatalf("last payment should be left in queue")
+	}
+
+	// Sent payments are found by the outputs of the batch transaction.
+	chain.txs = []btcjson.ListTransactionsResult{{
+		Category:      "send",
+		Address:       batchAddresses[1],
+		Amount:        -0.2,
+		TxID:          txID,
+		Confirmations: 3,
+	}}
+	chain.lastBlock = blockHash(1)
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if len(store.payments) != 3 ||
+		store.payments[queued[1].PaymentID].Status != connectors.Completed {
+		t.Fatalf("batched payment should be completed")
+	}
+}
+
+// TestSendBatchFailed checks that payments are kept in the queue if wallet
+// hasn't enough funds, that batch which couldn't be sent because of the
+// temporary error is left for the payment sender rather than queued again,
+// and that payments are failed on the permanent error.
+func TestSendBatchFailed(t *testing.T) {
+	c, chain, store := newTestBatcher(t)
+
+	payment, err := c.SendPayment(batchAddresses[0], "0.1", "",
+		connectors.DefaultPriority)
+	if err != nil {
+		t.Fatalf("unable to send payment: %v", err)
+	}
+
+	chain.balance = btcutil.Amount(1e6)
+	err = c.sendBatches()
+	if _, ok := err.(*connectors.ErrInsufficientFunds); !ok {
+		t.Fatalf("insufficient funds error should be returned, got %v", err)
+	}
+
+	if len(chain.sent) != 0 ||
+		store.payments[payment.PaymentID].Status != connectors.Waiting {
+		t.Fatalf("payment should be kept in queue")
+	}
+
+	chain.balance = btcutil.SatoshiPerBitcoin
+	chain.err = errors.New("request timeout")
+	if err := c.sendBatches(); err != nil {
+		t.Fatalf("unable to send batches: %v", err)
+	}
+
+	retried := store.payments[payment.PaymentID]
+	if retried.Status != connectors.Pending || retried.MediaID != "" {
+		t.Fatalf("payment should be retried by the payment sender")
+	}
+
+	if queue, _ := c.queuedPayments(); len(queue) != 0 {
+		t.Fatalf("retried payment shouldn't be returned to queue")
+	}
+
+	failed, err := c.SendPayment(batchAddresses[1], "0.1", "",
+		connectors.DefaultPriority)
+	if err != nil {
+		t.Fatalf("unable to send payment: %v", err)
+	}
+
+	chain.err = &btcjson.RPCError{Code: btcjson.ErrRPCWalletInsufficientFunds}
+	if err := c.sendBatches(); err == nil {
+		t.Fatalf("error should be returned")
+	}
+
+	if store.payments[failed.PaymentID].Status != connectors.Failed ||
+		store.payments[failed.PaymentID].FailureReason == "" {
+		t.Fatalf("payment should be failed")
+	}
+}
+
+// TestRecoverBatches checks that batch, sending of which has been
+// interrupted by the stop of the connector, is scheduled for the retry.
+func TestRecoverBatches(t *testing.T) {
+	c, _, store := newTestBatcher(t)
+
+	payment, err := c.newBatchedPayment(batchAddresses[0],
+		decimal.New(1, -1), "", &connectors.BatchedTxDetails{})
+	if err != nil {
+		t.Fatalf("unable to create payment: %v", err)
+	}
+
+	details := payment.Detail.(*connectors.BatchedTxDetails)
+	details.BatchSize = 1
+	details.BatchID = payment.PaymentID
+	payment.Status = connectors.Pending
+	if err := store.SavePayment(payment); err != nil {
+		t.Fatalf("unable to save payment: %v", err)
+	}
+
+	if err := c.recoverBatches(); err != nil {
+		t.Fatalf("unable to recover batches: %v", err)
+	}
+
+	storage := c.cfg.AttemptsStorage.(*memoryAttemptsStorage)
+	if storage.attempts[payment.PaymentID].Attempts != 1 {
+		t.Fatalf("interrupted batch should be retried")
+	}
+}
+
+// TestSendPaymentWithoutBatching checks that payment which is sent by the
+// wallet is saved with its idempotency key before the transaction is
+// broadcast, and that it is kept if fee of the transaction couldn't be
+// fetched.
+func TestSendPaymentWithoutBatching(t *testing.T) {
+	c, chain, store := newTestBatcher(t)
+	c.cfg.BatchPolicy = connectors.BatchPolicy{}
+
+	chain.err = &btcjson.RPCError{Code: btcjson.ErrRPCWalletInsufficientFunds}
+	_, err := c.SendPayment(batchAddresses[0], "0.1", "failed",
+		connectors.DefaultPriority)
+	if err == nil {
+		t.Fatalf("error should be returned")
+	}
+
+	failed, err := store.PaymentByIdempotencyKey("failed")
+	if err != nil || failed.Status != connectors.Failed ||
+		failed.FailureReason == "" {
+		t.Fatalf("payment should be saved as failed")
+	}
+
+	chain.err = nil
+	chain.feeErr = errors.New("request timeout")
+	payment, err := c.SendPayment(batchAddresses[0], "0.1", "key",
+		connectors.DefaultPriority)
+	if err != nil {
+		t.Fatalf("unable to send payment: %v", err)
+	}
+
+	if payment.Status != connectors.Pending ||
+		payment.MediaID != blockHash(101) || !payment.MediaFee.IsZero() {
+		t.Fatalf("payment should be sent without fee: %v", payment)
+	}
+
+	same, err := c.SendPayment(batchAddresses[0], "0.1", "key",
+		connectors.DefaultPriority)
+	if err != nil {
+		t.Fatalf("unable to send payment: %v", err)
+	}
+
+	if len(chain.sent) != 1 || same.PaymentID != payment.PaymentID {
+		t.Fatalf("payment with the same key shouldn't be sent twice")
+	}
+}
+
+// TestSendPaymentResponseLost checks that payments which have been sent by
+// the wallet, but response of the daemon has been lost, are kept pending
+// and are found by the id of the batch, rather than sent twice.
+func TestSendPaymentResponseLost(t *testing.T) {
+	c, chain, store := newTestBatcher(t)
+	c.cfg.BatchPolicy = connectors.BatchPolicy{}
+
+	chain.lost = true
+	payment, err := c.SendPayment(batchAddresses[0], "0.1", "key",
+		connectors.DefaultPriority)
+	if err != nil {
+		t.Fatalf("unable to send payment: %v", err)
+	}
+
+	if payment.Status != connectors.Pending || payment.MediaID != "" {
+		t.Fatalf("payment should be pending until it is found: %v",
+			payment)
+	}
+
+	// Retry looks up the transaction of the batch before sending it
+	// again.
+	found, err := c.lookupPayment(payment)
+	if err != nil {
+		t.Fatalf("unable to lookup payment: %v", err)
+	}
+
+	if found == nil || found.MediaID != blockHash(101) ||
+		store.payments[payment.PaymentID].MediaID != blockHash(101) {
+		t.Fatalf("payment should be found by the batch id")
+	}
+
+	recipients := []*connectors.Recipient{
+		{Address: batchAddresses[1], Amount: "0.1"},
+		{Address: batchAddresses[2], Amount: "0.2"},
+	}
+
+	payments, err := c.SendPayments(recipients, "multi")
+	if err != nil {
+		t.Fatalf("unable to send payments: %v", err)
+	}
+
+	// Payments are also found by the sync, in case it is run before the
+	// retry.
+	chain.lastBlock = blockHash(1)
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if len(chain.sent) != 2 || len(store.payments) != 3 {
+		t.Fatalf("payments shouldn't be duplicated")
+	}
+
+	for i, payment := range payments {
+		if store.payments[payment.PaymentID].MediaID != blockHash(102) {
+			t.Fatalf("payment(%v) should be found by the sync", i)
+		}
+	}
+}
+
+// TestBumpBatchFee checks that fee of the wallet transaction is bumped by
+// the wallet, that all payments of the transaction are updated with the
+// replacement, and that they are switched back to the replaced transaction
+// if it has been mined instead.
+func TestBumpBatchFee(t *testing.T) {
+	c, chain, store := newTestBatcher(t)
+	c.cfg.BatchPolicy = connectors.BatchPolicy{}
+
+	recipients := []*connectors.Recipient{
+		{Address: batchAddresses[0], Amount: "0.1"},
+		{Address: batchAddresses[1], Amount: "0.2"},
+	}
+
+	payments, err := c.SendPayments(recipients, "")
+	if err != nil {
+		t.Fatalf("unable to send payments: %v", err)
+	}
+
+	// Fee rate of the sent transaction is 150 sat/byte.
+	_, err = c.BumpFee(payments[0].PaymentID, decimal.New(150, 0))
+	if _, ok := err.(*connectors.ErrInvalidFeeRate); !ok {
+		t.Fatalf("fee rate should be bigger than the current one, got %v",
+			err)
+	}
+
+	payment, err := c.BumpFee(payments[0].PaymentID, decimal.New(200, 0))
+	if err != nil {
+		t.Fatalf("unable to bump fee: %v", err)
+	}
+
+	txID := blockHash(101)
+	replacementID := blockHash(201)
+	if len(chain.bumped) != 1 || chain.bumped[0] != txID ||
+		payment.MediaID != replacementID {
+		t.Fatalf("transaction should be replaced by the wallet")
+	}
+
+	fee := decimal.Zero
+	for i, payment := range payments {
+		bumped := store.payments[payment.PaymentID]
+		if bumped.MediaID != replacementID {
+			t.Fatalf("payment(%v) should be updated with replacement", i)
+		}
+		fee = fee.Add(bumped.MediaFee)
+	}
+
+	if !fee.Equal(decimal.RequireFromString("0.0004")) {
+		t.Fatalf("bumped fee should be split between payments, got %v",
+			fee)
+	}
+
+	// Replacement is never mined, and replaced transaction is.
+	for i, recipient := range recipients {
+		chain.txs = append(chain.txs, btcjson.ListTransactionsResult{
+			Category:      "send",
+			Address:       recipient.Address,
+			Amount:        -float64(i+1) / 10,
+			TxID:          txID,
+			Confirmations: 3,
+		}, btcjson.ListTransactionsResult{
+			Category:      "send",
+			Address:       recipient.Address,
+			Amount:        -float64(i+1) / 10,
+			TxID:          replacementID,
+			Confirmations: -3,
+		})
+	}
+	chain.lastBlock = blockHash(1)
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	for i, payment := range payments {
+		mined := store.payments[payment.PaymentID]
+		if mined.MediaID != txID || mined.Status != connectors.Completed {
+			t.Fatalf("payment(%v) should be completed with replaced "+
+				"transaction: %v", i, mined)
+		}
+	}
+
+	if len(store.payments) != 2 {
+		t.Fatalf("payments shouldn't be duplicated")
+	}
+}
+
+// TestCancelQueuedPayment checks that queued payment is removed from the
+// queue on cancellation.
+func TestCancelQueuedPayment(t *testing.T) {
+	c, chain, store := newTestBatcher(t)
+
+	payment, err := c.SendPayment(batchAddresses[0], "0.1", "",
+		connectors.DefaultPriority)
+	if err != nil {
+		t.Fatalf("unable to send payment: %v", err)
+	}
+
+	if _, err := c.CancelPayment(payment.PaymentID); err != nil {
+		t.Fatalf("unable to cancel payment: %v", err)
+	}
+
+	if err := c.sendBatches(); err != nil {
+		t.Fatalf("unable to send batches: %v", err)
+	}
+
+	if len(chain.sent) != 0 ||
+		store.payments[payment.PaymentID].Status != connectors.Failed {
+		t.Fatalf("canceled payment shouldn't be sent")
+	}
+}
+
+// TestSendPayments checks that payments to the several recipients are sent
+// in one transaction, and that retry with the same idempotency key returns
+// the sent payments.
+func TestSendPayments(t *testing.T) {
+	c, chain, store := newTestBatcher(t)
+
+	recipients := []*connectors.Recipient{
+		{Address: batchAddresses[0], Amount: "0.1"},
+		{Address: batchAddresses[1], Amount: "0.2"},
+	}
+
+	payments, err := c.SendPayments(recipients, "key")
+	if err != nil {
+		t.Fatalf("unable to send payments: %v", err)
+	}
+
+	if len(chain.sent) != 1 || len(chain.sent[0]) != 2 || len(payments) != 2 {
+		t.Fatalf("payments should be sent in one transaction")
+	}
+
+	txID := blockHash(101)
+	fee := decimal.Zero
+	for i, payment := range payments {
+		if payment.Status != connectors.Pending || payment.MediaID != txID ||
+			payment.Receipt != recipients[i].Address {
+			t.Fatalf("wrong payment(%v): %v", i, payment)
+		}
+
+		if _, ok := store.payments[payment.PaymentID]; !ok {
+			t.Fatalf("payment(%v) should be saved", i)
+		}
+		fee = fee.Add(payment.MediaFee)
+	}
+
+	if !fee.Equal(decimal.RequireFromString("0.0003")) {
+		t.Fatalf("fee should be split between payments, got %v", fee)
+	}
+
+	retried, err := c.SendPayments(recipients, "key")
+	if err != nil {
+		t.Fatalf("unable to retry payments: %v", err)
+	}
+
+	if len(chain.sent) != 1 || len(retried) != 2 ||
+		retried[0].PaymentID != payments[0].PaymentID {
+		t.Fatalf("sent payments should be returned on retry")
+	}
+
+	_, err = c.SendPayments(recipients[:1], "key")
+	if err != connectors.ErrIdempotencyKeyReused {
+		t.Fatalf("idempotency key reuse should be rejected, got %v", err)
+	}
+
+	duplicated := []*connectors.Recipient{recipients[0], recipients[0]}
+	_, err = c.SendPayments(duplicated, "")
+	if _, ok := err.(*connectors.ErrInvalidReceipt); !ok {
+		t.Fatalf("duplicated recipient should be rejected, got %v", err)
+	}
+}
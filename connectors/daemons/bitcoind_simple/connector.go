@@ -1,13 +1,18 @@
 package bitcoind_simple
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/bitlum/connector/common"
 	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/daemons/bitcoind"
 	"github.com/bitlum/connector/connectors/rpc"
 	"github.com/bitlum/connector/metrics"
 	"github.com/bitlum/connector/metrics/crypto"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btclog"
+	"github.com/btcsuite/btcutil"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/go-errors/errors"
 	"github.com/shopspring/decimal"
@@ -25,9 +30,6 @@ var (
 	// defaultAccount denotes default account of wallet.
 	defaultAccount = ""
 
-	// minimumFeeRate is the minimal satoshis which we should pay for one byte
-	//  of information in blockchain.
-	minimumFeeRate = decimal.NewFromFloat(1.0)
 )
 
 // Config is a bitcoind config.
@@ -57,13 +59,57 @@ type Config struct {
 	// connector.
 	Metrics crypto.MetricsBackend
 
-	// StateStorage is used to keep data which is needed for connector to
-	// properly synchronise and track transactions.
-	StateStore StateStorage
+	// StateStorage is used to keep the last synced block, from which
+	// connector continues synchronisation of the payments.
+	StateStore connectors.StateStorage
 
 	// PaymentStorage is an external storage for payments, it is used by
 	// connector to save payment as well as update its state.
 	PaymentStore connectors.PaymentsStore
+
+	// RetryPolicy determines how outgoing payments which couldn't be sent
+	// are retried.
+	RetryPolicy connectors.RetryPolicy
+
+	// AttemptsStorage is used to persist attempts of the outgoing
+	// payments which are being retried.
+	AttemptsStorage connectors.AttemptsStorage
+
+	// FeePolicy determines how fee rates of the payments are estimated.
+	FeePolicy connectors.FeePolicy
+
+	// FeeBumpPolicy determines how fee of the stuck outgoing payments is
+	// bumped.
+	FeeBumpPolicy connectors.FeeBumpPolicy
+
+	// ReplacementsStore is used to map the transactions which have
+	// replaced the original ones to the payments.
+	ReplacementsStore connectors.ReplacementsStorage
+
+	// BatchPolicy determines how outgoing payments without the fee
+	// priority are batched into one transaction.
+	BatchPolicy connectors.BatchPolicy
+
+	// BatchesStore is used to map the outputs of the batch transactions to
+	// the payments.
+	BatchesStore connectors.BatchesStorage
+
+	// DerivationPolicy determines the extended public key from which
+	// deposit addresses are derived, instead of being created by the
+	// daemon wallet.
+	DerivationPolicy connectors.DerivationPolicy
+
+	// DerivationStore is used to keep the index of the next derived
+	// deposit address, and of the next derived cold storage address.
+	DerivationStore connectors.DerivationStorage
+
+	// TreasuryPolicy determines how much funds are kept in the wallet of
+	// the daemon, and the cold storage to which the excess is swept.
+	TreasuryPolicy connectors.TreasuryPolicy
+
+	// ConsolidationPolicy determines when small unspent outputs of the
+	// wallet are merged into one output.
+	ConsolidationPolicy connectors.ConsolidationPolicy
 }
 
 func (c *Config) validate() error {
@@ -104,6 +150,34 @@ func (c *Config) validate() error {
 		return errors.New("state store should be specified")
 	}
 
+	if c.AttemptsStorage == nil {
+		return errors.New("attempts storage should be specified")
+	}
+
+	if c.ReplacementsStore == nil {
+		return errors.New("replacements store should be specified")
+	}
+
+	if c.BatchesStore == nil {
+		return errors.New("batches store should be specified")
+	}
+
+	if c.BatchPolicy.MaxOutputs < 0 {
+		return errors.New("batch max outputs shouldn't be negative")
+	}
+
+	if c.DerivationPolicy.Enabled() && c.DerivationStore == nil {
+		return errors.New("derivation store should be specified")
+	}
+
+	if err := c.TreasuryPolicy.Validate(); err != nil {
+		return errors.Errorf("invalid treasury policy: %v", err)
+	}
+
+	if err := c.ConsolidationPolicy.Validate(); err != nil {
+		return errors.Errorf("invalid consolidation policy: %v", err)
+	}
+
 	return nil
 }
 
@@ -118,26 +192,192 @@ type Connector struct {
 
 	netParams *chaincfg.Params
 	log       *common.NamedLogger
+
+	// sendMtx is used to prevent the same payment to be sent twice, in case
+	// of the concurrent requests with the same idempotency key.
+	sendMtx sync.Mutex
+
+	// sender sends outgoing payments and retries the ones which couldn't
+	// be sent.
+	sender *connectors.PaymentSender
+
+	// oracle estimates fee rates of the payment priorities.
+	oracle *connectors.FeeOracle
+
+	// replacer bumps fee of the pending payments by replacing their
+	// transactions.
+	replacer *bitcoind.Replacer
+
+	// flushBatch is used to send queued payments without waiting for the
+	// batch interval, when the queue is full.
+	flushBatch chan struct{}
+
+	// deriver derives deposit addresses from the extended public key, it
+	// is nil if addresses are created by the daemon wallet.
+	deriver *bitcoind.AddressDeriver
+
+	// treasurer sweeps excess of the wallet to the cold storage, it is nil
+	// if sweeping is disabled.
+	treasurer *connectors.Treasurer
+	cold      *bitcoind.ColdStorage
+
+	// consolidator merges small unspent outputs of the wallet, it is nil
+	// if consolidation is disabled.
+	consolidator *bitcoind.Consolidator
+
+	lifecycle connectors.Lifecycle
+
+	// syncedHeight is the height of the last synced block, it is updated
+	// atomically.
+	syncedHeight int64
 }
 
 // A compile time check to ensure Connector implements the BlockchainConnector
 // interface.
 var _ connectors.BlockchainConnector = (*Connector)(nil)
 
+// A compile time check to ensure Connector implements the FeeBumper
+// interface.
+var _ connectors.FeeBumper = (*Connector)(nil)
+
+// A compile time check to ensure Connector implements the MultiSender
+// interface.
+var _ connectors.MultiSender = (*Connector)(nil)
+
 func NewConnector(cfg *Config) (*Connector, error) {
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
 
-	return &Connector{
-		cfg:    cfg,
-		quit:   make(chan struct{}),
-		client: cfg.RPCClient,
+	c := &Connector{
+		cfg:        cfg,
+		quit:       make(chan struct{}),
+		flushBatch: make(chan struct{}, 1),
+		client:     cfg.RPCClient,
 		log: &common.NamedLogger{
 			Name:   string(cfg.Asset),
 			Logger: cfg.Logger,
 		},
-	}, nil
+	}
+
+	var err error
+	c.sender, err = connectors.NewPaymentSender(&connectors.PaymentSenderConfig{
+		Asset:        cfg.Asset,
+		Media:        connectors.Blockchain,
+		Policy:       cfg.RetryPolicy,
+		Storage:      cfg.AttemptsStorage,
+		PaymentStore: cfg.PaymentStore,
+		Send:         c.sendPayment,
+		Lookup:       c.lookupPayment,
+		Abandon:      c.abandonPayment,
+		Locker:       &c.sendMtx,
+		Logger:       cfg.Logger,
+	})
+	if err != nil {
+		return nil, errors.Errorf("unable to create payment sender: %v", err)
+	}
+
+	c.oracle, err = connectors.NewFeeOracle(&connectors.FeeOracleConfig{
+		Asset:    cfg.Asset,
+		Policy:   cfg.FeePolicy,
+		Estimate: c.estimateFeeRate,
+		Fallback: decimal.New(int64(cfg.FeePerByte), 0),
+		Logger:   cfg.Logger,
+	})
+	if err != nil {
+		return nil, errors.Errorf("unable to create fee oracle: %v", err)
+	}
+
+	c.replacer, err = bitcoind.NewReplacer(&bitcoind.ReplacerConfig{
+		Asset:            cfg.Asset,
+		Client:           cfg.RPCClient,
+		PaymentStore:     cfg.PaymentStore,
+		Storage:          cfg.ReplacementsStore,
+		Policy:           cfg.FeeBumpPolicy,
+		MaxFeeRate:       cfg.FeePolicy.MaxFeeRate,
+		MinConfirmations: cfg.MinConfirmations,
+		FeeRate:          c.getFeeRate,
+		SyncedHeight: func() int64 {
+			return atomic.LoadInt64(&c.syncedHeight)
+		},
+		DecodeAddress: func(address string) (btcutil.Address, error) {
+			return decodeAddress(cfg.Asset, address, c.netParams.Name)
+		},
+		BumpWalletTx: c.bumpBatchFee,
+		Locker:       &c.sendMtx,
+		Logger:       cfg.Logger,
+	})
+	if err != nil {
+		return nil, errors.Errorf("unable to create replacer: %v", err)
+	}
+
+	if cfg.DerivationPolicy.Enabled() {
+		c.deriver, err = bitcoind.NewAddressDeriver(&bitcoind.AddressDeriverConfig{
+			Asset:   cfg.Asset,
+			Policy:  cfg.DerivationPolicy,
+			Client:  cfg.RPCClient,
+			Storage: cfg.DerivationStore,
+			Label:   defaultAccount,
+			Logger:  cfg.Logger,
+		})
+		if err != nil {
+			return nil, errors.Errorf("unable to create address deriver: %v",
+				err)
+		}
+	}
+
+	if cfg.TreasuryPolicy.Enabled() {
+		c.cold, c.treasurer, err = bitcoind.NewTreasury(
+			&bitcoind.TreasuryConfig{
+				Asset:   cfg.Asset,
+				Daemon:  cfg.RPCClient.DaemonName(),
+				Policy:  cfg.TreasuryPolicy,
+				Client:  cfg.RPCClient,
+				Storage: cfg.DerivationStore,
+				NetParams: func() *chaincfg.Params {
+					return c.netParams
+				},
+				Balance:     c.ConfirmedBalance,
+				FeeRate:     c.getFeeRate,
+				EstimateFee: c.EstimateTxFee,
+				Create: func(address, amount string,
+					priority connectors.FeePriority) (*connectors.Payment,
+					error) {
+
+					payment, _, err := c.createPayment(address, amount, "",
+						priority, connectors.Internal)
+					return payment, err
+				},
+				Send:    c.sender.Send,
+				Locker:  &c.sendMtx,
+				Metrics: cfg.Metrics,
+				Logger:  cfg.Logger,
+			})
+		if err != nil {
+			return nil, errors.Errorf("unable to create treasurer: %v", err)
+		}
+	}
+
+	if cfg.ConsolidationPolicy.Enabled() {
+		c.consolidator, err = bitcoind.NewConsolidator(
+			&bitcoind.ConsolidatorConfig{
+				Asset:            cfg.Asset,
+				Policy:           cfg.ConsolidationPolicy,
+				Client:           cfg.RPCClient,
+				PaymentStore:     cfg.PaymentStore,
+				MinConfirmations: cfg.MinConfirmations,
+				FeeRate:          c.getFeeRate,
+				Send:             c.sender.Send,
+				Locker:           &c.sendMtx,
+				Logger:           cfg.Logger,
+			})
+		if err != nil {
+			return nil, errors.Errorf("unable to create consolidator: %v",
+				err)
+		}
+	}
+
+	return c, nil
 }
 
 func (c *Connector) Start() (err error) {
@@ -152,6 +392,8 @@ func (c *Connector) Start() (err error) {
 		if err != nil {
 			atomic.SwapInt32(&c.started, 0)
 		}
+
+		c.lifecycle.Started(err)
 	}()
 
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
@@ -177,6 +419,23 @@ func (c *Connector) Start() (err error) {
 		return errors.Errorf("failed to get net params: %v", err)
 	}
 
+	if c.deriver != nil {
+		if err := c.deriver.ValidateNet(c.netParams); err != nil {
+			return errors.Errorf("unable to derive addresses: %v", err)
+		}
+	}
+
+	if c.cold != nil {
+		if err := c.cold.ValidateNet(c.netParams); err != nil {
+			return errors.Errorf("unable to sweep to cold storage: %v", err)
+		}
+	}
+
+	if err := c.recoverBatches(); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return errors.Errorf("unable to recover batches: %v", err)
+	}
+
 	c.wg.Add(1)
 	go func() {
 		defer func() {
@@ -228,28 +487,131 @@ func (c *Connector) Start() (err error) {
 		}
 	}()
 
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		c.log.Info("Starting payments retry goroutine...")
+		c.sender.Run(c.quit)
+		c.log.Info("Quit payments retry goroutine")
+	}()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		c.log.Info("Starting fee rates estimation goroutine...")
+		c.oracle.Run(c.quit)
+		c.log.Info("Quit fee rates estimation goroutine")
+	}()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		c.log.Info("Starting fee bumping goroutine...")
+		c.replacer.Run(c.quit)
+		c.log.Info("Quit fee bumping goroutine")
+	}()
+
+	if c.cfg.BatchPolicy.Enabled() {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			c.log.Info("Starting payments batching goroutine...")
+			c.runBatcher()
+			c.log.Info("Quit payments batching goroutine")
+		}()
+	}
+
+	if c.treasurer != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			c.log.Info("Starting cold storage sweeping goroutine...")
+			c.treasurer.Run(c.quit)
+			c.log.Info("Quit cold storage sweeping goroutine")
+		}()
+	}
+
+	if c.consolidator != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			c.log.Info("Starting unspent outputs consolidation goroutine...")
+			c.consolidator.Run(c.quit)
+			c.log.Info("Quit unspent outputs consolidation goroutine")
+		}()
+	}
+
 	return err
 }
 
-func (c *Connector) Stop(reason string) {
+func (c *Connector) Stop(reason string) error {
 	if !atomic.CompareAndSwapInt32(&c.shutdown, 0, 1) {
 		c.log.Warn("client already shutdown")
-		return
+		return nil
 	}
 
 	c.log.Infof("client shutting down (reason: %v)...", reason)
 	close(c.quit)
 
 	c.wg.Wait()
+	c.lifecycle.Stopped()
 
 	c.log.Info("client shutdown")
+	return nil
+}
+
+// Status returns the lifecycle state of the connector, and the sync state
+// of the connector and its daemon.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) Status() *connectors.ConnectorStatus {
+	status := c.lifecycle.Status()
+	status.Net = c.cfg.Net
+	status.MinConfirmations = c.cfg.MinConfirmations
+
+	status.SyncedHeight = atomic.LoadInt64(&c.syncedHeight)
+
+	chainInfo, err := c.client.GetBlockChainInfo()
+	if err != nil {
+		status.DaemonError = connectors.WrapDaemonError(
+			c.client.DaemonName(), err).Error()
+		return status
+	}
+	status.BestHeight = chainInfo.Blocks
+
+	networkInfo, err := c.client.GetNetworkInfo()
+	if err != nil {
+		status.DaemonError = connectors.WrapDaemonError(
+			c.client.DaemonName(), err).Error()
+		return status
+	}
+	status.DaemonVersion = networkInfo.SubVersion
+
+	return status
 }
 
-// CreateAddress is used to create deposit address.
+// CreateAddress is used to create deposit address. If extended public key
+// is specified, address is derived from it, otherwise it is created by the
+// daemon wallet.
 func (c *Connector) CreateAddress() (string, error) {
+	if c.deriver != nil {
+		address, err := c.deriver.NewAddress(c.netParams)
+		if err != nil {
+			return "", err
+		}
+
+		return address.String(), nil
+	}
+
 	address, err := c.cfg.RPCClient.GetNewAddress(defaultAccount)
 	if err != nil {
-		return "", err
+		return "", connectors.WrapDaemonError(c.client.DaemonName(), err)
 	}
 
 	return address.String(), nil
@@ -259,7 +621,7 @@ func (c *Connector) CreateAddress() (string, error) {
 func (c *Connector) ConfirmedBalance() (decimal.Decimal, error) {
 	amount, err := c.cfg.RPCClient.GetBalanceByLabel(allAccounts, c.cfg.MinConfirmations)
 	if err != nil {
-		return decimal.Zero, err
+		return decimal.Zero, connectors.WrapDaemonError(c.client.DaemonName(), err)
 	}
 
 	return sat2DecAmount(amount), nil
@@ -269,273 +631,664 @@ func (c *Connector) ConfirmedBalance() (decimal.Decimal, error) {
 func (c *Connector) PendingBalance() (decimal.Decimal, error) {
 	overallBalance, err := c.cfg.RPCClient.GetBalanceByLabel(allAccounts, 0)
 	if err != nil {
-		return decimal.Zero, err
+		return decimal.Zero, connectors.WrapDaemonError(c.client.DaemonName(), err)
 	}
 
 	confirmedBalance, err := c.cfg.RPCClient.GetBalanceByLabel(allAccounts, c.cfg.MinConfirmations)
 	if err != nil {
-		return decimal.Zero, err
+		return decimal.Zero, connectors.WrapDaemonError(c.client.DaemonName(), err)
 	}
 
 	return sat2DecAmount(overallBalance - confirmedBalance), nil
 }
 
-// SendPayment sends payment with given amount to the given address.
-func (c *Connector) SendPayment(address, amount string) (*connectors.Payment, error) {
+// SendPayment sends payment with given amount to the given address. If
+// priority isn't specified, payment is sent by the wallet of the daemon,
+// which chooses the fee by itself, otherwise transaction is created with
+// the fee rate of the priority. If batching is enabled, payment without
+// the priority is queued and returned as waiting, it is sent later in one
+// transaction with the other queued payments. Payment which couldn't be
+// sent because of the temporary error is returned as pending, and it is
+// retried by the payment sender.
+func (c *Connector) SendPayment(address, amount, idempotencyKey string,
+	priority connectors.FeePriority) (*connectors.Payment, error) {
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	decodedAddress, err := decodeAddress(c.cfg.Asset, address, c.netParams.Name)
-	if err != nil {
+	if priority != connectors.DefaultPriority {
+		c.sendMtx.Lock()
+		defer c.sendMtx.Unlock()
+
+		payment, created, err := c.createPayment(address, amount,
+			idempotencyKey, priority, connectors.External)
+		if err != nil {
+			m.AddError(metrics.HighSeverity)
+			return nil, err
+		} else if !created {
+			return payment, nil
+		}
+
+		payment, err = c.sender.Send(payment)
+		if err != nil {
+			m.AddError(metrics.HighSeverity)
+			return nil, err
+		}
+
+		return payment, nil
+	}
+
+	if _, err := decodeAddress(c.cfg.Asset, address,
+		c.netParams.Name); err != nil {
 		m.AddError(metrics.LowSeverity)
-		return nil, errors.Errorf("invalid address: %v", err)
+		return nil, &connectors.ErrInvalidReceipt{Reason: err}
 	}
 
 	amtInBtc, err := decimal.NewFromString(amount)
 	if err != nil {
 		m.AddError(metrics.LowSeverity)
-		return nil, errors.Errorf("unable to decode amount: %v", err)
+		return nil, &connectors.ErrInvalidAmount{Amount: amount, Reason: err}
 	}
 
-	txHash, err := c.cfg.RPCClient.SendToAddress(decodedAddress, decAmount2Sat(amtInBtc))
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, err := connectors.PaymentByIdempotencyKey(c.cfg.PaymentStore,
+		idempotencyKey, c.cfg.Asset, connectors.Blockchain, address, amtInBtc)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	} else if payment != nil {
+		c.log.Infof("Payment(%v) with idempotency key(%v) has been already "+
+			"sent", payment.PaymentID, idempotencyKey)
+		return payment, nil
+	}
+
+	if c.cfg.BatchPolicy.Enabled() {
+		payment, err := c.queuePayment(address, amtInBtc, idempotencyKey)
+		if err != nil {
+			m.AddError(metrics.HighSeverity)
+			return nil, err
+		}
+
+		return payment, nil
+	}
+
+	// Payment is saved with its idempotency key before transaction is
+	// broadcast, so that retry of the request wouldn't send it twice if
+	// payment couldn't be updated after the broadcast.
+	payment, err = c.newBatchedPayment(address, amtInBtc, idempotencyKey,
+		&connectors.BatchedTxDetails{})
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	payments, err := c.sendWalletPayments([]*connectors.Payment{payment})
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	return payments[0], nil
+}
+
+// SendPayments sends payments to the recipients in one transaction, fee of
+// which is chosen by the wallet of the daemon, and is split between
+// payments pro-rata to their amounts. Every address is validated before
+// transaction is sent, and transaction couldn't have several outputs with
+// the same address. Like other payments sent by the wallet, they are
+// retried by the payment sender on the temporary error.
+//
+// NOTE: Part of the connectors.MultiSender interface.
+func (c *Connector) SendPayments(recipients []*connectors.Recipient,
+	idempotencyKey string) ([]*connectors.Payment, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	amounts, err := connectors.RecipientAmounts(recipients)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	receipts := make(map[string]struct{}, len(recipients))
+	for _, recipient := range recipients {
+		_, err := decodeAddress(c.cfg.Asset, recipient.Address,
+			c.netParams.Name)
+		if err != nil {
+			m.AddError(metrics.LowSeverity)
+			return nil, &connectors.ErrInvalidReceipt{Reason: err}
+		}
+
+		if _, ok := receipts[recipient.Address]; ok {
+			m.AddError(metrics.LowSeverity)
+			return nil, &connectors.ErrInvalidReceipt{
+				Reason: errors.Errorf("address(%v) is specified several "+
+					"times", recipient.Address),
+			}
+		}
+
+		receipts[recipient.Address] = struct{}{}
+	}
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payments, err := connectors.PaymentsByIdempotencyKey(c.cfg.PaymentStore,
+		idempotencyKey, c.cfg.Asset, connectors.Blockchain, recipients,
+		amounts)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	} else if payments != nil {
+		c.log.Infof("Payments with idempotency key(%v) have been already "+
+			"sent", idempotencyKey)
+		return payments, nil
+	}
+
+	batch := make([]*connectors.Payment, len(recipients))
+	for i, recipient := range recipients {
+		batch[i], err = c.newBatchedPayment(recipient.Address, amounts[i],
+			connectors.RecipientIdempotencyKey(idempotencyKey, i),
+			&connectors.BatchedTxDetails{})
+		if err != nil {
+			m.AddError(metrics.LowSeverity)
+			return nil, err
+		}
+	}
+
+	payments, err = c.sendWalletPayments(batch)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable send transaction: %v", err)
+		return nil, err
 	}
 
-	tx, err := c.cfg.RPCClient.GetTransaction(txHash)
+	return payments, nil
+}
+
+// CreatePayment creates and signs transaction which sends given amount to
+// the given address, and stores it as waiting payment with the exact fee.
+// Inputs of the transaction are locked until payment is confirmed or
+// canceled.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) CreatePayment(address, amount,
+	idempotencyKey string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, _, err := c.createPayment(address, amount, idempotencyKey,
+		connectors.DefaultPriority, connectors.External)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable get transaction by hash: %v", err)
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// createPayment creates and signs transaction which sends given amount to
+// the given address with the fee rate of the priority, and stores it as
+// waiting payment of the given system. If payment with the same idempotency
+// key already exists, it is returned, and false is returned as the second
+// value.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) createPayment(address, amount, idempotencyKey string,
+	priority connectors.FeePriority, system connectors.PaymentSystem) (
+	*connectors.Payment, bool, error) {
+
+	decodedAddress, err := decodeAddress(c.cfg.Asset, address, c.netParams.Name)
+	if err != nil {
+		return nil, false, &connectors.ErrInvalidReceipt{Reason: err}
+	}
+
+	amtInBtc, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, false, &connectors.ErrInvalidAmount{Amount: amount,
+			Reason: err}
+	}
+
+	payment, err := connectors.PaymentByIdempotencyKey(c.cfg.PaymentStore,
+		idempotencyKey, c.cfg.Asset, connectors.Blockchain, address, amtInBtc)
+	if err != nil {
+		return nil, false, err
+	} else if payment != nil {
+		c.log.Infof("Payment(%v) with idempotency key(%v) has been already "+
+			"created", payment.PaymentID, idempotencyKey)
+		return payment, false, nil
+	}
+
+	tx, fee, changeAddress, err := c.createTransaction(decodedAddress,
+		decAmount2Sat(amtInBtc), priority)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var rawTx bytes.Buffer
+	if err := tx.Serialize(&rawTx); err != nil {
+		bitcoind.UnlockInputs(c.client, txInputs(tx), c.log)
+		return nil, false, errors.Errorf("unable serialize signed tx: %v", err)
 	}
 
-	payment := &connectors.Payment{
-		UpdatedAt: connectors.ConvertTimeToMilliSeconds(time.Now()),
-		Status:    connectors.Pending,
+	txID := tx.TxHash().String()
+
+	payment = &connectors.Payment{
+		UpdatedAt: connectors.NowInMilliSeconds(),
+		Status:    connectors.Waiting,
 		Direction: connectors.Outgoing,
-		System:    connectors.External,
+		System:    system,
 		Receipt:   address,
 		Asset:     c.cfg.Asset,
 		Media:     connectors.Blockchain,
-		Amount:    amtInBtc,
-		MediaFee:  decimal.NewFromFloat(tx.Fee).Abs().Round(8),
-		MediaID:   txHash.String(),
+		Amount:    amtInBtc.Round(8),
+		MediaFee:  sat2DecAmount(fee),
+		MediaID:   txID,
+		Detail: &connectors.GeneratedTxDetails{
+			RawTx:         rawTx.Bytes(),
+			TxID:          txID,
+			ChangeAddress: changeAddress,
+		},
+		IdempotencyKey: idempotencyKey,
 	}
 
 	payment.PaymentID, err = payment.GenPaymentID()
 	if err != nil {
-		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable generate payment id: %v", err)
+		bitcoind.UnlockInputs(c.client, txInputs(tx), c.log)
+		return nil, false, errors.Errorf("unable generate payment id: %v", err)
 	}
 
 	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable save payment id: %v", err)
+		bitcoind.UnlockInputs(c.client, txInputs(tx), c.log)
+		return nil, false, errors.Errorf("unable save payment: %v", err)
 	}
 
-	return payment, nil
+	c.log.Infof("Create payment %v", spew.Sdump(payment))
+
+	return payment, true, nil
 }
 
-// DecodeAddress takes the blockchain address and ensure its validity.
-func (c *Connector) ValidateAddress(address string) error {
+// ConfirmPayment sends previously created waiting payment to the
+// blockchain network. If transaction couldn't be sent, it is retried, and
+// after all attempts payment is marked as failed and its inputs are
+// unlocked.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) ConfirmPayment(paymentID string) (*connectors.Payment, error) {
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	_, err := decodeAddress(c.cfg.Asset, address, c.netParams.Name)
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, _, err := c.waitingPayment(paymentID)
 	if err != nil {
 		m.AddError(metrics.LowSeverity)
-		return errors.Errorf("invalid address: %v", err)
+		return nil, err
 	}
 
-	return nil
+	payment, err = c.sender.Send(payment)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	return payment, nil
 }
 
-// EstimateFee estimate fee for the transaction with the given sending
-// amount.
+// sendPayment sends transaction of the payment to the blockchain network,
+// or sends the batch of the payment by the wallet of the daemon if
+// transaction hasn't been created by the connector. Failures are handled
+// by the payment sender, which calls it.
 //
-// NOTE: Fee depends on amount because of the number amount of inputs
-// which has to be used to construct the transaction.
-func (c *Connector) EstimateFee(amount string) (decimal.Decimal, error) {
-	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
-		common.GetFunctionName(), c.cfg.Metrics)
-	defer m.Finish()
+// NOTE: Should be called under the send mutex.
+func (c *Connector) sendPayment(payment *connectors.Payment) (
+	*connectors.Payment, error) {
+
+	if _, ok := payment.Detail.(*connectors.BatchedTxDetails); ok {
+		return c.sendBatchTx(payment)
+	}
+
+	tx, err := paymentTx(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.client.SendRawTransaction(tx); err != nil {
+		return nil, convertRPCError(c.client.DaemonName(), err,
+			fmt.Sprintf("unable to send payment(%v)", payment.PaymentID))
+	}
 
-	// Estimate fee for the median transaction size of 225 bytes.
-	// TODO(andrew.shvv) Use amount to construct actual transaction and
-	// calculate its size.
-	size := decimal.New(225, 0)
+	bitcoind.SetSentHeight(payment, atomic.LoadInt64(&c.syncedHeight))
+	payment.Status = connectors.Pending
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return nil, errors.Errorf("unable update payment(%v) status to "+
+			"pending: %v", payment.PaymentID, err)
+	}
 
-	feeRateSatoshiPerByte := c.getFeeRate()
-	feeInSatoshis := feeRateSatoshiPerByte.Mul(size)
-	feeInBitcoin := feeInSatoshis.Div(satoshiPerBitcoin)
+	c.log.Infof("Send payment %v", spew.Sdump(payment))
 
-	return feeInBitcoin.Round(8), nil
+	return payment, nil
 }
 
-// getFeeRate estimates the approximate rate in sat/byte needed for a
-// transaction to begin confirmation within 2 blocks if possible.
+// lookupPayment checks whether transaction of the payment is already known
+// by the wallet, so that it wouldn't be sent twice. If so, payment is marked
+// as pending.
 //
-// NOTE: Uses virtual transaction size as defined in BIP 141
-// (witness data is discounted).
-func (c *Connector) getFeeRate() decimal.Decimal {
+// NOTE: Should be called under the send mutex.
+func (c *Connector) lookupPayment(payment *connectors.Payment) (
+	*connectors.Payment, error) {
+
+	if _, ok := payment.Detail.(*connectors.BatchedTxDetails); ok {
+		return c.lookupBatchTx(payment)
+	}
+
+	tx, err := paymentTx(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash := tx.TxHash()
+	if _, err := c.client.GetTransaction(&txHash); err != nil {
+		// Error is returned by the wallet if transaction is unknown.
+		err = connectors.WrapDaemonError(c.client.DaemonName(), err)
+		if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	bitcoind.SetSentHeight(payment, atomic.LoadInt64(&c.syncedHeight))
+	payment.Status = connectors.Pending
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return nil, errors.Errorf("unable update payment(%v) status to "+
+			"pending: %v", payment.PaymentID, err)
+	}
+
+	return payment, nil
+}
+
+// abandonPayment unlocks inputs of the payment which has been marked as
+// failed by the payment sender. Payment which has been sent by the wallet
+// of the daemon has no locked inputs, instead the rest of its batch is
+// failed.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) abandonPayment(payment *connectors.Payment) {
+	if _, ok := payment.Detail.(*connectors.BatchedTxDetails); ok {
+		c.abandonBatch(payment)
+		return
+	}
+
+	tx, err := paymentTx(payment)
+	if err != nil {
+		c.log.Errorf("unable to unlock inputs of payment(%v): %v",
+			payment.PaymentID, err)
+		return
+	}
+
+	bitcoind.UnlockInputs(c.client, txInputs(tx), c.log)
+}
+
+// CancelPayment cancels previously created waiting payment, and unlocks
+// inputs of its transaction. Payment which is queued to be sent in the
+// batch transaction is removed from the queue.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) CancelPayment(paymentID string) (*connectors.Payment, error) {
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	feeRate, err := c.client.EstimateFee()
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, err := connectors.WaitingPayment(c.cfg.PaymentStore, paymentID,
+		c.cfg.Asset, connectors.Blockchain)
 	if err != nil {
-		if c.cfg.Net == "mainnet" {
-			// In case of mainnet such situation happens rarely for that
-			// reason we should notify about that. But in testnet and simnet
-			// usually not enough data to make fee proper fee estimation.
-			c.log.Errorf("unable get fee rate: %v", err)
-			m.AddError(metrics.HighSeverity)
-		}
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
 
-		// Take fee rate from config, which was initialised on the start of
-		// payserver.
-		feeRateSatoshiPerByte := decimal.New(int64(c.cfg.FeePerByte), 0).Round(8)
-		if feeRateSatoshiPerByte.LessThan(minimumFeeRate) {
-			feeRateSatoshiPerByte = minimumFeeRate
+	// Queued payments have no transaction yet, so they are just removed
+	// from the queue.
+	if _, ok := payment.Detail.(*connectors.BatchedTxDetails); !ok {
+		tx, err := paymentTx(payment)
+		if err != nil {
+			m.AddError(metrics.HighSeverity)
+			return nil, err
 		}
 
-		c.log.Debugf("Get fee rate(%v sat/byte) from config", feeRateSatoshiPerByte)
-		return feeRateSatoshiPerByte
+		bitcoind.UnlockInputs(c.client, txInputs(tx), c.log)
 	}
 
-	// Initially rate is returned as BTC/Kb, for convience we convert it
-	// to sat/byte.
-	feeRateBtcPerKiloByte := decimal.NewFromFloat(feeRate)
-	bytesInKiloByte := decimal.NewFromFloat(1024)
-	feeRateSatoshiPerKiloByte := feeRateBtcPerKiloByte.Mul(satoshiPerBitcoin)
-	feeRateSatoshiPerByte := feeRateSatoshiPerKiloByte.Div(bytesInKiloByte).Round(8)
-
-	if feeRateSatoshiPerByte.LessThan(minimumFeeRate) {
-		feeRateSatoshiPerByte = minimumFeeRate
+	payment.Status = connectors.Failed
+	payment.FailureReason = connectors.CanceledReason
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("unable update payment(%v) status to "+
+			"fail: %v", payment.PaymentID, err)
 	}
 
-	c.log.Debugf("Get fee rate(%v sat/byte) from daemon",
-		feeRateSatoshiPerByte)
+	c.log.Infof("Cancel payment %v", spew.Sdump(payment))
 
-	return feeRateSatoshiPerByte
+	return payment, nil
 }
 
-// syncPaymentState synchronise state of the payment and put in the db,
-// in order to avoid fetching directly from bitcoind daemon.
-func (c *Connector) syncPaymentState() error {
+// BumpFee replaces transaction of the pending payment by the transaction
+// paying the given fee rate in sat/byte, or the fee rate of the fee bump
+// policy priority if it is zero. Transaction which has been sent by the
+// wallet of the daemon is replaced by the wallet, and all payments which
+// have been sent in it are updated with the replacement.
+//
+// NOTE: Part of the connectors.FeeBumper interface.
+func (c *Connector) BumpFee(paymentID string,
+	feeRate decimal.Decimal) (*connectors.Payment, error) {
 	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	allTXs, err := c.cfg.RPCClient.ListTransactionByLabel(allAccounts, math.MaxInt16, 0)
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, err := c.replacer.BumpFee(paymentID, feeRate)
 	if err != nil {
-		m.AddError(metrics.HighSeverity)
-		return err
+		m.AddError(metrics.MiddleSeverity)
+		return nil, err
 	}
 
-	txCounter, err := c.cfg.StateStore.LastTxCounter()
+	return payment, nil
+}
+
+// waitingPayment returns waiting payment of this connector and its
+// transaction.
+func (c *Connector) waitingPayment(paymentID string) (*connectors.Payment,
+	*wire.MsgTx, error) {
+
+	payment, err := connectors.WaitingPayment(c.cfg.PaymentStore, paymentID,
+		c.cfg.Asset, connectors.Blockchain)
 	if err != nil {
-		m.AddError(metrics.HighSeverity)
-		return errors.Errorf("unable get last tx counter: %v", err)
+		return nil, nil, err
 	}
 
-	if len(allTXs) < txCounter {
-		return errors.Errorf("unexpected behaviour: number of downloaded " +
-			"transaction less than previously sync counter")
+	if _, ok := payment.Detail.(*connectors.BatchedTxDetails); ok {
+		return nil, nil, errors.Errorf("payment(%v) is queued to be sent "+
+			"in batch transaction", paymentID)
 	}
 
-	// TODO(andrew.shvv) it will stop syncing if blockchain daemon is cleaned
-	//  and started again
-	newTXS := allTXs[txCounter:]
-	if len(newTXS) == 0 {
-		return nil
+	tx, err := paymentTx(payment)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	c.log.Debugf("sync %v payments, last tx counter was %v",
-		len(newTXS), txCounter)
+	return payment, tx, nil
+}
 
-	for _, tx := range newTXS {
-		var status connectors.PaymentStatus
-		if tx.Confirmations >= int64(c.cfg.MinConfirmations) {
-			status = connectors.Completed
-		} else {
-			status = connectors.Pending
-		}
+// paymentTx returns transaction which has been generated for the payment.
+func paymentTx(payment *connectors.Payment) (*wire.MsgTx, error) {
+	details, ok := payment.Detail.(*connectors.GeneratedTxDetails)
+	if !ok {
+		return nil, errors.Errorf("unable get details for payment(%v)",
+			payment.PaymentID)
+	}
 
-		var direction connectors.PaymentDirection
-		switch tx.Category {
-		case "send":
-			direction = connectors.Outgoing
-		case "receive":
-			direction = connectors.Incoming
-		default:
-			c.log.Errorf("unknown tx category: %v", tx.Category)
-			m.AddError(metrics.HighSeverity)
+	tx := new(wire.MsgTx)
+	if err := tx.Deserialize(bytes.NewReader(details.RawTx)); err != nil {
+		return nil, errors.Errorf("unable to deserialize raw tx: %v", err)
+	}
 
-			txCounter++
-			err := c.cfg.StateStore.PutLastSyncedTxCounter(txCounter)
-			if err != nil {
-				return errors.Errorf("unable save last synced tx "+
-					"counter: %v", err)
-			}
+	return tx, nil
+}
 
-			continue
-		}
+// DecodeAddress takes the blockchain address and ensure its validity.
+func (c *Connector) ValidateAddress(address string) error {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
 
-		fee := decimal.Zero
-		if tx.Fee != nil {
-			fee = decimal.NewFromFloat(*tx.Fee).Abs().Round(8)
-		}
+	_, err := decodeAddress(c.cfg.Asset, address, c.netParams.Name)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return &connectors.ErrInvalidReceipt{Reason: err}
+	}
 
-		p := &connectors.Payment{
-			UpdatedAt: connectors.ConvertTimeToMilliSeconds(time.Now()),
-			Status:    status,
-			Direction: direction,
-			System:    connectors.External,
-			Receipt:   tx.Address,
-			Asset:     c.cfg.Asset,
-			Media:     connectors.Blockchain,
-			Amount:    decimal.NewFromFloat(tx.Amount).Abs().Round(8),
-			MediaFee:  fee,
-			MediaID:   tx.TxID,
-		}
+	return nil
+}
 
-		p.PaymentID, err = p.GenPaymentID()
+// EstimateFee estimate fee for the transaction with the given sending
+// amount.
+//
+// NOTE: Fee depends on amount because of the number amount of inputs
+// which has to be used to construct the transaction.
+func (c *Connector) EstimateFee(amount string,
+	priority connectors.FeePriority) (decimal.Decimal, error) {
+	estimate, err := c.EstimateTxFee("", amount, priority)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return estimate.Fee, nil
+}
+
+// EstimateTxFee estimates fee of the transaction which sends the given
+// amount to the address, by simulating the coin selection over the current
+// unspent outputs of the wallet.
+//
+// NOTE: Part of the connectors.TxFeeEstimator interface.
+func (c *Connector) EstimateTxFee(address, amount string,
+	priority connectors.FeePriority) (*connectors.FeeEstimate, error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	var receiver btcutil.Address
+	if address != "" {
+		var err error
+		receiver, err = decodeAddress(c.cfg.Asset, address,
+			c.netParams.Name)
 		if err != nil {
-			m.AddError(metrics.HighSeverity)
-			return errors.Errorf("unable to generate payment id, txid(%v): %v",
-				tx.TxID, err)
+			m.AddError(metrics.LowSeverity)
+			return nil, &connectors.ErrInvalidReceipt{Reason: err}
 		}
+	}
 
-		if _, err := c.cfg.PaymentStore.PaymentByID(p.PaymentID); err != nil {
-			c.log.Infof("New payment(%v) has been found: %v", p.PaymentID,
-				spew.Sdump(p))
+	amt, err := decimal.NewFromString(amount)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInvalidAmount{Amount: amount, Reason: err}
+	}
+
+	if amt.IsNegative() {
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInvalidAmount{
+			Amount: amount,
+			Reason: errors.New("amount shouldn't be negative"),
 		}
+	}
+
+	unspent, err := c.client.ListUnspentMinMax(c.cfg.MinConfirmations,
+		math.MaxInt32)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, convertRPCError(c.client.DaemonName(), err,
+			"unable to list unspent")
+	}
+
+	feeRatePerByte := uint64(c.getFeeRate(priority).Ceil().IntPart())
+	selection, err := bitcoind.SimulateSelection(largestFirst,
+		feeRatePerByte, decAmount2Sat(amt), []btcutil.Address{receiver},
+		bitcoind.SpendableInputs(unspent))
+	if err != nil {
+		return nil, err
+	}
+
+	return &connectors.FeeEstimate{
+		Fee:    sat2DecAmount(selection.Fee),
+		Inputs: len(selection.Inputs),
+		VSize:  selection.VSize,
+	}, nil
+}
+
+// getFeeRate returns current rate in sat/byte of the given priority, which
+// is estimated by the fee oracle.
+func (c *Connector) getFeeRate(priority connectors.FeePriority) decimal.Decimal {
+	return c.oracle.FeeRate(priority)
+}
+
+// FeeRates returns current fee rates of all priorities.
+//
+// NOTE: Part of the connectors.FeeRatesProvider interface.
+func (c *Connector) FeeRates() []*connectors.FeeRate {
+	return c.oracle.FeeRates()
+}
 
-		if err := c.cfg.PaymentStore.SavePayment(p); err != nil {
+// estimateFeeRate estimates the approximate rate in sat/byte needed for a
+// transaction to begin confirmation within the given number of blocks.
+//
+// NOTE: Uses virtual transaction size as defined in BIP 141
+// (witness data is discounted).
+func (c *Connector) estimateFeeRate(confTarget uint32) (decimal.Decimal,
+	error) {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	feeRate, err := c.client.EstimateFee(confTarget)
+	if err != nil {
+		if c.cfg.Net == "mainnet" {
+			// In case of mainnet such situation happens rarely for that
+			// reason we should notify about that. But in testnet and simnet
+			// usually not enough data to make fee proper fee estimation.
+			c.log.Errorf("unable get fee rate for %v blocks: %v",
+				confTarget, err)
 			m.AddError(metrics.HighSeverity)
-			return errors.Errorf("unable to save payment(%v): %v",
-				p.PaymentID, err)
 		}
 
-		// Increment tx synced counter only on confirmed transaction,
-		// so that we updated pending transaction earlier.
-		if tx.Confirmations >= int64(c.cfg.MinConfirmations) {
-			c.log.Infof("Payment(%v) is completed: %v", p.PaymentID,
-				spew.Sdump(p))
-
-			txCounter++
-			err := c.cfg.StateStore.PutLastSyncedTxCounter(txCounter)
-			if err != nil {
-				return errors.Errorf("unable save last synced tx "+
-					"counter: %v", err)
-			}
-		}
+		return decimal.Zero, err
 	}
 
-	return nil
+	// Initially rate is returned as BTC/Kb, for convience we convert it
+	// to sat/byte.
+	feeRateBtcPerKiloByte := decimal.NewFromFloat(feeRate)
+	bytesInKiloByte := decimal.NewFromFloat(1024)
+	feeRateSatoshiPerKiloByte := feeRateBtcPerKiloByte.Mul(satoshiPerBitcoin)
+	feeRateSatoshiPerByte := feeRateSatoshiPerKiloByte.Div(bytesInKiloByte).Round(8)
+
+	c.log.Debugf("Get fee rate(%v sat/byte) for %v blocks from daemon",
+		feeRateSatoshiPerByte, confTarget)
+
+	return feeRateSatoshiPerByte, nil
 }
 
 // reportMetrics is used to report necessary health metrics about internal
@@ -0,0 +1,93 @@
+package bitcoind_simple
+
+import (
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/bitlum/connector/connectors/rpc/bitcoin"
+	"github.com/bitlum/connector/connectors/rpc/bitcoincash"
+	"github.com/bitlum/connector/connectors/rpc/dash"
+	"github.com/bitlum/connector/connectors/rpc/litecoin"
+	"github.com/go-errors/errors"
+)
+
+// backendName is the name of the connector backend, which sends payments
+// with sendtoaddress and lets the daemon to select the inputs. It is also
+// the default backend of the bitcoin-like assets.
+const backendName = "simple"
+
+func init() {
+	register(connectors.BTC, func(cfg bitcoin.ClientConfig) (rpc.Client, error) {
+		cfg.Name = "bitcoind"
+		return bitcoin.NewClient(cfg)
+	})
+
+	register(connectors.BCH, func(cfg bitcoin.ClientConfig) (rpc.Client, error) {
+		cfg.Name = "bitcoinabc"
+		return bitcoincash.NewClient(bitcoincash.ClientConfig(cfg))
+	})
+
+	register(connectors.DASH, func(cfg bitcoin.ClientConfig) (rpc.Client, error) {
+		cfg.Name = "dashd"
+		return dash.NewClient(dash.ClientConfig(cfg))
+	})
+
+	register(connectors.LTC, func(cfg bitcoin.ClientConfig) (rpc.Client, error) {
+		cfg.Name = "litecoind"
+		return litecoin.NewClient(litecoin.ClientConfig(cfg))
+	})
+}
+
+// register registers factory of the asset both as the default one and
+// under the backend name.
+func register(asset connectors.Asset, newClient func(cfg bitcoin.ClientConfig) (
+	rpc.Client, error)) {
+
+	factory := newFactory(newClient)
+	connectors.RegisterFactory(asset, connectors.Blockchain, factory)
+	connectors.RegisterBackendFactory(asset, connectors.Blockchain,
+		backendName, factory)
+}
+
+// newFactory returns connector factory which creates rpc client of the
+// daemon with the given function.
+func newFactory(newClient func(cfg bitcoin.ClientConfig) (rpc.Client,
+	error)) connectors.Factory {
+
+	return func(cfg *connectors.FactoryConfig) (connectors.Connector, error) {
+		client, err := newClient(bitcoin.ClientConfig{
+			Logger:   cfg.RPCLogger,
+			Asset:    cfg.Asset,
+			RPCHost:  cfg.Daemon.Host,
+			RPCPort:  cfg.Daemon.Port,
+			User:     cfg.Daemon.User,
+			Password: cfg.Daemon.Password,
+		})
+		if err != nil {
+			return nil, errors.Errorf("unable to create %v rpc client: %v",
+				cfg.Asset, err)
+		}
+
+		return NewConnector(&Config{
+			Net:                 cfg.Net,
+			MinConfirmations:    cfg.Daemon.MinConfirmations,
+			RPCClient:           client,
+			Asset:               cfg.Asset,
+			FeePerByte:          cfg.Daemon.FeePerUnit,
+			Logger:              cfg.Logger,
+			Metrics:             cfg.Metrics,
+			StateStore:          cfg.Storage.ConnectorStateStorage(cfg.Asset),
+			PaymentStore:        cfg.PaymentStore,
+			RetryPolicy:         cfg.Daemon.SendRetry,
+			AttemptsStorage:     cfg.Storage.AttemptsStorage(),
+			FeePolicy:           cfg.Daemon.FeePolicy,
+			FeeBumpPolicy:       cfg.Daemon.FeeBump,
+			ReplacementsStore:   cfg.Storage.ReplacementsStorage(),
+			BatchPolicy:         cfg.Daemon.Batch,
+			BatchesStore:        cfg.Storage.BatchesStorage(),
+			DerivationPolicy:    cfg.Daemon.Derivation,
+			DerivationStore:     cfg.Storage.DerivationStorage(),
+			TreasuryPolicy:      cfg.Daemon.Treasury,
+			ConsolidationPolicy: cfg.Daemon.Consolidation,
+		})
+	}
+}
@@ -0,0 +1,417 @@
+package bitcoind_simple
+
+import (
+	"sync/atomic"
+
+	"github.com/bitlum/connector/common"
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/daemons/bitcoind"
+	"github.com/bitlum/connector/metrics"
+	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/bitlum/go-bitcoind-rpc/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// syncPaymentState synchronise state of the payment and put in the db,
+// in order to avoid fetching directly from bitcoind daemon.
+//
+// Wallet transactions are fetched with listsinceblock starting from the
+// last synced block. Daemon returns as the last block the one which has the
+// required number of confirmations, so that transactions which are not yet
+// confirmed are returned on the next sync again. If the last synced block
+// has been disconnected by reorganisation, sync is continued from the fork
+// point and all pending payments are re-evaluated. Completed payments which
+// transactions have been disconnected are returned by the daemon with less
+// confirmations, and they are moved back to pending, or to reverted if
+// transaction has been double spent.
+func (c *Connector) syncPaymentState() error {
+	m := crypto.NewMetric(c.client.DaemonName(), string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	lastHash, err := c.fetchLastSyncedBlockHash()
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return err
+	}
+
+	resp, err := c.client.ListSinceBlock(lastHash, c.cfg.MinConfirmations)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return errors.Errorf("unable to list transactions since block(%v): "+
+			"%v", lastHash, err)
+	}
+
+	if len(resp.Transactions) != 0 {
+		c.log.Debugf("Sync %v transactions since block(%v)",
+			len(resp.Transactions), lastHash)
+	}
+
+	for _, tx := range resp.Transactions {
+		if err := c.syncTransaction(tx); err != nil {
+			m.AddError(metrics.HighSeverity)
+			return err
+		}
+	}
+
+	if lastHash != nil && resp.LastBlock == lastHash.String() {
+		return nil
+	}
+
+	// Block is saved only after all its transactions have been processed,
+	// so that if sync fails they would be processed again.
+	err = c.cfg.StateStore.PutLastSyncedHash([]byte(resp.LastBlock))
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return errors.Errorf("unable to put block hash in db: %v", err)
+	}
+
+	c.log.Debugf("Last synced block(%v)", resp.LastBlock)
+
+	return nil
+}
+
+// fetchLastSyncedBlockHash returns hash of the last synced block which is
+// in the main chain, or nil if connector hasn't synced any block yet. If
+// the last synced block has been disconnected by reorganisation, the hash of
+// the fork point is returned, and the pending and reverted payments are
+// re-evaluated.
+func (c *Connector) fetchLastSyncedBlockHash() (*chainhash.Hash, error) {
+	// Error is returned by the storage if hash hasn't been saved yet.
+	data, _ := c.cfg.StateStore.LastSyncedHash()
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	lastHash, err := chainhash.NewHashFromStr(string(data))
+	if err != nil {
+		return nil, errors.Errorf("unable decode block hash: %v", err)
+	}
+
+	hash := lastHash
+	for {
+		block, err := c.client.GetBlockVerboseByHash(hash)
+		if err != nil {
+			return nil, errors.Errorf("unable to get block(%v): %v", hash, err)
+		}
+
+		// Daemon returns negative number of confirmations for the blocks
+		// which are not in the main chain.
+		if block.Confirmations >= 0 {
+			atomic.StoreInt64(&c.syncedHeight, block.Height)
+			break
+		}
+
+		c.log.Warnf("Block(%v) with height(%v) has been disconnected by "+
+			"reorganisation", block.Hash, block.Height)
+
+		hash, err = chainhash.NewHashFromStr(block.PreviousHash)
+		if err != nil {
+			return nil, errors.Errorf("unable decode previous hash of "+
+				"block(%v): %v", block.Hash, err)
+		}
+	}
+
+	if hash.IsEqual(lastHash) {
+		return lastHash, nil
+	}
+
+	c.log.Warnf("Reorganisation has been detected, last synced block(%v), "+
+		"fork block(%v)", lastHash, hash)
+
+	if err := c.reevaluatePayments(); err != nil {
+		return nil, errors.Errorf("unable to re-evaluate payments after "+
+			"reorganisation: %v", err)
+	}
+
+	return hash, nil
+}
+
+// reevaluatePayments updates status of all pending and reverted payments
+// of the connector according to the current number of confirmations of
+// their transactions.
+func (c *Connector) reevaluatePayments() error {
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	for _, status := range []connectors.PaymentStatus{connectors.Pending,
+		connectors.Reverted} {
+
+		payments, err := c.cfg.PaymentStore.ListPayments(c.cfg.Asset,
+			status, "", connectors.Blockchain, "")
+		if err != nil {
+			return errors.Errorf("unable to list %v payments: %v", status,
+				err)
+		}
+
+		for _, payment := range payments {
+			if err := c.reevaluatePayment(payment); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// reevaluatePayment updates status of the payment according to the current
+// number of confirmations of its transaction.
+func (c *Connector) reevaluatePayment(payment *connectors.Payment) error {
+	txHash, err := chainhash.NewHashFromStr(payment.MediaID)
+	if err != nil {
+		c.log.Errorf("unable decode tx id of payment(%v): %v",
+			payment.PaymentID, err)
+		return nil
+	}
+
+	tx, err := c.client.GetTransaction(txHash)
+	if err != nil {
+		err = connectors.WrapDaemonError(c.client.DaemonName(), err)
+		if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+			return err
+		}
+
+		// Transaction of the payment which is being retried might be
+		// unknown for the wallet.
+		c.log.Debugf("Unable to get tx(%v) of payment(%v): %v",
+			payment.MediaID, payment.PaymentID, err)
+		return nil
+	}
+
+	return c.updatePayment(payment, tx.Confirmations)
+}
+
+// syncTransaction saves wallet transaction as the payment. If payment
+// already exists, only its status is updated, so that information which
+// has been saved on payment creation is kept.
+func (c *Connector) syncTransaction(tx btcjson.ListTransactionsResult) error {
+	var direction connectors.PaymentDirection
+	switch tx.Category {
+	case "send":
+		direction = connectors.Outgoing
+	case "receive":
+		direction = connectors.Incoming
+	default:
+		c.log.Errorf("unknown category(%v) of tx(%v)", tx.Category, tx.TxID)
+		return nil
+	}
+
+	fee := decimal.Zero
+	if tx.Fee != nil {
+		fee = decimal.NewFromFloat(*tx.Fee).Abs().Round(8)
+	}
+
+	payment := &connectors.Payment{
+		Direction: direction,
+		System:    connectors.External,
+		Receipt:   tx.Address,
+		Asset:     c.cfg.Asset,
+		Media:     connectors.Blockchain,
+		Amount:    decimal.NewFromFloat(tx.Amount).Abs().Round(8),
+		MediaFee:  fee,
+		MediaID:   tx.TxID,
+	}
+
+	// Deposits and change are received on the same account, but outputs
+	// of the internal payments which are sent to the wallet itself, e.g.
+	// consolidation of the unspent outputs, shouldn't be treated as
+	// deposits.
+	if direction == connectors.Incoming && bitcoind.InternalPaymentID(
+		c.cfg.PaymentStore, tx.TxID, tx.Address) != "" {
+		payment.System = connectors.Internal
+	}
+
+	var err error
+	payment.PaymentID, err = payment.GenPaymentID()
+	if err != nil {
+		return errors.Errorf("unable to generate payment id, txid(%v): %v",
+			tx.TxID, err)
+	}
+
+	// Id of the outgoing payment is derived from its original transaction,
+	// which might have been replaced by the one paying bigger fee. Id of
+	// the batched payment is derived from its queue id, so it is taken
+	// from the index of the batch transactions.
+	var batchedID string
+	if direction == connectors.Outgoing {
+		batchedID, err = c.cfg.BatchesStore.BatchedPaymentID(c.cfg.Asset,
+			tx.TxID, tx.Address)
+		if err != nil {
+			return errors.Errorf("unable to get batched payment of "+
+				"tx(%v): %v", tx.TxID, err)
+		}
+
+		// Payments of the batch which response has been lost are found
+		// by the comment of the transaction.
+		if batchedID == "" && tx.Comment != "" {
+			batchedID, err = c.resolveBatchTx(tx)
+			if err != nil {
+				return err
+			}
+		}
+
+		if batchedID != "" {
+			payment.PaymentID = batchedID
+		} else {
+			externalID := payment.PaymentID
+			payment.PaymentID, err = c.replacer.ResolvePaymentID(tx.TxID,
+				externalID)
+			if err != nil {
+				return err
+			}
+
+			// Internal payments which have been created by the connector,
+			// e.g. sweeps to the cold storage, have their own ids.
+			if payment.PaymentID == externalID {
+				internalID := bitcoind.InternalPaymentID(c.cfg.PaymentStore,
+					tx.TxID, tx.Address)
+				if internalID != "" {
+					payment.PaymentID = internalID
+					payment.System = connectors.Internal
+				}
+			}
+		}
+	}
+
+	// Payment is updated under the send mutex, so that sync wouldn't
+	// overwrite changes which are made by the sending of the payment.
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	if stored, err := c.cfg.PaymentStore.PaymentByID(
+		payment.PaymentID); err == nil {
+		// Batched payment isn't updated with the transaction, if connector
+		// has been stopped right after sending the batch.
+		if batchedID != "" && stored.MediaID == "" {
+			stored.MediaID = tx.TxID
+		}
+
+		// Wallet transaction, fee of which has been bumped, might have
+		// been mined instead of its replacement.
+		_, sentByWallet := stored.Detail.(*connectors.BatchedTxDetails)
+		if sentByWallet && stored.MediaID != tx.TxID {
+			if tx.Confirmations <= 0 {
+				return nil
+			}
+
+			stored, err = c.restoreBatchTx(stored, tx.TxID)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Replaced transactions are conflicted, and they shouldn't revert
+		// the payment, unless one of them has been mined instead of the
+		// replacement.
+		current, err := c.replacer.SyncTx(stored, tx.TxID,
+			tx.Confirmations > 0)
+		if err != nil {
+			return err
+		} else if !current {
+			return nil
+		}
+
+		// Waiting payments are not yet sent, and failed ones are final.
+		// Completed payment is returned with less confirmations only if its
+		// transaction has been affected by the reorganisation.
+		switch stored.Status {
+		case connectors.Pending, connectors.Completed, connectors.Reverted:
+		default:
+			return nil
+		}
+
+		return c.updatePayment(stored, tx.Confirmations)
+	}
+
+	c.log.Infof("New payment(%v) has been found: %v", payment.PaymentID,
+		spew.Sdump(payment))
+
+	return c.updatePayment(payment, tx.Confirmations)
+}
+
+// resolveBatchTx returns id of the payment which has been sent to the
+// address of the transaction in the batch with id from the comment of the
+// transaction, or empty string if there is no such batch. Batch might not
+// have been updated with its transaction if connector hasn't received the
+// response of the daemon, in this case payments of the batch are updated.
+func (c *Connector) resolveBatchTx(tx btcjson.ListTransactionsResult) (
+	string, error) {
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	batch, err := c.batchPayments(tx.Comment)
+	if err != nil || len(batch) == 0 {
+		return "", err
+	}
+
+	if batch[0].MediaID == "" {
+		txHash, err := chainhash.NewHashFromStr(tx.TxID)
+		if err != nil {
+			return "", errors.Errorf("unable decode tx id(%v): %v", tx.TxID,
+				err)
+		}
+
+		if err := c.attachBatch(batch, txHash); err != nil {
+			return "", err
+		}
+	}
+
+	for _, payment := range batch {
+		if payment.Receipt == tx.Address {
+			return payment.PaymentID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// updatePayment sets status of the payment according to the number of
+// confirmations of its transaction, and saves payment if status has been
+// changed. Transaction with negative confirmations conflicts with the one in
+// the main chain, i.e. its inputs have been double spent.
+func (c *Connector) updatePayment(payment *connectors.Payment,
+	confirmations int64) error {
+
+	status := connectors.Pending
+	switch {
+	case confirmations >= int64(c.cfg.MinConfirmations):
+		status = connectors.Completed
+	case confirmations < 0:
+		status = connectors.Reverted
+	}
+
+	if payment.Status == status {
+		return nil
+	}
+
+	previousStatus := payment.Status
+	payment.Status = status
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return errors.Errorf("unable to save payment(%v): %v",
+			payment.PaymentID, err)
+	}
+
+	switch {
+	case previousStatus == connectors.Completed:
+		c.log.Errorf("Completed payment(%v) has been moved to %v by chain "+
+			"reorganisation: %v", payment.PaymentID, status,
+			spew.Sdump(payment))
+		c.cfg.Metrics.AddReorganisedPayment(c.client.DaemonName(),
+			string(c.cfg.Asset), string(status))
+
+	case status == connectors.Completed:
+		c.log.Infof("Payment(%v) is completed: %v", payment.PaymentID,
+			spew.Sdump(payment))
+
+	case status == connectors.Reverted:
+		c.log.Warnf("Payment(%v) has been reverted, its transaction "+
+			"conflicts with the one in the main chain", payment.PaymentID)
+	}
+
+	return nil
+}
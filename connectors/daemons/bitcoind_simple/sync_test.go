@@ -0,0 +1,655 @@
+package bitcoind_simple
+
+import (
+	"testing"
+
+	"github.com/bitlum/connector/common"
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/daemons/bitcoind"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/bitlum/go-bitcoind-rpc/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btclog"
+	"github.com/btcsuite/btcutil"
+	"github.com/go-errors/errors"
+)
+
+// stubChain is the rpc client which implements only methods which are used
+// by the payments synchronisation.
+type stubChain struct {
+	rpc.Client
+
+	// blocks is the map of block hash to block, blocks which are not in
+	// the main chain have negative confirmations.
+	blocks map[string]*rpc.BlockVerboseResp
+
+	// txs is the list of wallet transactions, confirmations of which are
+	// returned on sync.
+	txs []btcjson.ListTransactionsResult
+
+	lastBlock string
+	sinceHash *chainhash.Hash
+}
+
+func (c *stubChain) DaemonName() string {
+	return "bitcoind"
+}
+
+func (c *stubChain) ListSinceBlock(hash *chainhash.Hash,
+	targetConfirmations int) (*btcjson.ListSinceBlockResult, error) {
+
+	c.sinceHash = hash
+	return &btcjson.ListSinceBlockResult{
+		Transactions: c.txs,
+		LastBlock:    c.lastBlock,
+	}, nil
+}
+
+func (c *stubChain) GetBlockVerboseByHash(hash *chainhash.Hash) (
+	*rpc.BlockVerboseResp, error) {
+
+	block, ok := c.blocks[hash.String()]
+	if !ok {
+		return nil, errors.Errorf("block not found")
+	}
+
+	return block, nil
+}
+
+func (c *stubChain) GetTransaction(hash *chainhash.Hash) (*rpc.Transaction,
+	error) {
+
+	for _, tx := range c.txs {
+		if tx.TxID == hash.String() {
+			return &rpc.Transaction{
+				TxID:          tx.TxID,
+				Confirmations: tx.Confirmations,
+			}, nil
+		}
+	}
+
+	return nil, errors.Errorf("Invalid or non-wallet transaction id")
+}
+
+// memoryPaymentsStore implements only methods of payments store which are
+// used by the payments synchronisation and sending.
+type memoryPaymentsStore struct {
+	connectors.PaymentsStore
+	payments map[string]connectors.Payment
+}
+
+func (s *memoryPaymentsStore) PaymentByID(id string) (*connectors.Payment,
+	error) {
+
+	payment, ok := s.payments[id]
+	if !ok {
+		return nil, connectors.PaymentNotFound
+	}
+
+	return &payment, nil
+}
+
+func (s *memoryPaymentsStore) PaymentByIdempotencyKey(key string) (
+	*connectors.Payment, error) {
+
+	for _, payment := range s.payments {
+		if payment.IdempotencyKey == key {
+			return &payment, nil
+		}
+	}
+
+	return nil, connectors.PaymentNotFound
+}
+
+func (s *memoryPaymentsStore) SavePayment(payment *connectors.Payment) error {
+	s.payments[payment.PaymentID] = *payment
+	return nil
+}
+
+func (s *memoryPaymentsStore) ListPayments(asset connectors.Asset,
+	status connectors.PaymentStatus, direction connectors.PaymentDirection,
+	media connectors.PaymentMedia, system connectors.PaymentSystem) (
+	[]*connectors.Payment, error) {
+
+	var payments []*connectors.Payment
+	for _, payment := range s.payments {
+		if payment.Status == status {
+			p := payment
+			payments = append(payments, &p)
+		}
+	}
+
+	return payments, nil
+}
+
+// memoryStateStorage is a state storage which keeps hash in memory.
+type memoryStateStorage struct {
+	hash []byte
+}
+
+func (s *memoryStateStorage) PutLastSyncedHash(hash []byte) error {
+	s.hash = hash
+	return nil
+}
+
+func (s *memoryStateStorage) LastSyncedHash() ([]byte, error) {
+	if s.hash == nil {
+		return nil, errors.New("hash not found")
+	}
+
+	return s.hash, nil
+}
+
+// memoryReplacementsStorage is a replacements storage which keeps index in
+// memory.
+type memoryReplacementsStorage struct {
+	payments map[string]string
+}
+
+func (s *memoryReplacementsStorage) PutReplacement(asset connectors.Asset,
+	txID, paymentID string) error {
+	s.payments[txID] = paymentID
+	return nil
+}
+
+func (s *memoryReplacementsStorage) ReplacedPaymentID(asset connectors.Asset,
+	txID string) (string, error) {
+	return s.payments[txID], nil
+}
+
+// memoryBatchesStorage is a batches storage which keeps index in memory.
+type memoryBatchesStorage struct {
+	payments map[string]string
+}
+
+func (s *memoryBatchesStorage) PutBatch(asset connectors.Asset, txID string,
+	payments map[string]string) error {
+	for receipt, paymentID := range payments {
+		s.payments[txID+":"+receipt] = paymentID
+	}
+	return nil
+}
+
+func (s *memoryBatchesStorage) BatchedPaymentID(asset connectors.Asset, txID,
+	receipt string) (string, error) {
+	return s.payments[txID+":"+receipt], nil
+}
+
+// memoryAttemptsStorage is an attempts storage which keeps attempts in
+// memory.
+type memoryAttemptsStorage struct {
+	attempts map[string]connectors.PaymentAttempts
+}
+
+func (s *memoryAttemptsStorage) PutAttempts(
+	attempts *connectors.PaymentAttempts) error {
+	s.attempts[attempts.PaymentID] = *attempts
+	return nil
+}
+
+func (s *memoryAttemptsStorage) RemoveAttempts(paymentID string) error {
+	delete(s.attempts, paymentID)
+	return nil
+}
+
+func (s *memoryAttemptsStorage) ListAttempts(asset connectors.Asset,
+	media connectors.PaymentMedia) ([]*connectors.PaymentAttempts, error) {
+
+	var list []*connectors.PaymentAttempts
+	for _, attempts := range s.attempts {
+		a := attempts
+		list = append(list, &a)
+	}
+
+	return list, nil
+}
+
+// blockHash returns hash of the test block with the given number.
+func blockHash(n byte) string {
+	var hash chainhash.Hash
+	hash[0] = n
+	return hash.String()
+}
+
+func newTestConnector(chain *stubChain) (*Connector, *memoryPaymentsStore,
+	*memoryStateStorage) {
+
+	store := &memoryPaymentsStore{
+		payments: make(map[string]connectors.Payment),
+	}
+	state := &memoryStateStorage{}
+
+	c := &Connector{
+		cfg: &Config{
+			MinConfirmations: 3,
+			Asset:            connectors.BTC,
+			Metrics:          crypto.DisabledBackend,
+			StateStore:       state,
+			PaymentStore:     store,
+			BatchesStore: &memoryBatchesStorage{
+				payments: make(map[string]string),
+			},
+		},
+		client: chain,
+		log: &common.NamedLogger{
+			Name:   string(connectors.BTC),
+			Logger: btclog.Disabled,
+		},
+	}
+
+	setTestReplacer(c, &memoryReplacementsStorage{
+		payments: make(map[string]string),
+	})
+
+	c.cfg.AttemptsStorage = &memoryAttemptsStorage{
+		attempts: make(map[string]connectors.PaymentAttempts),
+	}
+	c.sender, _ = connectors.NewPaymentSender(&connectors.PaymentSenderConfig{
+		Asset:        connectors.BTC,
+		Media:        connectors.Blockchain,
+		Storage:      c.cfg.AttemptsStorage,
+		PaymentStore: store,
+		Send:         c.sendPayment,
+		Lookup:       c.lookupPayment,
+		Abandon:      c.abandonPayment,
+		Logger:       btclog.Disabled,
+	})
+
+	return c, store, state
+}
+
+// setTestReplacer sets replacer of the connector with the given storage.
+func setTestReplacer(c *Connector, storage connectors.ReplacementsStorage) {
+	c.replacer, _ = bitcoind.NewReplacer(&bitcoind.ReplacerConfig{
+		Asset:         connectors.BTC,
+		Client:        c.client,
+		PaymentStore:  c.cfg.PaymentStore,
+		Storage:       storage,
+		FeeRate:       c.getFeeRate,
+		SyncedHeight:  func() int64 { return c.syncedHeight },
+		DecodeAddress: func(string) (btcutil.Address, error) { return nil, nil },
+		BumpWalletTx:  c.bumpBatchFee,
+		Locker:        &c.sendMtx,
+		Logger:        btclog.Disabled,
+	})
+}
+
+// TestSyncPaymentState checks that payments are synced from the last
+// synced block, and that existing payments are updated rather than
+// overwritten.
+func TestSyncPaymentState(t *testing.T) {
+	chain := &stubChain{
+		blocks: map[string]*rpc.BlockVerboseResp{
+			blockHash(1): {Hash: blockHash(1), Height: 1, Confirmations: 3},
+		},
+		txs: []btcjson.ListTransactionsResult{{
+			Category:      "receive",
+			Address:       "address",
+			Amount:        0.1,
+			TxID:          blockHash(100),
+			Confirmations: 1,
+		}},
+		lastBlock: blockHash(1),
+	}
+
+	c, store, state := newTestConnector(chain)
+
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if chain.sinceHash != nil {
+		t.Fatalf("first sync should start from the first block")
+	}
+
+	if string(state.hash) != blockHash(1) {
+		t.Fatalf("last block hasn't been saved")
+	}
+
+	if len(store.payments) != 1 {
+		t.Fatalf("wrong number of payments: %v", len(store.payments))
+	}
+
+	var id string
+	for id = range store.payments {
+	}
+
+	payment := store.payments[id]
+	if payment.Status != connectors.Pending {
+		t.Fatalf("payment should be pending, got %v", payment.Status)
+	}
+
+	// Information which has been saved on payment creation should be
+	// kept after the sync.
+	payment.IdempotencyKey = "key"
+	store.payments[id] = payment
+
+	chain.txs[0].Confirmations = 3
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if chain.sinceHash == nil || chain.sinceHash.String() != blockHash(1) {
+		t.Fatalf("sync should continue from the last synced block")
+	}
+
+	payment = store.payments[id]
+	if payment.Status != connectors.Completed ||
+		payment.IdempotencyKey != "key" {
+		t.Fatalf("wrong synced payment: %v", payment)
+	}
+}
+
+// TestSyncPaymentStateReorg checks that after reorganisation sync is
+// continued from the fork point, and that pending payments are
+// re-evaluated.
+func TestSyncPaymentStateReorg(t *testing.T) {
+	chain := &stubChain{
+		blocks: map[string]*rpc.BlockVerboseResp{
+			blockHash(1): {Hash: blockHash(1), Height: 1, Confirmations: 4},
+			blockHash(2): {Hash: blockHash(2), Height: 2, Confirmations: -1,
+				PreviousHash: blockHash(1)},
+		},
+		txs: []btcjson.ListTransactionsResult{{
+			Category:      "send",
+			Address:       "address",
+			Amount:        -0.1,
+			TxID:          blockHash(100),
+			Confirmations: -1,
+		}},
+		lastBlock: blockHash(3),
+	}
+
+	c, store, state := newTestConnector(chain)
+	state.hash = []byte(blockHash(2))
+
+	payment := &connectors.Payment{
+		Status:    connectors.Pending,
+		Direction: connectors.Outgoing,
+		System:    connectors.External,
+		Receipt:   "address",
+		Asset:     connectors.BTC,
+		Media:     connectors.Blockchain,
+		MediaID:   blockHash(100),
+	}
+	payment.PaymentID, _ = payment.GenPaymentID()
+	store.payments[payment.PaymentID] = *payment
+
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if chain.sinceHash == nil || chain.sinceHash.String() != blockHash(1) {
+		t.Fatalf("sync should continue from the fork point, got %v",
+			chain.sinceHash)
+	}
+
+	if c.syncedHeight != 1 {
+		t.Fatalf("wrong synced height: %v", c.syncedHeight)
+	}
+
+	if string(state.hash) != blockHash(3) {
+		t.Fatalf("last block hasn't been saved")
+	}
+
+	synced := store.payments[payment.PaymentID]
+	if synced.Status != connectors.Reverted {
+		t.Fatalf("conflicted payment should be reverted, got %v",
+			synced.Status)
+	}
+}
+
+// reorgMetrics is the metrics backend which keeps statuses of the
+// reorganised payments.
+type reorgMetrics struct {
+	crypto.MockBackend
+	statuses []string
+}
+
+func (m *reorgMetrics) AddReorganisedPayment(daemon, asset, status string) {
+	m.statuses = append(m.statuses, status)
+}
+
+// TestSyncCompletedPaymentReorg checks that completed payment, transaction
+// of which has been affected by reorganisation, is moved back to pending or
+// to reverted, and that alert metric is reported.
+func TestSyncCompletedPaymentReorg(t *testing.T) {
+	chain := &stubChain{
+		blocks: map[string]*rpc.BlockVerboseResp{
+			blockHash(1): {Hash: blockHash(1), Height: 1, Confirmations: 5},
+		},
+		txs: []btcjson.ListTransactionsResult{{
+			Category:      "receive",
+			Address:       "address",
+			Amount:        0.1,
+			TxID:          blockHash(100),
+			Confirmations: 3,
+		}},
+		lastBlock: blockHash(1),
+	}
+
+	c, store, _ := newTestConnector(chain)
+	backend := &reorgMetrics{}
+	c.cfg.Metrics = backend
+
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	var id string
+	for id = range store.payments {
+	}
+
+	if store.payments[id].Status != connectors.Completed {
+		t.Fatalf("payment should be completed")
+	}
+
+	// Transaction has been disconnected and mined again in the other
+	// block.
+	chain.txs[0].Confirmations = 1
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if store.payments[id].Status != connectors.Pending {
+		t.Fatalf("reorganised payment should be pending, got %v",
+			store.payments[id].Status)
+	}
+
+	// Transaction has been double spent.
+	chain.txs[0].Confirmations = -2
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if store.payments[id].Status != connectors.Reverted {
+		t.Fatalf("double spent payment should be reverted, got %v",
+			store.payments[id].Status)
+	}
+
+	if len(backend.statuses) != 1 ||
+		backend.statuses[0] != string(connectors.Pending) {
+		t.Fatalf("wrong reorganisation metrics: %v", backend.statuses)
+	}
+
+	// Transaction has been mined again.
+	chain.txs[0].Confirmations = 3
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if store.payments[id].Status != connectors.Completed {
+		t.Fatalf("payment should be completed again, got %v",
+			store.payments[id].Status)
+	}
+}
+
+// TestSyncReplacedTransaction checks that conflicted transaction, which
+// has been replaced by the one paying bigger fee, doesn't revert the
+// payment, and that payment is completed by its replacement.
+func TestSyncReplacedTransaction(t *testing.T) {
+	chain := &stubChain{
+		blocks: map[string]*rpc.BlockVerboseResp{
+			blockHash(1): {Hash: blockHash(1), Height: 1, Confirmations: 3},
+		},
+		txs: []btcjson.ListTransactionsResult{{
+			Category:      "send",
+			Address:       "address",
+			Amount:        -0.1,
+			TxID:          blockHash(100),
+			Confirmations: -1,
+		}, {
+			Category:      "send",
+			Address:       "address",
+			Amount:        -0.1,
+			TxID:          blockHash(101),
+			Confirmations: 3,
+		}},
+		lastBlock: blockHash(1),
+	}
+
+	c, store, _ := newTestConnector(chain)
+
+	payment := &connectors.Payment{
+		Status:    connectors.Pending,
+		Direction: connectors.Outgoing,
+		System:    connectors.External,
+		Receipt:   "address",
+		Asset:     connectors.BTC,
+		Media:     connectors.Blockchain,
+		MediaID:   blockHash(100),
+	}
+	payment.PaymentID, _ = payment.GenPaymentID()
+
+	payment.MediaID = blockHash(101)
+	payment.Detail = &connectors.GeneratedTxDetails{
+		TxID: blockHash(101),
+		Replaced: []*connectors.ReplacedTx{{
+			TxID: blockHash(100),
+		}},
+	}
+	store.payments[payment.PaymentID] = *payment
+
+	setTestReplacer(c, &memoryReplacementsStorage{
+		payments: map[string]string{blockHash(101): payment.PaymentID},
+	})
+
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if len(store.payments) != 1 {
+		t.Fatalf("wrong number of payments: %v", len(store.payments))
+	}
+
+	synced := store.payments[payment.PaymentID]
+	if synced.Status != connectors.Completed ||
+		synced.MediaID != blockHash(101) {
+		t.Fatalf("payment should be completed by replacement, got %v",
+			synced.Status)
+	}
+}
+
+// TestSyncInternalPayment checks that outgoing internal payment, which has
+// been created by the connector, is completed by the sync, rather than
+// duplicated by the external one.
+func TestSyncInternalPayment(t *testing.T) {
+	chain := &stubChain{
+		blocks: map[string]*rpc.BlockVerboseResp{
+			blockHash(1): {Hash: blockHash(1), Height: 1, Confirmations: 3},
+		},
+		txs: []btcjson.ListTransactionsResult{{
+			Category:      "send",
+			Address:       "cold",
+			Amount:        -1,
+			TxID:          blockHash(100),
+			Confirmations: 3,
+		}},
+		lastBlock: blockHash(1),
+	}
+
+	c, store, _ := newTestConnector(chain)
+
+	payment := &connectors.Payment{
+		Status:    connectors.Pending,
+		Direction: connectors.Outgoing,
+		System:    connectors.Internal,
+		Receipt:   "cold",
+		Asset:     connectors.BTC,
+		Media:     connectors.Blockchain,
+		MediaID:   blockHash(100),
+	}
+	payment.PaymentID, _ = payment.GenPaymentID()
+	store.payments[payment.PaymentID] = *payment
+
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if len(store.payments) != 1 {
+		t.Fatalf("wrong number of payments: %v", len(store.payments))
+	}
+
+	synced := store.payments[payment.PaymentID]
+	if synced.Status != connectors.Completed ||
+		synced.System != connectors.Internal {
+		t.Fatalf("internal payment should be completed, got %v %v",
+			synced.System, synced.Status)
+	}
+}
+
+// TestSyncConsolidationPayment checks that output of the internal payment,
+// which has been sent to the wallet itself, is synced as internal incoming
+// payment rather than as deposit.
+func TestSyncConsolidationPayment(t *testing.T) {
+	chain := &stubChain{
+		blocks: map[string]*rpc.BlockVerboseResp{
+			blockHash(1): {Hash: blockHash(1), Height: 1, Confirmations: 3},
+		},
+		txs: []btcjson.ListTransactionsResult{{
+			Category:      "send",
+			Address:       "wallet",
+			Amount:        -1,
+			TxID:          blockHash(100),
+			Confirmations: 3,
+		}, {
+			Category:      "receive",
+			Address:       "wallet",
+			Amount:        1,
+			TxID:          blockHash(100),
+			Confirmations: 3,
+		}},
+		lastBlock: blockHash(1),
+	}
+
+	c, store, _ := newTestConnector(chain)
+
+	payment := &connectors.Payment{
+		Status:    connectors.Pending,
+		Direction: connectors.Outgoing,
+		System:    connectors.Internal,
+		Receipt:   "wallet",
+		Asset:     connectors.BTC,
+		Media:     connectors.Blockchain,
+		MediaID:   blockHash(100),
+	}
+	payment.PaymentID, _ = payment.GenPaymentID()
+	store.payments[payment.PaymentID] = *payment
+
+	if err := c.syncPaymentState(); err != nil {
+		t.Fatalf("unable to sync: %v", err)
+	}
+
+	if len(store.payments) != 2 {
+		t.Fatalf("wrong number of payments: %v", len(store.payments))
+	}
+
+	for _, synced := range store.payments {
+		if synced.Status != connectors.Completed ||
+			synced.System != connectors.Internal {
+			t.Fatalf("%v payment should be internal and completed, got "+
+				"%v %v", synced.Direction, synced.System, synced.Status)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package bitcoind_simple
+
+import (
+	"math"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/daemons/bitcoind"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/go-errors/errors"
+)
+
+// largestFirst is the coin selection strategy of the transactions which
+// are created by the connector.
+var largestFirst, _ = bitcoind.NewCoinSelector(bitcoind.LargestFirst)
+
+// coinSelect selects unspent outputs, starting from the largest ones, which
+// are sufficient to send the given amount to the receiver and pay the
+// transaction fee with the given fee rate. Sizes of the inputs are
+// estimated by the scripts of the spent outputs. If change is less than dust
+// limit, it is left to miners as a fee.
+func coinSelect(feeRatePerByte, amt btcutil.Amount, receiver btcutil.Address,
+	unspent []rpc.UnspentInput) (*bitcoind.CoinSelection, error) {
+	return largestFirst.Select(uint64(feeRatePerByte), amt,
+		[]btcutil.Address{receiver}, unspent)
+}
+
+// createTransaction selects and locks unspent outputs, and creates signed
+// transaction which sends the given amount to the address with the fee
+// rate of the priority. Returns the transaction, its fee and change
+// address, which is empty if transaction has no change. Locked outputs are
+// not used by the daemon for other transactions, until they are spent or
+// unlocked.
+//
+// NOTE: Daemon keeps locks only in memory, and they are dropped on the
+// daemon restart.
+func (c *Connector) createTransaction(address btcutil.Address,
+	amt btcutil.Amount, priority connectors.FeePriority) (*wire.MsgTx,
+	btcutil.Amount, string, error) {
+
+	if amt <= 0 {
+		return nil, 0, "", &connectors.ErrInvalidAmount{
+			Amount: printAmount(amt),
+			Reason: errors.New("amount should be positive"),
+		}
+	}
+
+	unspent, err := c.client.ListUnspentMinMax(c.cfg.MinConfirmations,
+		math.MaxInt32)
+	if err != nil {
+		return nil, 0, "", convertRPCError(c.client.DaemonName(), err,
+			"unable to list unspent")
+	}
+
+	feeRatePerByte := btcutil.Amount(c.getFeeRate(priority).Ceil().IntPart())
+	selection, err := coinSelect(feeRatePerByte, amt, address,
+		bitcoind.SpendableInputs(unspent))
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	inputs, changeAmt, fee := selection.Inputs, selection.Change, selection.Fee
+
+	c.log.Debugf("Selected %v unspent inputs, amount(%v), change(%v), "+
+		"fee(%v)", len(inputs), printAmount(amt), printAmount(changeAmt),
+		printAmount(fee))
+
+	var locked []rpc.UnspentInput
+	defer func() {
+		// If transaction creation has failed, inputs should be returned
+		// back, so that they could be used by other transactions.
+		if err != nil {
+			bitcoind.UnlockInputs(c.client, locked, c.log)
+		}
+	}()
+
+	for _, input := range inputs {
+		if err = c.client.LockUnspent(input); err != nil {
+			return nil, 0, "", errors.Errorf("unable to lock input: %v",
+				err)
+		}
+		locked = append(locked, input)
+	}
+
+	outputs := make(map[btcutil.Address]btcutil.Amount)
+	outputs[address] = amt
+
+	var changeAddress string
+	if changeAmt != 0 {
+		var changeAddr btcutil.Address
+		changeAddr, err = c.client.GetNewRawChangeAddress(defaultAccount)
+		if err != nil {
+			return nil, 0, "", errors.Errorf("unable to get change "+
+				"address: %v", err)
+		}
+		outputs[changeAddr] = changeAmt
+		changeAddress = changeAddr.String()
+	}
+
+	tx, err := c.client.CreateRawTransaction(inputs, outputs)
+	if err != nil {
+		return nil, 0, "", errors.Errorf("unable to create transaction: %v",
+			err)
+	}
+
+	if bitcoind.SupportsRBF(c.cfg.Asset) {
+		bitcoind.SignalRBF(tx)
+	}
+
+	signedTx, err := c.client.SignRawTransaction(tx)
+	if err != nil {
+		return nil, 0, "", errors.Errorf("unable to sign transaction: %v",
+			err)
+	}
+
+	return signedTx, fee, changeAddress, nil
+}
+
+// txInputs returns outputs which are spent by the transaction.
+func txInputs(tx *wire.MsgTx) []rpc.UnspentInput {
+	inputs := make([]rpc.UnspentInput, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		inputs[i] = rpc.UnspentInput{
+			TxID: txIn.PreviousOutPoint.Hash.String(),
+			Vout: txIn.PreviousOutPoint.Index,
+		}
+	}
+
+	return inputs
+}
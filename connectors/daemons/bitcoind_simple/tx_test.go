@@ -0,0 +1,108 @@
+package bitcoind_simple
+
+import (
+	"testing"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/daemons/bitcoind"
+	"github.com/bitlum/connector/connectors/rpc"
+	"github.com/btcsuite/btcutil"
+)
+
+// estimateTxFee returns fee of the transaction with given number of inputs
+// and outputs, assuming that all of them are P2PKH.
+func estimateTxFee(feeRatePerByte btcutil.Amount, numInputs,
+	numOutputs int) btcutil.Amount {
+
+	var weightEstimate bitcoind.TxWeightEstimator
+	for i := 0; i < numInputs; i++ {
+		weightEstimate.AddP2PKHInput()
+	}
+	for i := 0; i < numOutputs; i++ {
+		weightEstimate.AddP2PKHOutput()
+	}
+
+	return btcutil.Amount(weightEstimate.VSize()) * feeRatePerByte
+}
+
+// TestCoinSelect checks that largest inputs are selected first, and that
+// change and fee are calculated properly.
+func TestCoinSelect(t *testing.T) {
+	feeRatePerByte := btcutil.Amount(10)
+
+	inputs := []rpc.UnspentInput{
+		{TxID: "1", Amount: 0.1},
+		{TxID: "2", Amount: 0.5},
+		{TxID: "3", Amount: 0.3},
+	}
+
+	amt, _ := btcutil.NewAmount(0.7)
+	selection, err := coinSelect(feeRatePerByte, amt, nil, inputs)
+	if err != nil {
+		t.Fatalf("unable to select inputs: %v", err)
+	}
+
+	selected, changeAmt, fee := selection.Inputs, selection.Change,
+		selection.Fee
+
+	if len(selected) != 2 || selected[0].TxID != "2" ||
+		selected[1].TxID != "3" {
+		t.Fatalf("wrong inputs have been selected")
+	}
+
+	if fee != estimateTxFee(feeRatePerByte, 2, 2) {
+		t.Fatalf("wrong fee: %v", fee)
+	}
+
+	total, _ := btcutil.NewAmount(0.8)
+	if changeAmt != total-amt-fee {
+		t.Fatalf("wrong change: %v", changeAmt)
+	}
+}
+
+// TestCoinSelectDustChange checks that change which is less than dust limit
+// is left to miners.
+func TestCoinSelectDustChange(t *testing.T) {
+	feeRatePerByte := btcutil.Amount(1)
+
+	inputs := []rpc.UnspentInput{
+		{TxID: "1", Amount: 0.001},
+	}
+
+	total, _ := btcutil.NewAmount(0.001)
+	amt := total - estimateTxFee(feeRatePerByte, 1, 1) - 100
+
+	selection, err := coinSelect(feeRatePerByte, amt, nil, inputs)
+	if err != nil {
+		t.Fatalf("unable to select inputs: %v", err)
+	}
+
+	selected, changeAmt, fee := selection.Inputs, selection.Change,
+		selection.Fee
+
+	if len(selected) != 1 {
+		t.Fatalf("wrong number of inputs: %v", len(selected))
+	}
+
+	if changeAmt != 0 {
+		t.Fatalf("dust change should be omitted")
+	}
+
+	if fee != total-amt {
+		t.Fatalf("wrong fee: %v", fee)
+	}
+}
+
+// TestCoinSelectInsufficientFunds checks that error is returned if inputs
+// are not enough to pay amount and fee.
+func TestCoinSelectInsufficientFunds(t *testing.T) {
+	inputs := []rpc.UnspentInput{
+		{TxID: "1", Amount: 0.1},
+	}
+
+	amt, _ := btcutil.NewAmount(0.1)
+	_, err := coinSelect(1, amt, nil, inputs)
+	if _, ok := err.(*connectors.ErrInsufficientFunds); !ok {
+		t.Fatalf("insufficient funds error should be returned, got: %v", err)
+	}
+}
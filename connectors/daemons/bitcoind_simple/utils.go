@@ -8,6 +8,7 @@ import (
 	"github.com/bitlum/connector/connectors/rpc/bitcoincash"
 	"github.com/bitlum/connector/connectors/rpc/dash"
 	"github.com/bitlum/connector/connectors/rpc/litecoin"
+	"github.com/bitlum/go-bitcoind-rpc/btcjson"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil"
 	"github.com/go-errors/errors"
@@ -81,3 +82,21 @@ func getParams(asset connectors.Asset, network string) (*chaincfg.Params, error)
 		return nil, errors.Errorf("unsupported asset asset(%v)", asset)
 	}
 }
+
+// convertRPCError converts error returned by the daemon to the typed
+// connector error. If error couldn't be classified, it is annotated with
+// the given description.
+func convertRPCError(daemon string, err error, desc string) error {
+	if e, ok := err.(*btcjson.RPCError); ok &&
+		e.Code == btcjson.ErrRPCWalletInsufficientFunds {
+		return &connectors.ErrInsufficientFunds{}
+	}
+
+	if err := connectors.WrapDaemonError(daemon, err); err != nil {
+		if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+			return err
+		}
+	}
+
+	return errors.Errorf("%v: %v", desc, err)
+}
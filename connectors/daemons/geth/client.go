@@ -98,6 +98,14 @@ type Config struct {
 	// StateStorage is used to keep data which is needed for connector to
 	// properly synchronise and track transactions.
 	StateStorage connectors.StateStorage
+
+	// RetryPolicy determines how outgoing payments which couldn't be sent
+	// are retried.
+	RetryPolicy connectors.RetryPolicy
+
+	// AttemptsStorage is used to persist attempts of the outgoing
+	// payments which are being retried.
+	AttemptsStorage connectors.AttemptsStorage
 }
 
 func (c *Config) validate() error {
@@ -137,6 +145,10 @@ func (c *Config) validate() error {
 		return errors.New("state store should be specified")
 	}
 
+	if c.AttemptsStorage == nil {
+		return errors.New("attempts storage should be specified")
+	}
+
 	return nil
 }
 
@@ -148,6 +160,10 @@ type Connector struct {
 	wg       sync.WaitGroup
 	quit     chan struct{}
 
+	// syncedHeight is the number of the last synced block, it should be
+	// used atomically.
+	syncedHeight int64
+
 	cfg    *Config
 	client *ExtendedEthRpc
 
@@ -166,6 +182,17 @@ type Connector struct {
 	unconfirmedTxs pendingMap
 	pendingLock    sync.Mutex
 
+	// sendMtx is used to prevent the same payment to be sent twice, in case
+	// of the concurrent requests with the same idempotency key, as well as
+	// usage of the same nonce by concurrent payments.
+	sendMtx sync.Mutex
+
+	// sender sends outgoing payments and retries the ones which couldn't
+	// be sent.
+	sender *connectors.PaymentSender
+
+	lifecycle connectors.Lifecycle
+
 	log *common.NamedLogger
 }
 
@@ -173,12 +200,16 @@ type Connector struct {
 // interface.
 var _ connectors.BlockchainConnector = (*Connector)(nil)
 
+// A compile time check to ensure Connector implements the MultiSender
+// interface.
+var _ connectors.MultiSender = (*Connector)(nil)
+
 func NewConnector(cfg *Config) (*Connector, error) {
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
 
-	return &Connector{
+	c := &Connector{
 		cfg:            cfg,
 		quit:           make(chan struct{}),
 		memPoolTxs:     make(pendingMap),
@@ -187,7 +218,26 @@ func NewConnector(cfg *Config) (*Connector, error) {
 			Name:   string(cfg.Asset),
 			Logger: cfg.Logger,
 		},
-	}, nil
+	}
+
+	var err error
+	c.sender, err = connectors.NewPaymentSender(&connectors.PaymentSenderConfig{
+		Asset:        cfg.Asset,
+		Media:        connectors.Blockchain,
+		Policy:       cfg.RetryPolicy,
+		Storage:      cfg.AttemptsStorage,
+		PaymentStore: cfg.PaymentStorage,
+		Send:         c.sendPayment,
+		Lookup:       c.lookupPayment,
+		Abandon:      c.abandonPayment,
+		Locker:       &c.sendMtx,
+		Logger:       cfg.Logger,
+	})
+	if err != nil {
+		return nil, errors.Errorf("unable to create payment sender: %v", err)
+	}
+
+	return c, nil
 }
 
 func (c *Connector) Start() (err error) {
@@ -202,6 +252,8 @@ func (c *Connector) Start() (err error) {
 		if err != nil {
 			atomic.SwapInt32(&c.started, 0)
 		}
+
+		c.lifecycle.Started(err)
 	}()
 
 	m := crypto.NewMetric(c.cfg.DaemonCfg.Name, string(c.cfg.Asset),
@@ -303,6 +355,9 @@ func (c *Connector) Start() (err error) {
 					continue
 				}
 
+				atomic.StoreInt64(&c.syncedHeight,
+					int64(newLastSyncedBlock.Number))
+
 				if newLastSyncedBlock.Hash != prevLastSyncedBlockHash {
 					lastSyncedBlockHash = newLastSyncedBlock.Hash
 
@@ -327,21 +382,67 @@ func (c *Connector) Start() (err error) {
 		}
 	}()
 
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		c.log.Info("Starting payments retry goroutine...")
+		c.sender.Run(c.quit)
+		c.log.Info("Quit payments retry goroutine")
+	}()
+
 	return err
 }
 
-func (c *Connector) Stop(reason string) {
+func (c *Connector) Stop(reason string) error {
 	if !atomic.CompareAndSwapInt32(&c.shutdown, 0, 1) {
 		c.log.Warn("client already shutdown")
-		return
+		return nil
 	}
 
 	c.log.Infof("client shutting down (reason: %v)...", reason)
 	close(c.quit)
 
 	c.wg.Wait()
+	c.lifecycle.Stopped()
 
 	c.log.Info("client shutdown")
+	return nil
+}
+
+// Status returns the lifecycle state of the connector, and the sync state
+// of the connector and its daemon.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) Status() *connectors.ConnectorStatus {
+	status := c.lifecycle.Status()
+	status.Net = c.cfg.Net
+	status.MinConfirmations = c.cfg.MinConfirmations
+	status.SyncedHeight = atomic.LoadInt64(&c.syncedHeight)
+
+	// Client is created on start, that is why daemon couldn't be
+	// requested before it.
+	if status.State != connectors.Running {
+		return status
+	}
+
+	bestBlockNumber, err := c.client.EthBlockNumber()
+	if err != nil {
+		status.DaemonError = connectors.WrapDaemonError(
+			c.cfg.DaemonCfg.Name, err).Error()
+		return status
+	}
+	status.BestHeight = int64(bestBlockNumber)
+
+	version, err := c.client.Web3ClientVersion()
+	if err != nil {
+		status.DaemonError = connectors.WrapDaemonError(
+			c.cfg.DaemonCfg.Name, err).Error()
+		return status
+	}
+	status.DaemonVersion = version
+
+	return status
 }
 
 func (c *Connector) WaitShutDown() <-chan struct{} {
@@ -378,64 +479,471 @@ func (c *Connector) createAddress(account internalAccount) (string, error) {
 	return address, err
 }
 
-// CreatePayment generates the payment, but not sends it,
-// instead returns the payment id and waits for it to be approved.
+// SendPayment sends payment with given amount to the given address.
+// Priority is ignored, gas price suggested by the daemon is used.
 //
 // NOTE: Part of the connectors.BlockchainConnector interface.
-func (c *Connector) SendPayment(toAddress, amountStr string) (*connectors.Payment, error) {
+func (c *Connector) SendPayment(toAddress, amountStr, idempotencyKey string,
+	priority connectors.FeePriority) (*connectors.Payment, error) {
 	m := crypto.NewMetric(c.cfg.DaemonCfg.Name, string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
+	if err := ethereum.ValidateAddress(toAddress); err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInvalidReceipt{Reason: err}
+	}
+
 	amount, err := decimal.NewFromString(amountStr)
 	if err != nil {
-		return nil, errors.Errorf("unable parse amount: %v", err)
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInvalidAmount{Amount: amountStr, Reason: err}
 	}
 
-	// If we send transaction too frequently ethereum transaction counter
-	// is not working properly, for that reason we use internal nonce counter.
-	nonce, err := c.cfg.AccountStorage.DefaultAddressNonce()
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, created, err := c.createPayment(toAddress, amount, idempotencyKey)
+	if err == connectors.ErrIdempotencyKeyReused {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	} else if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	if !created {
+		c.log.Infof("Payment(%v) with idempotency key(%v) has been already "+
+			"sent", payment.PaymentID, idempotencyKey)
+		return payment, nil
+	}
+
+	payment, err = c.confirmPayment(payment)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable to get default nonce: %v", err)
+		return nil, err
 	}
 
-	details, fee, err := c.generateTransaction(c.defaultAddress, toAddress,
-		amount, false, nonce)
+	return payment, nil
+}
+
+// SendPayments sends payments to the recipients from the default address.
+// Ethereum transaction has only one receiver, so every payment is sent in
+// its own transaction. Every address is validated, and funds of the default
+// address are checked to be enough for all payments, before any of them is
+// created. Transactions are created with the consecutive nonces, and those
+// which couldn't be sent are retried by the payment sender. If some
+// payment has failed after the previous ones have been broadcast, all
+// payments are returned, and the failed one has its failure reason.
+//
+// NOTE: Part of the connectors.MultiSender interface.
+func (c *Connector) SendPayments(recipients []*connectors.Recipient,
+	idempotencyKey string) ([]*connectors.Payment, error) {
+	m := crypto.NewMetric(c.cfg.DaemonCfg.Name, string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	amounts, err := connectors.RecipientAmounts(recipients)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	for _, recipient := range recipients {
+		if err := ethereum.ValidateAddress(recipient.Address); err != nil {
+			m.AddError(metrics.LowSeverity)
+			return nil, &connectors.ErrInvalidReceipt{Reason: err}
+		}
+	}
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payments, err := connectors.PaymentsByIdempotencyKey(
+		c.cfg.PaymentStorage, idempotencyKey, c.cfg.Asset,
+		connectors.Blockchain, recipients, amounts)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	} else if payments != nil {
+		c.log.Infof("Payments with idempotency key(%v) have been already "+
+			"sent", idempotencyKey)
+		return payments, nil
+	}
+
+	fee, err := c.EstimateFee("", connectors.DefaultPriority)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
 		return nil, err
 	}
 
-	payment := &connectors.Payment{
-		UpdatedAt: connectors.NowInMilliSeconds(),
-		Status:    connectors.Waiting,
-		Direction: connectors.Outgoing,
-		System:    connectors.External,
-		Receipt:   toAddress,
-		Asset:     connectors.Asset(c.cfg.Asset),
-		Media:     connectors.Blockchain,
-		Amount:    amount.Round(8),
-		MediaFee:  fee,
-		MediaID:   details.TxID,
-		Detail:    details,
+	needed := fee.Mul(decimal.New(int64(len(recipients)), 0))
+	for _, amount := range amounts {
+		needed = needed.Add(amount)
 	}
 
-	payment.PaymentID, err = payment.GenPaymentID()
+	weis, err := c.client.EthGetBalance(c.defaultAddress, "latest")
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
+		return nil, connectors.WrapDaemonError(c.cfg.DaemonCfg.Name, err)
+	}
+
+	available := decimal.NewFromBigInt(&weis, 0).Div(weiInEth)
+	if available.LessThan(needed) {
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInsufficientFunds{
+			Needed:    needed.Round(8),
+			Available: available.Round(8),
+		}
+	}
+
+	payments = make([]*connectors.Payment, 0, len(recipients))
+	for i, recipient := range recipients {
+		payment, _, err := c.createPayment(recipient.Address, amounts[i],
+			connectors.RecipientIdempotencyKey(idempotencyKey, i))
+		if err != nil {
+			c.cancelPayments(payments)
+			m.AddError(metrics.HighSeverity)
+			return nil, err
+		}
+
+		payments = append(payments, payment)
+	}
+
+	// Transactions are sent in the order of their nonces. If the first one
+	// has failed nothing has been broadcast yet, so the rest is canceled.
+	// Otherwise the rest is sent anyway, because the sent ones couldn't be
+	// revoked, and every payment is returned with its own status.
+	for i, payment := range payments {
+		sent, err := c.confirmPayment(payment)
+		if err != nil && i == 0 {
+			c.cancelPayments(payments[1:])
+			m.AddError(metrics.HighSeverity)
+			return nil, err
+		} else if err != nil {
+			// Payment has been marked as failed by the payment sender,
+			// with the error as the failure reason.
+			m.AddError(metrics.HighSeverity)
+			continue
+		}
+
+		payments[i] = sent
+	}
+
+	return payments, nil
+}
+
+// cancelPayments cancels created payments which couldn't be sent along
+// with the others. Nonces are released in the reverse order, so that nonce
+// counter is decreased instead of filling the gaps.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) cancelPayments(payments []*connectors.Payment) {
+	for i := len(payments) - 1; i >= 0; i-- {
+		payment := payments[i]
+
+		details := payment.Detail.(*connectors.GeneratedTxDetails)
+		if err := c.releaseNonce(details.Nonce); err != nil {
+			c.log.Errorf("unable to release nonce(%v): %v", details.Nonce,
+				err)
+		}
+
+		payment.Status = connectors.Failed
+		payment.FailureReason = connectors.CanceledReason
+		payment.UpdatedAt = connectors.NowInMilliSeconds()
+		if err := c.cfg.PaymentStorage.SavePayment(payment); err != nil {
+			c.log.Errorf("unable update payment(%v) status: %v",
+				payment.PaymentID, err)
+		}
+	}
+}
+
+// CreatePayment generates the payment, but not sends it, instead stores it
+// as waiting for the approval. Default address nonce is reserved by the
+// payment until it is confirmed or canceled.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) CreatePayment(toAddress, amountStr,
+	idempotencyKey string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.cfg.DaemonCfg.Name, string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	if err := ethereum.ValidateAddress(toAddress); err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInvalidReceipt{Reason: err}
+	}
+
+	amount, err := decimal.NewFromString(amountStr)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, &connectors.ErrInvalidAmount{Amount: amountStr, Reason: err}
+	}
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, created, err := c.createPayment(toAddress, amount, idempotencyKey)
+	if err == connectors.ErrIdempotencyKeyReused {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	} else if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	if !created {
+		c.log.Infof("Payment(%v) with idempotency key(%v) has been already "+
+			"created", payment.PaymentID, idempotencyKey)
+	}
+
+	return payment, nil
+}
+
+// ConfirmPayment sends previously created waiting payment. If payment
+// couldn't be sent, it is retried, and after all attempts it is marked as
+// failed and its nonce is released.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) ConfirmPayment(paymentID string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.cfg.DaemonCfg.Name, string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, err := connectors.WaitingPayment(c.cfg.PaymentStorage, paymentID,
+		c.cfg.Asset, connectors.Blockchain)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
 		return nil, err
 	}
 
+	payment, err = c.confirmPayment(payment)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// CancelPayment cancels previously created waiting payment, and releases
+// default address nonce reserved by it.
+//
+// NOTE: Part of the connectors.BlockchainConnector interface.
+func (c *Connector) CancelPayment(paymentID string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.cfg.DaemonCfg.Name, string(c.cfg.Asset),
+		common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, err := connectors.WaitingPayment(c.cfg.PaymentStorage, paymentID,
+		c.cfg.Asset, connectors.Blockchain)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	details, ok := payment.Detail.(*connectors.GeneratedTxDetails)
+	if !ok {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("unable get details for payment(%v)",
+			paymentID)
+	}
+
+	if err := c.releaseNonce(details.Nonce); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("unable to release nonce(%v): %v",
+			details.Nonce, err)
+	}
+
+	payment.Status = connectors.Failed
+	payment.FailureReason = connectors.CanceledReason
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
 	if err := c.cfg.PaymentStorage.SavePayment(payment); err != nil {
 		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable add payment(%v) in store: %v",
-			payment.PaymentID, err)
+		return nil, errors.Errorf("unable update payment(%v) status: %v",
+			paymentID, err)
+	}
+
+	c.log.Infof("Cancel payment %v", spew.Sdump(payment))
+
+	return payment, nil
+}
+
+// createPayment generates the payment from default address and stores it
+// as waiting for the approval. If payment with the given idempotency key
+// has been already created, it is returned instead, and created flag is
+// false.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) createPayment(toAddress string, amount decimal.Decimal,
+	idempotencyKey string) (*connectors.Payment, bool, error) {
+
+	payment, err := connectors.PaymentByIdempotencyKey(c.cfg.PaymentStorage,
+		idempotencyKey, c.cfg.Asset, connectors.Blockchain, toAddress, amount)
+	if err != nil {
+		return nil, false, err
+	} else if payment != nil {
+		return payment, false, nil
+	}
+
+	// If we send transaction too frequently ethereum transaction counter
+	// is not working properly, for that reason we use internal nonce counter.
+	nonce, err := c.cfg.AccountStorage.DefaultAddressNonce()
+	if err != nil {
+		return nil, false, errors.Errorf("unable to get default nonce: %v",
+			err)
+	}
+
+	details, fee, err := c.generateTransaction(c.defaultAddress, toAddress,
+		amount, false, nonce)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Reserve nonce for the generated transaction, so that it wouldn't be
+	// used by other payments, until this one is sent or canceled.
+	err = c.cfg.AccountStorage.PutDefaultAddressNonce(nonce + 1)
+	if err != nil {
+		return nil, false, errors.Errorf("unable to save default nonce: %v",
+			err)
+	}
+
+	payment = &connectors.Payment{
+		UpdatedAt:      connectors.NowInMilliSeconds(),
+		Status:         connectors.Waiting,
+		Direction:      connectors.Outgoing,
+		System:         connectors.External,
+		Receipt:        toAddress,
+		Asset:          connectors.Asset(c.cfg.Asset),
+		Media:          connectors.Blockchain,
+		Amount:         amount.Round(8),
+		MediaFee:       fee,
+		MediaID:        details.TxID,
+		Detail:         details,
+		IdempotencyKey: idempotencyKey,
+	}
+
+	payment.PaymentID, err = payment.GenPaymentID()
+	if err == nil {
+		err = c.cfg.PaymentStorage.SavePayment(payment)
+	}
+
+	if err != nil {
+		if err := c.releaseNonce(nonce); err != nil {
+			c.log.Errorf("unable to release nonce(%v): %v", nonce, err)
+		}
+
+		return nil, false, errors.Errorf("unable add payment in store: %v",
+			err)
 	}
 
 	c.log.Infof("Create payment %v", spew.Sdump(payment))
 
-	return c.sendPayment(payment.PaymentID, true)
+	return payment, true, nil
+}
+
+// confirmPayment sends the waiting payment. If payment couldn't be sent it
+// is retried by the payment sender, which releases its nonce if payment
+// has failed.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) confirmPayment(payment *connectors.Payment) (
+	*connectors.Payment, error) {
+
+	if _, ok := payment.Detail.(*connectors.GeneratedTxDetails); !ok {
+		return nil, errors.Errorf("unable get details for payment(%v)",
+			payment.PaymentID)
+	}
+
+	return c.sender.Send(payment)
+}
+
+// abandonPayment releases nonce of the payment which has been marked as
+// failed by the payment sender.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) abandonPayment(payment *connectors.Payment) {
+	details, ok := payment.Detail.(*connectors.GeneratedTxDetails)
+	if !ok {
+		return
+	}
+
+	if err := c.releaseNonce(details.Nonce); err != nil {
+		c.log.Errorf("unable to release nonce(%v): %v", details.Nonce, err)
+	}
+}
+
+// lookupPayment checks whether transaction of the payment is already known
+// by the daemon, so that it wouldn't be sent twice. If so, payment is
+// marked as pending.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) lookupPayment(payment *connectors.Payment) (
+	*connectors.Payment, error) {
+
+	details, ok := payment.Detail.(*connectors.GeneratedTxDetails)
+	if !ok {
+		return nil, errors.Errorf("unable get details for payment(%v)",
+			payment.PaymentID)
+	}
+
+	tx, err := c.client.EthGetTransactionByHash(details.TxID)
+	if err != nil {
+		return nil, connectors.WrapDaemonError(c.cfg.DaemonCfg.Name, err)
+	}
+
+	// Daemon returns null if transaction is unknown.
+	if tx == nil || tx.Hash == "" {
+		return nil, nil
+	}
+
+	payment.Status = connectors.Pending
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStorage.SavePayment(payment); err != nil {
+		return nil, errors.Errorf("unable update payment(%v) status: %v",
+			payment.PaymentID, err)
+	}
+
+	return payment, nil
+}
+
+// releaseNonce releases default address nonce which has been reserved by
+// the payment which wasn't sent. If it is the last reserved nonce, counter
+// is decreased, otherwise the gap is filled with zero value transaction from
+// default address to itself, because transactions with greater nonces
+// wouldn't be mined until then.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) releaseNonce(nonce int) error {
+	lastNonce, err := c.cfg.AccountStorage.DefaultAddressNonce()
+	if err != nil {
+		return errors.Errorf("unable to get default nonce: %v", err)
+	}
+
+	if nonce+1 == lastNonce {
+		return c.cfg.AccountStorage.PutDefaultAddressNonce(nonce)
+	}
+
+	details, _, err := c.generateTransaction(c.defaultAddress,
+		c.defaultAddress, decimal.Zero, false, nonce)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.client.EthSendRawTransaction(string(details.RawTx)); err != nil {
+		return errors.Errorf("unable to send nonce gap tx: %v", err)
+	}
+
+	c.log.Infof("Released nonce(%v) is used by tx(%v) to default address",
+		nonce, details.TxID)
+
+	return nil
 }
 
 func (c *Connector) generateTransaction(fromAddress, toAddress string,
@@ -446,7 +954,8 @@ func (c *Connector) generateTransaction(fromAddress, toAddress string,
 	// Fetch suggested by the daemon gas price.
 	gp, err := c.client.EthGasPrice()
 	if err != nil {
-		return nil, decimal.Zero, err
+		return nil, decimal.Zero, connectors.WrapDaemonError(
+			c.cfg.DaemonCfg.Name, err)
 	}
 
 	gasPrice := big.NewInt(0)
@@ -496,74 +1005,46 @@ func (c *Connector) generateTransaction(fromAddress, toAddress string,
 	return &connectors.GeneratedTxDetails{
 		RawTx: []byte(rawTxStr),
 		TxID:  tx.Hash,
+		Nonce: nonce,
 	}, requiredFee, nil
 }
 
 // sendPayment sends created previously payment to the
-// blockchain network.
-func (c *Connector) sendPayment(paymentID string, isFromDefault bool) (*connectors.Payment, error) {
+// blockchain network. Unsent payment is left as is, so that the payment
+// sender could retry its transaction with the same nonce.
+//
+// NOTE: Nonce of the payments from default address is reserved on the
+// stage of payment creation.
+func (c *Connector) sendPayment(payment *connectors.Payment) (
+	*connectors.Payment, error) {
 	m := crypto.NewMetric(c.cfg.DaemonCfg.Name, string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	// We should be able to receive payment which we putted in storage
-	// earlier on the stage of payment generation.
-	payment, err := c.cfg.PaymentStorage.PaymentByID(paymentID)
-	if err != nil {
-		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable find payment(%v): %v", paymentID,
-			err)
-	}
-
 	// Extract the detail about payment, which were putter on the stage
 	// of creation of the payment, in order to use raw transaction
 	// to send it in blockchain.
 	details, ok := payment.Detail.(*connectors.GeneratedTxDetails)
 	if !ok {
 		return nil, errors.Errorf("unable get details for payment(%v)",
-			paymentID)
+			payment.PaymentID)
 	}
 
-	_, err = c.client.EthSendRawTransaction(string(details.RawTx))
+	_, err := c.client.EthSendRawTransaction(string(details.RawTx))
 	if err != nil {
-		payment.Status = connectors.Failed
-		if err := c.cfg.PaymentStorage.SavePayment(payment); err != nil {
-			m.AddError(metrics.HighSeverity)
-			c.log.Errorf("unable update payment(%v) status: %v",
-				paymentID, err)
-		}
-
 		m.AddError(metrics.HighSeverity)
-		return nil, errors.Errorf("unable to execute send tx rpc call: %v", err)
+		return nil, convertRPCError(c.cfg.DaemonCfg.Name, err,
+			"unable to execute send tx rpc call")
 	}
 
 	payment.Status = connectors.Pending
 	payment.UpdatedAt = connectors.NowInMilliSeconds()
 
-	// If we are sending payment from default address, than we should increase
-	// default nonce, because we are tracking nonce to avoid errors related to
-	// nonce repeat.
-	if isFromDefault {
-		nonce, err := c.cfg.AccountStorage.DefaultAddressNonce()
-		if err != nil {
-			m.AddError(metrics.HighSeverity)
-			return nil, errors.Errorf("unable to get default nonce: %v", err)
-		}
-
-		err = c.cfg.AccountStorage.PutDefaultAddressNonce(nonce + 1)
-		if err != nil {
-			m.AddError(metrics.HighSeverity)
-			return nil, errors.Errorf("unable to save default nonce: %v", err)
-		}
-
-		c.log.Infof("Payment is sent and default address nonce is"+
-			" increased to %v", nonce)
-	}
-
 	err = c.cfg.PaymentStorage.SavePayment(payment)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		c.log.Errorf("unable update payment(%v) status: %v", paymentID, err)
+		c.log.Errorf("unable update payment(%v) status: %v",
+			payment.PaymentID, err)
 	}
 
 	c.log.Infof("Sent payment %v", spew.Sdump(payment))
@@ -590,7 +1071,8 @@ func (c *Connector) ConfirmedBalance() (decimal.Decimal, error) {
 	for _, address := range addresses {
 		weis, err := c.client.EthGetBalance(address, "latest")
 		if err != nil {
-			return decimal.Zero, err
+			return decimal.Zero, connectors.WrapDaemonError(
+				c.cfg.DaemonCfg.Name, err)
 		}
 
 		amount := decimal.NewFromBigInt(&weis, 0).Div(weiInEth)
@@ -1002,6 +1484,16 @@ func (c *Connector) syncConfirmed(bestBlockNumber int,
 					return nil, err
 				}
 
+				// Block might be synced more than once, e.g. after the
+				// chain reorganisation, and payment shouldn't be
+				// redirected twice.
+				if needRedirect {
+					needRedirect, err = c.needRedirect(&incomingPayment)
+					if err != nil {
+						return nil, err
+					}
+				}
+
 				if err := c.cfg.PaymentStorage.SavePayment(&incomingPayment); err != nil {
 					return nil, errors.Errorf("unable to add payment to storage: %v",
 						incomingPayment.PaymentID)
@@ -1016,7 +1508,9 @@ func (c *Connector) syncConfirmed(bestBlockNumber int,
 					// aggregation on default account.
 					c.log.Infof("Make redirect of payment("+
 						"%v)", incomingPayment.PaymentID)
-					if err := c.makeRedirect(confirmedTx.To, amount); err != nil {
+					err := c.makeRedirect(confirmedTx.Hash, confirmedTx.To,
+						amount)
+					if err != nil {
 						c.log.Errorf("unable to make payment(%v) "+
 							"redirection: %v", spew.Sdump(incomingPayment), err)
 						m.AddError(metrics.HighSeverity)
@@ -1043,10 +1537,42 @@ func (c *Connector) syncConfirmed(bestBlockNumber int,
 	}
 }
 
+// redirectKey returns the idempotency key of the redirect of the payment
+// received by the given transaction.
+func redirectKey(txHash string) string {
+	return "redirect:" + txHash
+}
+
+// needRedirect checks whether received payment hasn't been redirected on
+// default address yet, neither it has been already completed.
+func (c *Connector) needRedirect(payment *connectors.Payment) (bool, error) {
+	stored, err := c.cfg.PaymentStorage.PaymentByID(payment.PaymentID)
+	if err == nil && stored.Status == connectors.Completed {
+		return false, nil
+	}
+
+	redirect, err := c.cfg.PaymentStorage.PaymentByIdempotencyKey(
+		redirectKey(payment.MediaID))
+	if err == connectors.PaymentNotFound {
+		return true, nil
+	} else if err != nil {
+		return false, errors.Errorf("unable to get redirect of payment(%v): "+
+			"%v", payment.PaymentID, err)
+	}
+
+	c.log.Infof("Payment(%v) has been already redirected by payment(%v)",
+		payment.PaymentID, redirect.PaymentID)
+
+	return redirect.Status == connectors.Failed, nil
+}
+
 // makeRedirect is used to make a redirect of previously received money on
 // default address. Such aggregation is needed so that later we could use
-// default address to send money with one transaction.
-func (c *Connector) makeRedirect(initialAddress string, amount decimal.Decimal) error {
+// default address to send money with one transaction. Redirect is saved
+// with the idempotency key derived from the hash of the transaction which
+// has been received, so that it wouldn't be made twice.
+func (c *Connector) makeRedirect(txHash, initialAddress string,
+	amount decimal.Decimal) error {
 	// TODO(andrew.shvv) use persistence task queue on
 	// case if fails.
 
@@ -1062,21 +1588,23 @@ func (c *Connector) makeRedirect(initialAddress string, amount decimal.Decimal)
 	aggregateTx, fee, err := c.generateTransaction(initialAddress, c.defaultAddress,
 		amount, true, txCount)
 	if err != nil {
-		return errors.Errorf("unable to generate transfer tx(%v): %v", err)
+		return errors.Errorf("unable to generate transfer tx(%v): %v",
+			initialAddress, err)
 	}
 
 	aggregatePayment := &connectors.Payment{
-		UpdatedAt: connectors.NowInMilliSeconds(),
-		Status:    connectors.Waiting,
-		System:    connectors.Internal,
-		Account:   string(defaultAccount),
-		Receipt:   c.defaultAddress,
-		Asset:     c.cfg.Asset,
-		Media:     connectors.Blockchain,
-		Amount:    amount.Sub(fee),
-		MediaFee:  fee,
-		MediaID:   aggregateTx.TxID,
-		Detail:    aggregateTx,
+		UpdatedAt:      connectors.NowInMilliSeconds(),
+		Status:         connectors.Waiting,
+		System:         connectors.Internal,
+		Account:        string(defaultAccount),
+		Receipt:        c.defaultAddress,
+		Asset:          c.cfg.Asset,
+		Media:          connectors.Blockchain,
+		Amount:         amount.Sub(fee),
+		MediaFee:       fee,
+		MediaID:        aggregateTx.TxID,
+		Detail:         aggregateTx,
+		IdempotencyKey: redirectKey(txHash),
 	}
 
 	// We have to track both outgoing and incoming for consistency with other
@@ -1105,7 +1633,15 @@ func (c *Connector) makeRedirect(initialAddress string, amount decimal.Decimal)
 
 	c.log.Infof("Send redirect payment(%v)", spew.Sdump(aggregatePayment))
 
-	if _, err = c.sendPayment(aggregatePayment.PaymentID, false); err != nil {
+	if _, err = c.sendPayment(aggregatePayment); err != nil {
+		aggregatePayment.Status = connectors.Failed
+		aggregatePayment.FailureReason = err.Error()
+		aggregatePayment.UpdatedAt = connectors.NowInMilliSeconds()
+		if err := c.cfg.PaymentStorage.SavePayment(aggregatePayment); err != nil {
+			c.log.Errorf("unable update payment(%v) status: %v",
+				aggregatePayment.PaymentID, err)
+		}
+
 		return errors.Errorf("unable to send aggregate tx(%v): %v",
 			aggregatePayment.PaymentID, err)
 	}
@@ -1177,10 +1713,21 @@ func (c *Connector) syncBlock(lastSyncedBlockHash string) (*ethrpc.Block, error)
 
 	lastSyncedBlock, err := c.client.EthGetBlockByHash(lastSyncedBlockHash, false)
 	if err != nil {
-		// TODO(andrew.shvv) Check reoginizations
 		m.AddError(metrics.HighSeverity)
 		return nil, errors.Errorf("unable to get last sync block from daemon"+
 			": %v", err)
+	} else if lastSyncedBlock == nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("last sync block(%v) not found",
+			lastSyncedBlockHash)
+	}
+
+	// Continue sync from the fork block, if last synced block has been
+	// disconnected by the reorganisation.
+	lastSyncedBlock, err = c.handleReorg(lastSyncedBlock)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("unable to handle reorganisation: %v", err)
 	}
 
 	// Sync block below minimum confirmations threshold,
@@ -1256,17 +1803,18 @@ func (c *Connector) ValidateAddress(address string) error {
 
 	if err := ethereum.ValidateAddress(address); err != nil {
 		m.AddError(metrics.LowSeverity)
-		return err
+		return &connectors.ErrInvalidReceipt{Reason: err}
 	}
 
 	return nil
 }
 
 // EstimateFee estimate fee for the transaction with the given sending
-// amount.
+// amount. Priority is ignored, gas price suggested by the daemon is used.
 //
 // NOTE: Part of the connectors.Connector interface.
-func (c *Connector) EstimateFee(amount string) (decimal.Decimal, error) {
+func (c *Connector) EstimateFee(amount string,
+	priority connectors.FeePriority) (decimal.Decimal, error) {
 	m := crypto.NewMetric(c.cfg.DaemonCfg.Name, string(c.cfg.Asset),
 		common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
@@ -1275,7 +1823,8 @@ func (c *Connector) EstimateFee(amount string) (decimal.Decimal, error) {
 	gp, err := c.client.EthGasPrice()
 	if err != nil {
 		m.AddError(metrics.LowSeverity)
-		return decimal.Zero, err
+		return decimal.Zero, connectors.WrapDaemonError(c.cfg.DaemonCfg.Name,
+			err)
 	}
 
 	gasPrice := big.NewInt(0)
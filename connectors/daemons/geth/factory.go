@@ -0,0 +1,51 @@
+package geth
+
+import (
+	"github.com/bitlum/connector/connectors"
+	"github.com/go-errors/errors"
+)
+
+// StorageBackend is the storage backend which is required by the factory
+// of the connector.
+type StorageBackend interface {
+	connectors.StorageBackend
+
+	// GethAccountsStorage returns storage which is used to keep the
+	// accounts and their addresses.
+	GethAccountsStorage() AccountsStorage
+}
+
+func init() {
+	connectors.RegisterFactory(connectors.ETH, connectors.Blockchain,
+		newConnector)
+}
+
+// newConnector creates ethereum connector from the factory config.
+func newConnector(cfg *connectors.FactoryConfig) (connectors.Connector, error) {
+	storage, ok := cfg.Storage.(StorageBackend)
+	if !ok {
+		return nil, errors.Errorf("storage backend doesn't provide geth " +
+			"accounts storage")
+	}
+
+	return NewConnector(&Config{
+		Net:                 cfg.Net,
+		MinConfirmations:    cfg.Daemon.MinConfirmations,
+		SyncTickDelay:       cfg.Daemon.SyncDelay,
+		Asset:               cfg.Asset,
+		Logger:              cfg.Logger,
+		Metrics:             cfg.Metrics,
+		LastSyncedBlockHash: cfg.Daemon.ForceLastHash,
+		PaymentStorage:      cfg.PaymentStore,
+		StateStorage:        storage.ConnectorStateStorage(cfg.Asset),
+		AccountStorage:      storage.GethAccountsStorage(),
+		RetryPolicy:         cfg.Daemon.SendRetry,
+		AttemptsStorage:     storage.AttemptsStorage(),
+		DaemonCfg: &DaemonConfig{
+			Name:       "geth",
+			ServerHost: cfg.Daemon.Host,
+			ServerPort: cfg.Daemon.Port,
+			Password:   cfg.Daemon.Password,
+		},
+	})
+}
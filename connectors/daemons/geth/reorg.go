@@ -0,0 +1,154 @@
+package geth
+
+import (
+	"github.com/bitlum/connector/connectors"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/go-errors/errors"
+	"github.com/onrik/ethrpc"
+)
+
+// handleReorg checks whether the last synced block is still in the main
+// chain. If it has been disconnected by the reorganisation, completed
+// payments of the disconnected blocks are re-evaluated, and the fork block,
+// from which sync should be continued, is returned and saved as the last
+// synced block.
+func (c *Connector) handleReorg(lastSyncedBlock *ethrpc.Block) (*ethrpc.Block,
+	error) {
+
+	var disconnected []*ethrpc.Block
+
+	block := lastSyncedBlock
+	for {
+		mainBlock, err := c.client.EthGetBlockByNumber(block.Number, false)
+		if err != nil {
+			return nil, errors.Errorf("unable to get block(%v): %v",
+				block.Number, err)
+		}
+
+		if mainBlock != nil && mainBlock.Hash == block.Hash {
+			break
+		}
+
+		c.log.Warnf("Block(%v) with number(%v) has been disconnected by "+
+			"reorganisation", block.Hash, block.Number)
+		disconnected = append(disconnected, block)
+
+		// Disconnected blocks are kept by the daemon, that is why parent
+		// could be fetched by hash.
+		parentHash := block.ParentHash
+		block, err = c.client.EthGetBlockByHash(parentHash, false)
+		if err != nil {
+			return nil, errors.Errorf("unable to get block(%v): %v",
+				parentHash, err)
+		} else if block == nil {
+			return nil, errors.Errorf("parent block(%v) of disconnected "+
+				"block not found", parentHash)
+		}
+	}
+
+	if len(disconnected) == 0 {
+		return lastSyncedBlock, nil
+	}
+
+	c.log.Warnf("Reorganisation has been detected, last synced block(%v), "+
+		"fork block(%v)", lastSyncedBlock.Hash, block.Hash)
+
+	for _, disconnectedBlock := range disconnected {
+		fullBlock, err := c.client.EthGetBlockByHash(disconnectedBlock.Hash,
+			true)
+		if err != nil {
+			return nil, errors.Errorf("unable to get block(%v): %v",
+				disconnectedBlock.Hash, err)
+		} else if fullBlock == nil {
+			return nil, errors.Errorf("disconnected block(%v) not found",
+				disconnectedBlock.Hash)
+		}
+
+		for _, tx := range fullBlock.Transactions {
+			if err := c.revertTransaction(tx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := c.cfg.StateStorage.PutLastSyncedHash([]byte(block.Hash)); err != nil {
+		return nil, errors.Errorf("unable to put block hash in db: %v", err)
+	}
+
+	return block, nil
+}
+
+// revertTransaction moves completed payments of the transaction from the
+// disconnected block back to pending, if transaction has been mined again
+// or it is in the mempool, or to reverted otherwise. Payments which are
+// mined again are completed by the sync after they receive enough
+// confirmations.
+func (c *Connector) revertTransaction(tx ethrpc.Transaction) error {
+	// Contract creation transactions are not tracked.
+	if tx.To == "" {
+		return nil
+	}
+
+	var payments []*connectors.Payment
+	for _, direction := range []connectors.PaymentDirection{
+		connectors.Incoming, connectors.Outgoing} {
+
+		for _, system := range []connectors.PaymentSystem{
+			connectors.External, connectors.Internal} {
+
+			id := connectors.GeneratePaymentID(tx.Hash, tx.To,
+				string(direction), string(system))
+
+			payment, err := c.cfg.PaymentStorage.PaymentByID(id)
+			if err != nil || payment.Status != connectors.Completed {
+				continue
+			}
+
+			payments = append(payments, payment)
+		}
+	}
+
+	if len(payments) == 0 {
+		return nil
+	}
+
+	status := connectors.Reverted
+
+	// Receipt is returned only for the transactions which are in the main
+	// chain, and empty receipt is returned for others.
+	receipt, err := c.client.EthGetTransactionReceipt(tx.Hash)
+	if err != nil {
+		return errors.Errorf("unable to get transaction receipt for "+
+			"tx(%v): %v", tx.Hash, err)
+	}
+
+	if receipt.BlockHash != "" {
+		status = connectors.Pending
+	} else {
+		mempoolTx, err := c.client.EthGetTransactionByHash(tx.Hash)
+		if err != nil {
+			return errors.Errorf("unable to get tx(%v): %v", tx.Hash, err)
+		}
+
+		if mempoolTx.Hash != "" {
+			status = connectors.Pending
+		}
+	}
+
+	for _, payment := range payments {
+		payment.Status = status
+		payment.UpdatedAt = connectors.NowInMilliSeconds()
+		if err := c.cfg.PaymentStorage.SavePayment(payment); err != nil {
+			return errors.Errorf("unable to save payment(%v): %v",
+				payment.PaymentID, err)
+		}
+
+		c.log.Errorf("Completed payment(%v) has been moved to %v by chain "+
+			"reorganisation: %v", payment.PaymentID, status,
+			spew.Sdump(payment))
+		c.cfg.Metrics.AddReorganisedPayment(c.cfg.DaemonCfg.Name,
+			string(c.cfg.Asset), string(status))
+	}
+
+	return nil
+}
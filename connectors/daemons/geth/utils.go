@@ -1,8 +1,11 @@
 package geth
 
 import (
+	"strings"
+
 	"github.com/bitlum/connector/connectors"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/go-errors/errors"
 )
 
 // pendingMap stores the information about pending transactions corresponding
@@ -78,3 +81,20 @@ func generatePaymentID(txID, receiveAddress string,
 	return connectors.GeneratePaymentID(txID, receiveAddress,
 		string(direction), string(system))
 }
+
+// convertRPCError converts error returned by the daemon to the typed
+// connector error. If error couldn't be classified, it is annotated with
+// the given description.
+func convertRPCError(daemon string, err error, desc string) error {
+	if strings.Contains(err.Error(), "insufficient funds") {
+		return &connectors.ErrInsufficientFunds{}
+	}
+
+	if err := connectors.WrapDaemonError(daemon, err); err != nil {
+		if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+			return err
+		}
+	}
+
+	return errors.Errorf("%v: %v", desc, err)
+}
@@ -0,0 +1,29 @@
+package lnd
+
+import (
+	"github.com/bitlum/connector/connectors"
+)
+
+func init() {
+	connectors.RegisterFactory(connectors.BTC, connectors.Lightning,
+		newConnector)
+}
+
+// newConnector creates bitcoin lightning connector from the factory config.
+func newConnector(cfg *connectors.FactoryConfig) (connectors.Connector, error) {
+	return NewConnector(&Config{
+		PeerHost:     cfg.Daemon.PeerHost,
+		PeerPort:     cfg.Daemon.PeerPort,
+		Net:          cfg.Net,
+		Name:         "lnd",
+		Host:         cfg.Daemon.Host,
+		Port:         cfg.Daemon.Port,
+		TlsCertPath:  cfg.Daemon.TLSCertPath,
+		MacaroonPath: cfg.Daemon.MacaroonPath,
+		Metrics:      cfg.Metrics,
+		PaymentStore: cfg.PaymentStore,
+
+		RetryPolicy:     cfg.Daemon.SendRetry,
+		AttemptsStorage: cfg.Storage.AttemptsStorage(),
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 
 	"encoding/hex"
+	"strings"
 
 	"github.com/bitlum/connector/connectors"
 	"github.com/bitlum/connector/connectors/rpc/bitcoin"
@@ -65,6 +66,14 @@ type Config struct {
 	// PaymentStorage is an external storage for payments, it is used by
 	// connector to save payment as well as update its state.
 	PaymentStore connectors.PaymentsStore
+
+	// RetryPolicy determines how outgoing payments which couldn't be sent
+	// are retried.
+	RetryPolicy connectors.RetryPolicy
+
+	// AttemptsStorage is used to persist attempts of the outgoing
+	// payments which are being retried.
+	AttemptsStorage connectors.AttemptsStorage
 }
 
 func (c *Config) validate() error {
@@ -100,6 +109,10 @@ func (c *Config) validate() error {
 		return errors.New("payment store should be specified")
 	}
 
+	if c.AttemptsStorage == nil {
+		return errors.New("attempts storage should be specified")
+	}
+
 	return nil
 }
 
@@ -120,6 +133,16 @@ type Connector struct {
 	// averageFee is an average fee which connectors pays to lightning
 	// network for routing the payment.
 	averageFee decimal.Decimal
+
+	// sendMtx is used to prevent the same payment to be sent twice, in case
+	// of the concurrent requests with the same idempotency key.
+	sendMtx sync.Mutex
+
+	// sender sends outgoing payments and retries the ones which couldn't
+	// be sent.
+	sender *connectors.PaymentSender
+
+	lifecycle connectors.Lifecycle
 }
 
 // Runtime check to ensure that Connector implements connectors.
@@ -132,11 +155,30 @@ func NewConnector(cfg *Config) (*Connector, error) {
 		return nil, errors.Errorf("config is invalid: %v", err)
 	}
 
-	return &Connector{
+	c := &Connector{
 		cfg:           cfg,
 		notifications: make(chan *connectors.Payment),
 		quit:          make(chan struct{}),
-	}, nil
+	}
+
+	// Payment is sent synchronously by the lnd, that is why send mutex
+	// isn't held during the sending, and it isn't used as locker.
+	var err error
+	c.sender, err = connectors.NewPaymentSender(&connectors.PaymentSenderConfig{
+		Asset:        connectors.BTC,
+		Media:        connectors.Lightning,
+		Policy:       cfg.RetryPolicy,
+		Storage:      cfg.AttemptsStorage,
+		PaymentStore: cfg.PaymentStore,
+		Send:         c.sendPayment,
+		Lookup:       c.lookupPayment,
+		Logger:       log,
+	})
+	if err != nil {
+		return nil, errors.Errorf("unable to create payment sender: %v", err)
+	}
+
+	return c, nil
 }
 
 func (c *Connector) Start() (err error) {
@@ -151,6 +193,8 @@ func (c *Connector) Start() (err error) {
 		if err != nil {
 			atomic.SwapInt32(&c.started, 0)
 		}
+
+		c.lifecycle.Started(err)
 	}()
 
 	m := crypto.NewMetric(c.cfg.Name, "BTC", common.GetFunctionName(), c.cfg.Metrics)
@@ -320,6 +364,15 @@ func (c *Connector) Start() (err error) {
 		}
 	}()
 
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		log.Info("Starting payments retry goroutine...")
+		c.sender.Run(c.quit)
+		log.Info("Quit payments retry goroutine")
+	}()
+
 	log.Info("lightning client started")
 	return err
 }
@@ -332,16 +385,56 @@ func (c *Connector) Stop(reason string) error {
 	}
 
 	close(c.quit)
-	if err := c.conn.Close(); err != nil {
-		return errors.Errorf("unable to close connection to lnd: %v", err)
+
+	// Connection is created on start, that is why it might be nil if
+	// connector hasn't been started.
+	if c.conn != nil {
+		if err := c.conn.Close(); err != nil {
+			return errors.Errorf("unable to close connection to lnd: %v", err)
+		}
 	}
 
 	c.wg.Wait()
+	c.lifecycle.Stopped()
 
 	log.Infof("lightning client shutdown, reason(%v)", reason)
 	return nil
 }
 
+// Status returns the lifecycle state of the connector, and the state of
+// the lightning network node.
+//
+// NOTE: Part of the connectors.LightningConnector interface.
+func (c *Connector) Status() *connectors.ConnectorStatus {
+	status := c.lifecycle.Status()
+	status.Net = c.cfg.Net
+
+	// Client is created on start, that is why daemon couldn't be
+	// requested before it.
+	if status.State != connectors.Running {
+		return status
+	}
+
+	info, err := c.Info()
+	if err != nil {
+		status.DaemonError = connectors.WrapDaemonError(c.cfg.Name,
+			err).Error()
+		return status
+	}
+
+	status.DaemonVersion = info.Version
+	status.BestHeight = int64(info.BlockHeight)
+	status.Lightning = info
+
+	// Lnd processes blocks by itself, so if it is synced to chain, than
+	// connector is synced as well.
+	if info.SyncedToChain {
+		status.SyncedHeight = int64(info.BlockHeight)
+	}
+
+	return status
+}
+
 // CreateInvoice is used to create lightning network invoice.
 //
 // NOTE: Part of the connectors.LightningConnector interface.
@@ -353,7 +446,7 @@ description string) (string, *zpay32.Invoice, error) {
 	satoshis, err := btcToSatoshi(amount)
 	if err != nil {
 		m.AddError(metrics.LowSeverity)
-		return "", nil, err
+		return "", nil, &connectors.ErrInvalidAmount{Amount: amount, Reason: err}
 	}
 
 	expirationTime := time.Minute * 15
@@ -367,7 +460,7 @@ description string) (string, *zpay32.Invoice, error) {
 	invoiceResp, err := c.client.AddInvoice(context.Background(), invoiceReq)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return "", nil, err
+		return "", nil, connectors.WrapDaemonError(c.cfg.Name, err)
 	}
 
 	// Check that invoice is valid, and that amount which we are sending is
@@ -391,31 +484,184 @@ description string) (string, *zpay32.Invoice, error) {
 // payment system.
 //
 // NOTE: Part of the connectors.LightningConnector interface.
-func (c *Connector) SendTo(invoiceStr, amountStr string) (*connectors.Payment,
-	error) {
+func (c *Connector) SendTo(invoiceStr, amountStr,
+	idempotencyKey string) (*connectors.Payment, error) {
 	m := crypto.NewMetric(c.cfg.Name, "BTC", common.GetFunctionName(), c.cfg.Metrics)
 	defer m.Finish()
 
-	// Check that invoice is valid, and that amount which we are sending is
-	// corresponding to what we expect.
-	netParams, err := bitcoin.GetParams(c.cfg.Net)
+	invoice, amountToSendSat, inputAmountSat, err := c.decodePayment(
+		invoiceStr, amountStr)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	// Save payment before sending it, so that the repeated request with the
+	// same idempotency key would not send it again while the first one is
+	// still in progress.
+	c.sendMtx.Lock()
+	payment, created, err := c.newPayment(invoiceStr, invoice,
+		amountToSendSat, inputAmountSat, decimal.Zero, connectors.Pending,
+		idempotencyKey)
+	c.sendMtx.Unlock()
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	if !created {
+		log.Infof("Payment(%v) with idempotency key(%v) has been already "+
+			"sent", payment.PaymentID, idempotencyKey)
+		return payment, nil
+	}
+
+	payment, err = c.sender.Send(payment)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
 		return nil, err
 	}
 
-	invoice, err := zpay32.Decode(invoiceStr, netParams)
+	return payment, nil
+}
+
+// CreatePayment finds the route to the invoice destination, and stores the
+// payment as waiting for the approval with the fee of this route.
+//
+// NOTE: Part of the connectors.LightningConnector interface.
+func (c *Connector) CreatePayment(invoiceStr, amountStr,
+	idempotencyKey string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.cfg.Name, "BTC", common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	invoice, amountToSendSat, inputAmountSat, err := c.decodePayment(
+		invoiceStr, amountStr)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	fee, err := c.routeFee(invoice, amountToSendSat)
+	if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	} else if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, errors.Errorf("unable to find route: %v", err)
+	}
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, created, err := c.newPayment(invoiceStr, invoice,
+		amountToSendSat, inputAmountSat, fee, connectors.Waiting,
+		idempotencyKey)
+	if err != nil {
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	if !created {
+		log.Infof("Payment(%v) with idempotency key(%v) has been already "+
+			"created", payment.PaymentID, idempotencyKey)
+		return payment, nil
+	}
+
+	log.Infof("Create payment %v", spew.Sdump(payment))
+
+	return payment, nil
+}
+
+// ConfirmPayment sends previously created waiting payment. Fee of the
+// payment is limited by the fee of the route found on creation.
+//
+// NOTE: Part of the connectors.LightningConnector interface.
+func (c *Connector) ConfirmPayment(paymentID string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.cfg.Name, "BTC", common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	// Mark payment as pending under the mutex, so that concurrent
+	// confirmation or cancellation wouldn't be possible.
+	c.sendMtx.Lock()
+	payment, err := connectors.WaitingPayment(c.cfg.PaymentStore, paymentID,
+		connectors.BTC, connectors.Lightning)
+	if err != nil {
+		c.sendMtx.Unlock()
+		m.AddError(metrics.LowSeverity)
+		return nil, err
+	}
+
+	payment.Status = connectors.Pending
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	err = c.cfg.PaymentStore.SavePayment(payment)
+	c.sendMtx.Unlock()
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("unable add payment in store: %v", err)
+	}
+
+	payment, err = c.sender.Send(payment)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// CancelPayment cancels previously created waiting payment.
+//
+// NOTE: Part of the connectors.LightningConnector interface.
+func (c *Connector) CancelPayment(paymentID string) (*connectors.Payment, error) {
+	m := crypto.NewMetric(c.cfg.Name, "BTC", common.GetFunctionName(), c.cfg.Metrics)
+	defer m.Finish()
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	payment, err := connectors.WaitingPayment(c.cfg.PaymentStore, paymentID,
+		connectors.BTC, connectors.Lightning)
 	if err != nil {
 		m.AddError(metrics.LowSeverity)
 		return nil, err
 	}
 
+	payment.Status = connectors.Failed
+	payment.FailureReason = connectors.CanceledReason
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		m.AddError(metrics.HighSeverity)
+		return nil, errors.Errorf("unable add payment in store: %v", err)
+	}
+
+	log.Infof("Cancel payment %v", spew.Sdump(payment))
+
+	return payment, nil
+}
+
+// decodePayment decodes the invoice and checks that amount which we are
+// sending is corresponding to what we expect. Returns decoded invoice,
+// amount which has to be sent and amount which was specified by user.
+func (c *Connector) decodePayment(invoiceStr, amountStr string) (
+	*zpay32.Invoice, int64, int64, error) {
+
+	netParams, err := bitcoin.GetParams(c.cfg.Net)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	invoice, err := zpay32.Decode(invoiceStr, netParams)
+	if err != nil {
+		return nil, 0, 0, &connectors.ErrInvalidReceipt{Reason: err}
+	}
+
 	var inputAmountSat int64
 	if amountStr != "" {
 		inputAmountSat, err = btcToSatoshi(amountStr)
 		if err != nil {
-			m.AddError(metrics.LowSeverity)
-			return nil, err
+			return nil, 0, 0, &connectors.ErrInvalidAmount{
+				Amount: amountStr,
+				Reason: err,
+			}
 		}
 	}
 
@@ -440,20 +686,108 @@ func (c *Connector) SendTo(invoiceStr, amountStr string) (*connectors.Payment,
 		// If both amounts are specified that we should check that they are
 		// equal.
 		if inputAmountSat != invoiceAmountSat {
-			m.AddError(metrics.LowSeverity)
-			return nil, errors.Errorf("amount are not equal: invoice amount("+
-				"%v), and input amount(%v)", btcutil.Amount(inputAmountSat),
-				btcutil.Amount(invoiceAmountSat))
+			return nil, 0, 0, &connectors.ErrAmountMismatch{
+				ReceiptAmount: sat2DecAmount(btcutil.Amount(invoiceAmountSat)),
+				Amount:        sat2DecAmount(btcutil.Amount(inputAmountSat)),
+			}
 		}
 		amountToSendSat = inputAmountSat
 
 	} else {
-		m.AddError(metrics.LowSeverity)
-		return nil, errors.Errorf("invoice and user amount are not specified")
+		return nil, 0, 0, &connectors.ErrInvalidAmount{
+			Amount: amountStr,
+			Reason: errors.New("invoice and user amount are not specified"),
+		}
+	}
+
+	return invoice, amountToSendSat, inputAmountSat, nil
+}
+
+// newPayment checks that invoice could be paid, and saves new outgoing
+// payment with the given status and fee. If payment with the given
+// idempotency key already exists, it is returned instead, and created flag
+// is false.
+//
+// NOTE: Should be called under the send mutex.
+func (c *Connector) newPayment(invoiceStr string, invoice *zpay32.Invoice,
+	amountToSendSat, inputAmountSat int64, fee decimal.Decimal,
+	status connectors.PaymentStatus, idempotencyKey string) (
+	*connectors.Payment, bool, error) {
+
+	payment, err := connectors.PaymentByIdempotencyKey(c.cfg.PaymentStore,
+		idempotencyKey, connectors.BTC, connectors.Lightning, invoiceStr,
+		sat2DecAmount(btcutil.Amount(inputAmountSat)))
+	if err != nil {
+		return nil, false, err
+	} else if payment != nil {
+		return payment, false, nil
+	}
+
+	// Invoice couldn't be paid twice, and we shouldn't overwrite the state
+	// of the previous payment.
+	paymentID := generatePaymentID(invoiceStr, connectors.Outgoing)
+	prevPayment, err := c.cfg.PaymentStore.PaymentByID(paymentID)
+	if err == nil && prevPayment.Status != connectors.Failed {
+		return nil, false, errors.Errorf("invoice has been already paid, "+
+			"payment(%v)", paymentID)
+	}
+
+	payment = &connectors.Payment{
+		PaymentID:      paymentID,
+		UpdatedAt:      connectors.NowInMilliSeconds(),
+		Status:         status,
+		System:         connectors.External,
+		Direction:      connectors.Outgoing,
+		Receipt:        invoiceStr,
+		Asset:          connectors.BTC,
+		Media:          connectors.Lightning,
+		Amount:         sat2DecAmount(btcutil.Amount(amountToSendSat)),
+		MediaFee:       fee,
+		MediaID:        hex.EncodeToString(invoice.PaymentHash[:]),
+		IdempotencyKey: idempotencyKey,
+	}
+
+	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return nil, false, errors.Errorf("unable add payment in store: %v",
+			err)
+	}
+
+	return payment, true, nil
+}
+
+// sendPayment sends previously saved payment to the recipient, and waits
+// for it to be received. Status of the unsent payment is left to the
+// payment sender.
+func (c *Connector) sendPayment(payment *connectors.Payment) (
+	*connectors.Payment, error) {
+
+	netParams, err := bitcoin.GetParams(c.cfg.Net)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := zpay32.Decode(payment.Receipt, netParams)
+	if err != nil {
+		return nil, &connectors.ErrInvalidReceipt{Reason: err}
+	}
+
+	// Fee of the payment which has been created and confirmed is limited
+	// by the fee of the route found on creation. Payments which are sent
+	// right away don't have the fee until they are sent.
+	feeLimit := &lnrpc.FeeLimit{
+		Limit: &lnrpc.FeeLimit_Percent{
+			Percent: 3,
+		},
+	}
+	if !payment.MediaFee.IsZero() {
+		feeLimit = &lnrpc.FeeLimit{
+			Limit: &lnrpc.FeeLimit_Fixed{
+				Fixed: int64(decAmount2Sat(payment.MediaFee)),
+			},
+		}
 	}
 
-	var mediaFee decimal.Decimal
-	paymentHash := hex.EncodeToString(invoice.PaymentHash[:])
+	amountToSendSat := int64(decAmount2Sat(payment.Amount))
 	receiverNodeAddr := hex.EncodeToString(invoice.Destination.
 		SerializeCompressed())
 
@@ -461,69 +795,59 @@ func (c *Connector) SendTo(invoiceStr, amountStr string) (*connectors.Payment,
 		// If we try to send payment to ourselves, than lightning network daemon
 		// will fail, for that reason we handle this and pretend as if payment
 		// was actually has been made.
-		payment := &connectors.Payment{
-			PaymentID: generatePaymentID(invoiceStr, connectors.Incoming),
+		incomingPayment := &connectors.Payment{
+			PaymentID: generatePaymentID(payment.Receipt, connectors.Incoming),
 			UpdatedAt: connectors.NowInMilliSeconds(),
 			Status:    connectors.Completed,
 			Direction: connectors.Incoming,
 			System:    connectors.External,
-			Receipt:   invoiceStr,
+			Receipt:   payment.Receipt,
 			Asset:     connectors.BTC,
 			Media:     connectors.Lightning,
-			Amount:    sat2DecAmount(btcutil.Amount(amountToSendSat)),
-			MediaFee:  mediaFee,
-			MediaID:   paymentHash,
+			Amount:    payment.Amount,
+			MediaFee:  decimal.Zero,
+			MediaID:   payment.MediaID,
 		}
 
-		if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-			m.AddError(metrics.HighSeverity)
+		if err := c.cfg.PaymentStore.SavePayment(incomingPayment); err != nil {
 			return nil, errors.Errorf("unable add payment in store: %v", err)
 		}
+
+		payment.MediaFee = decimal.Zero
 	} else {
 		// Send payment to the recipient and wait for it to be received.
 		req := &lnrpc.SendRequest{
 			Amt:            amountToSendSat,
-			PaymentRequest: invoiceStr,
-			FeeLimit: &lnrpc.FeeLimit{
-				Limit: &lnrpc.FeeLimit_Percent{
-					Percent: 3,
-				},
-			},
+			PaymentRequest: payment.Receipt,
+			FeeLimit:       feeLimit,
 		}
 
 		// TODO(andrew.shvv) Use async version and return waiting payment after
 		// 3-5 seconds.
 		resp, err := c.client.SendPaymentSync(context.Background(), req)
 		if err != nil {
-			m.AddError(metrics.HighSeverity)
+			err = connectors.WrapDaemonError(c.cfg.Name, err)
+			if _, ok := err.(*connectors.ErrDaemonUnavailable); ok {
+				return nil, err
+			}
 			return nil, errors.Errorf("unable to send payment: %v", err)
 		}
 
 		if resp.PaymentError != "" {
-			m.AddError(metrics.HighSeverity)
+			if strings.Contains(resp.PaymentError, "insufficient") {
+				return nil, &connectors.ErrInsufficientFunds{}
+			}
 			return nil, errors.Errorf("unable to send payment: %v", resp.PaymentError)
 		}
 
-		mediaFee = sat2DecAmount(btcutil.Amount(resp.PaymentRoute.TotalFees))
-		c.averageFee = c.averageFee.Add(mediaFee).Div(decimal.NewFromFloat(2.0))
+		payment.MediaFee = sat2DecAmount(btcutil.Amount(resp.PaymentRoute.TotalFees))
+		c.averageFee = c.averageFee.Add(payment.MediaFee).Div(decimal.NewFromFloat(2.0))
 	}
 
-	payment := &connectors.Payment{
-		PaymentID: generatePaymentID(invoiceStr, connectors.Outgoing),
-		UpdatedAt: connectors.NowInMilliSeconds(),
-		Status:    connectors.Completed,
-		System:    connectors.External,
-		Direction: connectors.Outgoing,
-		Receipt:   invoiceStr,
-		Asset:     connectors.BTC,
-		Media:     connectors.Lightning,
-		Amount:    sat2DecAmount(btcutil.Amount(amountToSendSat)),
-		MediaFee:  mediaFee,
-		MediaID:   paymentHash,
-	}
+	payment.Status = connectors.Completed
+	payment.UpdatedAt = connectors.NowInMilliSeconds()
 
 	if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
-		m.AddError(metrics.HighSeverity)
 		return nil, errors.Errorf("unable add payment in store: %v", err)
 	}
 
@@ -532,6 +856,97 @@ func (c *Connector) SendTo(invoiceStr, amountStr string) (*connectors.Payment,
 	return payment, nil
 }
 
+// routeFee returns fee of the cheapest route to the invoice destination.
+// If invoice contains route hints, fee of the hinted hops is added as well.
+func (c *Connector) routeFee(invoice *zpay32.Invoice,
+	amountSat int64) (decimal.Decimal, error) {
+
+	// Fee to our own node is zero.
+	destination := hex.EncodeToString(invoice.Destination.SerializeCompressed())
+	if destination == c.nodeAddr {
+		return decimal.Zero, nil
+	}
+
+	pubKey := destination
+
+	// TODO(andrew.shvv) There might several route hints
+	var hintFeeMSat int64
+	if len(invoice.RouteHints) != 0 && len(invoice.RouteHints[0]) != 0 {
+		hops := invoice.RouteHints[0]
+		pubKey = hex.EncodeToString(hops[0].NodeID.SerializeCompressed())
+
+		for _, hop := range hops {
+			hintFeeMSat += int64(hop.FeeBaseMSat) +
+				amountSat*1000*int64(hop.FeeProportionalMillionths)/1000000
+		}
+	}
+
+	var routesFeeSat int64
+	if pubKey != c.nodeAddr {
+		req := &lnrpc.QueryRoutesRequest{
+			PubKey: pubKey,
+			Amt:    amountSat,
+			FeeLimit: &lnrpc.FeeLimit{
+				Limit: &lnrpc.FeeLimit_Percent{
+					Percent: 3,
+				},
+			},
+			NumRoutes: 10,
+		}
+
+		resp, err := c.client.QueryRoutes(context.Background(), req)
+		if err != nil {
+			return decimal.Zero, connectors.WrapDaemonError(c.cfg.Name, err)
+		}
+
+		if len(resp.Routes) == 0 {
+			return decimal.Zero, errors.Errorf("no routes to node(%v)", pubKey)
+		}
+
+		routesFeeSat = resp.Routes[0].TotalFees
+		for _, route := range resp.Routes {
+			if route.TotalFees < routesFeeSat {
+				routesFeeSat = route.TotalFees
+			}
+		}
+	}
+
+	// Round up hinted hops fee to satoshis, so that payment wouldn't fail
+	// because of the fee limit.
+	feeSat := routesFeeSat + (hintFeeMSat+999)/1000
+	return sat2DecAmount(btcutil.Amount(feeSat)), nil
+}
+
+// lookupPayment checks whether payment with the same hash has been already
+// completed by the lnd, so that it wouldn't be sent twice. If so, payment is
+// marked as completed.
+func (c *Connector) lookupPayment(payment *connectors.Payment) (
+	*connectors.Payment, error) {
+
+	resp, err := c.client.ListPayments(context.Background(),
+		&lnrpc.ListPaymentsRequest{})
+	if err != nil {
+		return nil, connectors.WrapDaemonError(c.cfg.Name, err)
+	}
+
+	for _, lndPayment := range resp.Payments {
+		if lndPayment.PaymentHash != payment.MediaID {
+			continue
+		}
+
+		payment.Status = connectors.Completed
+		payment.MediaFee = sat2DecAmount(btcutil.Amount(lndPayment.Fee))
+		payment.UpdatedAt = connectors.NowInMilliSeconds()
+		if err := c.cfg.PaymentStore.SavePayment(payment); err != nil {
+			return nil, errors.Errorf("unable add payment in store: %v", err)
+		}
+
+		return payment, nil
+	}
+
+	return nil, nil
+}
+
 // ReceivedPayments returns channel with transactions which are passed
 // the minimum threshold required by the client to treat as confirmed.
 //
@@ -624,13 +1039,13 @@ amountStr string) (*zpay32.Invoice, error) {
 	amount, err := btcToSatoshi(amountStr)
 	if err != nil {
 		m.AddError(metrics.LowSeverity)
-		return nil, errors.Errorf("unable convert amount: %v", err)
+		return nil, &connectors.ErrInvalidAmount{Amount: amountStr, Reason: err}
 	}
 
 	invoice, err := zpay32.Decode(invoiceStr, netParams)
 	if err != nil {
 		m.AddError(metrics.LowSeverity)
-		return nil, errors.Errorf("unable decode invoice: %v", err)
+		return nil, &connectors.ErrInvalidReceipt{Reason: err}
 	}
 
 	// Only if amount is specified we need to check that it is the same as in
@@ -640,10 +1055,10 @@ amountStr string) (*zpay32.Invoice, error) {
 		if invoice.MilliSat != nil {
 			if invoice.MilliSat.ToSatoshis() != btcutil.Amount(amount) {
 				m.AddError(metrics.LowSeverity)
-				return nil, errors.Errorf("wrong amount received("+
-					"%v) and in invoice("+
-					"%v)", sat2DecAmount(btcutil.Amount(amount)).Round(8),
-					sat2DecAmount(invoice.MilliSat.ToSatoshis()).Round(8))
+				return nil, &connectors.ErrAmountMismatch{
+					ReceiptAmount: sat2DecAmount(invoice.MilliSat.ToSatoshis()),
+					Amount:        sat2DecAmount(btcutil.Amount(amount)),
+				}
 			}
 		}
 	}
@@ -663,7 +1078,7 @@ func (c *Connector) ConfirmedBalance() (decimal.Decimal, error) {
 	resp, err := c.client.WalletBalance(context.Background(), req)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return decimal.Zero, err
+		return decimal.Zero, connectors.WrapDaemonError(c.cfg.Name, err)
 	}
 
 	balanceSatoshis := decimal.New(resp.ConfirmedBalance, 0)
@@ -683,7 +1098,7 @@ func (c *Connector) PendingBalance() (decimal.Decimal, error) {
 	resp, err := c.client.WalletBalance(context.Background(), req)
 	if err != nil {
 		m.AddError(metrics.HighSeverity)
-		return decimal.Zero, err
+		return decimal.Zero, connectors.WrapDaemonError(c.cfg.Name, err)
 	}
 
 	balanceSatoshis := decimal.New(resp.UnconfirmedBalance, 0)
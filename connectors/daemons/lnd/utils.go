@@ -34,6 +34,10 @@ func btcToSatoshi(amount string) (int64, error) {
 	return int64(btcAmount), nil
 }
 
+func decAmount2Sat(amount decimal.Decimal) btcutil.Amount {
+	return btcutil.Amount(amount.Mul(satoshiPerBitcoin).IntPart())
+}
+
 func sat2DecAmount(amount btcutil.Amount) decimal.Decimal {
 	amt := decimal.NewFromBigInt(big.NewInt(int64(amount)), 0)
 	return amt.Div(satoshiPerBitcoin).Round(8)
@@ -0,0 +1,69 @@
+package connectors
+
+// DerivationScheme is the scheme by which deposit addresses are derived
+// from the extended public key.
+type DerivationScheme string
+
+const (
+	// BIP32 derives pay-to-pubkey-hash addresses from the direct children
+	// of the extended public key.
+	BIP32 DerivationScheme = "bip32"
+
+	// BIP44 derives pay-to-pubkey-hash addresses from the external chain
+	// of the account extended public key, m/44'/coin'/account'.
+	BIP44 DerivationScheme = "bip44"
+
+	// BIP84 derives pay-to-witness-pubkey-hash addresses from the external
+	// chain of the account extended public key, m/84'/coin'/account'.
+	BIP84 DerivationScheme = "bip84"
+)
+
+// DerivationPolicy determines how deposit addresses are derived from the
+// extended public key, instead of being created by the daemon wallet, so
+// that private keys are kept out of the daemon.
+type DerivationPolicy struct {
+	// XPub is the extended public key from which addresses are derived.
+	// Empty value disables derivation.
+	XPub string
+
+	// Scheme is the derivation scheme of the extended public key, BIP44
+	// is used if it isn't specified.
+	Scheme DerivationScheme
+
+	// Origin is the fingerprint of the master key and the derivation path
+	// of the extended public key, e.g. "d34db33f/84'/0'/0'", which is
+	// given to the signers of the partially signed transactions. If it
+	// isn't specified, extended public key is treated as the master key.
+	Origin string
+}
+
+// Enabled returns true if deposit addresses should be derived from the
+// extended public key.
+func (p DerivationPolicy) Enabled() bool {
+	return p.XPub != ""
+}
+
+// DerivationScheme returns the derivation scheme of the extended public
+// key.
+func (p DerivationPolicy) DerivationScheme() DerivationScheme {
+	if p.Scheme == "" {
+		return BIP44
+	}
+
+	return p.Scheme
+}
+
+// DerivationStorage is used to keep the index of the next address which
+// should be derived from the extended public key, so that the same address
+// isn't given twice.
+//
+// NOTE: This storage should be persistent.
+type DerivationStorage interface {
+	// NextDerivationIndex reserves and returns the index of the next
+	// address which should be derived from the extended public key.
+	NextDerivationIndex(asset Asset, xpub string) (uint32, error)
+
+	// DerivationIndex returns the index of the next address which should
+	// be derived from the extended public key, without reserving it.
+	DerivationIndex(asset Asset, xpub string) (uint32, error)
+}
@@ -0,0 +1,151 @@
+package connectors
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrInsufficientFunds is returned when connector doesn't have enough funds
+// to send the payment and pay its fee.
+type ErrInsufficientFunds struct {
+	// Needed is the amount which is needed to send the payment, fee
+	// included. Zero value means that it is unknown.
+	Needed decimal.Decimal
+
+	// Available is the amount which connector is able to spend. Zero
+	// value means that it is unknown.
+	Available decimal.Decimal
+}
+
+func (e *ErrInsufficientFunds) Error() string {
+	if e.Needed.IsZero() {
+		return "insufficient funds"
+	}
+
+	return fmt.Sprintf("insufficient funds, need %v only have %v available",
+		e.Needed.Round(8), e.Available.Round(8))
+}
+
+// ErrInvalidReceipt is returned when receipt, i.e. blockchain address or
+// lightning network invoice, is malformed or belongs to another network.
+type ErrInvalidReceipt struct {
+	Reason error
+}
+
+func (e *ErrInvalidReceipt) Error() string {
+	return fmt.Sprintf("invalid receipt: %v", e.Reason)
+}
+
+// ErrInvalidAmount is returned when amount couldn't be parsed or it is
+// out of the allowed range.
+type ErrInvalidAmount struct {
+	Amount string
+	Reason error
+}
+
+func (e *ErrInvalidAmount) Error() string {
+	return fmt.Sprintf("invalid amount(%v): %v", e.Amount, e.Reason)
+}
+
+// ErrAmountMismatch is returned when the amount encoded in the receipt
+// doesn't correspond to the given amount.
+type ErrAmountMismatch struct {
+	ReceiptAmount decimal.Decimal
+	Amount        decimal.Decimal
+}
+
+func (e *ErrAmountMismatch) Error() string {
+	return fmt.Sprintf("amount are not equal: receipt amount(%v), "+
+		"and input amount(%v)", e.ReceiptAmount.Round(8), e.Amount.Round(8))
+}
+
+// ErrInvalidFeeRate is returned when fee rate couldn't be parsed or it
+// isn't enough to replace the transaction.
+type ErrInvalidFeeRate struct {
+	FeeRate string
+	Reason  error
+}
+
+func (e *ErrInvalidFeeRate) Error() string {
+	return fmt.Sprintf("invalid fee rate(%v): %v", e.FeeRate, e.Reason)
+}
+
+// ErrNotReplaceable is returned when transaction of the payment couldn't
+// be replaced with the transaction paying bigger fee.
+type ErrNotReplaceable struct {
+	PaymentID string
+	Reason    error
+}
+
+func (e *ErrNotReplaceable) Error() string {
+	return fmt.Sprintf("transaction of payment(%v) couldn't be replaced: %v",
+		e.PaymentID, e.Reason)
+}
+
+// ErrInvalidPSBT is returned when partially signed transaction couldn't be
+// parsed, it doesn't correspond to the transaction of the payment, or it
+// isn't signed enough to be finalized.
+type ErrInvalidPSBT struct {
+	PaymentID string
+	Reason    error
+}
+
+func (e *ErrInvalidPSBT) Error() string {
+	return fmt.Sprintf("invalid psbt of payment(%v): %v", e.PaymentID,
+		e.Reason)
+}
+
+// ErrPSBTNotSupported is returned when partially signed transactions are
+// requested, but connector or its daemon doesn't support them.
+var ErrPSBTNotSupported = errors.New("partially signed transactions " +
+	"are not supported")
+
+// ErrDaemonUnavailable is returned when connector is unable to reach its
+// daemon.
+type ErrDaemonUnavailable struct {
+	Daemon string
+	Reason error
+}
+
+func (e *ErrDaemonUnavailable) Error() string {
+	return fmt.Sprintf("daemon(%v) is unavailable: %v", e.Daemon, e.Reason)
+}
+
+// WrapDaemonError returns ErrDaemonUnavailable if the error has been caused
+// by the inability to connect to the daemon, otherwise the error is
+// returned as is.
+func WrapDaemonError(daemon string, err error) error {
+	if err == nil || !isConnectionError(err) {
+		return err
+	}
+
+	return &ErrDaemonUnavailable{
+		Daemon: daemon,
+		Reason: err,
+	}
+}
+
+// isConnectionError checks whether error has been caused by the transport
+// failure rather than by the daemon itself.
+func isConnectionError(err error) bool {
+	switch e := err.(type) {
+	case *errors.Error:
+		return isConnectionError(e.Err)
+	case *url.Error:
+		return true
+	case net.Error:
+		return true
+	}
+
+	if s, ok := status.FromError(err); ok && s.Code() == codes.Unavailable {
+		return true
+	}
+
+	return false
+}
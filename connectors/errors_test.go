@@ -0,0 +1,39 @@
+package connectors
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-errors/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWrapDaemonError(t *testing.T) {
+	connErrors := []error{
+		&url.Error{Op: "Post", URL: "http://localhost", Err: errors.New("eof")},
+		errors.New(&url.Error{Op: "Post", Err: errors.New("eof")}),
+		status.Error(codes.Unavailable, "connection refused"),
+	}
+
+	for _, err := range connErrors {
+		if _, ok := WrapDaemonError("daemon", err).(*ErrDaemonUnavailable); !ok {
+			t.Fatalf("error(%v) should be wrapped", err)
+		}
+	}
+
+	otherErrors := []error{
+		errors.New("invalid address"),
+		status.Error(codes.Unknown, "invoice expired"),
+	}
+
+	for _, err := range otherErrors {
+		if WrapDaemonError("daemon", err) != err {
+			t.Fatalf("error(%v) shouldn't be wrapped", err)
+		}
+	}
+
+	if WrapDaemonError("daemon", nil) != nil {
+		t.Fatalf("nil error shouldn't be wrapped")
+	}
+}
@@ -0,0 +1,65 @@
+package connectors
+
+import (
+	"time"
+)
+
+const (
+	// defaultBumpPollInterval is the interval with which pending payments
+	// are checked for being stuck.
+	defaultBumpPollInterval = time.Minute
+)
+
+// FeeBumpPolicy determines how fee of the pending outgoing payments is
+// bumped.
+type FeeBumpPolicy struct {
+	// AutoBumpBlocks is the number of blocks after which pending payment
+	// is treated as stuck, and its fee is bumped automatically. Zero
+	// disables automatic bumping.
+	AutoBumpBlocks uint32
+
+	// Priority is the fee priority which fee rate is used to bump the fee,
+	// if fee rate isn't specified explicitly. Fast priority is used if
+	// it is default one.
+	Priority FeePriority
+
+	// PollInterval is the interval with which pending payments are
+	// checked for being stuck, default is used if it is zero.
+	PollInterval time.Duration
+}
+
+// BumpPriority returns the priority which fee rate is used to bump the fee.
+func (p FeeBumpPolicy) BumpPriority() FeePriority {
+	if p.Priority == DefaultPriority {
+		return FastPriority
+	}
+
+	return p.Priority
+}
+
+// Interval returns the interval with which pending payments are checked
+// for being stuck.
+func (p FeeBumpPolicy) Interval() time.Duration {
+	if p.PollInterval == 0 {
+		return defaultBumpPollInterval
+	}
+
+	return p.PollInterval
+}
+
+// ReplacementsStorage is used to keep the index of the transactions, which
+// have replaced the original transactions of the payments. Payment id is
+// derived from the id of the original transaction, that is why replacement
+// transactions should be mapped to the payment explicitly.
+//
+// NOTE: This storage should be persistent.
+type ReplacementsStorage interface {
+	// PutReplacement saves the payment to which replacement transaction
+	// belongs.
+	PutReplacement(asset Asset, txID, paymentID string) error
+
+	// ReplacedPaymentID returns id of the payment to which replacement
+	// transaction belongs, empty string is returned if transaction isn't
+	// a replacement one.
+	ReplacedPaymentID(asset Asset, txID string) (string, error)
+}
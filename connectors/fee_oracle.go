@@ -0,0 +1,391 @@
+package connectors
+
+import (
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btclog"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// FeePriority determines how fast transaction of the outgoing payment
+// should be confirmed, the faster the bigger fee is paid.
+type FeePriority string
+
+var (
+	// DefaultPriority means that priority hasn't been specified, and
+	// connector should use its default one.
+	DefaultPriority FeePriority = ""
+
+	// FastPriority means that transaction should be confirmed in the next
+	// couple of blocks.
+	FastPriority FeePriority = "fast"
+
+	// NormalPriority means that transaction should be confirmed within
+	// an hour.
+	NormalPriority FeePriority = "normal"
+
+	// EconomyPriority means that transaction might wait for confirmation
+	// for hours, in order to pay the lowest fee.
+	EconomyPriority FeePriority = "economy"
+)
+
+// FeePriorities is the list of the priorities from the fastest to the
+// cheapest one.
+var FeePriorities = []FeePriority{FastPriority, NormalPriority,
+	EconomyPriority}
+
+const (
+	// defaultFastTarget is the confirmation target in blocks of the fast
+	// priority.
+	defaultFastTarget = 2
+
+	// defaultNormalTarget is the confirmation target in blocks of the
+	// normal priority.
+	defaultNormalTarget = 6
+
+	// defaultEconomyTarget is the confirmation target in blocks of the
+	// economy priority.
+	defaultEconomyTarget = 24
+
+	// defaultMinFeeRate is the fee rate in unit per byte below which
+	// transactions are not relayed by the network.
+	defaultMinFeeRate = 1
+
+	// defaultFeeSmoothing is the weight of the new estimate in the
+	// smoothed fee rate.
+	defaultFeeSmoothing = 0.5
+
+	// defaultFeePollInterval is the interval with which fee rates are
+	// estimated.
+	defaultFeePollInterval = time.Minute
+)
+
+// FeePolicy determines how fee rates of the connector are estimated. Fee
+// rates are measured in the smallest units of the asset per byte, e.g.
+// sat/byte.
+type FeePolicy struct {
+	// FastTarget, NormalTarget and EconomyTarget are confirmation targets
+	// in blocks of the priorities. Defaults are used if they are zero.
+	FastTarget    uint32
+	NormalTarget  uint32
+	EconomyTarget uint32
+
+	// MinFeeRate is the floor of the fee rate, default is used if it is
+	// zero.
+	MinFeeRate float64
+
+	// MaxFeeRate is the ceiling of the fee rate, zero means that fee rate
+	// isn't limited.
+	MaxFeeRate float64
+
+	// Smoothing is the weight of the new estimate in the exponential moving
+	// average of the fee rate, from zero to one. One disables smoothing,
+	// default is used if it is zero.
+	Smoothing float64
+
+	// PollInterval is the interval with which fee rates are estimated,
+	// default is used if it is zero.
+	PollInterval time.Duration
+}
+
+// Target returns confirmation target of the priority, default priority is
+// treated as normal one.
+func (p FeePolicy) Target(priority FeePriority) uint32 {
+	switch priority {
+	case FastPriority:
+		return p.FastTarget
+	case EconomyPriority:
+		return p.EconomyTarget
+	default:
+		return p.NormalTarget
+	}
+}
+
+// ParseFeePriority returns priority by its name, empty name is the default
+// priority.
+func ParseFeePriority(name string) (FeePriority, error) {
+	switch priority := FeePriority(name); priority {
+	case DefaultPriority, FastPriority, NormalPriority, EconomyPriority:
+		return priority, nil
+	default:
+		return "", errors.Errorf("unknown fee priority(%v)", name)
+	}
+}
+
+// FeeRate is the fee rate of the priority.
+type FeeRate struct {
+	// Asset is the asset of the connector.
+	Asset Asset
+
+	// Priority is the priority of the fee rate.
+	Priority FeePriority
+
+	// ConfTarget is the number of blocks in which transaction with this
+	// fee rate is expected to be confirmed.
+	ConfTarget uint32
+
+	// Rate is the fee rate in the smallest units of the asset per byte.
+	Rate decimal.Decimal
+
+	// Estimated is true if rate has been estimated by the daemon, and false
+	// if the fallback rate is used.
+	Estimated bool
+
+	// UpdatedAt is the time in milliseconds of the last successful
+	// estimation.
+	UpdatedAt int64
+}
+
+// FeeRatesProvider is implemented by connectors which estimate fee rates
+// with the fee oracle.
+type FeeRatesProvider interface {
+	// FeeRates returns current fee rates of all priorities.
+	FeeRates() []*FeeRate
+}
+
+// FeeOracleConfig is a config of the fee oracle.
+type FeeOracleConfig struct {
+	// Asset is the asset of the connector.
+	Asset Asset
+
+	// Policy determines how fee rates are estimated.
+	Policy FeePolicy
+
+	// Estimate returns fee rate in the smallest units of the asset per
+	// byte, for the transaction to be confirmed in the given number of
+	// blocks.
+	Estimate func(confTarget uint32) (decimal.Decimal, error)
+
+	// Fallback is the fee rate which is used until fee rate of the
+	// priority has been estimated.
+	Fallback decimal.Decimal
+
+	Logger btclog.Logger
+}
+
+func (c *FeeOracleConfig) validate() error {
+	if c.Asset == "" {
+		return errors.New("asset should be specified")
+	}
+
+	if c.Estimate == nil {
+		return errors.New("estimate function should be specified")
+	}
+
+	if c.Policy.MinFeeRate < 0 || c.Policy.MaxFeeRate < 0 {
+		return errors.New("fee rate limits shouldn't be negative")
+	}
+
+	if c.Policy.MaxFeeRate != 0 &&
+		c.Policy.MaxFeeRate < c.Policy.MinFeeRate {
+		return errors.New("max fee rate shouldn't be less than min fee " +
+			"rate")
+	}
+
+	if c.Policy.Smoothing < 0 || c.Policy.Smoothing > 1 {
+		return errors.New("smoothing should be from zero to one")
+	}
+
+	if c.Logger == nil {
+		return errors.New("logger should be specified")
+	}
+
+	return nil
+}
+
+// FeeOracle periodically estimates fee rates of the priorities, and keeps
+// them smoothed and limited by the floor and ceiling of the policy, so that
+// single outlier estimate wouldn't affect the fee of the payments.
+type FeeOracle struct {
+	cfg *FeeOracleConfig
+
+	mtx   sync.RWMutex
+	rates map[FeePriority]*FeeRate
+}
+
+// NewFeeOracle creates new fee oracle, default policy values are used for
+// the ones which are not specified.
+func NewFeeOracle(cfg *FeeOracleConfig) (*FeeOracle, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Policy.FastTarget == 0 {
+		cfg.Policy.FastTarget = defaultFastTarget
+	}
+
+	if cfg.Policy.NormalTarget == 0 {
+		cfg.Policy.NormalTarget = defaultNormalTarget
+	}
+
+	if cfg.Policy.EconomyTarget == 0 {
+		cfg.Policy.EconomyTarget = defaultEconomyTarget
+	}
+
+	if cfg.Policy.MinFeeRate == 0 {
+		cfg.Policy.MinFeeRate = defaultMinFeeRate
+	}
+
+	if cfg.Policy.Smoothing == 0 {
+		cfg.Policy.Smoothing = defaultFeeSmoothing
+	}
+
+	if cfg.Policy.PollInterval == 0 {
+		cfg.Policy.PollInterval = defaultFeePollInterval
+	}
+
+	return &FeeOracle{
+		cfg:   cfg,
+		rates: make(map[FeePriority]*FeeRate),
+	}, nil
+}
+
+// Run estimates fee rates with the interval of the policy, until quit
+// channel is closed.
+func (o *FeeOracle) Run(quit <-chan struct{}) {
+	ticker := time.NewTicker(o.cfg.Policy.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		o.Poll()
+
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+	}
+}
+
+// Poll estimates fee rates of all priorities, and updates the cached ones.
+// If estimation of the priority has failed, previous fee rate is kept.
+func (o *FeeOracle) Poll() {
+	policy := o.cfg.Policy
+
+	// Estimates are requested from the daemon without holding the lock,
+	// so that readers of the fee rates aren't blocked by the slow daemon.
+	estimates := make(map[FeePriority]decimal.Decimal)
+	for _, priority := range FeePriorities {
+		target := policy.Target(priority)
+
+		estimate, err := o.cfg.Estimate(target)
+		if err != nil {
+			o.cfg.Logger.Debugf("Unable to estimate %v fee rate for %v "+
+				"blocks: %v", o.cfg.Asset, target, err)
+			continue
+		}
+
+		estimates[priority] = estimate
+	}
+
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	rates := make(map[FeePriority]*FeeRate, len(o.rates))
+	for priority, rate := range o.rates {
+		copied := *rate
+		rates[priority] = &copied
+	}
+
+	for _, priority := range FeePriorities {
+		estimate, ok := estimates[priority]
+		if !ok {
+			continue
+		}
+
+		rate := o.limit(estimate)
+		if prev, ok := rates[priority]; ok && prev.Estimated {
+			weight := decimal.NewFromFloat(policy.Smoothing)
+			rate = o.limit(rate.Mul(weight).Add(prev.Rate.Mul(
+				decimal.New(1, 0).Sub(weight))))
+		}
+
+		rates[priority] = &FeeRate{
+			Asset:      o.cfg.Asset,
+			Priority:   priority,
+			ConfTarget: policy.Target(priority),
+			Rate:       rate,
+			Estimated:  true,
+			UpdatedAt:  NowInMilliSeconds(),
+		}
+	}
+
+	// Smoothing might break the order of the fee rates, faster priority
+	// shouldn't be cheaper than the slower one.
+	for i := len(FeePriorities) - 2; i >= 0; i-- {
+		faster, ok := rates[FeePriorities[i]]
+		if !ok {
+			continue
+		}
+
+		slower, ok := rates[FeePriorities[i+1]]
+		if ok && faster.Rate.LessThan(slower.Rate) {
+			faster.Rate = slower.Rate
+		}
+	}
+
+	o.rates = rates
+}
+
+// FeeRate returns current fee rate of the priority in the smallest units
+// of the asset per byte. Default priority is treated as normal one.
+func (o *FeeOracle) FeeRate(priority FeePriority) decimal.Decimal {
+	if priority == DefaultPriority {
+		priority = NormalPriority
+	}
+
+	o.mtx.RLock()
+	defer o.mtx.RUnlock()
+
+	if rate, ok := o.rates[priority]; ok {
+		return rate.Rate
+	}
+
+	return o.limit(o.cfg.Fallback)
+}
+
+// FeeRates returns current fee rates of all priorities.
+//
+// NOTE: Part of the FeeRatesProvider interface.
+func (o *FeeOracle) FeeRates() []*FeeRate {
+	o.mtx.RLock()
+	defer o.mtx.RUnlock()
+
+	rates := make([]*FeeRate, len(FeePriorities))
+	for i, priority := range FeePriorities {
+		if rate, ok := o.rates[priority]; ok {
+			copied := *rate
+			rates[i] = &copied
+			continue
+		}
+
+		rates[i] = &FeeRate{
+			Asset:      o.cfg.Asset,
+			Priority:   priority,
+			ConfTarget: o.cfg.Policy.Target(priority),
+			Rate:       o.limit(o.cfg.Fallback),
+		}
+	}
+
+	return rates
+}
+
+// limit returns fee rate limited by the floor and the ceiling of the
+// policy.
+func (o *FeeOracle) limit(rate decimal.Decimal) decimal.Decimal {
+	min := decimal.NewFromFloat(o.cfg.Policy.MinFeeRate)
+	if rate.LessThan(min) {
+		return min
+	}
+
+	if o.cfg.Policy.MaxFeeRate != 0 {
+		max := decimal.NewFromFloat(o.cfg.Policy.MaxFeeRate)
+		if rate.GreaterThan(max) {
+			return max
+		}
+	}
+
+	return rate.Round(8)
+}
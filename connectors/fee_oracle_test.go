@@ -0,0 +1,169 @@
+package connectors
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btclog"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// stubEstimator returns fee rates of the confirmation targets.
+type stubEstimator struct {
+	rates map[uint32]float64
+}
+
+func (e *stubEstimator) estimate(confTarget uint32) (decimal.Decimal, error) {
+	rate, ok := e.rates[confTarget]
+	if !ok {
+		return decimal.Zero, errors.New("not enough data to make an " +
+			"estimation")
+	}
+
+	return decimal.NewFromFloat(rate), nil
+}
+
+func newTestOracle(t *testing.T, estimator *stubEstimator,
+	policy FeePolicy) *FeeOracle {
+
+	oracle, err := NewFeeOracle(&FeeOracleConfig{
+		Asset:    BTC,
+		Policy:   policy,
+		Estimate: estimator.estimate,
+		Fallback: decimal.New(5, 0),
+		Logger:   btclog.Disabled,
+	})
+	if err != nil {
+		t.Fatalf("unable to create oracle: %v", err)
+	}
+
+	return oracle
+}
+
+func checkRate(t *testing.T, oracle *FeeOracle, priority FeePriority,
+	expected float64) {
+
+	rate := oracle.FeeRate(priority)
+	if !rate.Equal(decimal.NewFromFloat(expected)) {
+		t.Fatalf("wrong %v fee rate: %v, expected: %v", priority, rate,
+			expected)
+	}
+}
+
+// TestFeeOracleFallback checks that fallback fee rate is used until fee
+// rate has been estimated, and that it is limited by the policy.
+func TestFeeOracleFallback(t *testing.T) {
+	estimator := &stubEstimator{rates: map[uint32]float64{}}
+	oracle := newTestOracle(t, estimator, FeePolicy{MaxFeeRate: 4})
+
+	oracle.Poll()
+
+	for _, rate := range oracle.FeeRates() {
+		if rate.Estimated || !rate.Rate.Equal(decimal.New(4, 0)) {
+			t.Fatalf("fallback rate should be limited by ceiling: %v",
+				rate.Rate)
+		}
+	}
+
+	// Default priority is the normal one.
+	estimator.rates[defaultNormalTarget] = 3
+	oracle.Poll()
+	checkRate(t, oracle, DefaultPriority, 3)
+	checkRate(t, oracle, FastPriority, 4)
+}
+
+// TestFeeOracleSmoothing checks that new estimates are smoothed with the
+// previous ones, and limited by the floor and the ceiling.
+func TestFeeOracleSmoothing(t *testing.T) {
+	estimator := &stubEstimator{rates: map[uint32]float64{
+		1:  20,
+		3:  10,
+		10: 0.5,
+	}}
+
+	oracle := newTestOracle(t, estimator, FeePolicy{
+		FastTarget:    1,
+		NormalTarget:  3,
+		EconomyTarget: 10,
+		MinFeeRate:    2,
+		MaxFeeRate:    50,
+		Smoothing:     0.5,
+	})
+
+	oracle.Poll()
+	checkRate(t, oracle, FastPriority, 20)
+	checkRate(t, oracle, NormalPriority, 10)
+	checkRate(t, oracle, EconomyPriority, 2)
+
+	// Outlier is limited by the ceiling and smoothed with the previous
+	// rate, and failed estimation keeps the previous rate.
+	estimator.rates[1] = 200
+	estimator.rates[3] = 20
+	delete(estimator.rates, 10)
+
+	oracle.Poll()
+	checkRate(t, oracle, FastPriority, 35)
+	checkRate(t, oracle, NormalPriority, 15)
+	checkRate(t, oracle, EconomyPriority, 2)
+
+	for _, rate := range oracle.FeeRates() {
+		if !rate.Estimated || rate.ConfTarget != oracle.cfg.Policy.Target(
+			rate.Priority) {
+			t.Fatalf("wrong fee rate: %v", rate)
+		}
+	}
+}
+
+// TestFeeOracleOrder checks that faster priority is never cheaper than the
+// slower one.
+func TestFeeOracleOrder(t *testing.T) {
+	estimator := &stubEstimator{rates: map[uint32]float64{
+		defaultFastTarget:    10,
+		defaultNormalTarget:  10,
+		defaultEconomyTarget: 10,
+	}}
+
+	oracle := newTestOracle(t, estimator, FeePolicy{Smoothing: 1})
+	oracle.Poll()
+
+	// Economy rate has risen, but fast one hasn't been estimated.
+	estimator.rates[defaultEconomyTarget] = 30
+	delete(estimator.rates, defaultFastTarget)
+	oracle.Poll()
+
+	checkRate(t, oracle, FastPriority, 30)
+	checkRate(t, oracle, NormalPriority, 30)
+	checkRate(t, oracle, EconomyPriority, 30)
+}
+
+// TestFeeOracleEstimateUnlocked checks that fee rates could be read while
+// the daemon is estimating them.
+func TestFeeOracleEstimateUnlocked(t *testing.T) {
+	var oracle *FeeOracle
+	oracle, err := NewFeeOracle(&FeeOracleConfig{
+		Asset: BTC,
+		Estimate: func(confTarget uint32) (decimal.Decimal, error) {
+			return oracle.FeeRate(NormalPriority).Add(decimal.New(1, 0)), nil
+		},
+		Fallback: decimal.New(5, 0),
+		Logger:   btclog.Disabled,
+	})
+	if err != nil {
+		t.Fatalf("unable to create oracle: %v", err)
+	}
+
+	oracle.Poll()
+	checkRate(t, oracle, NormalPriority, 6)
+}
+
+func TestParseFeePriority(t *testing.T) {
+	for _, name := range []string{"", "fast", "normal", "economy"} {
+		if _, err := ParseFeePriority(name); err != nil {
+			t.Fatalf("unable to parse priority(%v): %v", name, err)
+		}
+	}
+
+	if _, err := ParseFeePriority("instant"); err == nil {
+		t.Fatalf("unknown priority shouldn't be parsed")
+	}
+}
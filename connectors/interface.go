@@ -14,11 +14,28 @@ type LightningInfo struct {
 	*lnrpc.GetInfoResponse
 }
 
+// Connector is the lifecycle interface which is implemented by every
+// blockchain and lightning connector.
+type Connector interface {
+	// Start starts the connector, i.e. connects to the daemon and spawns
+	// goroutines which sync payments.
+	Start() error
+
+	// Stop stops the connector and waits for its goroutines to exit.
+	Stop(reason string) error
+
+	// Status returns the lifecycle state of the connector, and the sync
+	// state of the connector and its daemon.
+	Status() *ConnectorStatus
+}
+
 // BlockchainConnector is an interface which describes the blockchain service
 // which is able to connect to blockchain daemon of particular currency and
 // operate with transactions, addresses, and also  able to notify other
 // subsystems when transaction passes required number of confirmations.
 type BlockchainConnector interface {
+	Connector
+
 	// CreateAddress is used to create deposit address.
 	CreateAddress() (string, error)
 
@@ -28,15 +45,120 @@ type BlockchainConnector interface {
 	// PendingBalance return the amount of funds waiting to be confirmed.
 	PendingBalance() (decimal.Decimal, error)
 
-	// SendPayment sends payment with given amount to the given address.
-	SendPayment(address, amount string) (*Payment, error)
+	// SendPayment sends payment with given amount to the given address. If
+	// idempotency key is specified and payment with this key has been
+	// already sent, than previously sent payment is returned. Priority
+	// determines the fee of the payment, connectors which don't support it
+	// ignore it.
+	SendPayment(address, amount, idempotencyKey string,
+		priority FeePriority) (*Payment, error)
+
+	// CreatePayment creates and signs the payment with given amount to the
+	// given address, but not sends it. Payment is stored with waiting
+	// status and exact fee, and has to be confirmed or canceled later.
+	CreatePayment(address, amount, idempotencyKey string) (*Payment, error)
+
+	// ConfirmPayment sends previously created waiting payment.
+	ConfirmPayment(paymentID string) (*Payment, error)
+
+	// CancelPayment cancels previously created waiting payment, and releases
+	// resources which were reserved for it.
+	CancelPayment(paymentID string) (*Payment, error)
 
 	// ValidateAddress takes the blockchain address and ensure its valid.
 	ValidateAddress(address string) error
 
 	// EstimateFee estimate fee for the transaction with the given sending
-	// amount.
-	EstimateFee(amount string) (decimal.Decimal, error)
+	// amount and priority.
+	EstimateFee(amount string, priority FeePriority) (decimal.Decimal, error)
+}
+
+// FeeEstimate is the fee of the transaction which would be created in
+// order to send the payment, along with the details of this transaction.
+type FeeEstimate struct {
+	// Fee is the fee of the transaction.
+	Fee decimal.Decimal
+
+	// Inputs is the number of inputs of the transaction.
+	Inputs int
+
+	// VSize is the virtual size of the transaction in bytes.
+	VSize int
+}
+
+// TxFeeEstimator is implemented by blockchain connectors which are able to
+// estimate fee by constructing the transaction, e.g. connectors of UTXO
+// based blockchains.
+type TxFeeEstimator interface {
+	// EstimateTxFee estimates fee of the transaction which sends the given
+	// amount to the address with the given priority. If address is empty,
+	// the most common type of the address is assumed.
+	EstimateTxFee(address, amount string, priority FeePriority) (
+		*FeeEstimate, error)
+}
+
+// FeeBumper is implemented by blockchain connectors which are able to
+// replace the transaction of the pending payment with the transaction
+// paying bigger fee, e.g. connectors of the blockchains supporting
+// replace-by-fee.
+type FeeBumper interface {
+	// BumpFee replaces the transaction of the pending outgoing payment
+	// with the transaction paying the given fee rate in sat/byte, and
+	// returns updated payment. If fee rate is zero, the fee rate of the
+	// priority of the connector fee bump policy is used.
+	BumpFee(paymentID string, feeRate decimal.Decimal) (*Payment, error)
+}
+
+// MultiSender is implemented by blockchain connectors which are able to
+// send payments to several recipients at once.
+type MultiSender interface {
+	// SendPayments validates every recipient and sends payments to them,
+	// either all of the payments are sent or none of them. Payments which
+	// are sent in one transaction share the media id. If payments are sent
+	// in several transactions, and some of them has failed after the others
+	// have been broadcast, all payments are returned with their own status
+	// and failure reason, rather than the error. If idempotency key
+	// is specified and payments with this key have been already sent, than
+	// previously sent payments are returned.
+	SendPayments(recipients []*Recipient, idempotencyKey string) ([]*Payment,
+		error)
+}
+
+// ColdSigner is implemented by blockchain connectors which are able to
+// send payments from the cold storage, i.e. to spend the outputs of the
+// watch-only addresses, keys of which are kept offline. Transaction of the
+// payment is exported as partially signed transaction, as defined in
+// BIP174, signed elsewhere and imported back.
+type ColdSigner interface {
+	// CreatePSBTPayment creates unsigned transaction which sends given
+	// amount to the given address from the cold storage, and stores it as
+	// waiting payment with the exact fee.
+	CreatePSBTPayment(address, amount, idempotencyKey string) (*Payment,
+		error)
+
+	// ExportPSBT returns base64 encoded partially signed transaction of
+	// the waiting payment.
+	ExportPSBT(paymentID string) (string, error)
+
+	// ImportPSBT combines the signatures of the given base64 encoded
+	// partially signed transaction with the ones of the waiting payment.
+	ImportPSBT(paymentID, psbt string) (*Payment, error)
+
+	// FinalizePSBT finalizes the fully signed transaction of the waiting
+	// payment, and sends it to the blockchain network.
+	FinalizePSBT(paymentID string) (*Payment, error)
+}
+
+// InputsReorganiser is implemented by blockchain connectors which are able
+// to split large unspent outputs of the wallet into the outputs of the
+// optimal value, so that every payment wouldn't lock the most of the
+// balance in the unconfirmed change.
+type InputsReorganiser interface {
+	// ReorganiseInputs splits large unspent outputs in one transaction,
+	// which outputs are saved as the internal payments. If preview is true,
+	// transaction is only estimated and isn't sent. Nil is returned if
+	// there are no outputs to split.
+	ReorganiseInputs(preview bool) (*Reorganisation, error)
 }
 
 // LightningConnector is an interface which describes the service
@@ -44,6 +166,8 @@ type BlockchainConnector interface {
 // operate with transactions, addresses, and also  able to notify other
 // subsystems when invoice is settled.
 type LightningConnector interface {
+	Connector
+
 	// Info returns the information about our lnd node.
 	Info() (*LightningInfo, error)
 
@@ -52,8 +176,22 @@ type LightningConnector interface {
 		*zpay32.Invoice, error)
 
 	// SendTo is used to send specific amount of money to address within this
-	// payment system.
-	SendTo(invoice, amount string) (*Payment, error)
+	// payment system. If idempotency key is specified and payment with this
+	// key has been already sent, than previously sent payment is returned.
+	SendTo(invoice, amount, idempotencyKey string) (*Payment, error)
+
+	// CreatePayment prepares the payment of the given invoice, but not
+	// sends it. Payment is stored with waiting status and the fee of the
+	// found route, and has to be confirmed or canceled later.
+	CreatePayment(invoice, amount, idempotencyKey string) (*Payment, error)
+
+	// ConfirmPayment sends previously created waiting payment. Fee which is
+	// paid for the payment couldn't be greater than the fee of the
+	// created payment.
+	ConfirmPayment(paymentID string) (*Payment, error)
+
+	// CancelPayment cancels previously created waiting payment.
+	CancelPayment(paymentID string) (*Payment, error)
 
 	// ConfirmedBalance return the amount of confirmed funds available for account.
 	// TODO(andrew.shvv) Implement lightning wallet balance
@@ -55,8 +55,22 @@ var (
 	// Failed means that services has tried to send payment for couple of
 	// times, but without success, and now service gave up.
 	Failed PaymentStatus = "Failed"
+
+	// Reverted means that transaction of the payment has been removed from
+	// the main chain by the reorganisation, or has been double spent. If
+	// payment had been completed before, its funds should be clawed back.
+	// Payment might become pending again if transaction is mined again.
+	Reverted PaymentStatus = "Reverted"
 )
 
+// CanceledReason is the failure reason of the waiting payment which has
+// been canceled.
+const CanceledReason = "payment has been canceled"
+
+// ReplacedReason is the failure reason of the internal payment which
+// transaction has been replaced by the transaction with the bigger fee.
+const ReplacedReason = "transaction has been replaced"
+
 // PaymentDirection denotes the direction of the payment, whether payment is
 // going form us to someone else, or form someone else to us.
 type PaymentDirection string
@@ -139,6 +153,15 @@ type Payment struct {
 	// Detail stores all additional information which is needed for this type
 	// and status of payment.
 	Detail Serializable
+
+	// IdempotencyKey is the key which was specified by the client on
+	// payment sending, it is used to prevent sending the same payment twice
+	// in case of request retry.
+	IdempotencyKey string
+
+	// FailureReason is the error because of which payment has been marked
+	// as failed, it is empty for the payments of other statuses.
+	FailureReason string
 }
 
 // GenPaymentID generates unique string based on the tx id and receive
@@ -4,6 +4,8 @@ import (
 	"io"
 	"encoding/json"
 	"io/ioutil"
+
+	"github.com/shopspring/decimal"
 )
 
 // Serializable is an interface which defines a serializable
@@ -51,6 +53,45 @@ type GeneratedTxDetails struct {
 
 	// TxID blockchain identification of transaction.
 	TxID string
+
+	// Nonce is the sender account nonce which is used by the transaction.
+	//
+	// NOTE: Used only by account based blockchains.
+	Nonce int `json:",omitempty"`
+
+	// ChangeAddress is the address of the change output of the
+	// transaction, it is empty if transaction has no change.
+	//
+	// NOTE: Used only by UTXO based blockchains.
+	ChangeAddress string `json:",omitempty"`
+
+	// SentHeight is the height of the best block at the moment when
+	// transaction has been sent, it is used to determine for how long
+	// transaction is stuck in the mempool.
+	SentHeight int64 `json:",omitempty"`
+
+	// Replaced are the transactions of the payment which have been
+	// replaced by this one with the bigger fee, from the oldest one.
+	//
+	// NOTE: Used only by UTXO based blockchains.
+	Replaced []*ReplacedTx `json:",omitempty"`
+}
+
+// ReplacedTx is the transaction of the payment which has been replaced by
+// the transaction with the bigger fee.
+type ReplacedTx struct {
+	// RawTx byte representation of blockchain transaction.
+	RawTx []byte
+
+	// TxID blockchain identification of transaction.
+	TxID string
+
+	// Fee is the fee which is paid by the transaction.
+	Fee decimal.Decimal
+
+	// ChangeAddress is the address of the change output of the
+	// transaction, it is empty if transaction has no change.
+	ChangeAddress string `json:",omitempty"`
 }
 
 // Runtime check to ensure that BlockchainPendingDetails implements
@@ -78,3 +119,114 @@ func (d *GeneratedTxDetails) Encode(w io.Writer, v uint32) error {
 	_, err = w.Write(data)
 	return err
 }
+
+// BatchedTxDetails is the information about the outgoing payment which is
+// sent in one transaction along with the other queued payments.
+type BatchedTxDetails struct {
+	// QueuedAt is the time in nanoseconds when payment has been queued,
+	// payments are sent in the order in which they have been queued. It
+	// is zero if payment has been sent without queueing.
+	QueuedAt int64
+
+	// BatchSize is the number of payments in the transaction, it is zero
+	// until payment is sent.
+	BatchSize int `json:",omitempty"`
+
+	// BatchID is the id of the batch which is set as the comment of the
+	// transaction, so that transaction could be found in the wallet if
+	// connector hasn't received the response of the daemon. It is empty
+	// until payment is sent.
+	BatchID string `json:",omitempty"`
+
+	// SentHeight is the height of the best block at the moment when
+	// transaction has been sent, or its fee has been bumped.
+	SentHeight int64 `json:",omitempty"`
+
+	// TxFee is the fee of the whole transaction, pro-rata share of which
+	// is the fee of the payment.
+	TxFee decimal.Decimal
+}
+
+// Runtime check to ensure that BatchedTxDetails implements Serializable
+// interface.
+var _ Serializable = (*BatchedTxDetails)(nil)
+
+// Decode reads the bytes stream and converts it to the object.
+func (d *BatchedTxDetails) Decode(r io.Reader, v uint32) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, d)
+}
+
+// Encode converts object to the bytes stream and write it into the
+// writer.
+func (d *BatchedTxDetails) Encode(w io.Writer, v uint32) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// PSBTDetails is the information about the outgoing payment which spends
+// the outputs of the cold storage, keys of which are kept offline. Payment
+// is waiting until its partially signed transaction is signed elsewhere
+// and finalized.
+type PSBTDetails struct {
+	// PSBT is the base64 encoded partially signed transaction of the
+	// payment, as defined in BIP174. It is updated with the signatures
+	// every time signed transaction is imported.
+	PSBT string
+
+	// UnsignedTx byte representation of the unsigned transaction of the
+	// payment.
+	UnsignedTx []byte
+
+	// RawTx byte representation of the finalized transaction, it is empty
+	// until all inputs are signed and transaction is finalized.
+	RawTx []byte `json:",omitempty"`
+
+	// TxID blockchain identification of the finalized transaction. It
+	// might differ from the one of the unsigned transaction, if the
+	// signatures aren't segregated.
+	TxID string `json:",omitempty"`
+
+	// Complete denotes that all inputs of the transaction are signed, so
+	// that it could be finalized.
+	Complete bool `json:",omitempty"`
+
+	// ChangeAddress is the cold storage address of the change output of
+	// the transaction, it is empty if transaction has no change.
+	ChangeAddress string `json:",omitempty"`
+}
+
+// Runtime check to ensure that PSBTDetails implements Serializable
+// interface.
+var _ Serializable = (*PSBTDetails)(nil)
+
+// Decode reads the bytes stream and converts it to the object.
+func (d *PSBTDetails) Decode(r io.Reader, v uint32) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, d)
+}
+
+// Encode converts object to the bytes stream and write it into the
+// writer.
+func (d *PSBTDetails) Encode(w io.Writer, v uint32) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,175 @@
+package connectors
+
+import (
+	"sync"
+
+	"github.com/go-errors/errors"
+)
+
+// paymentsUpdatesBufferSize is the number of payment updates which might be
+// queued for the subscriber, before subscription would be considered as
+// stale and will be closed.
+const paymentsUpdatesBufferSize = 100
+
+// ErrSubscriptionOverflow is returned when subscriber wasn't able to
+// consume payment updates in time, and its subscription was closed in
+// order to not block the connectors.
+var ErrSubscriptionOverflow = errors.New("subscription has been closed " +
+	"because updates were not consumed in time")
+
+// PaymentsSubscription is a subscription on payment updates, which is
+// created by the PaymentsHub. Every creation of the payment or change of
+// its state, which corresponds to subscription filters, is sent in the
+// updates channel.
+type PaymentsSubscription struct {
+	id  uint64
+	hub *PaymentsHub
+
+	asset     Asset
+	media     PaymentMedia
+	direction PaymentDirection
+	system    PaymentSystem
+
+	updates chan *Payment
+
+	// overflowed is set if subscription was closed by the hub, because
+	// subscriber was too slow.
+	overflowed bool
+}
+
+// Updates returns channel of the payment updates. Channel is closed
+// when subscription has been canceled or it has been overflowed.
+func (s *PaymentsSubscription) Updates() <-chan *Payment {
+	return s.updates
+}
+
+// Err returns the reason why updates channel was closed by the hub, if any.
+func (s *PaymentsSubscription) Err() error {
+	s.hub.mutex.RLock()
+	defer s.hub.mutex.RUnlock()
+
+	if s.overflowed {
+		return ErrSubscriptionOverflow
+	}
+
+	return nil
+}
+
+// Cancel removes subscription from the hub and closes updates channel.
+func (s *PaymentsSubscription) Cancel() {
+	s.hub.mutex.Lock()
+	defer s.hub.mutex.Unlock()
+
+	if _, ok := s.hub.subscriptions[s.id]; !ok {
+		return
+	}
+
+	delete(s.hub.subscriptions, s.id)
+	close(s.updates)
+}
+
+// match checks that payment corresponds to the subscription filters.
+func (s *PaymentsSubscription) match(payment *Payment) bool {
+	if s.asset != "" && payment.Asset != s.asset {
+		return false
+	}
+
+	if s.media != "" && payment.Media != s.media {
+		return false
+	}
+
+	if s.direction != "" && payment.Direction != s.direction {
+		return false
+	}
+
+	if s.system != "" && payment.System != s.system {
+		return false
+	}
+
+	return true
+}
+
+// PaymentsHub is a wrapper around payments store, which notifies
+// subscribers about every saved payment. As far as all connectors
+// save payment on its creation and on every change of its state,
+// hub could be used to track payments without polling the store.
+type PaymentsHub struct {
+	PaymentsStore
+
+	mutex         sync.RWMutex
+	nextID        uint64
+	subscriptions map[uint64]*PaymentsSubscription
+}
+
+// Runtime check to ensure that PaymentsHub implements PaymentsStore
+// interface.
+var _ PaymentsStore = (*PaymentsHub)(nil)
+
+// NewPaymentsHub creates new payments hub on top of the given store.
+func NewPaymentsHub(store PaymentsStore) *PaymentsHub {
+	return &PaymentsHub{
+		PaymentsStore: store,
+		subscriptions: make(map[uint64]*PaymentsSubscription),
+	}
+}
+
+// SavePayment saves payment in the underlying store and notifies
+// subscribers about it.
+//
+// NOTE: Part of the PaymentsStore interface.
+func (h *PaymentsHub) SavePayment(payment *Payment) error {
+	if err := h.PaymentsStore.SavePayment(payment); err != nil {
+		return err
+	}
+
+	h.notify(payment)
+	return nil
+}
+
+// SubscribePayments creates new subscription on payment updates. Empty
+// filter values mean that payments shouldn't be filtered by this field.
+func (h *PaymentsHub) SubscribePayments(asset Asset, media PaymentMedia,
+	direction PaymentDirection, system PaymentSystem) *PaymentsSubscription {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.nextID++
+	s := &PaymentsSubscription{
+		id:        h.nextID,
+		hub:       h,
+		asset:     asset,
+		media:     media,
+		direction: direction,
+		system:    system,
+		updates:   make(chan *Payment, paymentsUpdatesBufferSize),
+	}
+
+	h.subscriptions[s.id] = s
+	return s
+}
+
+// notify sends copy of the payment to all subscribers which filters
+// corresponds to the payment. Subscribers which are unable to consume
+// updates in time are removed, so that connectors are never blocked.
+func (h *PaymentsHub) notify(payment *Payment) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for id, s := range h.subscriptions {
+		if !s.match(payment) {
+			continue
+		}
+
+		p := &Payment{}
+		*p = *payment
+
+		select {
+		case s.updates <- p:
+		default:
+			s.overflowed = true
+			delete(h.subscriptions, id)
+			close(s.updates)
+		}
+	}
+}
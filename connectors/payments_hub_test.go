@@ -0,0 +1,103 @@
+package connectors
+
+import (
+	"testing"
+)
+
+// stubPaymentsStore is a payments store which only remembers number of
+// saved payments.
+type stubPaymentsStore struct {
+	PaymentsStore
+	saved int
+}
+
+func (s *stubPaymentsStore) SavePayment(payment *Payment) error {
+	s.saved++
+	return nil
+}
+
+func TestPaymentsHubSubscribe(t *testing.T) {
+	store := &stubPaymentsStore{}
+	hub := NewPaymentsHub(store)
+
+	all := hub.SubscribePayments("", "", "", "")
+	defer all.Cancel()
+
+	onlyETH := hub.SubscribePayments(ETH, "", Incoming, "")
+	defer onlyETH.Cancel()
+
+	payments := []*Payment{
+		{PaymentID: "1", Asset: BTC, Direction: Incoming, Status: Pending},
+		{PaymentID: "2", Asset: ETH, Direction: Outgoing, Status: Waiting},
+		{PaymentID: "2", Asset: ETH, Direction: Incoming, Status: Completed},
+	}
+
+	for _, payment := range payments {
+		if err := hub.SavePayment(payment); err != nil {
+			t.Fatalf("unable to save payment: %v", err)
+		}
+	}
+
+	if store.saved != len(payments) {
+		t.Fatalf("payments haven't been saved in underlying store")
+	}
+
+	if len(all.Updates()) != 3 {
+		t.Fatalf("wrong number of updates: %v", len(all.Updates()))
+	}
+
+	if len(onlyETH.Updates()) != 1 {
+		t.Fatalf("wrong number of updates: %v", len(onlyETH.Updates()))
+	}
+
+	p := <-onlyETH.Updates()
+	if p.Status != Completed {
+		t.Fatalf("wrong payment status")
+	}
+
+	// Payment should be copied, so that subscriber wouldn't see changes
+	// made by connector after saving.
+	payments[2].Status = Failed
+	if p.Status != Completed {
+		t.Fatalf("payment hasn't been copied")
+	}
+}
+
+func TestPaymentsHubOverflow(t *testing.T) {
+	hub := NewPaymentsHub(&stubPaymentsStore{})
+	s := hub.SubscribePayments("", "", "", "")
+
+	for i := 0; i < paymentsUpdatesBufferSize+1; i++ {
+		if err := hub.SavePayment(&Payment{}); err != nil {
+			t.Fatalf("unable to save payment: %v", err)
+		}
+	}
+
+	for range s.Updates() {
+	}
+
+	if s.Err() != ErrSubscriptionOverflow {
+		t.Fatalf("subscription should be overflowed")
+	}
+
+	// Cancel of the already closed subscription shouldn't panic.
+	s.Cancel()
+}
+
+func TestPaymentsHubCancel(t *testing.T) {
+	hub := NewPaymentsHub(&stubPaymentsStore{})
+	s := hub.SubscribePayments("", "", "", "")
+	s.Cancel()
+
+	if err := hub.SavePayment(&Payment{}); err != nil {
+		t.Fatalf("unable to save payment: %v", err)
+	}
+
+	if _, ok := <-s.Updates(); ok {
+		t.Fatalf("updates channel should be closed")
+	}
+
+	if s.Err() != nil {
+		t.Fatalf("canceled subscription shouldn't have error")
+	}
+}
@@ -0,0 +1,102 @@
+package connectors
+
+import (
+	"fmt"
+
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Recipient is the receiver of one of the payments which are sent at once.
+type Recipient struct {
+	// Address is the blockchain address of the receiver.
+	Address string
+
+	// Amount is the amount which should be sent to the receiver.
+	Amount string
+}
+
+// RecipientAmounts parses amounts of the recipients, and ensures that
+// every amount is positive.
+func RecipientAmounts(recipients []*Recipient) ([]decimal.Decimal, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("recipients should be specified")
+	}
+
+	amounts := make([]decimal.Decimal, len(recipients))
+	for i, recipient := range recipients {
+		amount, err := decimal.NewFromString(recipient.Amount)
+		if err != nil {
+			return nil, &ErrInvalidAmount{Amount: recipient.Amount,
+				Reason: err}
+		}
+
+		if !amount.IsPositive() {
+			return nil, &ErrInvalidAmount{
+				Amount: recipient.Amount,
+				Reason: errors.New("amount should be positive"),
+			}
+		}
+
+		amounts[i] = amount
+	}
+
+	return amounts, nil
+}
+
+// RecipientIdempotencyKey returns idempotency key of the payment to the
+// recipient with the given index, which has been sent at once with the
+// others under the given key.
+func RecipientIdempotencyKey(key string, i int) string {
+	if key == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%v:%v", key, i)
+}
+
+// PaymentsByIdempotencyKey returns payments which were previously sent at
+// once with the given idempotency key, and ensures that they were sent to
+// the same recipients. If there are no such payments, nil is returned.
+func PaymentsByIdempotencyKey(store PaymentsStore, key string, asset Asset,
+	media PaymentMedia, recipients []*Recipient,
+	amounts []decimal.Decimal) ([]*Payment, error) {
+
+	if key == "" {
+		return nil, nil
+	}
+
+	var payments []*Payment
+	for i, recipient := range recipients {
+		payment, err := PaymentByIdempotencyKey(store,
+			RecipientIdempotencyKey(key, i), asset, media, recipient.Address,
+			amounts[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if payment == nil {
+			if i == 0 {
+				return nil, nil
+			}
+
+			// Payments are sent at once, so key has been used for the
+			// lesser number of recipients.
+			return nil, ErrIdempotencyKeyReused
+		}
+
+		payments = append(payments, payment)
+	}
+
+	// Key shouldn't have been used for the bigger number of recipients.
+	payment, err := PaymentByIdempotencyKey(store,
+		RecipientIdempotencyKey(key, len(recipients)), asset, media, "",
+		decimal.Zero)
+	if err == ErrIdempotencyKeyReused || payment != nil {
+		return nil, ErrIdempotencyKeyReused
+	} else if err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
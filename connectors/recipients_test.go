@@ -0,0 +1,74 @@
+package connectors
+
+import (
+	"testing"
+)
+
+func TestPaymentsByIdempotencyKey(t *testing.T) {
+	store := &memoryPaymentsStore{
+		payments: make(map[string]Payment),
+	}
+
+	recipients := []*Recipient{
+		{Address: "a1", Amount: "0.1"},
+		{Address: "a2", Amount: "0.2"},
+	}
+	amounts, err := RecipientAmounts(recipients)
+	if err != nil {
+		t.Fatalf("unable to parse amounts: %v", err)
+	}
+
+	payments, err := PaymentsByIdempotencyKey(store, "key", BTC, Blockchain,
+		recipients, amounts)
+	if err != nil || payments != nil {
+		t.Fatalf("payments shouldn't be found: %v", err)
+	}
+
+	for i, recipient := range recipients {
+		id := RecipientIdempotencyKey("key", i)
+		store.payments[id] = Payment{
+			PaymentID:      id,
+			Asset:          BTC,
+			Media:          Blockchain,
+			Receipt:        recipient.Address,
+			Amount:         amounts[i],
+			IdempotencyKey: id,
+		}
+	}
+
+	payments, err = PaymentsByIdempotencyKey(store, "key", BTC, Blockchain,
+		recipients, amounts)
+	if err != nil {
+		t.Fatalf("unable to get payments: %v", err)
+	}
+
+	if len(payments) != 2 || payments[1].Receipt != "a2" {
+		t.Fatalf("payments should be returned in the order of recipients")
+	}
+
+	// Key shouldn't be reused for the different set of recipients.
+	for _, recipients := range [][]*Recipient{
+		recipients[:1],
+		append(recipients, &Recipient{Address: "a3", Amount: "0.3"}),
+		{recipients[1], recipients[0]},
+	} {
+		amounts, _ := RecipientAmounts(recipients)
+		_, err := PaymentsByIdempotencyKey(store, "key", BTC, Blockchain,
+			recipients, amounts)
+		if err != ErrIdempotencyKeyReused {
+			t.Fatalf("key reuse should be detected, got %v", err)
+		}
+	}
+}
+
+func TestRecipientAmounts(t *testing.T) {
+	for _, recipients := range [][]*Recipient{
+		nil,
+		{{Address: "a1", Amount: "abc"}},
+		{{Address: "a1", Amount: "0.1"}, {Address: "a2", Amount: "0"}},
+	} {
+		if _, err := RecipientAmounts(recipients); err == nil {
+			t.Fatalf("invalid recipients should be rejected: %v", recipients)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package connectors
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/btcsuite/btclog"
+	"github.com/go-errors/errors"
+)
+
+// DaemonConfig contains options of the daemon behind the connector, and of
+// the connector itself, which are set by the user.
+type DaemonConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+
+	// TLSCertPath and MacaroonPath are used to connect to the daemons which
+	// require them, e.g. lnd.
+	TLSCertPath  string
+	MacaroonPath string
+
+	// PeerHost and PeerPort are the public host and port of the lightning
+	// network node.
+	PeerHost string
+	PeerPort string
+
+	// MinConfirmations is a minimum number of confirmations which is
+	// needed to treat transaction as confirmed.
+	MinConfirmations int
+
+	// SyncDelay is the delay in seconds between the syncs with the daemon.
+	SyncDelay int
+
+	// FeePerUnit is the fee which is used if daemon is unable to estimate
+	// it.
+	FeePerUnit int
+
+	// ForceLastHash is the block hash from which connector should start
+	// syncing, instead of the one which is saved in the storage.
+	ForceLastHash string
+
+	// SendRetry determines how outgoing payments which couldn't be sent
+	// are retried.
+	SendRetry RetryPolicy
+
+	// Backend is the name of the connector implementation which should be
+	// used for the daemon, empty value means the default one.
+	Backend string
+
+	// CoinSelection is the name of the coin selection strategy, which is
+	// used by connectors crafting transactions themselves, empty value
+	// means the default one.
+	CoinSelection string
+
+	// FeePolicy determines how fee rates of the priorities are estimated
+	// by the connectors with the fee oracle.
+	FeePolicy FeePolicy
+
+	// FeeBump determines how fee of the stuck outgoing payments is bumped
+	// by the connectors supporting replace-by-fee.
+	FeeBump FeeBumpPolicy
+
+	// Batch determines how outgoing payments are batched into one
+	// transaction by the connectors supporting batching.
+	Batch BatchPolicy
+
+	// Derivation determines the extended public key from which deposit
+	// addresses are derived by the connectors supporting derivation.
+	Derivation DerivationPolicy
+
+	// Treasury determines how excess of the hot wallet is swept to the
+	// cold storage by the connectors supporting sweeping.
+	Treasury TreasuryPolicy
+
+	// Consolidation determines when small unspent outputs are merged by
+	// the connectors supporting consolidation.
+	Consolidation ConsolidationPolicy
+
+	// Reorganisation determines how large unspent outputs are split by the
+	// connectors supporting reorganisation.
+	Reorganisation ReorganisationPolicy
+}
+
+// StorageBackend is used by connector factories to get the storages needed
+// by the connector. Connector packages might require backend to implement
+// additional methods for storages specific to them.
+type StorageBackend interface {
+	// ConnectorStateStorage returns storage which is used to keep the last
+	// synced block hash of the connector of the given asset.
+	ConnectorStateStorage(asset Asset) StateStorage
+
+	// AttemptsStorage returns storage which is used to persist attempts
+	// of the outgoing payments.
+	AttemptsStorage() AttemptsStorage
+
+	// ReplacementsStorage returns storage which is used to map the
+	// replacement transactions to the payments.
+	ReplacementsStorage() ReplacementsStorage
+
+	// BatchesStorage returns storage which is used to map the outputs of
+	// the batch transactions to the payments.
+	BatchesStorage() BatchesStorage
+
+	// DerivationStorage returns storage which is used to keep the indexes
+	// of the addresses derived from the extended public keys.
+	DerivationStorage() DerivationStorage
+}
+
+// FactoryConfig contains everything which is needed by factory to create
+// the connector.
+type FactoryConfig struct {
+	// Net is the blockchain network connector should operate with.
+	Net string
+
+	// Asset is an asset with which connector is working.
+	Asset Asset
+
+	Daemon *DaemonConfig
+
+	Logger btclog.Logger
+
+	// RPCLogger is used by the rpc clients of the daemon.
+	RPCLogger btclog.Logger
+
+	// Metrics is an metrics backend which is used for tracking the metrics
+	// of connector.
+	Metrics crypto.MetricsBackend
+
+	// PaymentStore is used by connector to save payments as well as update
+	// their state.
+	PaymentStore PaymentsStore
+
+	Storage StorageBackend
+}
+
+// Factory creates new connector. Blockchain factories should return
+// BlockchainConnector and lightning factories LightningConnector.
+type Factory func(cfg *FactoryConfig) (Connector, error)
+
+// connectorKey identifies the connector by its asset and media.
+type connectorKey struct {
+	asset Asset
+	media PaymentMedia
+}
+
+// DefaultBackend is the name under which the default factory of the asset
+// and media is registered.
+const DefaultBackend = ""
+
+var (
+	factoriesMtx sync.RWMutex
+
+	// factories is the map of asset and media to the factories of the
+	// connector backends, keyed by the backend name.
+	factories = make(map[connectorKey]map[string]Factory)
+)
+
+// RegisterFactory registers default connector factory for the given asset
+// and media. It is supposed to be called in the init function of the
+// connector package.
+func RegisterFactory(asset Asset, media PaymentMedia, factory Factory) {
+	RegisterBackendFactory(asset, media, DefaultBackend, factory)
+}
+
+// RegisterBackendFactory registers factory of the connector backend with
+// the given name for the given asset and media. Backend is chosen by the
+// user in the daemon config, which allows several connector implementations
+// to exist for the same daemon.
+func RegisterBackendFactory(asset Asset, media PaymentMedia, backend string,
+	factory Factory) {
+
+	factoriesMtx.Lock()
+	defer factoriesMtx.Unlock()
+
+	key := connectorKey{asset: asset, media: media}
+	if _, ok := factories[key][backend]; ok {
+		panic(errors.Errorf("factory of backend(%v) for asset(%v) and "+
+			"media(%v) has been already registered", backend, asset, media))
+	}
+
+	if factories[key] == nil {
+		factories[key] = make(map[string]Factory)
+	}
+
+	factories[key][backend] = factory
+}
+
+// FactoryKey identifies the registered factory.
+type FactoryKey struct {
+	Asset Asset
+	Media PaymentMedia
+}
+
+// RegisteredFactories returns asset and media of every registered factory,
+// sorted by media and asset.
+func RegisteredFactories() []FactoryKey {
+	factoriesMtx.RLock()
+	defer factoriesMtx.RUnlock()
+
+	keys := make([]FactoryKey, 0, len(factories))
+	for key := range factories {
+		keys = append(keys, FactoryKey{Asset: key.asset, Media: key.media})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Media != keys[j].Media {
+			return keys[i].Media < keys[j].Media
+		}
+		return keys[i].Asset < keys[j].Asset
+	})
+
+	return keys
+}
+
+// RegisteredConnector is the connector with the asset and media it is
+// working with.
+type RegisteredConnector struct {
+	Asset     Asset
+	Media     PaymentMedia
+	Connector Connector
+}
+
+// Registry contains connectors created by the registered factories.
+type Registry struct {
+	mtx        sync.RWMutex
+	connectors map[connectorKey]Connector
+}
+
+// NewRegistry creates new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		connectors: make(map[connectorKey]Connector),
+	}
+}
+
+// Create creates connector with the factory registered for the given asset
+// and media, and adds it in the registry. Factory of the backend which is
+// specified in the daemon config is used, or the default one otherwise.
+func (r *Registry) Create(asset Asset, media PaymentMedia,
+	cfg *FactoryConfig) (Connector, error) {
+
+	backend := DefaultBackend
+	if cfg.Daemon != nil {
+		backend = cfg.Daemon.Backend
+	}
+
+	factoriesMtx.RLock()
+	backends, ok := factories[connectorKey{asset: asset, media: media}]
+	factory, backendOk := backends[backend]
+	factoriesMtx.RUnlock()
+
+	if !ok {
+		return nil, errors.Errorf("factory for asset(%v) and media(%v) "+
+			"is not registered", asset, media)
+	}
+
+	if !backendOk {
+		return nil, errors.Errorf("backend(%v) for asset(%v) and "+
+			"media(%v) is not registered", backend, asset, media)
+	}
+
+	c, err := factory(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Add(asset, media, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Add adds connector in the registry. Connector should implement interface
+// corresponding to its media.
+func (r *Registry) Add(asset Asset, media PaymentMedia, c Connector) error {
+	switch media {
+	case Blockchain:
+		if _, ok := c.(BlockchainConnector); !ok {
+			return errors.Errorf("connector for asset(%v) doesn't "+
+				"implement blockchain connector interface", asset)
+		}
+	case Lightning:
+		if _, ok := c.(LightningConnector); !ok {
+			return errors.Errorf("connector for asset(%v) doesn't "+
+				"implement lightning connector interface", asset)
+		}
+	default:
+		return errors.Errorf("unknown media(%v)", media)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	key := connectorKey{asset: asset, media: media}
+	if _, ok := r.connectors[key]; ok {
+		return errors.Errorf("connector for asset(%v) and media(%v) "+
+			"already exists", asset, media)
+	}
+
+	r.connectors[key] = c
+	return nil
+}
+
+// BlockchainConnector returns blockchain connector of the given asset.
+func (r *Registry) BlockchainConnector(asset Asset) (BlockchainConnector, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	c, ok := r.connectors[connectorKey{asset: asset, media: Blockchain}]
+	if !ok {
+		return nil, false
+	}
+
+	return c.(BlockchainConnector), true
+}
+
+// LightningConnector returns lightning connector of the given asset.
+func (r *Registry) LightningConnector(asset Asset) (LightningConnector, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	c, ok := r.connectors[connectorKey{asset: asset, media: Lightning}]
+	if !ok {
+		return nil, false
+	}
+
+	return c.(LightningConnector), true
+}
+
+// BlockchainConnectors returns all blockchain connectors.
+func (r *Registry) BlockchainConnectors() map[Asset]BlockchainConnector {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	cntrs := make(map[Asset]BlockchainConnector)
+	for key, c := range r.connectors {
+		if key.media == Blockchain {
+			cntrs[key.asset] = c.(BlockchainConnector)
+		}
+	}
+
+	return cntrs
+}
+
+// LightningConnectors returns all lightning connectors.
+func (r *Registry) LightningConnectors() map[Asset]LightningConnector {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	cntrs := make(map[Asset]LightningConnector)
+	for key, c := range r.connectors {
+		if key.media == Lightning {
+			cntrs[key.asset] = c.(LightningConnector)
+		}
+	}
+
+	return cntrs
+}
+
+// Connectors returns all connectors, sorted by media and asset.
+func (r *Registry) Connectors() []RegisteredConnector {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	cntrs := make([]RegisteredConnector, 0, len(r.connectors))
+	for key, c := range r.connectors {
+		cntrs = append(cntrs, RegisteredConnector{
+			Asset:     key.asset,
+			Media:     key.media,
+			Connector: c,
+		})
+	}
+
+	sort.Slice(cntrs, func(i, j int) bool {
+		if cntrs[i].Media != cntrs[j].Media {
+			return cntrs[i].Media < cntrs[j].Media
+		}
+		return cntrs[i].Asset < cntrs[j].Asset
+	})
+
+	return cntrs
+}
@@ -0,0 +1,131 @@
+package connectors
+
+import (
+	"testing"
+)
+
+// stubBlockchainConnector keeps the asset it has been created with.
+type stubBlockchainConnector struct {
+	BlockchainConnector
+	asset Asset
+}
+
+// stubConnector implements only lifecycle interface.
+type stubConnector struct {
+	Connector
+}
+
+// unregisterFactories removes factories of all backends of the given asset
+// and media, registered by the test, so that test could be run again.
+func unregisterFactories(asset Asset, media PaymentMedia) {
+	factoriesMtx.Lock()
+	defer factoriesMtx.Unlock()
+
+	delete(factories, connectorKey{asset: asset, media: media})
+}
+
+func TestRegistry(t *testing.T) {
+	defer unregisterFactories("TEST1", Blockchain)
+	defer unregisterFactories("TEST2", Blockchain)
+
+	RegisterFactory("TEST1", Blockchain, func(cfg *FactoryConfig) (Connector,
+		error) {
+		return &stubBlockchainConnector{asset: cfg.Asset}, nil
+	})
+	RegisterFactory("TEST2", Blockchain, func(cfg *FactoryConfig) (Connector,
+		error) {
+		return &stubConnector{}, nil
+	})
+
+	registry := NewRegistry()
+
+	if _, err := registry.Create("TEST1", Blockchain,
+		&FactoryConfig{Asset: "TEST1"}); err != nil {
+		t.Fatalf("unable to create connector: %v", err)
+	}
+
+	if _, err := registry.Create("TEST1", Blockchain,
+		&FactoryConfig{Asset: "TEST1"}); err == nil {
+		t.Fatalf("connector shouldn't be created twice")
+	}
+
+	if _, err := registry.Create("TEST1", Lightning,
+		&FactoryConfig{Asset: "TEST1"}); err == nil {
+		t.Fatalf("connector without factory shouldn't be created")
+	}
+
+	// Connector which doesn't implement interface of its media shouldn't
+	// be added in registry.
+	if _, err := registry.Create("TEST2", Blockchain,
+		&FactoryConfig{Asset: "TEST2"}); err == nil {
+		t.Fatalf("connector of wrong type shouldn't be created")
+	}
+
+	c, ok := registry.BlockchainConnector("TEST1")
+	if !ok {
+		t.Fatalf("connector hasn't been found")
+	}
+
+	if c.(*stubBlockchainConnector).asset != "TEST1" {
+		t.Fatalf("wrong connector config")
+	}
+
+	if _, ok := registry.LightningConnector("TEST1"); ok {
+		t.Fatalf("lightning connector shouldn't be found")
+	}
+
+	cntrs := registry.Connectors()
+	if len(cntrs) != 1 || cntrs[0].Asset != "TEST1" ||
+		cntrs[0].Media != Blockchain {
+		t.Fatalf("wrong connectors: %v", cntrs)
+	}
+
+	if len(registry.BlockchainConnectors()) != 1 ||
+		len(registry.LightningConnectors()) != 0 {
+		t.Fatalf("wrong number of connectors")
+	}
+}
+
+func TestRegistryBackend(t *testing.T) {
+	defer unregisterFactories("TEST3", Blockchain)
+
+	RegisterFactory("TEST3", Blockchain, func(cfg *FactoryConfig) (Connector,
+		error) {
+		return &stubBlockchainConnector{asset: "default"}, nil
+	})
+	RegisterBackendFactory("TEST3", Blockchain, "full",
+		func(cfg *FactoryConfig) (Connector, error) {
+			return &stubBlockchainConnector{asset: "full"}, nil
+		})
+
+	registry := NewRegistry()
+
+	if _, err := registry.Create("TEST3", Blockchain, &FactoryConfig{
+		Daemon: &DaemonConfig{Backend: "unknown"},
+	}); err == nil {
+		t.Fatalf("connector of unknown backend shouldn't be created")
+	}
+
+	if _, err := registry.Create("TEST3", Blockchain, &FactoryConfig{
+		Daemon: &DaemonConfig{Backend: "full"},
+	}); err != nil {
+		t.Fatalf("unable to create connector: %v", err)
+	}
+
+	c, _ := registry.BlockchainConnector("TEST3")
+	if c.(*stubBlockchainConnector).asset != "full" {
+		t.Fatalf("connector should be created by the backend factory")
+	}
+
+	registry = NewRegistry()
+	if _, err := registry.Create("TEST3", Blockchain, &FactoryConfig{
+		Daemon: &DaemonConfig{},
+	}); err != nil {
+		t.Fatalf("unable to create connector: %v", err)
+	}
+
+	c, _ = registry.BlockchainConnector("TEST3")
+	if c.(*stubBlockchainConnector).asset != "default" {
+		t.Fatalf("connector should be created by the default factory")
+	}
+}
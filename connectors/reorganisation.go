@@ -0,0 +1,80 @@
+package connectors
+
+import (
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+// ReorganisationPolicy determines how large unspent outputs of the wallet
+// are split into the outputs of the optimal value.
+type ReorganisationPolicy struct {
+	// OptimalUTXOValue is the value of the outputs into which large
+	// unspent outputs are split, default of the connector is used if it is
+	// zero.
+	OptimalUTXOValue decimal.Decimal
+
+	// Priority is the fee priority of the reorganisation transaction,
+	// economy priority is used if it is default one.
+	Priority FeePriority
+
+	// Interval is the interval with which unspent outputs are
+	// reorganised. Zero disables automatic reorganisation, though it
+	// still could be requested explicitly.
+	Interval time.Duration
+}
+
+// Enabled returns true if unspent outputs should be reorganised
+// automatically.
+func (p ReorganisationPolicy) Enabled() bool {
+	return p.Interval > 0
+}
+
+// ReorganisationPriority returns the fee priority of the reorganisation
+// transaction.
+func (p ReorganisationPolicy) ReorganisationPriority() FeePriority {
+	if p.Priority == DefaultPriority {
+		return EconomyPriority
+	}
+
+	return p.Priority
+}
+
+// Validate checks that policy is consistent.
+func (p ReorganisationPolicy) Validate() error {
+	if p.OptimalUTXOValue.Sign() < 0 {
+		return errors.New("optimal utxo value shouldn't be negative")
+	}
+
+	if p.Interval < 0 {
+		return errors.New("reorganisation interval shouldn't be negative")
+	}
+
+	return nil
+}
+
+// Reorganisation describes the transaction which splits large unspent
+// outputs of the wallet.
+type Reorganisation struct {
+	// TxID is the id of the transaction, it is empty if reorganisation
+	// is only previewed.
+	TxID string
+
+	// Inputs is the number of the split unspent outputs.
+	Inputs int
+
+	// InputsAmount is the overall amount of the split unspent outputs.
+	InputsAmount decimal.Decimal
+
+	// Outputs are the amounts of the outputs of the transaction.
+	Outputs []decimal.Decimal
+
+	// Fee is the fee of the transaction.
+	Fee decimal.Decimal
+
+	// Payments are the internal payments of the outputs, fee of the
+	// transaction is split between them pro-rata to their amounts. They
+	// are empty if reorganisation is only previewed.
+	Payments []*Payment
+}
@@ -0,0 +1,416 @@
+package connectors
+
+import (
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btclog"
+	"github.com/go-errors/errors"
+)
+
+const (
+	// defaultMaxSendAttempts is the number of send attempts after which
+	// outgoing payment is marked as failed.
+	defaultMaxSendAttempts = 5
+
+	// defaultSendBackoff is the delay before the first retry of the
+	// payment, every next delay is twice bigger.
+	defaultSendBackoff = 30 * time.Second
+
+	// defaultMaxSendBackoff is the maximum delay between payment retries.
+	defaultMaxSendBackoff = 10 * time.Minute
+
+	// retryPollInterval is the interval with which storage is checked for
+	// the payments which should be retried.
+	retryPollInterval = time.Second
+)
+
+// RetryPolicy determines how outgoing payments which couldn't be sent are
+// retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of send attempts after which payment is
+	// marked as failed. One means that payment isn't retried, default is
+	// used if it is zero.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry, every next delay is
+	// twice bigger. Default is used if it is zero.
+	Backoff time.Duration
+
+	// MaxBackoff is the maximum delay between retries. Default is used if
+	// it is zero.
+	MaxBackoff time.Duration
+}
+
+// Delay returns the delay before the next attempt, it is doubled on every
+// failed attempt.
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+
+	return delay
+}
+
+// PaymentAttempts is the state of the outgoing payment which is being
+// retried. It is removed from the storage after payment has been sent or
+// marked as failed.
+type PaymentAttempts struct {
+	// PaymentID is the id of the payment which is being retried.
+	PaymentID string
+
+	// Asset and Media identify the connector which sends the payment.
+	Asset Asset
+	Media PaymentMedia
+
+	// Attempts is the number of failed send attempts.
+	Attempts int
+
+	// NextAttempt is the time after which payment should be retried.
+	NextAttempt time.Time
+
+	// LastError is the error of the last failed attempt.
+	LastError string
+}
+
+// AttemptsStorage is used to persist attempts of the outgoing payments, so
+// that retries are not lost on restart.
+//
+// NOTE: This storage should be persistent.
+type AttemptsStorage interface {
+	// PutAttempts saves attempts of the payment, or updates existing ones.
+	PutAttempts(attempts *PaymentAttempts) error
+
+	// RemoveAttempts removes attempts of the payment.
+	RemoveAttempts(paymentID string) error
+
+	// ListAttempts returns attempts of the payments which are retried by
+	// the connector of the given asset and media.
+	ListAttempts(asset Asset, media PaymentMedia) ([]*PaymentAttempts, error)
+}
+
+// IsPermanentError checks whether error is caused by the payment itself,
+// rather than by the state of the daemon or network, so that retry of the
+// payment is meaningless.
+func IsPermanentError(err error) bool {
+	switch err.(type) {
+	case *ErrInsufficientFunds, *ErrInvalidReceipt, *ErrInvalidAmount,
+		*ErrAmountMismatch:
+		return true
+	}
+
+	return false
+}
+
+// PaymentSenderConfig is a config of the payment sender.
+type PaymentSenderConfig struct {
+	// Asset and Media identify the connector which sends the payments.
+	Asset Asset
+	Media PaymentMedia
+
+	// Policy determines how payments are retried.
+	Policy RetryPolicy
+
+	// Storage is used to persist attempts of the payments.
+	Storage AttemptsStorage
+
+	// PaymentStore is used to save state of the payment on failure.
+	PaymentStore PaymentsStore
+
+	// Send sends payment to the daemon, on success payment is expected to
+	// be updated and saved by it.
+	Send func(payment *Payment) (*Payment, error)
+
+	// Lookup checks whether payment has been already sent by the daemon,
+	// for example if previous attempt has timed out, but it has actually
+	// reached the daemon. If so, payment is expected to be updated and
+	// saved by it, otherwise nil is returned. Lookup is done before every
+	// retry, so that the same payment is never sent twice.
+	Lookup func(payment *Payment) (*Payment, error)
+
+	// Abandon is called when payment has been marked as failed, so that
+	// connector could release the resources reserved by the payment. It
+	// might be nil.
+	Abandon func(payment *Payment)
+
+	// Locker is held while payment is retried, so that retry wouldn't
+	// interfere with the payments which are sent by the connector. It
+	// should be the same lock which is held by connector while calling
+	// Send of the sender. It might be nil.
+	Locker sync.Locker
+
+	Logger btclog.Logger
+}
+
+func (c *PaymentSenderConfig) validate() error {
+	if c.Asset == "" {
+		return errors.New("asset should be specified")
+	}
+
+	if c.Media == "" {
+		return errors.New("media should be specified")
+	}
+
+	if c.Policy.MaxAttempts < 0 {
+		return errors.New("max attempts shouldn't be negative")
+	}
+
+	if c.Storage == nil {
+		return errors.New("attempts storage should be specified")
+	}
+
+	if c.PaymentStore == nil {
+		return errors.New("payment store should be specified")
+	}
+
+	if c.Send == nil {
+		return errors.New("send function should be specified")
+	}
+
+	if c.Lookup == nil {
+		return errors.New("lookup function should be specified")
+	}
+
+	if c.Logger == nil {
+		return errors.New("logger should be specified")
+	}
+
+	return nil
+}
+
+// PaymentSender sends outgoing payments of the connector, and retries the
+// ones which couldn't be sent with the exponential backoff. Payment stays
+// pending while it is retried, and it is marked as failed, with the reason
+// of the failure, after all attempts have been used or if error is
+// permanent.
+type PaymentSender struct {
+	cfg *PaymentSenderConfig
+}
+
+// NewPaymentSender creates new payment sender, default policy values are
+// used for the ones which are not specified.
+func NewPaymentSender(cfg *PaymentSenderConfig) (*PaymentSender, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Policy.MaxAttempts == 0 {
+		cfg.Policy.MaxAttempts = defaultMaxSendAttempts
+	}
+
+	if cfg.Policy.Backoff == 0 {
+		cfg.Policy.Backoff = defaultSendBackoff
+	}
+
+	if cfg.Policy.MaxBackoff == 0 {
+		cfg.Policy.MaxBackoff = defaultMaxSendBackoff
+	}
+
+	return &PaymentSender{cfg: cfg}, nil
+}
+
+// Send makes the first attempt to send the payment. If attempt has failed
+// with the temporary error, payment is saved as pending and scheduled for
+// the retry, in this case error is not returned. If payment has been marked
+// as failed, the error of the attempt is returned.
+//
+// NOTE: Should be called under the locker of the config, if it is
+// specified.
+func (s *PaymentSender) Send(payment *Payment) (*Payment, error) {
+	return s.attempt(payment, &PaymentAttempts{
+		PaymentID: payment.PaymentID,
+		Asset:     s.cfg.Asset,
+		Media:     s.cfg.Media,
+	})
+}
+
+// Run retries payments which time has come, including the ones which have
+// been left since the previous run, until quit channel is closed.
+func (s *PaymentSender) Run(quit <-chan struct{}) {
+	ticker := time.NewTicker(retryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.retryPending(quit)
+
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+	}
+}
+
+// retryPending retries payments which time has come.
+func (s *PaymentSender) retryPending(quit <-chan struct{}) {
+	list, err := s.cfg.Storage.ListAttempts(s.cfg.Asset, s.cfg.Media)
+	if err != nil {
+		s.cfg.Logger.Errorf("unable to list payment attempts: %v", err)
+		return
+	}
+
+	for _, attempts := range list {
+		select {
+		case <-quit:
+			return
+		default:
+		}
+
+		if time.Now().Before(attempts.NextAttempt) {
+			continue
+		}
+
+		s.retry(attempts)
+	}
+}
+
+// retry makes the next attempt to send the payment.
+func (s *PaymentSender) retry(attempts *PaymentAttempts) {
+	if s.cfg.Locker != nil {
+		s.cfg.Locker.Lock()
+		defer s.cfg.Locker.Unlock()
+	}
+
+	payment, err := s.cfg.PaymentStore.PaymentByID(attempts.PaymentID)
+	if err != nil {
+		s.cfg.Logger.Errorf("unable to get retried payment(%v): %v",
+			attempts.PaymentID, err)
+		return
+	}
+
+	// Payment state might have been changed by the synchronisation of the
+	// connector, in this case there is nothing to retry.
+	if payment.Status != Pending {
+		s.cfg.Logger.Infof("Payment(%v) is %v, stop retrying",
+			payment.PaymentID, payment.Status)
+		s.removeAttempts(payment.PaymentID)
+		return
+	}
+
+	// If it is unknown whether payment has been already sent, it could be
+	// neither sent again nor failed, because failed payment releases
+	// resources which might be used by the sent transaction. That is why
+	// retry is postponed without using the attempt.
+	sentPayment, err := s.cfg.Lookup(payment)
+	if err != nil {
+		s.postpone(payment, attempts, errors.Errorf("unable to lookup "+
+			"payment: %v", err))
+		return
+	} else if sentPayment != nil {
+		s.cfg.Logger.Infof("Payment(%v) has been already sent, stop "+
+			"retrying", payment.PaymentID)
+		s.removeAttempts(payment.PaymentID)
+		return
+	}
+
+	s.cfg.Logger.Infof("Retry payment(%v), attempt(%v)", payment.PaymentID,
+		attempts.Attempts+1)
+
+	s.attempt(payment, attempts)
+}
+
+// attempt sends the payment and handles the failure of the attempt.
+func (s *PaymentSender) attempt(payment *Payment,
+	attempts *PaymentAttempts) (*Payment, error) {
+
+	sentPayment, err := s.cfg.Send(payment)
+	if err != nil {
+		return s.handleFailure(payment, attempts, err)
+	}
+
+	if attempts.Attempts != 0 {
+		s.removeAttempts(payment.PaymentID)
+	}
+
+	return sentPayment, nil
+}
+
+// handleFailure schedules the next attempt, or marks payment as failed if
+// error is permanent or all attempts have been used.
+func (s *PaymentSender) handleFailure(payment *Payment,
+	attempts *PaymentAttempts, sendErr error) (*Payment, error) {
+
+	attempts.Attempts++
+	attempts.LastError = sendErr.Error()
+
+	if IsPermanentError(sendErr) ||
+		attempts.Attempts >= s.cfg.Policy.MaxAttempts {
+		return nil, s.fail(payment, attempts, sendErr)
+	}
+
+	attempts.NextAttempt = time.Now().Add(s.cfg.Policy.Delay(attempts.Attempts))
+	if err := s.cfg.Storage.PutAttempts(attempts); err != nil {
+		s.cfg.Logger.Errorf("unable to save attempts of payment(%v): %v",
+			payment.PaymentID, err)
+		return nil, s.fail(payment, attempts, sendErr)
+	}
+
+	payment.Status = Pending
+	payment.UpdatedAt = NowInMilliSeconds()
+	if err := s.cfg.PaymentStore.SavePayment(payment); err != nil {
+		return nil, errors.Errorf("unable update payment(%v) status: %v",
+			payment.PaymentID, err)
+	}
+
+	s.cfg.Logger.Warnf("Unable to send payment(%v), attempt(%v), retry "+
+		"at %v: %v", payment.PaymentID, attempts.Attempts,
+		attempts.NextAttempt, sendErr)
+
+	return payment, nil
+}
+
+// postpone schedules the next attempt to send the payment, without
+// increasing the number of attempts.
+func (s *PaymentSender) postpone(payment *Payment, attempts *PaymentAttempts,
+	lookupErr error) {
+
+	attempts.LastError = lookupErr.Error()
+	attempts.NextAttempt = time.Now().Add(s.cfg.Policy.Delay(
+		attempts.Attempts))
+	if err := s.cfg.Storage.PutAttempts(attempts); err != nil {
+		s.cfg.Logger.Errorf("unable to save attempts of payment(%v): %v",
+			payment.PaymentID, err)
+		return
+	}
+
+	s.cfg.Logger.Warnf("Unable to retry payment(%v), retry at %v: %v",
+		payment.PaymentID, attempts.NextAttempt, lookupErr)
+}
+
+// fail marks payment as failed with the error of the last attempt, and
+// returns this error.
+func (s *PaymentSender) fail(payment *Payment, attempts *PaymentAttempts,
+	sendErr error) error {
+
+	if attempts.Attempts > 1 {
+		s.removeAttempts(payment.PaymentID)
+	}
+
+	payment.Status = Failed
+	payment.FailureReason = sendErr.Error()
+	payment.UpdatedAt = NowInMilliSeconds()
+	if err := s.cfg.PaymentStore.SavePayment(payment); err != nil {
+		s.cfg.Logger.Errorf("unable to mark payment(%v) as failed: %v",
+			payment.PaymentID, err)
+	}
+
+	if s.cfg.Abandon != nil {
+		s.cfg.Abandon(payment)
+	}
+
+	s.cfg.Logger.Errorf("Payment(%v) has failed after %v attempt(s): %v",
+		payment.PaymentID, attempts.Attempts, sendErr)
+
+	return sendErr
+}
+
+func (s *PaymentSender) removeAttempts(paymentID string) {
+	if err := s.cfg.Storage.RemoveAttempts(paymentID); err != nil {
+		s.cfg.Logger.Errorf("unable to remove attempts of payment(%v): %v",
+			paymentID, err)
+	}
+}
@@ -0,0 +1,307 @@
+package connectors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btclog"
+	"github.com/go-errors/errors"
+)
+
+// memoryPaymentsStore implements only methods of payments store which are
+// used by payment sender.
+type memoryPaymentsStore struct {
+	PaymentsStore
+	payments map[string]Payment
+}
+
+func (s *memoryPaymentsStore) PaymentByID(id string) (*Payment, error) {
+	payment, ok := s.payments[id]
+	if !ok {
+		return nil, PaymentNotFound
+	}
+
+	return &payment, nil
+}
+
+func (s *memoryPaymentsStore) PaymentByIdempotencyKey(key string) (*Payment,
+	error) {
+	for _, payment := range s.payments {
+		if payment.IdempotencyKey == key {
+			return &payment, nil
+		}
+	}
+
+	return nil, PaymentNotFound
+}
+
+func (s *memoryPaymentsStore) SavePayment(payment *Payment) error {
+	s.payments[payment.PaymentID] = *payment
+	return nil
+}
+
+// memoryAttemptsStorage is an attempts storage which keeps attempts in
+// memory.
+type memoryAttemptsStorage struct {
+	attempts map[string]PaymentAttempts
+}
+
+func (s *memoryAttemptsStorage) PutAttempts(attempts *PaymentAttempts) error {
+	s.attempts[attempts.PaymentID] = *attempts
+	return nil
+}
+
+func (s *memoryAttemptsStorage) RemoveAttempts(paymentID string) error {
+	delete(s.attempts, paymentID)
+	return nil
+}
+
+func (s *memoryAttemptsStorage) ListAttempts(asset Asset,
+	media PaymentMedia) ([]*PaymentAttempts, error) {
+
+	var list []*PaymentAttempts
+	for _, attempts := range s.attempts {
+		if attempts.Asset == asset && attempts.Media == media {
+			a := attempts
+			list = append(list, &a)
+		}
+	}
+
+	return list, nil
+}
+
+// stubDaemon is a daemon which fails the given number of send attempts.
+type stubDaemon struct {
+	store *memoryPaymentsStore
+
+	failures  int
+	sendErr   error
+	sent      int
+	known     bool
+	lookupErr error
+	abandoned int
+}
+
+func (d *stubDaemon) send(payment *Payment) (*Payment, error) {
+	if d.failures > 0 {
+		d.failures--
+		return nil, d.sendErr
+	}
+
+	d.sent++
+	payment.Status = Pending
+	return payment, d.store.SavePayment(payment)
+}
+
+func (d *stubDaemon) lookup(payment *Payment) (*Payment, error) {
+	if d.lookupErr != nil {
+		return nil, d.lookupErr
+	}
+
+	if !d.known {
+		return nil, nil
+	}
+
+	payment.Status = Pending
+	return payment, d.store.SavePayment(payment)
+}
+
+func (d *stubDaemon) abandon(payment *Payment) {
+	d.abandoned++
+}
+
+func newTestSender(t *testing.T, maxAttempts int, failures int,
+	sendErr error) (*PaymentSender, *stubDaemon, *memoryAttemptsStorage) {
+
+	store := &memoryPaymentsStore{payments: make(map[string]Payment)}
+	storage := &memoryAttemptsStorage{
+		attempts: make(map[string]PaymentAttempts),
+	}
+	daemon := &stubDaemon{
+		store:    store,
+		failures: failures,
+		sendErr:  sendErr,
+	}
+
+	sender, err := NewPaymentSender(&PaymentSenderConfig{
+		Asset: BTC,
+		Media: Blockchain,
+		Policy: RetryPolicy{
+			MaxAttempts: maxAttempts,
+			Backoff:     time.Millisecond,
+		},
+		Storage:      storage,
+		PaymentStore: store,
+		Send:         daemon.send,
+		Lookup:       daemon.lookup,
+		Abandon:      daemon.abandon,
+		Logger:       btclog.Disabled,
+	})
+	if err != nil {
+		t.Fatalf("unable to create sender: %v", err)
+	}
+
+	return sender, daemon, storage
+}
+
+// retryAll makes the next attempt for all retried payments.
+func retryAll(sender *PaymentSender, storage *memoryAttemptsStorage) {
+	for id, attempts := range storage.attempts {
+		attempts.NextAttempt = time.Time{}
+		storage.attempts[id] = attempts
+	}
+
+	sender.retryPending(make(chan struct{}))
+}
+
+func TestPaymentSenderRetry(t *testing.T) {
+	sender, daemon, storage := newTestSender(t, 3, 1,
+		errors.New("connection refused"))
+
+	payment, err := sender.Send(&Payment{PaymentID: "1", Status: Waiting})
+	if err != nil {
+		t.Fatalf("temporary error shouldn't be returned: %v", err)
+	}
+
+	if payment.Status != Pending {
+		t.Fatalf("retried payment should be pending, got %v",
+			payment.Status)
+	}
+
+	attempts, ok := storage.attempts["1"]
+	if !ok || attempts.Attempts != 1 ||
+		attempts.LastError != "connection refused" {
+		t.Fatalf("wrong attempts: %v", attempts)
+	}
+
+	retryAll(sender, storage)
+
+	if daemon.sent != 1 {
+		t.Fatalf("payment should be sent once, sent %v times", daemon.sent)
+	}
+
+	if len(storage.attempts) != 0 {
+		t.Fatalf("attempts of sent payment should be removed")
+	}
+}
+
+func TestPaymentSenderLookup(t *testing.T) {
+	sender, daemon, storage := newTestSender(t, 3, 1,
+		errors.New("timeout"))
+
+	if _, err := sender.Send(&Payment{PaymentID: "1"}); err != nil {
+		t.Fatalf("temporary error shouldn't be returned: %v", err)
+	}
+
+	// Previous attempt has reached the daemon, that is why payment
+	// shouldn't be sent again.
+	daemon.known = true
+	retryAll(sender, storage)
+
+	if daemon.sent != 0 {
+		t.Fatalf("already sent payment shouldn't be sent again")
+	}
+
+	if len(storage.attempts) != 0 {
+		t.Fatalf("attempts of sent payment should be removed")
+	}
+}
+
+// TestPaymentSenderLookupFailed checks that payment isn't failed while it
+// is unknown whether it has been already sent.
+func TestPaymentSenderLookupFailed(t *testing.T) {
+	sender, daemon, storage := newTestSender(t, 2, 1,
+		errors.New("timeout"))
+
+	if _, err := sender.Send(&Payment{PaymentID: "1"}); err != nil {
+		t.Fatalf("temporary error shouldn't be returned: %v", err)
+	}
+
+	daemon.lookupErr = errors.New("connection refused")
+	for i := 0; i < 5; i++ {
+		retryAll(sender, storage)
+	}
+
+	payment, _ := daemon.store.PaymentByID("1")
+	if payment.Status != Pending || daemon.abandoned != 0 ||
+		daemon.sent != 0 {
+		t.Fatalf("payment shouldn't be failed or sent, got %v",
+			payment.Status)
+	}
+
+	attempts := storage.attempts["1"]
+	if attempts.Attempts != 1 ||
+		attempts.LastError != "unable to lookup payment: connection refused" {
+		t.Fatalf("lookup failure shouldn't use the attempt: %v", attempts)
+	}
+
+	daemon.lookupErr = nil
+	retryAll(sender, storage)
+
+	if daemon.sent != 1 || len(storage.attempts) != 0 {
+		t.Fatalf("payment should be sent after successful lookup")
+	}
+}
+
+func TestPaymentSenderFail(t *testing.T) {
+	sender, daemon, storage := newTestSender(t, 2, 2,
+		errors.New("connection refused"))
+
+	if _, err := sender.Send(&Payment{PaymentID: "1"}); err != nil {
+		t.Fatalf("temporary error shouldn't be returned: %v", err)
+	}
+
+	retryAll(sender, storage)
+
+	payment, _ := daemon.store.PaymentByID("1")
+	if payment.Status != Failed ||
+		payment.FailureReason != "connection refused" {
+		t.Fatalf("payment should be failed with reason, got %v(%v)",
+			payment.Status, payment.FailureReason)
+	}
+
+	if daemon.abandoned != 1 {
+		t.Fatalf("failed payment should be abandoned")
+	}
+
+	if len(storage.attempts) != 0 {
+		t.Fatalf("attempts of failed payment should be removed")
+	}
+}
+
+func TestPaymentSenderPermanentError(t *testing.T) {
+	sender, daemon, storage := newTestSender(t, 3, 1,
+		&ErrInsufficientFunds{})
+
+	_, err := sender.Send(&Payment{PaymentID: "1"})
+	if _, ok := err.(*ErrInsufficientFunds); !ok {
+		t.Fatalf("permanent error should be returned, got %v", err)
+	}
+
+	payment, _ := daemon.store.PaymentByID("1")
+	if payment.Status != Failed || payment.FailureReason == "" {
+		t.Fatalf("payment should be failed with reason")
+	}
+
+	if len(storage.attempts) != 0 {
+		t.Fatalf("payment with permanent error shouldn't be retried")
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{
+		Backoff:    time.Second,
+		MaxBackoff: 5 * time.Second,
+	}
+
+	expected := []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second,
+		5 * time.Second,
+	}
+
+	for i, delay := range expected {
+		if d := policy.Delay(i + 1); d != delay {
+			t.Fatalf("wrong delay of attempt(%v): %v", i+1, d)
+		}
+	}
+}
@@ -1,7 +1,12 @@
 package bitcoin
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
+
 	"github.com/bitlum/connector/common"
 	"github.com/bitlum/connector/connectors"
 	"github.com/bitlum/connector/connectors/rpc"
@@ -73,7 +78,8 @@ func (c *Client) GetBlockChainInfo() (*rpc.BlockChainInfoResp, error) {
 	}
 
 	resp := &rpc.BlockChainInfoResp{
-		Chain: daemonResp.Chain,
+		Chain:  daemonResp.Chain,
+		Blocks: int64(daemonResp.Blocks),
 	}
 
 	c.Logger.Tracef("method: %v, response: %v", common.GetFunctionName(),
@@ -123,6 +129,35 @@ func (c *Client) GetBestBlockHash() (*chainhash.Hash, error) {
 	return resp, err
 }
 
+// NOTE: Part of the rpc.Client interface. For more info look in
+// the interface description.
+func (c *Client) GetNetworkInfo() (*rpc.NetworkInfoResp, error) {
+	res, err := c.Daemon.RawRequest("getnetworkinfo", nil)
+	if err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, err
+	}
+
+	var daemonResp struct {
+		Version    int64  `json:"version"`
+		SubVersion string `json:"subversion"`
+	}
+	if err := json.Unmarshal(res, &daemonResp); err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, err
+	}
+
+	resp := &rpc.NetworkInfoResp{
+		Version:    daemonResp.Version,
+		SubVersion: daemonResp.SubVersion,
+	}
+
+	c.Logger.Tracef("method: %v, response: %v", common.GetFunctionName(),
+		spew.Sdump(resp))
+
+	return resp, nil
+}
+
 // NOTE: Part of the rpc.Client interface. For more info look in
 // the interface description.
 func (c *Client) UnlockUnspent() error {
@@ -161,6 +196,29 @@ func (c *Client) LockUnspent(input rpc.UnspentInput) error {
 	return nil
 }
 
+// NOTE: Part of the rpc.Client interface. For more info look in
+// the interface description.
+func (c *Client) UnlockUnspentInput(input rpc.UnspentInput) error {
+	hash, err := chainhash.NewHashFromStr(input.TxID)
+	if err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return err
+	}
+
+	outputs := []*wire.OutPoint{{Hash: *hash, Index: input.Vout}}
+
+	if err := c.Daemon.LockUnspent(true, outputs); err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(),
+			err)
+		return err
+	}
+
+	c.Logger.Tracef("method: %v, response: %v", common.GetFunctionName(),
+		"empty")
+
+	return nil
+}
+
 // NOTE: Part of the rpc.Client interface. For more info look in
 // the interface description.
 func (c *Client) ListUnspentMinMax(minConf, maxConf int) ([]rpc.UnspentInput,
@@ -181,6 +239,8 @@ func (c *Client) ListUnspentMinMax(minConf, maxConf int) ([]rpc.UnspentInput,
 			Confirmations: u.Confirmations,
 			TxID:          u.TxID,
 			Vout:          u.Vout,
+			ScriptPubKey:  u.ScriptPubKey,
+			WatchOnly:     !u.Spendable,
 		})
 	}
 
@@ -235,6 +295,25 @@ func (c *Client) GetNewRawChangeAddress(label string) (btcutil.Address, error) {
 	return address, nil
 }
 
+// NOTE: Part of the rpc.Client interface. For more info look in
+// the interface description.
+func (c *Client) ImportAddress(address btcutil.Address, label string) error {
+	params, err := MarshalParams(address.EncodeAddress(), label, false)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.Daemon.RawRequest("importaddress", params); err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return err
+	}
+
+	c.Logger.Tracef("method: %v, address: %v", common.GetFunctionName(),
+		address)
+
+	return nil
+}
+
 // NOTE: Part of the rpc.Client interface. For more info look in
 // the interface description.
 func (c *Client) SignRawTransaction(tx *wire.MsgTx) (*wire.MsgTx, error) {
@@ -319,7 +398,7 @@ func (c *Client) GetBalanceByLabel(label string,
 func (c *Client) GetTransaction(txHash *chainhash.Hash) (
 	*rpc.Transaction, error) {
 
-	tx, err := c.Daemon.GetTransaction(txHash)
+	tx, err := c.getTransaction(txHash)
 	if err != nil {
 		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
 		return nil, err
@@ -338,11 +417,16 @@ func (c *Client) GetTransaction(txHash *chainhash.Hash) (
 		}
 	}
 
+	// Size is only known for the transactions which are returned with the
+	// hex, and it is zero otherwise.
+	vsize, _ := txVSize(tx.Hex)
+
 	resp := &rpc.Transaction{
 		Amount:        tx.Amount,
 		Fee:           tx.Fee,
 		Confirmations: tx.Confirmations,
 		TxID:          tx.TxID,
+		VSize:         vsize,
 		Details:       details,
 	}
 
@@ -354,8 +438,7 @@ func (c *Client) GetTransaction(txHash *chainhash.Hash) (
 
 // NOTE: Part of the rpc.Client interface. For more info look in
 // the interface description.
-func (c *Client) EstimateFee() (float64, error) {
-	confTarget := uint32(2)
+func (c *Client) EstimateFee(confTarget uint32) (float64, error) {
 	res, err := c.Daemon.EstimateSmartFeeWithMode(confTarget,
 		btcjson.ConservativeEstimateMode)
 	if err != nil {
@@ -375,7 +458,9 @@ func (c *Client) EstimateFee() (float64, error) {
 		return 0, err
 	}
 
-	if res.Blocks != int(confTarget) {
+	// Daemon returns estimate for the bigger number of blocks, if there
+	// is not enough data for the requested one.
+	if res.Blocks > int(confTarget) {
 		err := errors.New("not enough data to make an estimation")
 		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
 		return 0, err
@@ -407,6 +492,144 @@ func (c *Client) SendToAddress(address btcutil.Address,
 	return c.Daemon.SendToAddress(address, amount)
 }
 
+// NOTE: Part of the rpc.Client interface. For more info look in
+// the interface description.
+//
+// Transaction signals replace-by-fee, so that its fee could be bumped.
+func (c *Client) SendMany(amounts map[btcutil.Address]btcutil.Amount,
+	comment string) (*chainhash.Hash, error) {
+	return c.SendManyWithParams(amounts, 1, comment, []string{}, true)
+}
+
+// SendManyWithParams sends the passed amounts in one transaction with the
+// given parameters of the sendmany request, which follow the outputs,
+// because they are different for every daemon.
+func (c *Client) SendManyWithParams(amounts map[btcutil.Address]btcutil.Amount,
+	params ...interface{}) (*chainhash.Hash, error) {
+
+	outputs := make(map[string]float64, len(amounts))
+	for address, amount := range amounts {
+		outputs[address.EncodeAddress()] = amount.ToBTC()
+	}
+
+	rawParams, err := MarshalParams(append([]interface{}{"", outputs},
+		params...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Daemon.RawRequest("sendmany", rawParams)
+	if err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, err
+	}
+
+	var txID string
+	if err := json.Unmarshal(res, &txID); err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, err
+	}
+
+	return chainhash.NewHashFromStr(txID)
+}
+
+// NOTE: Part of the rpc.Client interface. For more info look in
+// the interface description.
+func (c *Client) BumpFee(txHash *chainhash.Hash, feeRate float64) (
+	*chainhash.Hash, error) {
+
+	version, err := c.daemonVersion()
+	if err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, err
+	}
+
+	// Fee rate option has been added in 0.21 version, before that only the
+	// total fee of the replacement could be specified.
+	options := make(map[string]interface{})
+	if version >= 210000 {
+		options["fee_rate"] = feeRate
+	} else {
+		tx, err := c.getTransaction(txHash)
+		if err != nil {
+			c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(),
+				err)
+			return nil, err
+		}
+
+		vsize, err := txVSize(tx.Hex)
+		if err != nil {
+			return nil, err
+		}
+
+		options["totalFee"] = int64(math.Ceil(feeRate * float64(vsize)))
+	}
+
+	params, err := MarshalParams(txHash.String(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Daemon.RawRequest("bumpfee", params)
+	if err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, err
+	}
+
+	var resp struct {
+		TxID   string   `json:"txid"`
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(res, &resp); err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, err
+	}
+
+	if len(resp.Errors) != 0 {
+		err := errors.New(resp.Errors[0])
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, err
+	}
+
+	c.Logger.Tracef("method: %v, response: %v", common.GetFunctionName(),
+		resp.TxID)
+
+	return chainhash.NewHashFromStr(resp.TxID)
+}
+
+// daemonVersion returns the version of the daemon, e.g. 210000 for 0.21.0.
+func (c *Client) daemonVersion() (int, error) {
+	res, err := c.Daemon.RawRequest("getnetworkinfo", nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var info struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(res, &info); err != nil {
+		return 0, err
+	}
+
+	return info.Version, nil
+}
+
+// txVSize returns virtual size of the hex encoded transaction.
+func txVSize(txHex string) (int, error) {
+	rawTx, err := hex.DecodeString(txHex)
+	if err != nil {
+		return 0, errors.Errorf("unable to decode tx hex: %v", err)
+	}
+
+	tx := new(wire.MsgTx)
+	if err := tx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+		return 0, errors.Errorf("unable to deserialize tx: %v", err)
+	}
+
+	weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+	return (weight + 3) / 4, nil
+}
+
 // NOTE: Part of the rpc.Client interface. For more info look in
 // the interface description.
 func (c *Client) ListTransactionByLabel(label string, count, from int) (
@@ -414,11 +637,45 @@ func (c *Client) ListTransactionByLabel(label string, count, from int) (
 	return c.Daemon.ListTransactionsCountFrom(label, count, from)
 }
 
+// NOTE: Part of the rpc.Client interface. For more info look in
+// the interface description.
+func (c *Client) ListSinceBlock(blockHash *chainhash.Hash,
+	targetConfirmations int) (*btcjson.ListSinceBlockResult, error) {
+
+	// Empty hash is treated by the daemon as the request of all
+	// transactions.
+	hash := ""
+	if blockHash != nil {
+		hash = blockHash.String()
+	}
+
+	// Watch-only transactions are requested explicitly, because daemon
+	// doesn't return them by default.
+	params, err := MarshalParams(hash, targetConfirmations, true)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Daemon.RawRequest("listsinceblock", params)
+	if err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, err
+	}
+
+	var resp btcjson.ListSinceBlockResult
+	if err := json.Unmarshal(res, &resp); err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
 // NOTE: Part of the rpc.Client interface. For more info look in
 // the interface description.
 func (c *Client) GetTransactionByHash(hash *chainhash.Hash) (
 	*rpc.Transaction, error) {
-	tx, err := c.Daemon.GetTransaction(hash)
+	tx, err := c.getTransaction(hash)
 	if err != nil {
 		return nil, err
 	}
@@ -444,3 +701,160 @@ func (c *Client) GetTransactionByHash(hash *chainhash.Hash) (
 		Details:       details,
 	}, nil
 }
+
+// getTransaction returns wallet transaction, including details of the
+// watch-only addresses, which aren't returned by the daemon by default.
+func (c *Client) getTransaction(hash *chainhash.Hash) (
+	*btcjson.GetTransactionResult, error) {
+
+	params, err := MarshalParams(hash.String(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Daemon.RawRequest("gettransaction", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx btcjson.GetTransactionResult
+	if err := json.Unmarshal(res, &tx); err != nil {
+		return nil, err
+	}
+
+	return &tx, nil
+}
+
+// NOTE: Part of the rpc.Client interface. For more info look in
+// the interface description.
+func (c *Client) CreatePSBT(tx *wire.MsgTx) (string, error) {
+	var rawTx bytes.Buffer
+	if err := tx.Serialize(&rawTx); err != nil {
+		return "", err
+	}
+
+	params, err := MarshalParams(hex.EncodeToString(rawTx.Bytes()))
+	if err != nil {
+		return "", err
+	}
+
+	res, err := c.Daemon.RawRequest("converttopsbt", params)
+	if err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return "", err
+	}
+
+	var psbt string
+	if err := json.Unmarshal(res, &psbt); err != nil {
+		return "", err
+	}
+
+	// Wallet fills the information about the spent outputs, which is
+	// needed by the signer, but inputs are not signed.
+	params, err = MarshalParams(psbt, false, "ALL", true)
+	if err != nil {
+		return "", err
+	}
+
+	res, err = c.Daemon.RawRequest("walletprocesspsbt", params)
+	if err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return "", err
+	}
+
+	var resp struct {
+		PSBT string `json:"psbt"`
+	}
+	if err := json.Unmarshal(res, &resp); err != nil {
+		return "", err
+	}
+
+	c.Logger.Tracef("method: %v, response: %v", common.GetFunctionName(),
+		resp.PSBT)
+
+	return resp.PSBT, nil
+}
+
+// NOTE: Part of the rpc.Client interface. For more info look in
+// the interface description.
+func (c *Client) CombinePSBT(psbts ...string) (string, error) {
+	params, err := MarshalParams(psbts)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := c.Daemon.RawRequest("combinepsbt", params)
+	if err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return "", err
+	}
+
+	var psbt string
+	if err := json.Unmarshal(res, &psbt); err != nil {
+		return "", err
+	}
+
+	c.Logger.Tracef("method: %v, response: %v", common.GetFunctionName(),
+		psbt)
+
+	return psbt, nil
+}
+
+// NOTE: Part of the rpc.Client interface. For more info look in
+// the interface description.
+func (c *Client) FinalizePSBT(psbt string) (*wire.MsgTx, bool, error) {
+	params, err := MarshalParams(psbt, true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	res, err := c.Daemon.RawRequest("finalizepsbt", params)
+	if err != nil {
+		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
+		return nil, false, err
+	}
+
+	var resp struct {
+		Hex      string `json:"hex"`
+		Complete bool   `json:"complete"`
+	}
+	if err := json.Unmarshal(res, &resp); err != nil {
+		return nil, false, err
+	}
+
+	if !resp.Complete {
+		c.Logger.Tracef("method: %v, response: %v", common.GetFunctionName(),
+			"incomplete")
+		return nil, false, nil
+	}
+
+	rawTx, err := hex.DecodeString(resp.Hex)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tx := new(wire.MsgTx)
+	if err := tx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+		return nil, false, err
+	}
+
+	c.Logger.Tracef("method: %v, response: %v", common.GetFunctionName(),
+		spew.Sdump(tx))
+
+	return tx, true, nil
+}
+
+// MarshalParams marshals parameters of the raw request to the daemon.
+func MarshalParams(params ...interface{}) ([]json.RawMessage, error) {
+	rawParams := make([]json.RawMessage, len(params))
+	for i, param := range params {
+		rawParam, err := json.Marshal(param)
+		if err != nil {
+			return nil, errors.Errorf("unable to marshal param(%v): %v",
+				param, err)
+		}
+		rawParams[i] = rawParam
+	}
+
+	return rawParams, nil
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/bitlum/connector/connectors/rpc"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/bitlum/connector/common"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
 )
 
 type ClientConfig bitcoin.ClientConfig
@@ -22,10 +24,10 @@ func NewClient(cfg ClientConfig) (*Client, error) {
 }
 
 // NOTE: Part of the rpc.Client interface.
-func (c *Client) EstimateFee() (float64, error) {
+func (c *Client) EstimateFee(confTarget uint32) (float64, error) {
 	// Bitcoin Cash has removed estimatesmartfee in 17.2 version of their
 	// client.
-	res, err := c.Client.Daemon.EstimateFee(2)
+	res, err := c.Client.Daemon.EstimateFee(int64(confTarget))
 	if err != nil {
 		c.Logger.Tracef("method: %v, error: %v", err)
 		return 0, err
@@ -49,3 +51,13 @@ func (c *Client) EstimateFee() (float64, error) {
 
 	return feeRate, nil
 }
+
+// SendMany sends the passed amounts in one transaction with the given
+// comment. Bitcoin Cash doesn't support replace-by-fee, so transaction is
+// sent without the signal.
+//
+// NOTE: Part of the rpc.Client interface.
+func (c *Client) SendMany(amounts map[btcutil.Address]btcutil.Amount,
+	comment string) (*chainhash.Hash, error) {
+	return c.SendManyWithParams(amounts, 1, comment)
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/bitlum/connector/connectors/rpc/bitcoin"
 	"github.com/bitlum/go-bitcoind-rpc/btcjson"
 	"github.com/bitlum/go-bitcoind-rpc/rpcclient"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/go-errors/errors"
 )
@@ -41,7 +43,8 @@ func (c *Client) GetBlockChainInfo() (*rpc.BlockChainInfoResp, error) {
 	}
 
 	resp := &rpc.BlockChainInfoResp{
-		Chain: info.Chain,
+		Chain:  info.Chain,
+		Blocks: int64(info.Blocks),
 	}
 
 	c.Logger.Tracef("method: %v, response: %v", common.GetFunctionName(),
@@ -65,8 +68,7 @@ func receiveDashInfo(r rpcclient.FutureGetBlockChainInfoResult) (
 	return &chainInfo, nil
 }
 
-func (c *Client) EstimateFee() (float64, error) {
-	confTarget := uint32(2)
+func (c *Client) EstimateFee(confTarget uint32) (float64, error) {
 	res, err := c.Daemon.EstimateSmartFeeWithMode(confTarget, "")
 	if err != nil {
 		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
@@ -85,7 +87,9 @@ func (c *Client) EstimateFee() (float64, error) {
 		return 0, err
 	}
 
-	if res.Blocks != int(confTarget) {
+	// Daemon returns estimate for the bigger number of blocks, if there
+	// is not enough data for the requested one.
+	if res.Blocks > int(confTarget) {
 		err := errors.New("not enough data to make an estimation")
 		c.Logger.Tracef("method: %v, error: %v", common.GetFunctionName(), err)
 		return 0, err
@@ -102,4 +106,14 @@ func (c *Client) EstimateFee() (float64, error) {
 		spew.Sdump(feeRate))
 
 	return feeRate, nil
-}
\ No newline at end of file
+}
+
+// SendMany sends the passed amounts in one transaction with the given
+// comment. In dash the fourth parameter of the sendmany is the flag of the
+// locked inputs rather than the comment.
+//
+// NOTE: Part of the rpc.Client interface.
+func (c *Client) SendMany(amounts map[btcutil.Address]btcutil.Amount,
+	comment string) (*chainhash.Hash, error) {
+	return c.SendManyWithParams(amounts, 1, false, comment)
+}
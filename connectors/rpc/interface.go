@@ -25,8 +25,9 @@ type Client interface {
 	GetBalanceByLabel(label string, minConfirms int) (btcutil.Amount, error)
 
 	// EstimateFee estimates the approximate fee per kilobyte needed
-	// for a transaction in order to be included in the block.
-	EstimateFee() (float64, error)
+	// for a transaction in order to be included in the given number of
+	// blocks.
+	EstimateFee(confTarget uint32) (float64, error)
 }
 
 type InputsManager interface {
@@ -39,6 +40,10 @@ type InputsManager interface {
 	// is marked unlocked again.
 	LockUnspent(input UnspentInput) error
 
+	// UnlockUnspentInput marks previously locked output as unlocked, so
+	// that it could be used for newly created transactions again.
+	UnlockUnspentInput(input UnspentInput) error
+
 	// ListUnspentMinMax returns all unspent transaction outputs known to a
 	// wallet, using the specified number of minimum and maximum number of
 	// confirmations as a filter.
@@ -53,6 +58,15 @@ type TransactionManager interface {
 	// ListTransactionByLabel return list of transactions.
 	ListTransactionByLabel(label string, count, from int) ([]btcjson.ListTransactionsResult, error)
 
+	// ListSinceBlock returns wallet transactions which have been made after
+	// the given block, including the unconfirmed and watch-only ones, or
+	// all transactions if hash is nil. Returned last block is the block
+	// which is deep by the given number of confirmations, it should be used
+	// as cursor for the next call, so that transactions with less
+	// confirmations are returned again.
+	ListSinceBlock(blockHash *chainhash.Hash,
+		targetConfirmations int) (*btcjson.ListSinceBlockResult, error)
+
 	// GetTransactionByHash
 	GetTransactionByHash(hash *chainhash.Hash) (*Transaction, error)
 
@@ -64,12 +78,40 @@ type TransactionManager interface {
 	// SendToAddress sends the passed amount to the given address.
 	SendToAddress(address btcutil.Address, amount btcutil.Amount) (*chainhash.Hash, error)
 
+	// SendMany sends the passed amounts to the given addresses in one
+	// transaction. Comment is saved by the wallet with the transaction, and
+	// it is returned in the list of the wallet transactions.
+	SendMany(amounts map[btcutil.Address]btcutil.Amount,
+		comment string) (*chainhash.Hash, error)
+
+	// BumpFee replaces the wallet transaction, which signals
+	// replace-by-fee, by the transaction paying the given fee rate in
+	// sat/byte, and returns hash of the replacement. Bigger fee is paid
+	// from the change of the transaction.
+	BumpFee(txHash *chainhash.Hash, feeRate float64) (*chainhash.Hash, error)
+
 	// SendRawTransaction submits the encoded transaction to the server which
 	// will then relay it to the network.
 	SendRawTransaction(tx *wire.MsgTx) error
 
-	// GetTransaction returns detailed information about a wallet transaction.
+	// GetTransaction returns detailed information about a wallet
+	// transaction, including details of the watch-only addresses.
 	GetTransaction(txHash *chainhash.Hash) (*Transaction, error)
+
+	// CreatePSBT converts the unsigned transaction to the base64 encoded
+	// partially signed transaction, as defined in BIP174, and fills it with
+	// the information about the spent outputs known by the wallet, without
+	// signing it.
+	CreatePSBT(tx *wire.MsgTx) (string, error)
+
+	// CombinePSBT combines the signatures of the partially signed
+	// transactions of the same transaction into one.
+	CombinePSBT(psbts ...string) (string, error)
+
+	// FinalizePSBT finalizes the inputs of the partially signed
+	// transaction, and extracts signed transaction from it. False is
+	// returned if not all inputs are signed.
+	FinalizePSBT(psbt string) (*wire.MsgTx, bool, error)
 }
 
 type BlocksManager interface {
@@ -84,6 +126,9 @@ type BlocksManager interface {
 	// GetBestBlockHash returns the hash of the best block in the longest block
 	// chain.
 	GetBestBlockHash() (*chainhash.Hash, error)
+
+	// GetNetworkInfo returns the information about daemon version.
+	GetNetworkInfo() (*NetworkInfoResp, error)
 }
 
 type AddressManager interface {
@@ -96,10 +141,22 @@ type AddressManager interface {
 
 	// GetNewRawChangeAddress returns new change address.
 	GetNewRawChangeAddress(label string) (btcutil.Address, error)
+
+	// ImportAddress adds the address to the wallet as watch-only with the
+	// given label, without rescanning the blockchain, so that transactions
+	// of the address are tracked by the wallet, although it isn't able to
+	// spend them.
+	ImportAddress(address btcutil.Address, label string) error
 }
 
 type BlockChainInfoResp struct {
-	Chain string
+	Chain  string
+	Blocks int64
+}
+
+type NetworkInfoResp struct {
+	Version    int64
+	SubVersion string
 }
 
 type BlockVerboseResp struct {
@@ -118,6 +175,14 @@ type UnspentInput struct {
 	Confirmations int64
 	TxID          string
 	Vout          uint32
+
+	// ScriptPubKey is the hex encoded script of the output, it is used to
+	// determine the size of the input which spends it.
+	ScriptPubKey string
+
+	// WatchOnly denotes that output belongs to the watch-only address, and
+	// couldn't be spent by the wallet.
+	WatchOnly bool
 }
 
 type Transaction struct {
@@ -125,7 +190,12 @@ type Transaction struct {
 	Fee           float64
 	Confirmations int64
 	TxID          string
-	Details       []TransactionDetails
+
+	// VSize is the virtual size of the transaction in bytes, it is zero if
+	// it is unknown.
+	VSize int
+
+	Details []TransactionDetails
 }
 
 type TransactionDetails struct {
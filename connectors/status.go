@@ -0,0 +1,117 @@
+package connectors
+
+import (
+	"sync"
+)
+
+// ConnectorState denotes the lifecycle state of the connector.
+type ConnectorState string
+
+var (
+	// Starting means that connector hasn't been started yet.
+	Starting ConnectorState = "Starting"
+
+	// Running means that connector has been successfully started and it is
+	// syncing with its daemon.
+	Running ConnectorState = "Running"
+
+	// StartFailed means that last attempt to start connector has failed,
+	// usually it means that daemon is unreachable or it works on another
+	// network.
+	StartFailed ConnectorState = "StartFailed"
+
+	// Stopped means that connector has been stopped.
+	Stopped ConnectorState = "Stopped"
+)
+
+// ConnectorStatus describes the state of the connector and of the daemon
+// behind it.
+type ConnectorStatus struct {
+	// State is the lifecycle state of the connector.
+	State ConnectorState
+
+	// StartError is the error of the last failed start attempt.
+	StartError string
+
+	// DaemonError is the error which has occurred during fetching the
+	// daemon state, if it is not empty daemon fields are not populated.
+	DaemonError string
+
+	// Net is the network the connector is working with.
+	Net string
+
+	// MinConfirmations is the number of confirmations after which
+	// payment is considered as completed.
+	MinConfirmations int
+
+	// DaemonVersion is the version of the daemon.
+	DaemonVersion string
+
+	// BestHeight is the height of the best block known to the daemon.
+	BestHeight int64
+
+	// SyncedHeight is the height of the last block processed by connector.
+	SyncedHeight int64
+
+	// Lightning is the information about lightning network node, it is
+	// populated only for lightning connectors.
+	Lightning *LightningInfo
+}
+
+// Lifecycle is used by connectors to keep track of their lifecycle state
+// and of the last start error.
+type Lifecycle struct {
+	mtx      sync.RWMutex
+	state    ConnectorState
+	startErr error
+}
+
+// Started records the result of the start attempt.
+func (l *Lifecycle) Started(err error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if err != nil {
+		l.state = StartFailed
+		l.startErr = err
+		return
+	}
+
+	l.state = Running
+}
+
+// Stopped records that connector has been stopped.
+func (l *Lifecycle) Stopped() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.state = Stopped
+}
+
+// State returns current lifecycle state and the error of the last failed
+// start attempt.
+func (l *Lifecycle) State() (ConnectorState, error) {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+
+	if l.state == "" {
+		return Starting, l.startErr
+	}
+
+	return l.state, l.startErr
+}
+
+// Status returns connector status with populated lifecycle fields.
+func (l *Lifecycle) Status() *ConnectorStatus {
+	state, startErr := l.State()
+
+	status := &ConnectorStatus{
+		State: state,
+	}
+
+	if startErr != nil {
+		status.StartError = startErr.Error()
+	}
+
+	return status
+}
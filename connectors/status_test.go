@@ -0,0 +1,38 @@
+package connectors
+
+import (
+	"testing"
+
+	"github.com/go-errors/errors"
+)
+
+func TestLifecycle(t *testing.T) {
+	var l Lifecycle
+
+	if status := l.Status(); status.State != Starting {
+		t.Fatalf("wrong initial state: %v", status.State)
+	}
+
+	l.Started(errors.New("daemon is unreachable"))
+	status := l.Status()
+	if status.State != StartFailed {
+		t.Fatalf("wrong state: %v", status.State)
+	}
+
+	if status.StartError != "daemon is unreachable" {
+		t.Fatalf("wrong start error: %v", status.StartError)
+	}
+
+	// Error of the last failed start should be kept, so that it could be
+	// seen after the successful retry.
+	l.Started(nil)
+	status = l.Status()
+	if status.State != Running || status.StartError == "" {
+		t.Fatalf("wrong status: %v", status)
+	}
+
+	l.Stopped()
+	if status := l.Status(); status.State != Stopped {
+		t.Fatalf("wrong state: %v", status.State)
+	}
+}
@@ -1,7 +1,12 @@
 package connectors
 
 import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
 	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
 )
 
 // PaymentStorage is an external storage for payments, it is used by
@@ -13,16 +18,217 @@ type PaymentsStore interface {
 	// PaymentByReceipt returns payment by receipt.
 	PaymentByReceipt(receipt string) ([]*Payment, error)
 
+	// PaymentByIdempotencyKey returns payment which was sent with the given
+	// idempotency key.
+	PaymentByIdempotencyKey(key string) (*Payment, error)
+
 	// SavePayment add payment to the store.
+	//
+	// NOTE: Idempotency key of the already stored payment is never
+	// overwritten with the empty value.
 	SavePayment(payment *Payment) error
 
 	// ListPayments return list of all payments.
 	ListPayments(asset Asset, status PaymentStatus, direction PaymentDirection,
 		media PaymentMedia, system PaymentSystem) ([]*Payment, error)
+
+	// ListPaymentsPage returns page of payments which corresponds to the
+	// given query, ordered from the most recently updated ones, as well as
+	// cursor which should be used to fetch the next page. Empty cursor
+	// means that there are no more payments.
+	ListPaymentsPage(query PaymentsQuery) ([]*Payment, string, error)
 }
 
 var PaymentNotFound = errors.New("payment not found")
 
+// ErrIdempotencyKeyReused is returned when payment with the same
+// idempotency key has been already sent, but with different parameters.
+var ErrIdempotencyKeyReused = errors.New("idempotency key has been already " +
+	"used for the payment with different parameters")
+
+// PaymentByIdempotencyKey returns payment which was previously sent with the
+// given idempotency key, and ensures that it was sent with the same
+// parameters. If there is no such payment, nil is returned.
+//
+// NOTE: Zero amount means that amount is determined by the receipt itself,
+// as it happens with lightning network invoices, and it is not checked.
+func PaymentByIdempotencyKey(store PaymentsStore, key string, asset Asset,
+	media PaymentMedia, receipt string, amount decimal.Decimal) (*Payment,
+	error) {
+
+	if key == "" {
+		return nil, nil
+	}
+
+	payment, err := store.PaymentByIdempotencyKey(key)
+	if err == PaymentNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if payment.Asset != asset || payment.Media != media ||
+		payment.Receipt != receipt {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if !amount.IsZero() && !payment.Amount.Round(8).Equal(amount.Round(8)) {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	return payment, nil
+}
+
+// ErrPaymentNotWaiting is returned when payment which is requested to be
+// confirmed or canceled isn't waiting for the approval.
+var ErrPaymentNotWaiting = errors.New("payment is not waiting for approval")
+
+// WaitingPayment returns outgoing payment which has been created by the
+// connector of the given asset and media, and which is waiting for the
+// approval to be sent.
+func WaitingPayment(store PaymentsStore, paymentID string, asset Asset,
+	media PaymentMedia) (*Payment, error) {
+
+	payment, err := store.PaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Asset != asset || payment.Media != media ||
+		payment.Direction != Outgoing || payment.System != External {
+		return nil, PaymentNotFound
+	}
+
+	if payment.Status != Waiting {
+		return nil, ErrPaymentNotWaiting
+	}
+
+	return payment, nil
+}
+
+// ErrPaymentNotPending is returned when fee of the payment is requested to
+// be bumped, but payment isn't pending.
+var ErrPaymentNotPending = errors.New("payment is not pending")
+
+// PendingPayment returns outgoing payment which has been sent by the
+// connector of the given asset and media, and which transaction isn't
+// confirmed yet.
+func PendingPayment(store PaymentsStore, paymentID string, asset Asset,
+	media PaymentMedia) (*Payment, error) {
+
+	payment, err := store.PaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Asset != asset || payment.Media != media ||
+		payment.Direction != Outgoing || payment.System != External {
+		return nil, PaymentNotFound
+	}
+
+	if payment.Status != Pending {
+		return nil, ErrPaymentNotPending
+	}
+
+	return payment, nil
+}
+
+// ErrInvalidCursor is returned when payments page cursor couldn't be
+// decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PaymentsQuery is used to fetch payments page by page. Empty value of
+// the field means that payments shouldn't be filtered by it.
+type PaymentsQuery struct {
+	Asset     Asset
+	Status    PaymentStatus
+	Direction PaymentDirection
+	Media     PaymentMedia
+	System    PaymentSystem
+
+	// UpdatedAfter is used to return only payments which were updated
+	// after the given time in milliseconds.
+	UpdatedAfter int64
+
+	// UpdatedBefore is used to return only payments which were updated
+	// before the given time in milliseconds.
+	UpdatedBefore int64
+
+	// Limit is the maximum number of payments in the page, zero means
+	// that all payments should be returned.
+	Limit int
+
+	// Cursor is the value returned with the previous page, it is used to
+	// continue the listing.
+	Cursor string
+}
+
+// Match checks that payment corresponds to the query filters, cursor is
+// not taken into account.
+func (q *PaymentsQuery) Match(payment *Payment) bool {
+	if q.Asset != "" && payment.Asset != q.Asset {
+		return false
+	}
+
+	if q.Status != "" && payment.Status != q.Status {
+		return false
+	}
+
+	if q.Direction != "" && payment.Direction != q.Direction {
+		return false
+	}
+
+	if q.Media != "" && payment.Media != q.Media {
+		return false
+	}
+
+	if q.System != "" && payment.System != q.System {
+		return false
+	}
+
+	if q.UpdatedAfter != 0 && payment.UpdatedAt <= q.UpdatedAfter {
+		return false
+	}
+
+	if q.UpdatedBefore != 0 && payment.UpdatedAt >= q.UpdatedBefore {
+		return false
+	}
+
+	return true
+}
+
+// EncodePaymentsCursor creates opaque cursor which points on the given
+// payment, as far as payments are ordered by update time and id, the
+// next page starts right after it.
+//
+// NOTE: Payment which was updated while listing is in progress might be
+// returned twice or skipped, because its position in the list changes.
+func EncodePaymentsCursor(payment *Payment) string {
+	cursor := strconv.FormatInt(payment.UpdatedAt, 10) + ":" + payment.PaymentID
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+// DecodePaymentsCursor returns update time and id of the payment on which
+// cursor points.
+func DecodePaymentsCursor(cursor string) (int64, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 {
+		return 0, "", ErrInvalidCursor
+	}
+
+	updatedAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+
+	return updatedAt, parts[1], nil
+}
+
 // StateStorage is used to keep data which is needed for connector to
 // properly synchronise and track transactions.
 //
@@ -0,0 +1,337 @@
+package connectors
+
+import (
+	"time"
+
+	"github.com/bitlum/connector/common"
+	"github.com/bitlum/connector/metrics"
+	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/btcsuite/btclog"
+	"github.com/go-errors/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// defaultSweepInterval is the interval with which balance of the hot
+	// wallet is checked for the excess.
+	defaultSweepInterval = 10 * time.Minute
+)
+
+// Statuses of the sweep which are reported to the metrics backend.
+const (
+	// SweepSent means that excess of the hot wallet has been sent to the
+	// cold storage.
+	SweepSent = "sent"
+
+	// SweepSkipped means that excess hasn't been sent, because fee of the
+	// sweep has exceeded the fee caps of the policy.
+	SweepSkipped = "skipped"
+
+	// SweepFailed means that excess hasn't been sent because of the error.
+	SweepFailed = "failed"
+)
+
+// TreasuryPolicy determines how much funds are kept in the hot wallet of
+// the daemon, the excess is swept to the cold storage.
+type TreasuryPolicy struct {
+	// MaxHotBalance is the confirmed balance of the hot wallet exceeding
+	// which triggers the sweep. Zero disables sweeping.
+	MaxHotBalance decimal.Decimal
+
+	// TargetHotBalance is the balance which is left in the hot wallet
+	// after the sweep, it shouldn't exceed the max hot balance.
+	TargetHotBalance decimal.Decimal
+
+	// ColdAddress is the address of the cold storage, to which excess is
+	// swept.
+	ColdAddress string
+
+	// ColdDerivation determines the extended public key of the cold
+	// storage, from which the new address is derived for every sweep. It
+	// is used instead of the cold address.
+	ColdDerivation DerivationPolicy
+
+	// MinSweepAmount is the amount below which excess isn't swept, so that
+	// fee isn't wasted on the small transactions.
+	MinSweepAmount decimal.Decimal
+
+	// MaxSweepFee is the maximum fee of the sweep, zero means that fee
+	// isn't limited.
+	MaxSweepFee decimal.Decimal
+
+	// MaxSweepFeeRate is the maximum fee rate of the sweep in the smallest
+	// units of the asset per byte, zero means that fee rate isn't limited.
+	MaxSweepFeeRate float64
+
+	// Priority is the fee priority of the sweep, economy priority is used
+	// if it is default one.
+	Priority FeePriority
+
+	// PollInterval is the interval with which balance of the hot wallet is
+	// checked, default is used if it is zero.
+	PollInterval time.Duration
+}
+
+// Enabled returns true if excess of the hot wallet should be swept.
+func (p TreasuryPolicy) Enabled() bool {
+	return p.MaxHotBalance.Sign() > 0
+}
+
+// SweepPriority returns the fee priority of the sweep.
+func (p TreasuryPolicy) SweepPriority() FeePriority {
+	if p.Priority == DefaultPriority {
+		return EconomyPriority
+	}
+
+	return p.Priority
+}
+
+// Interval returns the interval with which balance of the hot wallet is
+// checked.
+func (p TreasuryPolicy) Interval() time.Duration {
+	if p.PollInterval == 0 {
+		return defaultSweepInterval
+	}
+
+	return p.PollInterval
+}
+
+// Validate checks that policy is consistent.
+func (p TreasuryPolicy) Validate() error {
+	if !p.Enabled() {
+		return nil
+	}
+
+	if p.TargetHotBalance.Sign() < 0 {
+		return errors.New("target hot balance shouldn't be negative")
+	}
+
+	if p.TargetHotBalance.GreaterThan(p.MaxHotBalance) {
+		return errors.New("target hot balance shouldn't exceed max hot " +
+			"balance")
+	}
+
+	if p.ColdAddress == "" && !p.ColdDerivation.Enabled() {
+		return errors.New("either cold address or cold extended public " +
+			"key should be specified")
+	}
+
+	if p.ColdAddress != "" && p.ColdDerivation.Enabled() {
+		return errors.New("only one of cold address and cold extended " +
+			"public key should be specified")
+	}
+
+	if p.MinSweepAmount.Sign() < 0 || p.MaxSweepFee.Sign() < 0 ||
+		p.MaxSweepFeeRate < 0 {
+		return errors.New("sweep limits shouldn't be negative")
+	}
+
+	return nil
+}
+
+// TreasurerConfig is a config of the treasurer.
+type TreasurerConfig struct {
+	// Asset is the asset of the connector.
+	Asset Asset
+
+	// Daemon is the name of the daemon, which is used as the metrics
+	// label.
+	Daemon string
+
+	// Policy determines when and how much funds are swept.
+	Policy TreasuryPolicy
+
+	// Balance returns the confirmed balance of the hot wallet.
+	Balance func() (decimal.Decimal, error)
+
+	// FeeRate returns current fee rate of the priority in the smallest
+	// units of the asset per byte.
+	FeeRate func(priority FeePriority) decimal.Decimal
+
+	// EstimateFee estimates the fee of the transaction which sends given
+	// amount to the address with the given priority.
+	EstimateFee func(address, amount string, priority FeePriority) (
+		*FeeEstimate, error)
+
+	// ColdAddress returns the address of the cold storage to which excess
+	// should be sent.
+	ColdAddress func() (string, error)
+
+	// Send sends given amount to the address with the given priority, and
+	// saves it as internal payment.
+	Send func(address, amount string, priority FeePriority) (*Payment,
+		error)
+
+	// Metrics is used to report the sweeps.
+	Metrics crypto.MetricsBackend
+
+	Logger btclog.Logger
+}
+
+func (c *TreasurerConfig) validate() error {
+	if c.Asset == "" {
+		return errors.New("asset should be specified")
+	}
+
+	if err := c.Policy.Validate(); err != nil {
+		return err
+	}
+
+	if c.Balance == nil {
+		return errors.New("balance function should be specified")
+	}
+
+	if c.FeeRate == nil {
+		return errors.New("fee rate function should be specified")
+	}
+
+	if c.EstimateFee == nil {
+		return errors.New("estimate fee function should be specified")
+	}
+
+	if c.ColdAddress == nil {
+		return errors.New("cold address function should be specified")
+	}
+
+	if c.Send == nil {
+		return errors.New("send function should be specified")
+	}
+
+	if c.Metrics == nil {
+		return errors.New("metrics backend should be specified")
+	}
+
+	if c.Logger == nil {
+		return errors.New("logger should be specified")
+	}
+
+	return nil
+}
+
+// Treasurer periodically checks the confirmed balance of the hot wallet,
+// and if it exceeds the maximum of the policy, sends the excess to the
+// cold storage as the internal payment, leaving the target balance in the
+// hot wallet. Fee of the sweep is taken from the swept amount.
+type Treasurer struct {
+	cfg *TreasurerConfig
+}
+
+// NewTreasurer creates new treasurer.
+func NewTreasurer(cfg *TreasurerConfig) (*Treasurer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Treasurer{cfg: cfg}, nil
+}
+
+// Run checks balance of the hot wallet with the interval of the policy,
+// until quit channel is closed.
+func (t *Treasurer) Run(quit <-chan struct{}) {
+	ticker := time.NewTicker(t.cfg.Policy.Interval())
+	defer ticker.Stop()
+
+	for {
+		if _, err := t.Sweep(); err != nil {
+			t.cfg.Logger.Errorf("unable to sweep %v hot wallet: %v",
+				t.cfg.Asset, err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+	}
+}
+
+// Sweep sends excess of the hot wallet to the cold storage, and returns the
+// sweep payment. Nil is returned if there is nothing to sweep, or if fee of
+// the sweep exceeds the caps of the policy.
+func (t *Treasurer) Sweep() (*Payment, error) {
+	m := crypto.NewMetric(t.cfg.Daemon, string(t.cfg.Asset),
+		common.GetFunctionName(), t.cfg.Metrics)
+	defer m.Finish()
+
+	policy := t.cfg.Policy
+
+	balance, err := t.cfg.Balance()
+	if err != nil {
+		m.AddError(metrics.MiddleSeverity)
+		return nil, errors.Errorf("unable to get balance: %v", err)
+	}
+
+	if balance.LessThanOrEqual(policy.MaxHotBalance) {
+		return nil, nil
+	}
+
+	excess := balance.Sub(policy.TargetHotBalance)
+	if excess.LessThan(policy.MinSweepAmount) {
+		return nil, nil
+	}
+
+	priority := policy.SweepPriority()
+	feeRate := t.cfg.FeeRate(priority)
+	if policy.MaxSweepFeeRate != 0 &&
+		feeRate.GreaterThan(decimal.NewFromFloat(policy.MaxSweepFeeRate)) {
+		t.skip("fee rate(%v) exceeds the max sweep fee rate(%v)", feeRate,
+			policy.MaxSweepFeeRate)
+		return nil, nil
+	}
+
+	estimate, err := t.cfg.EstimateFee(policy.ColdAddress, excess.String(),
+		priority)
+	if err != nil {
+		m.AddError(metrics.MiddleSeverity)
+		t.report(SweepFailed, decimal.Zero)
+		return nil, errors.Errorf("unable to estimate fee of %v sweep: %v",
+			excess, err)
+	}
+
+	if policy.MaxSweepFee.Sign() > 0 &&
+		estimate.Fee.GreaterThan(policy.MaxSweepFee) {
+		t.skip("fee(%v) exceeds the max sweep fee(%v)", estimate.Fee,
+			policy.MaxSweepFee)
+		return nil, nil
+	}
+
+	amount := excess.Sub(estimate.Fee)
+	if amount.Sign() <= 0 || amount.LessThan(policy.MinSweepAmount) {
+		return nil, nil
+	}
+
+	address, err := t.cfg.ColdAddress()
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		t.report(SweepFailed, decimal.Zero)
+		return nil, errors.Errorf("unable to get cold address: %v", err)
+	}
+
+	payment, err := t.cfg.Send(address, amount.String(), priority)
+	if err != nil {
+		m.AddError(metrics.HighSeverity)
+		t.report(SweepFailed, decimal.Zero)
+		return nil, errors.Errorf("unable to send %v to cold address(%v): "+
+			"%v", amount, address, err)
+	}
+
+	t.report(SweepSent, payment.Amount)
+	t.cfg.Logger.Infof("Sweep %v %v from hot wallet with balance %v to "+
+		"cold address(%v), payment(%v)", amount, t.cfg.Asset, balance,
+		address, payment.PaymentID)
+
+	return payment, nil
+}
+
+// skip reports the sweep which has been skipped because of the fee caps.
+func (t *Treasurer) skip(format string, params ...interface{}) {
+	t.report(SweepSkipped, decimal.Zero)
+	t.cfg.Logger.Warnf("Skip %v sweep, "+format,
+		append([]interface{}{t.cfg.Asset}, params...)...)
+}
+
+func (t *Treasurer) report(status string, amount decimal.Decimal) {
+	amountF, _ := amount.Float64()
+	t.cfg.Metrics.AddSweep(t.cfg.Daemon, string(t.cfg.Asset), status,
+		amountF)
+}
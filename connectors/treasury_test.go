@@ -0,0 +1,235 @@
+package connectors
+
+import (
+	"testing"
+
+	"github.com/bitlum/connector/metrics/crypto"
+	"github.com/btcsuite/btclog"
+	"github.com/shopspring/decimal"
+)
+
+// stubWallet is the hot wallet which balance is decreased by the sweeps.
+type stubWallet struct {
+	balance decimal.Decimal
+	fee     decimal.Decimal
+	feeRate decimal.Decimal
+
+	sent    []*Payment
+	derived int
+}
+
+func (w *stubWallet) estimateFee(address, amount string,
+	priority FeePriority) (*FeeEstimate, error) {
+	return &FeeEstimate{Fee: w.fee, Inputs: 1, VSize: 200}, nil
+}
+
+func (w *stubWallet) coldAddress() (string, error) {
+	w.derived++
+	return "cold", nil
+}
+
+func (w *stubWallet) send(address, amount string,
+	priority FeePriority) (*Payment, error) {
+
+	amt, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, err
+	}
+	w.balance = w.balance.Sub(amt).Sub(w.fee)
+
+	payment := &Payment{
+		PaymentID: address,
+		Status:    Pending,
+		Direction: Outgoing,
+		System:    Internal,
+		Receipt:   address,
+		Amount:    amt,
+		MediaFee:  w.fee,
+	}
+	w.sent = append(w.sent, payment)
+
+	return payment, nil
+}
+
+// sweepMetrics counts reported sweeps by their status.
+type sweepMetrics struct {
+	crypto.MockBackend
+	sweeps map[string]int
+}
+
+func (m *sweepMetrics) AddSweep(daemon, asset, status string,
+	amount float64) {
+	m.sweeps[status]++
+}
+
+func newTestTreasurer(t *testing.T, wallet *stubWallet,
+	policy TreasuryPolicy) (*Treasurer, *sweepMetrics) {
+
+	metrics := &sweepMetrics{sweeps: make(map[string]int)}
+	treasurer, err := NewTreasurer(&TreasurerConfig{
+		Asset:  BTC,
+		Policy: policy,
+		Balance: func() (decimal.Decimal, error) {
+			return wallet.balance, nil
+		},
+		FeeRate: func(priority FeePriority) decimal.Decimal {
+			return wallet.feeRate
+		},
+		EstimateFee: wallet.estimateFee,
+		ColdAddress: wallet.coldAddress,
+		Send:        wallet.send,
+		Metrics:     metrics,
+		Logger:      btclog.Disabled,
+	})
+	if err != nil {
+		t.Fatalf("unable to create treasurer: %v", err)
+	}
+
+	return treasurer, metrics
+}
+
+// TestTreasuryPolicyValidate checks that inconsistent policies are
+// rejected.
+func TestTreasuryPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy TreasuryPolicy
+		valid  bool
+	}{{
+		name:  "disabled",
+		valid: true,
+	}, {
+		name: "cold address",
+		policy: TreasuryPolicy{
+			MaxHotBalance:    decimal.New(10, 0),
+			TargetHotBalance: decimal.New(5, 0),
+			ColdAddress:      "cold",
+		},
+		valid: true,
+	}, {
+		name: "target exceeds max",
+		policy: TreasuryPolicy{
+			MaxHotBalance:    decimal.New(10, 0),
+			TargetHotBalance: decimal.New(11, 0),
+			ColdAddress:      "cold",
+		},
+	}, {
+		name: "no cold storage",
+		policy: TreasuryPolicy{
+			MaxHotBalance: decimal.New(10, 0),
+		},
+	}, {
+		name: "both cold address and xpub",
+		policy: TreasuryPolicy{
+			MaxHotBalance:  decimal.New(10, 0),
+			ColdAddress:    "cold",
+			ColdDerivation: DerivationPolicy{XPub: "xpub"},
+		},
+	}, {
+		name: "negative fee cap",
+		policy: TreasuryPolicy{
+			MaxHotBalance: decimal.New(10, 0),
+			ColdAddress:   "cold",
+			MaxSweepFee:   decimal.New(-1, 0),
+		},
+	}}
+
+	for _, test := range tests {
+		err := test.policy.Validate()
+		if test.valid && err != nil {
+			t.Fatalf("%v: policy should be valid: %v", test.name, err)
+		} else if !test.valid && err == nil {
+			t.Fatalf("%v: policy should be invalid", test.name)
+		}
+	}
+}
+
+// TestTreasurerSweep checks that only the excess over the target balance
+// is swept, fee of the sweep is taken from the swept amount, and that
+// balance below the maximum isn't swept.
+func TestTreasurerSweep(t *testing.T) {
+	wallet := &stubWallet{
+		balance: decimal.NewFromFloat(12),
+		fee:     decimal.NewFromFloat(0.001),
+		feeRate: decimal.New(10, 0),
+	}
+	treasurer, metrics := newTestTreasurer(t, wallet, TreasuryPolicy{
+		MaxHotBalance:    decimal.New(10, 0),
+		TargetHotBalance: decimal.New(5, 0),
+		ColdAddress:      "cold",
+	})
+
+	payment, err := treasurer.Sweep()
+	if err != nil {
+		t.Fatalf("unable to sweep: %v", err)
+	}
+
+	if payment == nil || payment.System != Internal ||
+		!payment.Amount.Equal(decimal.NewFromFloat(6.999)) {
+		t.Fatalf("excess minus fee should be swept, got %v", payment)
+	}
+
+	if !wallet.balance.Equal(decimal.New(5, 0)) {
+		t.Fatalf("target balance should be left, got %v", wallet.balance)
+	}
+
+	// Balance is below the max now, so nothing should be swept.
+	payment, err = treasurer.Sweep()
+	if err != nil || payment != nil {
+		t.Fatalf("balance below max shouldn't be swept: %v", err)
+	}
+
+	if len(wallet.sent) != 1 || metrics.sweeps[SweepSent] != 1 {
+		t.Fatalf("only one sweep should be sent")
+	}
+}
+
+// TestTreasurerLimits checks that sweep isn't sent if the excess is less
+// than the min sweep amount, or if its fee exceeds the caps of the policy.
+func TestTreasurerLimits(t *testing.T) {
+	wallet := &stubWallet{
+		balance: decimal.NewFromFloat(10.5),
+		fee:     decimal.NewFromFloat(0.01),
+		feeRate: decimal.New(50, 0),
+	}
+	policy := TreasuryPolicy{
+		MaxHotBalance:    decimal.New(10, 0),
+		TargetHotBalance: decimal.New(10, 0),
+		ColdAddress:      "cold",
+		MinSweepAmount:   decimal.New(1, 0),
+	}
+
+	treasurer, _ := newTestTreasurer(t, wallet, policy)
+	if payment, err := treasurer.Sweep(); err != nil || payment != nil {
+		t.Fatalf("excess below min sweep amount shouldn't be swept: %v",
+			err)
+	}
+
+	wallet.balance = decimal.New(12, 0)
+	policy.MaxSweepFeeRate = 20
+	treasurer, metrics := newTestTreasurer(t, wallet, policy)
+	if payment, err := treasurer.Sweep(); err != nil || payment != nil {
+		t.Fatalf("sweep exceeding max fee rate shouldn't be sent: %v", err)
+	}
+
+	policy.MaxSweepFeeRate = 0
+	policy.MaxSweepFee = decimal.NewFromFloat(0.005)
+	treasurer, metrics = newTestTreasurer(t, wallet, policy)
+	if payment, err := treasurer.Sweep(); err != nil || payment != nil {
+		t.Fatalf("sweep exceeding max fee shouldn't be sent: %v", err)
+	}
+
+	if metrics.sweeps[SweepSkipped] != 1 || len(wallet.sent) != 0 {
+		t.Fatalf("skipped sweep should be reported")
+	}
+
+	// Cold address shouldn't be derived for the sweeps which aren't sent.
+	if wallet.derived != 0 {
+		t.Fatalf("cold address shouldn't be derived")
+	}
+
+	wallet.fee = decimal.NewFromFloat(0.001)
+	if payment, err := treasurer.Sweep(); err != nil || payment == nil {
+		t.Fatalf("sweep within the limits should be sent: %v", err)
+	}
+}
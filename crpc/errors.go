@@ -2,6 +2,10 @@ package crpc
 
 import (
 	"fmt"
+
+	"github.com/bitlum/connector/connectors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -11,6 +15,38 @@ const (
 
 	// ErrInternal...
 	ErrInternal
+
+	// ErrIdempotencyKeyReused is returned when payment with the same
+	// idempotency key has been already sent with different parameters.
+	ErrIdempotencyKeyReused
+
+	// ErrPaymentNotWaiting is returned when payment which is requested to be
+	// confirmed or canceled isn't waiting for the approval.
+	ErrPaymentNotWaiting
+
+	// ErrInsufficientFunds is returned when there is not enough funds to
+	// send the payment and pay its fee.
+	ErrInsufficientFunds
+
+	// ErrUnavailable is returned when the daemon which is behind the
+	// connector is unreachable.
+	ErrUnavailable
+
+	// ErrMacaroonsDisabled is returned when macaroon is requested to be
+	// baked, but authorisation with macaroons is disabled.
+	ErrMacaroonsDisabled
+
+	// ErrNotificationsDisabled is returned when webhook notifications are
+	// requested, but no webhook urls are configured.
+	ErrNotificationsDisabled
+
+	// ErrPaymentNotPending is returned when fee of the payment is requested
+	// to be bumped, but payment isn't pending.
+	ErrPaymentNotPending
+
+	// ErrNotReplaceable is returned when transaction of the payment
+	// couldn't be replaced with the transaction paying bigger fee.
+	ErrNotReplaceable
 )
 
 type Error struct {
@@ -23,6 +59,66 @@ func (e Error) Error() string {
 	return e.errMsg
 }
 
+// GRPCStatus returns gRPC status which corresponds to the error. It is used
+// by gRPC to send the proper status code to the client, payserver error
+// code is attached as the error detail.
+func (e Error) GRPCStatus() *status.Status {
+	s := status.New(grpcCode(e.code), e.errMsg)
+
+	sd, err := s.WithDetails(&ErrorDetail{Code: uint32(e.code)})
+	if err != nil {
+		return s
+	}
+
+	return sd
+}
+
+// grpcCode converts payserver error code to the gRPC status code.
+func grpcCode(code int) codes.Code {
+	switch code {
+	case ErrAssetNotSupported, ErrNetworkNotSupported:
+		return codes.Unimplemented
+	case ErrInvalidArgument:
+		return codes.InvalidArgument
+	case ErrIdempotencyKeyReused:
+		return codes.AlreadyExists
+	case ErrPaymentNotWaiting, ErrInsufficientFunds, ErrMacaroonsDisabled,
+		ErrNotificationsDisabled, ErrPaymentNotPending, ErrNotReplaceable:
+		return codes.FailedPrecondition
+	case ErrUnavailable:
+		return codes.Unavailable
+	case ErrInternal:
+		return codes.Internal
+	default:
+		return codes.Unknown
+	}
+}
+
+// newErrFromConnector converts error returned by connector to the
+// payserver error, errors which are not known are treated as internal.
+func newErrFromConnector(err error) Error {
+	switch e := err.(type) {
+	case *connectors.ErrInvalidReceipt:
+		return newErrInvalidArgumentDesc("receipt", e.Error())
+	case *connectors.ErrInvalidAmount:
+		return newErrInvalidArgumentDesc("amount", e.Error())
+	case *connectors.ErrAmountMismatch:
+		return newErrInvalidArgumentDesc("amount", e.Error())
+	case *connectors.ErrInsufficientFunds:
+		return newErrInsufficientFunds(e.Error())
+	case *connectors.ErrInvalidFeeRate:
+		return newErrInvalidArgumentDesc("fee_rate", e.Error())
+	case *connectors.ErrNotReplaceable:
+		return newErrNotReplaceable(e.Error())
+	case *connectors.ErrInvalidPSBT:
+		return newErrInvalidArgumentDesc("psbt", e.Error())
+	case *connectors.ErrDaemonUnavailable:
+		return newErrUnavailable(e.Error())
+	default:
+		return newErrInternal(err.Error())
+	}
+}
+
 func newErrNetworkNotSupported(network, operation string) Error {
 	return Error{
 		code: ErrNetworkNotSupported,
@@ -53,3 +149,73 @@ func newErrInvalidArgument(argName string) Error {
 			argName),
 	}
 }
+
+func newErrIdempotencyKeyReused(key string) Error {
+	return Error{
+		code: ErrIdempotencyKeyReused,
+		errMsg: fmt.Sprintf("%v: idempotency key '%v' has been already used "+
+			"for the payment with different parameters",
+			ErrIdempotencyKeyReused, key),
+	}
+}
+
+func newErrPaymentNotWaiting(paymentID string) Error {
+	return Error{
+		code: ErrPaymentNotWaiting,
+		errMsg: fmt.Sprintf("%v: payment(%v) is not waiting for approval",
+			ErrPaymentNotWaiting, paymentID),
+	}
+}
+
+func newErrInvalidArgumentDesc(argName, desc string) Error {
+	return Error{
+		code: ErrInvalidArgument,
+		errMsg: fmt.Sprintf("%v: invalid argument '%v': %v",
+			ErrInvalidArgument, argName, desc),
+	}
+}
+
+func newErrInsufficientFunds(desc string) Error {
+	return Error{
+		code:   ErrInsufficientFunds,
+		errMsg: fmt.Sprintf("%v: %v", ErrInsufficientFunds, desc),
+	}
+}
+
+func newErrUnavailable(desc string) Error {
+	return Error{
+		code:   ErrUnavailable,
+		errMsg: fmt.Sprintf("%v: %v", ErrUnavailable, desc),
+	}
+}
+
+func newErrMacaroonsDisabled() Error {
+	return Error{
+		code: ErrMacaroonsDisabled,
+		errMsg: fmt.Sprintf("%v: macaroons are disabled",
+			ErrMacaroonsDisabled),
+	}
+}
+
+func newErrNotificationsDisabled() Error {
+	return Error{
+		code: ErrNotificationsDisabled,
+		errMsg: fmt.Sprintf("%v: webhook notifications are disabled",
+			ErrNotificationsDisabled),
+	}
+}
+
+func newErrPaymentNotPending(paymentID string) Error {
+	return Error{
+		code: ErrPaymentNotPending,
+		errMsg: fmt.Sprintf("%v: payment(%v) is not pending",
+			ErrPaymentNotPending, paymentID),
+	}
+}
+
+func newErrNotReplaceable(desc string) Error {
+	return Error{
+		code:   ErrNotReplaceable,
+		errMsg: fmt.Sprintf("%v: %v", ErrNotReplaceable, desc),
+	}
+}
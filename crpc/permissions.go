@@ -0,0 +1,36 @@
+package crpc
+
+import (
+	"github.com/bitlum/connector/macaroons"
+)
+
+// Permissions maps every rpc method to the permission which macaroon should
+// grant in order to call it. Methods which are not listed here are
+// forbidden for everyone.
+var Permissions = map[string]macaroons.Permission{
+	"/crpc.PayServer/CreateReceipt":     macaroons.PermissionInvoice,
+	"/crpc.PayServer/ValidateReceipt":   macaroons.PermissionRead,
+	"/crpc.PayServer/Balance":           macaroons.PermissionRead,
+	"/crpc.PayServer/EstimateFee":       macaroons.PermissionRead,
+	"/crpc.PayServer/SendPayment":       macaroons.PermissionSend,
+	"/crpc.PayServer/SendPayments":      macaroons.PermissionSend,
+	"/crpc.PayServer/CreatePayment":     macaroons.PermissionSend,
+	"/crpc.PayServer/ConfirmPayment":    macaroons.PermissionSend,
+	"/crpc.PayServer/CancelPayment":     macaroons.PermissionSend,
+	"/crpc.PayServer/BumpFee":           macaroons.PermissionSend,
+	"/crpc.PayServer/CreatePSBTPayment": macaroons.PermissionSend,
+	"/crpc.PayServer/ExportPSBT":        macaroons.PermissionSend,
+	"/crpc.PayServer/ImportPSBT":        macaroons.PermissionSend,
+	"/crpc.PayServer/FinalizePSBT":      macaroons.PermissionSend,
+	"/crpc.PayServer/ReorganiseInputs":  macaroons.PermissionSend,
+	"/crpc.PayServer/PaymentByID":       macaroons.PermissionRead,
+	"/crpc.PayServer/PaymentsByReceipt": macaroons.PermissionRead,
+	"/crpc.PayServer/ListPayments":      macaroons.PermissionRead,
+	"/crpc.PayServer/SubscribePayments": macaroons.PermissionRead,
+	"/crpc.PayServer/BakeMacaroon":      macaroons.PermissionBake,
+	"/crpc.PayServer/GetInfo":           macaroons.PermissionRead,
+	"/crpc.PayServer/GetFeeRates":       macaroons.PermissionRead,
+
+	"/crpc.PayServer/ListFailedNotifications": macaroons.PermissionRead,
+	"/crpc.PayServer/ReplayNotifications":     macaroons.PermissionSend,
+}
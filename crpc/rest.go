@@ -0,0 +1,330 @@
+package crpc
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// restPathPrefix is the prefix of the url path, after which name of the
+	// rpc method goes, e.g. "/v1/Balance".
+	restPathPrefix = "/v1/"
+
+	// metadataHeaderPrefix is the prefix of the http headers which are
+	// passed as gRPC metadata, e.g. macaroon should be passed in the
+	// "Grpc-Metadata-Macaroon" header.
+	metadataHeaderPrefix = "Grpc-Metadata-"
+)
+
+// RESTGateway exposes every PayServer rpc method over HTTP with JSON
+// encoding. Request message is read from the body of the POST request on
+// the "/v1/<MethodName>" path, and response is written in the body. Stream
+// methods write every message on separate line.
+//
+// Requests are passed through the same interceptors as the gRPC requests,
+// so that authorisation works the same way.
+type RESTGateway struct {
+	server            PayServerServer
+	unaryInterceptor  grpc.UnaryServerInterceptor
+	streamInterceptor grpc.StreamServerInterceptor
+}
+
+// A compile time check to ensure that RESTGateway implements http.Handler.
+var _ http.Handler = (*RESTGateway)(nil)
+
+// NewRESTGateway creates new HTTP handler which proxies requests to the
+// given rpc server. Interceptors might be nil.
+func NewRESTGateway(server PayServerServer,
+	unaryInterceptor grpc.UnaryServerInterceptor,
+	streamInterceptor grpc.StreamServerInterceptor) *RESTGateway {
+	return &RESTGateway{
+		server:            server,
+		unaryInterceptor:  unaryInterceptor,
+		streamInterceptor: streamInterceptor,
+	}
+}
+
+// ServeHTTP finds rpc method by the url path and calls it.
+//
+// NOTE: Part of the http.Handler interface.
+func (g *RESTGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST method is supported",
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, restPathPrefix) {
+		writeRESTError(w, status.Errorf(codes.NotFound,
+			"path(%v) is not found", r.URL.Path))
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, restPathPrefix)
+	ctx := metadata.NewIncomingContext(r.Context(), headersToMetadata(r.Header))
+
+	for _, desc := range _PayServer_serviceDesc.Methods {
+		if desc.MethodName == name {
+			g.serveUnary(ctx, w, r.Body, desc)
+			return
+		}
+	}
+
+	for _, desc := range _PayServer_serviceDesc.Streams {
+		if desc.StreamName == name {
+			fullMethod := "/" + _PayServer_serviceDesc.ServiceName + "/" + name
+			g.serveStream(ctx, w, r.Body, fullMethod, desc)
+			return
+		}
+	}
+
+	writeRESTError(w, status.Errorf(codes.NotFound, "method(%v) is not "+
+		"found", name))
+}
+
+// serveUnary decodes request from the body, calls the unary rpc method and
+// writes its response.
+func (g *RESTGateway) serveUnary(ctx context.Context, w http.ResponseWriter,
+	body io.Reader, desc grpc.MethodDesc) {
+
+	dec := func(req interface{}) error {
+		return decodeRESTRequest(body, req)
+	}
+
+	resp, err := desc.Handler(g.server, ctx, dec, g.unaryInterceptor)
+	if err != nil {
+		writeRESTError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := jsonMarshaler.Marshal(w, resp.(proto.Message)); err != nil {
+		log.Errorf("unable to write rest response: %v", err)
+	}
+}
+
+// serveStream calls the server stream rpc method, and writes every sent
+// message on the separate line.
+func (g *RESTGateway) serveStream(ctx context.Context, w http.ResponseWriter,
+	body io.Reader, fullMethod string, desc grpc.StreamDesc) {
+
+	stream := &restServerStream{
+		ctx:  ctx,
+		w:    w,
+		body: body,
+	}
+
+	var err error
+	if g.streamInterceptor != nil {
+		info := &grpc.StreamServerInfo{
+			FullMethod:     fullMethod,
+			IsClientStream: desc.ClientStreams,
+			IsServerStream: desc.ServerStreams,
+		}
+		err = g.streamInterceptor(g.server, stream, info, desc.Handler)
+	} else {
+		err = desc.Handler(g.server, stream)
+	}
+
+	if err == nil {
+		return
+	}
+
+	// If stream has already been started, status code couldn't be changed,
+	// that is why error is written as the last line of the stream.
+	if stream.started() {
+		stream.writeError(err)
+		return
+	}
+
+	writeRESTError(w, err)
+}
+
+// restServerStream implements grpc.ServerStream over the http connection.
+type restServerStream struct {
+	ctx  context.Context
+	w    http.ResponseWriter
+	body io.Reader
+
+	mtx     sync.Mutex
+	written bool
+}
+
+// A compile time check to ensure that restServerStream implements
+// grpc.ServerStream interface.
+var _ grpc.ServerStream = (*restServerStream)(nil)
+
+func (s *restServerStream) SetHeader(metadata.MD) error  { return nil }
+func (s *restServerStream) SendHeader(metadata.MD) error { return nil }
+func (s *restServerStream) SetTrailer(metadata.MD)       {}
+
+func (s *restServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg writes message on the separate line, and flushes it to the
+// client.
+func (s *restServerStream) SendMsg(m interface{}) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.written {
+		s.w.Header().Set("Content-Type", "application/json")
+		s.written = true
+	}
+
+	if err := jsonMarshaler.Marshal(s.w, m.(proto.Message)); err != nil {
+		return err
+	}
+
+	if _, err := s.w.Write([]byte("\n")); err != nil {
+		return err
+	}
+
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	return nil
+}
+
+// RecvMsg decodes request from the body of http request.
+func (s *restServerStream) RecvMsg(m interface{}) error {
+	return decodeRESTRequest(s.body, m)
+}
+
+func (s *restServerStream) started() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.written
+}
+
+func (s *restServerStream) writeError(err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	json.NewEncoder(s.w).Encode(newRESTError(err))
+}
+
+// restError is the body of the response of the failed request.
+type restError struct {
+	Error string `json:"error"`
+	Code  int32  `json:"code"`
+
+	// PayServerCode is the payserver specific code of the error, it is
+	// present only if error has been returned by the rpc server.
+	PayServerCode uint32 `json:"payserver_code,omitempty"`
+}
+
+func newRESTError(err error) *restError {
+	s := status.Convert(err)
+
+	e := &restError{
+		Error: s.Message(),
+		Code:  int32(s.Code()),
+	}
+
+	for _, detail := range s.Details() {
+		if d, ok := detail.(*ErrorDetail); ok {
+			e.PayServerCode = d.Code
+		}
+	}
+
+	return e
+}
+
+// writeRESTError writes error with the http status code, which corresponds
+// to the gRPC status code of the error.
+func writeRESTError(w http.ResponseWriter, err error) {
+	e := newRESTError(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatusFromCode(codes.Code(e.Code)))
+	if err := json.NewEncoder(w).Encode(e); err != nil {
+		log.Errorf("unable to write rest error: %v", err)
+	}
+}
+
+// decodeRESTRequest decodes JSON request, empty body is treated as the
+// request with default values.
+func decodeRESTRequest(body io.Reader, req interface{}) error {
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "unable to read "+
+			"request: %v", err)
+	}
+
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+
+	err = jsonpb.UnmarshalString(string(data), req.(proto.Message))
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "unable to decode "+
+			"request: %v", err)
+	}
+
+	return nil
+}
+
+// headersToMetadata converts http headers with metadata prefix to the gRPC
+// metadata.
+func headersToMetadata(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range header {
+		if !strings.HasPrefix(key, metadataHeaderPrefix) {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimPrefix(key, metadataHeaderPrefix))
+		md[key] = append(md[key], values...)
+	}
+
+	return md
+}
+
+// httpStatusFromCode converts gRPC status code to the http status code.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return http.StatusRequestTimeout
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
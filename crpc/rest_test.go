@@ -0,0 +1,133 @@
+package crpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// stubPayServer implements only methods which are used in tests.
+type stubPayServer struct {
+	PayServerServer
+}
+
+func (s *stubPayServer) PaymentByID(ctx context.Context,
+	req *PaymentByIDRequest) (*Payment, error) {
+
+	if req.PaymentId != "1" {
+		return nil, newErrInvalidArgument("payment_id")
+	}
+
+	return &Payment{PaymentId: req.PaymentId, Amount: "1"}, nil
+}
+
+func (s *stubPayServer) SubscribePayments(req *SubscribePaymentsRequest,
+	stream PayServer_SubscribePaymentsServer) error {
+
+	for _, id := range []string{"1", "2"} {
+		if err := stream.Send(&Payment{PaymentId: id}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestRESTGateway(t *testing.T) {
+	gateway := NewRESTGateway(&stubPayServer{}, nil, nil)
+
+	do := func(path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, r)
+		return w
+	}
+
+	w := do("/v1/PaymentByID", `{"payment_id": "1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong status code: %v", w.Code)
+	}
+
+	var payment map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &payment); err != nil {
+		t.Fatalf("unable to decode response: %v", err)
+	}
+
+	if payment["payment_id"] != "1" || payment["amount"] != "1" {
+		t.Fatalf("wrong response: %v", w.Body.String())
+	}
+
+	w = do("/v1/PaymentByID", `{"payment_id": "2"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("wrong status code: %v", w.Code)
+	}
+
+	var restErr restError
+	if err := json.Unmarshal(w.Body.Bytes(), &restErr); err != nil {
+		t.Fatalf("unable to decode error: %v", err)
+	}
+
+	if restErr.PayServerCode != ErrInvalidArgument {
+		t.Fatalf("wrong payserver error code: %v", restErr.PayServerCode)
+	}
+
+	if w := do("/v1/PaymentByID", `{"unknown": 1}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("wrong status code: %v", w.Code)
+	}
+
+	if w := do("/v1/Unknown", ``); w.Code != http.StatusNotFound {
+		t.Fatalf("wrong status code: %v", w.Code)
+	}
+
+	w = do("/v1/SubscribePayments", ``)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong status code: %v", w.Code)
+	}
+
+	if n := strings.Count(w.Body.String(), "payment_id"); n != 2 {
+		t.Fatalf("wrong number of streamed payments: %v", n)
+	}
+}
+
+func TestRESTGatewayInterceptor(t *testing.T) {
+	interceptor := func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{},
+		error) {
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		if len(md["macaroon"]) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "no macaroon")
+		}
+
+		return handler(ctx, req)
+	}
+
+	gateway := NewRESTGateway(&stubPayServer{}, interceptor, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/PaymentByID",
+		strings.NewReader(`{"payment_id": "1"}`))
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong status code: %v", w.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/v1/PaymentByID",
+		strings.NewReader(`{"payment_id": "1"}`))
+	r.Header.Set("Grpc-Metadata-Macaroon", "0201")
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong status code: %v", w.Code)
+	}
+}
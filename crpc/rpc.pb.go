@@ -21,12 +21,42 @@ It has these top-level messages:
 	EstimateFeeRequest
 	EstimateFeeResponse
 	SendPaymentRequest
+	Recipient
+	SendPaymentsRequest
+	SendPaymentsResponse
+	CreatePaymentRequest
+	ConfirmPaymentRequest
+	CancelPaymentRequest
+	BumpFeeRequest
+	CreatePSBTPaymentRequest
+	ExportPSBTRequest
+	ExportPSBTResponse
+	ImportPSBTRequest
+	FinalizePSBTRequest
+	ReorganiseInputsRequest
+	ReorganiseInputsResponse
 	PaymentByIDRequest
 	PaymentsByReceiptRequest
 	PaymentsByReceiptResponse
+	GetInfoRequest
+	GetInfoResponse
+	ConnectorInfo
+	LightningInfo
+	BakeMacaroonRequest
+	BakeMacaroonResponse
+	ListFailedNotificationsRequest
+	ListFailedNotificationsResponse
+	ReplayNotificationsRequest
+	ReplayNotificationsResponse
+	GetFeeRatesRequest
+	GetFeeRatesResponse
+	FeeRate
+	Notification
 	ListPaymentsRequest
 	ListPaymentsResponse
+	SubscribePaymentsRequest
 	Payment
+	ErrorDetail
 */
 package crpc
 
@@ -149,6 +179,11 @@ const (
 	// FAILED means that services has tryied to send payment for couple of
 	// times, but without success, and now service gave up.
 	PaymentStatus_FAILED PaymentStatus = 4
+	//
+	// REVERTED means that transaction of the payment has been removed from
+	// the main chain by the reorganisation, or has been double spent. If
+	// payment had been completed before, its funds should be clawed back.
+	PaymentStatus_REVERTED PaymentStatus = 5
 )
 
 var PaymentStatus_name = map[int32]string{
@@ -157,6 +192,7 @@ var PaymentStatus_name = map[int32]string{
 	2: "PENDING",
 	3: "COMPLETED",
 	4: "FAILED",
+	5: "REVERTED",
 }
 var PaymentStatus_value = map[string]int32{
 	"STATUS_NONE": 0,
@@ -164,6 +200,7 @@ var PaymentStatus_value = map[string]int32{
 	"PENDING":     2,
 	"COMPLETED":   3,
 	"FAILED":      4,
+	"REVERTED":    5,
 }
 
 func (x PaymentStatus) String() string {
@@ -203,6 +240,82 @@ func (x PaymentDirection) String() string {
 }
 func (PaymentDirection) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
+// ConnectorState is the lifecycle state of the connector.
+type ConnectorState int32
+
+const (
+	ConnectorState_CONNECTOR_STATE_NONE ConnectorState = 0
+	//
+	// STARTING connector hasn't been started yet.
+	ConnectorState_STARTING ConnectorState = 1
+	//
+	// RUNNING connector has been started and it is syncing with its daemon.
+	ConnectorState_RUNNING ConnectorState = 2
+	//
+	// START_FAILED last attempt to start connector has failed, start is
+	// retried.
+	ConnectorState_START_FAILED ConnectorState = 3
+	//
+	// STOPPED connector has been stopped.
+	ConnectorState_STOPPED ConnectorState = 4
+)
+
+var ConnectorState_name = map[int32]string{
+	0: "CONNECTOR_STATE_NONE",
+	1: "STARTING",
+	2: "RUNNING",
+	3: "START_FAILED",
+	4: "STOPPED",
+}
+var ConnectorState_value = map[string]int32{
+	"CONNECTOR_STATE_NONE": 0,
+	"STARTING":             1,
+	"RUNNING":              2,
+	"START_FAILED":         3,
+	"STOPPED":              4,
+}
+
+func (x ConnectorState) String() string {
+	return proto.EnumName(ConnectorState_name, int32(x))
+}
+func (ConnectorState) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+// FeePriority determines how fast transaction should be confirmed, the
+// faster the bigger fee is paid.
+type FeePriority int32
+
+const (
+	FeePriority_PRIORITY_NONE FeePriority = 0
+	//
+	// FAST transaction should be confirmed in the next couple of blocks.
+	FeePriority_FAST FeePriority = 1
+	//
+	// NORMAL transaction should be confirmed within an hour.
+	FeePriority_NORMAL FeePriority = 2
+	//
+	// ECONOMY transaction might wait for confirmation for hours, in order
+	// to pay the lowest fee.
+	FeePriority_ECONOMY FeePriority = 3
+)
+
+var FeePriority_name = map[int32]string{
+	0: "PRIORITY_NONE",
+	1: "FAST",
+	2: "NORMAL",
+	3: "ECONOMY",
+}
+var FeePriority_value = map[string]int32{
+	"PRIORITY_NONE": 0,
+	"FAST":          1,
+	"NORMAL":        2,
+	"ECONOMY":       3,
+}
+
+func (x FeePriority) String() string {
+	return proto.EnumName(FeePriority_name, int32(x))
+}
+func (FeePriority) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
 // PaymentSystemSystem denotes is that payment belongs to business logic of
 // payment server or it was originated by user / third-party service.
 type PaymentSystem int32
@@ -237,7 +350,7 @@ var PaymentSystem_value = map[string]int32{
 func (x PaymentSystem) String() string {
 	return proto.EnumName(PaymentSystem_name, int32(x))
 }
-func (PaymentSystem) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (PaymentSystem) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 type EmptyRequest struct {
 }
@@ -673,6 +786,10 @@ type EstimateFeeRequest struct {
 	// network invoice. If receipt is specified the number are more accurate
 	// for lightning network payment.
 	Receipt string `protobuf:"bytes,4,opt,name=receipt" json:"receipt,omitempty"`
+	//
+	// (optional) Priority determines how fast the transaction should be
+	// confirmed, normal priority is used if not specified.
+	Priority FeePriority `protobuf:"varint,5,opt,name=priority,enum=crpc.FeePriority" json:"priority,omitempty"`
 }
 
 func (m *EstimateFeeRequest) Reset()                    { *m = EstimateFeeRequest{} }
@@ -708,11 +825,26 @@ func (m *EstimateFeeRequest) GetReceipt() string {
 	return ""
 }
 
+func (m *EstimateFeeRequest) GetPriority() FeePriority {
+	if m != nil {
+		return m.Priority
+	}
+	return FeePriority_PRIORITY_NONE
+}
+
 type EstimateFeeResponse struct {
 	//
 	// MediaFee is the fee which is taken by the blockchain or lightning
 	// network in order to propagate the payment.
 	MediaFee string `protobuf:"bytes,1,opt,name=media_fee,json=mediaFee" json:"media_fee,omitempty"`
+	//
+	// (optional) Inputs is the number of inputs of the transaction, it is
+	// returned only for UTXO based blockchains.
+	Inputs int32 `protobuf:"varint,2,opt,name=inputs" json:"inputs,omitempty"`
+	//
+	// (optional) VSize is the virtual size of the transaction in bytes, it
+	// is returned only for UTXO based blockchains.
+	Vsize int32 `protobuf:"varint,3,opt,name=vsize" json:"vsize,omitempty"`
 }
 
 func (m *EstimateFeeResponse) Reset()                    { *m = EstimateFeeResponse{} }
@@ -727,6 +859,20 @@ func (m *EstimateFeeResponse) GetMediaFee() string {
 	return ""
 }
 
+func (m *EstimateFeeResponse) GetInputs() int32 {
+	if m != nil {
+		return m.Inputs
+	}
+	return 0
+}
+
+func (m *EstimateFeeResponse) GetVsize() int32 {
+	if m != nil {
+		return m.Vsize
+	}
+	return 0
+}
+
 type SendPaymentRequest struct {
 	//
 	// Asset is an acronim of the crypto currency.
@@ -742,6 +888,17 @@ type SendPaymentRequest struct {
 	// Receipt represent either blockchains address or lightning
 	// network invoice, which we should use determine payment receiver.
 	Receipt string `protobuf:"bytes,4,opt,name=receipt" json:"receipt,omitempty"`
+	//
+	// (optional) IdempotencyKey is the unique key generated by the client,
+	// which is used to safely retry the request. If payment with the same
+	// key has been already sent, than this payment is returned instead of
+	// sending the new one.
+	IdempotencyKey string `protobuf:"bytes,5,opt,name=idempotency_key,json=idempotencyKey" json:"idempotency_key,omitempty"`
+	//
+	// (optional) Priority determines how fast the transaction should be
+	// confirmed. It is used only by blockchain connectors with the fee
+	// oracle, others ignore it.
+	Priority FeePriority `protobuf:"varint,6,opt,name=priority,enum=crpc.FeePriority" json:"priority,omitempty"`
 }
 
 func (m *SendPaymentRequest) Reset()                    { *m = SendPaymentRequest{} }
@@ -777,267 +934,1408 @@ func (m *SendPaymentRequest) GetReceipt() string {
 	return ""
 }
 
-type PaymentByIDRequest struct {
-	//
-	// PaymentID is the payment id which was created by service itself,
-	// for unified identification of the payment.
-	PaymentId string `protobuf:"bytes,1,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
+func (m *SendPaymentRequest) GetIdempotencyKey() string {
+	if m != nil {
+		return m.IdempotencyKey
+	}
+	return ""
 }
 
-func (m *PaymentByIDRequest) Reset()                    { *m = PaymentByIDRequest{} }
-func (m *PaymentByIDRequest) String() string            { return proto.CompactTextString(m) }
-func (*PaymentByIDRequest) ProtoMessage()               {}
-func (*PaymentByIDRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
-
-func (m *PaymentByIDRequest) GetPaymentId() string {
+func (m *SendPaymentRequest) GetPriority() FeePriority {
 	if m != nil {
-		return m.PaymentId
+		return m.Priority
 	}
-	return ""
+	return FeePriority_PRIORITY_NONE
 }
 
-type PaymentsByReceiptRequest struct {
+type Recipient struct {
 	//
-	// Receipt represent either blockchains address or lightning
-	// network invoice, depending on the type of the request.
+	// Receipt is the blockchain address of the receiver.
 	Receipt string `protobuf:"bytes,1,opt,name=receipt" json:"receipt,omitempty"`
+	//
+	// Amount is number of money which should be given to the receiver.
+	Amount string `protobuf:"bytes,2,opt,name=amount" json:"amount,omitempty"`
 }
 
-func (m *PaymentsByReceiptRequest) Reset()                    { *m = PaymentsByReceiptRequest{} }
-func (m *PaymentsByReceiptRequest) String() string            { return proto.CompactTextString(m) }
-func (*PaymentsByReceiptRequest) ProtoMessage()               {}
-func (*PaymentsByReceiptRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (m *Recipient) Reset()                    { *m = Recipient{} }
+func (m *Recipient) String() string            { return proto.CompactTextString(m) }
+func (*Recipient) ProtoMessage()               {}
+func (*Recipient) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
-func (m *PaymentsByReceiptRequest) GetReceipt() string {
+func (m *Recipient) GetReceipt() string {
 	if m != nil {
 		return m.Receipt
 	}
 	return ""
 }
 
-type PaymentsByReceiptResponse struct {
-	Payments []*Payment `protobuf:"bytes,1,rep,name=payments" json:"payments,omitempty"`
-}
-
-func (m *PaymentsByReceiptResponse) Reset()                    { *m = PaymentsByReceiptResponse{} }
-func (m *PaymentsByReceiptResponse) String() string            { return proto.CompactTextString(m) }
-func (*PaymentsByReceiptResponse) ProtoMessage()               {}
-func (*PaymentsByReceiptResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
-
-func (m *PaymentsByReceiptResponse) GetPayments() []*Payment {
+func (m *Recipient) GetAmount() string {
 	if m != nil {
-		return m.Payments
+		return m.Amount
 	}
-	return nil
+	return ""
 }
 
-type ListPaymentsRequest struct {
-	//
-	// (optional) Status denotes the stage of the processing the payment.
-	Status PaymentStatus `protobuf:"varint,1,opt,name=status,enum=crpc.PaymentStatus" json:"status,omitempty"`
-	//
-	// (optional) Direction denotes the direction of the payment.
-	Direction PaymentDirection `protobuf:"varint,2,opt,name=direction,enum=crpc.PaymentDirection" json:"direction,omitempty"`
+type SendPaymentsRequest struct {
 	//
-	// (optional) Asset is an acronim of the crypto currency.
-	Asset Asset `protobuf:"varint,3,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
+	// Asset is an acronim of the crypto currency.
+	Asset Asset `protobuf:"varint,1,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
 	//
-	// (optional) Media is a type of technology which is used to transport
-	// value of underlying asset.
-	Media Media `protobuf:"varint,4,opt,name=media,enum=crpc.Media" json:"media,omitempty"`
+	// Recipients are the receivers of the payments.
+	Recipients []*Recipient `protobuf:"bytes,2,rep,name=recipients" json:"recipients,omitempty"`
 	//
-	// (optional) PaymentSystem denotes is that payment belongs to business
-	// logic of payment server or it was originated by user / third-party
-	// service.
-	System PaymentSystem `protobuf:"varint,5,opt,name=system,enum=crpc.PaymentSystem" json:"system,omitempty"`
-}
-
-func (m *ListPaymentsRequest) Reset()                    { *m = ListPaymentsRequest{} }
-func (m *ListPaymentsRequest) String() string            { return proto.CompactTextString(m) }
-func (*ListPaymentsRequest) ProtoMessage()               {}
-func (*ListPaymentsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
-
-func (m *ListPaymentsRequest) GetStatus() PaymentStatus {
-	if m != nil {
-		return m.Status
-	}
-	return PaymentStatus_STATUS_NONE
+	// (optional) IdempotencyKey is the unique key generated by the client,
+	// which is used to safely retry the request. If payments with the same
+	// key have been already sent, than these payments are returned instead
+	// of sending the new ones.
+	IdempotencyKey string `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey" json:"idempotency_key,omitempty"`
 }
 
-func (m *ListPaymentsRequest) GetDirection() PaymentDirection {
-	if m != nil {
-		return m.Direction
-	}
-	return PaymentDirection_DIRECTION_NONE
-}
+func (m *SendPaymentsRequest) Reset()                    { *m = SendPaymentsRequest{} }
+func (m *SendPaymentsRequest) String() string            { return proto.CompactTextString(m) }
+func (*SendPaymentsRequest) ProtoMessage()               {}
+func (*SendPaymentsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
-func (m *ListPaymentsRequest) GetAsset() Asset {
+func (m *SendPaymentsRequest) GetAsset() Asset {
 	if m != nil {
 		return m.Asset
 	}
 	return Asset_ASSET_NONE
 }
 
-func (m *ListPaymentsRequest) GetMedia() Media {
+func (m *SendPaymentsRequest) GetRecipients() []*Recipient {
 	if m != nil {
-		return m.Media
+		return m.Recipients
 	}
-	return Media_MEDIA_NONE
+	return nil
 }
 
-func (m *ListPaymentsRequest) GetSystem() PaymentSystem {
+func (m *SendPaymentsRequest) GetIdempotencyKey() string {
 	if m != nil {
-		return m.System
+		return m.IdempotencyKey
 	}
-	return PaymentSystem_SYSTEM_NONE
+	return ""
 }
 
-type ListPaymentsResponse struct {
+type SendPaymentsResponse struct {
+	//
+	// Payments are the sent payments, in the order of the recipients.
 	Payments []*Payment `protobuf:"bytes,1,rep,name=payments" json:"payments,omitempty"`
 }
 
-func (m *ListPaymentsResponse) Reset()                    { *m = ListPaymentsResponse{} }
-func (m *ListPaymentsResponse) String() string            { return proto.CompactTextString(m) }
-func (*ListPaymentsResponse) ProtoMessage()               {}
-func (*ListPaymentsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+func (m *SendPaymentsResponse) Reset()                    { *m = SendPaymentsResponse{} }
+func (m *SendPaymentsResponse) String() string            { return proto.CompactTextString(m) }
+func (*SendPaymentsResponse) ProtoMessage()               {}
+func (*SendPaymentsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
 
-func (m *ListPaymentsResponse) GetPayments() []*Payment {
+func (m *SendPaymentsResponse) GetPayments() []*Payment {
 	if m != nil {
 		return m.Payments
 	}
 	return nil
 }
 
-type Payment struct {
-	//
-	// PaymentID it is unique identificator of the payment generated inside
-	// the system.
-	PaymentId string `protobuf:"bytes,1,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
-	//
-	// UpdatedAt denotes the time when payment object has been last updated.
-	UpdatedAt int64 `protobuf:"varint,2,opt,name=updated_at,json=updatedAt" json:"updated_at,omitempty"`
-	//
-	// Status denotes the stage of the processing the payment.
-	Status PaymentStatus `protobuf:"varint,3,opt,name=status,enum=crpc.PaymentStatus" json:"status,omitempty"`
-	//
-	// Direction denotes the direction of the payment, whether
-	// payment is going form us to someone else, or form someone else to us.
-	Direction PaymentDirection `protobuf:"varint,4,opt,name=direction,enum=crpc.PaymentDirection" json:"direction,omitempty"`
-	//
-	// System denotes is that payment belongs to business logic of
-	// payment server or it was originated by user / third-party service.
-	System PaymentSystem `protobuf:"varint,11,opt,name=system,enum=crpc.PaymentSystem" json:"system,omitempty"`
+type CreatePaymentRequest struct {
 	//
 	// Asset is an acronim of the crypto currency.
-	Asset Asset `protobuf:"varint,5,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
-	//
-	// Receipt is a string which identifies the receiver of the
-	// payment. It is address in case of the blockchain media,
-	// and lightning network invoice in case lightning media.
-	Receipt string `protobuf:"bytes,6,opt,name=receipt" json:"receipt,omitempty"`
-	// MediaID is identificator of the payment inside the media.
-	// In case of blockchain media payment id is the transaction id,
-	// in case of lightning media it is the payment hash. It is not used as
-	// payment identificator because of the reason that it is not unique.
-	MediaId string `protobuf:"bytes,7,opt,name=media_id,json=mediaId" json:"media_id,omitempty"`
+	Asset Asset `protobuf:"varint,1,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
 	//
 	// Media is a type of technology which is used to transport value of
 	// underlying asset.
-	Media Media `protobuf:"varint,8,opt,name=media,enum=crpc.Media" json:"media,omitempty"`
+	Media Media `protobuf:"varint,2,opt,name=media,enum=crpc.Media" json:"media,omitempty"`
 	//
-	// Amount is the number of funds which receiver gets at the end.
-	Amount string `protobuf:"bytes,9,opt,name=amount" json:"amount,omitempty"`
+	// Amount is number of money which should be given to the another entity.
+	Amount string `protobuf:"bytes,3,opt,name=amount" json:"amount,omitempty"`
 	//
-	// MediaFee is the fee which is taken by the blockchain or lightning
-	// network in order to propagate the payment.
-	MediaFee string `protobuf:"bytes,10,opt,name=media_fee,json=mediaFee" json:"media_fee,omitempty"`
+	// Receipt represent either blockchains address or lightning
+	// network invoice, which we should use determine payment receiver.
+	Receipt string `protobuf:"bytes,4,opt,name=receipt" json:"receipt,omitempty"`
+	//
+	// (optional) IdempotencyKey is the unique key generated by the client,
+	// which is used to safely retry the request. If payment with the same
+	// key has been already created, than this payment is returned instead
+	// of creating the new one.
+	IdempotencyKey string `protobuf:"bytes,5,opt,name=idempotency_key,json=idempotencyKey" json:"idempotency_key,omitempty"`
 }
 
-func (m *Payment) Reset()                    { *m = Payment{} }
-func (m *Payment) String() string            { return proto.CompactTextString(m) }
-func (*Payment) ProtoMessage()               {}
-func (*Payment) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+func (m *CreatePaymentRequest) Reset()                    { *m = CreatePaymentRequest{} }
+func (m *CreatePaymentRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreatePaymentRequest) ProtoMessage()               {}
+func (*CreatePaymentRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
 
-func (m *Payment) GetPaymentId() string {
+func (m *CreatePaymentRequest) GetAsset() Asset {
 	if m != nil {
-		return m.PaymentId
+		return m.Asset
 	}
-	return ""
+	return Asset_ASSET_NONE
 }
 
-func (m *Payment) GetUpdatedAt() int64 {
+func (m *CreatePaymentRequest) GetMedia() Media {
 	if m != nil {
-		return m.UpdatedAt
+		return m.Media
 	}
-	return 0
+	return Media_MEDIA_NONE
 }
 
-func (m *Payment) GetStatus() PaymentStatus {
+func (m *CreatePaymentRequest) GetAmount() string {
 	if m != nil {
-		return m.Status
+		return m.Amount
 	}
-	return PaymentStatus_STATUS_NONE
+	return ""
 }
 
-func (m *Payment) GetDirection() PaymentDirection {
+func (m *CreatePaymentRequest) GetReceipt() string {
 	if m != nil {
-		return m.Direction
+		return m.Receipt
 	}
-	return PaymentDirection_DIRECTION_NONE
+	return ""
 }
 
-func (m *Payment) GetSystem() PaymentSystem {
+func (m *CreatePaymentRequest) GetIdempotencyKey() string {
 	if m != nil {
-		return m.System
+		return m.IdempotencyKey
 	}
-	return PaymentSystem_SYSTEM_NONE
+	return ""
 }
 
-func (m *Payment) GetAsset() Asset {
+type ConfirmPaymentRequest struct {
+	//
+	// PaymentID is the id of the waiting payment, which was returned on
+	// payment creation.
+	PaymentId string `protobuf:"bytes,1,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
+}
+
+func (m *ConfirmPaymentRequest) Reset()                    { *m = ConfirmPaymentRequest{} }
+func (m *ConfirmPaymentRequest) String() string            { return proto.CompactTextString(m) }
+func (*ConfirmPaymentRequest) ProtoMessage()               {}
+func (*ConfirmPaymentRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *ConfirmPaymentRequest) GetPaymentId() string {
 	if m != nil {
-		return m.Asset
+		return m.PaymentId
 	}
-	return Asset_ASSET_NONE
+	return ""
 }
 
-func (m *Payment) GetReceipt() string {
+type CancelPaymentRequest struct {
+	//
+	// PaymentID is the id of the waiting payment, which was returned on
+	// payment creation.
+	PaymentId string `protobuf:"bytes,1,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
+}
+
+func (m *CancelPaymentRequest) Reset()                    { *m = CancelPaymentRequest{} }
+func (m *CancelPaymentRequest) String() string            { return proto.CompactTextString(m) }
+func (*CancelPaymentRequest) ProtoMessage()               {}
+func (*CancelPaymentRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *CancelPaymentRequest) GetPaymentId() string {
 	if m != nil {
-		return m.Receipt
+		return m.PaymentId
 	}
 	return ""
 }
 
-func (m *Payment) GetMediaId() string {
+type BumpFeeRequest struct {
+	//
+	// PaymentID is the id of the pending outgoing payment, which
+	// transaction should be replaced.
+	PaymentId string `protobuf:"bytes,1,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
+	//
+	// (optional) FeeRate is the fee rate in sat/byte of the replacement
+	// transaction. If not specified, fee rate of the fee bump policy
+	// priority is used.
+	FeeRate string `protobuf:"bytes,2,opt,name=fee_rate,json=feeRate" json:"fee_rate,omitempty"`
+}
+
+func (m *BumpFeeRequest) Reset()                    { *m = BumpFeeRequest{} }
+func (m *BumpFeeRequest) String() string            { return proto.CompactTextString(m) }
+func (*BumpFeeRequest) ProtoMessage()               {}
+func (*BumpFeeRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *BumpFeeRequest) GetPaymentId() string {
 	if m != nil {
-		return m.MediaId
+		return m.PaymentId
 	}
 	return ""
 }
 
-func (m *Payment) GetMedia() Media {
+func (m *BumpFeeRequest) GetFeeRate() string {
 	if m != nil {
-		return m.Media
+		return m.FeeRate
 	}
-	return Media_MEDIA_NONE
+	return ""
 }
 
-func (m *Payment) GetAmount() string {
+type CreatePSBTPaymentRequest struct {
+	//
+	// Asset is an acronim of the crypto currency.
+	Asset Asset `protobuf:"varint,1,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
+	//
+	// Amount is number of money which should be given to the another entity.
+	Amount string `protobuf:"bytes,2,opt,name=amount" json:"amount,omitempty"`
+	//
+	// Receipt is the blockchain address of the receiver.
+	Receipt string `protobuf:"bytes,3,opt,name=receipt" json:"receipt,omitempty"`
+	//
+	// (optional) IdempotencyKey is the unique key generated by the client,
+	// which is used to safely retry the request. If payment with the same
+	// key has been already created, than this payment is returned instead
+	// of creating the new one.
+	IdempotencyKey string `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey" json:"idempotency_key,omitempty"`
+}
+
+func (m *CreatePSBTPaymentRequest) Reset()                    { *m = CreatePSBTPaymentRequest{} }
+func (m *CreatePSBTPaymentRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreatePSBTPaymentRequest) ProtoMessage()               {}
+func (*CreatePSBTPaymentRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *CreatePSBTPaymentRequest) GetAsset() Asset {
+	if m != nil {
+		return m.Asset
+	}
+	return Asset_ASSET_NONE
+}
+
+func (m *CreatePSBTPaymentRequest) GetAmount() string {
 	if m != nil {
 		return m.Amount
 	}
 	return ""
 }
 
-func (m *Payment) GetMediaFee() string {
+func (m *CreatePSBTPaymentRequest) GetReceipt() string {
 	if m != nil {
-		return m.MediaFee
+		return m.Receipt
 	}
 	return ""
 }
 
-func init() {
-	proto.RegisterType((*EmptyRequest)(nil), "crpc.EmptyRequest")
-	proto.RegisterType((*EmptyResponse)(nil), "crpc.EmptyResponse")
+func (m *CreatePSBTPaymentRequest) GetIdempotencyKey() string {
+	if m != nil {
+		return m.IdempotencyKey
+	}
+	return ""
+}
+
+type ExportPSBTRequest struct {
+	//
+	// PaymentID is the id of the waiting cold storage payment, which was
+	// returned on payment creation.
+	PaymentId string `protobuf:"bytes,1,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
+}
+
+func (m *ExportPSBTRequest) Reset()                    { *m = ExportPSBTRequest{} }
+func (m *ExportPSBTRequest) String() string            { return proto.CompactTextString(m) }
+func (*ExportPSBTRequest) ProtoMessage()               {}
+func (*ExportPSBTRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *ExportPSBTRequest) GetPaymentId() string {
+	if m != nil {
+		return m.PaymentId
+	}
+	return ""
+}
+
+type ExportPSBTResponse struct {
+	//
+	// PSBT is the base64 encoded partially signed transaction of the
+	// payment, as defined in BIP174.
+	Psbt string `protobuf:"bytes,1,opt,name=psbt" json:"psbt,omitempty"`
+}
+
+func (m *ExportPSBTResponse) Reset()                    { *m = ExportPSBTResponse{} }
+func (m *ExportPSBTResponse) String() string            { return proto.CompactTextString(m) }
+func (*ExportPSBTResponse) ProtoMessage()               {}
+func (*ExportPSBTResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *ExportPSBTResponse) GetPsbt() string {
+	if m != nil {
+		return m.Psbt
+	}
+	return ""
+}
+
+type ImportPSBTRequest struct {
+	//
+	// PaymentID is the id of the waiting cold storage payment, which was
+	// returned on payment creation.
+	PaymentId string `protobuf:"bytes,1,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
+	//
+	// PSBT is the base64 encoded partially signed transaction of the
+	// payment, which has been signed offline.
+	Psbt string `protobuf:"bytes,2,opt,name=psbt" json:"psbt,omitempty"`
+}
+
+func (m *ImportPSBTRequest) Reset()                    { *m = ImportPSBTRequest{} }
+func (m *ImportPSBTRequest) String() string            { return proto.CompactTextString(m) }
+func (*ImportPSBTRequest) ProtoMessage()               {}
+func (*ImportPSBTRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *ImportPSBTRequest) GetPaymentId() string {
+	if m != nil {
+		return m.PaymentId
+	}
+	return ""
+}
+
+func (m *ImportPSBTRequest) GetPsbt() string {
+	if m != nil {
+		return m.Psbt
+	}
+	return ""
+}
+
+type FinalizePSBTRequest struct {
+	//
+	// PaymentID is the id of the waiting cold storage payment, which was
+	// returned on payment creation.
+	PaymentId string `protobuf:"bytes,1,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
+}
+
+func (m *FinalizePSBTRequest) Reset()                    { *m = FinalizePSBTRequest{} }
+func (m *FinalizePSBTRequest) String() string            { return proto.CompactTextString(m) }
+func (*FinalizePSBTRequest) ProtoMessage()               {}
+func (*FinalizePSBTRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *FinalizePSBTRequest) GetPaymentId() string {
+	if m != nil {
+		return m.PaymentId
+	}
+	return ""
+}
+
+type ReorganiseInputsRequest struct {
+	//
+	// Asset is an acronim of the crypto currency.
+	Asset Asset `protobuf:"varint,1,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
+	//
+	// (optional) Preview determines whether the reorganisation transaction
+	// should only be estimated, without being sent.
+	Preview bool `protobuf:"varint,2,opt,name=preview" json:"preview,omitempty"`
+}
+
+func (m *ReorganiseInputsRequest) Reset()                    { *m = ReorganiseInputsRequest{} }
+func (m *ReorganiseInputsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ReorganiseInputsRequest) ProtoMessage()               {}
+func (*ReorganiseInputsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *ReorganiseInputsRequest) GetAsset() Asset {
+	if m != nil {
+		return m.Asset
+	}
+	return Asset_ASSET_NONE
+}
+
+func (m *ReorganiseInputsRequest) GetPreview() bool {
+	if m != nil {
+		return m.Preview
+	}
+	return false
+}
+
+type ReorganiseInputsResponse struct {
+	//
+	// TxID is the id of the reorganisation transaction, it is empty if
+	// reorganisation has been only previewed, or if there are no large
+	// unspent outputs to split.
+	TxId string `protobuf:"bytes,1,opt,name=tx_id,json=txId" json:"tx_id,omitempty"`
+	//
+	// Inputs is the number of the split unspent outputs.
+	Inputs int32 `protobuf:"varint,2,opt,name=inputs" json:"inputs,omitempty"`
+	//
+	// InputsAmount is the overall amount of the split unspent outputs.
+	InputsAmount string `protobuf:"bytes,3,opt,name=inputs_amount,json=inputsAmount" json:"inputs_amount,omitempty"`
+	//
+	// Outputs are the amounts of the outputs of the transaction.
+	Outputs []string `protobuf:"bytes,4,rep,name=outputs" json:"outputs,omitempty"`
+	//
+	// Fee is the fee of the transaction.
+	Fee string `protobuf:"bytes,5,opt,name=fee" json:"fee,omitempty"`
+	//
+	// Payments are the internal payments of the outputs, fee of the
+	// transaction is split between them pro-rata to their amounts.
+	Payments []*Payment `protobuf:"bytes,6,rep,name=payments" json:"payments,omitempty"`
+}
+
+func (m *ReorganiseInputsResponse) Reset()                    { *m = ReorganiseInputsResponse{} }
+func (m *ReorganiseInputsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ReorganiseInputsResponse) ProtoMessage()               {}
+func (*ReorganiseInputsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *ReorganiseInputsResponse) GetTxId() string {
+	if m != nil {
+		return m.TxId
+	}
+	return ""
+}
+
+func (m *ReorganiseInputsResponse) GetInputs() int32 {
+	if m != nil {
+		return m.Inputs
+	}
+	return 0
+}
+
+func (m *ReorganiseInputsResponse) GetInputsAmount() string {
+	if m != nil {
+		return m.InputsAmount
+	}
+	return ""
+}
+
+func (m *ReorganiseInputsResponse) GetOutputs() []string {
+	if m != nil {
+		return m.Outputs
+	}
+	return nil
+}
+
+func (m *ReorganiseInputsResponse) GetFee() string {
+	if m != nil {
+		return m.Fee
+	}
+	return ""
+}
+
+func (m *ReorganiseInputsResponse) GetPayments() []*Payment {
+	if m != nil {
+		return m.Payments
+	}
+	return nil
+}
+
+type PaymentByIDRequest struct {
+	//
+	// PaymentID is the payment id which was created by service itself,
+	// for unified identification of the payment.
+	PaymentId string `protobuf:"bytes,1,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
+}
+
+func (m *PaymentByIDRequest) Reset()                    { *m = PaymentByIDRequest{} }
+func (m *PaymentByIDRequest) String() string            { return proto.CompactTextString(m) }
+func (*PaymentByIDRequest) ProtoMessage()               {}
+func (*PaymentByIDRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *PaymentByIDRequest) GetPaymentId() string {
+	if m != nil {
+		return m.PaymentId
+	}
+	return ""
+}
+
+type PaymentsByReceiptRequest struct {
+	//
+	// Receipt represent either blockchains address or lightning
+	// network invoice, depending on the type of the request.
+	Receipt string `protobuf:"bytes,1,opt,name=receipt" json:"receipt,omitempty"`
+}
+
+func (m *PaymentsByReceiptRequest) Reset()                    { *m = PaymentsByReceiptRequest{} }
+func (m *PaymentsByReceiptRequest) String() string            { return proto.CompactTextString(m) }
+func (*PaymentsByReceiptRequest) ProtoMessage()               {}
+func (*PaymentsByReceiptRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *PaymentsByReceiptRequest) GetReceipt() string {
+	if m != nil {
+		return m.Receipt
+	}
+	return ""
+}
+
+type PaymentsByReceiptResponse struct {
+	Payments []*Payment `protobuf:"bytes,1,rep,name=payments" json:"payments,omitempty"`
+}
+
+func (m *PaymentsByReceiptResponse) Reset()                    { *m = PaymentsByReceiptResponse{} }
+func (m *PaymentsByReceiptResponse) String() string            { return proto.CompactTextString(m) }
+func (*PaymentsByReceiptResponse) ProtoMessage()               {}
+func (*PaymentsByReceiptResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *PaymentsByReceiptResponse) GetPayments() []*Payment {
+	if m != nil {
+		return m.Payments
+	}
+	return nil
+}
+
+type GetInfoRequest struct {
+}
+
+func (m *GetInfoRequest) Reset()                    { *m = GetInfoRequest{} }
+func (m *GetInfoRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetInfoRequest) ProtoMessage()               {}
+func (*GetInfoRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+type GetInfoResponse struct {
+	//
+	// Net is the blockchain network payment server is working with.
+	Net        string           `protobuf:"bytes,1,opt,name=net" json:"net,omitempty"`
+	Connectors []*ConnectorInfo `protobuf:"bytes,2,rep,name=connectors" json:"connectors,omitempty"`
+}
+
+func (m *GetInfoResponse) Reset()                    { *m = GetInfoResponse{} }
+func (m *GetInfoResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetInfoResponse) ProtoMessage()               {}
+func (*GetInfoResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *GetInfoResponse) GetNet() string {
+	if m != nil {
+		return m.Net
+	}
+	return ""
+}
+
+func (m *GetInfoResponse) GetConnectors() []*ConnectorInfo {
+	if m != nil {
+		return m.Connectors
+	}
+	return nil
+}
+
+type ConnectorInfo struct {
+	//
+	// Asset is an acronim of the crypto currency.
+	Asset Asset `protobuf:"varint,1,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
+	//
+	// Media is a type of technology which is used to transport value of
+	// underlying asset.
+	Media Media `protobuf:"varint,2,opt,name=media,enum=crpc.Media" json:"media,omitempty"`
+	//
+	// State is the lifecycle state of the connector.
+	State ConnectorState `protobuf:"varint,3,opt,name=state,enum=crpc.ConnectorState" json:"state,omitempty"`
+	//
+	// StartError is the error of the last failed start attempt.
+	StartError string `protobuf:"bytes,4,opt,name=start_error,json=startError" json:"start_error,omitempty"`
+	//
+	// DaemonError is the error which occurred during fetching the daemon
+	// state, if it is not empty daemon fields are not populated.
+	DaemonError string `protobuf:"bytes,5,opt,name=daemon_error,json=daemonError" json:"daemon_error,omitempty"`
+	//
+	// Net is the network connector is working with.
+	Net string `protobuf:"bytes,6,opt,name=net" json:"net,omitempty"`
+	//
+	// MinConfirmations is the number of confirmations after which payment
+	// is considered as completed.
+	MinConfirmations int32 `protobuf:"varint,7,opt,name=min_confirmations,json=minConfirmations" json:"min_confirmations,omitempty"`
+	//
+	// DaemonVersion is the version of the daemon.
+	DaemonVersion string `protobuf:"bytes,8,opt,name=daemon_version,json=daemonVersion" json:"daemon_version,omitempty"`
+	//
+	// BestHeight is the height of the best block known to the daemon.
+	BestHeight int64 `protobuf:"varint,9,opt,name=best_height,json=bestHeight" json:"best_height,omitempty"`
+	//
+	// SyncedHeight is the height of the last block processed by connector.
+	SyncedHeight int64 `protobuf:"varint,10,opt,name=synced_height,json=syncedHeight" json:"synced_height,omitempty"`
+	//
+	// Lightning is the information about lightning network node, it is
+	// populated only for lightning media.
+	Lightning *LightningInfo `protobuf:"bytes,12,opt,name=lightning" json:"lightning,omitempty"`
+}
+
+func (m *ConnectorInfo) Reset()                    { *m = ConnectorInfo{} }
+func (m *ConnectorInfo) String() string            { return proto.CompactTextString(m) }
+func (*ConnectorInfo) ProtoMessage()               {}
+func (*ConnectorInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *ConnectorInfo) GetAsset() Asset {
+	if m != nil {
+		return m.Asset
+	}
+	return Asset_ASSET_NONE
+}
+
+func (m *ConnectorInfo) GetMedia() Media {
+	if m != nil {
+		return m.Media
+	}
+	return Media_MEDIA_NONE
+}
+
+func (m *ConnectorInfo) GetState() ConnectorState {
+	if m != nil {
+		return m.State
+	}
+	return ConnectorState_CONNECTOR_STATE_NONE
+}
+
+func (m *ConnectorInfo) GetStartError() string {
+	if m != nil {
+		return m.StartError
+	}
+	return ""
+}
+
+func (m *ConnectorInfo) GetDaemonError() string {
+	if m != nil {
+		return m.DaemonError
+	}
+	return ""
+}
+
+func (m *ConnectorInfo) GetNet() string {
+	if m != nil {
+		return m.Net
+	}
+	return ""
+}
+
+func (m *ConnectorInfo) GetMinConfirmations() int32 {
+	if m != nil {
+		return m.MinConfirmations
+	}
+	return 0
+}
+
+func (m *ConnectorInfo) GetDaemonVersion() string {
+	if m != nil {
+		return m.DaemonVersion
+	}
+	return ""
+}
+
+func (m *ConnectorInfo) GetBestHeight() int64 {
+	if m != nil {
+		return m.BestHeight
+	}
+	return 0
+}
+
+func (m *ConnectorInfo) GetSyncedHeight() int64 {
+	if m != nil {
+		return m.SyncedHeight
+	}
+	return 0
+}
+
+func (m *ConnectorInfo) GetLightning() *LightningInfo {
+	if m != nil {
+		return m.Lightning
+	}
+	return nil
+}
+
+type LightningInfo struct {
+	//
+	// Pubkey is the identity public key of the lightning network node.
+	Pubkey string `protobuf:"bytes,1,opt,name=pubkey" json:"pubkey,omitempty"`
+	Alias  string `protobuf:"bytes,2,opt,name=alias" json:"alias,omitempty"`
+	//
+	// Host and port via which other lightning network nodes could connect.
+	Host               string `protobuf:"bytes,3,opt,name=host" json:"host,omitempty"`
+	Port               string `protobuf:"bytes,4,opt,name=port" json:"port,omitempty"`
+	NumPendingChannels uint32 `protobuf:"varint,5,opt,name=num_pending_channels,json=numPendingChannels" json:"num_pending_channels,omitempty"`
+	NumActiveChannels  uint32 `protobuf:"varint,6,opt,name=num_active_channels,json=numActiveChannels" json:"num_active_channels,omitempty"`
+	NumPeers           uint32 `protobuf:"varint,7,opt,name=num_peers,json=numPeers" json:"num_peers,omitempty"`
+	//
+	// SyncedToChain denotes whether node is synced with the blockchain.
+	SyncedToChain bool `protobuf:"varint,8,opt,name=synced_to_chain,json=syncedToChain" json:"synced_to_chain,omitempty"`
+}
+
+func (m *LightningInfo) Reset()                    { *m = LightningInfo{} }
+func (m *LightningInfo) String() string            { return proto.CompactTextString(m) }
+func (*LightningInfo) ProtoMessage()               {}
+func (*LightningInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *LightningInfo) GetPubkey() string {
+	if m != nil {
+		return m.Pubkey
+	}
+	return ""
+}
+
+func (m *LightningInfo) GetAlias() string {
+	if m != nil {
+		return m.Alias
+	}
+	return ""
+}
+
+func (m *LightningInfo) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *LightningInfo) GetPort() string {
+	if m != nil {
+		return m.Port
+	}
+	return ""
+}
+
+func (m *LightningInfo) GetNumPendingChannels() uint32 {
+	if m != nil {
+		return m.NumPendingChannels
+	}
+	return 0
+}
+
+func (m *LightningInfo) GetNumActiveChannels() uint32 {
+	if m != nil {
+		return m.NumActiveChannels
+	}
+	return 0
+}
+
+func (m *LightningInfo) GetNumPeers() uint32 {
+	if m != nil {
+		return m.NumPeers
+	}
+	return 0
+}
+
+func (m *LightningInfo) GetSyncedToChain() bool {
+	if m != nil {
+		return m.SyncedToChain
+	}
+	return false
+}
+
+type BakeMacaroonRequest struct {
+	//
+	// Permissions is the list of permissions granted by the macaroon.
+	// Possible values are: "read" - access to the methods which don't
+	// change the state of the server, "invoice" - creation of the receipts,
+	// "send" - sending of the payments, "bake" - baking of the new macaroons.
+	Permissions []string `protobuf:"bytes,1,rep,name=permissions" json:"permissions,omitempty"`
+}
+
+func (m *BakeMacaroonRequest) Reset()                    { *m = BakeMacaroonRequest{} }
+func (m *BakeMacaroonRequest) String() string            { return proto.CompactTextString(m) }
+func (*BakeMacaroonRequest) ProtoMessage()               {}
+func (*BakeMacaroonRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *BakeMacaroonRequest) GetPermissions() []string {
+	if m != nil {
+		return m.Permissions
+	}
+	return nil
+}
+
+type BakeMacaroonResponse struct {
+	//
+	// Macaroon is the hex encoded macaroon.
+	Macaroon string `protobuf:"bytes,1,opt,name=macaroon" json:"macaroon,omitempty"`
+}
+
+func (m *BakeMacaroonResponse) Reset()                    { *m = BakeMacaroonResponse{} }
+func (m *BakeMacaroonResponse) String() string            { return proto.CompactTextString(m) }
+func (*BakeMacaroonResponse) ProtoMessage()               {}
+func (*BakeMacaroonResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *BakeMacaroonResponse) GetMacaroon() string {
+	if m != nil {
+		return m.Macaroon
+	}
+	return ""
+}
+
+type ListFailedNotificationsRequest struct {
+}
+
+func (m *ListFailedNotificationsRequest) Reset()         { *m = ListFailedNotificationsRequest{} }
+func (m *ListFailedNotificationsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListFailedNotificationsRequest) ProtoMessage()    {}
+func (*ListFailedNotificationsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{36}
+}
+
+type ListFailedNotificationsResponse struct {
+	Notifications []*Notification `protobuf:"bytes,1,rep,name=notifications" json:"notifications,omitempty"`
+}
+
+func (m *ListFailedNotificationsResponse) Reset()         { *m = ListFailedNotificationsResponse{} }
+func (m *ListFailedNotificationsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListFailedNotificationsResponse) ProtoMessage()    {}
+func (*ListFailedNotificationsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{37}
+}
+
+func (m *ListFailedNotificationsResponse) GetNotifications() []*Notification {
+	if m != nil {
+		return m.Notifications
+	}
+	return nil
+}
+
+type ReplayNotificationsRequest struct {
+	//
+	// (optional) Ids of the notifications which should be replayed, if not
+	// specified all failed notifications are replayed.
+	Ids []string `protobuf:"bytes,1,rep,name=ids" json:"ids,omitempty"`
+}
+
+func (m *ReplayNotificationsRequest) Reset()                    { *m = ReplayNotificationsRequest{} }
+func (m *ReplayNotificationsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ReplayNotificationsRequest) ProtoMessage()               {}
+func (*ReplayNotificationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *ReplayNotificationsRequest) GetIds() []string {
+	if m != nil {
+		return m.Ids
+	}
+	return nil
+}
+
+type ReplayNotificationsResponse struct {
+	Notifications []*Notification `protobuf:"bytes,1,rep,name=notifications" json:"notifications,omitempty"`
+}
+
+func (m *ReplayNotificationsResponse) Reset()                    { *m = ReplayNotificationsResponse{} }
+func (m *ReplayNotificationsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ReplayNotificationsResponse) ProtoMessage()               {}
+func (*ReplayNotificationsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *ReplayNotificationsResponse) GetNotifications() []*Notification {
+	if m != nil {
+		return m.Notifications
+	}
+	return nil
+}
+
+type GetFeeRatesRequest struct {
+	//
+	// (optional) Asset is an acronim of the crypto currency, if not
+	// specified fee rates of all assets are returned.
+	Asset Asset `protobuf:"varint,1,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
+}
+
+func (m *GetFeeRatesRequest) Reset()                    { *m = GetFeeRatesRequest{} }
+func (m *GetFeeRatesRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetFeeRatesRequest) ProtoMessage()               {}
+func (*GetFeeRatesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *GetFeeRatesRequest) GetAsset() Asset {
+	if m != nil {
+		return m.Asset
+	}
+	return Asset_ASSET_NONE
+}
+
+type GetFeeRatesResponse struct {
+	Rates []*FeeRate `protobuf:"bytes,1,rep,name=rates" json:"rates,omitempty"`
+}
+
+func (m *GetFeeRatesResponse) Reset()                    { *m = GetFeeRatesResponse{} }
+func (m *GetFeeRatesResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetFeeRatesResponse) ProtoMessage()               {}
+func (*GetFeeRatesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+func (m *GetFeeRatesResponse) GetRates() []*FeeRate {
+	if m != nil {
+		return m.Rates
+	}
+	return nil
+}
+
+type FeeRate struct {
+	//
+	// Asset is an acronim of the crypto currency.
+	Asset Asset `protobuf:"varint,1,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
+	//
+	// Priority is the priority of the fee rate.
+	Priority FeePriority `protobuf:"varint,2,opt,name=priority,enum=crpc.FeePriority" json:"priority,omitempty"`
+	//
+	// ConfTarget is the number of blocks in which transaction with this
+	// fee rate is expected to be confirmed.
+	ConfTarget uint32 `protobuf:"varint,3,opt,name=conf_target,json=confTarget" json:"conf_target,omitempty"`
+	//
+	// FeeRate is the fee rate in the smallest units of the asset per byte,
+	// e.g. sat/byte.
+	FeeRate string `protobuf:"bytes,4,opt,name=fee_rate,json=feeRate" json:"fee_rate,omitempty"`
+	//
+	// Estimated is false if the fee rate hasn't been estimated by the
+	// daemon yet, and the fallback fee rate is used.
+	Estimated bool `protobuf:"varint,5,opt,name=estimated" json:"estimated,omitempty"`
+	//
+	// UpdatedAt is the time in milliseconds of the last estimation.
+	UpdatedAt int64 `protobuf:"varint,6,opt,name=updated_at,json=updatedAt" json:"updated_at,omitempty"`
+}
+
+func (m *FeeRate) Reset()                    { *m = FeeRate{} }
+func (m *FeeRate) String() string            { return proto.CompactTextString(m) }
+func (*FeeRate) ProtoMessage()               {}
+func (*FeeRate) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+
+func (m *FeeRate) GetAsset() Asset {
+	if m != nil {
+		return m.Asset
+	}
+	return Asset_ASSET_NONE
+}
+
+func (m *FeeRate) GetPriority() FeePriority {
+	if m != nil {
+		return m.Priority
+	}
+	return FeePriority_PRIORITY_NONE
+}
+
+func (m *FeeRate) GetConfTarget() uint32 {
+	if m != nil {
+		return m.ConfTarget
+	}
+	return 0
+}
+
+func (m *FeeRate) GetFeeRate() string {
+	if m != nil {
+		return m.FeeRate
+	}
+	return ""
+}
+
+func (m *FeeRate) GetEstimated() bool {
+	if m != nil {
+		return m.Estimated
+	}
+	return false
+}
+
+func (m *FeeRate) GetUpdatedAt() int64 {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return 0
+}
+
+type Notification struct {
+	//
+	// Id is the unique identificator of the notification, it is also sent
+	// in the notification payload.
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	//
+	// Url is the webhook url on which notification should be delivered.
+	Url string `protobuf:"bytes,2,opt,name=url" json:"url,omitempty"`
+	//
+	// PaymentId is the id of the payment which status has been changed.
+	PaymentId string `protobuf:"bytes,3,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
+	//
+	// Payload is the JSON body of the notification.
+	Payload string `protobuf:"bytes,4,opt,name=payload" json:"payload,omitempty"`
+	//
+	// Attempts is the number of failed delivery attempts.
+	Attempts int32 `protobuf:"varint,5,opt,name=attempts" json:"attempts,omitempty"`
+	//
+	// LastError is the error of the last failed delivery attempt.
+	LastError string `protobuf:"bytes,6,opt,name=last_error,json=lastError" json:"last_error,omitempty"`
+	//
+	// CreatedAt is the time of notification creation, in milliseconds.
+	CreatedAt int64 `protobuf:"varint,7,opt,name=created_at,json=createdAt" json:"created_at,omitempty"`
+}
+
+func (m *Notification) Reset()                    { *m = Notification{} }
+func (m *Notification) String() string            { return proto.CompactTextString(m) }
+func (*Notification) ProtoMessage()               {}
+func (*Notification) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+
+func (m *Notification) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Notification) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *Notification) GetPaymentId() string {
+	if m != nil {
+		return m.PaymentId
+	}
+	return ""
+}
+
+func (m *Notification) GetPayload() string {
+	if m != nil {
+		return m.Payload
+	}
+	return ""
+}
+
+func (m *Notification) GetAttempts() int32 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
+func (m *Notification) GetLastError() string {
+	if m != nil {
+		return m.LastError
+	}
+	return ""
+}
+
+func (m *Notification) GetCreatedAt() int64 {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return 0
+}
+
+type ListPaymentsRequest struct {
+	//
+	// (optional) Status denotes the stage of the processing the payment.
+	Status PaymentStatus `protobuf:"varint,1,opt,name=status,enum=crpc.PaymentStatus" json:"status,omitempty"`
+	//
+	// (optional) Direction denotes the direction of the payment.
+	Direction PaymentDirection `protobuf:"varint,2,opt,name=direction,enum=crpc.PaymentDirection" json:"direction,omitempty"`
+	//
+	// (optional) Asset is an acronim of the crypto currency.
+	Asset Asset `protobuf:"varint,3,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
+	//
+	// (optional) Media is a type of technology which is used to transport
+	// value of underlying asset.
+	Media Media `protobuf:"varint,4,opt,name=media,enum=crpc.Media" json:"media,omitempty"`
+	//
+	// (optional) PaymentSystem denotes is that payment belongs to business
+	// logic of payment server or it was originated by user / third-party
+	// service.
+	System PaymentSystem `protobuf:"varint,5,opt,name=system,enum=crpc.PaymentSystem" json:"system,omitempty"`
+	//
+	// (optional) Limit is the maximum number of payments which should be
+	// returned. If limit is not specified all payments are returned.
+	Limit uint32 `protobuf:"varint,6,opt,name=limit" json:"limit,omitempty"`
+	//
+	// (optional) Cursor is the value of next_cursor returned in the previous
+	// response, it is used to fetch the next page of payments.
+	Cursor string `protobuf:"bytes,7,opt,name=cursor" json:"cursor,omitempty"`
+	//
+	// (optional) UpdatedAfter is used to return only payments which were
+	// updated after the given time in milliseconds.
+	UpdatedAfter int64 `protobuf:"varint,8,opt,name=updated_after,json=updatedAfter" json:"updated_after,omitempty"`
+	//
+	// (optional) UpdatedBefore is used to return only payments which were
+	// updated before the given time in milliseconds.
+	UpdatedBefore int64 `protobuf:"varint,9,opt,name=updated_before,json=updatedBefore" json:"updated_before,omitempty"`
+}
+
+func (m *ListPaymentsRequest) Reset()                    { *m = ListPaymentsRequest{} }
+func (m *ListPaymentsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListPaymentsRequest) ProtoMessage()               {}
+func (*ListPaymentsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *ListPaymentsRequest) GetStatus() PaymentStatus {
+	if m != nil {
+		return m.Status
+	}
+	return PaymentStatus_STATUS_NONE
+}
+
+func (m *ListPaymentsRequest) GetDirection() PaymentDirection {
+	if m != nil {
+		return m.Direction
+	}
+	return PaymentDirection_DIRECTION_NONE
+}
+
+func (m *ListPaymentsRequest) GetAsset() Asset {
+	if m != nil {
+		return m.Asset
+	}
+	return Asset_ASSET_NONE
+}
+
+func (m *ListPaymentsRequest) GetMedia() Media {
+	if m != nil {
+		return m.Media
+	}
+	return Media_MEDIA_NONE
+}
+
+func (m *ListPaymentsRequest) GetSystem() PaymentSystem {
+	if m != nil {
+		return m.System
+	}
+	return PaymentSystem_SYSTEM_NONE
+}
+
+func (m *ListPaymentsRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *ListPaymentsRequest) GetCursor() string {
+	if m != nil {
+		return m.Cursor
+	}
+	return ""
+}
+
+func (m *ListPaymentsRequest) GetUpdatedAfter() int64 {
+	if m != nil {
+		return m.UpdatedAfter
+	}
+	return 0
+}
+
+func (m *ListPaymentsRequest) GetUpdatedBefore() int64 {
+	if m != nil {
+		return m.UpdatedBefore
+	}
+	return 0
+}
+
+type ListPaymentsResponse struct {
+	//
+	// Payments ordered from the most recently updated ones.
+	Payments []*Payment `protobuf:"bytes,1,rep,name=payments" json:"payments,omitempty"`
+	//
+	// NextCursor should be used to fetch the next page of payments. Empty
+	// value means that there are no more payments.
+	NextCursor string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor" json:"next_cursor,omitempty"`
+}
+
+func (m *ListPaymentsResponse) Reset()                    { *m = ListPaymentsResponse{} }
+func (m *ListPaymentsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListPaymentsResponse) ProtoMessage()               {}
+func (*ListPaymentsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+func (m *ListPaymentsResponse) GetPayments() []*Payment {
+	if m != nil {
+		return m.Payments
+	}
+	return nil
+}
+
+func (m *ListPaymentsResponse) GetNextCursor() string {
+	if m != nil {
+		return m.NextCursor
+	}
+	return ""
+}
+
+type SubscribePaymentsRequest struct {
+	//
+	// (optional) Asset is an acronim of the crypto currency.
+	Asset Asset `protobuf:"varint,1,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
+	//
+	// (optional) Media is a type of technology which is used to transport
+	// value of underlying asset.
+	Media Media `protobuf:"varint,2,opt,name=media,enum=crpc.Media" json:"media,omitempty"`
+	//
+	// (optional) Direction denotes the direction of the payment.
+	Direction PaymentDirection `protobuf:"varint,3,opt,name=direction,enum=crpc.PaymentDirection" json:"direction,omitempty"`
+	//
+	// (optional) PaymentSystem denotes is that payment belongs to business
+	// logic of payment server or it was originated by user / third-party
+	// service.
+	System PaymentSystem `protobuf:"varint,4,opt,name=system,enum=crpc.PaymentSystem" json:"system,omitempty"`
+}
+
+func (m *SubscribePaymentsRequest) Reset()                    { *m = SubscribePaymentsRequest{} }
+func (m *SubscribePaymentsRequest) String() string            { return proto.CompactTextString(m) }
+func (*SubscribePaymentsRequest) ProtoMessage()               {}
+func (*SubscribePaymentsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
+
+func (m *SubscribePaymentsRequest) GetAsset() Asset {
+	if m != nil {
+		return m.Asset
+	}
+	return Asset_ASSET_NONE
+}
+
+func (m *SubscribePaymentsRequest) GetMedia() Media {
+	if m != nil {
+		return m.Media
+	}
+	return Media_MEDIA_NONE
+}
+
+func (m *SubscribePaymentsRequest) GetDirection() PaymentDirection {
+	if m != nil {
+		return m.Direction
+	}
+	return PaymentDirection_DIRECTION_NONE
+}
+
+func (m *SubscribePaymentsRequest) GetSystem() PaymentSystem {
+	if m != nil {
+		return m.System
+	}
+	return PaymentSystem_SYSTEM_NONE
+}
+
+type Payment struct {
+	//
+	// PaymentID it is unique identificator of the payment generated inside
+	// the system.
+	PaymentId string `protobuf:"bytes,1,opt,name=payment_id,json=paymentId" json:"payment_id,omitempty"`
+	//
+	// UpdatedAt denotes the time when payment object has been last updated.
+	UpdatedAt int64 `protobuf:"varint,2,opt,name=updated_at,json=updatedAt" json:"updated_at,omitempty"`
+	//
+	// Status denotes the stage of the processing the payment.
+	Status PaymentStatus `protobuf:"varint,3,opt,name=status,enum=crpc.PaymentStatus" json:"status,omitempty"`
+	//
+	// Direction denotes the direction of the payment, whether
+	// payment is going form us to someone else, or form someone else to us.
+	Direction PaymentDirection `protobuf:"varint,4,opt,name=direction,enum=crpc.PaymentDirection" json:"direction,omitempty"`
+	//
+	// System denotes is that payment belongs to business logic of
+	// payment server or it was originated by user / third-party service.
+	System PaymentSystem `protobuf:"varint,11,opt,name=system,enum=crpc.PaymentSystem" json:"system,omitempty"`
+	//
+	// Asset is an acronim of the crypto currency.
+	Asset Asset `protobuf:"varint,5,opt,name=asset,enum=crpc.Asset" json:"asset,omitempty"`
+	//
+	// Receipt is a string which identifies the receiver of the
+	// payment. It is address in case of the blockchain media,
+	// and lightning network invoice in case lightning media.
+	Receipt string `protobuf:"bytes,6,opt,name=receipt" json:"receipt,omitempty"`
+	// MediaID is identificator of the payment inside the media.
+	// In case of blockchain media payment id is the transaction id,
+	// in case of lightning media it is the payment hash. It is not used as
+	// payment identificator because of the reason that it is not unique.
+	MediaId string `protobuf:"bytes,7,opt,name=media_id,json=mediaId" json:"media_id,omitempty"`
+	//
+	// Media is a type of technology which is used to transport value of
+	// underlying asset.
+	Media Media `protobuf:"varint,8,opt,name=media,enum=crpc.Media" json:"media,omitempty"`
+	//
+	// Amount is the number of funds which receiver gets at the end.
+	Amount string `protobuf:"bytes,9,opt,name=amount" json:"amount,omitempty"`
+	//
+	// MediaFee is the fee which is taken by the blockchain or lightning
+	// network in order to propagate the payment.
+	MediaFee string `protobuf:"bytes,10,opt,name=media_fee,json=mediaFee" json:"media_fee,omitempty"`
+	//
+	// IdempotencyKey is the key which was specified by the client on
+	// payment sending.
+	IdempotencyKey string `protobuf:"bytes,12,opt,name=idempotency_key,json=idempotencyKey" json:"idempotency_key,omitempty"`
+	//
+	// FailureReason is the error because of which payment has been marked
+	// as failed, it is empty for the payments of other statuses.
+	FailureReason string `protobuf:"bytes,13,opt,name=failure_reason,json=failureReason" json:"failure_reason,omitempty"`
+}
+
+func (m *Payment) Reset()                    { *m = Payment{} }
+func (m *Payment) String() string            { return proto.CompactTextString(m) }
+func (*Payment) ProtoMessage()               {}
+func (*Payment) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+func (m *Payment) GetPaymentId() string {
+	if m != nil {
+		return m.PaymentId
+	}
+	return ""
+}
+
+func (m *Payment) GetUpdatedAt() int64 {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return 0
+}
+
+func (m *Payment) GetStatus() PaymentStatus {
+	if m != nil {
+		return m.Status
+	}
+	return PaymentStatus_STATUS_NONE
+}
+
+func (m *Payment) GetDirection() PaymentDirection {
+	if m != nil {
+		return m.Direction
+	}
+	return PaymentDirection_DIRECTION_NONE
+}
+
+func (m *Payment) GetSystem() PaymentSystem {
+	if m != nil {
+		return m.System
+	}
+	return PaymentSystem_SYSTEM_NONE
+}
+
+func (m *Payment) GetAsset() Asset {
+	if m != nil {
+		return m.Asset
+	}
+	return Asset_ASSET_NONE
+}
+
+func (m *Payment) GetReceipt() string {
+	if m != nil {
+		return m.Receipt
+	}
+	return ""
+}
+
+func (m *Payment) GetMediaId() string {
+	if m != nil {
+		return m.MediaId
+	}
+	return ""
+}
+
+func (m *Payment) GetMedia() Media {
+	if m != nil {
+		return m.Media
+	}
+	return Media_MEDIA_NONE
+}
+
+func (m *Payment) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+func (m *Payment) GetMediaFee() string {
+	if m != nil {
+		return m.MediaFee
+	}
+	return ""
+}
+
+func (m *Payment) GetIdempotencyKey() string {
+	if m != nil {
+		return m.IdempotencyKey
+	}
+	return ""
+}
+
+func (m *Payment) GetFailureReason() string {
+	if m != nil {
+		return m.FailureReason
+	}
+	return ""
+}
+
+// ErrorDetail is attached to the gRPC status of the failed request, and
+// contains payserver specific error code, which allows client to
+// distinguish errors with the same gRPC status code.
+type ErrorDetail struct {
+	Code uint32 `protobuf:"varint,1,opt,name=code" json:"code,omitempty"`
+}
+
+func (m *ErrorDetail) Reset()                    { *m = ErrorDetail{} }
+func (m *ErrorDetail) String() string            { return proto.CompactTextString(m) }
+func (*ErrorDetail) ProtoMessage()               {}
+func (*ErrorDetail) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{48} }
+
+func (m *ErrorDetail) GetCode() uint32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*EmptyRequest)(nil), "crpc.EmptyRequest")
+	proto.RegisterType((*EmptyResponse)(nil), "crpc.EmptyResponse")
 	proto.RegisterType((*CreateReceiptRequest)(nil), "crpc.CreateReceiptRequest")
 	proto.RegisterType((*CreateReceiptResponse)(nil), "crpc.CreateReceiptResponse")
 	proto.RegisterType((*BalanceRequest)(nil), "crpc.BalanceRequest")
@@ -1049,16 +2347,48 @@ func init() {
 	proto.RegisterType((*EstimateFeeRequest)(nil), "crpc.EstimateFeeRequest")
 	proto.RegisterType((*EstimateFeeResponse)(nil), "crpc.EstimateFeeResponse")
 	proto.RegisterType((*SendPaymentRequest)(nil), "crpc.SendPaymentRequest")
+	proto.RegisterType((*Recipient)(nil), "crpc.Recipient")
+	proto.RegisterType((*SendPaymentsRequest)(nil), "crpc.SendPaymentsRequest")
+	proto.RegisterType((*SendPaymentsResponse)(nil), "crpc.SendPaymentsResponse")
+	proto.RegisterType((*CreatePaymentRequest)(nil), "crpc.CreatePaymentRequest")
+	proto.RegisterType((*ConfirmPaymentRequest)(nil), "crpc.ConfirmPaymentRequest")
+	proto.RegisterType((*CancelPaymentRequest)(nil), "crpc.CancelPaymentRequest")
+	proto.RegisterType((*BumpFeeRequest)(nil), "crpc.BumpFeeRequest")
+	proto.RegisterType((*CreatePSBTPaymentRequest)(nil), "crpc.CreatePSBTPaymentRequest")
+	proto.RegisterType((*ExportPSBTRequest)(nil), "crpc.ExportPSBTRequest")
+	proto.RegisterType((*ExportPSBTResponse)(nil), "crpc.ExportPSBTResponse")
+	proto.RegisterType((*ImportPSBTRequest)(nil), "crpc.ImportPSBTRequest")
+	proto.RegisterType((*FinalizePSBTRequest)(nil), "crpc.FinalizePSBTRequest")
+	proto.RegisterType((*ReorganiseInputsRequest)(nil), "crpc.ReorganiseInputsRequest")
+	proto.RegisterType((*ReorganiseInputsResponse)(nil), "crpc.ReorganiseInputsResponse")
 	proto.RegisterType((*PaymentByIDRequest)(nil), "crpc.PaymentByIDRequest")
 	proto.RegisterType((*PaymentsByReceiptRequest)(nil), "crpc.PaymentsByReceiptRequest")
 	proto.RegisterType((*PaymentsByReceiptResponse)(nil), "crpc.PaymentsByReceiptResponse")
+	proto.RegisterType((*GetInfoRequest)(nil), "crpc.GetInfoRequest")
+	proto.RegisterType((*GetInfoResponse)(nil), "crpc.GetInfoResponse")
+	proto.RegisterType((*ConnectorInfo)(nil), "crpc.ConnectorInfo")
+	proto.RegisterType((*LightningInfo)(nil), "crpc.LightningInfo")
+	proto.RegisterType((*BakeMacaroonRequest)(nil), "crpc.BakeMacaroonRequest")
+	proto.RegisterType((*BakeMacaroonResponse)(nil), "crpc.BakeMacaroonResponse")
+	proto.RegisterType((*ListFailedNotificationsRequest)(nil), "crpc.ListFailedNotificationsRequest")
+	proto.RegisterType((*ListFailedNotificationsResponse)(nil), "crpc.ListFailedNotificationsResponse")
+	proto.RegisterType((*ReplayNotificationsRequest)(nil), "crpc.ReplayNotificationsRequest")
+	proto.RegisterType((*ReplayNotificationsResponse)(nil), "crpc.ReplayNotificationsResponse")
+	proto.RegisterType((*GetFeeRatesRequest)(nil), "crpc.GetFeeRatesRequest")
+	proto.RegisterType((*GetFeeRatesResponse)(nil), "crpc.GetFeeRatesResponse")
+	proto.RegisterType((*FeeRate)(nil), "crpc.FeeRate")
+	proto.RegisterType((*Notification)(nil), "crpc.Notification")
 	proto.RegisterType((*ListPaymentsRequest)(nil), "crpc.ListPaymentsRequest")
 	proto.RegisterType((*ListPaymentsResponse)(nil), "crpc.ListPaymentsResponse")
+	proto.RegisterType((*SubscribePaymentsRequest)(nil), "crpc.SubscribePaymentsRequest")
 	proto.RegisterType((*Payment)(nil), "crpc.Payment")
+	proto.RegisterType((*ErrorDetail)(nil), "crpc.ErrorDetail")
 	proto.RegisterEnum("crpc.Asset", Asset_name, Asset_value)
 	proto.RegisterEnum("crpc.Media", Media_name, Media_value)
 	proto.RegisterEnum("crpc.PaymentStatus", PaymentStatus_name, PaymentStatus_value)
 	proto.RegisterEnum("crpc.PaymentDirection", PaymentDirection_name, PaymentDirection_value)
+	proto.RegisterEnum("crpc.ConnectorState", ConnectorState_name, ConnectorState_value)
+	proto.RegisterEnum("crpc.FeePriority", FeePriority_name, FeePriority_value)
 	proto.RegisterEnum("crpc.PaymentSystem", PaymentSystem_name, PaymentSystem_value)
 }
 
@@ -1094,6 +2424,58 @@ type PayServerClient interface {
 	// account has enough money for doing that.
 	SendPayment(ctx context.Context, in *SendPaymentRequest, opts ...grpc.CallOption) (*Payment, error)
 	//
+	// SendPayments sends blockchain payments to several recipients at
+	// once, either all of them are sent or none. Every receipt is validated
+	// before anything is spent. Payments are sent in one transaction if
+	// blockchain supports it, and are linked by the shared media id.
+	SendPayments(ctx context.Context, in *SendPaymentsRequest, opts ...grpc.CallOption) (*SendPaymentsResponse, error)
+	//
+	// CreatePayment creates and signs the payment, but not sends it.
+	// Payment is stored with waiting status and the exact fee, so that
+	// it could be reviewed before being confirmed or canceled.
+	CreatePayment(ctx context.Context, in *CreatePaymentRequest, opts ...grpc.CallOption) (*Payment, error)
+	//
+	// ConfirmPayment sends previously created waiting payment.
+	ConfirmPayment(ctx context.Context, in *ConfirmPaymentRequest, opts ...grpc.CallOption) (*Payment, error)
+	//
+	// CancelPayment cancels previously created waiting payment, and
+	// releases resources reserved for it, such as locked unspent outputs
+	// or ethereum nonce.
+	CancelPayment(ctx context.Context, in *CancelPaymentRequest, opts ...grpc.CallOption) (*Payment, error)
+	//
+	// BumpFee replaces the transaction of the pending blockchain payment
+	// with the transaction paying bigger fee, so that stuck payment would
+	// be confirmed faster. Only payments of the assets supporting
+	// replace-by-fee could be bumped.
+	BumpFee(ctx context.Context, in *BumpFeeRequest, opts ...grpc.CallOption) (*Payment, error)
+	//
+	// CreatePSBTPayment creates the blockchain payment from the cold
+	// storage, i.e. from the outputs of the addresses derived from the
+	// extended public key. Payment is stored with waiting status and the
+	// exact fee, until its partially signed transaction is signed offline
+	// and finalized. Only BTC and LTC payments could be created.
+	CreatePSBTPayment(ctx context.Context, in *CreatePSBTPaymentRequest, opts ...grpc.CallOption) (*Payment, error)
+	//
+	// ExportPSBT returns base64 encoded partially signed transaction of the
+	// waiting cold storage payment, which should be signed offline.
+	ExportPSBT(ctx context.Context, in *ExportPSBTRequest, opts ...grpc.CallOption) (*ExportPSBTResponse, error)
+	//
+	// ImportPSBT imports the signatures of the partially signed transaction
+	// which has been signed offline into the waiting cold storage payment.
+	ImportPSBT(ctx context.Context, in *ImportPSBTRequest, opts ...grpc.CallOption) (*Payment, error)
+	//
+	// FinalizePSBT finalizes the fully signed transaction of the waiting
+	// cold storage payment and sends it, after which payment is tracked as
+	// any other outgoing payment.
+	FinalizePSBT(ctx context.Context, in *FinalizePSBTRequest, opts ...grpc.CallOption) (*Payment, error)
+	//
+	// ReorganiseInputs splits large unspent outputs of the wallet into the
+	// outputs of the optimal value, which are tracked as the internal
+	// payments. If preview is set, transaction is only estimated and isn't
+	// sent. Only outputs of the connectors with the full backend could be
+	// reorganised.
+	ReorganiseInputs(ctx context.Context, in *ReorganiseInputsRequest, opts ...grpc.CallOption) (*ReorganiseInputsResponse, error)
+	//
 	// PaymentByID is used to fetch the information about payment, by the
 	// given system payment id.
 	PaymentByID(ctx context.Context, in *PaymentByIDRequest, opts ...grpc.CallOption) (*Payment, error)
@@ -1103,8 +2485,34 @@ type PayServerClient interface {
 	PaymentsByReceipt(ctx context.Context, in *PaymentsByReceiptRequest, opts ...grpc.CallOption) (*PaymentsByReceiptResponse, error)
 	//
 	// ListPayments returnes list of payment which were registered by the
-	// system.
+	// system. Payments are returned page by page if limit is specified.
 	ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*ListPaymentsResponse, error)
+	//
+	// SubscribePayments is used to receive notifications about payments.
+	// Every creation of the payment as well as every change of its status is
+	// sent in the stream.
+	SubscribePayments(ctx context.Context, in *SubscribePaymentsRequest, opts ...grpc.CallOption) (PayServer_SubscribePaymentsClient, error)
+	//
+	// BakeMacaroon creates new macaroon which grants the given permissions.
+	// Macaroon should be passed by the client in the request metadata,
+	// in order to be authorised to call the rpc methods.
+	BakeMacaroon(ctx context.Context, in *BakeMacaroonRequest, opts ...grpc.CallOption) (*BakeMacaroonResponse, error)
+	//
+	// GetInfo returns the lifecycle and sync state of every connector, as
+	// well as the state of the daemons behind them.
+	GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*GetInfoResponse, error)
+	//
+	// ListFailedNotifications returns webhook notifications which haven't
+	// been delivered after all attempts.
+	ListFailedNotifications(ctx context.Context, in *ListFailedNotificationsRequest, opts ...grpc.CallOption) (*ListFailedNotificationsResponse, error)
+	//
+	// ReplayNotifications schedules failed webhook notifications for the
+	// delivery once again.
+	ReplayNotifications(ctx context.Context, in *ReplayNotificationsRequest, opts ...grpc.CallOption) (*ReplayNotificationsResponse, error)
+	//
+	// GetFeeRates returns current fee rates of the priorities, which are
+	// estimated by the fee oracle of the blockchain connectors.
+	GetFeeRates(ctx context.Context, in *GetFeeRatesRequest, opts ...grpc.CallOption) (*GetFeeRatesResponse, error)
 }
 
 type payServerClient struct {
@@ -1151,36 +2559,203 @@ func (c *payServerClient) EstimateFee(ctx context.Context, in *EstimateFeeReques
 	return out, nil
 }
 
-func (c *payServerClient) SendPayment(ctx context.Context, in *SendPaymentRequest, opts ...grpc.CallOption) (*Payment, error) {
-	out := new(Payment)
-	err := grpc.Invoke(ctx, "/crpc.PayServer/SendPayment", in, out, c.cc, opts...)
+func (c *payServerClient) SendPayment(ctx context.Context, in *SendPaymentRequest, opts ...grpc.CallOption) (*Payment, error) {
+	out := new(Payment)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/SendPayment", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) SendPayments(ctx context.Context, in *SendPaymentsRequest, opts ...grpc.CallOption) (*SendPaymentsResponse, error) {
+	out := new(SendPaymentsResponse)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/SendPayments", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) CreatePayment(ctx context.Context, in *CreatePaymentRequest, opts ...grpc.CallOption) (*Payment, error) {
+	out := new(Payment)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/CreatePayment", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) ConfirmPayment(ctx context.Context, in *ConfirmPaymentRequest, opts ...grpc.CallOption) (*Payment, error) {
+	out := new(Payment)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/ConfirmPayment", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) CancelPayment(ctx context.Context, in *CancelPaymentRequest, opts ...grpc.CallOption) (*Payment, error) {
+	out := new(Payment)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/CancelPayment", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) BumpFee(ctx context.Context, in *BumpFeeRequest, opts ...grpc.CallOption) (*Payment, error) {
+	out := new(Payment)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/BumpFee", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) CreatePSBTPayment(ctx context.Context, in *CreatePSBTPaymentRequest, opts ...grpc.CallOption) (*Payment, error) {
+	out := new(Payment)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/CreatePSBTPayment", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) ExportPSBT(ctx context.Context, in *ExportPSBTRequest, opts ...grpc.CallOption) (*ExportPSBTResponse, error) {
+	out := new(ExportPSBTResponse)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/ExportPSBT", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) ImportPSBT(ctx context.Context, in *ImportPSBTRequest, opts ...grpc.CallOption) (*Payment, error) {
+	out := new(Payment)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/ImportPSBT", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) FinalizePSBT(ctx context.Context, in *FinalizePSBTRequest, opts ...grpc.CallOption) (*Payment, error) {
+	out := new(Payment)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/FinalizePSBT", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) ReorganiseInputs(ctx context.Context, in *ReorganiseInputsRequest, opts ...grpc.CallOption) (*ReorganiseInputsResponse, error) {
+	out := new(ReorganiseInputsResponse)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/ReorganiseInputs", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) PaymentByID(ctx context.Context, in *PaymentByIDRequest, opts ...grpc.CallOption) (*Payment, error) {
+	out := new(Payment)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/PaymentByID", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) PaymentsByReceipt(ctx context.Context, in *PaymentsByReceiptRequest, opts ...grpc.CallOption) (*PaymentsByReceiptResponse, error) {
+	out := new(PaymentsByReceiptResponse)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/PaymentsByReceipt", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*ListPaymentsResponse, error) {
+	out := new(ListPaymentsResponse)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/ListPayments", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) SubscribePayments(ctx context.Context, in *SubscribePaymentsRequest, opts ...grpc.CallOption) (PayServer_SubscribePaymentsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_PayServer_serviceDesc.Streams[0], c.cc, "/crpc.PayServer/SubscribePayments", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &payServerSubscribePaymentsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PayServer_SubscribePaymentsClient interface {
+	Recv() (*Payment, error)
+	grpc.ClientStream
+}
+
+type payServerSubscribePaymentsClient struct {
+	grpc.ClientStream
+}
+
+func (x *payServerSubscribePaymentsClient) Recv() (*Payment, error) {
+	m := new(Payment)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *payServerClient) BakeMacaroon(ctx context.Context, in *BakeMacaroonRequest, opts ...grpc.CallOption) (*BakeMacaroonResponse, error) {
+	out := new(BakeMacaroonResponse)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/BakeMacaroon", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *payServerClient) GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*GetInfoResponse, error) {
+	out := new(GetInfoResponse)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/GetInfo", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *payServerClient) PaymentByID(ctx context.Context, in *PaymentByIDRequest, opts ...grpc.CallOption) (*Payment, error) {
-	out := new(Payment)
-	err := grpc.Invoke(ctx, "/crpc.PayServer/PaymentByID", in, out, c.cc, opts...)
+func (c *payServerClient) ListFailedNotifications(ctx context.Context, in *ListFailedNotificationsRequest, opts ...grpc.CallOption) (*ListFailedNotificationsResponse, error) {
+	out := new(ListFailedNotificationsResponse)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/ListFailedNotifications", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *payServerClient) PaymentsByReceipt(ctx context.Context, in *PaymentsByReceiptRequest, opts ...grpc.CallOption) (*PaymentsByReceiptResponse, error) {
-	out := new(PaymentsByReceiptResponse)
-	err := grpc.Invoke(ctx, "/crpc.PayServer/PaymentsByReceipt", in, out, c.cc, opts...)
+func (c *payServerClient) ReplayNotifications(ctx context.Context, in *ReplayNotificationsRequest, opts ...grpc.CallOption) (*ReplayNotificationsResponse, error) {
+	out := new(ReplayNotificationsResponse)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/ReplayNotifications", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *payServerClient) ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*ListPaymentsResponse, error) {
-	out := new(ListPaymentsResponse)
-	err := grpc.Invoke(ctx, "/crpc.PayServer/ListPayments", in, out, c.cc, opts...)
+func (c *payServerClient) GetFeeRates(ctx context.Context, in *GetFeeRatesRequest, opts ...grpc.CallOption) (*GetFeeRatesResponse, error) {
+	out := new(GetFeeRatesResponse)
+	err := grpc.Invoke(ctx, "/crpc.PayServer/GetFeeRates", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1211,6 +2786,58 @@ type PayServerServer interface {
 	// account has enough money for doing that.
 	SendPayment(context.Context, *SendPaymentRequest) (*Payment, error)
 	//
+	// SendPayments sends blockchain payments to several recipients at
+	// once, either all of them are sent or none. Every receipt is validated
+	// before anything is spent. Payments are sent in one transaction if
+	// blockchain supports it, and are linked by the shared media id.
+	SendPayments(context.Context, *SendPaymentsRequest) (*SendPaymentsResponse, error)
+	//
+	// CreatePayment creates and signs the payment, but not sends it.
+	// Payment is stored with waiting status and the exact fee, so that
+	// it could be reviewed before being confirmed or canceled.
+	CreatePayment(context.Context, *CreatePaymentRequest) (*Payment, error)
+	//
+	// ConfirmPayment sends previously created waiting payment.
+	ConfirmPayment(context.Context, *ConfirmPaymentRequest) (*Payment, error)
+	//
+	// CancelPayment cancels previously created waiting payment, and
+	// releases resources reserved for it, such as locked unspent outputs
+	// or ethereum nonce.
+	CancelPayment(context.Context, *CancelPaymentRequest) (*Payment, error)
+	//
+	// BumpFee replaces the transaction of the pending blockchain payment
+	// with the transaction paying bigger fee, so that stuck payment would
+	// be confirmed faster. Only payments of the assets supporting
+	// replace-by-fee could be bumped.
+	BumpFee(context.Context, *BumpFeeRequest) (*Payment, error)
+	//
+	// CreatePSBTPayment creates the blockchain payment from the cold
+	// storage, i.e. from the outputs of the addresses derived from the
+	// extended public key. Payment is stored with waiting status and the
+	// exact fee, until its partially signed transaction is signed offline
+	// and finalized. Only BTC and LTC payments could be created.
+	CreatePSBTPayment(context.Context, *CreatePSBTPaymentRequest) (*Payment, error)
+	//
+	// ExportPSBT returns base64 encoded partially signed transaction of the
+	// waiting cold storage payment, which should be signed offline.
+	ExportPSBT(context.Context, *ExportPSBTRequest) (*ExportPSBTResponse, error)
+	//
+	// ImportPSBT imports the signatures of the partially signed transaction
+	// which has been signed offline into the waiting cold storage payment.
+	ImportPSBT(context.Context, *ImportPSBTRequest) (*Payment, error)
+	//
+	// FinalizePSBT finalizes the fully signed transaction of the waiting
+	// cold storage payment and sends it, after which payment is tracked as
+	// any other outgoing payment.
+	FinalizePSBT(context.Context, *FinalizePSBTRequest) (*Payment, error)
+	//
+	// ReorganiseInputs splits large unspent outputs of the wallet into the
+	// outputs of the optimal value, which are tracked as the internal
+	// payments. If preview is set, transaction is only estimated and isn't
+	// sent. Only outputs of the connectors with the full backend could be
+	// reorganised.
+	ReorganiseInputs(context.Context, *ReorganiseInputsRequest) (*ReorganiseInputsResponse, error)
+	//
 	// PaymentByID is used to fetch the information about payment, by the
 	// given system payment id.
 	PaymentByID(context.Context, *PaymentByIDRequest) (*Payment, error)
@@ -1220,8 +2847,34 @@ type PayServerServer interface {
 	PaymentsByReceipt(context.Context, *PaymentsByReceiptRequest) (*PaymentsByReceiptResponse, error)
 	//
 	// ListPayments returnes list of payment which were registered by the
-	// system.
+	// system. Payments are returned page by page if limit is specified.
 	ListPayments(context.Context, *ListPaymentsRequest) (*ListPaymentsResponse, error)
+	//
+	// SubscribePayments is used to receive notifications about payments.
+	// Every creation of the payment as well as every change of its status is
+	// sent in the stream.
+	SubscribePayments(*SubscribePaymentsRequest, PayServer_SubscribePaymentsServer) error
+	//
+	// BakeMacaroon creates new macaroon which grants the given permissions.
+	// Macaroon should be passed by the client in the request metadata,
+	// in order to be authorised to call the rpc methods.
+	BakeMacaroon(context.Context, *BakeMacaroonRequest) (*BakeMacaroonResponse, error)
+	//
+	// GetInfo returns the lifecycle and sync state of every connector, as
+	// well as the state of the daemons behind them.
+	GetInfo(context.Context, *GetInfoRequest) (*GetInfoResponse, error)
+	//
+	// ListFailedNotifications returns webhook notifications which haven't
+	// been delivered after all attempts.
+	ListFailedNotifications(context.Context, *ListFailedNotificationsRequest) (*ListFailedNotificationsResponse, error)
+	//
+	// ReplayNotifications schedules failed webhook notifications for the
+	// delivery once again.
+	ReplayNotifications(context.Context, *ReplayNotificationsRequest) (*ReplayNotificationsResponse, error)
+	//
+	// GetFeeRates returns current fee rates of the priorities, which are
+	// estimated by the fee oracle of the blockchain connectors.
+	GetFeeRates(context.Context, *GetFeeRatesRequest) (*GetFeeRatesResponse, error)
 }
 
 func RegisterPayServerServer(s *grpc.Server, srv PayServerServer) {
@@ -1318,6 +2971,186 @@ func _PayServer_SendPayment_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PayServer_SendPayments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendPaymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).SendPayments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/SendPayments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).SendPayments(ctx, req.(*SendPaymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_CreatePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).CreatePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/CreatePayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).CreatePayment(ctx, req.(*CreatePaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_ConfirmPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).ConfirmPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/ConfirmPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).ConfirmPayment(ctx, req.(*ConfirmPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_CancelPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).CancelPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/CancelPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).CancelPayment(ctx, req.(*CancelPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_BumpFee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BumpFeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).BumpFee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/BumpFee",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).BumpFee(ctx, req.(*BumpFeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_CreatePSBTPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePSBTPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).CreatePSBTPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/CreatePSBTPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).CreatePSBTPayment(ctx, req.(*CreatePSBTPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_ExportPSBT_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportPSBTRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).ExportPSBT(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/ExportPSBT",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).ExportPSBT(ctx, req.(*ExportPSBTRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_ImportPSBT_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportPSBTRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).ImportPSBT(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/ImportPSBT",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).ImportPSBT(ctx, req.(*ImportPSBTRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_FinalizePSBT_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FinalizePSBTRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).FinalizePSBT(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/FinalizePSBT",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).FinalizePSBT(ctx, req.(*FinalizePSBTRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_ReorganiseInputs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReorganiseInputsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).ReorganiseInputs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/ReorganiseInputs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).ReorganiseInputs(ctx, req.(*ReorganiseInputsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _PayServer_PaymentByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PaymentByIDRequest)
 	if err := dec(in); err != nil {
@@ -1372,6 +3205,117 @@ func _PayServer_ListPayments_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PayServer_SubscribePayments_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribePaymentsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PayServerServer).SubscribePayments(m, &payServerSubscribePaymentsServer{stream})
+}
+
+type PayServer_SubscribePaymentsServer interface {
+	Send(*Payment) error
+	grpc.ServerStream
+}
+
+type payServerSubscribePaymentsServer struct {
+	grpc.ServerStream
+}
+
+func (x *payServerSubscribePaymentsServer) Send(m *Payment) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PayServer_BakeMacaroon_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BakeMacaroonRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).BakeMacaroon(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/BakeMacaroon",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).BakeMacaroon(ctx, req.(*BakeMacaroonRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).GetInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/GetInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).GetInfo(ctx, req.(*GetInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_ListFailedNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFailedNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).ListFailedNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/ListFailedNotifications",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).ListFailedNotifications(ctx, req.(*ListFailedNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_ReplayNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplayNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).ReplayNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/ReplayNotifications",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).ReplayNotifications(ctx, req.(*ReplayNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PayServer_GetFeeRates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFeeRatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PayServerServer).GetFeeRates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crpc.PayServer/GetFeeRates",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PayServerServer).GetFeeRates(ctx, req.(*GetFeeRatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _PayServer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "crpc.PayServer",
 	HandlerType: (*PayServerServer)(nil),
@@ -1396,6 +3340,46 @@ var _PayServer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SendPayment",
 			Handler:    _PayServer_SendPayment_Handler,
 		},
+		{
+			MethodName: "SendPayments",
+			Handler:    _PayServer_SendPayments_Handler,
+		},
+		{
+			MethodName: "CreatePayment",
+			Handler:    _PayServer_CreatePayment_Handler,
+		},
+		{
+			MethodName: "ConfirmPayment",
+			Handler:    _PayServer_ConfirmPayment_Handler,
+		},
+		{
+			MethodName: "CancelPayment",
+			Handler:    _PayServer_CancelPayment_Handler,
+		},
+		{
+			MethodName: "BumpFee",
+			Handler:    _PayServer_BumpFee_Handler,
+		},
+		{
+			MethodName: "CreatePSBTPayment",
+			Handler:    _PayServer_CreatePSBTPayment_Handler,
+		},
+		{
+			MethodName: "ExportPSBT",
+			Handler:    _PayServer_ExportPSBT_Handler,
+		},
+		{
+			MethodName: "ImportPSBT",
+			Handler:    _PayServer_ImportPSBT_Handler,
+		},
+		{
+			MethodName: "FinalizePSBT",
+			Handler:    _PayServer_FinalizePSBT_Handler,
+		},
+		{
+			MethodName: "ReorganiseInputs",
+			Handler:    _PayServer_ReorganiseInputs_Handler,
+		},
 		{
 			MethodName: "PaymentByID",
 			Handler:    _PayServer_PaymentByID_Handler,
@@ -1408,79 +3392,197 @@ var _PayServer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ListPayments",
 			Handler:    _PayServer_ListPayments_Handler,
 		},
+		{
+			MethodName: "BakeMacaroon",
+			Handler:    _PayServer_BakeMacaroon_Handler,
+		},
+		{
+			MethodName: "GetInfo",
+			Handler:    _PayServer_GetInfo_Handler,
+		},
+		{
+			MethodName: "ListFailedNotifications",
+			Handler:    _PayServer_ListFailedNotifications_Handler,
+		},
+		{
+			MethodName: "ReplayNotifications",
+			Handler:    _PayServer_ReplayNotifications_Handler,
+		},
+		{
+			MethodName: "GetFeeRates",
+			Handler:    _PayServer_GetFeeRates_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribePayments",
+			Handler:       _PayServer_SubscribePayments_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc.proto",
 }
 
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1048 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x57, 0xcd, 0x72, 0x9b, 0x56,
-	0x14, 0x0e, 0x02, 0xfd, 0x70, 0xf4, 0x63, 0x7a, 0xed, 0xb8, 0x58, 0x49, 0x5a, 0x97, 0x6e, 0x52,
-	0x77, 0xc6, 0x0b, 0x27, 0x93, 0x55, 0x36, 0x48, 0xc2, 0x16, 0x53, 0x09, 0x79, 0x10, 0x4e, 0xdb,
-	0x95, 0xe6, 0x1a, 0x6e, 0x3a, 0x4c, 0x25, 0x44, 0x01, 0x79, 0xaa, 0x27, 0xc8, 0xa6, 0x8b, 0xae,
-	0xfa, 0x18, 0xdd, 0xf6, 0xa1, 0xfa, 0x12, 0x1d, 0xee, 0xbd, 0x08, 0x90, 0xe4, 0xb1, 0x33, 0x93,
-	0x69, 0x76, 0x9c, 0xf3, 0x9d, 0x73, 0xf4, 0x9d, 0x3f, 0x0e, 0x02, 0x39, 0x0a, 0xdd, 0xf3, 0x30,
-	0x5a, 0x26, 0x4b, 0x24, 0xb9, 0x51, 0xe8, 0x6a, 0x1d, 0x68, 0x19, 0x8b, 0x30, 0x59, 0xdb, 0xe4,
-	0xb7, 0x15, 0x89, 0x13, 0xed, 0x00, 0xda, 0x5c, 0x8e, 0xc3, 0x65, 0x10, 0x13, 0xed, 0x2f, 0x01,
-	0x8e, 0xfa, 0x11, 0xc1, 0x09, 0xb1, 0x89, 0x4b, 0xfc, 0x30, 0xe1, 0x96, 0xe8, 0x1b, 0xa8, 0xe2,
-	0x38, 0x26, 0x89, 0x2a, 0x9c, 0x0a, 0x2f, 0x3b, 0x17, 0xcd, 0xf3, 0x34, 0xde, 0xb9, 0x9e, 0xaa,
-	0x6c, 0x86, 0xa4, 0x26, 0x0b, 0xe2, 0xf9, 0x58, 0xad, 0x14, 0x4d, 0xc6, 0xa9, 0xca, 0x66, 0x08,
-	0x3a, 0x86, 0x1a, 0x5e, 0x2c, 0x57, 0x41, 0xa2, 0x8a, 0xa7, 0xc2, 0x4b, 0xd9, 0xe6, 0x12, 0x3a,
-	0x85, 0xa6, 0x47, 0x62, 0x37, 0xf2, 0xc3, 0xc4, 0x5f, 0x06, 0xaa, 0x44, 0xc1, 0xa2, 0x4a, 0x0b,
-	0xe0, 0xe9, 0x16, 0x2f, 0xc6, 0x18, 0x7d, 0x0b, 0x6d, 0x37, 0x05, 0xfc, 0x65, 0x30, 0xf3, 0x70,
-	0x42, 0x28, 0x41, 0xd1, 0x6e, 0x65, 0xca, 0x01, 0x4e, 0x08, 0x52, 0xa1, 0x1e, 0x31, 0x3f, 0x4a,
-	0x4e, 0xb6, 0x33, 0x31, 0x65, 0x44, 0x7e, 0x0f, 0xfd, 0x68, 0x4d, 0x19, 0x89, 0x36, 0x97, 0xb4,
-	0x77, 0xd0, 0xe9, 0xe1, 0x39, 0x0e, 0x5c, 0xf2, 0x49, 0x2b, 0xa0, 0x7d, 0x10, 0xa0, 0xce, 0x03,
-	0xa3, 0xe7, 0x20, 0xe3, 0x3b, 0xec, 0xcf, 0xf1, 0xed, 0x9c, 0xd1, 0x96, 0xed, 0x5c, 0x91, 0x72,
-	0x0e, 0x49, 0xe0, 0xf9, 0xc1, 0x2f, 0x19, 0x67, 0x2e, 0xe6, 0x4c, 0xc4, 0x87, 0x99, 0x48, 0xf7,
-	0x32, 0x19, 0xc1, 0x97, 0xef, 0xf0, 0xdc, 0xf7, 0xf6, 0xd4, 0xf4, 0x3b, 0xa8, 0xfb, 0xc1, 0xdd,
-	0xd2, 0x77, 0x19, 0xad, 0xe6, 0x45, 0x9b, 0xf9, 0x9b, 0x4c, 0x39, 0x7c, 0x62, 0x67, 0x78, 0xaf,
-	0x06, 0x92, 0x87, 0x13, 0xac, 0xfd, 0x23, 0x40, 0x9d, 0xc3, 0x08, 0x81, 0xb4, 0x20, 0x8b, 0x25,
-	0x4f, 0x89, 0x3e, 0xa3, 0x23, 0xa8, 0xde, 0xe1, 0xf9, 0x8a, 0xf0, 0x5c, 0x98, 0xb0, 0xdb, 0x3c,
-	0x71, 0x4f, 0xf3, 0xf2, 0x16, 0x49, 0xc5, 0x16, 0xa5, 0xce, 0xef, 0xf1, 0x7c, 0x7e, 0x8b, 0xdd,
-	0x5f, 0x67, 0xd8, 0xf3, 0x22, 0xb5, 0x4a, 0x43, 0xb7, 0x32, 0xa5, 0xee, 0x79, 0x11, 0x9f, 0xac,
-	0xc4, 0x0f, 0x68, 0x3c, 0xb5, 0xb6, 0x99, 0xac, 0x4c, 0xa5, 0xbd, 0x85, 0x83, 0x4d, 0xa7, 0x37,
-	0xf9, 0x37, 0x6e, 0x99, 0x2a, 0x56, 0x85, 0x53, 0x31, 0x2f, 0x40, 0x66, 0xb8, 0x81, 0xb5, 0x3f,
-	0x05, 0x38, 0xde, 0x29, 0x23, 0x1b, 0x98, 0xc2, 0xd0, 0x09, 0xe5, 0xa1, 0xdb, 0x34, 0xb0, 0xf2,
-	0x70, 0x03, 0xc5, 0x47, 0x2c, 0x93, 0x54, 0x5c, 0x26, 0xed, 0x0f, 0x01, 0x90, 0x11, 0x27, 0xfe,
-	0x02, 0x27, 0xe4, 0x92, 0x90, 0xff, 0x67, 0x83, 0x0b, 0xc9, 0x4a, 0xa5, 0x64, 0xb5, 0x0b, 0x38,
-	0x2c, 0xb1, 0xe1, 0x35, 0x7e, 0x06, 0x32, 0x8d, 0x38, 0x7b, 0x4f, 0xb2, 0xe1, 0x6f, 0x50, 0xc5,
-	0x25, 0x21, 0x34, 0x85, 0x29, 0x09, 0xbc, 0x6b, 0xbc, 0x5e, 0x90, 0x20, 0xf9, 0xdc, 0x29, 0xbc,
-	0x02, 0xc4, 0x99, 0xf4, 0xd6, 0xe6, 0x20, 0x63, 0xf3, 0x02, 0x20, 0x64, 0xda, 0x99, 0xef, 0x65,
-	0xfb, 0xcb, 0x35, 0xa6, 0xa7, 0xbd, 0x06, 0x95, 0x3b, 0xc5, 0xbd, 0xf5, 0x63, 0x47, 0x43, 0xbb,
-	0x84, 0x93, 0x3d, 0x5e, 0xf9, 0x5c, 0xf2, 0xf8, 0x5b, 0x73, 0x99, 0xd5, 0x69, 0x03, 0x6b, 0xff,
-	0x0a, 0x70, 0x38, 0xf2, 0xe3, 0x24, 0x0b, 0x96, 0xfd, 0xf2, 0xf7, 0x50, 0x8b, 0x13, 0x9c, 0xac,
-	0x62, 0x5e, 0xc3, 0xc3, 0x52, 0x80, 0x29, 0x85, 0x6c, 0x6e, 0x82, 0x5e, 0x83, 0xec, 0xf9, 0x11,
-	0x71, 0xe9, 0xea, 0xb0, 0x82, 0x1e, 0x97, 0xec, 0x07, 0x19, 0x6a, 0xe7, 0x86, 0x9f, 0xe6, 0xf5,
-	0x44, 0x89, 0xae, 0xe3, 0x84, 0x2c, 0xe8, 0x5a, 0xef, 0x10, 0xa5, 0x90, 0xcd, 0x4d, 0x34, 0x1d,
-	0x8e, 0xca, 0xc9, 0x7e, 0x7c, 0xc1, 0x3e, 0x88, 0x50, 0xe7, 0xda, 0x07, 0x3a, 0x9b, 0xc2, 0xab,
-	0x30, 0x5d, 0x78, 0x6f, 0x86, 0xd9, 0x0e, 0x8b, 0xb6, 0xcc, 0x35, 0x7a, 0xb1, 0xc4, 0xe2, 0x47,
-	0x96, 0x58, 0x7a, 0x6c, 0x89, 0xf3, 0xe2, 0x34, 0x1f, 0x2c, 0x4e, 0xde, 0x8f, 0xea, 0xbd, 0xfd,
-	0x28, 0xcc, 0x63, 0xad, 0xfc, 0xaa, 0x3a, 0x01, 0xb6, 0x95, 0x69, 0x21, 0xea, 0x0c, 0xa2, 0xb2,
-	0xe9, 0xe5, 0x4d, 0x6c, 0x3c, 0x62, 0xd5, 0xe4, 0xd2, 0xaa, 0x95, 0x96, 0x1f, 0xca, 0xcb, 0x7f,
-	0x66, 0x40, 0x95, 0x92, 0x43, 0x1d, 0x00, 0x7d, 0x3a, 0x35, 0x9c, 0x99, 0x35, 0xb1, 0x0c, 0xe5,
-	0x09, 0xaa, 0x83, 0xd8, 0x73, 0xfa, 0x8a, 0x40, 0x1f, 0xfa, 0x43, 0xa5, 0x92, 0x3e, 0x18, 0xce,
-	0x50, 0x11, 0xd3, 0x87, 0x91, 0xd3, 0x57, 0x24, 0xd4, 0x00, 0x69, 0xa0, 0x4f, 0x87, 0x4a, 0xf5,
-	0xec, 0x0d, 0x54, 0x29, 0x97, 0x34, 0xcc, 0xd8, 0x18, 0x98, 0x7a, 0x16, 0xa6, 0x03, 0xd0, 0x1b,
-	0x4d, 0xfa, 0x3f, 0xf4, 0x87, 0xba, 0x69, 0x29, 0x02, 0x6a, 0x83, 0x3c, 0x32, 0xaf, 0x86, 0x8e,
-	0x65, 0x5a, 0x57, 0x4a, 0xe5, 0xec, 0x06, 0xda, 0xa5, 0x56, 0xa1, 0x03, 0x68, 0x4e, 0x1d, 0xdd,
-	0xb9, 0x99, 0x66, 0x01, 0x9a, 0x50, 0xff, 0x51, 0x37, 0x9d, 0xd4, 0x5c, 0x48, 0x85, 0x6b, 0xc3,
-	0x1a, 0x50, 0xdf, 0x34, 0x54, 0x7f, 0x32, 0xbe, 0x1e, 0x19, 0x8e, 0x31, 0x50, 0x44, 0x04, 0x50,
-	0xbb, 0xd4, 0xcd, 0x91, 0x31, 0x50, 0xa4, 0xb3, 0x1e, 0x28, 0xdb, 0x1d, 0x45, 0x08, 0x3a, 0x03,
-	0xd3, 0x36, 0xfa, 0x8e, 0x39, 0xb1, 0xb2, 0xe0, 0x2d, 0x68, 0x98, 0x56, 0x7f, 0x32, 0x66, 0xd1,
-	0x5b, 0xd0, 0x98, 0xdc, 0x38, 0x57, 0x13, 0x46, 0xed, 0x6d, 0x4e, 0x8d, 0xb5, 0x36, 0xa5, 0xf6,
-	0xf3, 0xd4, 0x31, 0xc6, 0x25, 0x6f, 0xc7, 0xb0, 0x2d, 0x7d, 0xc4, 0xbc, 0x8d, 0x9f, 0xb8, 0x54,
-	0xb9, 0xf8, 0x5b, 0x02, 0xf9, 0x1a, 0xaf, 0xa7, 0x24, 0xba, 0x23, 0x11, 0x1a, 0x42, 0xbb, 0xf4,
-	0x41, 0x85, 0xba, 0xac, 0x7f, 0xfb, 0xbe, 0xfe, 0xba, 0xcf, 0xf6, 0x62, 0x7c, 0xc9, 0x2c, 0x38,
-	0xd8, 0xba, 0x80, 0xe8, 0x39, 0xb3, 0xdf, 0x7f, 0x18, 0xbb, 0x2f, 0xee, 0x41, 0x79, 0xbc, 0x37,
-	0xf9, 0x17, 0xd2, 0x51, 0xf9, 0xec, 0x72, 0xff, 0xa7, 0x5b, 0x5a, 0xee, 0xd7, 0x83, 0x66, 0xe1,
-	0xd0, 0x20, 0x95, 0x59, 0xed, 0x5e, 0xc2, 0xee, 0xc9, 0x1e, 0x64, 0xf3, 0xdb, 0xcd, 0xc2, 0xdd,
-	0xc9, 0x62, 0xec, 0x9e, 0xa2, 0x6e, 0xf9, 0x3d, 0x92, 0xfa, 0x15, 0x2e, 0x44, 0xe6, 0xb7, 0x7b,
-	0x34, 0xb6, 0xfd, 0x1c, 0xf8, 0x62, 0xe7, 0x75, 0x8f, 0xbe, 0x2a, 0xd9, 0xec, 0x5c, 0x8f, 0xee,
-	0xd7, 0xf7, 0xe2, 0x3c, 0x0b, 0x03, 0x5a, 0xc5, 0xd7, 0x21, 0xe2, 0x09, 0xef, 0xb9, 0x07, 0xdd,
-	0xee, 0x3e, 0x88, 0x85, 0xb9, 0xad, 0xd1, 0xbf, 0x0e, 0xaf, 0xfe, 0x0b, 0x00, 0x00, 0xff, 0xff,
-	0xeb, 0x68, 0xcc, 0x2b, 0x47, 0x0c, 0x00, 0x00,
+	// 2524 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x19, 0xdb, 0x72, 0x1b, 0x49,
+	0x75, 0xa5, 0x91, 0x2c, 0xe9, 0xe8, 0x62, 0xb9, 0xed, 0x24, 0xb2, 0xb2, 0x49, 0x9c, 0x59, 0x02,
+	0xc1, 0x5b, 0x98, 0xc5, 0x09, 0xd9, 0xad, 0xad, 0x50, 0x94, 0x24, 0x8f, 0x63, 0xed, 0xda, 0x92,
+	0x69, 0x29, 0xd9, 0x0d, 0x3c, 0x88, 0xf1, 0x4c, 0x2b, 0x99, 0x8a, 0x34, 0x23, 0x66, 0x5a, 0xc6,
+	0xda, 0x1f, 0xe0, 0x85, 0x07, 0x8a, 0x07, 0x8a, 0x3f, 0xd9, 0x07, 0x8a, 0x27, 0x7e, 0x81, 0x2a,
+	0x7e, 0x00, 0xde, 0xf9, 0x04, 0xaa, 0x6f, 0xd2, 0xcc, 0x68, 0xe4, 0x0b, 0xa4, 0xa0, 0x78, 0xeb,
+	0x3e, 0xb7, 0x39, 0x7d, 0x6e, 0x7d, 0xfa, 0x0c, 0x14, 0xfc, 0x89, 0xb5, 0x37, 0xf1, 0x3d, 0xea,
+	0xa1, 0x8c, 0xe5, 0x4f, 0x2c, 0xbd, 0x02, 0x25, 0x63, 0x3c, 0xa1, 0x33, 0x4c, 0x7e, 0x35, 0x25,
+	0x01, 0xd5, 0xd7, 0xa1, 0x2c, 0xf7, 0xc1, 0xc4, 0x73, 0x03, 0xa2, 0xff, 0x21, 0x05, 0x5b, 0x2d,
+	0x9f, 0x98, 0x94, 0x60, 0x62, 0x11, 0x67, 0x42, 0x25, 0x25, 0x7a, 0x08, 0x59, 0x33, 0x08, 0x08,
+	0xad, 0xa5, 0x76, 0x52, 0x8f, 0x2b, 0xfb, 0xc5, 0x3d, 0x26, 0x6f, 0xaf, 0xc1, 0x40, 0x58, 0x60,
+	0x18, 0xc9, 0x98, 0xd8, 0x8e, 0x59, 0x4b, 0x87, 0x49, 0x4e, 0x18, 0x08, 0x0b, 0x0c, 0xba, 0x0d,
+	0x6b, 0xe6, 0xd8, 0x9b, 0xba, 0xb4, 0xa6, 0xed, 0xa4, 0x1e, 0x17, 0xb0, 0xdc, 0xa1, 0x1d, 0x28,
+	0xda, 0x24, 0xb0, 0x7c, 0x67, 0x42, 0x1d, 0xcf, 0xad, 0x65, 0x38, 0x32, 0x0c, 0xd2, 0x5d, 0xb8,
+	0x15, 0xd3, 0x4b, 0x68, 0x8c, 0x3e, 0x82, 0xb2, 0xc5, 0x10, 0x8e, 0xe7, 0x0e, 0x6c, 0x93, 0x12,
+	0xae, 0xa0, 0x86, 0x4b, 0x0a, 0x78, 0x60, 0x52, 0x82, 0x6a, 0x90, 0xf3, 0x05, 0x1f, 0x57, 0xae,
+	0x80, 0xd5, 0x96, 0x69, 0x44, 0x2e, 0x26, 0x8e, 0x3f, 0xe3, 0x1a, 0x69, 0x58, 0xee, 0xf4, 0x57,
+	0x50, 0x69, 0x9a, 0x23, 0xd3, 0xb5, 0xc8, 0x7b, 0xb5, 0x80, 0xfe, 0x9b, 0x14, 0xe4, 0xa4, 0x60,
+	0xf4, 0x21, 0x14, 0xcc, 0x73, 0xd3, 0x19, 0x99, 0x67, 0x23, 0xa1, 0x76, 0x01, 0x2f, 0x00, 0x4c,
+	0xe7, 0x09, 0x71, 0x6d, 0xc7, 0x7d, 0xa3, 0x74, 0x96, 0xdb, 0x85, 0x26, 0xda, 0xd5, 0x9a, 0x64,
+	0x56, 0x6a, 0x72, 0x0c, 0x77, 0x5e, 0x99, 0x23, 0xc7, 0x4e, 0xb0, 0xe9, 0xf7, 0x21, 0xe7, 0xb8,
+	0xe7, 0x9e, 0x63, 0x09, 0xb5, 0x8a, 0xfb, 0x65, 0xc1, 0xdf, 0x16, 0xc0, 0xa3, 0x0f, 0xb0, 0xc2,
+	0x37, 0xd7, 0x20, 0x63, 0x9b, 0xd4, 0xd4, 0xbf, 0x4d, 0x41, 0x4e, 0xa2, 0x11, 0x82, 0xcc, 0x98,
+	0x8c, 0x3d, 0x79, 0x24, 0xbe, 0x46, 0x5b, 0x90, 0x3d, 0x37, 0x47, 0x53, 0x22, 0xcf, 0x22, 0x36,
+	0xcb, 0xce, 0xd3, 0x12, 0x9c, 0xb7, 0x70, 0x51, 0x26, 0xec, 0x22, 0xc6, 0x3c, 0x34, 0x47, 0xa3,
+	0x33, 0xd3, 0x7a, 0x37, 0x30, 0x6d, 0xdb, 0xaf, 0x65, 0xb9, 0xe8, 0x92, 0x02, 0x36, 0x6c, 0xdb,
+	0x97, 0x91, 0x45, 0x1d, 0x97, 0xcb, 0xab, 0xad, 0xcd, 0x23, 0x4b, 0x81, 0xf4, 0xe7, 0xb0, 0x3e,
+	0xf7, 0xf4, 0xfc, 0xfc, 0xf9, 0x33, 0x01, 0x0a, 0x6a, 0xa9, 0x1d, 0x6d, 0x61, 0x00, 0x45, 0x38,
+	0x47, 0xeb, 0xbf, 0x4b, 0xc1, 0xed, 0x25, 0x33, 0x8a, 0x80, 0x09, 0x05, 0x5d, 0x2a, 0x1a, 0x74,
+	0x73, 0x07, 0xa6, 0xaf, 0x76, 0xa0, 0x76, 0x8d, 0x64, 0xca, 0x84, 0x93, 0x49, 0xff, 0x53, 0x0a,
+	0x90, 0x11, 0x50, 0x67, 0x6c, 0x52, 0x72, 0x48, 0xc8, 0x7f, 0x27, 0x83, 0x43, 0x87, 0xcd, 0x44,
+	0x0f, 0xfb, 0x03, 0xc8, 0x4f, 0x7c, 0xc7, 0xf3, 0x1d, 0x3a, 0xe3, 0x1e, 0xaa, 0xec, 0x6f, 0x08,
+	0xb9, 0x87, 0x84, 0x9c, 0x4a, 0x04, 0x9e, 0x93, 0xe8, 0xbf, 0x84, 0xcd, 0x88, 0xf2, 0xd2, 0x25,
+	0x77, 0xa1, 0xc0, 0x15, 0x18, 0x0c, 0x89, 0xca, 0x95, 0x3c, 0x07, 0x1c, 0x12, 0x1e, 0x21, 0x8e,
+	0x3b, 0x99, 0xd2, 0x80, 0x2b, 0x9e, 0xc5, 0x72, 0xc7, 0x83, 0x2e, 0x70, 0xbe, 0x11, 0x61, 0x95,
+	0xc5, 0x62, 0xa3, 0xff, 0x3d, 0x05, 0xa8, 0x47, 0x5c, 0xfb, 0xd4, 0x9c, 0x8d, 0x89, 0x4b, 0xff,
+	0xd7, 0xf6, 0xf9, 0x1e, 0xac, 0x3b, 0x36, 0x19, 0x4f, 0x3c, 0x4a, 0x5c, 0x6b, 0x36, 0x78, 0x47,
+	0x66, 0x32, 0x90, 0x2b, 0x21, 0xf0, 0x97, 0x64, 0x16, 0x31, 0xe4, 0xda, 0xd5, 0x86, 0xfc, 0x09,
+	0x14, 0x30, 0xb1, 0x9c, 0x89, 0x43, 0xdc, 0xcb, 0x62, 0x71, 0xa1, 0x70, 0x3a, 0x12, 0x45, 0xbf,
+	0x4f, 0xc1, 0x66, 0xc8, 0x4a, 0xc1, 0x0d, 0xcc, 0xf4, 0x43, 0x00, 0x5f, 0x7d, 0x99, 0xb9, 0x84,
+	0x25, 0xd0, 0xba, 0xa0, 0x9b, 0x6b, 0x84, 0x43, 0x24, 0x49, 0x26, 0xd0, 0x92, 0x4c, 0xa0, 0x37,
+	0x60, 0x2b, 0xaa, 0xd3, 0x22, 0x61, 0x27, 0x12, 0x16, 0x4d, 0x58, 0xe5, 0xe3, 0x39, 0x5a, 0xff,
+	0x76, 0x7e, 0xc3, 0xfd, 0x9f, 0xf9, 0x5f, 0x7f, 0x06, 0xb7, 0x5a, 0x9e, 0x3b, 0x74, 0xfc, 0x71,
+	0x4c, 0xf3, 0x7b, 0x00, 0xf2, 0x78, 0x03, 0xc7, 0x56, 0x17, 0x89, 0x84, 0xb4, 0x6d, 0xfd, 0xc7,
+	0xb0, 0xd5, 0x62, 0xc5, 0x6a, 0x74, 0x33, 0xb6, 0x2f, 0xa0, 0xd2, 0x9c, 0x8e, 0x27, 0xa1, 0x0a,
+	0x72, 0x39, 0x03, 0xda, 0x86, 0xfc, 0x90, 0x90, 0x81, 0xcf, 0xea, 0xb8, 0xbc, 0xb1, 0x86, 0x84,
+	0x60, 0x93, 0x12, 0xfd, 0x8f, 0x29, 0xa8, 0x49, 0xa3, 0xf7, 0x9a, 0xfd, 0x9b, 0x1b, 0x7e, 0x45,
+	0x90, 0x86, 0xad, 0xaa, 0x5d, 0x69, 0xd5, 0x4c, 0xa2, 0x55, 0xf7, 0x61, 0xc3, 0xb8, 0x98, 0x78,
+	0x3e, 0x65, 0x9a, 0x5d, 0xd3, 0x34, 0x8f, 0x01, 0x85, 0x79, 0x64, 0x10, 0x22, 0xc8, 0x4c, 0x82,
+	0x33, 0x95, 0x60, 0x7c, 0xad, 0x1f, 0xc2, 0x46, 0x7b, 0x7c, 0x33, 0xe9, 0x73, 0x39, 0xe9, 0x90,
+	0x9c, 0xa7, 0xb0, 0x79, 0xe8, 0xb8, 0xe6, 0xc8, 0xf9, 0x86, 0xdc, 0x40, 0xcf, 0x57, 0x70, 0x07,
+	0x13, 0xcf, 0x7f, 0x63, 0xba, 0x4e, 0x40, 0xda, 0xbc, 0x26, 0xde, 0xc0, 0xe8, 0xac, 0x01, 0xf1,
+	0xc9, 0xb9, 0x43, 0x7e, 0xcd, 0x55, 0xc9, 0x63, 0xb5, 0xd5, 0xff, 0x92, 0x82, 0xda, 0xb2, 0x60,
+	0x69, 0x86, 0x4d, 0xc8, 0xd2, 0x8b, 0x85, 0x3a, 0x19, 0x7a, 0xd1, 0xb6, 0x57, 0x56, 0xe8, 0x8f,
+	0xa0, 0x2c, 0x56, 0x83, 0x48, 0xd6, 0x94, 0x04, 0xb0, 0x31, 0xf7, 0xb2, 0x37, 0xa5, 0x9c, 0x3b,
+	0xb3, 0xa3, 0x31, 0x2f, 0xcb, 0x2d, 0xaa, 0x82, 0xc6, 0xee, 0x03, 0x91, 0x2f, 0x6c, 0x19, 0xa9,
+	0x04, 0x6b, 0x97, 0x57, 0x82, 0x27, 0x80, 0x24, 0xb0, 0x39, 0x6b, 0x1f, 0x5c, 0xd3, 0xa4, 0x4f,
+	0xa1, 0x26, 0x99, 0x82, 0xe6, 0xec, 0xba, 0x17, 0xbe, 0x7e, 0x08, 0xdb, 0x09, 0x5c, 0x37, 0x2f,
+	0x5e, 0x55, 0xa8, 0xbc, 0x20, 0xb4, 0xed, 0x0e, 0x3d, 0xd5, 0xc1, 0x7f, 0x0d, 0xeb, 0x73, 0x88,
+	0x94, 0x57, 0x05, 0xcd, 0x25, 0x4a, 0x05, 0xb6, 0x44, 0x4f, 0x00, 0x2c, 0xcf, 0x75, 0x89, 0x45,
+	0x3d, 0x5f, 0x15, 0xe4, 0x4d, 0xf1, 0x8d, 0x96, 0x82, 0x73, 0x11, 0x21, 0x32, 0xfd, 0xcf, 0x1a,
+	0x94, 0x23, 0xd8, 0xf7, 0x54, 0x21, 0x77, 0x21, 0x1b, 0x50, 0xd5, 0xeb, 0x55, 0xf6, 0xb7, 0x62,
+	0x7a, 0xf4, 0x18, 0x0e, 0x0b, 0x12, 0xf4, 0x00, 0x8a, 0x01, 0x35, 0x7d, 0x3a, 0x20, 0xbe, 0xef,
+	0xf9, 0x32, 0x83, 0x81, 0x83, 0x0c, 0x06, 0x41, 0x0f, 0xa1, 0x64, 0x9b, 0x64, 0xec, 0xb9, 0x92,
+	0x22, 0x2b, 0xfb, 0x3b, 0x0e, 0x13, 0x24, 0xd2, 0x1c, 0x6b, 0x0b, 0x73, 0x7c, 0x0c, 0x1b, 0x63,
+	0xc7, 0x1d, 0x58, 0xa2, 0x98, 0xf2, 0x2e, 0x30, 0xa8, 0xe5, 0x78, 0x5c, 0x56, 0xc7, 0x8e, 0xdb,
+	0x0a, 0xc3, 0xd1, 0x23, 0xa8, 0xc8, 0x2f, 0x9c, 0x13, 0x3f, 0x60, 0x3d, 0x64, 0x9e, 0x4b, 0x2a,
+	0x0b, 0xe8, 0x2b, 0x01, 0x64, 0x9a, 0x9e, 0x91, 0x80, 0x0e, 0xde, 0x12, 0xe7, 0xcd, 0x5b, 0x5a,
+	0x2b, 0xf0, 0x4e, 0x15, 0x18, 0xe8, 0x88, 0x43, 0x58, 0xa4, 0x07, 0x33, 0xd7, 0x22, 0xb6, 0x22,
+	0x01, 0xd1, 0xea, 0x0a, 0xa0, 0x24, 0xfa, 0x11, 0x14, 0x46, 0x6c, 0xe1, 0xb2, 0xae, 0xbf, 0xb4,
+	0x93, 0x5a, 0xf8, 0xe9, 0x58, 0x81, 0xb9, 0x9f, 0x16, 0x54, 0x5f, 0x64, 0xf2, 0xc5, 0x6a, 0x09,
+	0x6f, 0x48, 0xd9, 0xf4, 0x62, 0x60, 0xb1, 0x9c, 0x21, 0xbe, 0xfe, 0xdb, 0x34, 0x94, 0x23, 0x5c,
+	0x2c, 0x09, 0x27, 0xd3, 0x33, 0x56, 0x0a, 0x45, 0x6c, 0xc8, 0x1d, 0x6b, 0x93, 0xcc, 0x91, 0x63,
+	0x06, 0xaa, 0x37, 0xe7, 0x1b, 0x56, 0x86, 0xde, 0x7a, 0x81, 0xca, 0x48, 0xbe, 0x66, 0x30, 0x56,
+	0xcc, 0xa4, 0x23, 0xf8, 0x1a, 0x7d, 0x02, 0x5b, 0xee, 0x74, 0x3c, 0x90, 0x8f, 0x93, 0x81, 0xf5,
+	0xd6, 0x74, 0x5d, 0x32, 0x0a, 0xb8, 0x2b, 0xca, 0x18, 0xb9, 0xd3, 0xf1, 0xa9, 0x40, 0xb5, 0x24,
+	0x06, 0xed, 0xc1, 0x26, 0xe3, 0x30, 0x2d, 0xea, 0x9c, 0x93, 0x05, 0xc3, 0x1a, 0x67, 0xd8, 0x70,
+	0xa7, 0xe3, 0x06, 0xc7, 0xcc, 0xe9, 0xef, 0x42, 0x41, 0x7c, 0x81, 0xf8, 0xc2, 0x4f, 0x65, 0x9c,
+	0xe7, 0x62, 0x89, 0x1f, 0xa0, 0xef, 0xc2, 0xba, 0x3a, 0xbb, 0xc7, 0x64, 0x39, 0xc2, 0x41, 0x79,
+	0x2c, 0xcd, 0xdd, 0xf7, 0x5a, 0x0c, 0xa8, 0x7f, 0x0a, 0x9b, 0x4d, 0xf3, 0x1d, 0x39, 0x31, 0x2d,
+	0xd3, 0xf7, 0x3c, 0x57, 0xe5, 0xec, 0x0e, 0x14, 0x27, 0xc4, 0x1f, 0x3b, 0x41, 0xc0, 0xa3, 0x20,
+	0xc5, 0xeb, 0x4b, 0x18, 0xa4, 0xef, 0xc3, 0x56, 0x94, 0x51, 0xa6, 0x59, 0x1d, 0xf2, 0x63, 0x09,
+	0x9b, 0x37, 0xa4, 0x72, 0xaf, 0xef, 0xc0, 0xfd, 0x63, 0x27, 0xa0, 0x87, 0xa6, 0x33, 0x22, 0x76,
+	0xc7, 0xa3, 0xce, 0xd0, 0xb1, 0x44, 0x3c, 0xa9, 0xbc, 0xfd, 0x05, 0x3c, 0x58, 0x49, 0x21, 0x3f,
+	0xf0, 0x19, 0x94, 0xdd, 0x30, 0x42, 0x16, 0x07, 0x24, 0x02, 0x22, 0xcc, 0x83, 0xa3, 0x84, 0xfa,
+	0x1e, 0xd4, 0x31, 0x99, 0x8c, 0xcc, 0x59, 0xd2, 0xa7, 0x59, 0x42, 0x38, 0xb6, 0x3a, 0x2a, 0x5b,
+	0xea, 0x5f, 0xc1, 0xdd, 0x44, 0xfa, 0xff, 0x58, 0x91, 0x4f, 0x01, 0xbd, 0x20, 0xf4, 0x50, 0x74,
+	0x01, 0x37, 0xb8, 0x7b, 0xf4, 0xcf, 0x61, 0x33, 0xc2, 0x38, 0x7f, 0xec, 0x67, 0x7d, 0x06, 0x88,
+	0xd6, 0x49, 0x49, 0x86, 0x05, 0x4e, 0xff, 0x6b, 0x0a, 0x72, 0x12, 0x74, 0x9d, 0x92, 0x15, 0x6e,
+	0xab, 0xd3, 0x57, 0xb6, 0xd5, 0x2c, 0xd1, 0x59, 0xe1, 0x18, 0x50, 0xd3, 0x7f, 0x23, 0x9f, 0xe0,
+	0x65, 0x5e, 0x37, 0x87, 0x7d, 0x0e, 0x89, 0xb4, 0x41, 0x99, 0x48, 0x1b, 0xc4, 0x1e, 0xfc, 0x44,
+	0xbe, 0x6d, 0x6c, 0x9e, 0x1f, 0x79, 0xbc, 0x00, 0xb0, 0x9b, 0x67, 0x3a, 0xb1, 0xd9, 0x72, 0x60,
+	0x8a, 0x7a, 0xa5, 0xe1, 0x82, 0x84, 0x34, 0x28, 0xbb, 0x74, 0x4b, 0x61, 0x5b, 0xa3, 0x0a, 0xa4,
+	0xe7, 0x37, 0x54, 0xda, 0xb1, 0x99, 0x5f, 0xa7, 0xfe, 0x48, 0x26, 0x31, 0x5b, 0xc6, 0xee, 0x32,
+	0x2d, 0xde, 0x68, 0xb0, 0x0b, 0xde, 0x9c, 0x8d, 0x3c, 0xd3, 0x56, 0x8a, 0xca, 0x2d, 0x8b, 0x6d,
+	0x93, 0x52, 0x32, 0x9e, 0x50, 0x91, 0xc7, 0x59, 0x3c, 0xdf, 0x33, 0xa1, 0x23, 0x33, 0x50, 0x25,
+	0x59, 0x94, 0xd5, 0x02, 0x83, 0x88, 0x72, 0x7b, 0x0f, 0x80, 0xbf, 0xde, 0xc5, 0x29, 0x72, 0xe2,
+	0x14, 0x12, 0xd2, 0xa0, 0xfa, 0x3f, 0xd2, 0xb0, 0xc9, 0x02, 0x3f, 0xfe, 0xac, 0xf8, 0x18, 0xd6,
+	0x58, 0xc9, 0x9f, 0x06, 0xd2, 0x53, 0x9b, 0x91, 0x2b, 0xb0, 0xc7, 0x51, 0x58, 0x92, 0xa0, 0xa7,
+	0x50, 0xb0, 0x1d, 0x9f, 0x58, 0xfc, 0x49, 0x2f, 0x7c, 0x76, 0x3b, 0x42, 0x7f, 0xa0, 0xb0, 0x78,
+	0x41, 0xf8, 0x7e, 0xc6, 0x26, 0x5c, 0xd1, 0x59, 0x40, 0xc9, 0xb8, 0x96, 0x4d, 0x52, 0x94, 0xa3,
+	0xb0, 0x24, 0x61, 0x95, 0x75, 0xe4, 0x8c, 0x1d, 0x2a, 0x6b, 0x9b, 0xd8, 0xb0, 0x3a, 0x6c, 0x4d,
+	0xfd, 0xc0, 0xf3, 0xb9, 0x79, 0x0a, 0x58, 0xee, 0xd8, 0x15, 0x31, 0x0f, 0x80, 0x21, 0x25, 0x3e,
+	0x2f, 0x64, 0x1a, 0x2e, 0xa9, 0x18, 0x60, 0x30, 0x76, 0x1f, 0x29, 0xa2, 0x33, 0x32, 0xf4, 0x7c,
+	0x22, 0xef, 0x1a, 0xc5, 0xda, 0xe4, 0x40, 0xfd, 0x0c, 0xb6, 0xa2, 0x66, 0xbe, 0x71, 0xb3, 0xc1,
+	0x22, 0xdd, 0x25, 0x17, 0x74, 0x20, 0x75, 0x15, 0x71, 0x05, 0x0c, 0xd4, 0xe2, 0x10, 0xde, 0x06,
+	0xf6, 0xa6, 0x67, 0x6c, 0x48, 0x77, 0x46, 0xfe, 0x8d, 0x77, 0xe2, 0x35, 0x9a, 0x85, 0x88, 0xa7,
+	0xb5, 0xeb, 0x7a, 0x7a, 0xe1, 0xa3, 0xcc, 0x95, 0x3e, 0xd2, 0xff, 0xa6, 0x41, 0x4e, 0x62, 0xae,
+	0xea, 0xcc, 0xa3, 0x19, 0x9a, 0x8e, 0x65, 0x68, 0x28, 0x86, 0xb5, 0x1b, 0xc6, 0x70, 0xe6, 0xe6,
+	0x27, 0x2b, 0x5e, 0x1d, 0x7d, 0x73, 0x17, 0x64, 0x2f, 0xeb, 0xf1, 0x55, 0xcb, 0xba, 0x16, 0x7d,
+	0x40, 0x6d, 0x83, 0x98, 0xaf, 0x30, 0x43, 0x88, 0x30, 0xcd, 0xf1, 0x7d, 0xdb, 0x5e, 0xf8, 0x2d,
+	0x7f, 0x8d, 0x67, 0x70, 0x21, 0xf2, 0x60, 0x8b, 0x8c, 0x71, 0x20, 0x36, 0xc6, 0x49, 0x78, 0xb3,
+	0x95, 0x12, 0x27, 0x21, 0x8f, 0xa0, 0x32, 0x34, 0x9d, 0xd1, 0xd4, 0x27, 0x03, 0x9f, 0x98, 0x81,
+	0xe7, 0xd6, 0xca, 0xa2, 0x27, 0x93, 0x50, 0xcc, 0x81, 0xfa, 0x43, 0x28, 0xf2, 0x9a, 0x74, 0x40,
+	0xa8, 0xe9, 0x8c, 0x58, 0xf3, 0x62, 0x79, 0xb6, 0x98, 0x1e, 0x95, 0x31, 0x5f, 0xef, 0x1a, 0x90,
+	0xe5, 0xf6, 0x40, 0x15, 0x80, 0x46, 0xaf, 0x67, 0xf4, 0x07, 0x9d, 0x6e, 0xc7, 0xa8, 0x7e, 0x80,
+	0x72, 0xa0, 0x35, 0xfb, 0xad, 0x6a, 0x8a, 0x2f, 0x5a, 0x47, 0xd5, 0x34, 0x5b, 0x18, 0xfd, 0xa3,
+	0xaa, 0xc6, 0x16, 0xc7, 0xfd, 0x56, 0x35, 0x83, 0xf2, 0x90, 0x39, 0x68, 0xf4, 0x8e, 0xaa, 0xd9,
+	0xdd, 0x67, 0x90, 0xe5, 0xc7, 0x67, 0x62, 0x4e, 0x8c, 0x83, 0x76, 0x43, 0x89, 0xa9, 0x00, 0x34,
+	0x8f, 0xbb, 0xad, 0x2f, 0x5b, 0x47, 0x8d, 0x76, 0xa7, 0x9a, 0x42, 0x65, 0x28, 0x1c, 0xb7, 0x5f,
+	0x1c, 0xf5, 0x3b, 0xed, 0xce, 0x8b, 0x6a, 0x7a, 0xd7, 0x82, 0x72, 0x24, 0x3a, 0xd0, 0x3a, 0x14,
+	0x7b, 0xfd, 0x46, 0xff, 0x65, 0x4f, 0x09, 0x28, 0x42, 0xee, 0xab, 0x46, 0xbb, 0xcf, 0xc8, 0x53,
+	0x6c, 0x73, 0x6a, 0x74, 0x0e, 0x38, 0x2f, 0x13, 0xd5, 0xea, 0x9e, 0x9c, 0x1e, 0x1b, 0x7d, 0xe3,
+	0xa0, 0xaa, 0x21, 0x80, 0xb5, 0xc3, 0x46, 0xfb, 0xd8, 0x38, 0xa8, 0x66, 0x50, 0x09, 0xf2, 0xd8,
+	0x78, 0x65, 0x60, 0x86, 0xc9, 0xee, 0x36, 0xa1, 0x1a, 0x0f, 0x29, 0x84, 0xa0, 0x72, 0xd0, 0xc6,
+	0x46, 0xab, 0xdf, 0xee, 0x76, 0xd4, 0xa7, 0x4a, 0x90, 0x6f, 0x77, 0x5a, 0xdd, 0x13, 0xf1, 0xad,
+	0x12, 0xe4, 0xbb, 0x2f, 0xfb, 0x2f, 0xba, 0x42, 0x51, 0x1b, 0x2a, 0xd1, 0x0e, 0x1d, 0xd5, 0x60,
+	0xab, 0xd5, 0xed, 0x74, 0x8c, 0x56, 0xbf, 0x8b, 0x07, 0x4c, 0x67, 0x23, 0x24, 0xa7, 0xd7, 0x6f,
+	0xe0, 0x85, 0xce, 0xf8, 0x65, 0x47, 0x9c, 0x17, 0x55, 0xa1, 0xc4, 0x51, 0x03, 0xa9, 0xaa, 0xc6,
+	0xd0, 0xbd, 0x7e, 0xf7, 0xf4, 0x94, 0xe9, 0xbd, 0xdb, 0x82, 0x62, 0xe8, 0xd2, 0x45, 0x1b, 0x50,
+	0x3e, 0xc5, 0xed, 0x2e, 0x6e, 0xf7, 0x5f, 0x2b, 0xd9, 0x79, 0xc8, 0x1c, 0x36, 0x7a, 0xfd, 0x6a,
+	0x8a, 0x9d, 0xb7, 0xd3, 0xc5, 0x27, 0x8d, 0xe3, 0x6a, 0x9a, 0x09, 0x31, 0x5a, 0xdd, 0x4e, 0xf7,
+	0xe4, 0x75, 0x55, 0xdb, 0x7d, 0xbe, 0xb0, 0xa9, 0x48, 0x03, 0x66, 0xd3, 0xd7, 0xbd, 0xbe, 0x71,
+	0x12, 0x39, 0x68, 0xdf, 0xc0, 0x9d, 0xc6, 0xb1, 0x38, 0xa8, 0xf1, 0xb5, 0xdc, 0xa5, 0xf7, 0xff,
+	0x59, 0x82, 0xc2, 0xa9, 0x39, 0xeb, 0x11, 0xff, 0x9c, 0xf8, 0xe8, 0x08, 0xca, 0x91, 0xbf, 0x0e,
+	0xa8, 0x2e, 0x5f, 0x2b, 0x09, 0xbf, 0x48, 0xea, 0x77, 0x13, 0x71, 0xb2, 0xee, 0x76, 0x60, 0x3d,
+	0x36, 0x26, 0x46, 0x1f, 0x0a, 0xfa, 0xe4, 0xe9, 0x71, 0xfd, 0xde, 0x0a, 0xac, 0x94, 0xf7, 0x6c,
+	0xf1, 0x1b, 0x61, 0x2b, 0x3a, 0x9b, 0x96, 0xfc, 0xb7, 0x62, 0x50, 0xc9, 0xd7, 0x84, 0x62, 0x68,
+	0xbc, 0x8a, 0x6a, 0x82, 0x6a, 0x79, 0x5c, 0x5c, 0xdf, 0x4e, 0xc0, 0xcc, 0xbf, 0x5d, 0x0c, 0x4d,
+	0xe1, 0x94, 0x8c, 0xe5, 0x91, 0x6a, 0x3d, 0x7a, 0xb5, 0x20, 0x03, 0x4a, 0x21, 0xa2, 0x00, 0x6d,
+	0x2f, 0x31, 0xaa, 0xdb, 0xa3, 0x5e, 0x4f, 0x42, 0xc9, 0xcf, 0x7f, 0xae, 0x9c, 0xa2, 0xe4, 0x46,
+	0x9c, 0x72, 0xb9, 0x0a, 0xcf, 0xa1, 0x22, 0x9f, 0x77, 0x0a, 0x72, 0x77, 0xfe, 0xfe, 0x5c, 0x9e,
+	0xac, 0xc5, 0xb9, 0xd9, 0x97, 0xc3, 0x93, 0xb4, 0xf9, 0x97, 0x13, 0xc6, 0x6b, 0x71, 0xde, 0x3d,
+	0xc8, 0xc9, 0x71, 0xda, 0xdc, 0x61, 0x91, 0xe9, 0x5a, 0x9c, 0xbe, 0x09, 0x1b, 0x4b, 0x13, 0x33,
+	0x74, 0x3f, 0x72, 0xd2, 0xa5, 0x51, 0x5a, 0x5c, 0xc6, 0x4f, 0x01, 0x16, 0x73, 0x2a, 0x74, 0x47,
+	0x7a, 0x34, 0x3e, 0xed, 0xaa, 0xd7, 0x96, 0x11, 0xd2, 0xd4, 0x4f, 0x01, 0xda, 0xe3, 0xb8, 0x80,
+	0xa5, 0x81, 0x56, 0xfc, 0xb3, 0x9f, 0x41, 0x29, 0x3c, 0xac, 0x52, 0x7e, 0x4e, 0x18, 0x60, 0xc5,
+	0x39, 0x7f, 0x06, 0xd5, 0xf8, 0x5c, 0x09, 0xdd, 0x53, 0x93, 0xe3, 0xc4, 0x41, 0x56, 0xfd, 0xfe,
+	0x2a, 0xf4, 0x22, 0x58, 0x43, 0x53, 0x1e, 0x15, 0xac, 0xcb, 0x83, 0x9f, 0xb8, 0x2a, 0x7d, 0xd8,
+	0x58, 0x1a, 0xd9, 0x28, 0xfb, 0xaf, 0x9a, 0x00, 0xd5, 0x1f, 0xac, 0xc4, 0x4b, 0x6d, 0x0c, 0x28,
+	0x85, 0xdb, 0x32, 0x65, 0x9a, 0x84, 0x8e, 0xb8, 0x5e, 0x4f, 0x42, 0x49, 0x31, 0x07, 0xb0, 0xb1,
+	0xd4, 0x78, 0x29, 0xe5, 0x56, 0x75, 0x64, 0xb1, 0x03, 0x7e, 0x92, 0x62, 0xca, 0x84, 0x5f, 0xb6,
+	0x4a, 0x99, 0x84, 0x67, 0x72, 0xbd, 0x9e, 0x84, 0x5a, 0x94, 0x22, 0x39, 0x82, 0x52, 0x91, 0x1d,
+	0x9d, 0x51, 0xd5, 0x6f, 0xc5, 0xa0, 0x92, 0x6f, 0x08, 0x77, 0x56, 0x3c, 0x81, 0xd1, 0x77, 0x16,
+	0x67, 0x5f, 0xfd, 0x86, 0xae, 0x3f, 0xba, 0x82, 0x4a, 0x7e, 0xe7, 0xe7, 0xb0, 0x99, 0xf0, 0xba,
+	0x45, 0x3b, 0x2a, 0x70, 0x56, 0x3d, 0x94, 0xeb, 0x0f, 0x2f, 0xa1, 0x58, 0x94, 0xd3, 0xd0, 0x3b,
+	0x55, 0x45, 0xd7, 0xf2, 0x9b, 0xb7, 0xbe, 0x9d, 0x80, 0x11, 0x32, 0xce, 0xd6, 0xf8, 0x1f, 0xfa,
+	0x27, 0xff, 0x1a, 0x00, 0xb3, 0x7d, 0xd9, 0xeb, 0xae, 0x1f, 0x00, 0x00,
 }
@@ -4,21 +4,26 @@ import (
 	"encoding/hex"
 	"github.com/bitlum/connector/common"
 	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/macaroons"
 	"github.com/bitlum/connector/metrics"
 	"github.com/bitlum/connector/metrics/rpc"
-	"github.com/go-errors/errors"
+	"github.com/bitlum/connector/notifier"
 	"github.com/shopspring/decimal"
 	"golang.org/x/net/context"
 	"math/rand"
+	"sort"
+	"strings"
 )
 
 // Server is the gRPC server which implements PayServer interface.
 type Server struct {
-	net                  string
-	blockchainConnectors map[connectors.Asset]connectors.BlockchainConnector
-	lightningConnectors  map[connectors.Asset]connectors.LightningConnector
-	paymentsStore        connectors.PaymentsStore
-	metrics              rpc.MetricsBackend
+	net             string
+	registry        *connectors.Registry
+	paymentsStore   connectors.PaymentsStore
+	paymentsHub     *connectors.PaymentsHub
+	macaroonService *macaroons.Service
+	notifier        *notifier.Notifier
+	metrics         rpc.MetricsBackend
 }
 
 // A compile time check to ensure that Server fully implements the
@@ -27,16 +32,20 @@ var _ PayServerServer = (*Server)(nil)
 
 // NewRPCServer creates and returns a new instance of the Server.
 func NewRPCServer(net string,
-	blockchainConnectors map[connectors.Asset]connectors.BlockchainConnector,
-	lightningConnectors map[connectors.Asset]connectors.LightningConnector,
+	registry *connectors.Registry,
 	paymentsStore connectors.PaymentsStore,
+	paymentsHub *connectors.PaymentsHub,
+	macaroonService *macaroons.Service,
+	notifier *notifier.Notifier,
 	metrics rpc.MetricsBackend) (*Server, error) {
 	return &Server{
-		blockchainConnectors: blockchainConnectors,
-		lightningConnectors:  lightningConnectors,
-		paymentsStore:        paymentsStore,
-		metrics:              metrics,
-		net:                  net,
+		registry:        registry,
+		paymentsStore:   paymentsStore,
+		paymentsHub:     paymentsHub,
+		macaroonService: macaroonService,
+		notifier:        notifier,
+		metrics:         metrics,
+		net:             net,
 	}, nil
 }
 
@@ -56,7 +65,7 @@ func (s *Server) CreateReceipt(ctx context.Context,
 
 	switch req.Media {
 	case Media_BLOCKCHAIN:
-		c, ok := s.blockchainConnectors[connectors.Asset(req.Asset.String())]
+		c, ok := s.registry.BlockchainConnector(connectors.Asset(req.Asset.String()))
 		if !ok {
 			err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 			log.Errorf("command(%v), id(%v), error: %v",
@@ -67,7 +76,7 @@ func (s *Server) CreateReceipt(ctx context.Context,
 
 		address, err := c.CreateAddress()
 		if err != nil {
-			err := newErrInternal(err.Error())
+			err := newErrFromConnector(err)
 			log.Errorf("command(%v), id(%v),error: %v",
 				common.GetFunctionName(), requestID, err)
 			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -78,7 +87,7 @@ func (s *Server) CreateReceipt(ctx context.Context,
 			Receipt: address,
 		}
 	case Media_LIGHTNING:
-		c, ok := s.lightningConnectors[connectors.Asset(req.Asset.String())]
+		c, ok := s.registry.LightningConnector(connectors.Asset(req.Asset.String()))
 		if !ok {
 			err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 			log.Errorf("command(%v), id(%v),error: %v",
@@ -96,7 +105,7 @@ func (s *Server) CreateReceipt(ctx context.Context,
 		paymentRequest, invoice, err := c.CreateInvoice("zigzag", req.Amount,
 			req.Description)
 		if err != nil {
-			err := newErrInternal(err.Error())
+			err := newErrFromConnector(err)
 			log.Errorf("command(%v), id(%v),error: %v",
 				common.GetFunctionName(), requestID, err)
 			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -110,7 +119,7 @@ func (s *Server) CreateReceipt(ctx context.Context,
 		}
 
 	default:
-		err := errors.Errorf("media(%v) is not supported", req.Media.String())
+		err := newErrInvalidArgument("media")
 		log.Errorf("command(%v), id(%v), error: %v",
 			common.GetFunctionName(), requestID, err)
 		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -136,7 +145,7 @@ func (s *Server) ValidateReceipt(ctx context.Context,
 
 	switch req.Media {
 	case Media_BLOCKCHAIN:
-		c, ok := s.blockchainConnectors[connectors.Asset(req.Asset.String())]
+		c, ok := s.registry.BlockchainConnector(connectors.Asset(req.Asset.String()))
 		if !ok {
 			err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 			log.Errorf("command(%v), id(%v), error: %v",
@@ -146,6 +155,7 @@ func (s *Server) ValidateReceipt(ctx context.Context,
 		}
 
 		if err := c.ValidateAddress(req.Receipt); err != nil {
+			err := newErrFromConnector(err)
 			log.Errorf("command(%v), id(%v), error: %v",
 				common.GetFunctionName(), requestID, err)
 			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -153,7 +163,7 @@ func (s *Server) ValidateReceipt(ctx context.Context,
 		}
 
 	case Media_LIGHTNING:
-		c, ok := s.lightningConnectors[connectors.Asset(req.Asset.String())]
+		c, ok := s.registry.LightningConnector(connectors.Asset(req.Asset.String()))
 		if !ok {
 			err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 			log.Errorf("command(%v), id(%v), error: %v",
@@ -168,6 +178,7 @@ func (s *Server) ValidateReceipt(ctx context.Context,
 
 		invoice, err := c.ValidateInvoice(req.Receipt, req.Amount)
 		if err != nil {
+			err := newErrFromConnector(err)
 			log.Errorf("command(%v), id(%v), error: %v",
 				common.GetFunctionName(), requestID, err)
 			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -207,7 +218,7 @@ func (s *Server) ValidateReceipt(ctx context.Context,
 		}
 
 	default:
-		err := errors.Errorf("media(%v) is not supported", req.Media.String())
+		err := newErrInvalidArgument("media")
 		log.Errorf("command(%v), error: %v", common.GetFunctionName(), err)
 		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
 		return nil, err
@@ -239,9 +250,9 @@ func (s *Server) Balance(ctx context.Context, req *BalanceRequest,
 		if req.Asset == Asset_ASSET_NONE {
 			// If asset wasn't specified return balances for all blockchain
 			// assets.
-			cntrs = s.blockchainConnectors
+			cntrs = s.registry.BlockchainConnectors()
 		} else {
-			c, ok := s.blockchainConnectors[connectors.Asset(req.Asset.String())]
+			c, ok := s.registry.BlockchainConnector(connectors.Asset(req.Asset.String()))
 			if !ok {
 				err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 				log.Errorf("command(%v), id(%v), error: %v",
@@ -257,7 +268,7 @@ func (s *Server) Balance(ctx context.Context, req *BalanceRequest,
 		for asset, c := range cntrs {
 			available, err := c.ConfirmedBalance()
 			if err != nil {
-				err := newErrInternal(err.Error())
+				err := newErrFromConnector(err)
 				log.Errorf("command(%v), id(%v), error: %v",
 					common.GetFunctionName(), requestID, err)
 				s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -266,7 +277,7 @@ func (s *Server) Balance(ctx context.Context, req *BalanceRequest,
 
 			pending, err := c.PendingBalance()
 			if err != nil {
-				err := newErrInternal(err.Error())
+				err := newErrFromConnector(err)
 				log.Errorf("command(%v), id(%v), error: %v",
 					common.GetFunctionName(), requestID, err)
 				s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -299,9 +310,9 @@ func (s *Server) Balance(ctx context.Context, req *BalanceRequest,
 		if req.Asset == Asset_ASSET_NONE {
 			// If asset wasn't specified return balances for all blockchain
 			// assets.
-			cntrs = s.lightningConnectors
+			cntrs = s.registry.LightningConnectors()
 		} else {
-			c, ok := s.lightningConnectors[connectors.Asset(req.Asset.String())]
+			c, ok := s.registry.LightningConnector(connectors.Asset(req.Asset.String()))
 			if !ok {
 				err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 				log.Errorf("command(%v), id(%v), error: %v",
@@ -323,7 +334,7 @@ func (s *Server) Balance(ctx context.Context, req *BalanceRequest,
 
 			available, err := c.ConfirmedBalance()
 			if err != nil {
-				err := newErrInternal(err.Error())
+				err := newErrFromConnector(err)
 				log.Errorf("command(%v), id(%v), error: %v",
 					common.GetFunctionName(), requestID, err)
 				s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -332,7 +343,7 @@ func (s *Server) Balance(ctx context.Context, req *BalanceRequest,
 
 			pending, err := c.PendingBalance()
 			if err != nil {
-				err := newErrInternal(err.Error())
+				err := newErrFromConnector(err)
 				log.Errorf("command(%v), id(%v), error: %v",
 					common.GetFunctionName(), requestID, err)
 				s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -376,7 +387,7 @@ func (s *Server) EstimateFee(ctx context.Context,
 
 	switch req.Media {
 	case Media_BLOCKCHAIN:
-		c, ok := s.blockchainConnectors[connectors.Asset(req.Asset.String())]
+		c, ok := s.registry.BlockchainConnector(connectors.Asset(req.Asset.String()))
 		if !ok {
 			err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 			log.Errorf("command(%v), id(%v), error: %v",
@@ -389,9 +400,39 @@ func (s *Server) EstimateFee(ctx context.Context,
 			req.Amount = "0"
 		}
 
-		fee, err := c.EstimateFee(req.Amount)
+		priority, err := ConvertFeePriorityFromProto(req.Priority)
 		if err != nil {
-			err := newErrInternal(err.Error())
+			err := newErrInvalidArgument("priority")
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+
+		// Connectors which are able to construct the transaction return
+		// its size along with the fee.
+		if e, ok := c.(connectors.TxFeeEstimator); ok {
+			estimate, err := e.EstimateTxFee(req.Receipt, req.Amount,
+				priority)
+			if err != nil {
+				err := newErrFromConnector(err)
+				log.Errorf("command(%v), id(%v), error: %v",
+					common.GetFunctionName(), requestID, err)
+				s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+				return nil, err
+			}
+
+			resp = &EstimateFeeResponse{
+				MediaFee: estimate.Fee.String(),
+				Inputs:   int32(estimate.Inputs),
+				Vsize:    int32(estimate.VSize),
+			}
+			break
+		}
+
+		fee, err := c.EstimateFee(req.Amount, priority)
+		if err != nil {
+			err := newErrFromConnector(err)
 			log.Errorf("command(%v), id(%v), error: %v",
 				common.GetFunctionName(), requestID, err)
 			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -403,7 +444,7 @@ func (s *Server) EstimateFee(ctx context.Context,
 		}
 
 	case Media_LIGHTNING:
-		c, ok := s.lightningConnectors[connectors.Asset(req.Asset.String())]
+		c, ok := s.registry.LightningConnector(connectors.Asset(req.Asset.String()))
 		if !ok {
 			err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 			log.Errorf("command(%v), id(%v), error: %v",
@@ -414,7 +455,7 @@ func (s *Server) EstimateFee(ctx context.Context,
 
 		fee, err := c.EstimateFee(req.Receipt)
 		if err != nil {
-			err := newErrInternal(err.Error())
+			err := newErrFromConnector(err)
 			log.Errorf("command(%v), id(%v), error: %v",
 				common.GetFunctionName(), requestID, err)
 			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -426,7 +467,7 @@ func (s *Server) EstimateFee(ctx context.Context,
 		}
 
 	default:
-		err := errors.Errorf("media(%v) is not supported", req.Media.String())
+		err := newErrInvalidArgument("media")
 		log.Errorf("command(%v), id(%v), error: %v",
 			common.GetFunctionName(), requestID, err)
 		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -458,7 +499,7 @@ func (s *Server) SendPayment(ctx context.Context,
 
 	switch req.Media {
 	case Media_BLOCKCHAIN:
-		c, ok := s.blockchainConnectors[connectors.Asset(req.Asset.String())]
+		c, ok := s.registry.BlockchainConnector(connectors.Asset(req.Asset.String()))
 		if !ok {
 			err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 			log.Errorf("command(%v), id(%v), error: %v",
@@ -473,9 +514,25 @@ func (s *Server) SendPayment(ctx context.Context,
 			req.Amount = "0"
 		}
 
-		payment, err = c.SendPayment(req.Receipt, req.Amount)
+		priority, err := ConvertFeePriorityFromProto(req.Priority)
 		if err != nil {
-			err := newErrInternal(err.Error())
+			err := newErrInvalidArgument("priority")
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+
+		payment, err = c.SendPayment(req.Receipt, req.Amount,
+			req.IdempotencyKey, priority)
+		if err == connectors.ErrIdempotencyKeyReused {
+			err := newErrIdempotencyKeyReused(req.IdempotencyKey)
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		} else if err != nil {
+			err := newErrFromConnector(err)
 			log.Errorf("command(%v), id(%v), error: %v",
 				common.GetFunctionName(), requestID, err)
 			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -483,7 +540,7 @@ func (s *Server) SendPayment(ctx context.Context,
 		}
 
 	case Media_LIGHTNING:
-		c, ok := s.lightningConnectors[connectors.Asset(req.Asset.String())]
+		c, ok := s.registry.LightningConnector(connectors.Asset(req.Asset.String()))
 		if !ok {
 			err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 			log.Errorf("command(%v), id(%v), error: %v",
@@ -496,9 +553,15 @@ func (s *Server) SendPayment(ctx context.Context,
 			req.Amount = "0"
 		}
 
-		payment, err = c.SendTo(req.Receipt, req.Amount)
-		if err != nil {
-			err := newErrInternal(err.Error())
+		payment, err = c.SendTo(req.Receipt, req.Amount, req.IdempotencyKey)
+		if err == connectors.ErrIdempotencyKeyReused {
+			err := newErrIdempotencyKeyReused(req.IdempotencyKey)
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		} else if err != nil {
+			err := newErrFromConnector(err)
 			log.Errorf("command(%v), id(%v), error: %v",
 				common.GetFunctionName(), requestID, err)
 			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -506,7 +569,7 @@ func (s *Server) SendPayment(ctx context.Context,
 		}
 
 	default:
-		err := errors.Errorf("media(%v) is not supported", req.Media.String())
+		err := newErrInvalidArgument("media")
 		log.Errorf("command(%v), id(%v), error: %v",
 			common.GetFunctionName(), requestID, err)
 		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
@@ -528,159 +591,190 @@ func (s *Server) SendPayment(ctx context.Context,
 	return resp, nil
 }
 
+// SendPayments sends payments to the several recipients at once. Depending
+// on the asset payments are either sent in one transaction with multiple
+// outputs, or in the separate transactions after the check that the
+// account has enough money for all of them.
 //
-// PaymentByID is used to fetch the information about payment, by the
-// given system payment id.
-func (s *Server) PaymentByID(ctx context.Context, req *PaymentByIDRequest) (*Payment,
-	error) {
+// NOTE: Part of the PayServerServer interface.
+func (s *Server) SendPayments(ctx context.Context,
+	req *SendPaymentsRequest) (*SendPaymentsResponse, error) {
 	requestID := rand.Int()
 
 	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
 		requestID, convertProtoMessage(req))
 
-	payment, err := s.paymentsStore.PaymentByID(req.PaymentId)
+	payments, err := s.sendPayments(req)
 	if err != nil {
-		err := newErrInternal(err.Error())
 		log.Errorf("command(%v), id(%v), error: %v",
 			common.GetFunctionName(), requestID, err)
 		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
 		return nil, err
 	}
 
-	resp, err := convertPaymentToProto(payment)
-	if err != nil {
-		err := newErrInternal(err.Error())
-		log.Errorf("command(%v), id(%v), error: %v",
-			common.GetFunctionName(), requestID, err)
-		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
-		return nil, err
+	resp := &SendPaymentsResponse{}
+	for _, payment := range payments {
+		protoPayment, err := convertPaymentToProto(payment)
+		if err != nil {
+			err := newErrInternal(err.Error())
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+
+		resp.Payments = append(resp.Payments, protoPayment)
 	}
 
 	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
 		requestID, convertProtoMessage(resp))
 
-	return resp, err
+	return resp, nil
 }
 
-//
-// PaymentsByReceipt is used to fetch the information about payment, by the
-// given receipt.
-func (s *Server) PaymentsByReceipt(ctx context.Context,
-	req *PaymentsByReceiptRequest) (*PaymentsByReceiptResponse, error) {
-	requestID := rand.Int()
+// sendPayments finds the blockchain connector of the asset, and sends
+// payments to the recipients with it.
+func (s *Server) sendPayments(req *SendPaymentsRequest) ([]*connectors.Payment,
+	error) {
 
-	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
-		requestID, convertProtoMessage(req))
+	asset := connectors.Asset(req.Asset.String())
+	media := Media_BLOCKCHAIN.String()
 
-	payments, err := s.paymentsStore.PaymentByReceipt(req.Receipt)
-	if err != nil {
-		err := newErrInternal(err.Error())
-		log.Errorf("command(%v), id(%v), error: %v",
-			common.GetFunctionName(), requestID, err)
-		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
-		return nil, err
+	c, ok := s.registry.BlockchainConnector(asset)
+	if !ok {
+		return nil, newErrAssetNotSupported(req.Asset.String(), media)
 	}
 
-	var protoPayments []*Payment
-	for _, payment := range payments {
-		protoPayment, err := convertPaymentToProto(payment)
-		if err != nil {
-			err := newErrInternal(err.Error())
-			log.Errorf("command(%v), id(%v), error: %v",
-				common.GetFunctionName(), requestID, err)
-			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
-			return nil, err
-		}
+	sender, ok := c.(connectors.MultiSender)
+	if !ok {
+		return nil, newErrAssetNotSupported(req.Asset.String(), media)
+	}
 
-		protoPayments = append(protoPayments, protoPayment)
+	if len(req.Recipients) == 0 {
+		return nil, newErrInvalidArgument("recipients")
 	}
 
-	resp := &PaymentsByReceiptResponse{
-		Payments: protoPayments,
+	recipients := make([]*connectors.Recipient, len(req.Recipients))
+	for i, recipient := range req.Recipients {
+		recipients[i] = &connectors.Recipient{
+			Address: recipient.Receipt,
+			Amount:  recipient.Amount,
+		}
 	}
 
-	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
-		requestID, convertProtoMessage(resp))
+	payments, err := sender.SendPayments(recipients, req.IdempotencyKey)
+	if err == connectors.ErrIdempotencyKeyReused {
+		return nil, newErrIdempotencyKeyReused(req.IdempotencyKey)
+	} else if err != nil {
+		return nil, newErrFromConnector(err)
+	}
 
-	return resp, nil
+	return payments, nil
 }
 
+// CreatePayment creates and signs the payment, but not sends it. Payment is
+// stored with waiting status and the exact fee, so that it could be
+// reviewed before being confirmed or canceled.
 //
-// ListPayments returns list of payment which were registered by the
-// system.
-func (s *Server) ListPayments(ctx context.Context,
-	req *ListPaymentsRequest) (*ListPaymentsResponse, error) {
+// NOTE: Part of the PayServerServer interface.
+func (s *Server) CreatePayment(ctx context.Context,
+	req *CreatePaymentRequest) (*Payment, error) {
 	requestID := rand.Int()
 
 	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
 		requestID, convertProtoMessage(req))
 
 	var (
-		asset     connectors.Asset
-		status    connectors.PaymentStatus
-		direction connectors.PaymentDirection
-		media     connectors.PaymentMedia
-		system    connectors.PaymentSystem
-		err       error
+		resp    *Payment
+		payment *connectors.Payment
+		err     error
 	)
 
-	if req.Asset != Asset_ASSET_NONE {
-		asset, err = ConvertAssetFromProto(req.Asset)
-		if err != nil {
-			err := newErrInternal(err.Error())
-			log.Errorf("command(%v), id(%v), error: %v",
-				common.GetFunctionName(), requestID, err)
-			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
-			return nil, err
-		}
+	if req.Amount == "" {
+		req.Amount = "0"
 	}
 
-	if req.Direction != PaymentDirection_DIRECTION_NONE {
-		direction, err = ConvertPaymentDirectionFromProto(req.Direction)
-		if err != nil {
-			err := newErrInternal(err.Error())
+	switch req.Media {
+	case Media_BLOCKCHAIN:
+		c, ok := s.registry.BlockchainConnector(connectors.Asset(req.Asset.String()))
+		if !ok {
+			err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 			log.Errorf("command(%v), id(%v), error: %v",
 				common.GetFunctionName(), requestID, err)
 			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
 			return nil, err
 		}
-	}
 
-	if req.System != PaymentSystem_SYSTEM_NONE {
-		system, err = ConvertPaymentSystemFromProto(req.System)
-		if err != nil {
-			err := newErrInternal(err.Error())
+		payment, err = c.CreatePayment(req.Receipt, req.Amount, req.IdempotencyKey)
+
+	case Media_LIGHTNING:
+		c, ok := s.registry.LightningConnector(connectors.Asset(req.Asset.String()))
+		if !ok {
+			err := newErrAssetNotSupported(req.Asset.String(), req.Media.String())
 			log.Errorf("command(%v), id(%v), error: %v",
 				common.GetFunctionName(), requestID, err)
 			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
 			return nil, err
 		}
+
+		payment, err = c.CreatePayment(req.Receipt, req.Amount, req.IdempotencyKey)
+
+	default:
+		err := newErrInvalidArgument("media")
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
 	}
 
-	if req.Status != PaymentStatus_STATUS_NONE {
-		status, err = ConvertPaymentStatusFromProto(req.Status)
-		if err != nil {
-			err := newErrInternal(err.Error())
-			log.Errorf("command(%v), id(%v), error: %v",
-				common.GetFunctionName(), requestID, err)
-			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
-			return nil, err
-		}
+	if err == connectors.ErrIdempotencyKeyReused {
+		err := newErrIdempotencyKeyReused(req.IdempotencyKey)
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	} else if err != nil {
+		err := newErrFromConnector(err)
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
 	}
 
-	if req.Media != Media_MEDIA_NONE {
-		media, err = ConvertMediaFromProto(req.Media)
-		if err != nil {
-			err := newErrInternal(err.Error())
-			log.Errorf("command(%v), id(%v), error: %v",
-				common.GetFunctionName(), requestID, err)
-			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
-			return nil, err
-		}
+	resp, err = convertPaymentToProto(payment)
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+// ConfirmPayment sends previously created waiting payment.
+//
+// NOTE: Part of the PayServerServer interface.
+func (s *Server) ConfirmPayment(ctx context.Context,
+	req *ConfirmPaymentRequest) (*Payment, error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	payment, err := s.processWaitingPayment(req.PaymentId, true)
+	if err != nil {
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
 	}
 
-	payments, err := s.paymentsStore.ListPayments(asset, status, direction,
-		media, system)
+	resp, err := convertPaymentToProto(payment)
 	if err != nil {
 		err := newErrInternal(err.Error())
 		log.Errorf("command(%v), id(%v), error: %v",
@@ -689,24 +783,1044 @@ func (s *Server) ListPayments(ctx context.Context,
 		return nil, err
 	}
 
-	var protoPayments []*Payment
-	for _, payment := range payments {
-		protoPayment, err := convertPaymentToProto(payment)
-		if err != nil {
-			err := newErrInternal(err.Error())
-			log.Errorf("command(%v), id(%v), error: %v",
-				common.GetFunctionName(), requestID, err)
-			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
-			return nil, err
-		}
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
 
-		protoPayments = append(protoPayments, protoPayment)
+	return resp, nil
+}
+
+// CancelPayment cancels previously created waiting payment, and releases
+// resources reserved for it.
+//
+// NOTE: Part of the PayServerServer interface.
+func (s *Server) CancelPayment(ctx context.Context,
+	req *CancelPaymentRequest) (*Payment, error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	payment, err := s.processWaitingPayment(req.PaymentId, false)
+	if err != nil {
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
 	}
 
-	resp := &ListPaymentsResponse{
-		Payments: protoPayments,
+	resp, err := convertPaymentToProto(payment)
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+// processWaitingPayment finds the connector which has created the payment,
+// and either confirms or cancels the payment with it.
+func (s *Server) processWaitingPayment(paymentID string,
+	confirm bool) (*connectors.Payment, error) {
+
+	payment, err := s.paymentsStore.PaymentByID(paymentID)
+	if err == connectors.PaymentNotFound {
+		return nil, newErrInvalidArgument("payment_id")
+	} else if err != nil {
+		return nil, newErrInternal(err.Error())
 	}
 
+	switch payment.Media {
+	case connectors.Blockchain:
+		c, ok := s.registry.BlockchainConnector(payment.Asset)
+		if !ok {
+			return nil, newErrAssetNotSupported(string(payment.Asset),
+				string(payment.Media))
+		}
+
+		if confirm {
+			payment, err = c.ConfirmPayment(paymentID)
+		} else {
+			payment, err = c.CancelPayment(paymentID)
+		}
+
+	case connectors.Lightning:
+		c, ok := s.registry.LightningConnector(payment.Asset)
+		if !ok {
+			return nil, newErrAssetNotSupported(string(payment.Asset),
+				string(payment.Media))
+		}
+
+		if confirm {
+			payment, err = c.ConfirmPayment(paymentID)
+		} else {
+			payment, err = c.CancelPayment(paymentID)
+		}
+
+	default:
+		return nil, newErrInvalidArgument("payment_id")
+	}
+
+	if err == connectors.PaymentNotFound {
+		return nil, newErrInvalidArgument("payment_id")
+	} else if err == connectors.ErrPaymentNotWaiting {
+		return nil, newErrPaymentNotWaiting(paymentID)
+	} else if err != nil {
+		return nil, newErrFromConnector(err)
+	}
+
+	return payment, nil
+}
+
+// BumpFee replaces the transaction of the pending blockchain payment with
+// the transaction paying bigger fee.
+//
+// NOTE: Part of the PayServerServer interface.
+func (s *Server) BumpFee(ctx context.Context,
+	req *BumpFeeRequest) (*Payment, error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	payment, err := s.bumpFee(req)
+	if err != nil {
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	resp, err := convertPaymentToProto(payment)
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+// bumpFee finds the connector which has sent the payment, and bumps fee of
+// the payment with it.
+func (s *Server) bumpFee(req *BumpFeeRequest) (*connectors.Payment, error) {
+	feeRate := decimal.Zero
+	if req.FeeRate != "" {
+		var err error
+		feeRate, err = decimal.NewFromString(req.FeeRate)
+		if err != nil {
+			return nil, newErrInvalidArgument("fee_rate")
+		}
+	}
+
+	payment, err := s.paymentsStore.PaymentByID(req.PaymentId)
+	if err == connectors.PaymentNotFound {
+		return nil, newErrInvalidArgument("payment_id")
+	} else if err != nil {
+		return nil, newErrInternal(err.Error())
+	}
+
+	if payment.Media != connectors.Blockchain {
+		return nil, newErrAssetNotSupported(string(payment.Asset),
+			string(payment.Media))
+	}
+
+	c, ok := s.registry.BlockchainConnector(payment.Asset)
+	if !ok {
+		return nil, newErrAssetNotSupported(string(payment.Asset),
+			string(payment.Media))
+	}
+
+	bumper, ok := c.(connectors.FeeBumper)
+	if !ok {
+		return nil, newErrAssetNotSupported(string(payment.Asset),
+			string(payment.Media))
+	}
+
+	payment, err = bumper.BumpFee(req.PaymentId, feeRate)
+	if err == connectors.PaymentNotFound {
+		return nil, newErrInvalidArgument("payment_id")
+	} else if err == connectors.ErrPaymentNotPending {
+		return nil, newErrPaymentNotPending(req.PaymentId)
+	} else if err != nil {
+		return nil, newErrFromConnector(err)
+	}
+
+	return payment, nil
+}
+
+// CreatePSBTPayment creates the blockchain payment from the cold storage,
+// which is waiting until its partially signed transaction is signed
+// offline and finalized.
+//
+// NOTE: Part of the PayServerServer interface.
+func (s *Server) CreatePSBTPayment(ctx context.Context,
+	req *CreatePSBTPaymentRequest) (*Payment, error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	payment, err := s.createPSBTPayment(req)
+	if err != nil {
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	resp, err := convertPaymentToProto(payment)
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+// createPSBTPayment finds the blockchain connector of the asset, and
+// creates cold storage payment with it.
+func (s *Server) createPSBTPayment(req *CreatePSBTPaymentRequest) (
+	*connectors.Payment, error) {
+
+	asset := connectors.Asset(req.Asset.String())
+	media := Media_BLOCKCHAIN.String()
+
+	c, ok := s.registry.BlockchainConnector(asset)
+	if !ok {
+		return nil, newErrAssetNotSupported(req.Asset.String(), media)
+	}
+
+	signer, ok := c.(connectors.ColdSigner)
+	if !ok {
+		return nil, newErrAssetNotSupported(req.Asset.String(), media)
+	}
+
+	payment, err := signer.CreatePSBTPayment(req.Receipt, req.Amount,
+		req.IdempotencyKey)
+	if err == connectors.ErrIdempotencyKeyReused {
+		return nil, newErrIdempotencyKeyReused(req.IdempotencyKey)
+	} else if err == connectors.ErrPSBTNotSupported {
+		return nil, newErrAssetNotSupported(req.Asset.String(), media)
+	} else if err != nil {
+		return nil, newErrFromConnector(err)
+	}
+
+	return payment, nil
+}
+
+// ExportPSBT returns partially signed transaction of the waiting cold
+// storage payment.
+//
+// NOTE: Part of the PayServerServer interface.
+func (s *Server) ExportPSBT(ctx context.Context,
+	req *ExportPSBTRequest) (*ExportPSBTResponse, error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	var psbt string
+	_, err := s.processPSBT(req.PaymentId, func(
+		signer connectors.ColdSigner) (*connectors.Payment, error) {
+
+		var err error
+		psbt, err = signer.ExportPSBT(req.PaymentId)
+		return nil, err
+	})
+	if err != nil {
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	resp := &ExportPSBTResponse{
+		Psbt: psbt,
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+// ImportPSBT imports signatures of the partially signed transaction, which
+// has been signed offline, into the waiting cold storage payment.
+//
+// NOTE: Part of the PayServerServer interface.
+func (s *Server) ImportPSBT(ctx context.Context,
+	req *ImportPSBTRequest) (*Payment, error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	payment, err := s.processPSBT(req.PaymentId, func(
+		signer connectors.ColdSigner) (*connectors.Payment, error) {
+
+		if req.Psbt == "" {
+			return nil, newErrInvalidArgument("psbt")
+		}
+
+		return signer.ImportPSBT(req.PaymentId, req.Psbt)
+	})
+	if err != nil {
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	resp, err := convertPaymentToProto(payment)
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+// FinalizePSBT finalizes the fully signed transaction of the waiting cold
+// storage payment and sends it.
+//
+// NOTE: Part of the PayServerServer interface.
+func (s *Server) FinalizePSBT(ctx context.Context,
+	req *FinalizePSBTRequest) (*Payment, error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	payment, err := s.processPSBT(req.PaymentId, func(
+		signer connectors.ColdSigner) (*connectors.Payment, error) {
+		return signer.FinalizePSBT(req.PaymentId)
+	})
+	if err != nil {
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	resp, err := convertPaymentToProto(payment)
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+// processPSBT finds the connector which has created the cold storage
+// payment, and processes partially signed transaction of the payment with
+// it.
+func (s *Server) processPSBT(paymentID string,
+	process func(signer connectors.ColdSigner) (*connectors.Payment,
+		error)) (*connectors.Payment, error) {
+
+	payment, err := s.paymentsStore.PaymentByID(paymentID)
+	if err == connectors.PaymentNotFound {
+		return nil, newErrInvalidArgument("payment_id")
+	} else if err != nil {
+		return nil, newErrInternal(err.Error())
+	}
+
+	if payment.Media != connectors.Blockchain {
+		return nil, newErrAssetNotSupported(string(payment.Asset),
+			string(payment.Media))
+	}
+
+	c, ok := s.registry.BlockchainConnector(payment.Asset)
+	if !ok {
+		return nil, newErrAssetNotSupported(string(payment.Asset),
+			string(payment.Media))
+	}
+
+	signer, ok := c.(connectors.ColdSigner)
+	if !ok {
+		return nil, newErrAssetNotSupported(string(payment.Asset),
+			string(payment.Media))
+	}
+
+	payment, err = process(signer)
+	switch err {
+	case nil:
+		return payment, nil
+	case connectors.PaymentNotFound:
+		return nil, newErrInvalidArgument("payment_id")
+	case connectors.ErrPaymentNotWaiting:
+		return nil, newErrPaymentNotWaiting(paymentID)
+	}
+
+	if e, ok := err.(Error); ok {
+		return nil, e
+	}
+
+	return nil, newErrFromConnector(err)
+}
+
+// ReorganiseInputs splits large unspent outputs of the wallet into the
+// outputs of the optimal value, or only estimates the reorganisation if
+// preview is requested.
+//
+// NOTE: Part of the PayServerServer interface.
+func (s *Server) ReorganiseInputs(ctx context.Context,
+	req *ReorganiseInputsRequest) (*ReorganiseInputsResponse, error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	reorg, err := s.reorganiseInputs(req)
+	if err != nil {
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	// Empty response is returned if there are no large unspent outputs.
+	resp := &ReorganiseInputsResponse{}
+	if reorg != nil {
+		resp.TxId = reorg.TxID
+		resp.Inputs = int32(reorg.Inputs)
+		resp.InputsAmount = reorg.InputsAmount.String()
+		resp.Fee = reorg.Fee.String()
+		for _, amount := range reorg.Outputs {
+			resp.Outputs = append(resp.Outputs, amount.String())
+		}
+
+		for _, payment := range reorg.Payments {
+			protoPayment, err := convertPaymentToProto(payment)
+			if err != nil {
+				err := newErrInternal(err.Error())
+				log.Errorf("command(%v), id(%v), error: %v",
+					common.GetFunctionName(), requestID, err)
+				s.metrics.AddError(common.GetFunctionName(),
+					string(metrics.LowSeverity))
+				return nil, err
+			}
+
+			resp.Payments = append(resp.Payments, protoPayment)
+		}
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+// reorganiseInputs finds the blockchain connector of the asset, and
+// reorganises its unspent outputs.
+func (s *Server) reorganiseInputs(req *ReorganiseInputsRequest) (
+	*connectors.Reorganisation, error) {
+
+	asset := connectors.Asset(req.Asset.String())
+	media := Media_BLOCKCHAIN.String()
+
+	c, ok := s.registry.BlockchainConnector(asset)
+	if !ok {
+		return nil, newErrAssetNotSupported(req.Asset.String(), media)
+	}
+
+	reorganiser, ok := c.(connectors.InputsReorganiser)
+	if !ok {
+		return nil, newErrAssetNotSupported(req.Asset.String(), media)
+	}
+
+	reorg, err := reorganiser.ReorganiseInputs(req.Preview)
+	if err != nil {
+		return nil, newErrFromConnector(err)
+	}
+
+	return reorg, nil
+}
+
+//
+// PaymentByID is used to fetch the information about payment, by the
+// given system payment id.
+func (s *Server) PaymentByID(ctx context.Context, req *PaymentByIDRequest) (*Payment,
+	error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	payment, err := s.paymentsStore.PaymentByID(req.PaymentId)
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	resp, err := convertPaymentToProto(payment)
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, err
+}
+
+//
+// PaymentsByReceipt is used to fetch the information about payment, by the
+// given receipt.
+func (s *Server) PaymentsByReceipt(ctx context.Context,
+	req *PaymentsByReceiptRequest) (*PaymentsByReceiptResponse, error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	payments, err := s.paymentsStore.PaymentByReceipt(req.Receipt)
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	var protoPayments []*Payment
+	for _, payment := range payments {
+		protoPayment, err := convertPaymentToProto(payment)
+		if err != nil {
+			err := newErrInternal(err.Error())
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+
+		protoPayments = append(protoPayments, protoPayment)
+	}
+
+	resp := &PaymentsByReceiptResponse{
+		Payments: protoPayments,
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+//
+// ListPayments returns list of payment which were registered by the
+// system. Payments are returned page by page if limit is specified.
+func (s *Server) ListPayments(ctx context.Context,
+	req *ListPaymentsRequest) (*ListPaymentsResponse, error) {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	var (
+		asset     connectors.Asset
+		status    connectors.PaymentStatus
+		direction connectors.PaymentDirection
+		media     connectors.PaymentMedia
+		system    connectors.PaymentSystem
+		err       error
+	)
+
+	if req.Asset != Asset_ASSET_NONE {
+		asset, err = ConvertAssetFromProto(req.Asset)
+		if err != nil {
+			err := newErrInternal(err.Error())
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+	}
+
+	if req.Direction != PaymentDirection_DIRECTION_NONE {
+		direction, err = ConvertPaymentDirectionFromProto(req.Direction)
+		if err != nil {
+			err := newErrInternal(err.Error())
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+	}
+
+	if req.System != PaymentSystem_SYSTEM_NONE {
+		system, err = ConvertPaymentSystemFromProto(req.System)
+		if err != nil {
+			err := newErrInternal(err.Error())
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+	}
+
+	if req.Status != PaymentStatus_STATUS_NONE {
+		status, err = ConvertPaymentStatusFromProto(req.Status)
+		if err != nil {
+			err := newErrInternal(err.Error())
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+	}
+
+	if req.Media != Media_MEDIA_NONE {
+		media, err = ConvertMediaFromProto(req.Media)
+		if err != nil {
+			err := newErrInternal(err.Error())
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+	}
+
+	payments, cursor, err := s.paymentsStore.ListPaymentsPage(
+		connectors.PaymentsQuery{
+			Asset:         asset,
+			Status:        status,
+			Direction:     direction,
+			Media:         media,
+			System:        system,
+			UpdatedAfter:  req.UpdatedAfter,
+			UpdatedBefore: req.UpdatedBefore,
+			Limit:         int(req.Limit),
+			Cursor:        req.Cursor,
+		})
+	if err == connectors.ErrInvalidCursor {
+		err := newErrInvalidArgument("cursor")
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	} else if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	var protoPayments []*Payment
+	for _, payment := range payments {
+		protoPayment, err := convertPaymentToProto(payment)
+		if err != nil {
+			err := newErrInternal(err.Error())
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+
+		protoPayments = append(protoPayments, protoPayment)
+	}
+
+	resp := &ListPaymentsResponse{
+		Payments:   protoPayments,
+		NextCursor: cursor,
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+//
+// SubscribePayments is used to receive notifications about payments.
+// Every creation of the payment as well as every change of its status is
+// sent in the stream.
+func (s *Server) SubscribePayments(req *SubscribePaymentsRequest,
+	stream PayServer_SubscribePaymentsServer) error {
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	var (
+		asset     connectors.Asset
+		direction connectors.PaymentDirection
+		media     connectors.PaymentMedia
+		system    connectors.PaymentSystem
+		err       error
+	)
+
+	if req.Asset != Asset_ASSET_NONE {
+		asset, err = ConvertAssetFromProto(req.Asset)
+		if err != nil {
+			err := newErrInvalidArgument("asset")
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return err
+		}
+	}
+
+	if req.Direction != PaymentDirection_DIRECTION_NONE {
+		direction, err = ConvertPaymentDirectionFromProto(req.Direction)
+		if err != nil {
+			err := newErrInvalidArgument("direction")
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return err
+		}
+	}
+
+	if req.System != PaymentSystem_SYSTEM_NONE {
+		system, err = ConvertPaymentSystemFromProto(req.System)
+		if err != nil {
+			err := newErrInvalidArgument("system")
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return err
+		}
+	}
+
+	if req.Media != Media_MEDIA_NONE {
+		media, err = ConvertMediaFromProto(req.Media)
+		if err != nil {
+			err := newErrInvalidArgument("media")
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return err
+		}
+	}
+
+	subscription := s.paymentsHub.SubscribePayments(asset, media,
+		direction, system)
+	defer subscription.Cancel()
+
+	for {
+		select {
+		case payment, ok := <-subscription.Updates():
+			if !ok {
+				err := newErrInternal(subscription.Err().Error())
+				log.Errorf("command(%v), id(%v), error: %v",
+					common.GetFunctionName(), requestID, err)
+				s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+				return err
+			}
+
+			resp, err := convertPaymentToProto(payment)
+			if err != nil {
+				err := newErrInternal(err.Error())
+				log.Errorf("command(%v), id(%v), error: %v",
+					common.GetFunctionName(), requestID, err)
+				s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+				return err
+			}
+
+			log.Tracef("command(%v), id(%v), response(%v)",
+				common.GetFunctionName(), requestID, convertProtoMessage(resp))
+
+			if err := stream.Send(resp); err != nil {
+				log.Errorf("command(%v), id(%v), unable to send "+
+					"payment: %v", common.GetFunctionName(), requestID, err)
+				return err
+			}
+
+		case <-stream.Context().Done():
+			log.Tracef("command(%v), id(%v), subscription has been closed",
+				common.GetFunctionName(), requestID)
+			return nil
+		}
+	}
+}
+
+//
+// BakeMacaroon creates new macaroon which grants the given permissions.
+func (s *Server) BakeMacaroon(ctx context.Context,
+	req *BakeMacaroonRequest) (*BakeMacaroonResponse, error) {
+
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	if s.macaroonService == nil {
+		err := newErrMacaroonsDisabled()
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	permissions, err := macaroons.ParsePermissions(
+		strings.Join(req.Permissions, ","))
+	if err != nil {
+		err := newErrInvalidArgumentDesc("permissions", err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	m, err := s.macaroonService.BakeMacaroon(permissions)
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	data, err := m.MarshalBinary()
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	resp := &BakeMacaroonResponse{
+		Macaroon: hex.EncodeToString(data),
+	}
+
+	// Macaroon itself is not logged, because it is the credential.
+	log.Tracef("command(%v), id(%v), response(baked)",
+		common.GetFunctionName(), requestID)
+
+	return resp, nil
+}
+
+//
+// GetInfo returns the lifecycle and sync state of every connector, as well
+// as the state of the daemons behind them.
+func (s *Server) GetInfo(ctx context.Context,
+	req *GetInfoRequest) (*GetInfoResponse, error) {
+
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	resp := &GetInfoResponse{
+		Net: s.net,
+	}
+
+	for _, c := range s.registry.Connectors() {
+		info, err := convertConnectorStatusToProto(c.Asset, c.Media,
+			c.Connector.Status())
+		if err != nil {
+			err := newErrInternal(err.Error())
+			log.Errorf("command(%v), id(%v), error: %v",
+				common.GetFunctionName(), requestID, err)
+			s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+			return nil, err
+		}
+
+		resp.Connectors = append(resp.Connectors, info)
+	}
+
+	// Connectors are returned in the order of the proto enums, so that
+	// order doesn't depend on the asset and media names.
+	sort.Slice(resp.Connectors, func(i, j int) bool {
+		a, b := resp.Connectors[i], resp.Connectors[j]
+		if a.Media != b.Media {
+			return a.Media < b.Media
+		}
+		return a.Asset < b.Asset
+	})
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+//
+// ListFailedNotifications returns webhook notifications which haven't been
+// delivered after all attempts.
+func (s *Server) ListFailedNotifications(ctx context.Context,
+	req *ListFailedNotificationsRequest) (*ListFailedNotificationsResponse,
+	error) {
+
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	if s.notifier == nil {
+		err := newErrNotificationsDisabled()
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	events, err := s.notifier.FailedEvents()
+	if err != nil {
+		err := newErrInternal(err.Error())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	resp := &ListFailedNotificationsResponse{
+		Notifications: convertEventsToProto(events),
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+//
+// ReplayNotifications schedules failed webhook notifications for the
+// delivery once again.
+func (s *Server) ReplayNotifications(ctx context.Context,
+	req *ReplayNotificationsRequest) (*ReplayNotificationsResponse, error) {
+
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	if s.notifier == nil {
+		err := newErrNotificationsDisabled()
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	events, err := s.notifier.Replay(req.Ids...)
+	if err != nil {
+		if _, ok := err.(*notifier.ErrEventNotFound); ok {
+			err = newErrInvalidArgumentDesc("ids", err.Error())
+		} else {
+			err = newErrInternal(err.Error())
+		}
+
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	resp := &ReplayNotificationsResponse{
+		Notifications: convertEventsToProto(events),
+	}
+
+	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(resp))
+
+	return resp, nil
+}
+
+//
+// GetFeeRates returns current fee rates of the priorities, which are
+// estimated by the fee oracle of the blockchain connectors.
+func (s *Server) GetFeeRates(ctx context.Context,
+	req *GetFeeRatesRequest) (*GetFeeRatesResponse, error) {
+
+	requestID := rand.Int()
+
+	log.Tracef("command(%v), id(%v), request(%v)", common.GetFunctionName(),
+		requestID, convertProtoMessage(req))
+
+	asset, err := ConvertAssetFromProto(req.Asset)
+	if err != nil {
+		err := newErrInvalidArgument("asset")
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	resp := &GetFeeRatesResponse{}
+
+	for connectorAsset, c := range s.registry.BlockchainConnectors() {
+		if asset != "" && asset != connectorAsset {
+			continue
+		}
+
+		provider, ok := c.(connectors.FeeRatesProvider)
+		if !ok {
+			continue
+		}
+
+		for _, rate := range provider.FeeRates() {
+			protoRate, err := convertFeeRateToProto(rate)
+			if err != nil {
+				err := newErrInternal(err.Error())
+				log.Errorf("command(%v), id(%v), error: %v",
+					common.GetFunctionName(), requestID, err)
+				s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+				return nil, err
+			}
+
+			resp.Rates = append(resp.Rates, protoRate)
+		}
+	}
+
+	if asset != "" && len(resp.Rates) == 0 {
+		err := newErrAssetNotSupported(req.Asset.String(),
+			Media_BLOCKCHAIN.String())
+		log.Errorf("command(%v), id(%v), error: %v",
+			common.GetFunctionName(), requestID, err)
+		s.metrics.AddError(common.GetFunctionName(), string(metrics.LowSeverity))
+		return nil, err
+	}
+
+	// Fee rates are returned in the order of the proto enums, so that
+	// order doesn't depend on the map iteration.
+	sort.Slice(resp.Rates, func(i, j int) bool {
+		a, b := resp.Rates[i], resp.Rates[j]
+		if a.Asset != b.Asset {
+			return a.Asset < b.Asset
+		}
+		return a.Priority < b.Priority
+	})
+
 	log.Tracef("command(%v), id(%v), response(%v)", common.GetFunctionName(),
 		requestID, convertProtoMessage(resp))
 
@@ -3,18 +3,21 @@ package crpc
 import (
 	"fmt"
 	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/notifier"
 	"github.com/go-errors/errors"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
 )
 
-func convertProtoMessage(resp proto.Message) string {
-	jsonMarshaler := &jsonpb.Marshaler{
-		EmitDefaults: true,
-		Indent:       "    ",
-		OrigName:     true,
-	}
+// jsonMarshaler is used to encode proto messages in logs as well as in
+// responses of the rest gateway.
+var jsonMarshaler = &jsonpb.Marshaler{
+	EmitDefaults: true,
+	Indent:       "    ",
+	OrigName:     true,
+}
 
+func convertProtoMessage(resp proto.Message) string {
 	jsonStr, err := jsonMarshaler.MarshalToString(resp)
 	if err != nil {
 		return fmt.Sprintf("unable to decode response: %v", err)
@@ -34,6 +37,8 @@ func convertPaymentStatusToProto(status connectors.PaymentStatus) (PaymentStatus
 		protoStatus = PaymentStatus_PENDING
 	case connectors.Failed:
 		protoStatus = PaymentStatus_FAILED
+	case connectors.Reverted:
+		protoStatus = PaymentStatus_REVERTED
 	default:
 		protoStatus = PaymentStatus_STATUS_NONE
 	}
@@ -41,24 +46,16 @@ func convertPaymentStatusToProto(status connectors.PaymentStatus) (PaymentStatus
 	return protoStatus, nil
 }
 
+// convertAssetToProto converts asset to the proto enum with the same name,
+// so that adding new connector requires only adding the asset to the
+// proto file.
 func convertAssetToProto(asset connectors.Asset) (Asset, error) {
-	var protoAsset Asset
-	switch asset {
-	case connectors.BTC:
-		protoAsset = Asset_BTC
-	case connectors.BCH:
-		protoAsset = Asset_BCH
-	case connectors.ETH:
-		protoAsset = Asset_ETH
-	case connectors.LTC:
-		protoAsset = Asset_LTC
-	case connectors.DASH:
-		protoAsset = Asset_DASH
-	default:
-		protoAsset = Asset_ASSET_NONE
+	protoAsset, ok := Asset_value[string(asset)]
+	if !ok || asset == "" {
+		return Asset_ASSET_NONE, nil
 	}
 
-	return protoAsset, nil
+	return Asset(protoAsset), nil
 }
 
 func convertPaymentDirectionToProto(direction connectors.PaymentDirection) (PaymentDirection,
@@ -143,6 +140,9 @@ func convertPaymentToProto(payment *connectors.Payment) (*Payment, error) {
 		Amount:    payment.Amount.String(),
 		MediaFee:  payment.MediaFee.String(),
 		MediaId:   payment.MediaID,
+
+		IdempotencyKey: payment.IdempotencyKey,
+		FailureReason:  payment.FailureReason,
 	}, nil
 }
 
@@ -158,6 +158,8 @@ func ConvertPaymentStatusFromProto(protoStatus PaymentStatus) (
 		status = connectors.Pending
 	case PaymentStatus_FAILED:
 		status = connectors.Failed
+	case PaymentStatus_REVERTED:
+		status = connectors.Reverted
 	case PaymentStatus_STATUS_NONE:
 		status = ""
 	default:
@@ -169,26 +171,17 @@ func ConvertPaymentStatusFromProto(protoStatus PaymentStatus) (
 }
 
 func ConvertAssetFromProto(protoAsset Asset) (connectors.Asset, error) {
-	var asset connectors.Asset
-	switch protoAsset {
-	case Asset_BTC:
-		asset = connectors.BTC
-	case Asset_BCH:
-		asset = connectors.BCH
-	case Asset_ETH:
-		asset = connectors.ETH
-	case Asset_LTC:
-		asset = connectors.LTC
-	case Asset_DASH:
-		asset = connectors.DASH
-	case Asset_ASSET_NONE:
-		asset = ""
-	default:
-		return asset, errors.Errorf("unable convert unknown asset: %v",
+	if protoAsset == Asset_ASSET_NONE {
+		return "", nil
+	}
+
+	name, ok := Asset_name[int32(protoAsset)]
+	if !ok {
+		return "", errors.Errorf("unable convert unknown asset: %v",
 			protoAsset)
 	}
 
-	return asset, nil
+	return connectors.Asset(name), nil
 }
 
 func ConvertPaymentDirectionFromProto(protoDirection PaymentDirection) (
@@ -243,3 +236,129 @@ func ConvertMediaFromProto(protoMedia Media) (connectors.PaymentMedia, error) {
 
 	return media, nil
 }
+
+// ConvertFeePriorityFromProto converts proto priority to the connector one,
+// priority which isn't specified is the default one.
+func ConvertFeePriorityFromProto(protoPriority FeePriority) (
+	connectors.FeePriority, error) {
+	var priority connectors.FeePriority
+	switch protoPriority {
+	case FeePriority_FAST:
+		priority = connectors.FastPriority
+	case FeePriority_NORMAL:
+		priority = connectors.NormalPriority
+	case FeePriority_ECONOMY:
+		priority = connectors.EconomyPriority
+	case FeePriority_PRIORITY_NONE:
+		priority = connectors.DefaultPriority
+	default:
+		return priority, errors.Errorf("unable convert unknown priority: %v",
+			protoPriority)
+	}
+
+	return priority, nil
+}
+
+func convertFeePriorityToProto(priority connectors.FeePriority) FeePriority {
+	switch priority {
+	case connectors.FastPriority:
+		return FeePriority_FAST
+	case connectors.NormalPriority:
+		return FeePriority_NORMAL
+	case connectors.EconomyPriority:
+		return FeePriority_ECONOMY
+	default:
+		return FeePriority_PRIORITY_NONE
+	}
+}
+
+func convertFeeRateToProto(rate *connectors.FeeRate) (*FeeRate, error) {
+	asset, err := convertAssetToProto(rate.Asset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeeRate{
+		Asset:      asset,
+		Priority:   convertFeePriorityToProto(rate.Priority),
+		ConfTarget: rate.ConfTarget,
+		FeeRate:    rate.Rate.String(),
+		Estimated:  rate.Estimated,
+		UpdatedAt:  rate.UpdatedAt,
+	}, nil
+}
+
+func convertConnectorStateToProto(state connectors.ConnectorState) ConnectorState {
+	switch state {
+	case connectors.Starting:
+		return ConnectorState_STARTING
+	case connectors.Running:
+		return ConnectorState_RUNNING
+	case connectors.StartFailed:
+		return ConnectorState_START_FAILED
+	case connectors.Stopped:
+		return ConnectorState_STOPPED
+	default:
+		return ConnectorState_CONNECTOR_STATE_NONE
+	}
+}
+
+func convertConnectorStatusToProto(asset connectors.Asset,
+	media connectors.PaymentMedia,
+	status *connectors.ConnectorStatus) (*ConnectorInfo, error) {
+
+	protoAsset, err := convertAssetToProto(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	protoMedia, err := convertMediaToProto(media)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ConnectorInfo{
+		Asset:            protoAsset,
+		Media:            protoMedia,
+		State:            convertConnectorStateToProto(status.State),
+		StartError:       status.StartError,
+		DaemonError:      status.DaemonError,
+		Net:              status.Net,
+		MinConfirmations: int32(status.MinConfirmations),
+		DaemonVersion:    status.DaemonVersion,
+		BestHeight:       status.BestHeight,
+		SyncedHeight:     status.SyncedHeight,
+	}
+
+	if status.Lightning != nil && status.Lightning.GetInfoResponse != nil {
+		info.Lightning = &LightningInfo{
+			Pubkey:             status.Lightning.IdentityPubkey,
+			Alias:              status.Lightning.Alias,
+			Host:               status.Lightning.Host,
+			Port:               status.Lightning.Port,
+			NumPendingChannels: status.Lightning.NumPendingChannels,
+			NumActiveChannels:  status.Lightning.NumActiveChannels,
+			NumPeers:           status.Lightning.NumPeers,
+			SyncedToChain:      status.Lightning.SyncedToChain,
+		}
+	}
+
+	return info, nil
+}
+
+func convertEventsToProto(events []*notifier.Event) []*Notification {
+	notifications := make([]*Notification, len(events))
+	for i, event := range events {
+		notifications[i] = &Notification{
+			Id:        event.ID,
+			Url:       event.URL,
+			PaymentId: event.PaymentID,
+			Payload:   string(event.Payload),
+			Attempts:  int32(event.Attempts),
+			LastError: event.LastError,
+			CreatedAt: connectors.ConvertTimeToMilliSeconds(event.CreatedAt),
+		}
+	}
+
+	return notifications
+}
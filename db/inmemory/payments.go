@@ -57,6 +57,22 @@ func (s *MemoryPaymentsStore) PaymentByReceipt(receipt string) ([]*connectors.Pa
 	return payments, nil
 }
 
+// PaymentByIdempotencyKey returns payment which was sent with the given
+// idempotency key.
+func (s *MemoryPaymentsStore) PaymentByIdempotencyKey(key string) (
+	*connectors.Payment, error) {
+	s.paymentsMutex.RLock()
+	defer s.paymentsMutex.RUnlock()
+
+	for _, payment := range s.paymentsByID {
+		if payment.IdempotencyKey == key {
+			return payment, nil
+		}
+	}
+
+	return nil, connectors.PaymentNotFound
+}
+
 // SavePayment adds payment to the store.
 func (s *MemoryPaymentsStore) SavePayment(p *connectors.Payment) error {
 	s.paymentsMutex.Lock()
@@ -64,6 +80,13 @@ func (s *MemoryPaymentsStore) SavePayment(p *connectors.Payment) error {
 
 	payment := &connectors.Payment{}
 	*payment = *p
+
+	// Connectors are not aware of idempotency key during payment
+	// synchronisation, for that reason we shouldn't overwrite it.
+	if old, ok := s.paymentsByID[p.PaymentID]; ok && payment.IdempotencyKey == "" {
+		payment.IdempotencyKey = old.IdempotencyKey
+	}
+
 	s.paymentsByID[p.PaymentID] = payment
 	return nil
 }
@@ -73,37 +96,72 @@ func (s *MemoryPaymentsStore) ListPayments(asset connectors.Asset,
 	status connectors.PaymentStatus, direction connectors.PaymentDirection,
 	media connectors.PaymentMedia, system connectors.PaymentSystem) ([]*connectors.Payment, error) {
 
+	payments, _, err := s.ListPaymentsPage(connectors.PaymentsQuery{
+		Asset:     asset,
+		Status:    status,
+		Direction: direction,
+		Media:     media,
+		System:    system,
+	})
+	return payments, err
+}
+
+// ListPaymentsPage returns page of payments which corresponds to the
+// given query, ordered from the most recently updated ones, as well as
+// cursor which should be used to fetch the next page.
+func (s *MemoryPaymentsStore) ListPaymentsPage(query connectors.PaymentsQuery) (
+	[]*connectors.Payment, string, error) {
+
+	var (
+		cursorUpdatedAt int64
+		cursorPaymentID string
+		err             error
+	)
+
+	if query.Cursor != "" {
+		cursorUpdatedAt, cursorPaymentID, err = connectors.DecodePaymentsCursor(
+			query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
 	s.paymentsMutex.RLock()
 	defer s.paymentsMutex.RUnlock()
 
 	var payments []*connectors.Payment
 	for _, payment := range s.paymentsByID {
-		if asset != "" && payment.Asset != asset {
-			continue
-		}
-
-		if status != "" && payment.Status != status {
-			continue
-		}
-
-		if direction != "" && payment.Direction != direction {
+		if !query.Match(payment) {
 			continue
 		}
 
-		if media != "" && payment.Media != media {
-			continue
-		}
+		if query.Cursor != "" {
+			if payment.UpdatedAt > cursorUpdatedAt {
+				continue
+			}
 
-		if system != "" && payment.System != system {
-			continue
+			if payment.UpdatedAt == cursorUpdatedAt &&
+				payment.PaymentID >= cursorPaymentID {
+				continue
+			}
 		}
 
 		payments = append(payments, payment)
 	}
 
 	sort.Slice(payments, func(i, j int) bool {
+		if payments[i].UpdatedAt == payments[j].UpdatedAt {
+			return payments[i].PaymentID > payments[j].PaymentID
+		}
+
 		return payments[i].UpdatedAt > payments[j].UpdatedAt
 	})
 
-	return payments, nil
+	var cursor string
+	if query.Limit > 0 && len(payments) > query.Limit {
+		payments = payments[:query.Limit]
+		cursor = connectors.EncodePaymentsCursor(payments[len(payments)-1])
+	}
+
+	return payments, cursor, nil
 }
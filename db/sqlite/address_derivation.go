@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/jinzhu/gorm"
+)
+
+type AddressDerivation struct {
+	UpdatedAt time.Time
+
+	Asset string `gorm:"primary_key"`
+	XPub  string `gorm:"primary_key"`
+
+	// NextIndex is the index of the next address which should be derived
+	// from the extended public key.
+	NextIndex uint32
+}
+
+// AddressDerivationStorage is used to keep the indexes of the addresses
+// derived from the extended public keys.
+type AddressDerivationStorage struct {
+	db *DB
+}
+
+func NewAddressDerivationStorage(db *DB) *AddressDerivationStorage {
+	return &AddressDerivationStorage{
+		db: db,
+	}
+}
+
+// Runtime check to ensure that AddressDerivationStorage implements
+// connectors.DerivationStorage interface.
+var _ connectors.DerivationStorage = (*AddressDerivationStorage)(nil)
+
+// NextDerivationIndex reserves and returns the index of the next address
+// which should be derived from the extended public key.
+//
+// NOTE: Part of the connectors.DerivationStorage interface.
+func (s *AddressDerivationStorage) NextDerivationIndex(asset connectors.Asset,
+	xpub string) (uint32, error) {
+
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	tx := s.db.Begin()
+
+	derivation := &AddressDerivation{}
+	err := tx.Where("asset = ? AND x_pub = ?", string(asset),
+		xpub).Find(derivation).Error
+	if gorm.IsRecordNotFoundError(err) {
+		derivation = &AddressDerivation{
+			Asset: string(asset),
+			XPub:  xpub,
+		}
+	} else if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	index := derivation.NextIndex
+	derivation.NextIndex++
+
+	if err := tx.Save(derivation).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+
+	return index, nil
+}
+
+// DerivationIndex returns the index of the next address which should be
+// derived from the extended public key, without reserving it.
+//
+// NOTE: Part of the connectors.DerivationStorage interface.
+func (s *AddressDerivationStorage) DerivationIndex(asset connectors.Asset,
+	xpub string) (uint32, error) {
+
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	derivation := &AddressDerivation{}
+	err := s.db.Where("asset = ? AND x_pub = ?", string(asset),
+		xpub).Find(derivation).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return derivation.NextIndex, nil
+}
@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/bitlum/connector/connectors"
+)
+
+func TestAddressDerivationStorage(t *testing.T) {
+	db, clear, err := MakeTestDB()
+	if err != nil {
+		t.Fatalf("unable to create test database: %v", err)
+	}
+	defer clear()
+
+	storage := NewAddressDerivationStorage(db)
+
+	for _, test := range []struct {
+		asset    connectors.Asset
+		xpub     string
+		expected uint32
+	}{
+		{connectors.BTC, "xpub1", 0},
+		{connectors.BTC, "xpub1", 1},
+		{connectors.BTC, "xpub2", 0},
+		{connectors.LTC, "xpub1", 0},
+		{connectors.BTC, "xpub1", 2},
+	} {
+		index, err := storage.NextDerivationIndex(test.asset, test.xpub)
+		if err != nil {
+			t.Fatalf("unable to get derivation index: %v", err)
+		}
+
+		if index != test.expected {
+			t.Fatalf("wrong index of %v key(%v), expected: %v, actual: %v",
+				test.asset, test.xpub, test.expected, index)
+		}
+	}
+
+	// Index of the next address shouldn't be reserved when it is read.
+	for i := 0; i < 2; i++ {
+		index, err := storage.DerivationIndex(connectors.BTC, "xpub1")
+		if err != nil {
+			t.Fatalf("unable to get derivation index: %v", err)
+		}
+
+		if index != 3 {
+			t.Fatalf("wrong next index, expected: 3, actual: %v", index)
+		}
+	}
+
+	index, err := storage.DerivationIndex(connectors.BTC, "xpub3")
+	if err != nil || index != 0 {
+		t.Fatalf("index of unknown key should be zero: %v", err)
+	}
+}
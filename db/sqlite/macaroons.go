@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/bitlum/connector/macaroons"
+	"github.com/jinzhu/gorm"
+)
+
+// macaroonRootKeyID is the id of the single root key record.
+const macaroonRootKeyID = 1
+
+type MacaroonRootKey struct {
+	CreatedAt time.Time
+
+	ID  uint `gorm:"primary_key"`
+	Key []byte
+}
+
+// MacaroonRootKeyStorage is used to keep the root key with which all
+// macaroons of the rpc server are signed.
+type MacaroonRootKeyStorage struct {
+	db *DB
+}
+
+func NewMacaroonRootKeyStorage(db *DB) *MacaroonRootKeyStorage {
+	return &MacaroonRootKeyStorage{
+		db: db,
+	}
+}
+
+// Runtime check to ensure that MacaroonRootKeyStorage implements
+// macaroons.RootKeyStorage interface.
+var _ macaroons.RootKeyStorage = (*MacaroonRootKeyStorage)(nil)
+
+// RootKey returns root key, nil is returned if key hasn't been created yet.
+//
+// NOTE: Part of the macaroons.RootKeyStorage interface.
+func (s *MacaroonRootKeyStorage) RootKey() ([]byte, error) {
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	rootKey := &MacaroonRootKey{}
+	err := s.db.Where("id = ?", macaroonRootKeyID).Find(rootKey).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return rootKey.Key, nil
+}
+
+// PutRootKey saves the root key.
+//
+// NOTE: Part of the macaroons.RootKeyStorage interface.
+func (s *MacaroonRootKeyStorage) PutRootKey(key []byte) error {
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	return s.db.Save(&MacaroonRootKey{
+		ID:  macaroonRootKeyID,
+		Key: key,
+	}).Error
+}
@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMacaroonRootKeyStorage(t *testing.T) {
+	db, clear, err := MakeTestDB()
+	if err != nil {
+		t.Fatalf("unable to create test database: %v", err)
+	}
+	defer clear()
+
+	storage := NewMacaroonRootKeyStorage(db)
+
+	key, err := storage.RootKey()
+	if err != nil {
+		t.Fatalf("unable to get root key: %v", err)
+	}
+
+	if key != nil {
+		t.Fatalf("root key shouldn't exist")
+	}
+
+	if err := storage.PutRootKey([]byte("root_key")); err != nil {
+		t.Fatalf("unable to put root key: %v", err)
+	}
+
+	key, err = storage.RootKey()
+	if err != nil {
+		t.Fatalf("unable to get root key: %v", err)
+	}
+
+	if !bytes.Equal(key, []byte("root_key")) {
+		t.Fatalf("wrong root key")
+	}
+}
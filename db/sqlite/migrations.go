@@ -12,7 +12,12 @@ func (db *DB) Migrate() error {
 		&ConnectorState{},
 		&EthereumAddress{},
 		&Payment{},
-		&BitcoinSimpleState{},
+		&MacaroonRootKey{},
+		&NotifierEvent{},
+		&PaymentAttempts{},
+		&TxReplacement{},
+		&BatchedOutput{},
+		&AddressDerivation{},
 	).Error; err != nil {
 		return err
 	}
@@ -26,6 +31,7 @@ func migrate(gdb *gorm.DB, migrations []*gormigrate.Migration) error {
 
 var allMigrations = []*gormigrate.Migration{
 	addPaymentSystemType,
+	removeBitcoinSimpleTxCounter,
 }
 
 var addPaymentSystemType = &gormigrate.Migration{
@@ -76,3 +82,42 @@ var addPaymentSystemType = &gormigrate.Migration{
 		return nil
 	},
 }
+
+// removeBitcoinSimpleTxCounter drops the table in which bitcoind simple
+// connector has kept the number of synced wallet transactions. Now connector
+// syncs payments from the last processed block, which is kept in the
+// connector state storage. Tx counter couldn't be converted to the block
+// hash, that is why connector starts without the cursor and syncs the whole
+// wallet once, which is safe because already saved payments are merged
+// rather than overwritten.
+var removeBitcoinSimpleTxCounter = &gormigrate.Migration{
+	ID: "remove_bitcoin_simple_tx_counter",
+	Migrate: func(tx *gorm.DB) error {
+		if !tx.HasTable(bitcoinSimpleStatesTable) {
+			return nil
+		}
+
+		var states []struct {
+			Asset     string
+			TxCounter int
+		}
+
+		err := tx.Table(bitcoinSimpleStatesTable).Select("asset, tx_counter").
+			Scan(&states).Error
+		if err != nil {
+			return err
+		}
+
+		for _, state := range states {
+			log.Infof("Remove tx counter(%v) of bitcoind simple connector "+
+				"(%v), payments will be resynced from the first block",
+				state.TxCounter, state.Asset)
+		}
+
+		return tx.DropTable(bitcoinSimpleStatesTable).Error
+	},
+}
+
+// bitcoinSimpleStatesTable is the name of the table in which tx counter of
+// the bitcoind simple connector has been kept.
+const bitcoinSimpleStatesTable = "bitcoin_simple_states"
@@ -2,6 +2,8 @@ package sqlite
 
 import (
 	"gopkg.in/gormigrate.v1"
+	"io/ioutil"
+	"os"
 	"testing"
 )
 
@@ -14,8 +16,54 @@ func TestAddPaymentStatusMigration(t *testing.T) {
 	tx := db.Begin()
 	defer tx.Rollback()
 
+	// Migrations are applied after the models auto migration, so that they
+	// could operate with the latest schema.
+	if err := tx.AutoMigrate(&Payment{}).Error; err != nil {
+		t.Fatalf("unable auto migrate db: %v", err)
+	}
+
 	err = migrate(tx, []*gormigrate.Migration{addPaymentSystemType})
 	if err != nil {
 		t.Fatalf("unable migrate db: %v", err)
 	}
 }
+
+func TestRemoveBitcoinSimpleTxCounterMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatalf("unable create test dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := Open(dir, "sqlite.db", false)
+	if err != nil {
+		t.Fatalf("unable create test db: %v", err)
+	}
+	defer db.Close()
+
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	type BitcoinSimpleState struct {
+		Asset     string `gorm:"primary_key"`
+		TxCounter int
+	}
+
+	if err := tx.AutoMigrate(&BitcoinSimpleState{}).Error; err != nil {
+		t.Fatalf("unable auto migrate db: %v", err)
+	}
+
+	state := &BitcoinSimpleState{Asset: "BTC", TxCounter: 10}
+	if err := tx.Save(state).Error; err != nil {
+		t.Fatalf("unable to save state: %v", err)
+	}
+
+	err = migrate(tx, []*gormigrate.Migration{removeBitcoinSimpleTxCounter})
+	if err != nil {
+		t.Fatalf("unable migrate db: %v", err)
+	}
+
+	if tx.HasTable(bitcoinSimpleStatesTable) {
+		t.Fatalf("tx counter table should be removed")
+	}
+}
@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/bitlum/connector/notifier"
+)
+
+type NotifierEvent struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	ID          string `gorm:"primary_key"`
+	URL         string
+	PaymentID   string
+	Payload     []byte
+	State       string `gorm:"index"`
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string
+}
+
+// NotifierEventStorage is used to keep webhook events which haven't been
+// delivered yet.
+type NotifierEventStorage struct {
+	db *DB
+}
+
+func NewNotifierEventStorage(db *DB) *NotifierEventStorage {
+	return &NotifierEventStorage{
+		db: db,
+	}
+}
+
+// Runtime check to ensure that NotifierEventStorage implements
+// notifier.EventStorage interface.
+var _ notifier.EventStorage = (*NotifierEventStorage)(nil)
+
+// AddEvent saves new event or updates existing one.
+//
+// NOTE: Part of the notifier.EventStorage interface.
+func (s *NotifierEventStorage) AddEvent(event *notifier.Event) error {
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	return s.db.Save(&NotifierEvent{
+		CreatedAt:   event.CreatedAt,
+		ID:          event.ID,
+		URL:         event.URL,
+		PaymentID:   event.PaymentID,
+		Payload:     event.Payload,
+		State:       string(event.State),
+		Attempts:    event.Attempts,
+		NextAttempt: event.NextAttempt,
+		LastError:   event.LastError,
+	}).Error
+}
+
+// RemoveEvent removes event from the storage.
+//
+// NOTE: Part of the notifier.EventStorage interface.
+func (s *NotifierEventStorage) RemoveEvent(id string) error {
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	return s.db.Delete(&NotifierEvent{}, "id = ?", id).Error
+}
+
+// EventsByState returns events with the given state ordered by the
+// creation time.
+//
+// NOTE: Part of the notifier.EventStorage interface.
+func (s *NotifierEventStorage) EventsByState(state notifier.EventState) (
+	[]*notifier.Event, error) {
+
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	var dbEvents []*NotifierEvent
+	err := s.db.Where("state = ?", string(state)).
+		Order("created_at asc").
+		Find(&dbEvents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*notifier.Event, len(dbEvents))
+	for i, e := range dbEvents {
+		events[i] = &notifier.Event{
+			ID:          e.ID,
+			URL:         e.URL,
+			PaymentID:   e.PaymentID,
+			Payload:     e.Payload,
+			State:       notifier.EventState(e.State),
+			Attempts:    e.Attempts,
+			NextAttempt: e.NextAttempt,
+			LastError:   e.LastError,
+			CreatedAt:   e.CreatedAt,
+		}
+	}
+
+	return events, nil
+}
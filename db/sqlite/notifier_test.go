@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitlum/connector/notifier"
+)
+
+func TestNotifierEventStorage(t *testing.T) {
+	db, clear, err := MakeTestDB()
+	if err != nil {
+		t.Fatalf("unable to create test database: %v", err)
+	}
+	defer clear()
+
+	storage := NewNotifierEventStorage(db)
+
+	now := time.Now()
+	for i, id := range []string{"1", "2"} {
+		err := storage.AddEvent(&notifier.Event{
+			ID:          id,
+			URL:         "http://localhost",
+			PaymentID:   "payment",
+			Payload:     []byte("{}"),
+			State:       notifier.EventPending,
+			NextAttempt: now,
+			CreatedAt:   now.Add(time.Duration(i) * time.Second),
+		})
+		if err != nil {
+			t.Fatalf("unable to add event: %v", err)
+		}
+	}
+
+	events, err := storage.EventsByState(notifier.EventPending)
+	if err != nil {
+		t.Fatalf("unable to get events: %v", err)
+	}
+
+	if len(events) != 2 || events[0].ID != "1" ||
+		string(events[0].Payload) != "{}" {
+		t.Fatalf("wrong events: %v", events)
+	}
+
+	events[0].State = notifier.EventFailed
+	events[0].Attempts = 3
+	events[0].LastError = "timeout"
+	if err := storage.AddEvent(events[0]); err != nil {
+		t.Fatalf("unable to update event: %v", err)
+	}
+
+	failed, err := storage.EventsByState(notifier.EventFailed)
+	if err != nil {
+		t.Fatalf("unable to get events: %v", err)
+	}
+
+	if len(failed) != 1 || failed[0].Attempts != 3 ||
+		failed[0].LastError != "timeout" ||
+		!failed[0].CreatedAt.Equal(events[0].CreatedAt) {
+		t.Fatalf("wrong failed events: %v", failed)
+	}
+
+	if err := storage.RemoveEvent("2"); err != nil {
+		t.Fatalf("unable to remove event: %v", err)
+	}
+
+	events, err = storage.EventsByState(notifier.EventPending)
+	if err != nil {
+		t.Fatalf("unable to get events: %v", err)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("event hasn't been removed")
+	}
+}
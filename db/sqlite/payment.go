@@ -4,8 +4,8 @@ import (
 	"bytes"
 	"github.com/bitlum/connector/connectors"
 	"github.com/go-errors/errors"
+	"github.com/jinzhu/gorm"
 	"github.com/shopspring/decimal"
-	"sort"
 )
 
 type PaymentsStore struct {
@@ -24,7 +24,7 @@ type Payment struct {
 	PaymentID string `gorm:"primary_key"`
 
 	// UpdatedAt denotes the time when payment object has been last updated.
-	UpdatedAt int64
+	UpdatedAt int64 `gorm:"index"`
 
 	// Status denotes the stage of the processing the payment.
 	Status string
@@ -71,6 +71,14 @@ type Payment struct {
 
 	// DetailType is used to identify details type, to decode it properly.
 	DetailType int
+
+	// IdempotencyKey is the key which was specified by the client on
+	// payment sending, it is used to prevent sending the same payment twice.
+	IdempotencyKey string `gorm:"index"`
+
+	// FailureReason is the error because of which payment has been marked
+	// as failed.
+	FailureReason string
 }
 
 // Runtime check to ensure that PaymentStore implements
@@ -118,6 +126,26 @@ func (s *PaymentsStore) PaymentByReceipt(receipt string) ([]*connectors.Payment,
 	return payments, nil
 }
 
+// PaymentByIdempotencyKey returns payment which was sent with the given
+// idempotency key.
+//
+// NOTE: Part of the connectors.PaymentsStore interface.
+func (s *PaymentsStore) PaymentByIdempotencyKey(key string) (*connectors.Payment,
+	error) {
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	dbPayment := &Payment{}
+	err := s.db.Where("idempotency_key = ?", key).First(dbPayment).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, connectors.PaymentNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return convertPaymentFrom(dbPayment)
+}
+
 // SavePayment add payment to the store.
 //
 // NOTE: Part of the connectors.PaymentsStore interface.
@@ -130,7 +158,14 @@ func (s *PaymentsStore) SavePayment(payment *connectors.Payment) error {
 		return err
 	}
 
-	return s.db.Save(dbPayment).Error
+	// Connectors are not aware of idempotency key during payment
+	// synchronisation, for that reason we shouldn't overwrite it.
+	db := s.db.DB
+	if dbPayment.IdempotencyKey == "" {
+		db = db.Omit("idempotency_key")
+	}
+
+	return db.Save(dbPayment).Error
 }
 
 // ListPayments return list of all payments.
@@ -141,52 +176,102 @@ func (s *PaymentsStore) ListPayments(asset connectors.Asset,
 	media connectors.PaymentMedia, system connectors.PaymentSystem) (
 	[]*connectors.Payment, error) {
 
+	payments, _, err := s.ListPaymentsPage(connectors.PaymentsQuery{
+		Asset:     asset,
+		Status:    status,
+		Direction: direction,
+		Media:     media,
+		System:    system,
+	})
+	return payments, err
+}
+
+// ListPaymentsPage returns page of payments which corresponds to the
+// given query, ordered from the most recently updated ones, as well as
+// cursor which should be used to fetch the next page.
+//
+// NOTE: Part of the connectors.PaymentsStore interface.
+func (s *PaymentsStore) ListPaymentsPage(query connectors.PaymentsQuery) (
+	[]*connectors.Payment, string, error) {
+
 	s.db.globalMutex.Lock()
 	defer s.db.globalMutex.Unlock()
 
 	db := s.db.DB
 
-	if asset != "" {
-		db = db.Where("asset = ?", asset)
+	if query.Asset != "" {
+		db = db.Where("asset = ?", query.Asset)
 	}
 
-	if status != "" {
-		db = db.Where("status = ?", status)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
 	}
 
-	if direction != "" {
-		db = db.Where("direction = ?", direction)
+	if query.Direction != "" {
+		db = db.Where("direction = ?", query.Direction)
 	}
 
-	if media != "" {
-		db = db.Where("media = ?", media)
+	if query.Media != "" {
+		db = db.Where("media = ?", query.Media)
 	}
 
-	if system != "" {
-		db = db.Where("system = ?", system)
+	if query.System != "" {
+		db = db.Where("system = ?", query.System)
+	}
+
+	if query.UpdatedAfter != 0 {
+		db = db.Where("updated_at > ?", query.UpdatedAfter)
+	}
+
+	if query.UpdatedBefore != 0 {
+		db = db.Where("updated_at < ?", query.UpdatedBefore)
+	}
+
+	if query.Cursor != "" {
+		updatedAt, paymentID, err := connectors.DecodePaymentsCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		db = db.Where("updated_at < ? OR (updated_at = ? AND payment_id < ?)",
+			updatedAt, updatedAt, paymentID)
+	}
+
+	db = db.Order("updated_at desc").Order("payment_id desc")
+
+	// Fetch one more payment than needed, in order to understand is there
+	// a next page.
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit + 1)
 	}
 
 	var dbPayments []*Payment
 	err := db.Find(&dbPayments).Error
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	hasNextPage := query.Limit > 0 && len(dbPayments) > query.Limit
+	if hasNextPage {
+		dbPayments = dbPayments[:query.Limit]
 	}
 
 	var payments []*connectors.Payment
 	for _, dbPayment := range dbPayments {
 		payment, err := convertPaymentFrom(dbPayment)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		payments = append(payments, payment)
 	}
 
-	sort.Slice(payments, func(i, j int) bool {
-		return payments[i].UpdatedAt > payments[j].UpdatedAt
-	})
+	var cursor string
+	if hasNextPage {
+		cursor = connectors.EncodePaymentsCursor(payments[len(payments)-1])
+	}
 
-	return payments, nil
+	return payments, cursor, nil
 }
 
 func convertPaymentTo(payment *connectors.Payment) (*Payment, error) {
@@ -206,6 +291,10 @@ func convertPaymentTo(payment *connectors.Payment) (*Payment, error) {
 			detailType = 1
 		case *connectors.BlockchainPendingDetails:
 			detailType = 2
+		case *connectors.BatchedTxDetails:
+			detailType = 3
+		case *connectors.PSBTDetails:
+			detailType = 4
 		default:
 			return nil, errors.Errorf("unknown details type: %v", payment.Detail)
 		}
@@ -226,6 +315,9 @@ func convertPaymentTo(payment *connectors.Payment) (*Payment, error) {
 		MediaID:    payment.MediaID,
 		Detail:     details,
 		DetailType: detailType,
+
+		IdempotencyKey: payment.IdempotencyKey,
+		FailureReason:  payment.FailureReason,
 	}
 
 	return dbPayment, nil
@@ -240,6 +332,10 @@ func convertPaymentFrom(dbPayment *Payment) (*connectors.Payment, error) {
 			detail = &connectors.GeneratedTxDetails{}
 		case 2:
 			detail = &connectors.BlockchainPendingDetails{}
+		case 3:
+			detail = &connectors.BatchedTxDetails{}
+		case 4:
+			detail = &connectors.PSBTDetails{}
 		default:
 			return nil, errors.Errorf("unknown details type: %v", dbPayment.DetailType)
 		}
@@ -274,6 +370,9 @@ func convertPaymentFrom(dbPayment *Payment) (*connectors.Payment, error) {
 		MediaFee:  mediaFee,
 		MediaID:   dbPayment.MediaID,
 		Detail:    detail,
+
+		IdempotencyKey: dbPayment.IdempotencyKey,
+		FailureReason:  dbPayment.FailureReason,
 	}
 
 	return payment, nil
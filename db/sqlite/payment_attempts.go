@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/bitlum/connector/connectors"
+)
+
+type PaymentAttempts struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	PaymentID   string `gorm:"primary_key"`
+	Asset       string `gorm:"index"`
+	Media       string
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string
+}
+
+// PaymentAttemptsStorage is used to keep attempts of the outgoing payments
+// which are being retried.
+type PaymentAttemptsStorage struct {
+	db *DB
+}
+
+func NewPaymentAttemptsStorage(db *DB) *PaymentAttemptsStorage {
+	return &PaymentAttemptsStorage{
+		db: db,
+	}
+}
+
+// Runtime check to ensure that PaymentAttemptsStorage implements
+// connectors.AttemptsStorage interface.
+var _ connectors.AttemptsStorage = (*PaymentAttemptsStorage)(nil)
+
+// PutAttempts saves attempts of the payment, or updates existing ones.
+//
+// NOTE: Part of the connectors.AttemptsStorage interface.
+func (s *PaymentAttemptsStorage) PutAttempts(
+	attempts *connectors.PaymentAttempts) error {
+
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	return s.db.Save(&PaymentAttempts{
+		PaymentID:   attempts.PaymentID,
+		Asset:       string(attempts.Asset),
+		Media:       string(attempts.Media),
+		Attempts:    attempts.Attempts,
+		NextAttempt: attempts.NextAttempt,
+		LastError:   attempts.LastError,
+	}).Error
+}
+
+// RemoveAttempts removes attempts of the payment.
+//
+// NOTE: Part of the connectors.AttemptsStorage interface.
+func (s *PaymentAttemptsStorage) RemoveAttempts(paymentID string) error {
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	return s.db.Delete(&PaymentAttempts{}, "payment_id = ?", paymentID).Error
+}
+
+// ListAttempts returns attempts of the payments which are retried by the
+// connector of the given asset and media.
+//
+// NOTE: Part of the connectors.AttemptsStorage interface.
+func (s *PaymentAttemptsStorage) ListAttempts(asset connectors.Asset,
+	media connectors.PaymentMedia) ([]*connectors.PaymentAttempts, error) {
+
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	var dbAttempts []*PaymentAttempts
+	err := s.db.Where("asset = ? AND media = ?", string(asset), string(media)).
+		Order("next_attempt asc").
+		Find(&dbAttempts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*connectors.PaymentAttempts, len(dbAttempts))
+	for i, a := range dbAttempts {
+		list[i] = &connectors.PaymentAttempts{
+			PaymentID:   a.PaymentID,
+			Asset:       connectors.Asset(a.Asset),
+			Media:       connectors.PaymentMedia(a.Media),
+			Attempts:    a.Attempts,
+			NextAttempt: a.NextAttempt,
+			LastError:   a.LastError,
+		}
+	}
+
+	return list, nil
+}
@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitlum/connector/connectors"
+)
+
+func TestPaymentAttemptsStorage(t *testing.T) {
+	db, clear, err := MakeTestDB()
+	if err != nil {
+		t.Fatalf("unable to create test database: %v", err)
+	}
+	defer clear()
+
+	storage := NewPaymentAttemptsStorage(db)
+
+	now := time.Now()
+	for i, asset := range []connectors.Asset{connectors.ETH,
+		connectors.ETH, connectors.BTC} {
+
+		err := storage.PutAttempts(&connectors.PaymentAttempts{
+			PaymentID:   string(rune('a' + i)),
+			Asset:       asset,
+			Media:       connectors.Blockchain,
+			Attempts:    1,
+			NextAttempt: now.Add(-time.Duration(i) * time.Second),
+			LastError:   "timeout",
+		})
+		if err != nil {
+			t.Fatalf("unable to put attempts: %v", err)
+		}
+	}
+
+	list, err := storage.ListAttempts(connectors.ETH, connectors.Blockchain)
+	if err != nil {
+		t.Fatalf("unable to list attempts: %v", err)
+	}
+
+	// Attempts should be ordered by the time of the next attempt.
+	if len(list) != 2 || list[0].PaymentID != "b" ||
+		list[0].LastError != "timeout" {
+		t.Fatalf("wrong attempts: %v", list)
+	}
+
+	list[0].Attempts = 2
+	if err := storage.PutAttempts(list[0]); err != nil {
+		t.Fatalf("unable to update attempts: %v", err)
+	}
+
+	if err := storage.RemoveAttempts("a"); err != nil {
+		t.Fatalf("unable to remove attempts: %v", err)
+	}
+
+	list, err = storage.ListAttempts(connectors.ETH, connectors.Blockchain)
+	if err != nil {
+		t.Fatalf("unable to list attempts: %v", err)
+	}
+
+	if len(list) != 1 || list[0].PaymentID != "b" || list[0].Attempts != 2 {
+		t.Fatalf("wrong attempts: %v", list)
+	}
+
+	list, err = storage.ListAttempts(connectors.BTC, connectors.Lightning)
+	if err != nil {
+		t.Fatalf("unable to list attempts: %v", err)
+	}
+
+	if len(list) != 0 {
+		t.Fatalf("attempts of other connector shouldn't be listed")
+	}
+}
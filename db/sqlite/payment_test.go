@@ -4,6 +4,7 @@ import (
 	"github.com/bitlum/connector/connectors"
 	"github.com/shopspring/decimal"
 	"reflect"
+	"strconv"
 	"testing"
 )
 
@@ -70,7 +71,9 @@ func TestPaymentsStorage(t *testing.T) {
 		t.Fatalf("unable to get payments: %v", err)
 	}
 
-	if !reflect.DeepEqual(paymentsBefore, paymentsAfter) {
+	// Payments should be ordered from the most recently updated ones.
+	if !reflect.DeepEqual([]*connectors.Payment{paymentsBefore[1],
+		paymentsBefore[0]}, paymentsAfter) {
 		t.Fatalf("wrong data")
 	}
 
@@ -80,7 +83,7 @@ func TestPaymentsStorage(t *testing.T) {
 			t.Fatalf("unable to get payment by receipt: %v", err)
 		}
 
-		if !reflect.DeepEqual(payment, paymentsAfter[0]) {
+		if !reflect.DeepEqual(payment, paymentsBefore[0]) {
 			t.Fatalf("wrong data")
 		}
 	}
@@ -91,7 +94,7 @@ func TestPaymentsStorage(t *testing.T) {
 			t.Fatalf("unable to get payment by receipt: %v", err)
 		}
 
-		if !reflect.DeepEqual(payments, paymentsAfter) {
+		if !reflect.DeepEqual(payments, paymentsBefore) {
 			t.Fatalf("wrong data")
 		}
 	}
@@ -102,7 +105,7 @@ func TestPaymentsStorage(t *testing.T) {
 			t.Fatalf("unable to list payments: %v", err)
 		}
 
-		if !reflect.DeepEqual(payments[0], paymentsAfter[1]) {
+		if !reflect.DeepEqual(payments[0], paymentsBefore[1]) {
 			t.Fatalf("wrong data")
 		}
 	}
@@ -113,7 +116,7 @@ func TestPaymentsStorage(t *testing.T) {
 			t.Fatalf("unable to list payments: %v", err)
 		}
 
-		if !reflect.DeepEqual(payments[0], paymentsAfter[1]) {
+		if !reflect.DeepEqual(payments[0], paymentsBefore[1]) {
 			t.Fatalf("wrong data")
 		}
 	}
@@ -124,7 +127,7 @@ func TestPaymentsStorage(t *testing.T) {
 			t.Fatalf("unable to list payments: %v", err)
 		}
 
-		if !reflect.DeepEqual(payments[0], paymentsAfter[0]) {
+		if !reflect.DeepEqual(payments[0], paymentsBefore[0]) {
 			t.Fatalf("wrong data")
 		}
 	}
@@ -135,8 +138,162 @@ func TestPaymentsStorage(t *testing.T) {
 			t.Fatalf("unable to list payments: %v", err)
 		}
 
-		if !reflect.DeepEqual(payments[0], paymentsAfter[1]) {
+		if !reflect.DeepEqual(payments[0], paymentsBefore[1]) {
 			t.Fatalf("wrong data")
 		}
 	}
 }
+
+func TestPaymentsStoragePagination(t *testing.T) {
+	db, clear, err := MakeTestDB()
+	if err != nil {
+		t.Fatalf("unable to create test database: %v", err)
+	}
+	defer clear()
+
+	store := PaymentsStore{db: db}
+
+	// Two payments have the same update time, to check that they are
+	// properly ordered by id.
+	updates := []int64{1, 2, 2, 3, 4}
+	for i, updatedAt := range updates {
+		payment := &connectors.Payment{
+			PaymentID: strconv.Itoa(i),
+			UpdatedAt: updatedAt,
+			Status:    connectors.Completed,
+			Direction: connectors.Incoming,
+			System:    connectors.External,
+			Asset:     connectors.BTC,
+			Media:     connectors.Blockchain,
+			Amount:    decimal.NewFromFloat(1.1),
+			MediaFee:  decimal.NewFromFloat(1.1),
+		}
+
+		if err := store.SavePayment(payment); err != nil {
+			t.Fatalf("unable to save payment: %v", err)
+		}
+	}
+
+	var ids []string
+	query := connectors.PaymentsQuery{
+		Asset: connectors.BTC,
+		Limit: 2,
+	}
+
+	for {
+		payments, cursor, err := store.ListPaymentsPage(query)
+		if err != nil {
+			t.Fatalf("unable to list payments: %v", err)
+		}
+
+		if len(payments) > query.Limit {
+			t.Fatalf("page exceeds the limit")
+		}
+
+		for _, payment := range payments {
+			ids = append(ids, payment.PaymentID)
+		}
+
+		if cursor == "" {
+			break
+		}
+
+		query.Cursor = cursor
+	}
+
+	if !reflect.DeepEqual(ids, []string{"4", "3", "2", "1", "0"}) {
+		t.Fatalf("wrong payments order: %v", ids)
+	}
+
+	payments, cursor, err := store.ListPaymentsPage(connectors.PaymentsQuery{
+		UpdatedAfter:  1,
+		UpdatedBefore: 4,
+	})
+	if err != nil {
+		t.Fatalf("unable to list payments: %v", err)
+	}
+
+	if len(payments) != 3 || cursor != "" {
+		t.Fatalf("wrong payments number: %v", len(payments))
+	}
+
+	_, _, err = store.ListPaymentsPage(connectors.PaymentsQuery{
+		Cursor: "invalid",
+	})
+	if err != connectors.ErrInvalidCursor {
+		t.Fatalf("invalid cursor error hasn't been returned")
+	}
+}
+
+func TestPaymentsStorageIdempotencyKey(t *testing.T) {
+	db, clear, err := MakeTestDB()
+	if err != nil {
+		t.Fatalf("unable to create test database: %v", err)
+	}
+	defer clear()
+
+	store := PaymentsStore{db: db}
+
+	payment := &connectors.Payment{
+		PaymentID:      "1",
+		UpdatedAt:      1,
+		Status:         connectors.Pending,
+		Direction:      connectors.Outgoing,
+		System:         connectors.External,
+		Receipt:        "receipt",
+		Asset:          connectors.BTC,
+		Media:          connectors.Blockchain,
+		Amount:         decimal.NewFromFloat(1.1),
+		MediaFee:       decimal.NewFromFloat(0.1),
+		IdempotencyKey: "key",
+	}
+
+	if err := store.SavePayment(payment); err != nil {
+		t.Fatalf("unable to save payment: %v", err)
+	}
+
+	// Connector sync doesn't know about idempotency key, and it shouldn't
+	// be erased.
+	synced := *payment
+	synced.Status = connectors.Completed
+	synced.IdempotencyKey = ""
+	if err := store.SavePayment(&synced); err != nil {
+		t.Fatalf("unable to save payment: %v", err)
+	}
+
+	p, err := store.PaymentByIdempotencyKey("key")
+	if err != nil {
+		t.Fatalf("unable to get payment by idempotency key: %v", err)
+	}
+
+	if p.PaymentID != "1" || p.Status != connectors.Completed {
+		t.Fatalf("wrong payment")
+	}
+
+	if _, err := store.PaymentByIdempotencyKey("unknown"); err !=
+		connectors.PaymentNotFound {
+		t.Fatalf("payment should be not found")
+	}
+
+	{
+		p, err := connectors.PaymentByIdempotencyKey(&store, "key",
+			connectors.BTC, connectors.Blockchain, "receipt",
+			decimal.NewFromFloat(1.1))
+		if err != nil {
+			t.Fatalf("unable to check idempotency key: %v", err)
+		}
+
+		if p == nil || p.PaymentID != "1" {
+			t.Fatalf("previous payment should be returned")
+		}
+	}
+
+	{
+		_, err := connectors.PaymentByIdempotencyKey(&store, "key",
+			connectors.BTC, connectors.Blockchain, "receipt",
+			decimal.NewFromFloat(2))
+		if err != connectors.ErrIdempotencyKeyReused {
+			t.Fatalf("idempotency key reuse should be detected")
+		}
+	}
+}
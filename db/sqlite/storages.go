@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"github.com/bitlum/connector/connectors"
+	"github.com/bitlum/connector/connectors/daemons/geth"
+)
+
+// Runtime check to ensure that DB implements connectors.StorageBackend
+// interface.
+var _ connectors.StorageBackend = (*DB)(nil)
+
+// ConnectorStateStorage returns storage which is used to keep the last
+// synced block hash of the connector of the given asset.
+//
+// NOTE: Part of the connectors.StorageBackend interface.
+func (db *DB) ConnectorStateStorage(asset connectors.Asset) connectors.StateStorage {
+	return NewConnectorStateStorage(asset, db)
+}
+
+// AttemptsStorage returns storage which is used to persist attempts of the
+// outgoing payments.
+//
+// NOTE: Part of the connectors.StorageBackend interface.
+func (db *DB) AttemptsStorage() connectors.AttemptsStorage {
+	return NewPaymentAttemptsStorage(db)
+}
+
+// ReplacementsStorage returns storage which is used to map the replacement
+// transactions to the payments.
+//
+// NOTE: Part of the connectors.StorageBackend interface.
+func (db *DB) ReplacementsStorage() connectors.ReplacementsStorage {
+	return NewTxReplacementsStorage(db)
+}
+
+// BatchesStorage returns storage which is used to map the outputs of the
+// batch transactions to the payments.
+//
+// NOTE: Part of the connectors.StorageBackend interface.
+func (db *DB) BatchesStorage() connectors.BatchesStorage {
+	return NewTxBatchesStorage(db)
+}
+
+// DerivationStorage returns storage which is used to keep the indexes of the
+// addresses derived from the extended public keys.
+//
+// NOTE: Part of the connectors.StorageBackend interface.
+func (db *DB) DerivationStorage() connectors.DerivationStorage {
+	return NewAddressDerivationStorage(db)
+}
+
+// GethAccountsStorage returns storage which is used by geth connector to
+// keep the accounts and their addresses.
+func (db *DB) GethAccountsStorage() geth.AccountsStorage {
+	return NewGethAccountsStorage(db)
+}
@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/jinzhu/gorm"
+)
+
+type BatchedOutput struct {
+	CreatedAt time.Time
+
+	TxID      string `gorm:"primary_key"`
+	Asset     string `gorm:"primary_key"`
+	Receipt   string `gorm:"primary_key"`
+	PaymentID string
+}
+
+// TxBatchesStorage is used to keep the index of the outputs of the batch
+// transactions.
+type TxBatchesStorage struct {
+	db *DB
+}
+
+func NewTxBatchesStorage(db *DB) *TxBatchesStorage {
+	return &TxBatchesStorage{
+		db: db,
+	}
+}
+
+// Runtime check to ensure that TxBatchesStorage implements
+// connectors.BatchesStorage interface.
+var _ connectors.BatchesStorage = (*TxBatchesStorage)(nil)
+
+// PutBatch saves the payments which have been sent by the batch
+// transaction, keyed by their receipts.
+//
+// NOTE: Part of the connectors.BatchesStorage interface.
+func (s *TxBatchesStorage) PutBatch(asset connectors.Asset, txID string,
+	payments map[string]string) error {
+
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	tx := s.db.Begin()
+	for receipt, paymentID := range payments {
+		err := tx.Save(&BatchedOutput{
+			TxID:      txID,
+			Asset:     string(asset),
+			Receipt:   receipt,
+			PaymentID: paymentID,
+		}).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// BatchedPaymentID returns id of the payment which has been sent to the
+// receipt by the batch transaction, empty string is returned if there is no
+// such payment.
+//
+// NOTE: Part of the connectors.BatchesStorage interface.
+func (s *TxBatchesStorage) BatchedPaymentID(asset connectors.Asset, txID,
+	receipt string) (string, error) {
+
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	output := &BatchedOutput{}
+	err := s.db.Where("tx_id = ? AND asset = ? AND receipt = ?", txID,
+		string(asset), receipt).Find(output).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return output.PaymentID, nil
+}
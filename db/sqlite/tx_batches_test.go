@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/bitlum/connector/connectors"
+)
+
+func TestTxBatchesStorage(t *testing.T) {
+	db, clear, err := MakeTestDB()
+	if err != nil {
+		t.Fatalf("unable to create test database: %v", err)
+	}
+	defer clear()
+
+	storage := NewTxBatchesStorage(db)
+
+	err = storage.PutBatch(connectors.BTC, "tx1", map[string]string{
+		"a1": "p1",
+		"a2": "p2",
+	})
+	if err != nil {
+		t.Fatalf("unable to put batch: %v", err)
+	}
+
+	for _, test := range []struct {
+		asset    connectors.Asset
+		txID     string
+		receipt  string
+		expected string
+	}{
+		{connectors.BTC, "tx1", "a1", "p1"},
+		{connectors.BTC, "tx1", "a2", "p2"},
+		{connectors.BTC, "tx1", "a3", ""},
+		{connectors.BTC, "tx2", "a1", ""},
+		{connectors.LTC, "tx1", "a1", ""},
+	} {
+		paymentID, err := storage.BatchedPaymentID(test.asset, test.txID,
+			test.receipt)
+		if err != nil {
+			t.Fatalf("unable to get payment of output(%v:%v): %v",
+				test.txID, test.receipt, err)
+		}
+
+		if paymentID != test.expected {
+			t.Fatalf("wrong payment of %v output(%v:%v), expected: %v, "+
+				"actual: %v", test.asset, test.txID, test.receipt,
+				test.expected, paymentID)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/jinzhu/gorm"
+)
+
+type TxReplacement struct {
+	CreatedAt time.Time
+
+	TxID      string `gorm:"primary_key"`
+	Asset     string `gorm:"primary_key"`
+	PaymentID string
+}
+
+// TxReplacementsStorage is used to keep the index of the transactions which
+// have replaced the original transactions of the payments.
+type TxReplacementsStorage struct {
+	db *DB
+}
+
+func NewTxReplacementsStorage(db *DB) *TxReplacementsStorage {
+	return &TxReplacementsStorage{
+		db: db,
+	}
+}
+
+// Runtime check to ensure that TxReplacementsStorage implements
+// connectors.ReplacementsStorage interface.
+var _ connectors.ReplacementsStorage = (*TxReplacementsStorage)(nil)
+
+// PutReplacement saves the payment to which replacement transaction
+// belongs.
+//
+// NOTE: Part of the connectors.ReplacementsStorage interface.
+func (s *TxReplacementsStorage) PutReplacement(asset connectors.Asset,
+	txID, paymentID string) error {
+
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	return s.db.Save(&TxReplacement{
+		TxID:      txID,
+		Asset:     string(asset),
+		PaymentID: paymentID,
+	}).Error
+}
+
+// ReplacedPaymentID returns id of the payment to which replacement
+// transaction belongs, empty string is returned if transaction isn't a
+// replacement one.
+//
+// NOTE: Part of the connectors.ReplacementsStorage interface.
+func (s *TxReplacementsStorage) ReplacedPaymentID(asset connectors.Asset,
+	txID string) (string, error) {
+
+	s.db.globalMutex.Lock()
+	defer s.db.globalMutex.Unlock()
+
+	replacement := &TxReplacement{}
+	err := s.db.Where("tx_id = ? AND asset = ?", txID, string(asset)).
+		Find(replacement).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return replacement.PaymentID, nil
+}
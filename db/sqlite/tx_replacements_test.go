@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/bitlum/connector/connectors"
+)
+
+func TestTxReplacementsStorage(t *testing.T) {
+	db, clear, err := MakeTestDB()
+	if err != nil {
+		t.Fatalf("unable to create test database: %v", err)
+	}
+	defer clear()
+
+	storage := NewTxReplacementsStorage(db)
+
+	if err := storage.PutReplacement(connectors.BTC, "tx2", "p1"); err != nil {
+		t.Fatalf("unable to put replacement: %v", err)
+	}
+
+	if err := storage.PutReplacement(connectors.BTC, "tx3", "p1"); err != nil {
+		t.Fatalf("unable to put replacement: %v", err)
+	}
+
+	for txID, expected := range map[string]string{
+		"tx1": "",
+		"tx2": "p1",
+		"tx3": "p1",
+	} {
+		paymentID, err := storage.ReplacedPaymentID(connectors.BTC, txID)
+		if err != nil {
+			t.Fatalf("unable to get payment of tx(%v): %v", txID, err)
+		}
+
+		if paymentID != expected {
+			t.Fatalf("wrong payment of tx(%v), expected: %v, actual: %v",
+				txID, expected, paymentID)
+		}
+	}
+
+	// Replacements of the other assets shouldn't be returned.
+	paymentID, err := storage.ReplacedPaymentID(connectors.LTC, "tx2")
+	if err != nil {
+		t.Fatalf("unable to get payment: %v", err)
+	}
+
+	if paymentID != "" {
+		t.Fatalf("replacement of other asset is returned: %v", paymentID)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/bitlum/connector/connectors/rpc"
 	"github.com/bitlum/connector/crpc"
 	"github.com/bitlum/connector/metrics"
+	"github.com/bitlum/connector/notifier"
 	"github.com/btcsuite/btclog"
 	"github.com/jrick/logrotate/rotator"
 )
@@ -50,12 +51,13 @@ var (
 	// It is written to by the Write method of the logWriter type.
 	logRotatorPipe *io.PipeWriter
 
-	metricsLog = backendLog.Logger("METRICS")
-	sqliteLog  = backendLog.Logger("SQLITE")
-	mainLog    = backendLog.Logger("MAIN")
-	crpcLog    = backendLog.Logger("CONNECTOR_RPC")
-	rpcLog     = backendLog.Logger("BLOCKCHAIN_RPC")
-	lndLog     = backendLog.Logger("LND")
+	metricsLog  = backendLog.Logger("METRICS")
+	sqliteLog   = backendLog.Logger("SQLITE")
+	mainLog     = backendLog.Logger("MAIN")
+	crpcLog     = backendLog.Logger("CONNECTOR_RPC")
+	rpcLog      = backendLog.Logger("BLOCKCHAIN_RPC")
+	lndLog      = backendLog.Logger("LND")
+	notifierLog = backendLog.Logger("NOTIFIER")
 )
 
 // Initialize package-global logger variables.
@@ -65,6 +67,7 @@ func init() {
 	rpc.UseLogger(rpcLog)
 	lnd.UseLogger(lndLog)
 	sqlite.UseLogger(sqliteLog)
+	notifier.UseLogger(notifierLog)
 }
 
 // subsystemLoggers maps each subsystem identifier to its associated logger.
@@ -75,6 +78,7 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"BLOCKCHAIN_RPC": rpcLog,
 	"SQLITE":         sqliteLog,
 	"CONNECTOR_RPC":  crpcLog,
+	"NOTIFIER":       notifierLog,
 }
 
 // initLogRotator initializes the logging rotator to write logs to logFile and
@@ -0,0 +1,48 @@
+package macaroons
+
+import (
+	"encoding/hex"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"gopkg.in/macaroon.v2"
+)
+
+// Credential wraps macaroon, so that it could be passed along with every
+// gRPC request made by the client.
+type Credential struct {
+	*macaroon.Macaroon
+}
+
+// A compile time check to ensure that Credential implements
+// credentials.PerRPCCredentials interface.
+var _ credentials.PerRPCCredentials = (*Credential)(nil)
+
+// NewCredential returns a copy of the passed macaroon wrapped in a
+// Credential struct.
+func NewCredential(m *macaroon.Macaroon) Credential {
+	return Credential{m.Clone()}
+}
+
+// RequireTransportSecurity implements the PerRPCCredentials interface.
+//
+// NOTE: TLS on payment server is optional, that is why macaroon is allowed
+// to be sent over unencrypted connection.
+func (c Credential) RequireTransportSecurity() bool {
+	return false
+}
+
+// GetRequestMetadata implements the PerRPCCredentials interface. It returns
+// the hex encoded macaroon, which is put in the request metadata.
+func (c Credential) GetRequestMetadata(ctx context.Context,
+	uri ...string) (map[string]string, error) {
+
+	data, err := c.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		metadataKey: hex.EncodeToString(data),
+	}, nil
+}
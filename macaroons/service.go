@@ -0,0 +1,262 @@
+package macaroons
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/macaroon.v2"
+)
+
+const (
+	// location is the location which is put in all macaroons baked by the
+	// service.
+	location = "payserver"
+
+	// rootKeyLen is the length of the root key with which macaroons are
+	// signed.
+	rootKeyLen = 32
+
+	// permissionsCaveat is the prefix of the first party caveat which
+	// restricts the set of permissions granted by the macaroon.
+	permissionsCaveat = "permissions"
+
+	// metadataKey is the key of the gRPC metadata, which is used by the
+	// client to pass the hex encoded macaroon.
+	metadataKey = "macaroon"
+)
+
+// Permission denotes the group of RPC methods which could be accessed by
+// the macaroon holder.
+type Permission string
+
+const (
+	// PermissionRead allows to call methods which doesn't change the state
+	// of the payment server, i.e. balances, payments listing, fee estimation.
+	PermissionRead Permission = "read"
+
+	// PermissionInvoice allows to create receipts, i.e. blockchain
+	// addresses and lightning network invoices.
+	PermissionInvoice Permission = "invoice"
+
+	// PermissionSend allows to send money from the payment server.
+	PermissionSend Permission = "send"
+
+	// PermissionBake allows to bake new macaroons.
+	PermissionBake Permission = "bake"
+)
+
+// AllPermissions is the list of all existing permissions, macaroon with
+// them is the admin macaroon.
+var AllPermissions = []Permission{
+	PermissionRead,
+	PermissionInvoice,
+	PermissionSend,
+	PermissionBake,
+}
+
+// ParsePermissions parses list of permissions, separated by comma.
+func ParsePermissions(str string) ([]Permission, error) {
+	var permissions []Permission
+	for _, s := range strings.Split(str, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		permission, err := parsePermission(s)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if len(permissions) == 0 {
+		return nil, errors.New("permissions are not specified")
+	}
+
+	return permissions, nil
+}
+
+func parsePermission(str string) (Permission, error) {
+	for _, permission := range AllPermissions {
+		if string(permission) == str {
+			return permission, nil
+		}
+	}
+
+	return "", errors.Errorf("unknown permission(%v)", str)
+}
+
+// RootKeyStorage is used to persist the root key with which all macaroons
+// are signed, so that macaroons would survive the restart of the service.
+type RootKeyStorage interface {
+	// RootKey returns root key, nil is returned if key hasn't been created
+	// yet.
+	RootKey() ([]byte, error)
+
+	// PutRootKey saves the root key.
+	PutRootKey(key []byte) error
+}
+
+// Service is used to bake new macaroons and to check that gRPC requests
+// are authorised by the valid macaroon with the required permissions.
+type Service struct {
+	rootKey []byte
+}
+
+// NewService creates new macaroon service, if root key doesn't exist yet it
+// is generated and saved in the storage.
+func NewService(storage RootKeyStorage) (*Service, error) {
+	rootKey, err := storage.RootKey()
+	if err != nil {
+		return nil, errors.Errorf("unable to fetch root key: %v", err)
+	}
+
+	if len(rootKey) == 0 {
+		rootKey = make([]byte, rootKeyLen)
+		if _, err := rand.Read(rootKey); err != nil {
+			return nil, errors.Errorf("unable to generate root key: %v", err)
+		}
+
+		if err := storage.PutRootKey(rootKey); err != nil {
+			return nil, errors.Errorf("unable to save root key: %v", err)
+		}
+	}
+
+	return &Service{
+		rootKey: rootKey,
+	}, nil
+}
+
+// BakeMacaroon creates new macaroon which grants the given permissions.
+func (s *Service) BakeMacaroon(permissions []Permission) (*macaroon.Macaroon,
+	error) {
+
+	if len(permissions) == 0 {
+		return nil, errors.New("permissions are not specified")
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, errors.Errorf("unable to generate macaroon id: %v", err)
+	}
+
+	m, err := macaroon.New(s.rootKey, id, location, macaroon.LatestVersion)
+	if err != nil {
+		return nil, errors.Errorf("unable to create macaroon: %v", err)
+	}
+
+	names := make([]string, len(permissions))
+	for i, permission := range permissions {
+		names[i] = string(permission)
+	}
+
+	caveat := permissionsCaveat + " " + strings.Join(names, ",")
+	if err := m.AddFirstPartyCaveat([]byte(caveat)); err != nil {
+		return nil, errors.Errorf("unable to add caveat: %v", err)
+	}
+
+	return m, nil
+}
+
+// ValidateMacaroon checks that macaroon has been baked by this service, and
+// that it grants the required permission. If macaroon has several
+// permissions caveats, required permission should be in every of them.
+func (s *Service) ValidateMacaroon(m *macaroon.Macaroon,
+	required Permission) error {
+
+	return m.Verify(s.rootKey, func(caveat string) error {
+		parts := strings.SplitN(caveat, " ", 2)
+		if len(parts) != 2 || parts[0] != permissionsCaveat {
+			return errors.Errorf("unknown caveat(%v)", caveat)
+		}
+
+		for _, name := range strings.Split(parts[1], ",") {
+			if name == string(required) {
+				return nil
+			}
+		}
+
+		return errors.Errorf("permission(%v) is not granted", required)
+	}, nil)
+}
+
+// UnaryServerInterceptor returns gRPC interceptor which checks that request
+// is authorised to call the unary method. Permissions map contains the
+// permission required by every method, methods which are not in the map
+// are forbidden.
+func (s *Service) UnaryServerInterceptor(
+	permissions map[string]Permission) grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{},
+		error) {
+
+		if err := s.validateRequest(ctx, info.FullMethod,
+			permissions); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns gRPC interceptor which checks that request
+// is authorised to call the stream method.
+func (s *Service) StreamServerInterceptor(
+	permissions map[string]Permission) grpc.StreamServerInterceptor {
+
+	return func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		if err := s.validateRequest(ss.Context(), info.FullMethod,
+			permissions); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// validateRequest extracts macaroon from the request metadata and checks
+// that it grants permission required by the method.
+func (s *Service) validateRequest(ctx context.Context, method string,
+	permissions map[string]Permission) error {
+
+	required, ok := permissions[method]
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "permission for "+
+			"method(%v) is not defined", method)
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md[metadataKey]) != 1 {
+		return status.Error(codes.Unauthenticated, "macaroon is missing")
+	}
+
+	data, err := hex.DecodeString(md[metadataKey][0])
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "unable to decode "+
+			"macaroon: %v", err)
+	}
+
+	m := &macaroon.Macaroon{}
+	if err := m.UnmarshalBinary(data); err != nil {
+		return status.Errorf(codes.Unauthenticated, "unable to unmarshal "+
+			"macaroon: %v", err)
+	}
+
+	if err := s.ValidateMacaroon(m, required); err != nil {
+		return status.Errorf(codes.PermissionDenied, "access denied: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package macaroons
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// memoryRootKeyStorage is a root key storage which keeps key in memory.
+type memoryRootKeyStorage struct {
+	key []byte
+}
+
+func (s *memoryRootKeyStorage) RootKey() ([]byte, error) {
+	return s.key, nil
+}
+
+func (s *memoryRootKeyStorage) PutRootKey(key []byte) error {
+	s.key = key
+	return nil
+}
+
+func TestValidateMacaroon(t *testing.T) {
+	storage := &memoryRootKeyStorage{}
+	service, err := NewService(storage)
+	if err != nil {
+		t.Fatalf("unable to create service: %v", err)
+	}
+
+	m, err := service.BakeMacaroon([]Permission{PermissionRead,
+		PermissionInvoice})
+	if err != nil {
+		t.Fatalf("unable to bake macaroon: %v", err)
+	}
+
+	if err := service.ValidateMacaroon(m, PermissionRead); err != nil {
+		t.Fatalf("read permission should be granted: %v", err)
+	}
+
+	if err := service.ValidateMacaroon(m, PermissionSend); err == nil {
+		t.Fatalf("send permission shouldn't be granted")
+	}
+
+	// Holder of macaroon should be able to restrict it further by adding
+	// the caveat.
+	restricted := m.Clone()
+	err = restricted.AddFirstPartyCaveat([]byte(permissionsCaveat + " read"))
+	if err != nil {
+		t.Fatalf("unable to add caveat: %v", err)
+	}
+
+	if err := service.ValidateMacaroon(restricted, PermissionInvoice); err == nil {
+		t.Fatalf("invoice permission shouldn't be granted")
+	}
+
+	// Macaroon should be valid after the restart of the service.
+	restarted, err := NewService(storage)
+	if err != nil {
+		t.Fatalf("unable to create service: %v", err)
+	}
+
+	if err := restarted.ValidateMacaroon(m, PermissionRead); err != nil {
+		t.Fatalf("read permission should be granted: %v", err)
+	}
+
+	// Macaroon signed by another root key should be rejected.
+	other, err := NewService(&memoryRootKeyStorage{})
+	if err != nil {
+		t.Fatalf("unable to create service: %v", err)
+	}
+
+	if err := other.ValidateMacaroon(m, PermissionRead); err == nil {
+		t.Fatalf("macaroon with wrong signature should be rejected")
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	service, err := NewService(&memoryRootKeyStorage{})
+	if err != nil {
+		t.Fatalf("unable to create service: %v", err)
+	}
+
+	m, err := service.BakeMacaroon([]Permission{PermissionRead})
+	if err != nil {
+		t.Fatalf("unable to bake macaroon: %v", err)
+	}
+
+	md, err := NewCredential(m).GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("unable to get metadata: %v", err)
+	}
+
+	interceptor := service.UnaryServerInterceptor(map[string]Permission{
+		"/crpc.PayServer/Balance":     PermissionRead,
+		"/crpc.PayServer/SendPayment": PermissionSend,
+	})
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+
+	call := func(ctx context.Context, method string) codes.Code {
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{
+			FullMethod: method,
+		}, handler)
+		return status.Code(err)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.New(md))
+
+	if code := call(ctx, "/crpc.PayServer/Balance"); code != codes.OK {
+		t.Fatalf("request should be authorised, got: %v", code)
+	}
+
+	if code := call(ctx, "/crpc.PayServer/SendPayment"); code != codes.PermissionDenied {
+		t.Fatalf("request should be denied, got: %v", code)
+	}
+
+	if code := call(ctx, "/crpc.PayServer/Unknown"); code != codes.PermissionDenied {
+		t.Fatalf("unknown method should be denied, got: %v", code)
+	}
+
+	code := call(context.Background(), "/crpc.PayServer/Balance")
+	if code != codes.Unauthenticated {
+		t.Fatalf("request without macaroon should be denied, got: %v", code)
+	}
+}
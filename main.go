@@ -7,21 +7,23 @@ import (
 	"runtime"
 
 	"net"
+	"net/http"
 	"sync"
 
 	"github.com/bitlum/connector/connectors"
-	bitcoind "github.com/bitlum/connector/connectors/daemons/bitcoind_simple"
-	"github.com/bitlum/connector/connectors/daemons/geth"
-	"github.com/bitlum/connector/connectors/daemons/lnd"
-	"github.com/bitlum/connector/connectors/rpc/bitcoin"
-	"github.com/bitlum/connector/connectors/rpc/bitcoincash"
-	"github.com/bitlum/connector/connectors/rpc/dash"
-	"github.com/bitlum/connector/connectors/rpc/litecoin"
+	// Connector packages are imported in order to register their
+	// factories.
+	_ "github.com/bitlum/connector/connectors/daemons/bitcoind"
+	_ "github.com/bitlum/connector/connectors/daemons/bitcoind_simple"
+	_ "github.com/bitlum/connector/connectors/daemons/geth"
+	_ "github.com/bitlum/connector/connectors/daemons/lnd"
 	rpc "github.com/bitlum/connector/crpc"
 	"github.com/bitlum/connector/db/sqlite"
+	"github.com/bitlum/connector/macaroons"
 	"github.com/bitlum/connector/metrics"
 	cryptoMetrics "github.com/bitlum/connector/metrics/crypto"
 	rpcMetrics "github.com/bitlum/connector/metrics/rpc"
+	"github.com/bitlum/connector/notifier"
 	"github.com/btcsuite/go-flags"
 	"github.com/go-errors/errors"
 	"google.golang.org/grpc"
@@ -63,197 +65,84 @@ func backendMain() error {
 	// daemon is shutting down.
 	quit := make(chan struct{}, 0)
 
-	blockchainConnectors := make(map[connectors.Asset]connectors.BlockchainConnector)
-	lightningConnectors := make(map[connectors.Asset]connectors.LightningConnector)
-
 	dbConn, err := sqlite.Open(loadedConfig.DataDir, "sqlite", true)
 	if err != nil {
 		return errors.Errorf("unable open sqlite db: %v", err)
 	}
 
-	bitcoinRPCClient, err := bitcoin.NewClient(bitcoin.ClientConfig{
-		Name:     "bitcoind",
-		Logger:   rpcLog,
-		Asset:    connectors.BTC,
-		RPCHost:  loadedConfig.Bitcoin.Host,
-		RPCPort:  loadedConfig.Bitcoin.Port,
-		User:     loadedConfig.Bitcoin.User,
-		Password: loadedConfig.Bitcoin.Password,
-	})
-	if err != nil {
-		return errors.Errorf("unable to create bitcoin rpc client: %v")
-	}
-
-	bitcoincashRPCClient, err := bitcoincash.NewClient(bitcoincash.ClientConfig{
-		Name:     "bitcoinabc",
-		Logger:   rpcLog,
-		Asset:    connectors.BCH,
-		RPCHost:  loadedConfig.BitcoinCash.Host,
-		RPCPort:  loadedConfig.BitcoinCash.Port,
-		User:     loadedConfig.BitcoinCash.User,
-		Password: loadedConfig.BitcoinCash.Password,
-	})
-	if err != nil {
-		return errors.Errorf("unable to create bitcoin cash rpc client: %v")
-	}
-
-	dashRPCClient, err := dash.NewClient(dash.ClientConfig{
-		Name:     "dashd",
-		Logger:   rpcLog,
-		Asset:    connectors.DASH,
-		RPCHost:  loadedConfig.Dash.Host,
-		RPCPort:  loadedConfig.Dash.Port,
-		User:     loadedConfig.Dash.User,
-		Password: loadedConfig.Dash.Password,
-	})
-	if err != nil {
-		return errors.Errorf("unable to create dash rpc client: %v")
-	}
-
-	litecoinRPCClient, err := litecoin.NewClient(litecoin.ClientConfig{
-		Name:     "litecoind",
-		Logger:   rpcLog,
-		Asset:    connectors.LTC,
-		RPCHost:  loadedConfig.Litecoin.Host,
-		RPCPort:  loadedConfig.Litecoin.Port,
-		User:     loadedConfig.Litecoin.User,
-		Password: loadedConfig.Litecoin.Password,
-	})
-	if err != nil {
-		return errors.Errorf("unable to create dash rpc client: %v")
-	}
-
-	// Create blockchain connectors in order to be able to listen for incoming
-	// transaction, be able to answer on the question how many
-	// pending transaction user have and also to withdraw money from exchange.
-	if !loadedConfig.BitcoinCash.Disabled {
-		blockchainConnectors[connectors.BCH], err = bitcoind.NewConnector(&bitcoind.Config{
-			Net:              loadedConfig.Network,
-			MinConfirmations: loadedConfig.BitcoinCash.MinConfirmations,
-			Asset:            connectors.BCH,
-			Logger:           mainLog,
-			Metrics:          cryptoMetricsBackend,
-			PaymentStore:     sqlite.NewPaymentStore(dbConn),
-			StateStore:       sqlite.NewBitcoinSimpleStateStorage(connectors.BCH, dbConn),
-			// TODO(andrew.shvv) Create subsystem to return current fee per unit
-			FeePerByte: loadedConfig.BitcoinCash.FeePerUnit,
-			RPCClient:  bitcoincashRPCClient,
+	var paymentsStore connectors.PaymentsStore = sqlite.NewPaymentStore(dbConn)
+
+	// If webhook urls are specified, payments are saved through the
+	// notifier, which posts every change of the payment status on them.
+	var paymentsNotifier *notifier.Notifier
+	if len(loadedConfig.Webhooks.URLs) != 0 {
+		paymentsNotifier, err = notifier.NewNotifier(&notifier.Config{
+			Store:          paymentsStore,
+			Storage:        sqlite.NewNotifierEventStorage(dbConn),
+			URLs:           loadedConfig.Webhooks.URLs,
+			Secret:         loadedConfig.Webhooks.Secret,
+			MaxAttempts:    loadedConfig.Webhooks.MaxAttempts,
+			InitialBackoff: loadedConfig.Webhooks.InitialBackoff,
+			MaxBackoff:     loadedConfig.Webhooks.MaxBackoff,
 		})
 		if err != nil {
-			return errors.Errorf("unable to create bitcoin cash connector: %v", err)
+			return errors.Errorf("unable to create notifier: %v", err)
 		}
-	}
 
-	if !loadedConfig.Bitcoin.Disabled {
-		blockchainConnectors[connectors.BTC], err = bitcoind.NewConnector(&bitcoind.Config{
-			Net:              loadedConfig.Network,
-			MinConfirmations: loadedConfig.Bitcoin.MinConfirmations,
-			Asset:            connectors.BTC,
-			Logger:           mainLog,
-			Metrics:          cryptoMetricsBackend,
-			PaymentStore:     sqlite.NewPaymentStore(dbConn),
-			StateStore:       sqlite.NewBitcoinSimpleStateStorage(connectors.BTC, dbConn),
-			// TODO(andrew.shvv) Create subsystem to return current fee per unit
-			FeePerByte: loadedConfig.BitcoinCash.FeePerUnit,
-			RPCClient:  bitcoinRPCClient,
-		})
-		if err != nil {
-			return errors.Errorf("unable to create bitcoin connector: %v", err)
+		if err := paymentsNotifier.Start(); err != nil {
+			return errors.Errorf("unable to start notifier: %v", err)
 		}
-	}
 
-	if !loadedConfig.Dash.Disabled {
-		blockchainConnectors[connectors.DASH], err = bitcoind.NewConnector(&bitcoind.Config{
-			Net:              loadedConfig.Network,
-			MinConfirmations: loadedConfig.Dash.MinConfirmations,
-			Asset:            connectors.DASH,
-			Logger:           mainLog,
-			Metrics:          cryptoMetricsBackend,
-			PaymentStore:     sqlite.NewPaymentStore(dbConn),
-			StateStore: sqlite.NewBitcoinSimpleStateStorage(connectors.
-				DASH, dbConn),
-			// TODO(andrew.shvv) Create subsystem to return current fee per unit
-			FeePerByte: loadedConfig.Dash.FeePerUnit,
-			RPCClient:  dashRPCClient,
-		})
-		if err != nil {
-			return errors.Errorf("unable to create dash connector: %v", err)
-		}
+		paymentsStore = paymentsNotifier
 	}
 
-	if !loadedConfig.Litecoin.Disabled {
-		blockchainConnectors[connectors.LTC], err = bitcoind.NewConnector(&bitcoind.Config{
-			Net:              loadedConfig.Network,
-			MinConfirmations: loadedConfig.Litecoin.MinConfirmations,
-			Asset:            connectors.LTC,
-			Logger:           mainLog,
-			Metrics:          cryptoMetricsBackend,
-			PaymentStore:     sqlite.NewPaymentStore(dbConn),
-			StateStore:       sqlite.NewBitcoinSimpleStateStorage(connectors.LTC, dbConn),
-			// TODO(andrew.shvv) Create subsystem to return current fee per unit
-			FeePerByte: loadedConfig.Litecoin.FeePerUnit,
-			RPCClient:  litecoinRPCClient,
-		})
-		if err != nil {
-			return errors.Errorf("unable to create litecoin connector: %v", err)
+	// All connectors are saving payments through the hub, so that rpc
+	// server could notify its clients about payments updates.
+	paymentsHub := connectors.NewPaymentsHub(paymentsStore)
+
+	// Create connector for every registered factory which daemon is enabled
+	// in config. Blockchain connectors are needed in order to be able to
+	// listen for incoming transaction, be able to answer on the question how
+	// many pending transaction user have and also to withdraw money from
+	// exchange.
+	registry := connectors.NewRegistry()
+	daemonConfigs := loadedConfig.daemonConfigs()
+	for _, key := range connectors.RegisteredFactories() {
+		daemonConfig, ok := daemonConfigs[key]
+		if !ok {
+			continue
 		}
-	}
 
-	if !loadedConfig.Ethereum.Disabled {
-		blockchainConnectors[connectors.ETH], err = geth.NewConnector(&geth.Config{
-			Net:                 loadedConfig.Network,
-			MinConfirmations:    loadedConfig.Ethereum.MinConfirmations,
-			SyncTickDelay:       loadedConfig.Ethereum.SyncDelay,
-			Asset:               connectors.ETH,
-			Logger:              mainLog,
-			Metrics:             cryptoMetricsBackend,
-			LastSyncedBlockHash: loadedConfig.Ethereum.ForceLastHash,
-			PaymentStorage:      sqlite.NewPaymentStore(dbConn),
-			StateStorage: sqlite.NewConnectorStateStorage(connectors.
-				ETH, dbConn),
-			AccountStorage: sqlite.NewGethAccountsStorage(dbConn),
-			DaemonCfg: &geth.DaemonConfig{
-				Name:       "geth",
-				ServerHost: loadedConfig.Ethereum.Host,
-				ServerPort: loadedConfig.Ethereum.Port,
-				Password:   loadedConfig.Ethereum.Password,
-			},
-		})
-		if err != nil {
-			return errors.Errorf("unable to create ethereum connector: %v", err)
-		}
-	}
-
-	if !loadedConfig.BitcoinLightning.Disabled {
-		lightningConnector, err := lnd.NewConnector(&lnd.Config{
-			PeerHost:     loadedConfig.BitcoinLightning.PeerHost,
-			PeerPort:     loadedConfig.BitcoinLightning.PeerPort,
+		_, err := registry.Create(key.Asset, key.Media, &connectors.FactoryConfig{
 			Net:          loadedConfig.Network,
-			Name:         "lnd",
-			Host:         loadedConfig.BitcoinLightning.Host,
-			Port:         loadedConfig.BitcoinLightning.Port,
-			TlsCertPath:  loadedConfig.BitcoinLightning.TlsCertPath,
-			MacaroonPath: loadedConfig.BitcoinLightning.MacaroonPath,
+			Asset:        key.Asset,
+			Daemon:       daemonConfig,
+			Logger:       mainLog,
+			RPCLogger:    rpcLog,
 			Metrics:      cryptoMetricsBackend,
-			PaymentStore: sqlite.NewPaymentStore(dbConn),
+			PaymentStore: paymentsHub,
+			Storage:      dbConn,
 		})
 		if err != nil {
-			return errors.Errorf("unable to create lightning bitcoin "+
-				"connector: %v", err)
+			return errors.Errorf("unable to create %v %v connector: %v",
+				key.Asset, key.Media, err)
 		}
+	}
 
+	for _, entry := range registry.Connectors() {
 		// Retry start connector until daemon will exit or connector start
 		// succeed. It is needed so that prometheus could scratch the fail
 		// start metric and send alert.
-		go func(c *lnd.Connector) {
+		go func(entry connectors.RegisteredConnector) {
 			for {
-				if err := c.Start(); err != nil {
-					mainLog.Errorf("unable to start BTC lightning "+
-						" connector: %v", err)
+				if err := entry.Connector.Start(); err != nil {
+					mainLog.Errorf("unable to start %v %v connector: %v",
+						entry.Asset, entry.Media, err)
 
 					select {
 					case <-time.After(5 * time.Second):
-						mainLog.Infof("Retrying start BTC lightning connector")
+						mainLog.Infof("Retrying start %v %v connector",
+							entry.Asset, entry.Media)
 						continue
 					case <-quit:
 						return
@@ -262,67 +151,7 @@ func backendMain() error {
 
 				return
 			}
-		}(lightningConnector)
-
-		defer func() {
-			if err := lightningConnector.Stop("stopped by user"); err != nil {
-				mainLog.Warn("unable to shutdown lightning bitcoin"+
-					" connector: %v", err)
-			}
-		}()
-
-		lightningConnectors[connectors.BTC] = lightningConnector
-	}
-
-	for asset, connector := range blockchainConnectors {
-		switch c := connector.(type) {
-		case *bitcoind.Connector:
-			// Retry start connector until daemon will exit or connector start
-			// succeed. It is needed so that prometheus could scratch the fail
-			// start metric and send alert.
-			go func(c *bitcoind.Connector, asset connectors.Asset) {
-				for {
-					if err := c.Start(); err != nil {
-						mainLog.Errorf("unable to start %v blockchain "+
-							"connector: %v", asset, err)
-
-						select {
-						case <-time.After(5 * time.Second):
-							mainLog.Infof("Retrying start %v connector", asset)
-							continue
-						case <-quit:
-							return
-						}
-					}
-
-					return
-				}
-			}(c, asset)
-
-		case *geth.Connector:
-			// Retry start connector until daemon will exit or connector start
-			// succeed. It is needed so that prometheus could scratch the fail
-			// start metric and send alert.
-			go func(c *geth.Connector, asset connectors.Asset) {
-				for {
-					if err := c.Start(); err != nil {
-						mainLog.Errorf("unable to start %v connector: %v",
-							asset, err)
-
-						select {
-						case <-time.After(5 * time.Second):
-							mainLog.Infof("Retrying start lightning ETH " +
-								"connector")
-							continue
-						case <-quit:
-							return
-						}
-					}
-
-					return
-				}
-			}(c, asset)
-		}
+		}(entry)
 	}
 
 	// Initialise the metric endpoint. This endpoint is used by the metric
@@ -331,16 +160,49 @@ func backendMain() error {
 		loadedConfig.Prometheus.Port)
 	metrics.StartServer(metricsEndpointAddr)
 
+	var (
+		opts              []grpc.ServerOption
+		unaryInterceptor  grpc.UnaryServerInterceptor
+		streamInterceptor grpc.StreamServerInterceptor
+	)
+
+	// If macaroons aren't disabled, every gRPC request should be authorised
+	// with the macaroon which grants permission required by the method.
+	var macaroonService *macaroons.Service
+	if !loadedConfig.NoMacaroons {
+		macaroonService, err = macaroons.NewService(
+			sqlite.NewMacaroonRootKeyStorage(dbConn))
+		if err != nil {
+			return errors.Errorf("unable to create macaroon service: %v", err)
+		}
+
+		if err := genMacaroons(macaroonService, loadedConfig.AdminMacaroonPath,
+			loadedConfig.ReadOnlyMacaroonPath,
+			loadedConfig.InvoiceMacaroonPath); err != nil {
+			return errors.Errorf("unable to generate macaroons: %v", err)
+		}
+
+		unaryInterceptor = macaroonService.UnaryServerInterceptor(
+			rpc.Permissions)
+		streamInterceptor = macaroonService.StreamServerInterceptor(
+			rpc.Permissions)
+
+		opts = append(opts,
+			grpc.UnaryInterceptor(unaryInterceptor),
+			grpc.StreamInterceptor(streamInterceptor),
+		)
+		mainLog.Info("Macaroon authentication enabled")
+	}
+
 	// Initialize RPC server to handle gRPC requests from trading bots and
 	// frontend users.
-	rpcServer, err := rpc.NewRPCServer(loadedConfig.Network, blockchainConnectors,
-		lightningConnectors, sqlite.NewPaymentStore(dbConn), rpcMetricsBackend)
+	rpcServer, err := rpc.NewRPCServer(loadedConfig.Network, registry,
+		paymentsHub, paymentsHub, macaroonService, paymentsNotifier,
+		rpcMetricsBackend)
 	if err != nil {
 		return errors.Errorf("unable to init RPC server: %v", err)
 	}
 
-	var opts []grpc.ServerOption
-
 	// If TLS files are exist than use it to encrypt gRPC endpoints
 	// communications.
 	if fileExists(loadedConfig.TLSCertPath) && fileExists(loadedConfig.TLSKeyPath) {
@@ -375,20 +237,61 @@ func backendMain() error {
 		mainLog.Info("stop serving gRPC")
 	}()
 
+	// If REST port is specified, spawn http server which exposes the same
+	// rpc methods with JSON encoding, for the services which are unable to
+	// use gRPC.
+	var restServer *http.Server
+	if loadedConfig.RESTPort != "" {
+		restAddr := net.JoinHostPort(loadedConfig.RESTHost,
+			loadedConfig.RESTPort)
+		restServer = &http.Server{
+			Addr: restAddr,
+			Handler: rpc.NewRESTGateway(rpcServer, unaryInterceptor,
+				streamInterceptor),
+		}
+
+		useTLS := fileExists(loadedConfig.TLSCertPath) &&
+			fileExists(loadedConfig.TLSKeyPath)
+
+		go func() {
+			mainLog.Infof("server REST on addr: '%v'", restAddr)
+
+			var err error
+			if useTLS {
+				err = restServer.ListenAndServeTLS(loadedConfig.TLSCertPath,
+					loadedConfig.TLSKeyPath)
+			} else {
+				err = restServer.ListenAndServe()
+			}
+
+			if err != nil && err != http.ErrServerClosed {
+				errChan <- errors.Errorf("unable to serve REST server: %v", err)
+				return
+			}
+			mainLog.Info("stop serving REST")
+		}()
+	}
+
 	var wg sync.WaitGroup
 
 	addInterruptHandler(shutdownChannel, func() {
 		grpcServer.Stop()
 
-		for _, c := range blockchainConnectors {
-			switch c := c.(type) {
-			case *bitcoind.Connector:
-				c.Stop("stopped by user")
-			case *geth.Connector:
-				c.Stop("stopped by user")
+		if restServer != nil {
+			restServer.Close()
+		}
+
+		for _, entry := range registry.Connectors() {
+			if err := entry.Connector.Stop("stopped by user"); err != nil {
+				mainLog.Warnf("unable to shutdown %v %v connector: %v",
+					entry.Asset, entry.Media, err)
 			}
 		}
 
+		if paymentsNotifier != nil {
+			paymentsNotifier.Stop("stopped by user")
+		}
+
 		close(quit)
 		wg.Wait()
 	})
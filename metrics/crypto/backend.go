@@ -28,6 +28,10 @@ const (
 	// daemonLabel is used to distinguish different daemon names,
 	// and quickly identify the problem if such occurs.
 	daemonLabel = "daemon"
+
+	// statusLabel is used to distinguish the status to which payment has
+	// been moved.
+	statusLabel = "status"
 )
 
 // MetricsBackend is a system which is responsible for receiving and storing
@@ -38,6 +42,8 @@ type MetricsBackend interface {
 	OverallFee(daemon, asset string, amount float64)
 	CurrentFunds(daemon, asset string, amount float64)
 	BlockNumber(daemon, asset string, blockNumber int64)
+	AddReorganisedPayment(daemon, asset, status string)
+	AddSweep(daemon, asset, status string, amount float64)
 
 	AddRequest(daemon, asset, request string)
 	AddError(daemon, asset, request, severity string)
@@ -56,6 +62,8 @@ func (b *MockBackend) OverallReceived(daemon, asset string, amount float64)
 func (b *MockBackend) OverallFee(daemon, asset string, amount float64)                     {}
 func (b *MockBackend) CurrentFunds(daemon, asset string, amount float64)                   {}
 func (b *MockBackend) BlockNumber(daemon, asset string, blockNumber int64)                 {}
+func (b *MockBackend) AddReorganisedPayment(daemon, asset, status string)                  {}
+func (b *MockBackend) AddSweep(daemon, asset, status string, amount float64)               {}
 func (b *MockBackend) AddRequest(daemon, asset, request string)                            {}
 func (b *MockBackend) AddError(daemon, asset, request, severity string)                    {}
 func (b *MockBackend) AddPanic(daemon, asset, request string)                              {}
@@ -80,6 +88,9 @@ type PrometheusBackend struct {
 	overallReceivedFunds   *prometheus.GaugeVec
 	overallFeeFunds        *prometheus.GaugeVec
 	blockNumber            *prometheus.GaugeVec
+	reorganisedPayments    *prometheus.CounterVec
+	sweepsTotal            *prometheus.CounterVec
+	sweptFunds             *prometheus.CounterVec
 }
 
 // CurrentFunds sets the number of funds available under control of system.
@@ -148,6 +159,46 @@ func (m PrometheusBackend) BlockNumber(daemon, asset string, blockNumber int64)
 	).Set(float64(blockNumber))
 }
 
+// AddReorganisedPayment increases counter of the completed payments which
+// have been moved back to the given status because of the chain
+// reorganisation. Every increase should be treated as an alert, because
+// funds of the payment might be already credited.
+//
+// NOTE: Non-pointer receiver made by intent to avoid conflict in the system
+// with parallel metrics report.
+func (m PrometheusBackend) AddReorganisedPayment(daemon, asset, status string) {
+	m.reorganisedPayments.With(
+		prometheus.Labels{
+			statusLabel: status,
+			assetLabel:  asset,
+			daemonLabel: daemon,
+		},
+	).Add(1)
+}
+
+// AddSweep increases counter of the sweeps of the hot wallet excess to the
+// cold storage with the given status, and the counter of the swept funds.
+//
+// NOTE: Non-pointer receiver made by intent to avoid conflict in the system
+// with parallel metrics report.
+func (m PrometheusBackend) AddSweep(daemon, asset, status string,
+	amount float64) {
+	m.sweepsTotal.With(
+		prometheus.Labels{
+			statusLabel: status,
+			assetLabel:  asset,
+			daemonLabel: daemon,
+		},
+	).Add(1)
+
+	m.sweptFunds.With(
+		prometheus.Labels{
+			assetLabel:  asset,
+			daemonLabel: daemon,
+		},
+	).Add(amount)
+}
+
 // AddRequest increases request counter for the given request name.
 //
 // NOTE: Non-pointer receiver made by intent to avoid conflict in the system
@@ -422,5 +473,73 @@ func InitMetricsBackend(net string) (MetricsBackend, error) {
 				err.Error())
 	}
 
+	backend.reorganisedPayments = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: subsystem,
+			Name:      "reorganised_payments_total",
+			Help:      "Total completed payments reverted by chain reorganisation",
+			ConstLabels: prometheus.Labels{
+				metrics.NetLabel: net,
+			},
+		},
+		[]string{
+			statusLabel,
+			assetLabel,
+			daemonLabel,
+		},
+	)
+
+	if err := prometheus.Register(backend.reorganisedPayments); err != nil {
+		return backend, errors.Errorf(
+			"unable to register 'reorganisedPayments' metric: " +
+				err.Error())
+	}
+
+	backend.sweepsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: subsystem,
+			Name:      "sweeps_total",
+			Help:      "Total sweeps of the hot wallet excess to the cold storage",
+			ConstLabels: prometheus.Labels{
+				metrics.NetLabel: net,
+			},
+		},
+		[]string{
+			statusLabel,
+			assetLabel,
+			daemonLabel,
+		},
+	)
+
+	if err := prometheus.Register(backend.sweepsTotal); err != nil {
+		return backend, errors.Errorf(
+			"unable to register 'sweepsTotal' metric: " +
+				err.Error())
+	}
+
+	backend.sweptFunds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: subsystem,
+			Name:      "swept_funds_total",
+			Help:      "Number of funds swept from the hot wallet to the cold storage",
+			ConstLabels: prometheus.Labels{
+				metrics.NetLabel: net,
+			},
+		},
+		[]string{
+			assetLabel,
+			daemonLabel,
+		},
+	)
+
+	if err := prometheus.Register(backend.sweptFunds); err != nil {
+		return backend, errors.Errorf(
+			"unable to register 'sweptFunds' metric: " +
+				err.Error())
+	}
+
 	return backend, nil
 }
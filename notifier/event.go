@@ -0,0 +1,118 @@
+package notifier
+
+import (
+	"time"
+
+	"github.com/bitlum/connector/connectors"
+)
+
+// EventState denotes the stage of the event delivery.
+type EventState string
+
+var (
+	// EventPending means that event is waiting for the delivery, or its
+	// delivery is being retried.
+	EventPending EventState = "Pending"
+
+	// EventFailed means that all delivery attempts have failed, event is
+	// kept in the storage until it will be replayed.
+	EventFailed EventState = "Failed"
+)
+
+// Event is the notification about payment status change, which should be
+// delivered on the single url. Event is removed from the storage after
+// successful delivery.
+type Event struct {
+	// ID is the unique identificator of the event, it is also sent in the
+	// payload so that receiver could deduplicate events.
+	ID string
+
+	// URL is the webhook url on which event should be delivered.
+	URL string
+
+	// PaymentID is the id of the payment which status has been changed.
+	PaymentID string
+
+	// Payload is the JSON encoded body of the request.
+	Payload []byte
+
+	// State denotes the stage of the event delivery.
+	State EventState
+
+	// Attempts is the number of failed delivery attempts.
+	Attempts int
+
+	// NextAttempt is the time after which delivery should be retried.
+	NextAttempt time.Time
+
+	// LastError is the error of the last failed delivery attempt.
+	LastError string
+
+	// CreatedAt is the time when event has been created.
+	CreatedAt time.Time
+}
+
+// EventStorage is used to persist events, so that undelivered ones are
+// not lost on restart.
+type EventStorage interface {
+	// AddEvent saves new event or updates existing one.
+	AddEvent(event *Event) error
+
+	// RemoveEvent removes event from the storage.
+	RemoveEvent(id string) error
+
+	// EventsByState returns events with the given state ordered by the
+	// creation time.
+	EventsByState(state EventState) ([]*Event, error)
+}
+
+// PaymentEvent is the JSON payload of the webhook request.
+type PaymentEvent struct {
+	// EventID is the unique identificator of the event.
+	EventID string `json:"event_id"`
+
+	// CreatedAt is the time of the event creation, in milliseconds.
+	CreatedAt int64 `json:"created_at"`
+
+	// PreviousStatus is the status of payment before the change, it is
+	// empty if payment has been just created.
+	PreviousStatus string `json:"previous_status,omitempty"`
+
+	Payment *Payment `json:"payment"`
+}
+
+// Payment is the payment in the webhook payload.
+type Payment struct {
+	PaymentID      string `json:"payment_id"`
+	UpdatedAt      int64  `json:"updated_at"`
+	Status         string `json:"status"`
+	Direction      string `json:"direction"`
+	System         string `json:"system"`
+	Receipt        string `json:"receipt"`
+	Asset          string `json:"asset"`
+	Media          string `json:"media"`
+	Amount         string `json:"amount"`
+	MediaFee       string `json:"media_fee"`
+	MediaID        string `json:"media_id"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	FailureReason  string `json:"failure_reason,omitempty"`
+}
+
+// convertPayment converts payment to its webhook representation.
+func convertPayment(payment *connectors.Payment) *Payment {
+	return &Payment{
+		PaymentID:      payment.PaymentID,
+		UpdatedAt:      payment.UpdatedAt,
+		Status:         string(payment.Status),
+		Direction:      string(payment.Direction),
+		System:         string(payment.System),
+		Receipt:        payment.Receipt,
+		Asset:          string(payment.Asset),
+		Media:          string(payment.Media),
+		Amount:         payment.Amount.String(),
+		MediaFee:       payment.MediaFee.String(),
+		MediaID:        payment.MediaID,
+		IdempotencyKey: payment.IdempotencyKey,
+		FailureReason:  payment.FailureReason,
+	}
+}
@@ -0,0 +1,457 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/go-errors/errors"
+)
+
+const (
+	// SignatureHeader is the http header which contains hex encoded
+	// HMAC-SHA256 signature of the request body.
+	SignatureHeader = "X-Payserver-Signature"
+
+	// EventIDHeader is the http header which contains id of the event.
+	EventIDHeader = "X-Payserver-Event-Id"
+
+	// defaultMaxAttempts is the number of delivery attempts after which
+	// event is marked as failed.
+	defaultMaxAttempts = 10
+
+	// defaultInitialBackoff is the delay before the first retry, every
+	// next delay is twice bigger.
+	defaultInitialBackoff = 5 * time.Second
+
+	// defaultMaxBackoff is the maximum delay between retries.
+	defaultMaxBackoff = time.Hour
+
+	// defaultTimeout is the timeout of the webhook request.
+	defaultTimeout = 10 * time.Second
+
+	// pollInterval is the interval with which storage is checked for the
+	// events which should be retried.
+	pollInterval = time.Second
+)
+
+// ErrEventNotFound is returned when failed event which is requested to be
+// replayed doesn't exist.
+type ErrEventNotFound struct {
+	ID string
+}
+
+func (e *ErrEventNotFound) Error() string {
+	return fmt.Sprintf("failed event(%v) not found", e.ID)
+}
+
+// Config is a notifier config.
+type Config struct {
+	// Store is the payments store which is wrapped by the notifier.
+	Store connectors.PaymentsStore
+
+	// Storage is used to persist undelivered events.
+	Storage EventStorage
+
+	// URLs is the list of webhook urls on which events are delivered.
+	URLs []string
+
+	// Secret is used to sign the body of the webhook request with
+	// HMAC-SHA256.
+	Secret string
+
+	// MaxAttempts is the number of delivery attempts after which event is
+	// marked as failed. Default is used if it is zero.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry, every next delay
+	// is twice bigger. Default is used if it is zero.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between retries. Default is used if
+	// it is zero.
+	MaxBackoff time.Duration
+
+	// Client is the http client which is used to deliver events. Client
+	// with default timeout is used if it is nil.
+	Client *http.Client
+}
+
+func (c *Config) validate() error {
+	if c.Store == nil {
+		return errors.New("payments store should be specified")
+	}
+
+	if c.Storage == nil {
+		return errors.New("event storage should be specified")
+	}
+
+	if len(c.URLs) == 0 {
+		return errors.New("at least one url should be specified")
+	}
+
+	if c.Secret == "" {
+		return errors.New("secret should be specified")
+	}
+
+	if c.MaxAttempts < 0 {
+		return errors.New("max attempts shouldn't be negative")
+	}
+
+	return nil
+}
+
+// Notifier is a wrapper around payments store, which sends webhook
+// notification on every change of the payment status. Events are persisted
+// before the delivery, and they are retried with the exponential backoff,
+// so that they are not lost in case of receiver or payserver downtime.
+type Notifier struct {
+	connectors.PaymentsStore
+
+	started  int32
+	shutdown int32
+	wg       sync.WaitGroup
+	quit     chan struct{}
+
+	// wake is used to start delivery without waiting for the next poll.
+	wake chan struct{}
+
+	cfg *Config
+}
+
+// Runtime check to ensure that Notifier implements PaymentsStore
+// interface.
+var _ connectors.PaymentsStore = (*Notifier)(nil)
+
+// NewNotifier creates new notifier on top of the given payments store.
+func NewNotifier(cfg *Config) (*Notifier, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+
+	if cfg.InitialBackoff == 0 {
+		cfg.InitialBackoff = defaultInitialBackoff
+	}
+
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: defaultTimeout}
+	}
+
+	return &Notifier{
+		PaymentsStore: cfg.Store,
+		quit:          make(chan struct{}),
+		wake:          make(chan struct{}, 1),
+		cfg:           cfg,
+	}, nil
+}
+
+// Start spawns goroutine which delivers events, including the ones which
+// have been left undelivered since the previous run.
+func (n *Notifier) Start() error {
+	if !atomic.CompareAndSwapInt32(&n.started, 0, 1) {
+		log.Warn("notifier already started")
+		return nil
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			n.deliverPending()
+
+			select {
+			case <-ticker.C:
+			case <-n.wake:
+			case <-n.quit:
+				return
+			}
+		}
+	}()
+
+	log.Info("notifier started")
+	return nil
+}
+
+// Stop stops delivery of the events. Undelivered events are left in the
+// storage and are delivered on the next start.
+func (n *Notifier) Stop(reason string) error {
+	if !atomic.CompareAndSwapInt32(&n.shutdown, 0, 1) {
+		log.Warn("notifier already shutdown")
+		return nil
+	}
+
+	close(n.quit)
+	n.wg.Wait()
+
+	log.Infof("notifier shutdown, reason(%v)", reason)
+	return nil
+}
+
+// SavePayment saves payment in the underlying store and, if status of the
+// payment has been changed, creates event for every webhook url.
+//
+// NOTE: Part of the PaymentsStore interface.
+func (n *Notifier) SavePayment(payment *connectors.Payment) error {
+	// If previous version of the payment couldn't be fetched, payment is
+	// treated as the new one.
+	var previousStatus connectors.PaymentStatus
+	previous, err := n.PaymentsStore.PaymentByID(payment.PaymentID)
+	if err == nil {
+		previousStatus = previous.Status
+	}
+
+	if err := n.PaymentsStore.SavePayment(payment); err != nil {
+		return err
+	}
+
+	if previous != nil && previousStatus == payment.Status {
+		return nil
+	}
+
+	// Payment has been already saved, that is why error of the event
+	// creation is only logged, in order to not fail the connector.
+	if err := n.addEvents(payment, previousStatus); err != nil {
+		log.Errorf("unable to create events for payment(%v): %v",
+			payment.PaymentID, err)
+		return nil
+	}
+
+	n.notifyWake()
+	return nil
+}
+
+// FailedEvents returns events which haven't been delivered after all
+// attempts.
+func (n *Notifier) FailedEvents() ([]*Event, error) {
+	return n.cfg.Storage.EventsByState(EventFailed)
+}
+
+// Replay schedules failed events for the delivery. If no ids are given,
+// all failed events are replayed. Replayed events are returned.
+func (n *Notifier) Replay(ids ...string) ([]*Event, error) {
+	events, err := n.FailedEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := make(map[string]struct{})
+	for _, id := range ids {
+		filter[id] = struct{}{}
+	}
+
+	var replayed []*Event
+	for _, event := range events {
+		if len(ids) != 0 {
+			if _, ok := filter[event.ID]; !ok {
+				continue
+			}
+			delete(filter, event.ID)
+		}
+
+		replayed = append(replayed, event)
+	}
+
+	for id := range filter {
+		return nil, &ErrEventNotFound{ID: id}
+	}
+
+	for _, event := range replayed {
+		event.State = EventPending
+		event.Attempts = 0
+		event.NextAttempt = time.Now()
+
+		if err := n.cfg.Storage.AddEvent(event); err != nil {
+			return nil, err
+		}
+	}
+
+	n.notifyWake()
+	return replayed, nil
+}
+
+// addEvents creates and persists event for every webhook url.
+func (n *Notifier) addEvents(payment *connectors.Payment,
+	previousStatus connectors.PaymentStatus) error {
+
+	now := time.Now()
+	for _, url := range n.cfg.URLs {
+		id, err := genEventID()
+		if err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(&PaymentEvent{
+			EventID:        id,
+			CreatedAt:      connectors.ConvertTimeToMilliSeconds(now),
+			PreviousStatus: string(previousStatus),
+			Payment:        convertPayment(payment),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = n.cfg.Storage.AddEvent(&Event{
+			ID:          id,
+			URL:         url,
+			PaymentID:   payment.PaymentID,
+			Payload:     payload,
+			State:       EventPending,
+			NextAttempt: now,
+			CreatedAt:   now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliverPending delivers pending events which time has come.
+func (n *Notifier) deliverPending() {
+	events, err := n.cfg.Storage.EventsByState(EventPending)
+	if err != nil {
+		log.Errorf("unable to fetch pending events: %v", err)
+		return
+	}
+
+	for _, event := range events {
+		select {
+		case <-n.quit:
+			return
+		default:
+		}
+
+		if time.Now().Before(event.NextAttempt) {
+			continue
+		}
+
+		if err := n.deliver(event); err != nil {
+			n.handleFailure(event, err)
+			continue
+		}
+
+		log.Debugf("event(%v) of payment(%v) has been delivered to %v",
+			event.ID, event.PaymentID, event.URL)
+
+		if err := n.cfg.Storage.RemoveEvent(event.ID); err != nil {
+			log.Errorf("unable to remove delivered event(%v): %v",
+				event.ID, err)
+		}
+	}
+}
+
+// handleFailure schedules the next delivery attempt, or marks event as
+// failed if all attempts have been used.
+func (n *Notifier) handleFailure(event *Event, err error) {
+	event.Attempts++
+	event.LastError = err.Error()
+
+	if event.Attempts >= n.cfg.MaxAttempts {
+		event.State = EventFailed
+		log.Errorf("unable to deliver event(%v) of payment(%v) to %v "+
+			"after %v attempts: %v", event.ID, event.PaymentID, event.URL,
+			event.Attempts, err)
+	} else {
+		event.NextAttempt = time.Now().Add(n.backoff(event.Attempts))
+		log.Warnf("unable to deliver event(%v) of payment(%v) to %v, "+
+			"retry at %v: %v", event.ID, event.PaymentID, event.URL,
+			event.NextAttempt, err)
+	}
+
+	if err := n.cfg.Storage.AddEvent(event); err != nil {
+		log.Errorf("unable to update event(%v): %v", event.ID, err)
+	}
+}
+
+// backoff returns the delay before the next attempt, it is doubled on every
+// failed attempt.
+func (n *Notifier) backoff(attempts int) time.Duration {
+	delay := n.cfg.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= n.cfg.MaxBackoff {
+			return n.cfg.MaxBackoff
+		}
+	}
+
+	return delay
+}
+
+// deliver sends signed event on its url, any non 2xx response is treated
+// as failure.
+func (n *Notifier) deliver(event *Event) error {
+	req, err := http.NewRequest(http.MethodPost, event.URL,
+		bytes.NewReader(event.Payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, event.ID)
+	req.Header.Set(SignatureHeader, Sign(n.cfg.Secret, event.Payload))
+
+	resp, err := n.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Body is drained, so that connection could be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected response status: %v", resp.Status)
+	}
+
+	return nil
+}
+
+func (n *Notifier) notifyWake() {
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Sign returns hex encoded HMAC-SHA256 signature of the body. Receiver
+// should compute the same signature with the shared secret and compare it
+// with the one in the SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// genEventID generates random event id.
+func genEventID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
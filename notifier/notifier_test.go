@@ -0,0 +1,306 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitlum/connector/connectors"
+	"github.com/shopspring/decimal"
+)
+
+// memoryPaymentsStore implements only methods of payments store which are
+// used by notifier.
+type memoryPaymentsStore struct {
+	connectors.PaymentsStore
+
+	mtx      sync.Mutex
+	payments map[string]connectors.Payment
+}
+
+func (s *memoryPaymentsStore) PaymentByID(id string) (*connectors.Payment,
+	error) {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	payment, ok := s.payments[id]
+	if !ok {
+		return nil, connectors.PaymentNotFound
+	}
+
+	return &payment, nil
+}
+
+func (s *memoryPaymentsStore) SavePayment(payment *connectors.Payment) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.payments[payment.PaymentID] = *payment
+	return nil
+}
+
+// memoryEventStorage is an event storage which keeps events in memory.
+type memoryEventStorage struct {
+	mtx    sync.Mutex
+	events map[string]Event
+}
+
+func (s *memoryEventStorage) AddEvent(event *Event) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.events[event.ID] = *event
+	return nil
+}
+
+func (s *memoryEventStorage) RemoveEvent(id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.events, id)
+	return nil
+}
+
+func (s *memoryEventStorage) EventsByState(state EventState) ([]*Event,
+	error) {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var events []*Event
+	for _, event := range s.events {
+		if event.State == state {
+			e := event
+			events = append(events, &e)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+
+	return events, nil
+}
+
+// receiver is a webhook receiver which fails first requests.
+type receiver struct {
+	mtx      sync.Mutex
+	failures int
+	events   []*PaymentEvent
+	received chan struct{}
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	body, _ := ioutil.ReadAll(req.Body)
+	if req.Header.Get(SignatureHeader) != Sign("secret", body) {
+		http.Error(w, "wrong signature", http.StatusForbidden)
+		return
+	}
+
+	if r.failures > 0 {
+		r.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	event := &PaymentEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.events = append(r.events, event)
+	r.received <- struct{}{}
+}
+
+func newTestNotifier(t *testing.T, url string) (*Notifier,
+	*memoryEventStorage) {
+
+	storage := &memoryEventStorage{events: make(map[string]Event)}
+	n, err := NewNotifier(&Config{
+		Store: &memoryPaymentsStore{
+			payments: make(map[string]connectors.Payment),
+		},
+		Storage:        storage,
+		URLs:           []string{url},
+		Secret:         "secret",
+		MaxAttempts:    2,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unable to create notifier: %v", err)
+	}
+
+	return n, storage
+}
+
+func waitReceived(t *testing.T, r *receiver) {
+	select {
+	case <-r.received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event hasn't been received")
+	}
+}
+
+func TestNotifierDelivery(t *testing.T) {
+	r := &receiver{failures: 1, received: make(chan struct{}, 10)}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	n, storage := newTestNotifier(t, server.URL)
+	if err := n.Start(); err != nil {
+		t.Fatalf("unable to start notifier: %v", err)
+	}
+	defer n.Stop("test")
+
+	payment := &connectors.Payment{
+		PaymentID: "1",
+		Status:    connectors.Pending,
+		Amount:    decimal.NewFromFloat(1),
+	}
+
+	if err := n.SavePayment(payment); err != nil {
+		t.Fatalf("unable to save payment: %v", err)
+	}
+
+	// Event should be delivered after the failed attempt.
+	waitReceived(t, r)
+
+	// Update without status change shouldn't trigger event.
+	payment.MediaID = "tx"
+	if err := n.SavePayment(payment); err != nil {
+		t.Fatalf("unable to save payment: %v", err)
+	}
+
+	payment.Status = connectors.Completed
+	if err := n.SavePayment(payment); err != nil {
+		t.Fatalf("unable to save payment: %v", err)
+	}
+
+	waitReceived(t, r)
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.events) != 2 {
+		t.Fatalf("wrong number of events: %v", len(r.events))
+	}
+
+	if r.events[0].PreviousStatus != "" ||
+		r.events[0].Payment.Status != string(connectors.Pending) {
+		t.Fatalf("wrong first event: %v", r.events[0])
+	}
+
+	if r.events[1].PreviousStatus != string(connectors.Pending) ||
+		r.events[1].Payment.Status != string(connectors.Completed) ||
+		r.events[1].Payment.MediaID != "tx" {
+		t.Fatalf("wrong second event: %v", r.events[1])
+	}
+
+	// Event is removed after the response has been received, that is why
+	// it might be still in the storage.
+	for i := 0; ; i++ {
+		events, _ := storage.EventsByState(EventPending)
+		if len(events) == 0 {
+			break
+		}
+
+		if i == 100 {
+			t.Fatalf("delivered events should be removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNotifierReplay(t *testing.T) {
+	r := &receiver{failures: 2, received: make(chan struct{}, 10)}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	n, _ := newTestNotifier(t, server.URL)
+	if err := n.Start(); err != nil {
+		t.Fatalf("unable to start notifier: %v", err)
+	}
+	defer n.Stop("test")
+
+	err := n.SavePayment(&connectors.Payment{
+		PaymentID: "1",
+		Status:    connectors.Pending,
+	})
+	if err != nil {
+		t.Fatalf("unable to save payment: %v", err)
+	}
+
+	// Wait for all attempts to fail.
+	var failed []*Event
+	for i := 0; i < 100 && len(failed) == 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if failed, err = n.FailedEvents(); err != nil {
+			t.Fatalf("unable to get failed events: %v", err)
+		}
+	}
+
+	if len(failed) != 1 || failed[0].Attempts != 2 ||
+		failed[0].LastError == "" {
+		t.Fatalf("wrong failed events: %v", failed)
+	}
+
+	if _, err := n.Replay("unknown"); err == nil {
+		t.Fatalf("unknown event shouldn't be replayed")
+	}
+
+	replayed, err := n.Replay(failed[0].ID)
+	if err != nil {
+		t.Fatalf("unable to replay events: %v", err)
+	}
+
+	if len(replayed) != 1 {
+		t.Fatalf("wrong number of replayed events: %v", len(replayed))
+	}
+
+	waitReceived(t, r)
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.events) != 1 || r.events[0].EventID != failed[0].ID {
+		t.Fatalf("wrong events: %v", r.events)
+	}
+}
+
+func TestNotifierBackoff(t *testing.T) {
+	n := &Notifier{cfg: &Config{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	}}
+
+	expected := []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second,
+		5 * time.Second,
+	}
+
+	for i, delay := range expected {
+		if d := n.backoff(i + 1); d != delay {
+			t.Fatalf("wrong delay of attempt(%v): %v", i+1, d)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Signature is checked against value computed with openssl:
+	// echo -n "body" | openssl dgst -sha256 -hmac "secret"
+	expected := "dc46983557fea127b43af721467eb9b3fde2338fe3e14f51952aa8478c13d355"
+	if s := Sign("secret", []byte("body")); s != expected {
+		t.Fatalf("wrong signature: %v", s)
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"io/ioutil"
 	"os"
+
+	"github.com/bitlum/connector/macaroons"
 )
 
 // fileExists reports whether the named file or directory exists.
@@ -14,3 +17,50 @@ func fileExists(name string) bool {
 	}
 	return true
 }
+
+// genMacaroons writes the admin, read-only and invoice macaroons on disk,
+// if they don't exist yet.
+func genMacaroons(service *macaroons.Service, adminPath, readOnlyPath,
+	invoicePath string) error {
+
+	files := []struct {
+		path        string
+		permissions []macaroons.Permission
+	}{
+		{
+			path:        adminPath,
+			permissions: macaroons.AllPermissions,
+		},
+		{
+			path:        readOnlyPath,
+			permissions: []macaroons.Permission{macaroons.PermissionRead},
+		},
+		{
+			path: invoicePath,
+			permissions: []macaroons.Permission{macaroons.PermissionRead,
+				macaroons.PermissionInvoice},
+		},
+	}
+
+	for _, f := range files {
+		if fileExists(f.path) {
+			continue
+		}
+
+		m, err := service.BakeMacaroon(f.permissions)
+		if err != nil {
+			return err
+		}
+
+		data, err := m.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(f.path, data, 0600); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}